@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"banking-ledger/internal/domain"
 
@@ -11,25 +17,42 @@ import (
 
 // AccountHandler handles account-related HTTP requests
 type AccountHandler struct {
-	accountService domain.AccountService
+	accountService  domain.AccountService
+	snapshotService domain.BalanceSnapshotService
 }
 
 // NewAccountHandler creates a new account handler
-func NewAccountHandler(accountService domain.AccountService) *AccountHandler {
+func NewAccountHandler(accountService domain.AccountService, snapshotService domain.BalanceSnapshotService) *AccountHandler {
 	return &AccountHandler{
-		accountService: accountService,
+		accountService:  accountService,
+		snapshotService: snapshotService,
 	}
 }
 
 // CreateAccountRequest represents the request body for creating an account
 type CreateAccountRequest struct {
-	UserID         string  `json:"user_id" validate:"required"`
-	InitialBalance float64 `json:"initial_balance" validate:"min=0"`
-	Currency       string  `json:"currency" validate:"required,len=3"`
+	UserID         string         `json:"user_id" validate:"required"`
+	InitialBalance domain.Decimal `json:"initial_balance"`
+	Currency       string         `json:"currency" validate:"required,len=3"`
+	// Type defaults to checking
+	Type     domain.AccountType   `json:"type"`
+	Nickname string               `json:"nickname" validate:"max=100"`
+	Labels   domain.AccountLabels `json:"labels"`
 }
 
-// CreateAccount creates a new account
+// CreateAccount creates a new checking or savings account
 func (h *AccountHandler) CreateAccount(c echo.Context) error {
+	return h.createAccount(c, false)
+}
+
+// CreateInternalAccount creates an account of any type, including the fee
+// collection and settlement accounts that belong to the bank
+func (h *AccountHandler) CreateInternalAccount(c echo.Context) error {
+	return h.createAccount(c, true)
+}
+
+// createAccount creates an account, allowing internal types only if asked
+func (h *AccountHandler) createAccount(c echo.Context, allowInternal bool) error {
 	var req CreateAccountRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -43,34 +66,144 @@ func (h *AccountHandler) CreateAccount(c echo.Context) error {
 		})
 	}
 
+	if req.Type.IsInternal() && !allowInternal {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": fmt.Sprintf("Accounts of type %s can only be created through the admin API", req.Type),
+		})
+	}
+
+	initialBalance, err := req.InitialBalance.Money(req.Currency)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": amountError(err),
+		})
+	}
+
 	account, err := h.accountService.CreateAccount(
 		c.Request().Context(),
 		req.UserID,
-		req.InitialBalance,
+		initialBalance,
 		req.Currency,
+		req.Type,
+		domain.AccountDetails{Nickname: req.Nickname, Labels: req.Labels},
 	)
 	if err != nil {
-		switch err {
-		case domain.ErrAccountExists:
-			return c.JSON(http.StatusConflict, map[string]string{
-				"error": "Account already exists",
-			})
-		case domain.ErrInvalidAmount:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid amount",
-			})
-		case domain.ErrMissingCurrency:
+		status, message := accountCreationError(err)
+		return c.JSON(status, map[string]string{
+			"error": message,
+		})
+	}
+
+	return c.JSON(http.StatusCreated, account)
+}
+
+// accountCreationError maps an error opening an account to its status and message
+func accountCreationError(err error) (int, string) {
+	var precisionErr *domain.PrecisionError
+	if errors.As(err, &precisionErr) {
+		return http.StatusBadRequest, amountError(err)
+	}
+
+	switch err {
+	case domain.ErrInvalidAccountType:
+		return http.StatusBadRequest, invalidAccountTypeError()
+	case domain.ErrInternalAccountType:
+		return http.StatusForbidden, "Accounts of this type can only be created through the admin API"
+	case domain.ErrAccountExists:
+		return http.StatusConflict, "Account already exists"
+	case domain.ErrInvalidAmount:
+		return http.StatusBadRequest, "Invalid amount"
+	case domain.ErrMissingUserID:
+		return http.StatusBadRequest, "User ID is required"
+	case domain.ErrMissingCurrency:
+		return http.StatusBadRequest, "Missing currency"
+	case domain.ErrUnsupportedCurrency:
+		return http.StatusBadRequest, "Unsupported currency; use an ISO 4217 code such as USD"
+	case domain.ErrNicknameTooLong, domain.ErrInvalidAccountLabels:
+		return http.StatusBadRequest, accountDetailsError(err)
+	default:
+		return http.StatusInternalServerError, "Internal server error"
+	}
+}
+
+// BulkCreateAccountsRequest represents the request body for opening many
+// accounts at once
+type BulkCreateAccountsRequest struct {
+	Accounts []CreateAccountRequest `json:"accounts"`
+	// DryRun validates the accounts without opening them, as does the
+	// dry_run=true query parameter
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// bulkAccountResult reports one account of a bulk creation
+type bulkAccountResult struct {
+	Index   int                      `json:"index"`
+	Status  domain.BulkAccountStatus `json:"status"`
+	Account *domain.Account          `json:"account,omitempty"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// BulkCreateAccounts opens many accounts at once for onboarding imports,
+// reporting on each by its index in the request. Duplicate and invalid
+// accounts are skipped without failing the others.
+func (h *AccountHandler) BulkCreateAccounts(c echo.Context) error {
+	var req BulkCreateAccountsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	dryRun := req.DryRun
+	if value := c.QueryParam("dry_run"); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			dryRun = parsed
+		}
+	}
+
+	requests := make([]*domain.NewAccount, len(req.Accounts))
+	for i, account := range req.Accounts {
+		requests[i] = &domain.NewAccount{
+			UserID:         account.UserID,
+			InitialBalance: account.InitialBalance,
+			Currency:       account.Currency,
+			Type:           account.Type,
+			AccountDetails: domain.AccountDetails{Nickname: account.Nickname, Labels: account.Labels},
+		}
+	}
+
+	results, err := h.accountService.CreateAccounts(c.Request().Context(), requests, dryRun)
+	if err != nil {
+		var bulkErr *domain.BulkAccountsError
+		if errors.As(err, &bulkErr) {
 			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Missing currency",
-			})
-		default:
-			return c.JSON(http.StatusInternalServerError, map[string]string{
-				"error": "Internal server error",
+				"error": fmt.Sprintf("Provide between 1 and %d accounts", bulkErr.Max),
 			})
 		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
 	}
 
-	return c.JSON(http.StatusCreated, account)
+	counts := make(map[domain.BulkAccountStatus]int)
+	response := make([]bulkAccountResult, len(results))
+	for i, result := range results {
+		counts[result.Status]++
+		response[i] = bulkAccountResult{
+			Index:   result.Index,
+			Status:  result.Status,
+			Account: result.Account,
+		}
+		if result.Err != nil {
+			_, response[i].Error = accountCreationError(result.Err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results": response,
+		"dry_run": dryRun,
+		"counts":  counts,
+	})
 }
 
 // GetAccount retrieves an account by ID
@@ -89,6 +222,8 @@ func (h *AccountHandler) GetAccount(c echo.Context) error {
 			return c.JSON(http.StatusNotFound, map[string]string{
 				"error": "Account not found",
 			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
 				"error": "Internal server error",
@@ -96,7 +231,20 @@ func (h *AccountHandler) GetAccount(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusOK, account)
+	return c.JSON(http.StatusOK, visibleAccounts(c, account)[0])
+}
+
+// visibleAccounts hides who changed the status of accounts, and why, from
+// callers other than admins
+func visibleAccounts(c echo.Context, accounts ...*domain.Account) []*domain.Account {
+	if domain.Unscoped(c.Request().Context()) {
+		return accounts
+	}
+	visible := make([]*domain.Account, len(accounts))
+	for i, account := range accounts {
+		visible[i] = account.WithoutStatusAudit()
+	}
+	return visible
 }
 
 // GetAccountsByUser retrieves accounts by user ID
@@ -108,15 +256,23 @@ func (h *AccountHandler) GetAccountsByUser(c echo.Context) error {
 		})
 	}
 
-	accounts, err := h.accountService.GetAccountsByUser(c.Request().Context(), userID)
+	accounts, err := h.accountService.GetAccountsByUser(c.Request().Context(), userID, domain.AccountType(c.QueryParam("type")), strings.TrimSpace(c.QueryParam("label")))
 	if err != nil {
+		if err == domain.ErrInvalidAccountType {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": invalidAccountTypeError(),
+			})
+		}
+		if err == domain.ErrForbidden {
+			return forbiddenError(c)
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Internal server error",
 		})
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"accounts": accounts,
+		"accounts": visibleAccounts(c, accounts...),
 		"count":    len(accounts),
 	})
 }
@@ -130,13 +286,35 @@ func (h *AccountHandler) GetAccountSummary(c echo.Context) error {
 		})
 	}
 
-	summary, err := h.accountService.GetAccountSummary(c.Request().Context(), id)
+	var from, to *time.Time
+	for param, bound := range map[string]**time.Time{"from_date": &from, "to_date": &to} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("%s must be an RFC 3339 time", param),
+			})
+		}
+		*bound = &parsed
+	}
+	if from != nil && to != nil && from.After(*to) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "from_date must not be after to_date",
+		})
+	}
+
+	summary, err := h.accountService.GetAccountSummary(c.Request().Context(), id, from, to)
 	if err != nil {
 		switch err {
 		case domain.ErrAccountNotFound:
 			return c.JSON(http.StatusNotFound, map[string]string{
 				"error": "Account not found",
 			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
 				"error": "Internal server error",
@@ -144,11 +322,13 @@ func (h *AccountHandler) GetAccountSummary(c echo.Context) error {
 		}
 	}
 
+	summary.Account = visibleAccounts(c, summary.Account)[0]
 	return c.JSON(http.StatusOK, summary)
 }
 
-// ListAccounts retrieves accounts with pagination
-func (h *AccountHandler) ListAccounts(c echo.Context) error {
+// GetAccountEvents retrieves the changes to an account's status and limits,
+// newest first
+func (h *AccountHandler) GetAccountEvents(c echo.Context) error {
 	limit := 10
 	offset := 0
 
@@ -164,7 +344,179 @@ func (h *AccountHandler) ListAccounts(c echo.Context) error {
 		}
 	}
 
-	accounts, err := h.accountService.ListAccounts(c.Request().Context(), limit, offset)
+	events, err := h.accountService.ListAccountEvents(c.Request().Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	visible := make([]*domain.AccountEvent, len(events))
+	for i, event := range events {
+		if domain.Unscoped(c.Request().Context()) {
+			visible[i] = event
+		} else {
+			visible[i] = event.WithoutAudit()
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"events": visible,
+		"count":  len(visible),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GetUserSummary retrieves a user's accounts grouped by currency with their
+// recent activity
+func (h *AccountHandler) GetUserSummary(c echo.Context) error {
+	summary, err := h.accountService.GetUserSummary(c.Request().Context(), c.Param("user_id"))
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User has no accounts",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	for _, currency := range summary.Currencies {
+		currency.Accounts = visibleAccounts(c, currency.Accounts...)
+	}
+	return c.JSON(http.StatusOK, summary)
+}
+
+// ListAccounts retrieves accounts with pagination, filtered by type, status,
+// currency, user ID prefix, label and creation time
+func (h *AccountHandler) ListAccounts(c echo.Context) error {
+	filter := &domain.AccountFilter{
+		Type:         domain.AccountType(c.QueryParam("type")),
+		Status:       domain.AccountStatus(c.QueryParam("status")),
+		Currency:     strings.TrimSpace(c.QueryParam("currency")),
+		UserIDPrefix: c.QueryParam("user_id_prefix"),
+		Label:        strings.TrimSpace(c.QueryParam("label")),
+		Limit:        10,
+	}
+
+	if l := c.QueryParam("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			filter.Limit = parsed
+		}
+	}
+
+	if o := c.QueryParam("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			filter.Offset = parsed
+		}
+	}
+
+	if include := c.QueryParam("include_closed"); include != "" {
+		if parsed, err := strconv.ParseBool(include); err == nil {
+			filter.IncludeClosed = parsed
+		}
+	}
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": invalidAccountStatusError(),
+		})
+	}
+
+	for param, bound := range map[string]**time.Time{"created_from": &filter.CreatedFrom, "created_to": &filter.CreatedTo} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseCreatedBound(value, param == "created_to")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("%s must be an RFC 3339 time or a YYYY-MM-DD date", param),
+			})
+		}
+		*bound = &parsed
+	}
+
+	includeTotal := true
+	if include := c.QueryParam("include_total"); include != "" {
+		if parsed, err := strconv.ParseBool(include); err == nil {
+			includeTotal = parsed
+		}
+	}
+
+	page, err := h.accountService.ListAccounts(c.Request().Context(), filter, includeTotal)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidAccountType:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": invalidAccountTypeError(),
+			})
+		case domain.ErrInvalidInput:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "created_from must not be after created_to",
+			})
+		case domain.ErrUnsupportedCurrency:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Unsupported currency; use an ISO 4217 code such as USD",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	response := map[string]interface{}{
+		"accounts": visibleAccounts(c, page.Accounts...),
+		"count":    len(page.Accounts),
+		"limit":    filter.Limit,
+		"offset":   filter.Offset,
+		"has_more": page.HasMore,
+		"filters":  filter,
+	}
+	if page.Total != nil {
+		response["total"] = *page.Total
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListUsers lists the users holding accounts for the admin console, in user
+// ID order, with their account counts, balances by currency and account
+// statuses
+func (h *AccountHandler) ListUsers(c echo.Context) error {
+	filter := &domain.UserFilter{
+		UserIDPrefix: c.QueryParam("user_id_prefix"),
+		Limit:        10,
+	}
+
+	if l := c.QueryParam("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			filter.Limit = parsed
+		}
+	}
+
+	if o := c.QueryParam("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			filter.Offset = parsed
+		}
+	}
+
+	page, err := h.accountService.ListUsers(c.Request().Context(), filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Internal server error",
@@ -172,14 +524,34 @@ func (h *AccountHandler) ListAccounts(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"accounts": accounts,
-		"count":    len(accounts),
-		"limit":    limit,
-		"offset":   offset,
+		"users":          page.Users,
+		"count":          len(page.Users),
+		"limit":          filter.Limit,
+		"offset":         filter.Offset,
+		"has_more":       page.HasMore,
+		"user_id_prefix": filter.UserIDPrefix,
 	})
 }
 
-// DeactivateAccount deactivates an account
+// parseCreatedBound reads a creation time bound given as an RFC 3339 time or
+// a date. A date starts at its UTC midnight, or as an upper bound runs to its
+// end.
+func parseCreatedBound(value string, upper bool) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	day, err := time.Parse(domain.StatementDateLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// DeactivateAccount deactivates an account. With force=true it does so even
+// if the account has outgoing transactions still to be processed.
 func (h *AccountHandler) DeactivateAccount(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
@@ -188,13 +560,42 @@ func (h *AccountHandler) DeactivateAccount(c echo.Context) error {
 		})
 	}
 
-	err := h.accountService.DeactivateAccount(c.Request().Context(), id)
+	var force bool
+	if value := c.QueryParam("force"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "force must be true or false",
+			})
+		}
+		force = parsed
+	}
+
+	err := h.accountService.DeactivateAccount(c.Request().Context(), id, force)
 	if err != nil {
 		switch err {
 		case domain.ErrAccountNotFound:
 			return c.JSON(http.StatusNotFound, map[string]string{
 				"error": "Account not found",
 			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrInvalidStatusTransition:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account cannot be deactivated from its current status",
+			})
+		case domain.ErrPendingDebits:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account has outgoing transactions still to be processed; use force=true to deactivate it anyway",
+			})
+		case domain.ErrStatusReasonRequired:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Accounts are frozen and unfrozen only through /freeze and /unfreeze, with a reason",
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account was modified concurrently, please retry",
+			})
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
 				"error": "Internal server error",
@@ -207,8 +608,8 @@ func (h *AccountHandler) DeactivateAccount(c echo.Context) error {
 	})
 }
 
-// GetAccountBalance retrieves the current balance of an account
-func (h *AccountHandler) GetAccountBalance(c echo.Context) error {
+// ActivateAccount returns an inactive account to active
+func (h *AccountHandler) ActivateAccount(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -216,13 +617,31 @@ func (h *AccountHandler) GetAccountBalance(c echo.Context) error {
 		})
 	}
 
-	account, err := h.accountService.GetAccount(c.Request().Context(), id)
+	account, err := h.accountService.ActivateAccount(c.Request().Context(), id)
 	if err != nil {
 		switch err {
 		case domain.ErrAccountNotFound:
 			return c.JSON(http.StatusNotFound, map[string]string{
 				"error": "Account not found",
 			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrAccountAlreadyActive:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account is already active",
+			})
+		case domain.ErrInvalidStatusTransition:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account cannot be activated from its current status",
+			})
+		case domain.ErrStatusReasonRequired:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Accounts are frozen and unfrozen only through /freeze and /unfreeze, with a reason",
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account was modified concurrently, please retry",
+			})
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
 				"error": "Internal server error",
@@ -230,11 +649,572 @@ func (h *AccountHandler) GetAccountBalance(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"account_id": account.ID,
-		"balance":    account.Balance,
-		"currency":   account.Currency,
-		"status":     account.Status,
-		"updated_at": account.UpdatedAt,
-	})
+	return c.JSON(http.StatusOK, account)
+}
+
+// CloseAccount closes an account for good once its balance is zero and no
+// transactions to or from it are awaiting processing
+func (h *AccountHandler) CloseAccount(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account ID is required",
+		})
+	}
+
+	account, err := h.accountService.CloseAccount(c.Request().Context(), id)
+	if err != nil {
+		var residualErr *domain.ResidualBalanceError
+		if errors.As(err, &residualErr) {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error":     "Account balance must be zero to close it",
+				"remaining": residualErr.Balance.Format(residualErr.Currency),
+				"currency":  residualErr.Currency,
+			})
+		}
+
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrAccountClosed:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account is already closed",
+			})
+		case domain.ErrPendingTransactions:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account has transactions still to be processed",
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account was modified concurrently, please retry",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// AccountStatusReasonRequest represents the request body for freezing or
+// unfreezing an account
+type AccountStatusReasonRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// FreezeAccount blocks debits from an account while still accepting credits
+func (h *AccountHandler) FreezeAccount(c echo.Context) error {
+	return h.changeFrozen(c, h.accountService.FreezeAccount)
+}
+
+// UnfreezeAccount returns a frozen account to active
+func (h *AccountHandler) UnfreezeAccount(c echo.Context) error {
+	return h.changeFrozen(c, h.accountService.UnfreezeAccount)
+}
+
+// changeFrozen freezes or unfreezes an account for the reason in the body
+func (h *AccountHandler) changeFrozen(c echo.Context, change func(ctx context.Context, id, reason string) (*domain.Account, error)) error {
+	var req AccountStatusReasonRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	account, err := change(c.Request().Context(), c.Param("id"), req.Reason)
+	if err != nil {
+		switch err {
+		case domain.ErrStatusReasonRequired:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "A reason is required",
+			})
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrInvalidStatusTransition:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account cannot be frozen or unfrozen from its current status",
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account was modified concurrently, please retry",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// UpdateAccountStatusRequest represents the request body for changing an account's status
+type UpdateAccountStatusRequest struct {
+	Status domain.AccountStatus `json:"status" validate:"required"`
+}
+
+// UpdateAccountStatus moves an account to a new status
+func (h *AccountHandler) UpdateAccountStatus(c echo.Context) error {
+	var req UpdateAccountStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	account, err := h.accountService.UpdateAccountStatus(c.Request().Context(), c.Param("id"), req.Status)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidInput:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid account status",
+			})
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrInvalidStatusTransition:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": fmt.Sprintf("Account cannot move to status %s from its current status", req.Status),
+			})
+		case domain.ErrStatusReasonRequired:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Accounts are frozen and unfrozen only through /freeze and /unfreeze, with a reason",
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account was modified concurrently, please retry",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// UpdateOverdraftLimitRequest represents the request body for setting an account's overdraft limit
+type UpdateOverdraftLimitRequest struct {
+	OverdraftLimit domain.Decimal `json:"overdraft_limit" validate:"required"`
+}
+
+// UpdateOverdraftLimit sets how far below zero an account's balance may go
+func (h *AccountHandler) UpdateOverdraftLimit(c echo.Context) error {
+	var req UpdateOverdraftLimitRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	account, err := h.accountService.UpdateOverdraftLimit(c.Request().Context(), c.Param("id"), req.OverdraftLimit)
+	if err != nil {
+		if err == domain.ErrInvalidAmount || errors.Is(err, domain.ErrInvalidPrecision) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": amountError(err),
+			})
+		}
+
+		switch err {
+		case domain.ErrInvalidOverdraft:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Overdraft limit must not be negative",
+			})
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account was modified concurrently, please retry",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// UpdateMinimumBalanceRequest represents the request body for setting an account's minimum balance
+type UpdateMinimumBalanceRequest struct {
+	MinimumBalance domain.Decimal `json:"minimum_balance" validate:"required"`
+}
+
+// UpdateMinimumBalance sets the balance an account's debits may not take it below
+func (h *AccountHandler) UpdateMinimumBalance(c echo.Context) error {
+	var req UpdateMinimumBalanceRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	account, err := h.accountService.UpdateMinimumBalance(c.Request().Context(), c.Param("id"), req.MinimumBalance)
+	if err != nil {
+		if err == domain.ErrInvalidAmount || errors.Is(err, domain.ErrInvalidPrecision) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": amountError(err),
+			})
+		}
+
+		switch err {
+		case domain.ErrInvalidMinimumBalance:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Minimum balance must not be negative",
+			})
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account was modified concurrently, please retry",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// nullableDecimal is a request field that tells an explicit null apart from
+// an omitted field
+type nullableDecimal struct {
+	Set   bool
+	Value *domain.Decimal
+}
+
+// UnmarshalJSON records that the field was given, reading null as no value
+func (d *nullableDecimal) UnmarshalJSON(data []byte) error {
+	d.Set = true
+	if string(data) == "null" {
+		d.Value = nil
+		return nil
+	}
+	var value domain.Decimal
+	if err := value.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	d.Value = &value
+	return nil
+}
+
+// nullableMetadata is a metadata request field that tells an explicit null
+// apart from an omitted field
+type nullableMetadata struct {
+	Set   bool
+	Value domain.AccountMetadata
+}
+
+// UnmarshalJSON records that the field was given, reading null as no labels
+func (m *nullableMetadata) UnmarshalJSON(data []byte) error {
+	m.Set = true
+	return json.Unmarshal(data, &m.Value)
+}
+
+// UpdateAccountRequest represents the request body for changing an account's
+// settings. Version is the account version the client last read. A null
+// low_balance_threshold removes it, an empty nickname clears it, and labels
+// and metadata replace the account's own. The immutable fields are only read so that a
+// body setting them can be refused.
+type UpdateAccountRequest struct {
+	Version             *int64                `json:"version"`
+	LowBalanceThreshold nullableDecimal       `json:"low_balance_threshold"`
+	Nickname            *string               `json:"nickname" validate:"omitempty,max=100"`
+	Labels              *domain.AccountLabels `json:"labels"`
+	Metadata            nullableMetadata      `json:"metadata"`
+
+	Balance  json.RawMessage `json:"balance"`
+	Currency json.RawMessage `json:"currency"`
+	UserID   json.RawMessage `json:"user_id"`
+}
+
+// accountDetailsError describes the bound a nickname, labels or metadata
+// broke
+func accountDetailsError(err error) string {
+	switch err {
+	case domain.ErrNicknameTooLong:
+		return fmt.Sprintf("Nickname may be at most %d characters", domain.MaxNicknameLength)
+	case domain.ErrInvalidAccountLabels:
+		return fmt.Sprintf("Labels must not be blank, at most %d characters each and at most %d in all",
+			domain.MaxAccountLabelLength, domain.MaxAccountLabels)
+	default:
+		return fmt.Sprintf("Metadata may have at most %d keys and %d bytes",
+			domain.MaxAccountMetadataKeys, domain.MaxAccountMetadataBytes)
+	}
+}
+
+// immutableFields lists the fields the request tried to set that an update
+// cannot change
+func (r *UpdateAccountRequest) immutableFields() map[string]string {
+	fields := make(map[string]string)
+	for name, value := range map[string]json.RawMessage{"balance": r.Balance, "currency": r.Currency, "user_id": r.UserID} {
+		if value != nil {
+			fields[name] = "cannot be changed"
+		}
+	}
+	return fields
+}
+
+// UpdateAccount changes an account's settings
+func (h *AccountHandler) UpdateAccount(c echo.Context) error {
+	var req UpdateAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if fields := req.immutableFields(); len(fields) > 0 {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":  "Request sets fields that cannot be changed",
+			"fields": fields,
+		})
+	}
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if req.Version == nil {
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":  "Version is required",
+			"fields": map[string]string{"version": "required"},
+		})
+	}
+
+	var labels domain.AccountLabels
+	if req.Labels != nil {
+		labels = *req.Labels
+	}
+	update := &domain.AccountUpdate{
+		Version:                  *req.Version,
+		LowBalanceThreshold:      req.LowBalanceThreshold.Value,
+		ClearLowBalanceThreshold: req.LowBalanceThreshold.Set && req.LowBalanceThreshold.Value == nil,
+		Nickname:                 req.Nickname,
+		Labels:                   labels,
+		ReplaceLabels:            req.Labels != nil,
+		Metadata:                 req.Metadata.Value,
+		ReplaceMetadata:          req.Metadata.Set,
+	}
+	account, err := h.accountService.UpdateAccount(c.Request().Context(), c.Param("id"), update)
+	if err != nil {
+		if err == domain.ErrInvalidAmount || errors.Is(err, domain.ErrInvalidPrecision) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": amountError(err),
+			})
+		}
+
+		switch err {
+		case domain.ErrInvalidAccountUpdate, domain.ErrNicknameTooLong, domain.ErrInvalidAccountLabels:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": accountDetailsError(err),
+			})
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account has changed since it was read, refetch it and retry with its current version",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, visibleAccounts(c, account)[0])
+}
+
+// UpdateLimitsRequest represents the request body for setting an account's
+// limits on outgoing money. An omitted limit is left as it is; zero removes it.
+type UpdateLimitsRequest struct {
+	MaxTransactionAmount *domain.Decimal `json:"max_transaction_amount"`
+	DailyOutgoing        *domain.Decimal `json:"daily_outgoing"`
+}
+
+// UpdateLimits sets an account's per-transaction and daily outgoing limits
+func (h *AccountHandler) UpdateLimits(c echo.Context) error {
+	var req UpdateLimitsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.MaxTransactionAmount == nil && req.DailyOutgoing == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Set max_transaction_amount, daily_outgoing or both",
+		})
+	}
+
+	account, err := h.accountService.UpdateLimits(c.Request().Context(), c.Param("id"), req.MaxTransactionAmount, req.DailyOutgoing)
+	if err != nil {
+		if err == domain.ErrInvalidAmount || errors.Is(err, domain.ErrInvalidPrecision) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": amountError(err),
+			})
+		}
+
+		switch err {
+		case domain.ErrInvalidLimit:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Limits must not be negative",
+			})
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account was modified concurrently, please retry",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// GetAccountBalance retrieves the current balance of an account
+func (h *AccountHandler) GetAccountBalance(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account ID is required",
+		})
+	}
+
+	if asOf := c.QueryParam("as_of"); asOf != "" {
+		return h.getAccountBalanceAsOf(c, id, asOf)
+	}
+
+	account, err := h.accountService.GetAccount(c.Request().Context(), id)
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"account_id":        account.ID,
+		"balance":           account.Balance.Format(account.Currency),
+		"available_balance": account.AvailableBalance().Format(account.Currency),
+		"currency":          account.Currency,
+		"status":            account.Status,
+		"updated_at":        account.UpdatedAt,
+	})
+}
+
+// getAccountBalanceAsOf returns the account's balance at the close of a day
+func (h *AccountHandler) getAccountBalanceAsOf(c echo.Context, id, asOf string) error {
+	snapshot, err := h.snapshotService.GetBalanceAsOf(c.Request().Context(), id, asOf)
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrBalanceSnapshotNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "No balance snapshot for the date",
+			})
+		case domain.ErrInvalidInput:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "as_of must be today or an earlier date in YYYY-MM-DD format",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, snapshot)
+}
+
+// forbiddenError refuses a request for another user's account
+func forbiddenError(c echo.Context) error {
+	return c.JSON(http.StatusForbidden, map[string]string{
+		"error": "Account belongs to another user",
+		"code":  "account_forbidden",
+	})
+}
+
+// invalidAccountStatusError lists the account statuses in an error message
+func invalidAccountStatusError() string {
+	statuses := make([]string, len(domain.AccountStatuses))
+	for i, status := range domain.AccountStatuses {
+		statuses[i] = string(status)
+	}
+	return "Invalid account status; use one of " + strings.Join(statuses, ", ")
+}
+
+// invalidAccountTypeError lists the account types in an error message
+func invalidAccountTypeError() string {
+	types := make([]string, len(domain.AccountTypes))
+	for i, accountType := range domain.AccountTypes {
+		types[i] = string(accountType)
+	}
+	return "Invalid account type; use one of " + strings.Join(types, ", ")
 }
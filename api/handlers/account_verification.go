@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AccountVerificationHandler handles micro-deposit verification HTTP requests
+type AccountVerificationHandler struct {
+	verificationService domain.AccountVerificationService
+}
+
+// NewAccountVerificationHandler creates a new account verification handler
+func NewAccountVerificationHandler(verificationService domain.AccountVerificationService) *AccountVerificationHandler {
+	return &AccountVerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// VerifyMicroDepositsRequest represents the request body for confirming micro-deposits
+type VerifyMicroDepositsRequest struct {
+	Amounts []domain.Decimal `json:"amounts" validate:"required,len=2"`
+}
+
+// StartMicroDeposits sends two micro-deposits to an account
+func (h *AccountVerificationHandler) StartMicroDeposits(c echo.Context) error {
+	challenge, err := h.verificationService.StartMicroDeposits(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return verificationError(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, challenge)
+}
+
+// VerifyMicroDeposits confirms the micro-deposit amounts for an account
+func (h *AccountVerificationHandler) VerifyMicroDeposits(c echo.Context) error {
+	var req VerifyMicroDepositsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	account, err := h.verificationService.VerifyMicroDeposits(c.Request().Context(), c.Param("id"), req.Amounts)
+	if err != nil {
+		return verificationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, account)
+}
+
+// verificationError maps account verification errors to responses
+func verificationError(c echo.Context, err error) error {
+	if err == domain.ErrInvalidAmount || errors.Is(err, domain.ErrInvalidPrecision) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": amountError(err),
+		})
+	}
+
+	switch err {
+	case domain.ErrAccountNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Account not found",
+		})
+	case domain.ErrAccountInactive:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "Account is inactive",
+		})
+	case domain.ErrAccountClosed:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "Account is closed",
+		})
+	case domain.ErrAccountFrozen:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "Account is frozen",
+		})
+	case domain.ErrAccountAlreadyVerified:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Account already verified",
+		})
+	case domain.ErrMicroDepositPending:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Micro-deposit verification already pending",
+		})
+	case domain.ErrMicroDepositNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "No pending micro-deposit verification",
+		})
+	case domain.ErrMicroDepositExpired:
+		return c.JSON(http.StatusGone, map[string]string{
+			"error": "Micro-deposit verification expired",
+		})
+	case domain.ErrMicroDepositMismatch:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "Micro-deposit amounts do not match",
+		})
+	case domain.ErrMicroDepositAttemptsExceeded:
+		return c.JSON(http.StatusTooManyRequests, map[string]string{
+			"error": "Too many verification attempts",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
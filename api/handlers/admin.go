@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminHandler handles operational admin HTTP requests
+type AdminHandler struct {
+	diagnosticsService    domain.DiagnosticsService
+	reconciliationService domain.ReconciliationService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(diagnosticsService domain.DiagnosticsService, reconciliationService domain.ReconciliationService) *AdminHandler {
+	return &AdminHandler{
+		diagnosticsService:    diagnosticsService,
+		reconciliationService: reconciliationService,
+	}
+}
+
+// GetTransactionDiagnostics retrieves the diagnostics document for a transaction
+func (h *AdminHandler) GetTransactionDiagnostics(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Transaction ID is required",
+		})
+	}
+
+	diagnostics, err := h.diagnosticsService.GetTransactionDiagnostics(c.Request().Context(), id)
+	if err != nil {
+		switch err {
+		case domain.ErrTransactionNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Transaction not found",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, diagnostics)
+}
+
+// GetReconciliationReport retrieves the report of the latest reconciliation run
+func (h *AdminHandler) GetReconciliationReport(c echo.Context) error {
+	report, err := h.reconciliationService.GetLatestReport(c.Request().Context())
+	if err != nil {
+		switch err {
+		case domain.ErrReconciliationReportNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "No reconciliation report yet",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetInvariants checks that the total balance in each currency matches the
+// opening balances and transactions
+func (h *AdminHandler) GetInvariants(c echo.Context) error {
+	report, err := h.reconciliationService.CheckInvariants(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SubmitBatchRequest represents the request body for a batch: either a list
+// of legs, or one source account paying many credits, as for payroll
+type SubmitBatchRequest struct {
+	Legs []ProcessTransactionRequest `json:"legs,omitempty"`
+
+	FromAccountID *string       `json:"from_account_id,omitempty"`
+	Currency      string        `json:"currency,omitempty"`
+	Credits       []BatchCredit `json:"credits,omitempty"`
+}
+
+// BatchCredit is one credit of a multi-credit batch
+type BatchCredit struct {
+	ToAccountID string                 `json:"to_account_id" validate:"required"`
+	Amount      domain.Decimal         `json:"amount"`
+	Description string                 `json:"description"`
+	Reference   string                 `json:"reference"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// legs expands the request into transaction requests for each leg
+func (req *SubmitBatchRequest) legs() []ProcessTransactionRequest {
+	if len(req.Credits) == 0 {
+		return req.Legs
+	}
+
+	legs := make([]ProcessTransactionRequest, len(req.Credits))
+	for i, credit := range req.Credits {
+		toAccountID := credit.ToAccountID
+		legs[i] = ProcessTransactionRequest{
+			Type:          domain.TransactionTypeTransfer,
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   &toAccountID,
+			Amount:        credit.Amount,
+			Currency:      req.Currency,
+			Description:   credit.Description,
+			Reference:     credit.Reference,
+			Metadata:      credit.Metadata,
+		}
+	}
+	return legs
+}
+
+// SubmitBatch queues a batch of transactions to be applied together
+func (h *TransactionHandler) SubmitBatch(c echo.Context) error {
+	var req SubmitBatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.Legs) > 0 && len(req.Credits) > 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Provide either legs or credits, not both",
+		})
+	}
+
+	legs := req.legs()
+	request := &domain.BatchRequest{Legs: make([]*domain.TransactionRequest, len(legs))}
+	for i := range legs {
+		leg := &legs[i]
+		if err := c.Validate(leg); err != nil {
+			return batchLegError(c, i, err.Error())
+		}
+
+		amount, err := leg.Amount.Money(leg.Currency)
+		if err != nil {
+			return batchLegError(c, i, amountError(err))
+		}
+
+		request.Legs[i] = &domain.TransactionRequest{
+			Type:          leg.Type,
+			FromAccountID: leg.FromAccountID,
+			ToAccountID:   leg.ToAccountID,
+			Amount:        amount,
+			Currency:      leg.Currency,
+			Description:   leg.Description,
+			Reference:     leg.Reference,
+			Metadata:      leg.Metadata,
+			ScheduledAt:   leg.ScheduledAt,
+
+			ExternalSource:      leg.ExternalSource,
+			ExternalDestination: leg.ExternalDestination,
+		}
+	}
+
+	batch, err := h.transactionService.ProcessBatch(c.Request().Context(), request)
+	if err != nil {
+		if errors.Is(err, domain.ErrForbidden) {
+			return forbiddenError(c)
+		}
+
+		var quotaErr *domain.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			return quotaExceededError(c, quotaErr)
+		}
+
+		var legErr *domain.BatchLegError
+		if errors.As(err, &legErr) {
+			var referenceErr *domain.DuplicateReferenceError
+			if errors.As(err, &referenceErr) {
+				return c.JSON(http.StatusConflict, map[string]interface{}{
+					"error":                   fmt.Sprintf("Leg %d: reference already used by another transaction from this account", legErr.Index),
+					"leg":                     legErr.Index,
+					"existing_transaction_id": referenceErr.ExistingTransactionID,
+				})
+			}
+			if errors.Is(err, domain.ErrVerificationRequired) {
+				return verificationRequiredError(c, err)
+			}
+			return batchLegError(c, legErr.Index, legErr.Err.Error())
+		}
+
+		switch {
+		case errors.Is(err, domain.ErrInvalidBatch):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("A batch must have between 1 and %d legs", domain.MaxBatchLegs),
+			})
+		case errors.Is(err, domain.ErrInsufficientFunds):
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Insufficient funds for the batch total: " + err.Error(),
+			})
+		case errors.Is(err, domain.ErrBelowMinimumBalance):
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "Batch total would take an account below its minimum balance: " + err.Error(),
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusAccepted, batch)
+}
+
+// GetBatch reports a batch and the status of each of its legs
+func (h *TransactionHandler) GetBatch(c echo.Context) error {
+	batchID := c.Param("batch_id")
+	if batchID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Batch ID is required",
+		})
+	}
+
+	batch, err := h.transactionService.GetBatch(c.Request().Context(), batchID)
+	if err != nil {
+		switch err {
+		case domain.ErrBatchNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Batch not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, batch)
+}
+
+// batchLegError reports a rejected leg of a batch
+func batchLegError(c echo.Context, index int, message string) error {
+	return c.JSON(http.StatusBadRequest, map[string]interface{}{
+		"error": fmt.Sprintf("Leg %d: %s", index, message),
+		"leg":   index,
+	})
+}
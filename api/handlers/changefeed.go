@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ChangeFeedHandler handles data export change feed HTTP requests
+type ChangeFeedHandler struct {
+	changeFeedService domain.ChangeFeedService
+}
+
+// NewChangeFeedHandler creates a new change feed handler
+func NewChangeFeedHandler(changeFeedService domain.ChangeFeedService) *ChangeFeedHandler {
+	return &ChangeFeedHandler{
+		changeFeedService: changeFeedService,
+	}
+}
+
+// GetChanges returns the account and transaction changes after a cursor
+func (h *ChangeFeedHandler) GetChanges(c echo.Context) error {
+	limit := 0
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid limit",
+			})
+		}
+		limit = parsed
+	}
+
+	feed, err := h.changeFeedService.GetChanges(c.Request().Context(), c.QueryParam("cursor"), limit)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidCursor:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid cursor",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, feed)
+}
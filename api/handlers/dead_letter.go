@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DeadLetterHandler handles the admin API over the transaction queue's dead
+// letters
+type DeadLetterHandler struct {
+	deadLetterService domain.DeadLetterService
+}
+
+// NewDeadLetterHandler creates a new dead letter handler
+func NewDeadLetterHandler(deadLetterService domain.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// ListDeadLetters peeks at the oldest dead-lettered transaction messages
+// without removing them
+func (h *DeadLetterHandler) ListDeadLetters(c echo.Context) error {
+	limit := 10
+	if l := c.QueryParam("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	deadLetters, err := h.deadLetterService.ListDeadLetters(c.Request().Context(), limit)
+	if err != nil {
+		return deadLetterError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"dead_letters": deadLetters,
+		"count":        len(deadLetters),
+	})
+}
+
+// RequeueDeadLettersRequest represents the request body for moving dead
+// letters back onto the transaction queue
+type RequeueDeadLettersRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// RequeueDeadLetters moves the dead letters with the IDs back onto the
+// transaction queue, listing the IDs not found
+func (h *DeadLetterHandler) RequeueDeadLetters(c echo.Context) error {
+	var req RequeueDeadLettersRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	result, err := h.deadLetterService.RequeueDeadLetters(c.Request().Context(), req.IDs)
+	if err != nil {
+		return deadLetterError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// deadLetterError answers a failure of the dead letter service
+func deadLetterError(c echo.Context, err error) error {
+	switch err {
+	case domain.ErrDeadLetterQueueNotConfigured:
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "No dead letter queue is configured",
+		})
+	case domain.ErrInvalidDeadLetterRequeue:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Provide between 1 and %d dead letter IDs, none blank", domain.MaxDeadLetterRequeueIDs),
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"banking-ledger/api/middleware"
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// exportFlushRows is how many rows are written between flushes to the client
+const exportFlushRows = 100
+
+// exportContentType returns the content type and file extension of an export
+// format
+func exportContentType(format string) (string, string) {
+	contentType := "text/csv; charset=utf-8"
+	if format == domain.ExportFormatJSONLines {
+		contentType = middleware.NDJSONContentType
+	}
+	return contentType, domain.ExportFileExtension(format)
+}
+
+// transactionExport writes rows as CSV or JSON lines, sending the headers
+// only with the first row so that an error found before it can still be
+// answered with a status
+type transactionExport struct {
+	c        echo.Context
+	format   string
+	filename string
+	started  bool
+	rows     int
+	encoder  *domain.ExportEncoder
+}
+
+// start sends the headers and, for CSV, the column names
+func (e *transactionExport) start() error {
+	e.started = true
+	response := e.c.Response()
+	contentType, extension := exportContentType(e.format)
+	response.Header().Set(echo.HeaderContentType, contentType)
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, e.filename, extension))
+	response.WriteHeader(http.StatusOK)
+
+	encoder, err := domain.NewExportEncoder(response, e.format)
+	if err != nil {
+		return err
+	}
+	e.encoder = encoder
+	return nil
+}
+
+// write sends one row, flushing every exportFlushRows rows
+func (e *transactionExport) write(view *domain.AccountTransaction) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+
+	if err := e.encoder.Encode(view); err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushRows == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// flush sends whatever has been written so far
+func (e *transactionExport) flush() error {
+	if err := e.encoder.Flush(); err != nil {
+		return err
+	}
+	e.c.Response().Flush()
+	return nil
+}
+
+// ExportAccountTransactions streams an account's transactions, oldest first,
+// as CSV or, with format=json-lines, one JSON object per line. It takes the
+// filters of the account's transaction history, and from and to as RFC 3339
+// times or dates, but no limit or offset.
+func (h *TransactionHandler) ExportAccountTransactions(c echo.Context) error {
+	accountID := c.Param("account_id")
+	if accountID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account ID is required",
+		})
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = domain.ExportFormatCSV
+	}
+	if !domain.IsExportFormat(format) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Unsupported format; use %s or %s", domain.ExportFormatCSV, domain.ExportFormatJSONLines),
+		})
+	}
+
+	filter, err := h.parseTransactionFilter(c)
+	if err != nil {
+		return invalidFilterError(c, err)
+	}
+	for _, bound := range []struct {
+		param string
+		upper bool
+		field **time.Time
+	}{
+		{"from", false, &filter.FromDate},
+		{"to", true, &filter.ToDate},
+	} {
+		value := c.QueryParam(bound.param)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseCreatedBound(value, bound.upper)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Invalid %s; use an RFC 3339 time or a YYYY-MM-DD date", bound.param),
+			})
+		}
+		*bound.field = &parsed
+	}
+	if filter.FromDate != nil && filter.ToDate != nil && filter.FromDate.After(*filter.ToDate) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "from must not be after to",
+		})
+	}
+
+	export := &transactionExport{
+		c:        c,
+		format:   format,
+		filename: fmt.Sprintf("account-%s-transactions", accountID),
+	}
+	err = h.transactionService.ExportAccountTransactions(c.Request().Context(), accountID, filter, export.write)
+	if err != nil && !export.started {
+		var tooLarge *domain.ExportTooLargeError
+		switch {
+		case errors.Is(err, domain.ErrAccountNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case errors.Is(err, domain.ErrForbidden):
+			return forbiddenError(c)
+		case errors.As(err, &tooLarge):
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error": fmt.Sprintf("Export would hold %d transactions, more than the %d allowed; narrow it with from and to", tooLarge.Count, tooLarge.Max),
+				"count": tooLarge.Count,
+				"max":   tooLarge.Max,
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+	// The status has been sent, so a failure part way can only cut the export short
+	if err != nil {
+		return err
+	}
+
+	if !export.started {
+		if err := export.start(); err != nil {
+			return err
+		}
+	}
+	return export.flush()
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ExportJobHandler handles export job HTTP requests
+type ExportJobHandler struct {
+	exportService domain.ExportJobService
+}
+
+// NewExportJobHandler creates a new export job handler
+func NewExportJobHandler(exportService domain.ExportJobService) *ExportJobHandler {
+	return &ExportJobHandler{
+		exportService: exportService,
+	}
+}
+
+// exportJobView is an export job with the link its file is downloaded from
+// once it is complete
+type exportJobView struct {
+	*domain.ExportJob
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+func newExportJobView(job *domain.ExportJob) *exportJobView {
+	view := &exportJobView{ExportJob: job}
+	if job.Status == domain.ExportJobCompleted {
+		view.DownloadURL = fmt.Sprintf("/api/v1/exports/%s/download", job.ID)
+	}
+	return view
+}
+
+// CreateExportJob queues an export of an account's transactions matching a
+// filter, as CSV or JSON lines, to be written by the processor
+func (h *ExportJobHandler) CreateExportJob(c echo.Context) error {
+	var req domain.ExportJobRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	job, err := h.exportService.CreateExportJob(c.Request().Context(), &req)
+	if err != nil {
+		return exportJobError(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, newExportJobView(job))
+}
+
+// GetExportJob reports an export job's status and progress, with a download
+// link once it is complete
+func (h *ExportJobHandler) GetExportJob(c echo.Context) error {
+	job, err := h.exportService.GetExportJob(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return exportJobError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, newExportJobView(job))
+}
+
+// CancelExportJob cancels an export job that is still queued
+func (h *ExportJobHandler) CancelExportJob(c echo.Context) error {
+	job, err := h.exportService.CancelExportJob(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return exportJobError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, newExportJobView(job))
+}
+
+// DownloadExport streams a completed export job's file
+func (h *ExportJobHandler) DownloadExport(c echo.Context) error {
+	job, file, err := h.exportService.OpenExportFile(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return exportJobError(c, err)
+	}
+	defer file.Close()
+
+	contentType, extension := exportContentType(job.Format)
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="account-%s-transactions.%s"`, job.AccountID, extension))
+	return c.Stream(http.StatusOK, contentType, file)
+}
+
+// exportJobError maps export job service errors to responses
+func exportJobError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrExportJobNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Export job not found",
+		})
+	case errors.Is(err, domain.ErrAccountNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Account not found",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		return forbiddenError(c)
+	case errors.Is(err, domain.ErrInvalidExportJob):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Export job needs an account_id, a format of %s or %s, and a filter with from_date before to_date and min_amount below max_amount",
+				domain.ExportFormatCSV, domain.ExportFormatJSONLines),
+		})
+	case errors.Is(err, domain.ErrExportJobNotCancellable):
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Export job can only be cancelled while queued",
+		})
+	case errors.Is(err, domain.ErrExportNotReady):
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Export file is not available",
+		})
+	case errors.Is(err, domain.ErrQueueError):
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Export job could not be queued",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
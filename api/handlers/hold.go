@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HoldHandler handles hold HTTP requests
+type HoldHandler struct {
+	holdService domain.HoldService
+}
+
+// NewHoldHandler creates a new hold handler
+func NewHoldHandler(holdService domain.HoldService) *HoldHandler {
+	return &HoldHandler{
+		holdService: holdService,
+	}
+}
+
+// PlaceHoldRequest represents the request body for placing a hold
+type PlaceHoldRequest struct {
+	AccountID   string         `json:"account_id" validate:"required"`
+	Amount      domain.Decimal `json:"amount" validate:"required"`
+	Currency    string         `json:"currency" validate:"required"`
+	Description string         `json:"description"`
+	Reference   string         `json:"reference"`
+}
+
+// CaptureHoldRequest represents the request body for capturing a hold. An
+// empty amount captures the whole hold.
+type CaptureHoldRequest struct {
+	Amount domain.Decimal `json:"amount"`
+}
+
+// PlaceHold reserves funds on an account
+func (h *HoldHandler) PlaceHold(c echo.Context) error {
+	var req PlaceHoldRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	amount, err := req.Amount.Money(req.Currency)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": amountError(err),
+		})
+	}
+
+	hold, err := h.holdService.PlaceHold(c.Request().Context(), &domain.HoldRequest{
+		AccountID:   req.AccountID,
+		Amount:      amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		Reference:   req.Reference,
+	})
+	if err != nil {
+		return holdError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, hold)
+}
+
+// GetHold retrieves a hold by ID
+func (h *HoldHandler) GetHold(c echo.Context) error {
+	hold, err := h.holdService.GetHold(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return holdError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, hold)
+}
+
+// CaptureHold debits all or part of a hold's amount
+func (h *HoldHandler) CaptureHold(c echo.Context) error {
+	var req CaptureHoldRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	hold, err := h.holdService.CaptureHold(c.Request().Context(), c.Param("id"), req.Amount)
+	if err != nil {
+		return holdError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, hold)
+}
+
+// ReleaseHold returns a hold's funds to the account
+func (h *HoldHandler) ReleaseHold(c echo.Context) error {
+	hold, err := h.holdService.ReleaseHold(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return holdError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, hold)
+}
+
+// holdError maps hold errors to responses
+func holdError(c echo.Context, err error) error {
+	if err == domain.ErrInvalidAmount || errors.Is(err, domain.ErrInvalidPrecision) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": amountError(err),
+		})
+	}
+
+	var fundsErr *domain.InsufficientFundsError
+	if errors.As(err, &fundsErr) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":     "Insufficient funds",
+			"available": fundsErr.Available.Format(fundsErr.Currency),
+			"currency":  fundsErr.Currency,
+		})
+	}
+
+	var minimumErr *domain.BelowMinimumBalanceError
+	if errors.As(err, &minimumErr) {
+		return belowMinimumBalanceError(c, minimumErr)
+	}
+
+	switch err {
+	case domain.ErrHoldNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Hold not found",
+		})
+	case domain.ErrHoldNotActive:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Hold has already been captured, released or expired",
+		})
+	case domain.ErrCaptureExceedsHold:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Capture amount exceeds the held amount",
+		})
+	case domain.ErrMissingFromAccount:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing account",
+		})
+	case domain.ErrMissingCurrency:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing currency",
+		})
+	case domain.ErrUnsupportedCurrency:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Unsupported currency",
+		})
+	case domain.ErrAmountTooLarge:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Amount exceeds the per-transaction maximum",
+		})
+	case domain.ErrAccountNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Account not found",
+		})
+	case domain.ErrForbidden:
+		return forbiddenError(c)
+	case domain.ErrAccountInactive:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account is inactive",
+		})
+	case domain.ErrAccountClosed:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account is closed",
+		})
+	case domain.ErrAccountFrozen:
+		return c.JSON(http.StatusLocked, map[string]string{
+			"error": "Account is frozen",
+		})
+	case domain.ErrCurrencyMismatch:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Currency mismatch",
+		})
+	case domain.ErrConcurrentUpdate:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Account was modified concurrently, please retry",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
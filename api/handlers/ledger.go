@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// LedgerHandler handles account ledger and statement HTTP requests
+type LedgerHandler struct {
+	ledgerService    domain.AccountLedgerService
+	statementService domain.LedgerService
+}
+
+// NewLedgerHandler creates a new ledger handler
+func NewLedgerHandler(ledgerService domain.AccountLedgerService, statementService domain.LedgerService) *LedgerHandler {
+	return &LedgerHandler{
+		ledgerService:    ledgerService,
+		statementService: statementService,
+	}
+}
+
+// GetAccountLedger retrieves an account's ledger entries with running balances
+func (h *LedgerHandler) GetAccountLedger(c echo.Context) error {
+	limit := 10
+	offset := 0
+
+	if l := c.QueryParam("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	if o := c.QueryParam("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+
+	entries, err := h.ledgerService.GetAccountLedger(c.Request().Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	if entries == nil {
+		entries = []*domain.LedgerEntry{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// GetAccountStatement retrieves an account's statement for the days from and
+// to, or for the current month if either is missing or invalid
+func (h *LedgerHandler) GetAccountStatement(c echo.Context) error {
+	statement, err := h.statementService.GetAccountStatement(c.Request().Context(), c.Param("id"), c.QueryParam("from"), c.QueryParam("to"))
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrStatementPeriodTooLong:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, statement)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetTransactionReceipt issues the signed receipt of a completed transaction
+func (h *TransactionHandler) GetTransactionReceipt(c echo.Context) error {
+	receipt, err := h.transactionService.GetTransactionReceipt(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrTransactionNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Transaction not found",
+			})
+		case domain.ErrReceiptUnavailable:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Receipt available only for completed transactions",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return receiptError(c, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, receipt)
+}
+
+// VerifyReceipt checks a receipt, as issued, against its verification hash
+func (h *TransactionHandler) VerifyReceipt(c echo.Context) error {
+	var receipt domain.Receipt
+	if err := c.Bind(&receipt); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if receipt.TransactionID == "" || receipt.Hash == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Receipt must have a transaction_id and verification_hash",
+		})
+	}
+
+	valid, err := h.transactionService.VerifyReceipt(c.Request().Context(), &receipt)
+	if err != nil {
+		return receiptError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"transaction_id": receipt.TransactionID,
+		"valid":          valid,
+	})
+}
+
+// receiptError maps the errors common to issuing and verifying receipts
+func receiptError(c echo.Context, err error) error {
+	if err == domain.ErrReceiptsNotConfigured {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{
+			"error": "Receipts are not configured",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Internal server error",
+	})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SettlementHandler handles settlement group HTTP requests
+type SettlementHandler struct {
+	settlementService domain.SettlementService
+}
+
+// NewSettlementHandler creates a new settlement handler
+func NewSettlementHandler(settlementService domain.SettlementService) *SettlementHandler {
+	return &SettlementHandler{
+		settlementService: settlementService,
+	}
+}
+
+// SettlementGroupRequest represents the request body for creating or updating
+// a settlement group
+type SettlementGroupRequest struct {
+	Name       string   `json:"name" validate:"required"`
+	AccountIDs []string `json:"account_ids" validate:"required,min=1"`
+}
+
+// CreateSettlementGroup creates a new settlement group
+func (h *SettlementHandler) CreateSettlementGroup(c echo.Context) error {
+	var req SettlementGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	group, err := h.settlementService.CreateGroup(c.Request().Context(), req.Name, req.AccountIDs)
+	if err != nil {
+		return settlementError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, group)
+}
+
+// ListSettlementGroups lists all settlement groups
+func (h *SettlementHandler) ListSettlementGroups(c echo.Context) error {
+	groups, err := h.settlementService.ListGroups(c.Request().Context())
+	if err != nil {
+		return settlementError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"settlement_groups": groups,
+		"count":             len(groups),
+	})
+}
+
+// GetSettlementGroup retrieves a settlement group by ID
+func (h *SettlementHandler) GetSettlementGroup(c echo.Context) error {
+	group, err := h.settlementService.GetGroup(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return settlementError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, group)
+}
+
+// UpdateSettlementGroup replaces a settlement group's name and accounts
+func (h *SettlementHandler) UpdateSettlementGroup(c echo.Context) error {
+	var req SettlementGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	group, err := h.settlementService.UpdateGroup(c.Request().Context(), c.Param("id"), req.Name, req.AccountIDs)
+	if err != nil {
+		return settlementError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, group)
+}
+
+// GetSettlementNet computes a group's net positions for a day without settling
+func (h *SettlementHandler) GetSettlementNet(c echo.Context) error {
+	date, err := parseSettlementDate(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	net, err := h.settlementService.ComputeNet(c.Request().Context(), c.Param("id"), date)
+	if err != nil {
+		return settlementError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, net)
+}
+
+// SettleGroup settles a group's net positions for a day
+func (h *SettlementHandler) SettleGroup(c echo.Context) error {
+	date, err := parseSettlementDate(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid date format. Use YYYY-MM-DD",
+		})
+	}
+
+	settlement, err := h.settlementService.Settle(c.Request().Context(), c.Param("id"), date)
+	if err != nil {
+		return settlementError(c, err)
+	}
+
+	if settlement.AlreadySettled {
+		return c.JSON(http.StatusOK, settlement)
+	}
+	return c.JSON(http.StatusCreated, settlement)
+}
+
+// parseSettlementDate reads the date query parameter, defaulting to the
+// previous UTC day
+func parseSettlementDate(c echo.Context) (time.Time, error) {
+	dateStr := c.QueryParam("date")
+	if dateStr == "" {
+		return time.Now().UTC().AddDate(0, 0, -1), nil
+	}
+	return time.Parse("2006-01-02", dateStr)
+}
+
+// settlementError maps settlement service errors to responses
+func settlementError(c echo.Context, err error) error {
+	switch err {
+	case domain.ErrSettlementGroupNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Settlement group not found",
+		})
+	case domain.ErrInvalidSettlementGroup:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid settlement group",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
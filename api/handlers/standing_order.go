@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StandingOrderHandler handles standing order HTTP requests
+type StandingOrderHandler struct {
+	standingOrderService domain.StandingOrderService
+}
+
+// NewStandingOrderHandler creates a new standing order handler
+func NewStandingOrderHandler(standingOrderService domain.StandingOrderService) *StandingOrderHandler {
+	return &StandingOrderHandler{
+		standingOrderService: standingOrderService,
+	}
+}
+
+// CreateStandingOrderRequest represents the request body for creating a standing order
+type CreateStandingOrderRequest struct {
+	FromAccountID string                        `json:"from_account_id" validate:"required"`
+	ToAccountID   string                        `json:"to_account_id" validate:"required"`
+	Amount        domain.Decimal                `json:"amount" validate:"required"`
+	Currency      string                        `json:"currency" validate:"required,len=3"`
+	Description   string                        `json:"description"`
+	Frequency     domain.StandingOrderFrequency `json:"frequency" validate:"required"`
+	Interval      int                           `json:"interval"`
+	StartAt       *time.Time                    `json:"start_at,omitempty"`
+	EndAt         *time.Time                    `json:"end_at,omitempty"`
+}
+
+// UpdateStandingOrderRequest represents the request body for changing a standing order
+type UpdateStandingOrderRequest struct {
+	Amount      *domain.Decimal             `json:"amount,omitempty"`
+	Description *string                     `json:"description,omitempty"`
+	Status      *domain.StandingOrderStatus `json:"status,omitempty"`
+}
+
+// CreateStandingOrder creates a recurring transfer
+func (h *StandingOrderHandler) CreateStandingOrder(c echo.Context) error {
+	var req CreateStandingOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	amount, err := req.Amount.Money(req.Currency)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": amountError(err),
+		})
+	}
+
+	request := &domain.StandingOrderRequest{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        amount,
+		Currency:      req.Currency,
+		Description:   req.Description,
+		Frequency:     req.Frequency,
+		Interval:      req.Interval,
+		EndAt:         req.EndAt,
+	}
+	if req.StartAt != nil {
+		request.StartAt = *req.StartAt
+	}
+
+	order, err := h.standingOrderService.CreateStandingOrder(c.Request().Context(), request)
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
+
+// ListStandingOrders lists standing orders, optionally for one account
+func (h *StandingOrderHandler) ListStandingOrders(c echo.Context) error {
+	orders, err := h.standingOrderService.ListStandingOrders(c.Request().Context(), c.QueryParam("account_id"))
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"standing_orders": orders,
+		"count":           len(orders),
+	})
+}
+
+// GetStandingOrder retrieves a standing order by ID
+func (h *StandingOrderHandler) GetStandingOrder(c echo.Context) error {
+	order, err := h.standingOrderService.GetStandingOrder(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// UpdateStandingOrder changes a standing order's amount or description, or
+// pauses or resumes it
+func (h *StandingOrderHandler) UpdateStandingOrder(c echo.Context) error {
+	var req UpdateStandingOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	order, err := h.standingOrderService.UpdateStandingOrder(c.Request().Context(), c.Param("id"), &domain.StandingOrderUpdate{
+		Amount:      req.Amount,
+		Description: req.Description,
+		Status:      req.Status,
+	})
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// CancelStandingOrder stops a standing order from running again
+func (h *StandingOrderHandler) CancelStandingOrder(c echo.Context) error {
+	order, err := h.standingOrderService.CancelStandingOrder(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return standingOrderError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+// standingOrderError maps standing order errors to responses
+func standingOrderError(c echo.Context, err error) error {
+	if err == domain.ErrInvalidAmount || errors.Is(err, domain.ErrInvalidPrecision) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": amountError(err),
+		})
+	}
+
+	switch err {
+	case domain.ErrStandingOrderNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Standing order not found",
+		})
+	case domain.ErrStandingOrderClosed:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Standing order is cancelled or completed",
+		})
+	case domain.ErrInvalidStandingOrder:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid schedule; use a daily, weekly or monthly frequency, a positive interval and a start time that is not in the past",
+		})
+	case domain.ErrInvalidInput:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Status must be active or paused",
+		})
+	case domain.ErrMissingAccounts:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing from and to accounts",
+		})
+	case domain.ErrSameAccount:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "From and to accounts cannot be the same",
+		})
+	case domain.ErrMissingCurrency:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing currency",
+		})
+	case domain.ErrUnsupportedCurrency:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Unsupported currency",
+		})
+	case domain.ErrCurrencyMismatch:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Currency mismatch",
+		})
+	case domain.ErrAmountTooLarge:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Amount exceeds the per-transaction maximum",
+		})
+	case domain.ErrAccountNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Account not found",
+		})
+	case domain.ErrForbidden:
+		return forbiddenError(c)
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StatementHandler handles generated monthly statement HTTP requests
+type StatementHandler struct {
+	statementService domain.MonthlyStatementService
+}
+
+// NewStatementHandler creates a new statement handler
+func NewStatementHandler(statementService domain.MonthlyStatementService) *StatementHandler {
+	return &StatementHandler{
+		statementService: statementService,
+	}
+}
+
+// ListStatements lists an account's generated monthly statements without
+// their lines, newest month first
+func (h *StatementHandler) ListStatements(c echo.Context) error {
+	limit := 10
+	offset := 0
+
+	if l := c.QueryParam("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil {
+			limit = parsed
+		}
+	}
+
+	if o := c.QueryParam("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil {
+			offset = parsed
+		}
+	}
+
+	statements, err := h.statementService.ListStatements(c.Request().Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	if statements == nil {
+		statements = []*domain.MonthlyStatement{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"statements": statements,
+		"count":      len(statements),
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// GetStatement retrieves one of an account's generated monthly statements
+// with its lines
+func (h *StatementHandler) GetStatement(c echo.Context) error {
+	statement, err := h.statementService.GetStatement(c.Request().Context(), c.Param("id"), c.Param("statement_id"))
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrStatementNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Statement not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, statement)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"banking-ledger/api/middleware"
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// streamFlushLines is how many lines of a listing are written between
+// flushes to the client
+const streamFlushLines = 20
+
+// transactionStream writes a listing as NDJSON, one transaction per line,
+// sending the headers only with the first line so that an error found
+// before it can still be answered with a status
+type transactionStream struct {
+	c       echo.Context
+	started bool
+	broken  bool
+	lines   int
+	encoder *json.Encoder
+}
+
+// start sends the headers
+func (s *transactionStream) start() {
+	s.started = true
+	response := s.c.Response()
+	response.Header().Set(echo.HeaderContentType, middleware.NDJSONContentType)
+	response.WriteHeader(http.StatusOK)
+	s.encoder = json.NewEncoder(response)
+}
+
+// write sends one line, flushing every streamFlushLines lines
+func (s *transactionStream) write(value interface{}) error {
+	if !s.started {
+		s.start()
+	}
+
+	if err := s.encoder.Encode(value); err != nil {
+		s.broken = true
+		return err
+	}
+
+	s.lines++
+	if s.lines%streamFlushLines == 0 {
+		s.c.Response().Flush()
+	}
+	return nil
+}
+
+// finish ends a stream that has started. The status has been sent, so a
+// failure part way is reported by a trailing error line, which a reader
+// tells from a transaction by its lone error field, unless the client can
+// no longer be written to.
+func (s *transactionStream) finish(err error) error {
+	if !s.started {
+		s.start()
+	}
+	if err != nil && !s.broken {
+		s.encoder.Encode(map[string]string{
+			"error": "Internal server error",
+		})
+	}
+	s.c.Response().Flush()
+	return err
+}
+
+// streamTransactions writes the page of transactions the filter selects as
+// NDJSON, each line holding the fields the projection selects, as they are
+// read from the database
+func (h *TransactionHandler) streamTransactions(c echo.Context, filter *domain.TransactionFilter) error {
+	stream := &transactionStream{c: c}
+	err := h.transactionService.StreamTransactions(c.Request().Context(), filter, func(transaction *domain.Transaction) error {
+		selected, err := filter.Projection.Select(visibleTransactions(c, transaction)[0])
+		if err != nil {
+			return err
+		}
+		return stream.write(selected)
+	})
+	if err == domain.ErrForbidden {
+		return forbiddenError(c)
+	}
+	if err != nil && !stream.started {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+	return stream.finish(err)
+}
+
+// streamAccountTransactions writes a page of an account's transaction
+// history as NDJSON as it is read from the database
+func (h *TransactionHandler) streamAccountTransactions(c echo.Context, accountID string, filter *domain.TransactionFilter) error {
+	stream := &transactionStream{c: c}
+	err := h.transactionService.StreamAccountTransactions(c.Request().Context(), accountID, filter, func(view *domain.AccountTransaction) error {
+		return stream.write(view)
+	})
+	if err != nil && !stream.started {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+	return stream.finish(err)
+}
@@ -1,12 +1,20 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"banking-ledger/api/middleware"
 	"banking-ledger/internal/domain"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
@@ -22,21 +30,717 @@ func NewTransactionHandler(transactionService domain.TransactionService) *Transa
 	}
 }
 
+// maxIdempotencyKeyLength bounds the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
 // ProcessTransactionRequest represents the request body for processing a transaction
 type ProcessTransactionRequest struct {
-	Type          domain.TransactionType `json:"type" validate:"required"`
-	FromAccountID *string                `json:"from_account_id,omitempty"`
-	ToAccountID   *string                `json:"to_account_id,omitempty"`
-	Amount        float64                `json:"amount" validate:"required,gt=0"`
-	Currency      string                 `json:"currency" validate:"required,len=3"`
-	Description   string                 `json:"description"`
-	Reference     string                 `json:"reference"`
-	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	// ID is an optional UUID chosen by the client, which becomes the
+	// transaction's permanent ID; one is generated without it
+	ID             string                 `json:"id,omitempty"`
+	Type           domain.TransactionType `json:"type" validate:"required"`
+	FromAccountID  *string                `json:"from_account_id,omitempty"`
+	ToAccountID    *string                `json:"to_account_id,omitempty"`
+	Amount         domain.Decimal         `json:"amount"`
+	Currency       string                 `json:"currency" validate:"required,len=3"`
+	Description    string                 `json:"description"`
+	Reference      string                 `json:"reference"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	Tags           []string               `json:"tags,omitempty"`
+	AllowDuplicate bool                   `json:"allow_duplicate,omitempty"`
+	ScheduledAt    *time.Time             `json:"scheduled_at,omitempty"`
+	// DryRun validates the transaction without submitting it, as does the
+	// dry_run=true query parameter
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// ExternalSource is where a deposit's money came from
+	ExternalSource *domain.ExternalParty `json:"external_source,omitempty"`
+	// ExternalDestination is where a withdrawal's money went
+	ExternalDestination *domain.ExternalParty `json:"external_destination,omitempty"`
+}
+
+// ProcessTransaction processes a transaction
+func (h *TransactionHandler) ProcessTransaction(c echo.Context) error {
+	var req ProcessTransactionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	// Reversals must go through the reverse endpoint so the original is claimed
+	if req.Type == domain.TransactionTypeReversal {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Use POST /api/v1/transactions/{id}/reverse to reverse a transaction",
+		})
+	}
+	if req.Type == domain.TransactionTypeRefund {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Use POST /api/v1/transactions/{id}/refund to refund a transaction",
+		})
+	}
+
+	amount, err := req.Amount.Money(req.Currency)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": amountError(err),
+		})
+	}
+
+	var idSource domain.TransactionIDSource
+	if req.ID != "" {
+		id, ok := clientTransactionID(req.ID)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "id must be a UUID such as 3f2c6a1e-8d4b-4c1a-9e2f-5b7d0a9c4e21",
+			})
+		}
+		req.ID, idSource = id, domain.TransactionIDClient
+	}
+
+	transactionReq := &domain.TransactionRequest{
+		ID:             req.ID,
+		IDSource:       idSource,
+		Type:           req.Type,
+		FromAccountID:  req.FromAccountID,
+		ToAccountID:    req.ToAccountID,
+		Amount:         amount,
+		Currency:       req.Currency,
+		Description:    req.Description,
+		Reference:      req.Reference,
+		Metadata:       req.Metadata,
+		Tags:           req.Tags,
+		AllowDuplicate: req.AllowDuplicate,
+		ScheduledAt:    req.ScheduledAt,
+		IdempotencyKey: c.Request().Header.Get("Idempotency-Key"),
+
+		ExternalSource:      req.ExternalSource,
+		ExternalDestination: req.ExternalDestination,
+	}
+	if len(transactionReq.IdempotencyKey) > maxIdempotencyKeyLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Idempotency-Key may be at most %d characters", maxIdempotencyKeyLength),
+		})
+	}
+
+	if allow := c.QueryParam("allow_duplicate"); allow != "" {
+		if parsed, err := strconv.ParseBool(allow); err == nil {
+			transactionReq.AllowDuplicate = parsed
+		}
+	}
+
+	dryRun := req.DryRun
+	if value := c.QueryParam("dry_run"); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			dryRun = parsed
+		}
+	}
+
+	// A dry run reports whether the transaction would succeed without
+	// submitting it
+	if dryRun {
+		validation, err := h.transactionService.ValidateTransaction(c.Request().Context(), transactionReq)
+		if err != nil {
+			return transactionSubmissionError(c, err, transactionReq.Currency)
+		}
+		return c.JSON(http.StatusOK, validation)
+	}
+
+	transaction, err := h.transactionService.ProcessTransaction(c.Request().Context(), transactionReq)
+	if err != nil {
+		return transactionSubmissionError(c, err, transactionReq.Currency)
+	}
+
+	// A repeated idempotency key returns the transaction as it is now
+	if transaction.Type == domain.TransactionTypeVerification || transaction.Replayed {
+		return c.JSON(http.StatusOK, transaction)
+	}
+
+	return c.JSON(http.StatusAccepted, transaction)
+}
+
+// clientTransactionID checks a client-supplied transaction ID is a non-nil
+// UUID in its 36-character hyphenated form, returning it lowercased
+func clientTransactionID(id string) (string, bool) {
+	parsed, err := uuid.Parse(id)
+	if err != nil || len(id) != 36 || parsed == uuid.Nil {
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+// transactionSubmissionError maps the errors of submitting a transaction, or
+// of a dry run of one, to responses
+func transactionSubmissionError(c echo.Context, err error, currency string) error {
+	var duplicateErr *domain.DuplicateTransactionError
+	if errors.As(err, &duplicateErr) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error":                   "Duplicate of a pending transaction submitted moments ago; pass allow_duplicate=true to submit anyway",
+			"existing_transaction_id": duplicateErr.ExistingTransactionID,
+		})
+	}
+
+	var referenceErr *domain.DuplicateReferenceError
+	if errors.As(err, &referenceErr) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error":                   "Reference already used by another transaction from this account",
+			"existing_transaction_id": referenceErr.ExistingTransactionID,
+		})
+	}
+
+	var fundsErr *domain.InsufficientFundsError
+	if errors.As(err, &fundsErr) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error":     "Insufficient funds",
+			"available": fundsErr.Available.Format(fundsErr.Currency),
+			"currency":  fundsErr.Currency,
+		})
+	}
+
+	var minimumErr *domain.BelowMinimumBalanceError
+	if errors.As(err, &minimumErr) {
+		return belowMinimumBalanceError(c, minimumErr)
+	}
+
+	var limitErr *domain.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error":        "Account limit exceeded",
+			"limit":        string(limitErr.Limit),
+			"limit_amount": limitErr.Amount.Format(limitErr.Currency),
+			"currency":     limitErr.Currency,
+		})
+	}
+
+	if errors.Is(err, domain.ErrVerificationRequired) {
+		return verificationRequiredError(c, err)
+	}
+
+	var quotaErr *domain.QuotaExceededError
+	if errors.As(err, &quotaErr) {
+		return quotaExceededError(c, quotaErr)
+	}
+
+	if errors.Is(err, domain.ErrTransactionIDTaken) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "A transaction with this id already exists",
+		})
+	}
+
+	switch err {
+	case domain.ErrInvalidAmount:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid amount",
+		})
+	case domain.ErrInvalidTransactionType:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid transaction type",
+		})
+	case domain.ErrMissingFromAccount:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing from account",
+		})
+	case domain.ErrMissingToAccount:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing to account",
+		})
+	case domain.ErrMissingAccounts:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Missing from and to accounts",
+		})
+	case domain.ErrSameAccount:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "From and to accounts cannot be the same",
+		})
+	case domain.ErrAccountNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Account not found",
+		})
+	case domain.ErrForbidden:
+		return forbiddenError(c)
+	case domain.ErrInsufficientFunds:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Insufficient funds",
+		})
+	case domain.ErrAccountInactive:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account is inactive",
+		})
+	case domain.ErrAccountClosed:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account is closed",
+		})
+	case domain.ErrAccountFrozen:
+		return c.JSON(http.StatusLocked, map[string]string{
+			"error": "Account is frozen",
+		})
+	case domain.ErrCurrencyMismatch:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Currency mismatch",
+		})
+	case domain.ErrUnsupportedCurrency:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Unsupported currency; use an ISO 4217 code such as USD",
+		})
+	case domain.ErrAmountTooLarge:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Amount exceeds the per-transaction maximum of %d %s", domain.ActiveTransactionLimits.MaxAmount, currency),
+		})
+	case domain.ErrInvalidSchedule:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Verifications cannot be scheduled",
+		})
+	case domain.ErrMetadataTooLarge:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("Metadata may have at most %d keys and %d bytes", domain.ActiveTransactionLimits.MaxMetadataKeys, domain.ActiveTransactionLimits.MaxMetadataBytes),
+		})
+	case domain.ErrInvalidTags:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": tagsError,
+		})
+	case domain.ErrInvalidExternalParty:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "External sources are only allowed on deposits and external destinations on withdrawals, each with a known type and a reference",
+		})
+	case domain.ErrExternalPartyRequired:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Deposits require an external_source and withdrawals an external_destination",
+		})
+	case domain.ErrIdempotencyKeyReused:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "Idempotency-Key was already used with a different request",
+		})
+	case domain.ErrIdempotencyKeyInUse:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "A request with this Idempotency-Key is still in progress; retry shortly",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
+
+// belowMinimumBalanceError explains a debit refused because it would take its
+// account below the account's minimum balance, and by how much
+func belowMinimumBalanceError(c echo.Context, err *domain.BelowMinimumBalanceError) error {
+	return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+		"error":           "Balance would fall below the account's minimum balance",
+		"minimum_balance": err.Minimum.Format(err.Currency),
+		"shortfall":       err.Shortfall.Format(err.Currency),
+		"currency":        err.Currency,
+	})
+}
+
+// GetTransaction retrieves a transaction by ID
+func (h *TransactionHandler) GetTransaction(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Transaction ID is required",
+		})
+	}
+
+	transaction, err := h.transactionService.GetTransaction(c.Request().Context(), id)
+	if err != nil {
+		switch err {
+		case domain.ErrTransactionNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Transaction not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, visibleTransactions(c, transaction)[0])
+}
+
+// GetTransactionStatus reports a transaction's status, with its place in
+// the queue and an approximate wait while it is pending
+func (h *TransactionHandler) GetTransactionStatus(c echo.Context) error {
+	report, err := h.transactionService.GetTransactionStatus(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case domain.ErrTransactionNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Transaction not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// tagsError describes the tags a transaction may carry
+var tagsError = fmt.Sprintf("A transaction may have at most %d tags of up to %d letters, digits, spaces, - or _", domain.MaxTransactionTags, domain.MaxTransactionTagLength)
+
+// UpdateTransactionTagsRequest represents the request body for editing a
+// transaction's tags; an empty list removes them
+type UpdateTransactionTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// UpdateTransactionTags replaces a transaction's tags. The body may hold
+// nothing but the tags.
+func (h *TransactionHandler) UpdateTransactionTags(c echo.Context) error {
+	var req UpdateTransactionTagsRequest
+	decoder := json.NewDecoder(c.Request().Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil || req.Tags == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Request body must be {\"tags\": [...]} and nothing else",
+		})
+	}
+
+	transaction, err := h.transactionService.UpdateTransactionTags(c.Request().Context(), c.Param("id"), req.Tags)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidTags:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": tagsError,
+			})
+		case domain.ErrTransactionNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Transaction not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrTransactionArchived:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Archived transactions cannot be edited",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, visibleTransactions(c, transaction)[0])
+}
+
+// visibleTransactions hides the balance changes of transactions from callers
+// other than admins
+func visibleTransactions(c echo.Context, transactions ...*domain.Transaction) []*domain.Transaction {
+	if domain.Unscoped(c.Request().Context()) {
+		return transactions
+	}
+	visible := make([]*domain.Transaction, len(transactions))
+	for i, transaction := range transactions {
+		visible[i] = transaction.WithoutBalanceChanges()
+	}
+	return visible
+}
+
+// LookupTransactionsRequest represents the request body for fetching
+// transactions by ID
+type LookupTransactionsRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// LookupTransactions fetches many transactions by ID at once, both by ID and
+// in the order asked for, listing the IDs not found
+func (h *TransactionHandler) LookupTransactions(c echo.Context) error {
+	var req LookupTransactionsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if len(req.IDs) > domain.MaxTransactionLookupIDs {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": fmt.Sprintf("Provide at most %d transaction IDs", domain.MaxTransactionLookupIDs),
+		})
+	}
+
+	lookup, err := h.transactionService.LookupTransactions(c.Request().Context(), req.IDs)
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidTransactionLookup:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Provide between 1 and %d transaction IDs, none blank", domain.MaxTransactionLookupIDs),
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	ordered := visibleTransactions(c, lookup.Transactions...)
+	byID := make(map[string]*domain.Transaction, len(ordered))
+	for _, transaction := range ordered {
+		byID[transaction.ID] = transaction
+	}
+	missing := lookup.Missing
+	if missing == nil {
+		missing = []string{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"transactions": byID,
+		"ordered":      ordered,
+		"missing":      missing,
+		"count":        len(ordered),
+	})
+}
+
+// GetTransactionHistory retrieves transaction history for an account
+func (h *TransactionHandler) GetTransactionHistory(c echo.Context) error {
+	accountID := c.Param("account_id")
+	if accountID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account ID is required",
+		})
+	}
+
+	filter, err := h.parseTransactionFilter(c)
+	if err != nil {
+		return invalidFilterError(c, err)
+	}
+	if middleware.AcceptsNDJSON(c) {
+		return h.streamAccountTransactions(c, accountID, filter)
+	}
+	transactions, err := h.transactionService.GetAccountTransactions(c.Request().Context(), accountID, filter)
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"transactions": transactions,
+		"count":        len(transactions),
+		"account_id":   accountID,
+	})
+}
+
+// GetTransactionHistoryByQuery retrieves transaction history using query parameters
+func (h *TransactionHandler) GetTransactionHistoryByQuery(c echo.Context) error {
+	accountID := c.QueryParam("account_id")
+	if accountID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Account ID is required",
+		})
+	}
+
+	filter, err := h.parseTransactionFilter(c)
+	if err != nil {
+		return invalidFilterError(c, err)
+	}
+	if middleware.AcceptsNDJSON(c) {
+		return h.streamAccountTransactions(c, accountID, filter)
+	}
+	transactions, err := h.transactionService.GetAccountTransactions(c.Request().Context(), accountID, filter)
+	if err != nil {
+		switch err {
+		case domain.ErrAccountNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Account not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"transactions": transactions,
+		"count":        len(transactions),
+		"account_id":   accountID,
+	})
+}
+
+// GetTransactionsByReference retrieves the transactions carrying a reference
+func (h *TransactionHandler) GetTransactionsByReference(c echo.Context) error {
+	reference := c.Param("reference")
+	if reference == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Reference is required",
+		})
+	}
+
+	filter, err := h.parseTransactionFilter(c)
+	if err != nil {
+		return invalidFilterError(c, err)
+	}
+	filter.Reference = &reference
+	transactions, err := h.transactionService.GetTransactionsByFilter(c.Request().Context(), filter)
+	if err == domain.ErrForbidden {
+		return forbiddenError(c)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"transactions": visibleTransactions(c, transactions...),
+		"count":        len(transactions),
+		"reference":    reference,
+	})
+}
+
+// GetTransactions retrieves transactions by filter, with only the fields
+// selected by ?fields= or, by default, without the heavyweight ones
+func (h *TransactionHandler) GetTransactions(c echo.Context) error {
+	filter, err := h.parseTransactionFilter(c)
+	if err != nil {
+		return invalidFilterError(c, err)
+	}
+	filter.Projection, err = parseTransactionProjection(c)
+	if err != nil {
+		return invalidFilterError(c, err)
+	}
+	if middleware.AcceptsNDJSON(c) {
+		return h.streamTransactions(c, filter)
+	}
+	page, err := h.transactionService.ListTransactions(c.Request().Context(), filter)
+	if err == domain.ErrForbidden {
+		return forbiddenError(c)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+
+	transactions := make([]map[string]json.RawMessage, len(page.Transactions))
+	for i, transaction := range visibleTransactions(c, page.Transactions...) {
+		if transactions[i], err = filter.Projection.Select(transaction); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"transactions": transactions,
+		"count":        len(page.Transactions),
+		"limit":        filter.Limit,
+		"offset":       filter.Offset,
+		"total":        page.Total,
+		"has_more":     page.HasMore,
+	})
+}
+
+// SearchTransactions finds transactions by words of their description or
+// reference, combined with the usual filters, most relevant first
+func (h *TransactionHandler) SearchTransactions(c echo.Context) error {
+	filter, err := h.parseTransactionFilter(c)
+	if err != nil {
+		return invalidFilterError(c, err)
+	}
+
+	matches, err := h.transactionService.SearchTransactions(c.Request().Context(), c.QueryParam("q"), filter)
+	if err != nil {
+		switch err {
+		case domain.ErrSearchQueryTooShort:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("q must be at least %d characters", domain.MinSearchQueryLength),
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	if matches == nil {
+		matches = []*domain.TransactionMatch{}
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results": matches,
+		"count":   len(matches),
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// CancelTransaction cancels a pending transaction
+func (h *TransactionHandler) CancelTransaction(c echo.Context) error {
+	id := c.Param("id")
+	if id == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Transaction ID is required",
+		})
+	}
+
+	err := h.transactionService.CancelTransaction(c.Request().Context(), id)
+	if err != nil {
+		switch err {
+		case domain.ErrTransactionNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Transaction not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrTransactionAlreadyProcessed:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Transaction already processed",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Transaction cancelled successfully",
+	})
 }
 
-// ProcessTransaction processes a transaction
-func (h *TransactionHandler) ProcessTransaction(c echo.Context) error {
-	var req ProcessTransactionRequest
+// ApproveTransaction queues or schedules a transaction awaiting approval
+func (h *TransactionHandler) ApproveTransaction(c echo.Context) error {
+	transaction, err := h.transactionService.ApproveTransaction(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return approvalError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, transaction)
+}
+
+// RejectTransactionRequest represents the request body for rejecting a
+// transaction awaiting approval
+type RejectTransactionRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// RejectTransaction cancels a transaction awaiting approval
+func (h *TransactionHandler) RejectTransaction(c echo.Context) error {
+	var req RejectTransactionRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": "Invalid request body",
@@ -49,59 +753,49 @@ func (h *TransactionHandler) ProcessTransaction(c echo.Context) error {
 		})
 	}
 
-	transactionReq := &domain.TransactionRequest{
-		Type:          req.Type,
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
-		Currency:      req.Currency,
-		Description:   req.Description,
-		Reference:     req.Reference,
-		Metadata:      req.Metadata,
+	transaction, err := h.transactionService.RejectTransaction(c.Request().Context(), c.Param("id"), req.Reason)
+	if err != nil {
+		return approvalError(c, err)
 	}
 
-	transaction, err := h.transactionService.ProcessTransaction(c.Request().Context(), transactionReq)
+	return c.JSON(http.StatusOK, transaction)
+}
+
+// approvalError maps approval and rejection errors to responses
+func approvalError(c echo.Context, err error) error {
+	switch err {
+	case domain.ErrInvalidInput:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "A reason is required",
+		})
+	case domain.ErrTransactionNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Transaction not found",
+		})
+	case domain.ErrNotAwaitingApproval:
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": "Transaction is not awaiting approval",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
+
+// RequeueTransaction publishes a stuck pending transaction, or one failed
+// with a retryable code, to the transaction queue again
+func (h *TransactionHandler) RequeueTransaction(c echo.Context) error {
+	requeue, err := h.transactionService.RequeueTransaction(c.Request().Context(), c.Param("id"))
 	if err != nil {
 		switch err {
-		case domain.ErrInvalidAmount:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid amount",
-			})
-		case domain.ErrInvalidTransactionType:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid transaction type",
-			})
-		case domain.ErrMissingFromAccount:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Missing from account",
-			})
-		case domain.ErrMissingToAccount:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Missing to account",
-			})
-		case domain.ErrMissingAccounts:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Missing from and to accounts",
-			})
-		case domain.ErrSameAccount:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "From and to accounts cannot be the same",
-			})
-		case domain.ErrAccountNotFound:
+		case domain.ErrTransactionNotFound:
 			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Account not found",
-			})
-		case domain.ErrInsufficientFunds:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Insufficient funds",
-			})
-		case domain.ErrAccountInactive:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Account is inactive",
+				"error": "Transaction not found",
 			})
-		case domain.ErrCurrencyMismatch:
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Currency mismatch",
+		case domain.ErrTransactionNotRequeueable:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Only pending transactions, or those failed with a retryable error, can be requeued",
 			})
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -110,11 +804,11 @@ func (h *TransactionHandler) ProcessTransaction(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusAccepted, transaction)
+	return c.JSON(http.StatusOK, requeue)
 }
 
-// GetTransaction retrieves a transaction by ID
-func (h *TransactionHandler) GetTransaction(c echo.Context) error {
+// ReverseTransaction submits a reversal of a completed transaction
+func (h *TransactionHandler) ReverseTransaction(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -122,13 +816,27 @@ func (h *TransactionHandler) GetTransaction(c echo.Context) error {
 		})
 	}
 
-	transaction, err := h.transactionService.GetTransaction(c.Request().Context(), id)
+	reversal, err := h.transactionService.ReverseTransaction(c.Request().Context(), id)
 	if err != nil {
 		switch err {
 		case domain.ErrTransactionNotFound:
 			return c.JSON(http.StatusNotFound, map[string]string{
 				"error": "Transaction not found",
 			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrTransactionNotReversible:
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "Only completed deposits, withdrawals and transfers can be reversed",
+			})
+		case domain.ErrTransactionAlreadyReversed:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Transaction has already been reversed",
+			})
+		case domain.ErrTransactionArchived:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Archived transactions cannot be reversed",
+			})
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
 				"error": "Internal server error",
@@ -136,92 +844,167 @@ func (h *TransactionHandler) GetTransaction(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusOK, transaction)
+	return c.JSON(http.StatusAccepted, reversal)
 }
 
-// GetTransactionHistory retrieves transaction history for an account
-func (h *TransactionHandler) GetTransactionHistory(c echo.Context) error {
-	accountID := c.Param("account_id")
-	if accountID == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Account ID is required",
-		})
+// RefundTransactionRequest represents the request body for a refund. An empty
+// amount refunds everything not yet refunded.
+type RefundTransactionRequest struct {
+	Amount domain.Decimal `json:"amount"`
+}
+
+// RefundTransaction submits a full or partial refund of a completed payment
+func (h *TransactionHandler) RefundTransaction(c echo.Context) error {
+	var req RefundTransactionRequest
+	if c.Request().ContentLength != 0 {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+		}
 	}
 
-	filter := h.parseTransactionFilter(c)
-	transactions, err := h.transactionService.GetTransactionHistory(c.Request().Context(), accountID, filter)
+	refund, err := h.transactionService.RefundTransaction(c.Request().Context(), c.Param("id"), req.Amount)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Internal server error",
-		})
+		if errors.Is(err, domain.ErrInvalidPrecision) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": amountError(err),
+			})
+		}
+
+		switch err {
+		case domain.ErrTransactionNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Transaction not found",
+			})
+		case domain.ErrForbidden:
+			return forbiddenError(c)
+		case domain.ErrInvalidAmount:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid amount",
+			})
+		case domain.ErrTransactionNotRefundable:
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "Only deposits, withdrawals and transfers can be refunded",
+			})
+		case domain.ErrTransactionNotCompleted:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Only completed transactions can be refunded",
+			})
+		case domain.ErrTransactionAlreadyReversed:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Transaction has been reversed",
+			})
+		case domain.ErrTransactionArchived:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Archived transactions cannot be refunded",
+			})
+		case domain.ErrRefundExceedsOriginal:
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "Refund exceeds the amount left to refund",
+			})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Internal server error",
+			})
+		}
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"transactions": transactions,
-		"count":        len(transactions),
-		"account_id":   accountID,
-	})
+	return c.JSON(http.StatusAccepted, refund)
 }
 
-// GetTransactionHistoryByQuery retrieves transaction history using query parameters
-func (h *TransactionHandler) GetTransactionHistoryByQuery(c echo.Context) error {
-	accountID := c.QueryParam("account_id")
-	if accountID == "" {
+// CreateAdjustmentRequest represents the request body for a manual balance
+// correction
+type CreateAdjustmentRequest struct {
+	AccountID            string         `json:"account_id" validate:"required"`
+	Amount               domain.Decimal `json:"amount" validate:"required"`
+	Currency             string         `json:"currency" validate:"required,len=3"`
+	Reason               string         `json:"reason" validate:"required"`
+	OperatorID           string         `json:"operator_id" validate:"required"`
+	Reference            string         `json:"reference"`
+	AllowNegativeBalance bool           `json:"allow_negative_balance,omitempty"`
+}
+
+// CreateAdjustment applies a signed balance correction to an account
+func (h *TransactionHandler) CreateAdjustment(c echo.Context) error {
+	var req CreateAdjustmentRequest
+	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Account ID is required",
+			"error": "Invalid request body",
 		})
 	}
 
-	filter := h.parseTransactionFilter(c)
-	transactions, err := h.transactionService.GetTransactionHistory(c.Request().Context(), accountID, filter)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Internal server error",
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"transactions": transactions,
-		"count":        len(transactions),
-		"account_id":   accountID,
-	})
-}
-
-// GetTransactions retrieves transactions by filter
-func (h *TransactionHandler) GetTransactions(c echo.Context) error {
-	filter := h.parseTransactionFilter(c)
-	transactions, err := h.transactionService.GetTransactionsByFilter(c.Request().Context(), filter)
+	amount, err := req.Amount.Money(req.Currency)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Internal server error",
-		})
-	}
-
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"transactions": transactions,
-		"count":        len(transactions),
-	})
-}
-
-// CancelTransaction cancels a pending transaction
-func (h *TransactionHandler) CancelTransaction(c echo.Context) error {
-	id := c.Param("id")
-	if id == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Transaction ID is required",
+			"error": amountError(err),
 		})
 	}
 
-	err := h.transactionService.CancelTransaction(c.Request().Context(), id)
+	adjustment, err := h.transactionService.AdjustBalance(c.Request().Context(), &domain.AdjustmentRequest{
+		AccountID:            req.AccountID,
+		Amount:               amount,
+		Currency:             req.Currency,
+		Reason:               req.Reason,
+		OperatorID:           req.OperatorID,
+		Reference:            req.Reference,
+		AllowNegativeBalance: req.AllowNegativeBalance,
+	})
 	if err != nil {
+		var referenceErr *domain.DuplicateReferenceError
+		if errors.As(err, &referenceErr) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error":                   "Reference already used by another transaction from this account",
+				"existing_transaction_id": referenceErr.ExistingTransactionID,
+			})
+		}
+
 		switch err {
-		case domain.ErrTransactionNotFound:
+		case domain.ErrInvalidAmount:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Amount must not be zero",
+			})
+		case domain.ErrInvalidAdjustment:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Reason and operator_id are required",
+			})
+		case domain.ErrNegativeBalance:
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+				"error": "Adjustment would take the balance below zero; set allow_negative_balance to override",
+			})
+		case domain.ErrAccountNotFound:
 			return c.JSON(http.StatusNotFound, map[string]string{
-				"error": "Transaction not found",
+				"error": "Account not found",
 			})
-		case domain.ErrTransactionAlreadyProcessed:
+		case domain.ErrAccountInactive:
 			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Transaction already processed",
+				"error": "Account is inactive",
+			})
+		case domain.ErrAccountClosed:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Account is closed",
+			})
+		case domain.ErrCurrencyMismatch:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Currency mismatch",
+			})
+		case domain.ErrUnsupportedCurrency:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Unsupported currency; use an ISO 4217 code such as USD",
+			})
+		case domain.ErrAmountTooLarge:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Amount exceeds the per-transaction maximum of %d %s", domain.ActiveTransactionLimits.MaxAmount, req.Currency),
+			})
+		case domain.ErrConcurrentUpdate:
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Account is being updated concurrently; retry the adjustment",
 			})
 		default:
 			return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -230,14 +1013,43 @@ func (h *TransactionHandler) CancelTransaction(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"message": "Transaction cancelled successfully",
+	return c.JSON(http.StatusCreated, adjustment)
+}
+
+// filterError maps the query parameters a transaction filter could not be
+// parsed from to the format each expects
+type filterError map[string]string
+
+func (e filterError) Error() string {
+	params := make([]string, 0, len(e))
+	for param := range e {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	return "invalid query parameters: " + strings.Join(params, ", ")
+}
+
+// invalidFilterError rejects a request whose filter parameters did not parse,
+// listing each with the format it expects
+func invalidFilterError(c echo.Context, err error) error {
+	var invalid filterError
+	if !errors.As(err, &invalid) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusBadRequest, map[string]interface{}{
+		"error":              "Invalid query parameters",
+		"invalid_parameters": invalid,
 	})
 }
 
-// parseTransactionFilter parses query parameters into a transaction filter
-func (h *TransactionHandler) parseTransactionFilter(c echo.Context) *domain.TransactionFilter {
-	filter := &domain.TransactionFilter{}
+// parseTransactionFilter parses query parameters into a transaction filter,
+// returning a filterError naming every parameter that is malformed or out of
+// range. Parameters it does not know are ignored.
+func (h *TransactionHandler) parseTransactionFilter(c echo.Context) (*domain.TransactionFilter, error) {
+	filter := &domain.TransactionFilter{Limit: 10}
+	invalid := filterError{}
 
 	if accountID := c.QueryParam("account_id"); accountID != "" {
 		filter.AccountID = &accountID
@@ -253,43 +1065,206 @@ func (h *TransactionHandler) parseTransactionFilter(c echo.Context) *domain.Tran
 		filter.Status = &transactionStatus
 	}
 
-	if fromDate := c.QueryParam("from_date"); fromDate != "" {
-		if parsed, err := time.Parse(time.RFC3339, fromDate); err == nil {
-			filter.FromDate = &parsed
+	if failureCode := c.QueryParam("failure_code"); failureCode != "" {
+		code := domain.FailureCode(failureCode)
+		filter.FailureCode = &code
+	}
+
+	if source := c.QueryParam("external_source"); source != "" {
+		filter.ExternalSource = &source
+	}
+
+	if destination := c.QueryParam("external_destination"); destination != "" {
+		filter.ExternalDestination = &destination
+	}
+
+	if currency := c.QueryParam("currency"); currency != "" {
+		if normalized, err := domain.SupportedCurrencies.Normalize(currency); err == nil {
+			filter.Currency = &normalized
+		} else {
+			invalid["currency"] = "a supported ISO 4217 code such as USD"
+		}
+	}
+
+	// A trailing * asks for references starting with the rest
+	if reference := c.QueryParam("reference"); reference != "" {
+		if prefix, ok := strings.CutSuffix(reference, "*"); ok {
+			filter.ReferencePrefix = &prefix
+		} else {
+			filter.Reference = &reference
+		}
+	}
+
+	for param, values := range c.QueryParams() {
+		key, ok := strings.CutPrefix(param, "metadata.")
+		if !ok {
+			continue
+		}
+		if !domain.IsMetadataFilterKey(key) || len(values) != 1 {
+			invalid[param] = "one value for a top-level metadata key of letters, digits, _ or -"
+			continue
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[key] = values[0]
+	}
+	if len(filter.Metadata) > domain.MaxTransactionMetadataFilters {
+		invalid["metadata"] = fmt.Sprintf("at most %d metadata.{key} parameters", domain.MaxTransactionMetadataFilters)
+	}
+
+	// Tags are comma-separated, or given as repeated parameters
+	for param, field := range map[string]*[]string{"tags": &filter.Tags, "tags_any": &filter.AnyTags} {
+		values := c.QueryParams()[param]
+		if len(values) == 0 {
+			continue
+		}
+		tags, err := domain.NormalizeTags(strings.Split(strings.Join(values, ","), ","))
+		if err != nil {
+			invalid[param] = fmt.Sprintf("up to %d comma-separated tags of letters, digits, spaces, - or _", domain.MaxTransactionTags)
+			continue
+		}
+		*field = tags
+	}
+
+	if selfTransfer := c.QueryParam("self_transfer"); selfTransfer != "" {
+		if parsed, err := strconv.ParseBool(selfTransfer); err == nil {
+			filter.SelfTransfer = &parsed
+		} else {
+			invalid["self_transfer"] = "true or false"
+		}
+	}
+
+	if include := c.QueryParam("include_verifications"); include != "" {
+		if parsed, err := strconv.ParseBool(include); err == nil {
+			filter.IncludeVerifications = parsed
+		} else {
+			invalid["include_verifications"] = "true or false"
+		}
+	}
+
+	for param, field := range map[string]**time.Time{"from_date": &filter.FromDate, "to_date": &filter.ToDate} {
+		if value := c.QueryParam(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				invalid[param] = "an RFC 3339 time such as 2024-03-01T00:00:00Z"
+				continue
+			}
+			*field = &parsed
+		}
+	}
+	if filter.FromDate != nil && filter.ToDate != nil && filter.FromDate.After(*filter.ToDate) {
+		invalid["to_date"] = "an RFC 3339 time not before from_date"
+	}
+
+	for _, bound := range []struct {
+		param string
+		lower bool
+		field **domain.Decimal
+	}{
+		{"min_amount", true, &filter.MinAmount},
+		{"max_amount", false, &filter.MaxAmount},
+	} {
+		if value := c.QueryParam(bound.param); value != "" {
+			parsed := domain.Decimal(value)
+			if _, ok := parsed.Bound(0, bound.lower); !ok {
+				invalid[bound.param] = "a decimal amount such as 10.50"
+				continue
+			}
+			*bound.field = &parsed
+		}
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil {
+		if order, ok := filter.MinAmount.Compare(*filter.MaxAmount); ok && order > 0 {
+			invalid["max_amount"] = "a decimal amount not below min_amount"
+		}
+	}
+
+	for param, field := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := c.QueryParam(param); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				invalid[param] = "a non-negative integer"
+				continue
+			}
+			*field = parsed
 		}
 	}
 
-	if toDate := c.QueryParam("to_date"); toDate != "" {
-		if parsed, err := time.Parse(time.RFC3339, toDate); err == nil {
-			filter.ToDate = &parsed
+	if sortBy := c.QueryParam("sort_by"); sortBy != "" {
+		filter.SortBy = domain.TransactionSortField(sortBy)
+		if !filter.SortBy.IsValid() {
+			invalid["sort_by"] = fmt.Sprintf("%s, %s or %s", domain.TransactionSortCreatedAt, domain.TransactionSortAmount, domain.TransactionSortProcessedAt)
 		}
 	}
 
-	if minAmount := c.QueryParam("min_amount"); minAmount != "" {
-		if parsed, err := strconv.ParseFloat(minAmount, 64); err == nil {
-			filter.MinAmount = &parsed
+	if sortOrder := c.QueryParam("sort_order"); sortOrder != "" {
+		filter.SortOrder = domain.SortOrder(sortOrder)
+		if !filter.SortOrder.IsValid() {
+			invalid["sort_order"] = fmt.Sprintf("%s or %s", domain.SortAscending, domain.SortDescending)
 		}
 	}
 
-	if maxAmount := c.QueryParam("max_amount"); maxAmount != "" {
-		if parsed, err := strconv.ParseFloat(maxAmount, 64); err == nil {
-			filter.MaxAmount = &parsed
+	if len(invalid) > 0 {
+		return nil, invalid
+	}
+	return filter, nil
+}
+
+// parseTransactionProjection parses the fields a listing returns: only those
+// in ?fields=, or all but those in ?exclude=, which defaults to
+// domain.DefaultExcludedTransactionFields. An empty ?exclude= returns every
+// field.
+func parseTransactionProjection(c echo.Context) (*domain.TransactionProjection, error) {
+	invalid := filterError{}
+	fieldsExpected := fmt.Sprintf("comma-separated fields among %s", strings.Join(domain.TransactionFieldNames(), ", "))
+	projection := domain.DefaultTransactionProjection()
+
+	if value := c.QueryParam("fields"); value != "" {
+		fields, unknown := domain.ParseTransactionFields(value)
+		if len(unknown) > 0 || len(fields) == 0 {
+			invalid["fields"] = fieldsExpected
 		}
+		projection = &domain.TransactionProjection{Include: fields}
 	}
 
-	if limit := c.QueryParam("limit"); limit != "" {
-		if parsed, err := strconv.Atoi(limit); err == nil {
-			filter.Limit = parsed
+	if values, ok := c.QueryParams()["exclude"]; ok {
+		fields, unknown := domain.ParseTransactionFields(strings.Join(values, ","))
+		switch {
+		case len(projection.Include) > 0 || invalid["fields"] != "":
+			invalid["exclude"] = "not given with fields"
+		case len(unknown) > 0:
+			invalid["exclude"] = fieldsExpected
+		default:
+			projection.Exclude = fields
 		}
-	} else {
-		filter.Limit = 10
 	}
 
-	if offset := c.QueryParam("offset"); offset != "" {
-		if parsed, err := strconv.Atoi(offset); err == nil {
-			filter.Offset = parsed
+	if len(invalid) > 0 {
+		return nil, invalid
+	}
+	return projection, nil
+}
+
+// amountError describes why a decimal amount could not be parsed
+func amountError(err error) string {
+	var precisionErr *domain.PrecisionError
+	if errors.As(err, &precisionErr) {
+		if precisionErr.Exponent == 0 {
+			return fmt.Sprintf("%s amounts cannot have decimal places", precisionErr.Currency)
 		}
+		return fmt.Sprintf("%s amounts allow at most %d decimal places", precisionErr.Currency, precisionErr.Exponent)
 	}
+	return "Invalid amount"
+}
 
-	return filter
+// quotaExceededError reports a user's transaction quota reached, with when to
+// retry
+func quotaExceededError(c echo.Context, quotaErr *domain.QuotaExceededError) error {
+	retryAfter := int(math.Ceil(quotaErr.RetryAfter.Seconds()))
+	c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+		"error":       fmt.Sprintf("Transaction quota of %d per %s reached", quotaErr.Limit, quotaErr.Window),
+		"retry_after": retryAfter,
+	})
 }
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// UserVerificationHandler handles user verification HTTP requests
+type UserVerificationHandler struct {
+	verificationService domain.UserVerificationService
+}
+
+// NewUserVerificationHandler creates a new user verification handler
+func NewUserVerificationHandler(verificationService domain.UserVerificationService) *UserVerificationHandler {
+	return &UserVerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// UpdateUserVerificationRequest represents the request body for changing a
+// user's verification level
+type UpdateUserVerificationRequest struct {
+	Level domain.VerificationLevel `json:"level" validate:"required"`
+}
+
+// GetUserVerification retrieves a user's verification level
+func (h *UserVerificationHandler) GetUserVerification(c echo.Context) error {
+	verification, err := h.verificationService.GetVerification(c.Request().Context(), c.Param("user_id"))
+	if err != nil {
+		return userVerificationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, verification)
+}
+
+// UpdateUserVerification moves a user to a verification level
+func (h *UserVerificationHandler) UpdateUserVerification(c echo.Context) error {
+	var req UpdateUserVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	verification, err := h.verificationService.SetVerification(c.Request().Context(), c.Param("user_id"), req.Level)
+	if err != nil {
+		return userVerificationError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, verification)
+}
+
+// userVerificationError maps user verification service errors to responses
+func userVerificationError(c echo.Context, err error) error {
+	switch err {
+	case domain.ErrInvalidVerificationLevel:
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":  "Invalid verification level",
+			"levels": domain.VerificationLevels,
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
+
+// verificationRequiredError explains a transaction refused because its user
+// is not verified, and what they can do about it
+func verificationRequiredError(c echo.Context, err error) error {
+	var verificationErr *domain.VerificationRequiredError
+	if !errors.As(err, &verificationErr) || verificationErr.TransactionType != domain.TransactionTypeDeposit {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Withdrawals and transfers are only available to verified users; complete identity verification to enable them",
+			"code":  string(domain.FailureCodeVerificationRequired),
+		})
+	}
+
+	return c.JSON(http.StatusForbidden, map[string]string{
+		"error":         fmt.Sprintf("Unverified users may deposit up to %s %s in total; complete identity verification to deposit more", verificationErr.DepositLimit.Format(verificationErr.Currency), verificationErr.Currency),
+		"code":          string(domain.FailureCodeVerificationRequired),
+		"deposit_limit": verificationErr.DepositLimit.Format(verificationErr.Currency),
+		"deposited":     verificationErr.Deposited.Format(verificationErr.Currency),
+		"currency":      verificationErr.Currency,
+	})
+}
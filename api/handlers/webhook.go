@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// WebhookHandler handles webhook subscription HTTP requests
+type WebhookHandler struct {
+	webhookService domain.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService domain.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// RegisterWebhookRequest represents the request body for registering a
+// webhook
+type RegisterWebhookRequest struct {
+	URL        string                    `json:"url" validate:"required"`
+	EventTypes []domain.NotificationType `json:"event_types" validate:"required,min=1"`
+}
+
+// RegisterWebhook subscribes a URL to events. The response is the only one
+// that shows the subscription's signing secret.
+func (h *WebhookHandler) RegisterWebhook(c echo.Context) error {
+	var req RegisterWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	subscription, err := h.webhookService.RegisterWebhook(c.Request().Context(), req.URL, req.EventTypes)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, struct {
+		*domain.WebhookSubscription
+		Secret string `json:"secret"`
+	}{subscription, subscription.Secret})
+}
+
+// GetWebhook retrieves a webhook subscription
+func (h *WebhookHandler) GetWebhook(c echo.Context) error {
+	subscription, err := h.webhookService.GetWebhook(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, subscription)
+}
+
+// DeleteWebhook stops a subscription receiving events
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	if err := h.webhookService.DeleteWebhook(c.Request().Context(), c.Param("id")); err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetDeliveries lists a subscription's latest deliveries with their attempts
+// and last status
+func (h *WebhookHandler) GetDeliveries(c echo.Context) error {
+	limit := 0
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid limit",
+			})
+		}
+		limit = parsed
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request().Context(), c.Param("id"), limit)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// webhookError maps webhook service errors to responses
+func webhookError(c echo.Context, err error) error {
+	switch err {
+	case domain.ErrWebhookNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Webhook not found",
+		})
+	case domain.ErrInvalidWebhook:
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{
+			"error":       "Webhook needs an http or https URL and known event types",
+			"event_types": domain.WebhookEventTypes,
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Internal server error",
+		})
+	}
+}
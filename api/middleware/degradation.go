@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"banking-ledger/internal/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+// latencySmoothing is the weight given to each new latency observation
+const latencySmoothing = 0.2
+
+// DegradationSignals holds the load signals the controller reacts to
+type DegradationSignals struct {
+	PoolSaturation float64       `json:"pool_saturation"`
+	Latency        time.Duration `json:"latency"`
+}
+
+// DegradationState represents the current degradation state
+type DegradationState struct {
+	Degraded    bool               `json:"degraded"`
+	Since       *time.Time         `json:"since,omitempty"`
+	Signals     DegradationSignals `json:"signals"`
+	ShedRoutes  []string           `json:"shed_routes"`
+	ShedCount   int64              `json:"shed_count"`
+	Transitions int64              `json:"transitions"`
+}
+
+// DegradationController decides when expensive routes should shed load.
+// It enters degraded mode when either signal crosses its high threshold and
+// only leaves once both signals are back under their low thresholds, so a
+// signal hovering around a single threshold cannot make it flap.
+type DegradationController struct {
+	mu          sync.RWMutex
+	cfg         config.DegradationConfig
+	shedRoutes  map[string]bool
+	degraded    bool
+	since       time.Time
+	signals     DegradationSignals
+	latencyEWMA float64
+	shedCount   int64
+	transitions int64
+}
+
+// NewDegradationController creates a new degradation controller
+func NewDegradationController(cfg config.DegradationConfig) *DegradationController {
+	shedRoutes := make(map[string]bool, len(cfg.ShedRoutes))
+	for _, route := range cfg.ShedRoutes {
+		shedRoutes[normalizeRoute(route)] = true
+	}
+
+	return &DegradationController{
+		cfg:        cfg,
+		shedRoutes: shedRoutes,
+	}
+}
+
+// Observe feeds a new set of signals into the controller and returns whether
+// it is degraded afterwards
+func (dc *DegradationController) Observe(signals DegradationSignals) bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	dc.signals = signals
+
+	if !dc.degraded {
+		if signals.PoolSaturation >= dc.cfg.PoolSaturationHigh || signals.Latency >= dc.cfg.LatencyHigh {
+			dc.degraded = true
+			dc.since = time.Now()
+			dc.transitions++
+		}
+		return dc.degraded
+	}
+
+	if signals.PoolSaturation <= dc.cfg.PoolSaturationLow && signals.Latency <= dc.cfg.LatencyLow {
+		dc.degraded = false
+		dc.since = time.Time{}
+		dc.transitions++
+	}
+
+	return dc.degraded
+}
+
+// RecordLatency folds a request latency into the smoothed latency signal
+func (dc *DegradationController) RecordLatency(latency time.Duration) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if dc.latencyEWMA == 0 {
+		dc.latencyEWMA = float64(latency)
+		return
+	}
+	dc.latencyEWMA = latencySmoothing*float64(latency) + (1-latencySmoothing)*dc.latencyEWMA
+}
+
+// Latency returns the smoothed request latency
+func (dc *DegradationController) Latency() time.Duration {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return time.Duration(dc.latencyEWMA)
+}
+
+// IsDegraded reports whether the controller is currently shedding load
+func (dc *DegradationController) IsDegraded() bool {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return dc.degraded
+}
+
+// ShouldShed reports whether a request for the given method and route path
+// should be rejected in the current state
+func (dc *DegradationController) ShouldShed(method, path string) bool {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return dc.degraded && dc.shedRoutes[normalizeRoute(method+" "+path)]
+}
+
+// State returns a snapshot of the current degradation state
+func (dc *DegradationController) State() DegradationState {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+
+	state := DegradationState{
+		Degraded:    dc.degraded,
+		Signals:     dc.signals,
+		ShedRoutes:  dc.cfg.ShedRoutes,
+		ShedCount:   dc.shedCount,
+		Transitions: dc.transitions,
+	}
+	if dc.degraded {
+		since := dc.since
+		state.Since = &since
+	}
+
+	return state
+}
+
+// Monitor samples the database pool and the smoothed request latency on every
+// interval until the context is cancelled
+func (dc *DegradationController) Monitor(ctx context.Context, stats func() sql.DBStats) {
+	interval := dc.cfg.SampleInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			dc.Observe(DegradationSignals{
+				PoolSaturation: poolSaturation(stats()),
+				Latency:        dc.Latency(),
+			})
+		}
+	}
+}
+
+func (dc *DegradationController) recordShed() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.shedCount++
+}
+
+// Degradation returns a middleware that rejects shedable routes with 503 while
+// the controller is degraded and feeds the latency of served requests back
+// into the controller
+func Degradation(dc *DegradationController) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if dc.ShouldShed(c.Request().Method, c.Path()) {
+				dc.recordShed()
+				retryAfter := int(dc.cfg.RetryAfter.Seconds())
+				c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				return c.JSON(http.StatusServiceUnavailable, map[string]interface{}{
+					"error":       "Service temporarily degraded, please retry later",
+					"retry_after": retryAfter,
+				})
+			}
+
+			start := time.Now()
+			err := next(c)
+			dc.RecordLatency(time.Since(start))
+			return err
+		}
+	}
+}
+
+// poolSaturation returns the share of the connection pool currently in use
+func poolSaturation(stats sql.DBStats) float64 {
+	if stats.MaxOpenConnections <= 0 {
+		return 0
+	}
+	return float64(stats.InUse) / float64(stats.MaxOpenConnections)
+}
+
+// normalizeRoute canonicalises a "METHOD /path" route key
+func normalizeRoute(route string) string {
+	parts := strings.Fields(route)
+	if len(parts) != 2 {
+		return strings.TrimSpace(route)
+	}
+	return strings.ToUpper(parts[0]) + " " + strings.TrimSuffix(parts[1], "/")
+}
@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"banking-ledger/internal/domain"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
 )
 
 // CORS returns a CORS middleware
@@ -13,7 +19,7 @@ func CORS() echo.MiddlewareFunc {
 	return middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "Idempotency-Key", UserIDHeader},
 	})
 }
 
@@ -29,13 +35,33 @@ func Recover() echo.MiddlewareFunc {
 	return middleware.Recover()
 }
 
-// Timeout returns a timeout middleware
-func Timeout(timeout time.Duration) echo.MiddlewareFunc {
+// Timeout returns a timeout middleware. It buffers each response until the
+// handler returns, so routes that stream are named in streamingPaths and,
+// like requests for NDJSON, left to the server's write timeout.
+func Timeout(timeout time.Duration, streamingPaths ...string) echo.MiddlewareFunc {
 	return middleware.TimeoutWithConfig(middleware.TimeoutConfig{
 		Timeout: timeout,
+		Skipper: func(c echo.Context) bool {
+			return slices.Contains(streamingPaths, c.Path()) || AcceptsNDJSON(c)
+		},
 	})
 }
 
+// NDJSONContentType is the media type of a response streamed as one JSON
+// value per line
+const NDJSONContentType = "application/x-ndjson"
+
+// AcceptsNDJSON reports whether the request's Accept header lists NDJSON
+func AcceptsNDJSON(c echo.Context) bool {
+	for _, accepted := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+		mediaType, _, _ := strings.Cut(accepted, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), NDJSONContentType) {
+			return true
+		}
+	}
+	return false
+}
+
 // RateLimiter returns a rate limiter middleware
 func RateLimiter() echo.MiddlewareFunc {
 	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
@@ -43,21 +69,96 @@ func RateLimiter() echo.MiddlewareFunc {
 	})
 }
 
+// RouteRateLimiter returns a rate limiter middleware with a custom rate, for
+// routes that need a tighter limit than the global one
+func RouteRateLimiter(requestsPerSecond float64) echo.MiddlewareFunc {
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStore(rate.Limit(requestsPerSecond)),
+	})
+}
+
+// AdminAuth returns a middleware that only lets through requests carrying the
+// configured admin token as a bearer token. An empty token disables the admin
+// API entirely.
+func AdminAuth(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if token == "" {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "Admin API is disabled",
+				})
+			}
+
+			provided := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "Admin credentials required",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// UserIDHeader names the user a request is made by. The gateway in front of
+// the API sets it once it has authenticated the user.
+const UserIDHeader = "X-User-ID"
+
+// Authenticate sets the caller of each request: an admin for a request
+// carrying the admin token as a bearer token, otherwise the user named by
+// UserIDHeader. A request with neither is refused if required is set and is
+// left unscoped otherwise.
+func Authenticate(adminToken string, required bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var principal *domain.Principal
+			provided := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if adminToken != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(adminToken)) == 1 {
+				principal = &domain.Principal{Admin: true}
+			} else if userID := c.Request().Header.Get(UserIDHeader); userID != "" {
+				principal = &domain.Principal{UserID: userID}
+			}
+
+			if principal == nil {
+				if required {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error": "Authentication required",
+					})
+				}
+				return next(c)
+			}
+
+			ctx := domain.ContextWithPrincipal(c.Request().Context(), principal)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
 // RequestID returns a request ID middleware
 func RequestID() echo.MiddlewareFunc {
 	return middleware.RequestID()
 }
 
 // HealthCheck is a simple health check middleware
-func HealthCheck() echo.MiddlewareFunc {
+func HealthCheck(degradation *DegradationController) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			if c.Request().URL.Path == "/health" {
-				return c.JSON(http.StatusOK, map[string]interface{}{
+				response := map[string]interface{}{
 					"status":    "healthy",
 					"timestamp": time.Now(),
 					"service":   "banking-ledger",
-				})
+				}
+				if degradation != nil {
+					state := degradation.State()
+					if state.Degraded {
+						response["status"] = "degraded"
+					}
+					response["degradation"] = state
+				}
+				return c.JSON(http.StatusOK, response)
 			}
 			return next(c)
 		}
@@ -20,12 +20,50 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	return cv.validator.Struct(i)
 }
 
+// Dependencies holds the services and settings the routes are wired to
+type Dependencies struct {
+	AccountService       domain.AccountService
+	TransactionService   domain.TransactionService
+	DiagnosticsService   domain.DiagnosticsService
+	VerificationService  domain.AccountVerificationService
+	SettlementService    domain.SettlementService
+	ChangeFeedService    domain.ChangeFeedService
+	LedgerService        domain.AccountLedgerService
+	StatementService     domain.LedgerService
+	HoldService          domain.HoldService
+	StandingOrderService domain.StandingOrderService
+	// ReconciliationService serves the reports of the reconciler
+	ReconciliationService domain.ReconciliationService
+	// SnapshotService serves balances as of a past day
+	SnapshotService domain.BalanceSnapshotService
+	// MonthlyStatementService serves the statements generated each month
+	MonthlyStatementService domain.MonthlyStatementService
+	// WebhookService manages webhook subscriptions and their delivery logs
+	WebhookService domain.WebhookService
+	// ExportService queues transaction exports written in the background
+	ExportService domain.ExportJobService
+	// DeadLetterService inspects and replays the transaction queue's dead
+	// letters
+	DeadLetterService domain.DeadLetterService
+	// UserVerificationService manages the verification levels that gate
+	// unverified users' transactions
+	UserVerificationService domain.UserVerificationService
+
+	// Degradation enables load shedding of expensive routes when set
+	Degradation *middleware.DegradationController
+
+	// AdminToken is the bearer token required by the admin API
+	AdminToken string
+
+	// AdminRateLimit is the per-client request rate allowed on admin routes
+	AdminRateLimit float64
+
+	// AuthRequired refuses requests that identify neither a user nor an admin
+	AuthRequired bool
+}
+
 // SetupRoutes sets up all application routes
-func SetupRoutes(
-	e *echo.Echo,
-	accountService domain.AccountService,
-	transactionService domain.TransactionService,
-) {
+func SetupRoutes(e *echo.Echo, deps Dependencies) {
 	// Set custom validator
 	e.Validator = &CustomValidator{validator: validator.New()}
 
@@ -35,26 +73,58 @@ func SetupRoutes(
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 	e.Use(middleware.RateLimiter())
-	e.Use(middleware.Timeout(30 * time.Second))
-	e.Use(middleware.HealthCheck())
+	e.Use(middleware.Timeout(30*time.Second, "/api/v1/accounts/:account_id/transactions/export", "/api/v1/exports/:id/download"))
+	e.Use(middleware.HealthCheck(deps.Degradation))
+	if deps.Degradation != nil {
+		e.Use(middleware.Degradation(deps.Degradation))
+	}
 
 	// Initialize handlers
-	accountHandler := handlers.NewAccountHandler(accountService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	accountHandler := handlers.NewAccountHandler(deps.AccountService, deps.SnapshotService)
+	transactionHandler := handlers.NewTransactionHandler(deps.TransactionService)
+	verificationHandler := handlers.NewAccountVerificationHandler(deps.VerificationService)
+	adminHandler := handlers.NewAdminHandler(deps.DiagnosticsService, deps.ReconciliationService)
+	settlementHandler := handlers.NewSettlementHandler(deps.SettlementService)
+	changeFeedHandler := handlers.NewChangeFeedHandler(deps.ChangeFeedService)
+	ledgerHandler := handlers.NewLedgerHandler(deps.LedgerService, deps.StatementService)
+	statementHandler := handlers.NewStatementHandler(deps.MonthlyStatementService)
+	holdHandler := handlers.NewHoldHandler(deps.HoldService)
+	userVerificationHandler := handlers.NewUserVerificationHandler(deps.UserVerificationService)
+	standingOrderHandler := handlers.NewStandingOrderHandler(deps.StandingOrderService)
+	webhookHandler := handlers.NewWebhookHandler(deps.WebhookService)
+	exportJobHandler := handlers.NewExportJobHandler(deps.ExportService)
+	deadLetterHandler := handlers.NewDeadLetterHandler(deps.DeadLetterService)
 
 	// API version 1
-	v1 := e.Group("/api/v1")
+	v1 := e.Group("/api/v1", middleware.Authenticate(deps.AdminToken, deps.AuthRequired))
 
 	// Account routes
 	accounts := v1.Group("/accounts")
 	{
 		accounts.POST("", accountHandler.CreateAccount)
 		accounts.GET("", accountHandler.ListAccounts)
+		accounts.POST("/bulk", accountHandler.BulkCreateAccounts, middleware.AdminAuth(deps.AdminToken))
 		accounts.GET("/search", accountHandler.GetAccountsByUser)
 		accounts.GET("/:id", accountHandler.GetAccount)
+		accounts.PATCH("/:id", accountHandler.UpdateAccount)
 		accounts.GET("/:id/balance", accountHandler.GetAccountBalance)
 		accounts.GET("/:id/summary", accountHandler.GetAccountSummary)
+		accounts.GET("/:id/ledger", ledgerHandler.GetAccountLedger)
+		accounts.GET("/:id/statement", ledgerHandler.GetAccountStatement)
+		accounts.GET("/:id/statements", statementHandler.ListStatements)
+		accounts.GET("/:id/statements/:statement_id", statementHandler.GetStatement)
+		accounts.GET("/:id/events", accountHandler.GetAccountEvents)
 		accounts.PATCH("/:id/deactivate", accountHandler.DeactivateAccount)
+		accounts.PATCH("/:id/activate", accountHandler.ActivateAccount)
+		accounts.PATCH("/:id/close", accountHandler.CloseAccount)
+		accounts.PATCH("/:id/freeze", accountHandler.FreezeAccount, middleware.AdminAuth(deps.AdminToken))
+		accounts.PATCH("/:id/unfreeze", accountHandler.UnfreezeAccount, middleware.AdminAuth(deps.AdminToken))
+		accounts.PATCH("/:id/status", accountHandler.UpdateAccountStatus)
+		accounts.PATCH("/:id/overdraft", accountHandler.UpdateOverdraftLimit, middleware.AdminAuth(deps.AdminToken))
+		accounts.PATCH("/:id/minimum-balance", accountHandler.UpdateMinimumBalance, middleware.AdminAuth(deps.AdminToken))
+		accounts.PATCH("/:id/limits", accountHandler.UpdateLimits, middleware.AdminAuth(deps.AdminToken))
+		accounts.POST("/:id/micro-deposits", verificationHandler.StartMicroDeposits)
+		accounts.POST("/:id/verify", verificationHandler.VerifyMicroDeposits)
 	}
 
 	// Transaction routes
@@ -63,12 +133,96 @@ func SetupRoutes(
 		transactions.POST("", transactionHandler.ProcessTransaction)
 		transactions.GET("", transactionHandler.GetTransactions)
 		transactions.GET("/history", transactionHandler.GetTransactionHistoryByQuery)
+		transactions.GET("/search", transactionHandler.SearchTransactions, middleware.AdminAuth(deps.AdminToken))
+		transactions.POST("/lookup", transactionHandler.LookupTransactions)
+		transactions.GET("/by-reference/:reference", transactionHandler.GetTransactionsByReference)
+		transactions.POST("/batch", transactionHandler.SubmitBatch)
+		transactions.GET("/batches/:batch_id", transactionHandler.GetBatch)
 		transactions.GET("/:id", transactionHandler.GetTransaction)
+		transactions.GET("/:id/status", transactionHandler.GetTransactionStatus)
 		transactions.PATCH("/:id/cancel", transactionHandler.CancelTransaction)
+		transactions.PATCH("/:id/tags", transactionHandler.UpdateTransactionTags)
+		transactions.POST("/:id/approve", transactionHandler.ApproveTransaction, middleware.AdminAuth(deps.AdminToken))
+		transactions.POST("/:id/reject", transactionHandler.RejectTransaction, middleware.AdminAuth(deps.AdminToken))
+		transactions.POST("/:id/reverse", transactionHandler.ReverseTransaction)
+		transactions.POST("/:id/refund", transactionHandler.RefundTransaction)
+		transactions.GET("/:id/receipt", transactionHandler.GetTransactionReceipt)
+	}
+
+	// Receipt routes
+	v1.POST("/receipts/verify", transactionHandler.VerifyReceipt)
+
+	// Hold routes
+	holds := v1.Group("/holds")
+	{
+		holds.POST("", holdHandler.PlaceHold)
+		holds.GET("/:id", holdHandler.GetHold)
+		holds.POST("/:id/capture", holdHandler.CaptureHold)
+		holds.POST("/:id/release", holdHandler.ReleaseHold)
+	}
+
+	// Standing order routes
+	standingOrders := v1.Group("/standing-orders")
+	{
+		standingOrders.POST("", standingOrderHandler.CreateStandingOrder)
+		standingOrders.GET("", standingOrderHandler.ListStandingOrders)
+		standingOrders.GET("/:id", standingOrderHandler.GetStandingOrder)
+		standingOrders.PATCH("/:id", standingOrderHandler.UpdateStandingOrder)
+		standingOrders.DELETE("/:id", standingOrderHandler.CancelStandingOrder)
+	}
+
+	// Webhook routes, which see every user's events and so need the admin
+	// token
+	webhooks := v1.Group("/webhooks", middleware.AdminAuth(deps.AdminToken))
+	{
+		webhooks.POST("", webhookHandler.RegisterWebhook)
+		webhooks.GET("/:id", webhookHandler.GetWebhook)
+		webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+		webhooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+	}
+
+	// Export job routes
+	exports := v1.Group("/exports")
+	{
+		exports.POST("", exportJobHandler.CreateExportJob)
+		exports.GET("/:id", exportJobHandler.GetExportJob)
+		exports.PATCH("/:id/cancel", exportJobHandler.CancelExportJob)
+		exports.GET("/:id/download", exportJobHandler.DownloadExport)
 	}
 
 	// Account transaction routes
 	v1.GET("/accounts/:account_id/transactions", transactionHandler.GetTransactionHistory)
+	v1.GET("/accounts/:account_id/transactions/export", transactionHandler.ExportAccountTransactions)
+
+	// User routes
+	v1.GET("/users/:user_id/summary", accountHandler.GetUserSummary)
+
+	// Admin routes
+	adminRateLimit := deps.AdminRateLimit
+	if adminRateLimit <= 0 {
+		adminRateLimit = 5
+	}
+	admin := v1.Group("/admin", middleware.AdminAuth(deps.AdminToken))
+	{
+		admin.GET("/transactions/:id/diagnostics", adminHandler.GetTransactionDiagnostics, middleware.RouteRateLimiter(adminRateLimit))
+		admin.POST("/transactions/:id/requeue", transactionHandler.RequeueTransaction)
+		admin.GET("/dlq", deadLetterHandler.ListDeadLetters)
+		admin.POST("/dlq/requeue", deadLetterHandler.RequeueDeadLetters)
+		admin.GET("/changes", changeFeedHandler.GetChanges, middleware.RouteRateLimiter(adminRateLimit))
+		admin.GET("/reconciliation", adminHandler.GetReconciliationReport)
+		admin.GET("/invariants", adminHandler.GetInvariants, middleware.RouteRateLimiter(adminRateLimit))
+		admin.POST("/accounts", accountHandler.CreateInternalAccount)
+		admin.POST("/adjustments", transactionHandler.CreateAdjustment)
+		admin.GET("/users", accountHandler.ListUsers)
+		admin.GET("/users/:user_id/verification", userVerificationHandler.GetUserVerification)
+		admin.PATCH("/users/:user_id/verification", userVerificationHandler.UpdateUserVerification)
+		admin.POST("/settlement-groups", settlementHandler.CreateSettlementGroup)
+		admin.GET("/settlement-groups", settlementHandler.ListSettlementGroups)
+		admin.GET("/settlement-groups/:id", settlementHandler.GetSettlementGroup)
+		admin.PUT("/settlement-groups/:id", settlementHandler.UpdateSettlementGroup)
+		admin.GET("/settlement-groups/:id/net", settlementHandler.GetSettlementNet)
+		admin.POST("/settlement-groups/:id/settle", settlementHandler.SettleGroup)
+	}
 
 	// API documentation endpoint
 	v1.GET("/docs", func(c echo.Context) error {
@@ -77,21 +231,98 @@ func SetupRoutes(
 			"version": "1.0.0",
 			"endpoints": map[string]interface{}{
 				"accounts": map[string]interface{}{
-					"POST /api/v1/accounts":                          "Create account",
-					"GET /api/v1/accounts":                           "List accounts",
-					"GET /api/v1/accounts/search?user_id={}":         "Get accounts by user",
-					"GET /api/v1/accounts/{id}":                      "Get account",
-					"GET /api/v1/accounts/{id}/balance":              "Get account balance",
-					"GET /api/v1/accounts/{id}/summary":              "Get account summary",
-					"PATCH /api/v1/accounts/{id}/deactivate":         "Deactivate account",
-					"GET /api/v1/accounts/{account_id}/transactions": "Get account transactions",
+					"POST /api/v1/accounts":                 "Create checking or savings account",
+					"POST /api/v1/accounts/bulk?dry_run={}": "Open many accounts at once, skipping duplicates and invalid ones (admin token required)",
+					"GET /api/v1/accounts?type={}&status={}&currency={}&user_id_prefix={}&label={}&created_from={}&created_to={}&include_closed={}": "List accounts matching every filter given; closed ones only with include_closed=true or status=closed",
+					"GET /api/v1/accounts/search?user_id={}&type={}&label={}":                                                                       "Get accounts by user, optionally of one type or with a label",
+					"GET /api/v1/accounts/{id}":                                                     "Get account",
+					"GET /api/v1/accounts/{id}/balance?as_of={}":                                    "Get account balance, or the balance at the close of a day",
+					"GET /api/v1/accounts/{id}/summary?from_date={}&to_date={}":                     "Get account transaction counts and per-type totals, over all time by default",
+					"GET /api/v1/accounts/{id}/ledger":                                              "Get account ledger entries with running balances",
+					"GET /api/v1/accounts/{id}/statement?from={}&to={}":                             "Get account statement with opening, running and closing balances",
+					"GET /api/v1/accounts/{id}/statements?limit={}&offset={}":                       "List the account's generated monthly statements, newest first",
+					"GET /api/v1/accounts/{id}/statements/{statement_id}":                           "Get a generated monthly statement with its totals and lines",
+					"GET /api/v1/accounts/{id}/events?limit={}&offset={}":                           "Get account status and limit changes, newest first",
+					"PATCH /api/v1/accounts/{id}/deactivate":                                        "Deactivate account; ?force=true even with outgoing transactions pending",
+					"PATCH /api/v1/accounts/{id}/activate":                                          "Reactivate an inactive or frozen account",
+					"PATCH /api/v1/accounts/{id}/close":                                             "Close a zero-balance account with no transactions in flight",
+					"PATCH /api/v1/accounts/{id}/freeze":                                            "Block debits but not credits, with a reason (admin token required)",
+					"PATCH /api/v1/accounts/{id}/unfreeze":                                          "Lift a freeze, with a reason (admin token required)",
+					"PATCH /api/v1/accounts/{id}/status":                                            "Change account status (active, frozen, inactive, closed)",
+					"PATCH /api/v1/accounts/{id}/overdraft":                                         "Set account overdraft limit (admin token required)",
+					"PATCH /api/v1/accounts/{id}/minimum-balance":                                   "Set account minimum balance (admin token required)",
+					"POST /api/v1/accounts/{id}/micro-deposits":                                     "Send verification micro-deposits",
+					"POST /api/v1/accounts/{id}/verify":                                             "Confirm verification micro-deposits",
+					"GET /api/v1/accounts/{account_id}/transactions":                                "Get account transactions; streamed as NDJSON with Accept: application/x-ndjson",
+					"GET /api/v1/accounts/{account_id}/transactions/export?format={}&from={}&to={}": "Stream account transactions, oldest first, as csv or json-lines",
+				},
+				"users": map[string]interface{}{
+					"GET /api/v1/users/{user_id}/summary": "Get user's accounts by currency with last transaction and this month's deposits and withdrawals",
 				},
 				"transactions": map[string]interface{}{
-					"POST /api/v1/transactions":                      "Process transaction",
-					"GET /api/v1/transactions":                       "Get transactions",
-					"GET /api/v1/transactions/history?account_id={}": "Get transaction history by query",
-					"GET /api/v1/transactions/{id}":                  "Get transaction",
-					"PATCH /api/v1/transactions/{id}/cancel":         "Cancel transaction",
+					"POST /api/v1/transactions":                          "Process transaction, optionally under a client-chosen UUID id",
+					"GET /api/v1/transactions?sort_by={}&sort_order={}":  "Get transactions, paged and sorted by created_at, amount or processed_at, filtered by tags or tags_any, without metadata or error_message unless selected by fields or exclude; streamed as NDJSON with Accept: application/x-ndjson",
+					"GET /api/v1/transactions/history?account_id={}":     "Get transaction history by query",
+					"GET /api/v1/transactions/search?q={}&account_id={}": "Search transaction descriptions and references, most relevant first (admin token required)",
+					"GET /api/v1/transactions/by-reference/{reference}":  "Get transactions by reference",
+					"POST /api/v1/transactions/batch":                    "Submit batch of transactions applied together",
+					"GET /api/v1/transactions/batches/{batch_id}":        "Get batch and per-leg status",
+					"POST /api/v1/transactions/lookup":                   "Get up to 500 transactions by ID, in the order asked for, with the IDs not found",
+					"GET /api/v1/transactions/{id}":                      "Get transaction",
+					"GET /api/v1/transactions/{id}/status":               "Get transaction status, with approximate queue position and wait while pending",
+					"PATCH /api/v1/transactions/{id}/cancel":             "Cancel transaction",
+					"PATCH /api/v1/transactions/{id}/tags":               "Replace transaction tags",
+					"POST /api/v1/transactions/{id}/reverse":             "Reverse completed transaction",
+					"POST /api/v1/transactions/{id}/refund":              "Refund all or part of completed transaction",
+					"GET /api/v1/transactions/{id}/receipt":              "Get signed receipt of completed transaction",
+				},
+				"receipts": map[string]interface{}{
+					"POST /api/v1/receipts/verify": "Check a receipt against its verification hash",
+				},
+				"holds": map[string]interface{}{
+					"POST /api/v1/holds":              "Place hold on account funds",
+					"GET /api/v1/holds/{id}":          "Get hold",
+					"POST /api/v1/holds/{id}/capture": "Capture all or part of a hold",
+					"POST /api/v1/holds/{id}/release": "Release hold",
+				},
+				"standing_orders": map[string]interface{}{
+					"POST /api/v1/standing-orders":              "Create recurring transfer",
+					"GET /api/v1/standing-orders?account_id={}": "List standing orders",
+					"GET /api/v1/standing-orders/{id}":          "Get standing order",
+					"PATCH /api/v1/standing-orders/{id}":        "Change amount or description, or pause or resume",
+					"DELETE /api/v1/standing-orders/{id}":       "Cancel standing order",
+				},
+				"webhooks": map[string]interface{}{
+					"POST /api/v1/webhooks":                         "Register webhook URL for event types (admin token required)",
+					"GET /api/v1/webhooks/{id}":                     "Get webhook subscription",
+					"DELETE /api/v1/webhooks/{id}":                  "Stop webhook subscription",
+					"GET /api/v1/webhooks/{id}/deliveries?limit={}": "Get recent webhook deliveries with attempts and last status",
+				},
+				"exports": map[string]interface{}{
+					"POST /api/v1/exports":              "Queue export of account transactions matching a filter",
+					"GET /api/v1/exports/{id}":          "Get export job status, progress and download link",
+					"PATCH /api/v1/exports/{id}/cancel": "Cancel queued export job",
+					"GET /api/v1/exports/{id}/download": "Download completed export file",
+				},
+				"admin": map[string]interface{}{
+					"GET /api/v1/admin/transactions/{id}/diagnostics":              "Get transaction diagnostics",
+					"POST /api/v1/admin/transactions/{id}/requeue":                 "Queue a stuck pending, or retryably failed, transaction again and count its requeues",
+					"GET /api/v1/admin/dlq?limit={}":                               "Peek at the oldest dead-lettered transaction messages, with failure counts and last errors",
+					"POST /api/v1/admin/dlq/requeue":                               "Move dead-lettered transaction messages back onto the transaction queue by ID",
+					"GET /api/v1/admin/changes?cursor={}&limit={}":                 "Get account and transaction change feed",
+					"POST /api/v1/admin/accounts":                                  "Create account of any type, including fee_collection and settlement",
+					"POST /api/v1/admin/adjustments":                               "Apply manual balance correction",
+					"GET /api/v1/admin/reconciliation":                             "Get latest balance reconciliation report",
+					"GET /api/v1/admin/invariants":                                 "Check that total balances match opening balances and transactions per currency",
+					"GET /api/v1/admin/users?user_id_prefix={}&limit={}&offset={}": "List users holding accounts with account counts, balances by currency and statuses",
+					"GET /api/v1/admin/users/{user_id}/verification":               "Get user verification level",
+					"PATCH /api/v1/admin/users/{user_id}/verification":             "Set user verification level (unverified or verified)",
+					"POST /api/v1/admin/settlement-groups":                         "Create settlement group",
+					"GET /api/v1/admin/settlement-groups":                          "List settlement groups",
+					"GET /api/v1/admin/settlement-groups/{id}":                     "Get settlement group",
+					"PUT /api/v1/admin/settlement-groups/{id}":                     "Update settlement group",
+					"GET /api/v1/admin/settlement-groups/{id}/net?date={}":         "Get settlement group net positions",
+					"POST /api/v1/admin/settlement-groups/{id}/settle?date={}":     "Settle settlement group",
 				},
 			},
 		})
@@ -8,12 +8,19 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"banking-ledger/api/middleware"
 	"banking-ledger/api/routes"
 	"banking-ledger/internal/config"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/exchange"
 	"banking-ledger/internal/queue"
 	"banking-ledger/internal/repository"
+	"banking-ledger/internal/storage"
 	"banking-ledger/internal/usecase"
+	"banking-ledger/internal/worker"
+	"banking-ledger/pkg/calendar"
 	"banking-ledger/pkg/database"
 
 	"github.com/labstack/echo/v4"
@@ -27,6 +34,13 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.Printf("Starting Banking Ledger API on port %s", cfg.Server.Port)
 
+	// Apply transaction size limits
+	domain.ActiveTransactionLimits = domain.TransactionLimits{
+		MaxAmount:        int64(cfg.Transaction.MaxAmount),
+		MaxMetadataKeys:  cfg.Transaction.MaxMetadataKeys,
+		MaxMetadataBytes: cfg.Transaction.MaxMetadataBytes,
+	}
+
 	// Initialize databases
 	postgresDB, err := database.NewPostgreSQLConnection(cfg.Database)
 	if err != nil {
@@ -48,31 +62,244 @@ func main() {
 		log.Fatalf("Failed to create MongoDB indexes: %v", err)
 	}
 
-	// Initialize message queue
-	messageQueue, err := queue.NewRabbitMQQueue(cfg.RabbitMQ.URL)
+	if cfg.Archive.After > 0 {
+		if err := database.CreateMongoDBArchiveIndexes(mongoDB, repository.ArchiveCollectionName(cfg.MongoDB.Collection)); err != nil {
+			log.Fatalf("Failed to create MongoDB archive indexes: %v", err)
+		}
+	}
+
+	if err := database.MigrateMongoDBAmounts(mongoDB, cfg.MongoDB.Collection); err != nil {
+		log.Fatalf("Failed to migrate MongoDB amounts: %v", err)
+	}
+
+	if err := database.CreateSubmissionGuardIndexes(mongoDB, cfg.MongoDB.SubmissionGuardCollection, time.Hour); err != nil {
+		log.Fatalf("Failed to create submission guard indexes: %v", err)
+	}
+
+	if err := database.CreateIdempotencyIndexes(mongoDB, cfg.MongoDB.IdempotencyCollection, cfg.Transaction.IdempotencyTTL); err != nil {
+		log.Fatalf("Failed to create idempotency indexes: %v", err)
+	}
+
+	// Initialize message queue. The retry policy, workers and failure
+	// handler only apply to the in-memory queue, whose transactions are
+	// processed in-process; the handler only runs once the processor, built
+	// below, has started consuming.
+	var processor *worker.Processor
+	retryPolicy, err := queue.NewRetryPolicy(cfg.RabbitMQ.MaxRetries, cfg.RabbitMQ.RetryDelay, cfg.RabbitMQ.RetryBackoff, cfg.RabbitMQ.RetryJitter)
 	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+		log.Fatalf("Invalid retry policy configuration: %v", err)
+	}
+	messageQueue, err := queue.New(cfg.Queue.Driver, cfg.RabbitMQ.URL,
+		queue.WithRetryPolicy(retryPolicy),
+		queue.WithConfirmTimeout(cfg.RabbitMQ.ConfirmTimeout),
+		queue.WithTimeouts(cfg.RabbitMQ.PublishTimeout, cfg.RabbitMQ.ProcessingTimeout),
+		queue.WithDeadLetterQueue(cfg.RabbitMQ.TransactionQueue, cfg.RabbitMQ.DeadLetterQueue),
+		queue.WithConsumers(cfg.RabbitMQ.TransactionQueue, cfg.RabbitMQ.Prefetch, cfg.RabbitMQ.Workers),
+		queue.WithFanout(cfg.RabbitMQ.CancellationQueue),
+		queue.WithFailureHandler(cfg.RabbitMQ.TransactionQueue, func(ctx context.Context, message []byte, err error) {
+			processor.FailUndelivered(ctx, message, err)
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize message queue: %v", err)
 	}
 	defer messageQueue.Close()
 
 	// Initialize repositories
 	accountRepo := repository.NewPostgreSQLAccountRepository(postgresDB)
-	transactionRepo := repository.NewMongoTransactionRepository(mongoDB, cfg.MongoDB.Collection)
+	transactionRepo := repository.NewMongoTransactionRepository(mongoDB, cfg.MongoDB.Collection, repository.WithArchive(cfg.Archive.After))
+	settlementGroupRepo := repository.NewPostgreSQLSettlementGroupRepository(postgresDB)
+	microDepositRepo := repository.NewPostgreSQLMicroDepositRepository(postgresDB)
+	ledgerRepo := repository.NewPostgreSQLLedgerEntryRepository(postgresDB)
+	holdRepo := repository.NewPostgreSQLHoldRepository(postgresDB)
+	standingOrderRepo := repository.NewPostgreSQLStandingOrderRepository(postgresDB)
+	userVerificationRepo := repository.NewPostgreSQLUserVerificationRepository(postgresDB)
+	submissionGuard := repository.NewMongoSubmissionGuard(mongoDB, cfg.MongoDB.SubmissionGuardCollection)
+	idempotencyStore := repository.NewMongoIdempotencyStore(mongoDB, cfg.MongoDB.IdempotencyCollection)
+	reconciliationRepo := repository.NewMongoReconciliationReportRepository(mongoDB, cfg.MongoDB.ReconciliationCollection)
+	queueStats := repository.NewMongoQueueStatsStore(mongoDB, cfg.MongoDB.QueueStatsCollection)
+
+	limitLocation, err := time.LoadLocation(cfg.Transaction.LimitsTimezone)
+	if err != nil {
+		log.Fatalf("Invalid limits timezone: %v", err)
+	}
+
+	// Fees and the savings withdrawal limit are charged and enforced by the
+	// processor; the API only estimates them for dry runs
+	feePolicy, err := usecase.LoadFeePolicy(cfg.Fee.Rules, cfg.Fee.CollectionAccountID, cfg.Fee.FailureMode)
+	if err != nil {
+		log.Fatalf("Invalid fee configuration: %v", err)
+	}
+	feePolicy.ExemptSelfTransfers(cfg.Fee.ExemptSelfTransfers)
+
+	quota, err := usecase.LoadTransactionQuota(cfg.Transaction.UserQuota, cfg.Transaction.UserQuotaWindow, cfg.Transaction.UserQuotaOverrides)
+	if err != nil {
+		log.Fatalf("Invalid transaction quota configuration: %v", err)
+	}
+
+	transactionOptions := []usecase.TransactionOption{
+		usecase.WithDuplicateGuard(submissionGuard, cfg.Transaction.DuplicateWindow),
+		usecase.WithLedgerEntries(ledgerRepo),
+		usecase.WithSubmissionValidation(cfg.Transaction.ValidateFunds),
+		usecase.WithIdempotency(idempotencyStore, cfg.Transaction.IdempotencyTTL),
+		usecase.WithLimitTimezone(limitLocation),
+		usecase.WithApproval(domain.Decimal(cfg.Transaction.ApprovalThreshold), cfg.Transaction.ApprovalTTL),
+		usecase.WithTransactionQuota(quota),
+		usecase.WithFeePolicy(feePolicy),
+		usecase.WithSavingsWithdrawalLimit(cfg.Transaction.SavingsWithdrawalLimit),
+		usecase.WithCancellationTombstones(cfg.RabbitMQ.CancellationQueue, 0),
+		usecase.WithExportMaxRows(int64(cfg.Transaction.ExportMaxRows)),
+		usecase.WithReceiptSecret(cfg.Receipt.Secret),
+		usecase.WithQueueStats(queueStats),
+	}
+
+	// Accept cross-currency transfers, which the processor converts
+	if cfg.Exchange.Enabled {
+		rates, err := exchange.LoadStaticRates(cfg.Exchange.Rates, time.Now())
+		if err != nil {
+			log.Fatalf("Invalid exchange rate configuration: %v", err)
+		}
+		transactionOptions = append(transactionOptions, usecase.WithExchangeRates(rates, cfg.Exchange.MaxRateAge))
+	}
+
+	// Let transfers between a user's own accounts skip approval
+	if cfg.Transaction.ApprovalExemptSelfTransfers {
+		transactionOptions = append(transactionOptions, usecase.WithSelfTransferApprovalExemption())
+	}
+
+	// Hold unverified users to capped deposits
+	if cfg.Transaction.RequireVerification {
+		depositLimit := domain.Decimal(cfg.Transaction.UnverifiedDepositLimit)
+		if _, ok := depositLimit.Bound(0, false); !ok {
+			log.Fatalf("Invalid unverified deposit limit: %q", depositLimit)
+		}
+		transactionOptions = append(transactionOptions, usecase.WithVerificationGate(userVerificationRepo, depositLimit))
+	}
+
+	// Hold deposits and withdrawals to naming the party outside the ledger
+	if cfg.Transaction.RequireExternalParty {
+		transactionOptions = append(transactionOptions, usecase.WithExternalPartiesRequired())
+	}
 
 	// Initialize use cases
-	accountService := usecase.NewAccountUseCase(accountRepo, transactionRepo)
+	accountOptions := []usecase.AccountOption{usecase.WithMaxBulkAccounts(cfg.Account.MaxBulkAccounts)}
+	if cfg.Notification.Enabled {
+		notifier := usecase.NewNotificationUseCase(messageQueue, cfg.RabbitMQ.NotificationQueue)
+		accountOptions = append(accountOptions, usecase.WithAccountNotifications(notifier))
+	}
+	accountService := usecase.NewAccountUseCase(accountRepo, transactionRepo, accountOptions...)
 	transactionService := usecase.NewTransactionUseCase(
 		accountRepo,
 		transactionRepo,
 		messageQueue,
 		cfg.RabbitMQ.TransactionQueue,
+		transactionOptions...,
+	)
+	diagnosticsService := usecase.NewDiagnosticsUseCase(accountRepo, transactionRepo)
+	reconciliationService := usecase.NewReconciliationUseCase(accountRepo, transactionRepo, reconciliationRepo)
+	verificationService := usecase.NewAccountVerificationUseCase(
+		accountRepo,
+		microDepositRepo,
+		transactionService,
+		cfg.MicroDeposit.TTL,
+		cfg.MicroDeposit.MaxAttempts,
+	)
+	businessCalendars, err := calendar.Load(cfg.Calendar.Weekend, cfg.Calendar.Holidays)
+	if err != nil {
+		log.Fatalf("Invalid business calendar configuration: %v", err)
+	}
+	settlementConvention, err := calendar.ParseConvention(cfg.Calendar.SettlementConvention)
+	if err != nil {
+		log.Fatalf("Invalid settlement roll convention: %v", err)
+	}
+	settlementService := usecase.NewSettlementUseCase(
+		settlementGroupRepo,
+		accountRepo,
+		transactionRepo,
+		usecase.WithSettlementCalendar(businessCalendars, settlementConvention),
 	)
+	ledgerService := usecase.NewAccountLedgerUseCase(accountRepo, ledgerRepo)
+	statementService := usecase.NewLedgerUseCase(accountRepo, transactionRepo, usecase.WithStatementMaxDays(cfg.Statement.MaxDays))
+	snapshotService := usecase.NewBalanceSnapshotUseCase(accountRepo, ledgerRepo, repository.NewPostgreSQLBalanceSnapshotRepository(postgresDB))
+	monthlyStatementService := usecase.NewMonthlyStatementUseCase(accountRepo, transactionRepo, repository.NewMongoMonthlyStatementRepository(mongoDB, cfg.MongoDB.StatementCollection))
+	holdService := usecase.NewHoldUseCase(accountRepo, holdRepo, transactionRepo, cfg.Hold.TTL)
+	standingOrderService := usecase.NewStandingOrderUseCase(standingOrderRepo, accountRepo, transactionService)
+	webhookService := usecase.NewWebhookUseCase(repository.NewPostgreSQLWebhookRepository(postgresDB))
+	exportStorage, err := storage.NewLocalExportStorage(cfg.Export.Dir)
+	if err != nil {
+		log.Fatalf("Failed to open export storage: %v", err)
+	}
+	exportService := usecase.NewExportJobUseCase(
+		repository.NewPostgreSQLExportJobRepository(postgresDB),
+		accountRepo,
+		transactionRepo,
+		exportStorage,
+		messageQueue,
+		cfg.RabbitMQ.ExportQueue,
+		usecase.WithExportRetention(cfg.Export.Retention),
+	)
+	userVerificationService := usecase.NewUserVerificationUseCase(userVerificationRepo)
+	changeFeedService := usecase.NewChangeFeedUseCase([]domain.ChangeSource{
+		repository.NewPostgreSQLAccountChangeSource(postgresDB),
+		repository.NewMongoTransactionChangeSource(mongoDB, cfg.MongoDB.Collection),
+	}, cfg.ChangeFeed.SettleWindow)
+
+	// Dead letters are read and replayed through the queue they were set aside by
+	deadLetterQueue, _ := messageQueue.(domain.DeadLetterQueue)
+	deadLetterService := usecase.NewDeadLetterUseCase(deadLetterQueue, cfg.RabbitMQ.TransactionQueue)
+
+	// Initialize load shedding for expensive endpoints
+	var degradation *middleware.DegradationController
+	if cfg.Degradation.Enabled {
+		degradation = middleware.NewDegradationController(cfg.Degradation)
+		monitorCtx, stopMonitor := context.WithCancel(context.Background())
+		defer stopMonitor()
+		go degradation.Monitor(monitorCtx, postgresDB.Stats)
+	}
 
 	// Initialize Echo
 	e := echo.New()
 
 	// Setup routes
-	routes.SetupRoutes(e, accountService, transactionService)
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:          accountService,
+		TransactionService:      transactionService,
+		DiagnosticsService:      diagnosticsService,
+		VerificationService:     verificationService,
+		SettlementService:       settlementService,
+		ChangeFeedService:       changeFeedService,
+		LedgerService:           ledgerService,
+		StatementService:        statementService,
+		HoldService:             holdService,
+		StandingOrderService:    standingOrderService,
+		ReconciliationService:   reconciliationService,
+		SnapshotService:         snapshotService,
+		MonthlyStatementService: monthlyStatementService,
+		WebhookService:          webhookService,
+		ExportService:           exportService,
+		UserVerificationService: userVerificationService,
+		DeadLetterService:       deadLetterService,
+		Degradation:             degradation,
+		AdminToken:              cfg.Admin.Token,
+		AdminRateLimit:          cfg.Admin.RateLimit,
+		AuthRequired:            cfg.Auth.Required,
+	})
+
+	// With the in-memory queue, nothing outside this process can take the
+	// work it queues, so it runs the processor and its workers itself
+	stopProcessor := func() {}
+	if cfg.Queue.Driver == queue.DriverMemory {
+		processor, err = worker.New(cfg, postgresDB, mongoDB, messageQueue)
+		if err != nil {
+			log.Fatalf("Failed to initialize transaction processor: %v", err)
+		}
+		var processorCtx context.Context
+		processorCtx, stopProcessor = context.WithCancel(context.Background())
+		if err := processor.Start(processorCtx); err != nil {
+			log.Fatalf("Failed to start transaction processor: %v", err)
+		}
+		log.Println("Processing queued work in-process on the in-memory queue")
+	}
 
 	// Start server
 	server := &http.Server{
@@ -107,5 +334,13 @@ func main() {
 		log.Fatalf("Failed to shutdown server: %v", err)
 	}
 
+	// Finish the work in hand once no more is accepted
+	stopProcessor()
+	if processor != nil {
+		drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.RabbitMQ.DrainTimeout)
+		defer cancelDrain()
+		processor.Drain(drainCtx)
+	}
+
 	log.Println("Server stopped")
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"banking-ledger/internal/config"
+	"banking-ledger/internal/repository"
+	"banking-ledger/pkg/database"
+)
+
+func main() {
+	// Load configuration
+	cfg := config.Load()
+
+	// Initialize logger
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.Println("Backfilling failure codes on failed transactions")
+
+	mongoDB, err := database.NewMongoDBConnection(cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	updated, err := repository.BackfillFailureCodes(context.Background(), mongoDB, cfg.MongoDB.Collection)
+	if err != nil {
+		log.Fatalf("Backfill stopped after %d transactions: %v", updated, err)
+	}
+
+	log.Printf("Backfilled failure codes on %d transactions", updated)
+}
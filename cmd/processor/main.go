@@ -8,9 +8,9 @@ import (
 	"syscall"
 
 	"banking-ledger/internal/config"
+	"banking-ledger/internal/domain"
 	"banking-ledger/internal/queue"
-	"banking-ledger/internal/repository"
-	"banking-ledger/internal/usecase"
+	"banking-ledger/internal/worker"
 	"banking-ledger/pkg/database"
 )
 
@@ -22,6 +22,13 @@ func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.Println("Starting Banking Ledger Transaction Processor")
 
+	// Apply transaction size limits
+	domain.ActiveTransactionLimits = domain.TransactionLimits{
+		MaxAmount:        int64(cfg.Transaction.MaxAmount),
+		MaxMetadataKeys:  cfg.Transaction.MaxMetadataKeys,
+		MaxMetadataBytes: cfg.Transaction.MaxMetadataBytes,
+	}
+
 	// Initialize databases
 	postgresDB, err := database.NewPostgreSQLConnection(cfg.Database)
 	if err != nil {
@@ -34,36 +41,44 @@ func main() {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 
-	// Initialize message queue
-	messageQueue, err := queue.NewRabbitMQQueue(cfg.RabbitMQ.URL)
+	// Initialize message queue. A transaction message dropped without a dead
+	// letter queue to set it aside in fails its transaction; the handler only
+	// runs once the processor, built below, has started consuming.
+	var processor *worker.Processor
+	retryPolicy, err := queue.NewRetryPolicy(cfg.RabbitMQ.MaxRetries, cfg.RabbitMQ.RetryDelay, cfg.RabbitMQ.RetryBackoff, cfg.RabbitMQ.RetryJitter)
+	if err != nil {
+		log.Fatalf("Invalid retry policy configuration: %v", err)
+	}
+	messageQueue, err := queue.New(cfg.Queue.Driver, cfg.RabbitMQ.URL,
+		queue.WithRetryPolicy(retryPolicy),
+		queue.WithConfirmTimeout(cfg.RabbitMQ.ConfirmTimeout),
+		queue.WithTimeouts(cfg.RabbitMQ.PublishTimeout, cfg.RabbitMQ.ProcessingTimeout),
+		queue.WithDeadLetterQueue(cfg.RabbitMQ.TransactionQueue, cfg.RabbitMQ.DeadLetterQueue),
+		queue.WithConsumers(cfg.RabbitMQ.TransactionQueue, cfg.RabbitMQ.Prefetch, cfg.RabbitMQ.Workers),
+		queue.WithFanout(cfg.RabbitMQ.CancellationQueue),
+		queue.WithFailureHandler(cfg.RabbitMQ.TransactionQueue, func(ctx context.Context, message []byte, err error) {
+			processor.FailUndelivered(ctx, message, err)
+		}),
+	)
 	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
+		log.Fatalf("Failed to initialize message queue: %v", err)
 	}
 	defer messageQueue.Close()
 
-	// Initialize repositories
-	accountRepo := repository.NewPostgreSQLAccountRepository(postgresDB)
-	transactionRepo := repository.NewMongoTransactionRepository(mongoDB, cfg.MongoDB.Collection)
-
-	// Initialize transaction service
-	transactionService := usecase.NewTransactionUseCase(
-		accountRepo,
-		transactionRepo,
-		messageQueue,
-		cfg.RabbitMQ.TransactionQueue,
-	)
+	processor, err = worker.New(cfg, postgresDB, mongoDB, messageQueue)
+	if err != nil {
+		log.Fatalf("Failed to initialize transaction processor: %v", err)
+	}
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start transaction processor
-	if err := transactionService.(*usecase.TransactionUseCase).StartTransactionProcessor(ctx); err != nil {
+	// Start the transaction processor and its workers
+	if err := processor.Start(ctx); err != nil {
 		log.Fatalf("Failed to start transaction processor: %v", err)
 	}
 
-	log.Println("Transaction processor started and listening for messages...")
-
 	// Wait for interrupt signal to gracefully shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -72,5 +87,11 @@ func main() {
 	log.Println("Shutting down transaction processor...")
 	cancel()
 
+	// Finish the messages in hand, which are acknowledged as they are,
+	// before the deferred Close closes the connection
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.RabbitMQ.DrainTimeout)
+	defer cancelDrain()
+	processor.Drain(drainCtx)
+
 	log.Println("Transaction processor stopped")
 }
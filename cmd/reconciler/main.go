@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+
+	"banking-ledger/internal/config"
+	"banking-ledger/internal/repository"
+	"banking-ledger/internal/usecase"
+	"banking-ledger/pkg/database"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "record adjustments for the approved discrepancies of the latest report instead of reconciling")
+	approved := flag.String("accounts", "", "comma-separated IDs of the accounts whose discrepancies are approved for -fix")
+	operator := flag.String("operator", "", "operator approving the discrepancies fixed with -fix")
+	pageSize := flag.Int("page-size", 500, "accounts reconciled per query")
+	flag.Parse()
+
+	// Load configuration
+	cfg := config.Load()
+
+	// Initialize logger
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	// Initialize databases
+	postgresDB, err := database.NewPostgreSQLConnection(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer postgresDB.Close()
+
+	mongoDB, err := database.NewMongoDBConnection(cfg.MongoDB)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	if err := database.CreateReconciliationIndexes(mongoDB, cfg.MongoDB.ReconciliationCollection); err != nil {
+		log.Fatalf("Failed to create reconciliation indexes: %v", err)
+	}
+
+	reconciliationService := usecase.NewReconciliationUseCase(
+		repository.NewPostgreSQLAccountRepository(postgresDB),
+		repository.NewMongoTransactionRepository(mongoDB, cfg.MongoDB.Collection, repository.WithArchive(cfg.Archive.After)),
+		repository.NewMongoReconciliationReportRepository(mongoDB, cfg.MongoDB.ReconciliationCollection),
+		usecase.WithReconciliationPageSize(*pageSize),
+	)
+
+	ctx := context.Background()
+
+	if *fix {
+		var accountIDs []string
+		for _, id := range strings.Split(*approved, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				accountIDs = append(accountIDs, id)
+			}
+		}
+		if len(accountIDs) == 0 || *operator == "" {
+			log.Fatal("-fix requires -accounts and -operator")
+		}
+
+		report, err := reconciliationService.FixDiscrepancies(ctx, accountIDs, *operator)
+		if err != nil {
+			log.Fatalf("Failed to fix discrepancies: %v", err)
+		}
+
+		for _, id := range accountIDs {
+			discrepancy := report.Discrepancy(id)
+			if discrepancy.AdjustmentID == "" {
+				log.Printf("Account %s not fixed: its delta changed since report %s, reconcile again", id, report.ID)
+				continue
+			}
+			log.Printf("Account %s: recorded adjustment %s for %s %s", id, discrepancy.AdjustmentID, discrepancy.Delta.Format(discrepancy.Currency), discrepancy.Currency)
+		}
+		return
+	}
+
+	log.Println("Reconciling account balances against their transactions")
+
+	report, err := reconciliationService.Reconcile(ctx)
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	for _, discrepancy := range report.Discrepancies {
+		log.Printf("Account %s: expected %s, actual %s, delta %s %s", discrepancy.AccountID,
+			discrepancy.Expected.Format(discrepancy.Currency), discrepancy.Actual.Format(discrepancy.Currency),
+			discrepancy.Delta.Format(discrepancy.Currency), discrepancy.Currency)
+	}
+	log.Printf("Report %s: %d accounts checked, %d discrepancies", report.ID, report.AccountsChecked, len(report.Discrepancies))
+}
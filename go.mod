@@ -12,6 +12,7 @@ require (
 	github.com/lib/pq v1.10.9
 	github.com/streadway/amqp v1.1.0
 	go.mongodb.org/mongo-driver v1.12.1
+	golang.org/x/time v0.12.0
 )
 
 require (
@@ -37,5 +38,4 @@ require (
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
-	golang.org/x/time v0.12.0 // indirect
 )
@@ -0,0 +1,39 @@
+package domain
+
+// DefaultMaxBulkAccounts is how many accounts one bulk creation may open
+// unless configured otherwise
+const DefaultMaxBulkAccounts = 500
+
+// NewAccount is one account of a bulk creation. The initial balance is read
+// in the account's currency, and an empty type opens a checking account.
+type NewAccount struct {
+	UserID         string
+	InitialBalance Decimal
+	Currency       string
+	Type           AccountType
+	AccountDetails
+}
+
+// BulkAccountStatus is what became of one account of a bulk creation
+type BulkAccountStatus string
+
+const (
+	// BulkAccountCreated accounts were opened
+	BulkAccountCreated BulkAccountStatus = "created"
+	// BulkAccountValid accounts passed validation on a dry run
+	BulkAccountValid BulkAccountStatus = "valid"
+	// BulkAccountDuplicate accounts were skipped because the user already
+	// has an account of the currency and type, or an earlier item opens one
+	BulkAccountDuplicate BulkAccountStatus = "duplicate"
+	// BulkAccountInvalid accounts failed validation
+	BulkAccountInvalid BulkAccountStatus = "invalid"
+)
+
+// BulkAccountResult reports one account of a bulk creation by its index in
+// the request. Account is set for created accounts, and Err for invalid ones.
+type BulkAccountResult struct {
+	Index   int
+	Status  BulkAccountStatus
+	Account *Account
+	Err     error
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// AccountEventType names a change to an account's lifecycle
+type AccountEventType string
+
+const (
+	AccountEventCreated               AccountEventType = "created"
+	AccountEventStatusChanged         AccountEventType = "status_changed"
+	AccountEventClosed                AccountEventType = "closed"
+	AccountEventOverdraftLimitChanged AccountEventType = "overdraft_limit_changed"
+	AccountEventMinimumBalanceChanged AccountEventType = "minimum_balance_changed"
+	AccountEventMaxTransactionChanged AccountEventType = "max_transaction_amount_changed"
+	AccountEventDailyOutgoingChanged  AccountEventType = "daily_outgoing_limit_changed"
+)
+
+// AccountEvent records who changed an account's status or limits, when and
+// why, with the values before and after. Money moving through the account is
+// recorded by the ledger, not here.
+type AccountEvent struct {
+	ID        string           `json:"id" db:"id"`
+	AccountID string           `json:"account_id" db:"account_id"`
+	EventType AccountEventType `json:"event_type" db:"event_type"`
+	Actor     string           `json:"actor" db:"actor"`
+	Reason    string           `json:"reason,omitempty" db:"reason"`
+	OldValue  string           `json:"old_value,omitempty" db:"old_value"`
+	NewValue  string           `json:"new_value,omitempty" db:"new_value"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+}
+
+// NewAccountEvent records a change to an account by the caller in ctx
+func NewAccountEvent(ctx context.Context, accountID string, eventType AccountEventType, oldValue, newValue string) *AccountEvent {
+	return &AccountEvent{
+		AccountID: accountID,
+		EventType: eventType,
+		Actor:     Actor(ctx),
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		CreatedAt: time.Now(),
+	}
+}
+
+// WithoutAudit returns a copy of the event without who made the change and
+// why, for callers other than admins
+func (e *AccountEvent) WithoutAudit() *AccountEvent {
+	redacted := *e
+	redacted.Actor = ""
+	redacted.Reason = ""
+	return &redacted
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// AccountFilter narrows an account listing; empty fields match every
+// account. Closed accounts are left out unless IncludeClosed is set or Status
+// asks for them. CreatedFrom and CreatedTo bound the creation time inclusively.
+type AccountFilter struct {
+	Type          AccountType   `json:"type,omitempty"`
+	Status        AccountStatus `json:"status,omitempty"`
+	Currency      string        `json:"currency,omitempty"`
+	UserID        string        `json:"user_id,omitempty"`
+	UserIDPrefix  string        `json:"user_id_prefix,omitempty"`
+	Label         string        `json:"label,omitempty"`
+	CreatedFrom   *time.Time    `json:"created_from,omitempty"`
+	CreatedTo     *time.Time    `json:"created_to,omitempty"`
+	IncludeClosed bool          `json:"include_closed,omitempty"`
+	Limit         int           `json:"-"`
+	Offset        int           `json:"-"`
+}
+
+// IsValid checks the filter's type and status are known and its creation
+// range is not reversed
+func (f *AccountFilter) IsValid() error {
+	if f.Type != "" && !f.Type.IsValid() {
+		return ErrInvalidAccountType
+	}
+	if f.Status != "" && !f.Status.IsValid() {
+		return ErrInvalidInput
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedFrom.After(*f.CreatedTo) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// Matches reports whether the account passes the filter
+func (f *AccountFilter) Matches(account *Account) bool {
+	switch {
+	case f.Type != "" && account.Type != f.Type,
+		f.Status != "" && account.Status != f.Status,
+		f.Status == "" && !f.IncludeClosed && account.Status == AccountStatusClosed,
+		f.Currency != "" && account.Currency != f.Currency,
+		f.UserID != "" && account.UserID != f.UserID,
+		!strings.HasPrefix(account.UserID, f.UserIDPrefix),
+		f.Label != "" && !account.Labels.Has(f.Label),
+		f.CreatedFrom != nil && account.CreatedAt.Before(*f.CreatedFrom),
+		f.CreatedTo != nil && account.CreatedAt.After(*f.CreatedTo):
+		return false
+	}
+	return true
+}
+
+// AccountPage is one page of an account listing. Total counts every account
+// the filter matches and is nil when the count was not asked for.
+type AccountPage struct {
+	Accounts []*Account
+	Total    *int64
+	HasMore  bool
+}
@@ -0,0 +1,67 @@
+package domain
+
+// AccountLimit names a limit on an account's outgoing money
+type AccountLimit string
+
+const (
+	// AccountLimitMaxTransaction caps a single withdrawal or outgoing transfer
+	AccountLimitMaxTransaction AccountLimit = "max_transaction_amount"
+	// AccountLimitDailyOutgoing caps the total of a day's withdrawals and
+	// outgoing transfers
+	AccountLimitDailyOutgoing AccountLimit = "daily_outgoing"
+)
+
+// AccountLimits caps the money leaving an account through withdrawals and
+// transfers, in the account's currency. A zero limit is no limit.
+type AccountLimits struct {
+	MaxTransactionAmount Money `json:"-" db:"max_transaction_amount"`
+	DailyOutgoing        Money `json:"-" db:"daily_outgoing_limit"`
+}
+
+// CheckTransaction reports whether a single outgoing amount exceeds the
+// account's maximum
+func (l AccountLimits) CheckTransaction(amount Money, currency string) error {
+	if l.MaxTransactionAmount > 0 && amount > l.MaxTransactionAmount {
+		return &LimitExceededError{Limit: AccountLimitMaxTransaction, Amount: l.MaxTransactionAmount, Currency: currency}
+	}
+	return nil
+}
+
+// CheckDaily reports whether an outgoing amount on top of the day's earlier
+// outgoing total exceeds the account's daily limit
+func (l AccountLimits) CheckDaily(amount, used Money, currency string) error {
+	if l.DailyOutgoing > 0 && used+amount > l.DailyOutgoing {
+		return &LimitExceededError{Limit: AccountLimitDailyOutgoing, Amount: l.DailyOutgoing, Used: used, Currency: currency}
+	}
+	return nil
+}
+
+// accountLimitsJSON carries account limits as decimal strings, leaving out
+// the limits that are not set
+type accountLimitsJSON struct {
+	MaxTransactionAmount Decimal `json:"max_transaction_amount,omitempty"`
+	DailyOutgoing        Decimal `json:"daily_outgoing,omitempty"`
+}
+
+func (l AccountLimits) toJSON(currency string) accountLimitsJSON {
+	var encoded accountLimitsJSON
+	if l.MaxTransactionAmount > 0 {
+		encoded.MaxTransactionAmount = Decimal(l.MaxTransactionAmount.Format(currency))
+	}
+	if l.DailyOutgoing > 0 {
+		encoded.DailyOutgoing = Decimal(l.DailyOutgoing.Format(currency))
+	}
+	return encoded
+}
+
+func (encoded accountLimitsJSON) limits(currency string) (AccountLimits, error) {
+	maxTransaction, err := encoded.MaxTransactionAmount.Money(currency)
+	if err != nil {
+		return AccountLimits{}, err
+	}
+	daily, err := encoded.DailyOutgoing.Money(currency)
+	if err != nil {
+		return AccountLimits{}, err
+	}
+	return AccountLimits{MaxTransactionAmount: maxTransaction, DailyOutgoing: daily}, nil
+}
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxNicknameLength bounds an account's nickname
+	MaxNicknameLength = 100
+	// MaxAccountMetadataKeys bounds how many labels an account may carry
+	MaxAccountMetadataKeys = 20
+	// MaxAccountMetadataBytes bounds the encoded size of an account's labels
+	MaxAccountMetadataBytes = 4 << 10
+)
+
+// AccountMetadata holds free-form string labels on an account, stored as JSON
+type AccountMetadata map[string]string
+
+// Value encodes the labels as JSON, storing none as NULL
+func (m AccountMetadata) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan decodes labels stored as JSON
+func (m *AccountMetadata) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return fmt.Errorf("cannot scan %T into account metadata", src)
+	}
+	return json.Unmarshal(data, m)
+}
+
+// NormalizeNickname trims a nickname, returning ErrNicknameTooLong if it is
+// still over MaxNicknameLength
+func NormalizeNickname(nickname string) (string, error) {
+	nickname = strings.TrimSpace(nickname)
+	if len(nickname) > MaxNicknameLength {
+		return "", ErrNicknameTooLong
+	}
+	return nickname, nil
+}
+
+// IsValid checks the nickname and metadata an update sets are within bounds
+func (u *AccountUpdate) IsValid() error {
+	if u.Nickname != nil {
+		if _, err := NormalizeNickname(*u.Nickname); err != nil {
+			return err
+		}
+	}
+	if !u.ReplaceMetadata || len(u.Metadata) == 0 {
+		return nil
+	}
+	if len(u.Metadata) > MaxAccountMetadataKeys {
+		return ErrInvalidAccountUpdate
+	}
+	encoded, err := json.Marshal(u.Metadata)
+	if err != nil || len(encoded) > MaxAccountMetadataBytes {
+		return ErrInvalidAccountUpdate
+	}
+	for key := range u.Metadata {
+		if strings.TrimSpace(key) == "" {
+			return ErrInvalidAccountUpdate
+		}
+	}
+	return nil
+}
+
+const (
+	// MaxAccountLabels bounds how many labels an account may carry
+	MaxAccountLabels = 20
+	// MaxAccountLabelLength bounds a single label
+	MaxAccountLabelLength = 50
+)
+
+// AccountLabels are the owner's names for grouping accounts, such as "Rent",
+// stored as a JSON array
+type AccountLabels []string
+
+// Value encodes the labels as a JSON array, storing none as an empty array
+func (l AccountLabels) Value() (driver.Value, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(l))
+}
+
+// Scan decodes labels stored as a JSON array
+func (l *AccountLabels) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return fmt.Errorf("cannot scan %T into account labels", src)
+	}
+	if err := json.Unmarshal(data, (*[]string)(l)); err != nil {
+		return err
+	}
+	if len(*l) == 0 {
+		*l = nil
+	}
+	return nil
+}
+
+// Normalize trims the labels and drops repeats, returning ErrInvalidAccountLabels
+// if any is blank or too long or there are too many
+func (l AccountLabels) Normalize() (AccountLabels, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	normalized := make(AccountLabels, 0, len(l))
+	for _, label := range l {
+		label = strings.TrimSpace(label)
+		if label == "" || len(label) > MaxAccountLabelLength {
+			return nil, ErrInvalidAccountLabels
+		}
+		if !normalized.Has(label) {
+			normalized = append(normalized, label)
+		}
+	}
+	if len(normalized) > MaxAccountLabels {
+		return nil, ErrInvalidAccountLabels
+	}
+	return normalized, nil
+}
+
+// Has reports whether the labels include the label
+func (l AccountLabels) Has(label string) bool {
+	for _, existing := range l {
+		if existing == label {
+			return true
+		}
+	}
+	return false
+}
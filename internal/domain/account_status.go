@@ -0,0 +1,73 @@
+package domain
+
+// AccountStatus represents the lifecycle state of an account
+type AccountStatus string
+
+const (
+	// AccountStatusActive accounts accept credits and debits
+	AccountStatusActive AccountStatus = "active"
+	// AccountStatusFrozen accounts accept credits but block debits
+	AccountStatusFrozen AccountStatus = "frozen"
+	// AccountStatusInactive accounts block all movements until reactivated
+	AccountStatusInactive AccountStatus = "inactive"
+	// AccountStatusClosed accounts block all movements for good
+	AccountStatusClosed AccountStatus = "closed"
+)
+
+// AccountStatuses lists every account status
+var AccountStatuses = []AccountStatus{
+	AccountStatusActive,
+	AccountStatusFrozen,
+	AccountStatusInactive,
+	AccountStatusClosed,
+}
+
+// accountStatusTransitions lists the statuses each status may move to
+var accountStatusTransitions = map[AccountStatus][]AccountStatus{
+	AccountStatusActive:   {AccountStatusFrozen, AccountStatusInactive, AccountStatusClosed},
+	AccountStatusFrozen:   {AccountStatusActive, AccountStatusInactive, AccountStatusClosed},
+	AccountStatusInactive: {AccountStatusActive, AccountStatusClosed},
+	AccountStatusClosed:   {},
+}
+
+// IsValid reports whether the status is a known account status
+func (s AccountStatus) IsValid() bool {
+	_, ok := accountStatusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether an account may move from s to next
+func (s AccountStatus) CanTransitionTo(next AccountStatus) bool {
+	for _, allowed := range accountStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// CanCredit reports whether money may be paid into an account in this status
+func (s AccountStatus) CanCredit() error {
+	switch s {
+	case AccountStatusActive, AccountStatusFrozen:
+		return nil
+	case AccountStatusClosed:
+		return ErrAccountClosed
+	default:
+		return ErrAccountInactive
+	}
+}
+
+// CanDebit reports whether money may be taken from an account in this status
+func (s AccountStatus) CanDebit() error {
+	switch s {
+	case AccountStatusActive:
+		return nil
+	case AccountStatusFrozen:
+		return ErrAccountFrozen
+	case AccountStatusClosed:
+		return ErrAccountClosed
+	default:
+		return ErrAccountInactive
+	}
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AccountTransaction is a transaction as seen from one of its accounts. The
+// signed amount is the transaction's amount, negative when it is a debit of
+// the account, and the counterparty is the other account of a transaction
+// between two accounts, with its owner if it could be found. A completed
+// transaction carries the account's balance after it, in the account's
+// currency, and its balance changes are left out.
+type AccountTransaction struct {
+	*Transaction
+	Direction             EntryDirection `json:"direction"`
+	SignedAmount          Money          `json:"signed_amount"`
+	CounterpartyAccountID *string        `json:"counterparty_account_id"`
+	CounterpartyUserID    *string        `json:"counterparty_user_id"`
+	RunningBalance        *Money         `json:"running_balance"`
+
+	accountCurrency string
+}
+
+// NewAccountTransaction returns the transaction as seen from the account,
+// without the counterparty's owner or a running balance
+func NewAccountTransaction(transaction *Transaction, account *Account) *AccountTransaction {
+	accountID := account.ID
+	view := &AccountTransaction{
+		Transaction:     transaction.WithoutBalanceChanges(),
+		Direction:       EntryDirectionDebit,
+		SignedAmount:    -transaction.Amount,
+		accountCurrency: account.Currency,
+	}
+	if transaction.ToAccountID != nil && *transaction.ToAccountID == accountID {
+		view.Direction = EntryDirectionCredit
+		view.SignedAmount = transaction.Amount
+	}
+
+	if transaction.FromAccountID != nil && transaction.ToAccountID != nil {
+		counterparty := *transaction.FromAccountID
+		if view.Direction == EntryDirectionDebit {
+			counterparty = *transaction.ToAccountID
+		}
+		view.CounterpartyAccountID = &counterparty
+	}
+
+	return view
+}
+
+// MarshalJSON emits the transaction followed by its direction, its signed
+// amount as a decimal string in the transaction's currency, its counterparty
+// and its running balance as a decimal string in the account's currency
+func (t AccountTransaction) MarshalJSON() ([]byte, error) {
+	transaction, err := json.Marshal(t.Transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	var runningBalance *string
+	if t.RunningBalance != nil {
+		formatted := t.RunningBalance.Format(t.accountCurrency)
+		runningBalance = &formatted
+	}
+	view, err := json.Marshal(struct {
+		Direction             EntryDirection `json:"direction"`
+		SignedAmount          string         `json:"signed_amount"`
+		CounterpartyAccountID *string        `json:"counterparty_account_id"`
+		CounterpartyUserID    *string        `json:"counterparty_user_id"`
+		RunningBalance        *string        `json:"running_balance"`
+	}{t.Direction, t.SignedAmount.Format(t.Currency), t.CounterpartyAccountID, t.CounterpartyUserID, runningBalance})
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("%s,%s", transaction[:len(transaction)-1], view[1:])), nil
+}
+
+// RunningBalances returns an account's balance after each of its
+// transactions, given newest first, walking back from its current balance.
+// Every transaction the account took part in since the oldest must be given.
+func RunningBalances(accountID string, balance Money, transactions []*Transaction) map[string]Money {
+	balances := make(map[string]Money, len(transactions))
+	for _, transaction := range transactions {
+		balances[transaction.ID] = balance
+		balance -= transaction.Movements()[accountID]
+	}
+	return balances
+}
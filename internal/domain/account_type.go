@@ -0,0 +1,41 @@
+package domain
+
+// AccountType represents what an account is used for
+type AccountType string
+
+const (
+	// AccountTypeChecking is the default everyday account
+	AccountTypeChecking AccountType = "checking"
+	// AccountTypeSavings accounts limit how many withdrawals and outgoing
+	// transfers may be made each month
+	AccountTypeSavings AccountType = "savings"
+	// AccountTypeFeeCollection accounts receive fees charged by the fee engine
+	AccountTypeFeeCollection AccountType = "fee_collection"
+	// AccountTypeSettlement accounts hold funds the bank settles with
+	// outside parties
+	AccountTypeSettlement AccountType = "settlement"
+)
+
+// AccountTypes lists every account type
+var AccountTypes = []AccountType{
+	AccountTypeChecking,
+	AccountTypeSavings,
+	AccountTypeFeeCollection,
+	AccountTypeSettlement,
+}
+
+// IsValid reports whether the type is a known account type
+func (t AccountType) IsValid() bool {
+	for _, known := range AccountTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInternal reports whether accounts of this type belong to the bank and
+// may only be opened through the admin API
+func (t AccountType) IsInternal() bool {
+	return t == AccountTypeFeeCollection || t == AccountTypeSettlement
+}
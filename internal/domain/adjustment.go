@@ -0,0 +1,54 @@
+package domain
+
+import "strings"
+
+// AdjustmentRequest represents a manual balance correction made by an
+// operator, such as after reconciliation
+type AdjustmentRequest struct {
+	AccountID string
+	// Amount is signed: positive amounts credit the account and negative
+	// amounts debit it
+	Amount     Money
+	Currency   string
+	Reason     string
+	OperatorID string
+	Reference  string
+	// AllowNegativeBalance lets a debit take the balance below zero
+	AllowNegativeBalance bool
+}
+
+// IsValid validates the adjustment and normalizes its currency code
+func (r *AdjustmentRequest) IsValid() error {
+	if r.AccountID == "" {
+		return ErrMissingAccounts
+	}
+	if strings.TrimSpace(r.Reason) == "" || strings.TrimSpace(r.OperatorID) == "" {
+		return ErrInvalidAdjustment
+	}
+	if r.Amount == 0 {
+		return ErrInvalidAmount
+	}
+
+	if r.Currency == "" {
+		return ErrMissingCurrency
+	}
+	currency, err := SupportedCurrencies.Normalize(r.Currency)
+	if err != nil {
+		return err
+	}
+	r.Currency = currency
+
+	if limit, ok := ActiveTransactionLimits.MaxAmountIn(r.Currency); ok && r.Amount.Abs() > limit {
+		return ErrAmountTooLarge
+	}
+
+	return nil
+}
+
+// Direction returns whether the adjustment credits or debits the account
+func (r *AdjustmentRequest) Direction() EntryDirection {
+	if r.Amount < 0 {
+		return EntryDirectionDebit
+	}
+	return EntryDirectionCredit
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BalanceSnapshot is an account's balance at the close of a day, which ends
+// at midnight UTC
+type BalanceSnapshot struct {
+	AccountID string    `json:"account_id" db:"account_id"`
+	AsOfDate  time.Time `json:"as_of_date" db:"as_of_date"`
+	Balance   Money     `json:"balance" db:"balance"`
+	Currency  string    `json:"currency" db:"currency"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// MarshalJSON emits the date as YYYY-MM-DD and the balance as a decimal
+// string in the account's currency
+func (s BalanceSnapshot) MarshalJSON() ([]byte, error) {
+	type balanceSnapshot BalanceSnapshot
+	return json.Marshal(struct {
+		balanceSnapshot
+		AsOfDate string `json:"as_of_date"`
+		Balance  string `json:"balance"`
+	}{balanceSnapshot(s), s.AsOfDate.Format(StatementDateLayout), s.Balance.Format(s.Currency)})
+}
+
+// SnapshotDay returns the UTC day a time falls on
+func SnapshotDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
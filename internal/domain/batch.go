@@ -0,0 +1,108 @@
+package domain
+
+import "fmt"
+
+// MaxBatchLegs is the most legs one batch may have
+const MaxBatchLegs = 500
+
+// BatchRequest represents transactions submitted together, such as a payroll
+// run debiting one account and crediting many. The legs are queued as one
+// message and applied in order; if any leg fails the applied legs are undone
+// and the whole batch fails.
+type BatchRequest struct {
+	ID   string                `json:"batch_id"`
+	Legs []*TransactionRequest `json:"legs"`
+}
+
+// IsValid validates every leg, normalizing its currency code. Legs may be
+// deposits, withdrawals or transfers and cannot be scheduled.
+func (b *BatchRequest) IsValid() error {
+	if len(b.Legs) == 0 || len(b.Legs) > MaxBatchLegs {
+		return ErrInvalidBatch
+	}
+
+	for i, leg := range b.Legs {
+		if leg == nil {
+			return &BatchLegError{Index: i, Err: ErrInvalidTransactionType}
+		}
+		switch leg.Type {
+		case TransactionTypeDeposit, TransactionTypeWithdrawal, TransactionTypeTransfer:
+		default:
+			return &BatchLegError{Index: i, Err: ErrInvalidTransactionType}
+		}
+		if leg.ScheduledAt != nil {
+			return &BatchLegError{Index: i, Err: ErrInvalidSchedule}
+		}
+		if err := leg.IsValid(); err != nil {
+			return &BatchLegError{Index: i, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Debits returns the total each account is debited across the batch
+func (b *BatchRequest) Debits() map[string]Money {
+	debits := make(map[string]Money)
+	for _, leg := range b.Legs {
+		if leg.FromAccountID != nil {
+			debits[*leg.FromAccountID] += leg.Amount
+		}
+	}
+	return debits
+}
+
+// LegID returns the ID of a batch's leg. Zero-padded positions keep the legs
+// in order when sorted by ID.
+func LegID(batchID string, index int) string {
+	return fmt.Sprintf("%s-%03d", batchID, index)
+}
+
+// TransactionBatch reports a batch and the status of each of its legs
+type TransactionBatch struct {
+	ID     string            `json:"batch_id"`
+	Status TransactionStatus `json:"status"`
+	Legs   []*Transaction    `json:"legs"`
+}
+
+// NewTransactionBatch summarizes a batch from its legs. The batch has failed
+// if any leg failed, completed once every leg completed, and is processing
+// while some legs are applied and others are not.
+func NewTransactionBatch(id string, legs []*Transaction) *TransactionBatch {
+	batch := &TransactionBatch{ID: id, Status: TransactionStatusPending, Legs: legs}
+
+	completed := 0
+	for _, leg := range legs {
+		switch leg.Status {
+		case TransactionStatusFailed, TransactionStatusCancelled:
+			batch.Status = TransactionStatusFailed
+			return batch
+		case TransactionStatusProcessing:
+			batch.Status = TransactionStatusProcessing
+		case TransactionStatusCompleted:
+			completed++
+		}
+	}
+
+	if completed == len(legs) {
+		batch.Status = TransactionStatusCompleted
+	} else if completed > 0 {
+		batch.Status = TransactionStatusProcessing
+	}
+
+	return batch
+}
+
+// BatchLegError reports the leg of a batch that was rejected
+type BatchLegError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchLegError) Error() string {
+	return fmt.Sprintf("leg %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchLegError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChangeEntity identifies the kind of document a change event describes
+type ChangeEntity string
+
+const (
+	ChangeEntityAccount     ChangeEntity = "account"
+	ChangeEntityTransaction ChangeEntity = "transaction"
+)
+
+// ChangeOperation describes what happened to a document
+type ChangeOperation string
+
+const (
+	// ChangeOperationCreate is emitted for documents not modified since creation
+	ChangeOperationCreate ChangeOperation = "create"
+	// ChangeOperationUpdate is emitted for documents modified after creation
+	ChangeOperationUpdate ChangeOperation = "update"
+	// ChangeOperationDelete is a tombstone for a removed document
+	ChangeOperationDelete ChangeOperation = "delete"
+)
+
+// ChangeCursor is a position in the change feed. Events are totally ordered
+// by (updated_at, entity, id), so documents written in the same instant are
+// still strictly ordered and none is skipped on resumption.
+type ChangeCursor struct {
+	UpdatedAt time.Time
+	Entity    ChangeEntity
+	ID        string
+}
+
+// IsZero reports whether the cursor points at the start of the feed
+func (c ChangeCursor) IsZero() bool {
+	return c.UpdatedAt.IsZero() && c.Entity == "" && c.ID == ""
+}
+
+// Less reports whether c comes before other in feed order
+func (c ChangeCursor) Less(other ChangeCursor) bool {
+	if !c.UpdatedAt.Equal(other.UpdatedAt) {
+		return c.UpdatedAt.Before(other.UpdatedAt)
+	}
+	if c.Entity != other.Entity {
+		return c.Entity < other.Entity
+	}
+	return c.ID < other.ID
+}
+
+// TieBreak tells a source for entity which documents with an updated_at equal
+// to the cursor's still come after it: none when includeTies is false,
+// otherwise those with an ID greater than afterID. Sources return documents
+// matching updated_at > cursor OR (includeTies AND updated_at = cursor AND
+// id > afterID).
+func (c ChangeCursor) TieBreak(entity ChangeEntity) (includeTies bool, afterID string) {
+	switch {
+	case entity < c.Entity:
+		return false, ""
+	case entity == c.Entity:
+		return true, c.ID
+	default:
+		return true, ""
+	}
+}
+
+// Encode renders the cursor as an opaque token. Tokens compare
+// lexicographically in feed order.
+func (c ChangeCursor) Encode() string {
+	if c.IsZero() {
+		return ""
+	}
+	raw := fmt.Sprintf("%020d|%s|%s", c.UpdatedAt.UnixNano(), c.Entity, c.ID)
+	return hex.EncodeToString([]byte(raw))
+}
+
+// DecodeChangeCursor parses a token produced by Encode. An empty token is
+// the start of the feed.
+func DecodeChangeCursor(token string) (ChangeCursor, error) {
+	if token == "" {
+		return ChangeCursor{}, nil
+	}
+
+	raw, err := hex.DecodeString(token)
+	if err != nil {
+		return ChangeCursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return ChangeCursor{}, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ChangeCursor{}, ErrInvalidCursor
+	}
+
+	return ChangeCursor{
+		UpdatedAt: time.Unix(0, nanos).UTC(),
+		Entity:    ChangeEntity(parts[1]),
+		ID:        parts[2],
+	}, nil
+}
+
+// ChangeEvent is a single entry in the change feed, carrying the full current
+// document for creates and updates
+type ChangeEvent struct {
+	Cursor    string          `json:"cursor"`
+	Entity    ChangeEntity    `json:"entity"`
+	Operation ChangeOperation `json:"operation"`
+	ID        string          `json:"id"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Document  interface{}     `json:"document,omitempty"`
+}
+
+// Position returns the event's position in the feed
+func (e *ChangeEvent) Position() ChangeCursor {
+	return ChangeCursor{UpdatedAt: e.UpdatedAt, Entity: e.Entity, ID: e.ID}
+}
+
+// ChangeOperationFor classifies a live document by its timestamps
+func ChangeOperationFor(createdAt, updatedAt time.Time) ChangeOperation {
+	if updatedAt.Equal(createdAt) {
+		return ChangeOperationCreate
+	}
+	return ChangeOperationUpdate
+}
+
+// ChangeFeed is a page of the change feed
+type ChangeFeed struct {
+	Events     []*ChangeEvent `json:"events"`
+	NextCursor string         `json:"next_cursor"`
+	HasMore    bool           `json:"has_more"`
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency the ledger can hold
+type Currency struct {
+	Code string `json:"code"`
+	// Exponent is the number of decimal places of the minor unit
+	Exponent int `json:"exponent"`
+}
+
+// CurrencyRegistry is a set of currencies looked up by code
+type CurrencyRegistry struct {
+	currencies map[string]Currency
+}
+
+// NewCurrencyRegistry creates a registry of the given currencies
+func NewCurrencyRegistry(currencies ...Currency) *CurrencyRegistry {
+	registry := &CurrencyRegistry{currencies: make(map[string]Currency, len(currencies))}
+	for _, currency := range currencies {
+		currency.Code = NormalizeCurrency(currency.Code)
+		registry.currencies[currency.Code] = currency
+	}
+	return registry
+}
+
+// Lookup finds a currency by code, ignoring case
+func (r *CurrencyRegistry) Lookup(code string) (Currency, bool) {
+	currency, ok := r.currencies[NormalizeCurrency(code)]
+	return currency, ok
+}
+
+// Normalize returns the canonical code of a currency in the registry, or
+// ErrUnsupportedCurrency if it is not one
+func (r *CurrencyRegistry) Normalize(code string) (string, error) {
+	currency, ok := r.Lookup(code)
+	if !ok {
+		return "", ErrUnsupportedCurrency
+	}
+	return currency.Code, nil
+}
+
+// Only returns a registry restricted to the given codes. Codes not in this
+// registry are ignored.
+func (r *CurrencyRegistry) Only(codes ...string) *CurrencyRegistry {
+	subset := NewCurrencyRegistry()
+	for _, code := range codes {
+		if currency, ok := r.Lookup(code); ok {
+			subset.currencies[currency.Code] = currency
+		}
+	}
+	return subset
+}
+
+// Codes returns the registry's currency codes in order
+func (r *CurrencyRegistry) Codes() []string {
+	codes := make([]string, 0, len(r.currencies))
+	for code := range r.currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// NormalizeCurrency canonicalizes a currency code's case and spacing
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ISO4217 holds the circulating ISO 4217 currencies. Fund, precious metal and
+// testing codes such as XXX are left out.
+var ISO4217 = NewCurrencyRegistry(isoCurrencies()...)
+
+// SupportedCurrencies is the registry accounts and transactions are validated
+// against. Replace it with ISO4217.Only(...) at startup to accept a subset.
+var SupportedCurrencies = ISO4217
+
+// CurrencyExponent returns the number of decimal places a currency supports.
+// Unknown codes use two places.
+func CurrencyExponent(code string) int {
+	if currency, ok := ISO4217.Lookup(code); ok {
+		return currency.Exponent
+	}
+	return defaultCurrencyExponent
+}
+
+// CurrencyExponents returns the currencies with a non-default exponent,
+// grouped by exponent
+func CurrencyExponents() map[int][]string {
+	grouped := make(map[int][]string)
+	for _, code := range ISO4217.Codes() {
+		if exponent := CurrencyExponent(code); exponent != defaultCurrencyExponent {
+			grouped[exponent] = append(grouped[exponent], code)
+		}
+	}
+	return grouped
+}
+
+// defaultCurrencyExponent is the exponent of most currencies
+const defaultCurrencyExponent = 2
+
+func isoCurrencies() []Currency {
+	exponents := map[int]string{
+		0: "BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX VND VUV XAF XOF XPF",
+		2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BRL BSD BTN " +
+			"BWP BYN BZD CAD CDF CHF CNY COP CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD " +
+			"FKP GBP GEL GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR " +
+			"KPW KYD KZT LAK LBP LKR LRD LSL MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN " +
+			"MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK PHP PKR PLN QAR RON RSD RUB SAR SBD " +
+			"SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS TMT TOP TRY TTD TWD " +
+			"TZS UAH USD UYU UZS VES WST XCD YER ZAR ZMW ZWG",
+		3: "BHD IQD JOD KWD LYD OMR TND",
+	}
+
+	var currencies []Currency
+	for exponent, codes := range exponents {
+		for _, code := range strings.Fields(codes) {
+			currencies = append(currencies, Currency{Code: code, Exponent: exponent})
+		}
+	}
+	return currencies
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MaxDeadLetterPageSize bounds how many dead letters one peek returns
+const MaxDeadLetterPageSize = 100
+
+// MaxDeadLetterRequeueIDs bounds how many dead letters one requeue moves
+const MaxDeadLetterRequeueIDs = 100
+
+// DeadLetter is a message set aside after its handler failed permanently or
+// ran out of retries
+type DeadLetter struct {
+	ID    string `json:"id"`
+	Queue string `json:"queue"`
+	// FailureCount is how many times handling the message failed, over every
+	// time it was requeued
+	FailureCount   int             `json:"failure_count"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeadLetteredAt time.Time       `json:"dead_lettered_at"`
+	Body           json.RawMessage `json:"body"`
+}
+
+// DeadLetterRequeue reports the dead letters moved back onto their queue
+type DeadLetterRequeue struct {
+	Requeued []string `json:"requeued"`
+	Missing  []string `json:"missing"`
+}
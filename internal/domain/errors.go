@@ -1,27 +1,151 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 var (
 	// Account errors
-	ErrAccountNotFound   = errors.New("account not found")
-	ErrAccountExists     = errors.New("account already exists")
-	ErrInsufficientFunds = errors.New("insufficient funds")
-	ErrAccountInactive   = errors.New("account is inactive")
-	ErrInvalidAccountID  = errors.New("invalid account ID")
-	ErrConcurrentUpdate  = errors.New("concurrent update detected")
+	ErrAccountNotFound         = errors.New("account not found")
+	ErrAccountExists           = errors.New("account already exists")
+	ErrInsufficientFunds       = errors.New("insufficient funds")
+	ErrAccountInactive         = errors.New("account is inactive")
+	ErrAccountFrozen           = errors.New("account is frozen")
+	ErrAccountClosed           = errors.New("account is closed")
+	ErrInvalidAccountID        = errors.New("invalid account ID")
+	ErrConcurrentUpdate        = errors.New("concurrent update detected")
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	ErrInvalidOverdraft        = errors.New("overdraft limit must not be negative")
+	ErrInvalidMinimumBalance   = errors.New("minimum balance must not be negative")
+	ErrBelowMinimumBalance     = errors.New("balance would fall below the account's minimum balance")
+	ErrInvalidLimit            = errors.New("account limits must not be negative")
+	ErrLimitExceeded           = errors.New("account limit exceeded")
+	ErrInvalidAccountType      = errors.New("invalid account type")
+	ErrInternalAccountType     = errors.New("account type can only be opened through the admin API")
+	ErrWithdrawalLimitExceeded = errors.New("monthly withdrawal limit reached for savings account")
+	ErrAccountRestricted       = errors.New("fee collection accounts only accept fees")
+	ErrAccountAlreadyActive    = errors.New("account is already active")
+	ErrPendingDebits           = errors.New("account has outgoing transactions still to be processed")
+	ErrPendingTransactions     = errors.New("account has transactions still to be processed")
+	ErrResidualBalance         = errors.New("account balance must be zero to close it")
+	ErrStatusReasonRequired    = errors.New("a reason is required to freeze or unfreeze an account")
+	ErrInvalidAccountUpdate    = errors.New("account metadata is too large")
+	ErrNicknameTooLong         = errors.New("account nickname is too long")
+	ErrInvalidAccountLabels    = errors.New("account labels must be non-blank and within the allowed count and length")
+	ErrMissingUserID           = errors.New("missing user ID")
+	ErrInvalidBulkAccounts     = errors.New("bulk creation must have between 1 and the allowed number of accounts")
 
 	// Transaction errors
 	ErrTransactionNotFound         = errors.New("transaction not found")
+	ErrTransactionIDTaken          = errors.New("a transaction with this ID already exists")
 	ErrInvalidAmount               = errors.New("invalid amount")
+	ErrInvalidPrecision            = errors.New("amount has more decimal places than the currency supports")
 	ErrInvalidTransactionType      = errors.New("invalid transaction type")
 	ErrMissingCurrency             = errors.New("missing currency")
+	ErrUnsupportedCurrency         = errors.New("unsupported currency")
 	ErrMissingFromAccount          = errors.New("missing from account")
 	ErrMissingToAccount            = errors.New("missing to account")
 	ErrMissingAccounts             = errors.New("missing from and to accounts")
 	ErrSameAccount                 = errors.New("from and to accounts cannot be the same")
 	ErrTransactionAlreadyProcessed = errors.New("transaction already processed")
 	ErrCurrencyMismatch            = errors.New("currency mismatch")
+	ErrDuplicateSubmission         = errors.New("duplicate transaction submission")
+	ErrDuplicateReference          = errors.New("duplicate transaction reference")
+	ErrAmountTooLarge              = errors.New("amount exceeds the per-transaction maximum")
+	ErrMetadataTooLarge            = errors.New("metadata exceeds the allowed size")
+	ErrTransactionNotReversible    = errors.New("transaction cannot be reversed")
+	ErrTransactionAlreadyReversed  = errors.New("transaction already reversed")
+	ErrTransactionArchived         = errors.New("transaction is archived")
+	ErrInvalidTags                 = errors.New("invalid transaction tags")
+	ErrReceiptUnavailable          = errors.New("receipt available only for completed transactions")
+	ErrReceiptsNotConfigured       = errors.New("receipt signing secret not configured")
+	ErrInvalidSchedule             = errors.New("transaction cannot be scheduled")
+	ErrTransactionExpired          = errors.New("transaction expired while pending")
+	ErrApprovalExpired             = errors.New("transaction expired awaiting approval")
+	ErrNotAwaitingApproval         = errors.New("transaction is not awaiting approval")
+	ErrApprovalRequired            = errors.New("transfer needs approval and cannot be sent in a batch")
+	ErrTransactionNotRequeueable   = errors.New("transaction cannot be requeued")
+	ErrInvalidAdjustment           = errors.New("adjustment requires a reason and an operator")
+	ErrNegativeBalance             = errors.New("adjustment would take the balance below zero")
+	ErrTransactionNotRefundable    = errors.New("transaction cannot be refunded")
+	ErrTransactionNotCompleted     = errors.New("transaction has not completed")
+	ErrRefundExceedsOriginal       = errors.New("refund exceeds the amount left to refund")
+	ErrInvalidBatch                = errors.New("batch must have between 1 and 500 legs")
+	ErrBatchNotFound               = errors.New("batch not found")
+	ErrBatchFailed                 = errors.New("another leg of the batch failed")
+	ErrIdempotencyKeyReused        = errors.New("idempotency key already used with a different request")
+	ErrIdempotencyKeyInUse         = errors.New("a request with this idempotency key is still in progress")
+	ErrInvalidExternalParty        = errors.New("invalid external source or destination")
+	ErrExternalPartyRequired       = errors.New("deposits need an external source and withdrawals an external destination")
+	ErrRateUnavailable             = errors.New("exchange rate unavailable")
+	ErrQuotaExceeded               = errors.New("transaction quota exceeded")
+
+	// Hold errors
+	ErrHoldNotFound       = errors.New("hold not found")
+	ErrHoldNotActive      = errors.New("hold is no longer active")
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds the held amount")
+
+	// Standing order errors
+	ErrStandingOrderNotFound = errors.New("standing order not found")
+	ErrInvalidStandingOrder  = errors.New("invalid standing order schedule")
+	ErrStandingOrderClosed   = errors.New("standing order is cancelled or completed")
+
+	// Micro-deposit verification errors
+	ErrAccountAlreadyVerified       = errors.New("account already verified")
+	ErrMicroDepositPending          = errors.New("micro-deposit verification already pending")
+	ErrMicroDepositNotFound         = errors.New("no pending micro-deposit verification")
+	ErrMicroDepositExpired          = errors.New("micro-deposit verification expired")
+	ErrMicroDepositMismatch         = errors.New("micro-deposit amounts do not match")
+	ErrMicroDepositAttemptsExceeded = errors.New("too many micro-deposit verification attempts")
+
+	// Settlement errors
+	ErrSettlementGroupNotFound = errors.New("settlement group not found")
+	ErrInvalidSettlementGroup  = errors.New("invalid settlement group")
+
+	// Change feed errors
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// Statement errors
+	ErrStatementPeriodTooLong = errors.New("statement period is longer than allowed")
+	ErrStatementNotFound      = errors.New("statement not found")
+
+	// Export errors
+	ErrExportTooLarge          = errors.New("export covers more transactions than allowed")
+	ErrExportJobNotFound       = errors.New("export job not found")
+	ErrInvalidExportJob        = errors.New("export job needs an account, a known format and a valid filter")
+	ErrExportJobNotCancellable = errors.New("export job can only be cancelled while queued")
+	ErrExportNotReady          = errors.New("export file is not available")
+
+	// Search errors
+	ErrSearchQueryTooShort = errors.New("search query is too short")
+
+	// Lookup errors
+	ErrInvalidTransactionLookup = errors.New("lookup must have between 1 and the allowed number of transaction IDs, none blank")
+
+	// Dead letter errors
+	ErrDeadLetterQueueNotConfigured = errors.New("no dead letter queue is configured")
+	ErrInvalidDeadLetterRequeue     = errors.New("requeue must name between 1 and the allowed number of dead letter IDs, none blank")
+
+	// Reconciliation errors
+	ErrReconciliationReportNotFound = errors.New("no reconciliation report yet")
+	ErrDiscrepancyNotFound          = errors.New("account has no discrepancy in the latest reconciliation report")
+
+	// Authorization errors
+	ErrForbidden = errors.New("account belongs to another user")
+
+	// User verification errors
+	ErrVerificationRequired     = errors.New("user verification required")
+	ErrInvalidVerificationLevel = errors.New("invalid verification level")
+
+	// Balance snapshot errors
+	ErrBalanceSnapshotNotFound = errors.New("no balance snapshot for the date")
+
+	// Webhook errors
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+	ErrInvalidWebhook  = errors.New("webhook needs an http or https URL and known event types")
 
 	// General errors
 	ErrInvalidInput       = errors.New("invalid input")
@@ -30,3 +154,467 @@ var (
 	ErrInternalError      = errors.New("internal error")
 	ErrServiceUnavailable = errors.New("service unavailable")
 )
+
+// DuplicateTransactionError reports a submission identical to a recent
+// transaction that is still pending
+type DuplicateTransactionError struct {
+	ExistingTransactionID string
+}
+
+func (e *DuplicateTransactionError) Error() string {
+	return fmt.Sprintf("%s: matches pending transaction %s", ErrDuplicateSubmission, e.ExistingTransactionID)
+}
+
+func (e *DuplicateTransactionError) Unwrap() error {
+	return ErrDuplicateSubmission
+}
+
+// ResidualBalanceError reports an account that cannot be closed until its
+// balance is brought to zero
+type ResidualBalanceError struct {
+	Balance  Money
+	Currency string
+}
+
+func (e *ResidualBalanceError) Error() string {
+	return fmt.Sprintf("%s: %s %s remaining", ErrResidualBalance, e.Balance.Format(e.Currency), e.Currency)
+}
+
+func (e *ResidualBalanceError) Unwrap() error {
+	return ErrResidualBalance
+}
+
+// InsufficientFundsError reports a debit larger than the account's available
+// balance
+type InsufficientFundsError struct {
+	Available Money
+	Currency  string
+	// Overdraft reports whether the available amount includes an overdraft
+	Overdraft bool
+}
+
+func (e *InsufficientFundsError) Error() string {
+	if e.Overdraft {
+		return fmt.Sprintf("%s: %s %s available including overdraft", ErrInsufficientFunds, e.Available.Format(e.Currency), e.Currency)
+	}
+	return fmt.Sprintf("%s: %s %s available", ErrInsufficientFunds, e.Available.Format(e.Currency), e.Currency)
+}
+
+func (e *InsufficientFundsError) Unwrap() error {
+	return ErrInsufficientFunds
+}
+
+// BelowMinimumBalanceError reports a debit that would take an account's
+// balance below its minimum, and by how much
+type BelowMinimumBalanceError struct {
+	Minimum   Money
+	Shortfall Money
+	Currency  string
+}
+
+func (e *BelowMinimumBalanceError) Error() string {
+	return fmt.Sprintf("%s of %s %s: %s %s short", ErrBelowMinimumBalance, e.Minimum.Format(e.Currency), e.Currency, e.Shortfall.Format(e.Currency), e.Currency)
+}
+
+func (e *BelowMinimumBalanceError) Unwrap() error {
+	return ErrBelowMinimumBalance
+}
+
+// PrecisionError reports an amount with more decimal places than its
+// currency's minor unit allows
+type PrecisionError struct {
+	Currency string
+	Exponent int
+}
+
+func (e *PrecisionError) Error() string {
+	return fmt.Sprintf("%s: %s allows %d", ErrInvalidPrecision, e.Currency, e.Exponent)
+}
+
+func (e *PrecisionError) Unwrap() error {
+	return ErrInvalidPrecision
+}
+
+// BulkAccountsError reports a bulk creation with no accounts or more than
+// Max of them
+type BulkAccountsError struct {
+	Max int
+}
+
+func (e *BulkAccountsError) Error() string {
+	return fmt.Sprintf("%s: at most %d", ErrInvalidBulkAccounts, e.Max)
+}
+
+func (e *BulkAccountsError) Unwrap() error {
+	return ErrInvalidBulkAccounts
+}
+
+// ExportTooLargeError reports an export matching Count transactions, more
+// than the Max one export may hold
+type ExportTooLargeError struct {
+	Count int64
+	Max   int64
+}
+
+func (e *ExportTooLargeError) Error() string {
+	return fmt.Sprintf("%s: %d of at most %d", ErrExportTooLarge, e.Count, e.Max)
+}
+
+func (e *ExportTooLargeError) Unwrap() error {
+	return ErrExportTooLarge
+}
+
+// LimitExceededError reports an outgoing transaction over one of its
+// account's limits
+type LimitExceededError struct {
+	Limit    AccountLimit
+	Amount   Money
+	Used     Money
+	Currency string
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Limit == AccountLimitDailyOutgoing {
+		return fmt.Sprintf("%s: %s of %s, %s already used today", ErrLimitExceeded, e.Limit, e.Amount.Format(e.Currency), e.Used.Format(e.Currency))
+	}
+	return fmt.Sprintf("%s: %s of %s", ErrLimitExceeded, e.Limit, e.Amount.Format(e.Currency))
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// QuotaExceededError reports a user who has already submitted their quota of
+// transactions within the rolling window
+type QuotaExceededError struct {
+	Limit  int
+	Window time.Duration
+	// RetryAfter is how long until the oldest counted submission leaves
+	// the window
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: %d per %s, retry after %s", ErrQuotaExceeded, e.Limit, e.Window, e.RetryAfter)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// VerificationRequiredError reports a transaction an unverified user may not
+// make. DepositLimit and Deposited are set when a deposit would take the
+// user's deposits over their limit, and are zero for a withdrawal or transfer.
+type VerificationRequiredError struct {
+	TransactionType TransactionType
+	DepositLimit    Money
+	Deposited       Money
+	Currency        string
+}
+
+func (e *VerificationRequiredError) Error() string {
+	if e.TransactionType == TransactionTypeDeposit {
+		return fmt.Sprintf("%s: deposits limited to %s, %s already deposited", ErrVerificationRequired, e.DepositLimit.Format(e.Currency), e.Deposited.Format(e.Currency))
+	}
+	return fmt.Sprintf("%s: unverified users cannot make a %s", ErrVerificationRequired, e.TransactionType)
+}
+
+func (e *VerificationRequiredError) Unwrap() error {
+	return ErrVerificationRequired
+}
+
+// DuplicateReferenceError reports a reference already used by another
+// transaction from the same account that has not been cancelled
+type DuplicateReferenceError struct {
+	ExistingTransactionID string
+}
+
+func (e *DuplicateReferenceError) Error() string {
+	return fmt.Sprintf("%s: used by transaction %s", ErrDuplicateReference, e.ExistingTransactionID)
+}
+
+func (e *DuplicateReferenceError) Unwrap() error {
+	return ErrDuplicateReference
+}
+
+// CompensatedError reports a transfer whose credit failed permanently after
+// its debit was posted, and whose debited amount is being returned
+type CompensatedError struct {
+	Code   FailureCode
+	Reason string
+}
+
+func (e *CompensatedError) Error() string {
+	return e.Reason
+}
+
+// UnrecordedStatusError reports a transaction whose balance changes were
+// posted but whose completion could not be saved. Retrying it could post the
+// changes again, so it needs manual repair.
+type UnrecordedStatusError struct {
+	TransactionID string
+	Err           error
+}
+
+func (e *UnrecordedStatusError) Error() string {
+	return fmt.Sprintf("transaction %s was applied but its status could not be recorded: %v", e.TransactionID, e.Err)
+}
+
+func (e *UnrecordedStatusError) Unwrap() error {
+	return e.Err
+}
+
+// FailureCode is a machine-readable category for a failed transaction
+type FailureCode string
+
+const (
+	FailureCodeAccountNotFound      FailureCode = "account_not_found"
+	FailureCodeAccountInactive      FailureCode = "account_inactive"
+	FailureCodeAccountFrozen        FailureCode = "account_frozen"
+	FailureCodeAccountClosed        FailureCode = "account_closed"
+	FailureCodeAccountRestricted    FailureCode = "account_restricted"
+	FailureCodeCurrencyMismatch     FailureCode = "currency_mismatch"
+	FailureCodeRateUnavailable      FailureCode = "rate_unavailable"
+	FailureCodeInsufficientFunds    FailureCode = "insufficient_funds"
+	FailureCodeLimitExceeded        FailureCode = "limit_exceeded"
+	FailureCodeConcurrentConflict   FailureCode = "concurrent_conflict"
+	FailureCodeQueueError           FailureCode = "queue_error"
+	FailureCodeExpired              FailureCode = "expired"
+	FailureCodeVerificationRequired FailureCode = "verification_required"
+	FailureCodeInternal             FailureCode = "internal"
+)
+
+// FailureCodes lists every failure code
+var FailureCodes = []FailureCode{
+	FailureCodeAccountNotFound,
+	FailureCodeAccountInactive,
+	FailureCodeAccountFrozen,
+	FailureCodeAccountClosed,
+	FailureCodeAccountRestricted,
+	FailureCodeCurrencyMismatch,
+	FailureCodeRateUnavailable,
+	FailureCodeInsufficientFunds,
+	FailureCodeLimitExceeded,
+	FailureCodeConcurrentConflict,
+	FailureCodeQueueError,
+	FailureCodeExpired,
+	FailureCodeVerificationRequired,
+	FailureCodeInternal,
+}
+
+// IsValid reports whether the code is a known failure code
+func (c FailureCode) IsValid() bool {
+	for _, code := range FailureCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable reports whether a transaction that failed with the code may
+// succeed if it is processed again, its failure having come from the
+// ledger's infrastructure or timing rather than from the transaction
+func (c FailureCode) Retryable() bool {
+	switch c {
+	case FailureCodeRateUnavailable, FailureCodeConcurrentConflict, FailureCodeQueueError, FailureCodeExpired:
+		return true
+	}
+	return false
+}
+
+// failureCodes classifies every domain error, including those that cannot
+// fail a stored transaction, so that adding an error forces a decision
+var failureCodes = []struct {
+	err  error
+	code FailureCode
+}{
+	{ErrAccountNotFound, FailureCodeAccountNotFound},
+	{ErrInvalidAccountID, FailureCodeAccountNotFound},
+	{ErrAccountInactive, FailureCodeAccountInactive},
+	{ErrAccountFrozen, FailureCodeAccountFrozen},
+	{ErrAccountClosed, FailureCodeAccountClosed},
+	{ErrAccountRestricted, FailureCodeAccountRestricted},
+	{ErrWithdrawalLimitExceeded, FailureCodeLimitExceeded},
+	{ErrCurrencyMismatch, FailureCodeCurrencyMismatch},
+	{ErrRateUnavailable, FailureCodeRateUnavailable},
+	{ErrInsufficientFunds, FailureCodeInsufficientFunds},
+	{ErrBelowMinimumBalance, FailureCodeInsufficientFunds},
+	{ErrConcurrentUpdate, FailureCodeConcurrentConflict},
+	{ErrTransactionAlreadyProcessed, FailureCodeConcurrentConflict},
+	{ErrQueueError, FailureCodeQueueError},
+	{ErrTransactionExpired, FailureCodeExpired},
+	{ErrApprovalExpired, FailureCodeExpired},
+	{ErrNotAwaitingApproval, FailureCodeInternal},
+	{ErrApprovalRequired, FailureCodeInternal},
+	{ErrTransactionNotRequeueable, FailureCodeInternal},
+	{ErrAccountExists, FailureCodeInternal},
+	{ErrInvalidStatusTransition, FailureCodeInternal},
+	{ErrAccountAlreadyActive, FailureCodeInternal},
+	{ErrPendingDebits, FailureCodeInternal},
+	{ErrPendingTransactions, FailureCodeInternal},
+	{ErrResidualBalance, FailureCodeInternal},
+	{ErrStatusReasonRequired, FailureCodeInternal},
+	{ErrInvalidAccountUpdate, FailureCodeInternal},
+	{ErrNicknameTooLong, FailureCodeInternal},
+	{ErrInvalidAccountLabels, FailureCodeInternal},
+	{ErrTransactionNotFound, FailureCodeInternal},
+	{ErrTransactionIDTaken, FailureCodeInternal},
+	{ErrInvalidAmount, FailureCodeInternal},
+	{ErrInvalidPrecision, FailureCodeInternal},
+	{ErrInvalidTransactionType, FailureCodeInternal},
+	{ErrMissingCurrency, FailureCodeInternal},
+	{ErrUnsupportedCurrency, FailureCodeInternal},
+	{ErrMissingFromAccount, FailureCodeInternal},
+	{ErrMissingToAccount, FailureCodeInternal},
+	{ErrMissingAccounts, FailureCodeInternal},
+	{ErrSameAccount, FailureCodeInternal},
+	{ErrDuplicateSubmission, FailureCodeInternal},
+	{ErrDuplicateReference, FailureCodeInternal},
+	{ErrIdempotencyKeyReused, FailureCodeInternal},
+	{ErrIdempotencyKeyInUse, FailureCodeInternal},
+	{ErrAmountTooLarge, FailureCodeLimitExceeded},
+	{ErrQuotaExceeded, FailureCodeLimitExceeded},
+	{ErrMetadataTooLarge, FailureCodeInternal},
+	{ErrInvalidExternalParty, FailureCodeInternal},
+	{ErrExternalPartyRequired, FailureCodeInternal},
+	{ErrTransactionNotReversible, FailureCodeInternal},
+	{ErrTransactionAlreadyReversed, FailureCodeInternal},
+	{ErrTransactionArchived, FailureCodeInternal},
+	{ErrInvalidTags, FailureCodeInternal},
+	{ErrReceiptUnavailable, FailureCodeInternal},
+	{ErrReceiptsNotConfigured, FailureCodeInternal},
+	{ErrInvalidSchedule, FailureCodeInternal},
+	{ErrInvalidAdjustment, FailureCodeInternal},
+	{ErrNegativeBalance, FailureCodeInsufficientFunds},
+	{ErrTransactionNotRefundable, FailureCodeInternal},
+	{ErrTransactionNotCompleted, FailureCodeInternal},
+	{ErrRefundExceedsOriginal, FailureCodeLimitExceeded},
+	{ErrInvalidBatch, FailureCodeInternal},
+	{ErrBatchNotFound, FailureCodeInternal},
+	{ErrBatchFailed, FailureCodeInternal},
+	{ErrAccountAlreadyVerified, FailureCodeInternal},
+	{ErrMicroDepositPending, FailureCodeInternal},
+	{ErrMicroDepositNotFound, FailureCodeInternal},
+	{ErrMicroDepositExpired, FailureCodeInternal},
+	{ErrMicroDepositMismatch, FailureCodeInternal},
+	{ErrMicroDepositAttemptsExceeded, FailureCodeInternal},
+	{ErrSettlementGroupNotFound, FailureCodeInternal},
+	{ErrInvalidSettlementGroup, FailureCodeInternal},
+	{ErrInvalidCursor, FailureCodeInternal},
+	{ErrStatementPeriodTooLong, FailureCodeInternal},
+	{ErrStatementNotFound, FailureCodeInternal},
+	{ErrExportTooLarge, FailureCodeInternal},
+	{ErrExportJobNotFound, FailureCodeInternal},
+	{ErrInvalidExportJob, FailureCodeInternal},
+	{ErrExportJobNotCancellable, FailureCodeInternal},
+	{ErrExportNotReady, FailureCodeInternal},
+	{ErrSearchQueryTooShort, FailureCodeInternal},
+	{ErrInvalidTransactionLookup, FailureCodeInternal},
+	{ErrDeadLetterQueueNotConfigured, FailureCodeInternal},
+	{ErrInvalidDeadLetterRequeue, FailureCodeInternal},
+	{ErrReconciliationReportNotFound, FailureCodeInternal},
+	{ErrDiscrepancyNotFound, FailureCodeInternal},
+	{ErrBalanceSnapshotNotFound, FailureCodeInternal},
+	{ErrWebhookNotFound, FailureCodeInternal},
+	{ErrInvalidWebhook, FailureCodeInternal},
+	{ErrForbidden, FailureCodeInternal},
+	{ErrVerificationRequired, FailureCodeVerificationRequired},
+	{ErrInvalidVerificationLevel, FailureCodeInternal},
+	{ErrInvalidInput, FailureCodeInternal},
+	{ErrInvalidOverdraft, FailureCodeInternal},
+	{ErrInvalidMinimumBalance, FailureCodeInternal},
+	{ErrInvalidLimit, FailureCodeInternal},
+	{ErrLimitExceeded, FailureCodeLimitExceeded},
+	{ErrInvalidAccountType, FailureCodeInternal},
+	{ErrInternalAccountType, FailureCodeInternal},
+	{ErrMissingUserID, FailureCodeInternal},
+	{ErrInvalidBulkAccounts, FailureCodeInternal},
+	{ErrHoldNotFound, FailureCodeInternal},
+	{ErrHoldNotActive, FailureCodeInternal},
+	{ErrCaptureExceedsHold, FailureCodeInternal},
+	{ErrStandingOrderNotFound, FailureCodeInternal},
+	{ErrInvalidStandingOrder, FailureCodeInternal},
+	{ErrStandingOrderClosed, FailureCodeInternal},
+	{ErrDatabaseError, FailureCodeInternal},
+	{ErrInternalError, FailureCodeInternal},
+	{ErrServiceUnavailable, FailureCodeInternal},
+}
+
+// FailureCodeFor maps the error that failed a transaction to its failure code
+func FailureCodeFor(err error) FailureCode {
+	var compensated *CompensatedError
+	if errors.As(err, &compensated) {
+		return compensated.Code
+	}
+	for _, entry := range failureCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return FailureCodeInternal
+}
+
+// FailureCodeFromMessage recovers a failure code from a stored error message,
+// for transactions that failed before codes were recorded
+func FailureCodeFromMessage(message string) FailureCode {
+	for _, entry := range failureCodes {
+		if strings.Contains(message, entry.err.Error()) {
+			return entry.code
+		}
+	}
+	// Publish failures were stored with the broker's own error text
+	if strings.Contains(message, "failed to publish") || strings.Contains(message, "failed to declare queue") {
+		return FailureCodeQueueError
+	}
+	return FailureCodeInternal
+}
+
+// PermanentError marks an error that retrying cannot resolve, such as a
+// malformed queue message
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// transientErrors are the domain errors that may succeed when retried
+var transientErrors = []error{
+	ErrConcurrentUpdate,
+	ErrDatabaseError,
+	ErrQueueError,
+	ErrInternalError,
+	ErrServiceUnavailable,
+}
+
+// IsPermanent reports whether retrying err cannot change the outcome. Domain
+// errors such as insufficient funds are permanent; infrastructure errors and
+// errors the domain does not recognise, such as a lost database connection,
+// are transient.
+func IsPermanent(err error) bool {
+	if err == nil {
+		return false
+	}
+	var permanent *PermanentError
+	var compensated *CompensatedError
+	if errors.As(err, &permanent) || errors.As(err, &compensated) {
+		return true
+	}
+	for _, transient := range transientErrors {
+		if errors.Is(err, transient) {
+			return false
+		}
+	}
+	for _, entry := range failureCodes {
+		if errors.Is(err, entry.err) {
+			return true
+		}
+	}
+	return false
+}
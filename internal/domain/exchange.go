@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Exchange records the conversion of a cross-currency transfer. The debited
+// amount is the transaction's amount in its currency; the destination is
+// credited CreditedAmount in CreditedCurrency.
+type Exchange struct {
+	// Rate is how many units of CreditedCurrency one unit of the
+	// transaction's currency bought
+	Rate             Decimal   `json:"rate" bson:"rate"`
+	QuotedAt         time.Time `json:"quoted_at" bson:"quoted_at"`
+	CreditedAmount   Money     `json:"-" bson:"credited_amount"`
+	CreditedCurrency string    `json:"credited_currency" bson:"credited_currency"`
+}
+
+// MarshalJSON emits the credited amount as a decimal string in the credited
+// currency
+func (e Exchange) MarshalJSON() ([]byte, error) {
+	type exchange Exchange
+	return json.Marshal(struct {
+		exchange
+		CreditedAmount string `json:"credited_amount"`
+	}{exchange(e), e.CreditedAmount.Format(e.CreditedCurrency)})
+}
+
+// UnmarshalJSON reads the credited amount as a decimal in the credited
+// currency
+func (e *Exchange) UnmarshalJSON(data []byte) error {
+	type exchange Exchange
+	var decoded struct {
+		exchange
+		CreditedAmount Decimal `json:"credited_amount"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*e = Exchange(decoded.exchange)
+	amount, err := decoded.CreditedAmount.Money(e.CreditedCurrency)
+	if err != nil {
+		return err
+	}
+	e.CreditedAmount = amount
+	return nil
+}
+
+// Convert converts an amount in minor units of one currency into minor units
+// of another at rate, rounding half up to the target currency's precision
+func Convert(amount Money, from, to string, rate Decimal) (Money, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(string(rate)))
+	if !ok || value.Sign() <= 0 || strings.ContainsAny(string(rate), "eE/") {
+		return 0, ErrRateUnavailable
+	}
+
+	// Rescale from the source's minor units to the target's
+	value.Mul(value, new(big.Rat).SetInt64(int64(amount)))
+	shift := CurrencyExponent(to) - CurrencyExponent(from)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs64(int64(shift)))), nil))
+	if shift >= 0 {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	// Adding a half before flooring rounds half up for positive amounts
+	value.Add(value, big.NewRat(1, 2))
+	units := new(big.Int).Quo(value.Num(), value.Denom())
+	if !units.IsInt64() {
+		return 0, ErrInvalidAmount
+	}
+	return Money(units.Int64()), nil
+}
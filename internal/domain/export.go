@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	// ExportFormatCSV writes an export as CSV headed by ExportColumns
+	ExportFormatCSV = "csv"
+	// ExportFormatJSONLines writes an export as one JSON object per line
+	ExportFormatJSONLines = "json-lines"
+)
+
+// IsExportFormat reports whether transactions can be exported in the format
+func IsExportFormat(format string) bool {
+	return format == ExportFormatCSV || format == ExportFormatJSONLines
+}
+
+// ExportFileExtension returns the file extension of an export format
+func ExportFileExtension(format string) string {
+	if format == ExportFormatJSONLines {
+		return "jsonl"
+	}
+	return "csv"
+}
+
+// ExportColumns heads the columns of a CSV transaction export
+var ExportColumns = []string{"id", "type", "direction", "amount", "currency", "status", "counterparty", "reference", "created_at", "processed_at"}
+
+// ExportRow is one row of a transaction export
+type ExportRow struct {
+	ID           string            `json:"id"`
+	Type         TransactionType   `json:"type"`
+	Direction    EntryDirection    `json:"direction"`
+	Amount       string            `json:"amount"`
+	Currency     string            `json:"currency"`
+	Status       TransactionStatus `json:"status"`
+	Counterparty string            `json:"counterparty"`
+	Reference    string            `json:"reference"`
+	CreatedAt    time.Time         `json:"created_at"`
+	ProcessedAt  *time.Time        `json:"processed_at"`
+}
+
+// NewExportRow flattens a transaction as seen from the account. The
+// counterparty is the other account of a transfer, or the external party of a
+// deposit or withdrawal.
+func NewExportRow(view *AccountTransaction) *ExportRow {
+	row := &ExportRow{
+		ID:          view.ID,
+		Type:        view.Type,
+		Direction:   view.Direction,
+		Amount:      view.Amount.Format(view.Currency),
+		Currency:    view.Currency,
+		Status:      view.Status,
+		Reference:   view.Reference,
+		CreatedAt:   view.CreatedAt,
+		ProcessedAt: view.ProcessedAt,
+	}
+	switch {
+	case view.CounterpartyAccountID != nil:
+		row.Counterparty = *view.CounterpartyAccountID
+	case view.ExternalSource != nil:
+		row.Counterparty = view.ExternalSource.Reference
+	case view.ExternalDestination != nil:
+		row.Counterparty = view.ExternalDestination.Reference
+	}
+	return row
+}
+
+// Record returns the row as CSV fields in the order of ExportColumns
+func (r *ExportRow) Record() []string {
+	processedAt := ""
+	if r.ProcessedAt != nil {
+		processedAt = r.ProcessedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		r.ID,
+		string(r.Type),
+		string(r.Direction),
+		r.Amount,
+		r.Currency,
+		string(r.Status),
+		r.Counterparty,
+		r.Reference,
+		r.CreatedAt.UTC().Format(time.RFC3339),
+		processedAt,
+	}
+}
+
+// ExportEncoder writes export rows in one of the export formats
+type ExportEncoder struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// NewExportEncoder starts an export in the format, writing the column names
+// first for CSV
+func NewExportEncoder(w io.Writer, format string) (*ExportEncoder, error) {
+	if format == ExportFormatJSONLines {
+		return &ExportEncoder{json: json.NewEncoder(w)}, nil
+	}
+	encoder := &ExportEncoder{csv: csv.NewWriter(w)}
+	if err := encoder.csv.Write(ExportColumns); err != nil {
+		return nil, err
+	}
+	return encoder, nil
+}
+
+// Encode writes a transaction as seen from the account as one row
+func (e *ExportEncoder) Encode(view *AccountTransaction) error {
+	row := NewExportRow(view)
+	if e.json != nil {
+		return e.json.Encode(row)
+	}
+	return e.csv.Write(row.Record())
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (e *ExportEncoder) Flush() error {
+	if e.csv == nil {
+		return nil
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+)
+
+// ExportJobStatus is the state of an export job
+type ExportJobStatus string
+
+const (
+	// ExportJobQueued is waiting for the processor and may still be cancelled
+	ExportJobQueued ExportJobStatus = "queued"
+	// ExportJobRunning is being written by the processor
+	ExportJobRunning ExportJobStatus = "running"
+	// ExportJobCompleted has its file ready to download
+	ExportJobCompleted ExportJobStatus = "completed"
+	// ExportJobFailed stopped on an error; any partial file is removed
+	ExportJobFailed ExportJobStatus = "failed"
+	// ExportJobCancelled was cancelled before the processor started it
+	ExportJobCancelled ExportJobStatus = "cancelled"
+	// ExportJobExpired had its file removed after the retention period
+	ExportJobExpired ExportJobStatus = "expired"
+)
+
+// ExportJobRequest asks for an account's transactions matching a filter to
+// be exported in the background
+type ExportJobRequest struct {
+	AccountID string            `json:"account_id"`
+	Format    string            `json:"format"`
+	Filter    TransactionFilter `json:"filter"`
+}
+
+// Normalize defaults the format to CSV and scopes the filter to the account,
+// dropping the paging and ordering an export does not take. It returns
+// ErrInvalidExportJob for a missing account, an unknown format or a filter
+// with its bounds reversed.
+func (r *ExportJobRequest) Normalize() error {
+	r.AccountID = strings.TrimSpace(r.AccountID)
+	if r.AccountID == "" {
+		return ErrInvalidExportJob
+	}
+	if r.Format == "" {
+		r.Format = ExportFormatCSV
+	}
+	if !IsExportFormat(r.Format) {
+		return ErrInvalidExportJob
+	}
+
+	filter := &r.Filter
+	if filter.FromDate != nil && filter.ToDate != nil && filter.FromDate.After(*filter.ToDate) {
+		return ErrInvalidExportJob
+	}
+	for _, amount := range []*Decimal{filter.MinAmount, filter.MaxAmount} {
+		if amount == nil {
+			continue
+		}
+		if _, ok := amount.Bound(0, true); !ok {
+			return ErrInvalidExportJob
+		}
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil {
+		if cmp, _ := filter.MinAmount.Compare(*filter.MaxAmount); cmp > 0 {
+			return ErrInvalidExportJob
+		}
+	}
+
+	filter.AccountID = &r.AccountID
+	filter.AccountIDs = nil
+	filter.Limit, filter.Offset = 0, 0
+	filter.SortBy, filter.SortOrder = "", ""
+	return nil
+}
+
+// ExportJob is a transaction export written to a file in the background. The
+// processor updates RowCount as it goes, so that progress can be shown
+// against TotalRows, counted when the job starts.
+type ExportJob struct {
+	ID        string            `json:"id"`
+	AccountID string            `json:"account_id"`
+	UserID    string            `json:"user_id"`
+	Format    string            `json:"format"`
+	Filter    TransactionFilter `json:"filter"`
+	Status    ExportJobStatus   `json:"status"`
+	RowCount  int64             `json:"row_count"`
+	TotalRows *int64            `json:"total_rows,omitempty"`
+	// FileLocation is where the storage keeps the file, which is only handed
+	// out through the download endpoint
+	FileLocation string     `json:"-"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ExportStorage keeps the files written by export jobs
+type ExportStorage interface {
+	// Create opens a new file for writing and returns where it is kept
+	Create(ctx context.Context, name string) (io.WriteCloser, string, error)
+	// Open reads a file back from where it is kept
+	Open(ctx context.Context, location string) (io.ReadCloser, error)
+	// Delete removes a file; one already gone is not an error
+	Delete(ctx context.Context, location string) error
+}
@@ -0,0 +1,41 @@
+package domain
+
+// ExternalPartyType is the kind of party outside the ledger that funds a
+// deposit or receives a withdrawal
+type ExternalPartyType string
+
+const (
+	ExternalPartyBankAccount ExternalPartyType = "bank_account"
+	ExternalPartyCard        ExternalPartyType = "card"
+	ExternalPartyCash        ExternalPartyType = "cash"
+	ExternalPartyWallet      ExternalPartyType = "wallet"
+)
+
+// IsValid reports whether the type is a known external party type
+func (t ExternalPartyType) IsValid() bool {
+	switch t {
+	case ExternalPartyBankAccount, ExternalPartyCard, ExternalPartyCash, ExternalPartyWallet:
+		return true
+	}
+	return false
+}
+
+// MaxExternalReferenceLength bounds an external party's reference
+const MaxExternalReferenceLength = 255
+
+// ExternalParty identifies where a deposit's money came from or where a
+// withdrawal's went. Reference is the party's identifier with its provider,
+// such as a masked account number or a card token.
+type ExternalParty struct {
+	Type      ExternalPartyType `json:"type" bson:"type"`
+	Reference string            `json:"reference" bson:"reference"`
+	Name      string            `json:"name,omitempty" bson:"name,omitempty"`
+}
+
+// IsValid checks the party has a known type and a reference
+func (p *ExternalParty) IsValid() error {
+	if !p.Type.IsValid() || p.Reference == "" || len(p.Reference) > MaxExternalReferenceLength {
+		return ErrInvalidExternalParty
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// HoldStatus represents the state of a hold on account funds
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "active"
+	HoldStatusCaptured HoldStatus = "captured"
+	HoldStatusReleased HoldStatus = "released"
+	HoldStatusExpired  HoldStatus = "expired"
+)
+
+// Hold reserves funds on an account, as for a card authorization. An active
+// hold counts against the account's available balance without changing its
+// balance until it is captured, released or expires.
+type Hold struct {
+	ID             string     `json:"id" db:"id"`
+	AccountID      string     `json:"account_id" db:"account_id"`
+	Amount         Money      `json:"amount" db:"amount"`
+	CapturedAmount Money      `json:"captured_amount" db:"captured_amount"`
+	Currency       string     `json:"currency" db:"currency"`
+	Status         HoldStatus `json:"status" db:"status"`
+	Description    string     `json:"description" db:"description"`
+	Reference      string     `json:"reference" db:"reference"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	Version        int64      `json:"version" db:"version"` // For optimistic locking
+}
+
+// MarshalJSON emits the amounts as decimal strings in the hold's currency
+func (h Hold) MarshalJSON() ([]byte, error) {
+	type hold Hold
+	return json.Marshal(struct {
+		hold
+		Amount         string `json:"amount"`
+		CapturedAmount string `json:"captured_amount"`
+	}{hold(h), h.Amount.Format(h.Currency), h.CapturedAmount.Format(h.Currency)})
+}
+
+// IsExpired reports whether the hold's window has closed
+func (h *Hold) IsExpired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
+
+// CaptureID returns the ID of the transaction debiting the captured amount
+func (h *Hold) CaptureID() string {
+	return h.ID + "-capture"
+}
+
+// HoldRequest represents a request to place a hold
+type HoldRequest struct {
+	AccountID   string `json:"account_id"`
+	Amount      Money  `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+	Reference   string `json:"reference"`
+}
+
+// IsValid validates the hold request, normalizing its currency
+func (hr *HoldRequest) IsValid() error {
+	if hr.AccountID == "" {
+		return ErrMissingFromAccount
+	}
+	if hr.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	if hr.Currency == "" {
+		return ErrMissingCurrency
+	}
+	currency, err := SupportedCurrencies.Normalize(hr.Currency)
+	if err != nil {
+		return err
+	}
+	hr.Currency = currency
+
+	if limit, ok := ActiveTransactionLimits.MaxAmountIn(hr.Currency); ok && hr.Amount > limit {
+		return ErrAmountTooLarge
+	}
+
+	return nil
+}
@@ -2,62 +2,664 @@ package domain
 
 import (
 	"context"
+	"io"
+	"time"
 )
 
 // AccountRepository defines the interface for account data operations
 type AccountRepository interface {
-	Create(ctx context.Context, account *Account) error
+	// Create inserts the account and records its events in the same
+	// database transaction
+	Create(ctx context.Context, account *Account, events ...*AccountEvent) error
+	// CreateMany inserts the accounts in one database transaction, skipping
+	// any whose user already has an account of the currency and type, and
+	// reports the IDs of those inserted. Only the events of inserted accounts
+	// are recorded.
+	CreateMany(ctx context.Context, accounts []*Account, events ...*AccountEvent) (map[string]bool, error)
 	GetByID(ctx context.Context, id string) (*Account, error)
 	GetByUserID(ctx context.Context, userID string) ([]*Account, error)
-	Update(ctx context.Context, account *Account) error
-	UpdateBalance(ctx context.Context, id string, newBalance float64, version int64) error
+	// Update saves the account and records its events in the same database
+	// transaction
+	Update(ctx context.Context, account *Account, events ...*AccountEvent) error
+	UpdateBalance(ctx context.Context, id string, newBalance Money, version int64) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset int) ([]*Account, error)
+	// List pages through the accounts the filter matches, newest first
+	List(ctx context.Context, filter *AccountFilter) ([]*Account, error)
+	// CountAccounts counts every account the filter matches, ignoring its
+	// limit and offset
+	CountAccounts(ctx context.Context, filter *AccountFilter) (int64, error)
+	// SetBelowThreshold records whether an account is below its low balance
+	// threshold, reporting whether the flag changed
+	SetBelowThreshold(ctx context.Context, id string, below bool) (bool, error)
+	// ListAfter pages through accounts of every type in ID order, starting
+	// after afterID, so that a walk over all accounts is not thrown off by
+	// accounts opened meanwhile
+	ListAfter(ctx context.Context, afterID string, limit int) ([]*Account, error)
+	// SumBalances totals the balances and opening balances of every account
+	// in each currency
+	SumBalances(ctx context.Context) ([]*BalanceTotal, error)
+	// GetByIDs retrieves the accounts with the IDs in one query, leaving out
+	// those that do not exist
+	GetByIDs(ctx context.Context, ids []string) ([]*Account, error)
+	// ListUsers pages through the users holding accounts in user ID order,
+	// aggregating each user's accounts
+	ListUsers(ctx context.Context, filter *UserFilter) ([]*UserOverview, error)
+	// ListEvents pages through an account's events, newest first
+	ListEvents(ctx context.Context, accountID string, limit, offset int) ([]*AccountEvent, error)
+}
+
+// LedgerEntryRepository defines the interface for ledger entry data operations
+type LedgerEntryRepository interface {
+	// Post writes the entries and moves each account to its entry's resulting
+	// balance atomically. It fails with ErrConcurrentUpdate if any account has
+	// changed, and with ErrTransactionAlreadyProcessed if the entries exist.
+	Post(ctx context.Context, posting LedgerPosting) error
+	// IsApplied reports whether a transaction's entries have been posted,
+	// which is recorded atomically with the balances they change
+	IsApplied(ctx context.Context, transactionID string) (bool, error)
+	GetByAccountID(ctx context.Context, accountID string, limit, offset int) ([]*LedgerEntry, error)
+	// BalancesAt returns the resulting balance of each account's last entry
+	// before at, leaving out accounts with no entry by then
+	BalancesAt(ctx context.Context, accountIDs []string, at time.Time) (map[string]Money, error)
+	// SumSince returns the net change of an account's entries since a time
+	SumSince(ctx context.Context, accountID string, since time.Time) (Money, error)
 }
 
 // TransactionRepository defines the interface for transaction data operations
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *Transaction) error
 	GetByID(ctx context.Context, id string) (*Transaction, error)
+	// GetByIDs retrieves the transactions with the IDs in one query, leaving
+	// out those not found
+	GetByIDs(ctx context.Context, ids []string) ([]*Transaction, error)
 	GetByAccountID(ctx context.Context, accountID string, filter *TransactionFilter) ([]*Transaction, error)
+	// EachByAccountID calls fn with each of an account's transactions the
+	// filter matches, oldest first, as they are read, ignoring the filter's
+	// limit and offset. An error from fn stops the walk and is returned.
+	EachByAccountID(ctx context.Context, accountID string, filter *TransactionFilter, fn func(*Transaction) error) error
 	GetByFilter(ctx context.Context, filter *TransactionFilter) ([]*Transaction, error)
+	// EachByFilter calls fn with each transaction of the page the filter
+	// selects, in its sort order, as they are read. An error from fn stops
+	// the walk and is returned.
+	EachByFilter(ctx context.Context, filter *TransactionFilter, fn func(*Transaction) error) error
 	Update(ctx context.Context, transaction *Transaction) error
+	// UpdateTags replaces a transaction's tags and nothing else
+	UpdateTags(ctx context.Context, id string, tags []string) error
 	UpdateStatus(ctx context.Context, id string, status TransactionStatus, errorMessage string) error
+	// UpdateStatusIf updates the status of a transaction still in the expected
+	// status, failing with ErrTransactionAlreadyProcessed otherwise
+	UpdateStatusIf(ctx context.Context, id string, expected, status TransactionStatus, errorMessage string) error
+	MarkFailed(ctx context.Context, id string, code FailureCode, errorMessage string) error
+	// MarkCompleted completes a transaction with the balance changes it was
+	// applied with
+	MarkCompleted(ctx context.Context, id string, changes []*BalanceChange) error
 	Count(ctx context.Context, filter *TransactionFilter) (int64, error)
+	// Search finds the transactions whose description or reference match any
+	// word of the query, most relevant first, among those the filter matches
+	Search(ctx context.Context, query string, filter *TransactionFilter) ([]*TransactionMatch, error)
+	SetSettlementBatch(ctx context.Context, ids []string, batchID string) (int64, error)
+	// ClaimReversal records reversalID on a completed transaction that has no
+	// reversal yet, failing with ErrTransactionAlreadyReversed otherwise
+	ClaimReversal(ctx context.Context, id, reversalID string) error
+	// ResolveReversal marks a claimed reversal as completed, setting
+	// reversed_by, or releases the claim so the transaction can be reversed again
+	ResolveReversal(ctx context.Context, id, reversalID string, completed bool) error
+	// ListDueScheduled lists scheduled transactions whose time has come,
+	// earliest first
+	ListDueScheduled(ctx context.Context, now time.Time, limit int) ([]*Transaction, error)
+	// ClaimProcessing moves a pending transaction to processing, failing with
+	// ErrTransactionAlreadyProcessed if it is no longer pending
+	ClaimProcessing(ctx context.Context, id string) error
+	// ReleaseScheduled moves a scheduled transaction to pending, failing with
+	// ErrTransactionAlreadyProcessed if it is no longer scheduled
+	ReleaseScheduled(ctx context.Context, id string) error
+	// ListStalePending lists transactions pending since before the cutoff,
+	// counting from when they were last queued, oldest first. Transactions
+	// whose message is still in the outbox have not been queued yet.
+	ListStalePending(ctx context.Context, before time.Time, limit int) ([]*Transaction, error)
+	// FailPending marks a transaction failed only if it is still pending,
+	// failing with ErrTransactionAlreadyProcessed otherwise
+	FailPending(ctx context.Context, id string, code FailureCode, errorMessage string) error
+	// FailIf marks a transaction failed only if it is still in the expected
+	// status, failing with ErrTransactionAlreadyProcessed otherwise
+	FailIf(ctx context.Context, id string, expected TransactionStatus, code FailureCode, errorMessage string) error
+	// ListAwaitingApproval lists transactions awaiting approval since before
+	// the cutoff, oldest first
+	ListAwaitingApproval(ctx context.Context, before time.Time, limit int) ([]*Transaction, error)
+	// RequeuePending records that a pending transaction that has not been
+	// requeued before is being queued again, failing with
+	// ErrTransactionAlreadyProcessed otherwise
+	RequeuePending(ctx context.Context, id string) error
+	// Requeue returns a transaction still in the expected status, and for a
+	// failed one with the expected failure code, to pending, recording who
+	// queued it again. It fails with ErrTransactionAlreadyProcessed if the
+	// transaction has moved on.
+	Requeue(ctx context.Context, id string, expected TransactionStatus, code FailureCode, actor string) (*Transaction, error)
+	// RecordRefund adds a refund's amount to a completed, unreversed
+	// transaction's refunded amount, failing with ErrRefundExceedsOriginal if
+	// the total would exceed the transaction's amount. A refund already
+	// recorded is not counted again.
+	RecordRefund(ctx context.Context, id, refundID string, amount Money) error
+	// ReleaseRefund removes a recorded refund's amount again
+	ReleaseRefund(ctx context.Context, id, refundID string, amount Money) error
+	// GetByBatchID lists the legs of a batch in order
+	GetByBatchID(ctx context.Context, batchID string) ([]*Transaction, error)
+	// SetOutbox saves a message to publish for a stored transaction
+	SetOutbox(ctx context.Context, id string, message *OutboxMessage) error
+	// ListUndispatched lists transactions whose outbox message was saved
+	// before the cutoff and has not been published, oldest first
+	ListUndispatched(ctx context.Context, before time.Time, limit int) ([]*Transaction, error)
+	// MarkDispatched clears a transaction's outbox message once published,
+	// recording its enqueue sequence unless that is zero
+	MarkDispatched(ctx context.Context, id string, sequence int64) error
+	// CountUndispatched counts the outbox messages waiting to be published
+	CountUndispatched(ctx context.Context) (int64, error)
+	// RecordCompensation records a compensation on a transfer and flags it
+	// compensated in its metadata, unless one is already recorded
+	RecordCompensation(ctx context.Context, id string, compensation *Compensation) error
+	// CompleteCompensation marks a transfer's compensation completed
+	CompleteCompensation(ctx context.Context, id string, completedAt time.Time) error
+	// RecordExchange records the conversion a cross-currency transfer is
+	// posted with
+	RecordExchange(ctx context.Context, id string, exchange *Exchange) error
+	// SumOutgoing totals the completed and processing withdrawals and
+	// transfers from an account created since a time, leaving out one
+	// transaction
+	SumOutgoing(ctx context.Context, accountID string, since time.Time, excludeID string) (Money, error)
+	// SumMovements totals, for each of the accounts, the balance changes its
+	// transactions account for as Transaction.Movements defines them.
+	// Accounts without any are left out.
+	SumMovements(ctx context.Context, accountIDs []string) (map[string]Money, error)
+	// SumMovementsByType totals the balance changes of every transaction,
+	// as Transaction.Movements defines them, by type and currency
+	SumMovementsByType(ctx context.Context) ([]*MovementTotal, error)
+	// SumDeposits totals the completed deposits into any of the accounts
+	SumDeposits(ctx context.Context, accountIDs []string) (Money, error)
+	// SummarizeByAccount totals an account's transactions created between
+	// from and to, either of which may be nil, in one query
+	SummarizeByAccount(ctx context.Context, accountID string, from, to *time.Time) (*AccountTotals, error)
+	// SummarizeActivity finds the latest transaction involving any of the
+	// accounts and totals their completed deposits and withdrawals since a
+	// time by currency, in one query
+	SummarizeActivity(ctx context.Context, accountIDs []string, since time.Time) (*AccountActivity, error)
+	// ArchiveBefore moves up to limit archivable transactions created before
+	// a time to the archive, oldest first, returning how many were moved.
+	// Archived transactions are still read, flagged as archived.
+	ArchiveBefore(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// ReconciliationReportRepository defines the interface for reconciliation report data operations
+type ReconciliationReportRepository interface {
+	// Save stores a report, replacing any earlier version of it
+	Save(ctx context.Context, report *ReconciliationReport) error
+	// GetLatest returns the report of the most recent run, failing with
+	// ErrReconciliationReportNotFound if there has been none
+	GetLatest(ctx context.Context) (*ReconciliationReport, error)
+}
+
+// BalanceSnapshotRepository defines the interface for balance snapshot data operations
+type BalanceSnapshotRepository interface {
+	// Save stores the snapshots, replacing any taken for the same account and day
+	Save(ctx context.Context, snapshots []*BalanceSnapshot) error
+	// Get fails with ErrBalanceSnapshotNotFound if the account has no
+	// snapshot for the day
+	Get(ctx context.Context, accountID string, day time.Time) (*BalanceSnapshot, error)
+	// GetLatestBefore returns the account's last snapshot for a day before
+	// the given one, failing with ErrBalanceSnapshotNotFound if there is none
+	GetLatestBefore(ctx context.Context, accountID string, day time.Time) (*BalanceSnapshot, error)
+	// LatestDay returns the last day snapshotted, or nil if none has been
+	LatestDay(ctx context.Context) (*time.Time, error)
+}
+
+// MonthlyStatementRepository defines the interface for monthly statement data operations
+type MonthlyStatementRepository interface {
+	// Create stores the statement, returning false without storing it if the
+	// account already has one for the month
+	Create(ctx context.Context, statement *MonthlyStatement) (bool, error)
+	// GetByID fails with ErrStatementNotFound if there is no such statement
+	GetByID(ctx context.Context, id string) (*MonthlyStatement, error)
+	// GetForMonth fails with ErrStatementNotFound if the account has no
+	// statement for the month, given as YYYY-MM
+	GetForMonth(ctx context.Context, accountID, month string) (*MonthlyStatement, error)
+	// ListByAccount pages through an account's statements without their
+	// lines, newest month first
+	ListByAccount(ctx context.Context, accountID string, limit, offset int) ([]*MonthlyStatement, error)
+	// MarkNotified records when the statement's event was published
+	MarkNotified(ctx context.Context, id string, at time.Time) error
+}
+
+// MicroDepositRepository defines the interface for micro-deposit challenge data operations
+type MicroDepositRepository interface {
+	Create(ctx context.Context, challenge *MicroDepositChallenge) error
+	GetPendingByAccountID(ctx context.Context, accountID string) (*MicroDepositChallenge, error)
+	RecordAttempt(ctx context.Context, id string) (int, error)
+	Resolve(ctx context.Context, id string, status MicroDepositStatus) error
+	MarkClawedBack(ctx context.Context, id string, at time.Time) error
+	ListExpiredPending(ctx context.Context, now time.Time, limit int) ([]*MicroDepositChallenge, error)
+}
+
+// HoldRepository defines the interface for hold data operations
+type HoldRepository interface {
+	// Place records the hold and adds it to the account's held amount. It
+	// fails with ErrConcurrentUpdate if the account has changed.
+	Place(ctx context.Context, hold *Hold, account *Account) error
+	GetByID(ctx context.Context, id string) (*Hold, error)
+	// Resolve moves an active hold still at hold.Version to hold.Status,
+	// removes it from the account's held amount and applies the posting, all
+	// together. It fails with ErrConcurrentUpdate if the hold or the account
+	// has changed.
+	Resolve(ctx context.Context, hold *Hold, account *Account, posting LedgerPosting) error
+	ListExpiredActive(ctx context.Context, now time.Time, limit int) ([]*Hold, error)
+}
+
+// WebhookRepository defines the interface for webhook subscription and
+// delivery data operations
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *WebhookSubscription) error
+	GetSubscription(ctx context.Context, id string) (*WebhookSubscription, error)
+	// ListSubscriptionsFor lists the active subscriptions to an event type
+	ListSubscriptionsFor(ctx context.Context, eventType NotificationType) ([]*WebhookSubscription, error)
+	DeactivateSubscription(ctx context.Context, id string) error
+	// CreateDelivery records a delivery, reporting false without changing
+	// anything if the subscription already has one for the event
+	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) (bool, error)
+	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	// ClaimDueDeliveries returns up to limit pending deliveries due by now,
+	// pushing their next attempt to lease so no other worker claims them
+	ClaimDueDeliveries(ctx context.Context, now, lease time.Time, limit int) ([]*WebhookDelivery, error)
+	// ListDeliveries lists a subscription's latest deliveries, newest first
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*WebhookDelivery, error)
+}
+
+// ExportJobRepository defines the interface for export job data operations
+type ExportJobRepository interface {
+	Create(ctx context.Context, job *ExportJob) error
+	Get(ctx context.Context, id string) (*ExportJob, error)
+	// Claim marks a queued job running and returns it, as it does a running
+	// job whose progress has not moved since staleBefore, left by a worker
+	// that stopped. It returns nil for a job in any other state.
+	Claim(ctx context.Context, id string, now, staleBefore time.Time) (*ExportJob, error)
+	// UpdateProgress records a running job's rows written and total
+	UpdateProgress(ctx context.Context, job *ExportJob) error
+	// Finish records a job's outcome, its file and when the file expires
+	Finish(ctx context.Context, job *ExportJob) error
+	// Cancel cancels a queued job, failing with ErrExportJobNotCancellable
+	// once it has started
+	Cancel(ctx context.Context, id string) (*ExportJob, error)
+	// ListExpired lists up to limit completed jobs whose files expire by now
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*ExportJob, error)
+	// Expire marks a completed job expired once its file is removed
+	Expire(ctx context.Context, id string) error
 }
 
-// MessageQueue defines the interface for message queue operations
+// UserVerificationRepository defines the interface for user verification
+// data operations
+type UserVerificationRepository interface {
+	// Get retrieves a user's verification, which is unverified for a user
+	// who has never had a level set
+	Get(ctx context.Context, userID string) (*UserVerification, error)
+	// Set records a user's verification level
+	Set(ctx context.Context, verification *UserVerification) error
+}
+
+// SettlementGroupRepository defines the interface for settlement group data operations
+type SettlementGroupRepository interface {
+	Create(ctx context.Context, group *SettlementGroup) error
+	GetByID(ctx context.Context, id string) (*SettlementGroup, error)
+	List(ctx context.Context) ([]*SettlementGroup, error)
+	Update(ctx context.Context, group *SettlementGroup) error
+}
+
+// StandingOrderRepository defines the interface for standing order data operations
+type StandingOrderRepository interface {
+	Create(ctx context.Context, order *StandingOrder) error
+	GetByID(ctx context.Context, id string) (*StandingOrder, error)
+	// List returns the orders paying from or into the account, or every
+	// order when accountID is empty
+	List(ctx context.Context, accountID string) ([]*StandingOrder, error)
+	// Update saves the order's amount, description and status, failing with
+	// ErrStandingOrderClosed if the stored order is cancelled or completed
+	Update(ctx context.Context, order *StandingOrder) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*StandingOrder, error)
+	// Claim saves the order's advanced schedule if it is still active and
+	// due at now with the next run it was listed with, failing with
+	// ErrConcurrentUpdate otherwise, so only one processor runs each due run
+	Claim(ctx context.Context, order *StandingOrder, previousRunAt, now time.Time) error
+	AppendTransaction(ctx context.Context, id, transactionID string) error
+}
+
+// ChangeSource lists the changes of one store in feed order
+type ChangeSource interface {
+	// Changes returns up to limit events after the cursor whose updated_at is
+	// before until, ordered by (updated_at, id)
+	Changes(ctx context.Context, after ChangeCursor, until time.Time, limit int) ([]*ChangeEvent, error)
+}
+
+// SubmissionGuard records recent transaction submissions by fingerprint so
+// accidental duplicates can be detected across API replicas
+type SubmissionGuard interface {
+	// Claim registers transactionID for the fingerprint unless another
+	// submission claimed it less than window before now, in which case the
+	// holder's transaction ID is returned instead
+	Claim(ctx context.Context, fingerprint, transactionID string, now time.Time, window time.Duration) (string, error)
+	// Takeover hands the fingerprint from previousID to transactionID, returning
+	// the current holder's ID if someone else took it over first
+	Takeover(ctx context.Context, fingerprint, previousID, transactionID string, now time.Time) (string, error)
+}
+
+// IdempotencyStore remembers the transaction created for each idempotency key
+// so that retried submissions are answered alike across API replicas
+type IdempotencyStore interface {
+	// Claim stores record unless its key was claimed less than ttl before
+	// record.CreatedAt, in which case the existing record is returned instead
+	Claim(ctx context.Context, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	// Release removes the key's record if it still belongs to transactionID,
+	// so that a submission that was rejected can be retried
+	Release(ctx context.Context, key, transactionID string) error
+}
+
+// ExchangeRateProvider quotes exchange rates between currencies
+type ExchangeRateProvider interface {
+	// GetRate returns how many units of to one unit of from buys, and when
+	// the rate was quoted
+	GetRate(ctx context.Context, from, to string) (Decimal, time.Time, error)
+}
+
+// MessageQueue defines the interface for message queue operations. Publish
+// and setting up a subscription return once ctx is done. The handler is given
+// a context for each message, done once the message has taken too long or
+// the subscription's drain runs out.
 type MessageQueue interface {
 	Publish(ctx context.Context, queueName string, message []byte) error
-	Subscribe(ctx context.Context, queueName string, handler func([]byte) error) error
+	Subscribe(ctx context.Context, queueName string, handler func(ctx context.Context, data []byte) error) (Subscription, error)
 	Close() error
 }
 
+// Subscription is a consumer started by MessageQueue.Subscribe, which takes
+// no more messages once the context it was started with is done
+type Subscription interface {
+	// Drain waits for the messages in hand when the subscription's context
+	// was done to be handled and acknowledged, and returns how many were.
+	// Once ctx is done, the handlers still running are cancelled and their
+	// messages returned to the queue.
+	Drain(ctx context.Context) (int, error)
+}
+
+// DeadLetterQueue inspects and replays the messages a queue's handler gave up
+// on. Both fail with ErrDeadLetterQueueNotConfigured if the queue has no
+// dead letter queue.
+type DeadLetterQueue interface {
+	// PeekDeadLetters returns up to limit of the queue's dead letters, oldest
+	// first, leaving them in place
+	PeekDeadLetters(ctx context.Context, queueName string, limit int) ([]*DeadLetter, error)
+	// RequeueDeadLetters moves the dead letters with the IDs back onto the
+	// queue, reporting those not found
+	RequeueDeadLetters(ctx context.Context, queueName string, ids []string) (*DeadLetterRequeue, error)
+}
+
 // AccountService defines the interface for account business logic
 type AccountService interface {
-	CreateAccount(ctx context.Context, userID string, initialBalance float64, currency string) (*Account, error)
+	// CreateAccount opens an account of the type, or a checking account if
+	// accountType is empty
+	CreateAccount(ctx context.Context, userID string, initialBalance Money, currency string, accountType AccountType, details AccountDetails) (*Account, error)
+	// CreateAccounts opens many accounts at once, reporting on each in
+	// request order. Duplicate and invalid accounts are skipped without
+	// failing the rest, and a dry run only validates them.
+	CreateAccounts(ctx context.Context, accounts []*NewAccount, dryRun bool) ([]*BulkAccountResult, error)
 	GetAccount(ctx context.Context, id string) (*Account, error)
-	GetAccountsByUser(ctx context.Context, userID string) ([]*Account, error)
-	GetAccountSummary(ctx context.Context, id string) (*AccountSummary, error)
-	ListAccounts(ctx context.Context, limit, offset int) ([]*Account, error)
-	DeactivateAccount(ctx context.Context, id string) error
+	GetAccountsByUser(ctx context.Context, userID string, accountType AccountType, label string) ([]*Account, error)
+	// GetAccountSummary totals an account's transactions created between
+	// from and to, or all of them if both are nil
+	GetAccountSummary(ctx context.Context, id string, from, to *time.Time) (*AccountSummary, error)
+	// ListAccountEvents pages through the changes to an account's status and
+	// limits, newest first
+	ListAccountEvents(ctx context.Context, id string, limit, offset int) ([]*AccountEvent, error)
+	// ListAccounts pages through the accounts the filter matches, counting
+	// them all only if includeTotal is set
+	ListAccounts(ctx context.Context, filter *AccountFilter, includeTotal bool) (*AccountPage, error)
+	// GetUserSummary gathers a user's accounts by currency with their recent
+	// activity, failing with ErrAccountNotFound if the user has none
+	GetUserSummary(ctx context.Context, userID string) (*UserSummary, error)
+	// ListUsers pages through the users holding accounts with their account
+	// counts, balances by currency and account statuses
+	ListUsers(ctx context.Context, filter *UserFilter) (*UserPage, error)
+	DeactivateAccount(ctx context.Context, id string, force bool) error
+	ActivateAccount(ctx context.Context, id string) (*Account, error)
+	CloseAccount(ctx context.Context, id string) (*Account, error)
+	FreezeAccount(ctx context.Context, id, reason string) (*Account, error)
+	UnfreezeAccount(ctx context.Context, id, reason string) (*Account, error)
+	UpdateAccountStatus(ctx context.Context, id string, status AccountStatus) (*Account, error)
+	UpdateOverdraftLimit(ctx context.Context, id string, limit Decimal) (*Account, error)
+	// UpdateMinimumBalance sets the balance an account's debits may not
+	// take it below
+	UpdateMinimumBalance(ctx context.Context, id string, minimum Decimal) (*Account, error)
+	// UpdateLimits sets an account's limits on outgoing money, keeping any
+	// given as nil
+	UpdateLimits(ctx context.Context, id string, maxTransaction, dailyOutgoing *Decimal) (*Account, error)
+	UpdateAccount(ctx context.Context, id string, update *AccountUpdate) (*Account, error)
 }
 
 // TransactionService defines the interface for transaction business logic
 type TransactionService interface {
 	ProcessTransaction(ctx context.Context, request *TransactionRequest) (*Transaction, error)
+	// ValidateTransaction runs a transaction's checks without saving or
+	// queueing it, reporting whether it would succeed
+	ValidateTransaction(ctx context.Context, request *TransactionRequest) (*TransactionValidation, error)
 	GetTransaction(ctx context.Context, id string) (*Transaction, error)
+	// GetTransactionReceipt issues the signed receipt of a completed
+	// transaction, failing with ErrReceiptUnavailable for any other
+	GetTransactionReceipt(ctx context.Context, id string) (*Receipt, error)
+	// VerifyReceipt reports whether a receipt is unchanged from one issued
+	VerifyReceipt(ctx context.Context, receipt *Receipt) (bool, error)
+	// GetTransactionStatus reports a transaction's status, with its place in
+	// the queue and an approximate wait while it is pending
+	GetTransactionStatus(ctx context.Context, id string) (*TransactionStatusReport, error)
+	// UpdateTransactionTags replaces a transaction's tags, failing with
+	// ErrInvalidTags if they are not valid tags
+	UpdateTransactionTags(ctx context.Context, id string, tags []string) (*Transaction, error)
+	// LookupTransactions fetches up to MaxTransactionLookupIDs transactions by
+	// ID, reporting those not found
+	LookupTransactions(ctx context.Context, ids []string) (*TransactionLookup, error)
 	GetTransactionHistory(ctx context.Context, accountID string, filter *TransactionFilter) ([]*Transaction, error)
+	// GetAccountTransactions retrieves an account's transaction history as
+	// seen from the account
+	GetAccountTransactions(ctx context.Context, accountID string, filter *TransactionFilter) ([]*AccountTransaction, error)
+	// ExportAccountTransactions calls fn with each of an account's
+	// transactions the filter matches, oldest first, without running
+	// balances or counterparty owners. It fails with an ExportTooLargeError
+	// before calling fn if they are more than one export may hold.
+	ExportAccountTransactions(ctx context.Context, accountID string, filter *TransactionFilter, fn func(*AccountTransaction) error) error
+	// StreamAccountTransactions calls fn with each transaction of an
+	// account's history page as seen from the account, in the filter's sort
+	// order, as they are read, without running balances or counterparty owners
+	StreamAccountTransactions(ctx context.Context, accountID string, filter *TransactionFilter, fn func(*AccountTransaction) error) error
 	GetTransactionsByFilter(ctx context.Context, filter *TransactionFilter) ([]*Transaction, error)
+	// StreamTransactions calls fn with each transaction of the page
+	// ListTransactions would return, as they are read, without counting them
+	StreamTransactions(ctx context.Context, filter *TransactionFilter, fn func(*Transaction) error) error
+	// ListTransactions pages through the transactions the filter matches in
+	// its sort order, at most MaxTransactionPageSize at a time, counting them all
+	ListTransactions(ctx context.Context, filter *TransactionFilter) (*TransactionPage, error)
+	// SearchTransactions finds transactions by words of their description or
+	// reference, most relevant first, failing with ErrSearchQueryTooShort if
+	// the query is under MinSearchQueryLength characters
+	SearchTransactions(ctx context.Context, query string, filter *TransactionFilter) ([]*TransactionMatch, error)
 	CancelTransaction(ctx context.Context, id string) error
+	// ApproveTransaction queues or schedules a transaction awaiting approval
+	ApproveTransaction(ctx context.Context, id string) (*Transaction, error)
+	// RequeueTransaction publishes a pending transaction, or one failed with
+	// a retryable code, again, failing with ErrTransactionNotRequeueable for
+	// any other
+	RequeueTransaction(ctx context.Context, id string) (*TransactionRequeue, error)
+	// RejectTransaction cancels a transaction awaiting approval with a reason
+	RejectTransaction(ctx context.Context, id, reason string) (*Transaction, error)
+	ReverseTransaction(ctx context.Context, id string) (*Transaction, error)
+	AdjustBalance(ctx context.Context, request *AdjustmentRequest) (*Transaction, error)
+	// RefundTransaction submits a refund of the amount, or of everything left
+	// to refund if it is empty
+	RefundTransaction(ctx context.Context, id string, amount Decimal) (*Transaction, error)
+	ProcessBatch(ctx context.Context, request *BatchRequest) (*TransactionBatch, error)
+	GetBatch(ctx context.Context, batchID string) (*TransactionBatch, error)
+}
+
+// AccountLedgerService defines the interface for reading account ledger entries
+type AccountLedgerService interface {
+	GetAccountLedger(ctx context.Context, accountID string, limit, offset int) ([]*LedgerEntry, error)
+}
+
+// DiagnosticsService defines the interface for operational diagnostics
+type DiagnosticsService interface {
+	GetTransactionDiagnostics(ctx context.Context, id string) (*TransactionDiagnostics, error)
+}
+
+// AccountVerificationService defines the interface for micro-deposit account verification
+type AccountVerificationService interface {
+	StartMicroDeposits(ctx context.Context, accountID string) (*MicroDepositChallenge, error)
+	VerifyMicroDeposits(ctx context.Context, accountID string, amounts []Decimal) (*Account, error)
+	ExpireMicroDeposits(ctx context.Context) (int, error)
+}
+
+// HoldService defines the interface for placing and resolving holds
+type HoldService interface {
+	PlaceHold(ctx context.Context, request *HoldRequest) (*Hold, error)
+	GetHold(ctx context.Context, id string) (*Hold, error)
+	// CaptureHold debits the amount, or the whole hold if it is empty, and
+	// releases the rest
+	CaptureHold(ctx context.Context, id string, amount Decimal) (*Hold, error)
+	ReleaseHold(ctx context.Context, id string) (*Hold, error)
+	ExpireHolds(ctx context.Context) (int, error)
+}
+
+// SettlementService defines the interface for inter-ledger settlement netting
+type SettlementService interface {
+	CreateGroup(ctx context.Context, name string, accountIDs []string) (*SettlementGroup, error)
+	GetGroup(ctx context.Context, id string) (*SettlementGroup, error)
+	ListGroups(ctx context.Context) ([]*SettlementGroup, error)
+	UpdateGroup(ctx context.Context, id, name string, accountIDs []string) (*SettlementGroup, error)
+	ComputeNet(ctx context.Context, groupID string, date time.Time) (*SettlementNet, error)
+	Settle(ctx context.Context, groupID string, date time.Time) (*Settlement, error)
+}
+
+// StandingOrderService defines the interface for recurring transfers
+type StandingOrderService interface {
+	CreateStandingOrder(ctx context.Context, request *StandingOrderRequest) (*StandingOrder, error)
+	GetStandingOrder(ctx context.Context, id string) (*StandingOrder, error)
+	ListStandingOrders(ctx context.Context, accountID string) ([]*StandingOrder, error)
+	UpdateStandingOrder(ctx context.Context, id string, update *StandingOrderUpdate) (*StandingOrder, error)
+	CancelStandingOrder(ctx context.Context, id string) (*StandingOrder, error)
+	// ExecuteDue submits a transfer for every due standing order and returns
+	// how many were submitted
+	ExecuteDue(ctx context.Context) (int, error)
+}
+
+// ChangeFeedService defines the interface for the incremental data export feed
+type ChangeFeedService interface {
+	GetChanges(ctx context.Context, cursor string, limit int) (*ChangeFeed, error)
+}
+
+// ReconciliationService defines the interface for reconciling account
+// balances against their transactions
+type ReconciliationService interface {
+	// Reconcile compares every account's balance with the balance its opening
+	// balance and transactions account for, and saves the report
+	Reconcile(ctx context.Context) (*ReconciliationReport, error)
+	GetLatestReport(ctx context.Context) (*ReconciliationReport, error)
+	// FixDiscrepancies records an adjustment for each approved account's
+	// discrepancy in the latest report that is still unchanged
+	FixDiscrepancies(ctx context.Context, accountIDs []string, operatorID string) (*ReconciliationReport, error)
+	// CheckInvariants checks that the total balance in each currency is what
+	// the opening balances and transactions account for
+	CheckInvariants(ctx context.Context) (*InvariantReport, error)
+}
+
+// BalanceSnapshotService defines the interface for daily balance snapshots
+type BalanceSnapshotService interface {
+	// SnapshotDay snapshots the balance of every account opened by the close
+	// of a day, returning how many were taken
+	SnapshotDay(ctx context.Context, day time.Time) (int, error)
+	// SnapshotDue snapshots each day closed since the last one snapshotted
+	SnapshotDue(ctx context.Context) (int, error)
+	// GetBalanceAsOf returns an account's balance at the close of a day, given
+	// as YYYY-MM-DD, or its balance so far for today
+	GetBalanceAsOf(ctx context.Context, accountID, day string) (*BalanceSnapshot, error)
+}
+
+// MonthlyStatementService defines the interface for generated monthly statements
+type MonthlyStatementService interface {
+	// GenerateMonth generates the statement of a closed month for every
+	// account opened by its end that lacks one, returning how many were
+	// generated
+	GenerateMonth(ctx context.Context, month time.Time) (int, error)
+	// GenerateDue generates the statements of the last month due
+	GenerateDue(ctx context.Context) (int, error)
+	// ListStatements lists an account's statements, newest month first
+	ListStatements(ctx context.Context, accountID string, limit, offset int) ([]*MonthlyStatement, error)
+	// GetStatement returns one of an account's statements with its lines
+	GetStatement(ctx context.Context, accountID, statementID string) (*MonthlyStatement, error)
 }
 
 // LedgerService defines the interface for ledger operations
 type LedgerService interface {
 	RecordTransaction(ctx context.Context, transaction *Transaction) error
-	GetAccountBalance(ctx context.Context, accountID string) (float64, error)
+	GetAccountBalance(ctx context.Context, accountID string) (Money, error)
 	GetTransactionHistory(ctx context.Context, accountID string, filter *TransactionFilter) ([]*Transaction, error)
-	GetAccountStatement(ctx context.Context, accountID string, fromDate, toDate string) ([]*Transaction, error)
+	// GetAccountStatement builds the statement of the days fromDate to
+	// toDate, given as YYYY-MM-DD, or of the current month if either is
+	// missing or invalid
+	GetAccountStatement(ctx context.Context, accountID string, fromDate, toDate string) (*AccountStatement, error)
+}
+
+// WebhookService defines the interface for webhook subscriptions and their
+// delivery
+type WebhookService interface {
+	// RegisterWebhook subscribes a URL to event types, returning the
+	// subscription with its signing secret
+	RegisterWebhook(ctx context.Context, url string, eventTypes []NotificationType) (*WebhookSubscription, error)
+	GetWebhook(ctx context.Context, id string) (*WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	GetDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*WebhookDelivery, error)
+	// HandleNotification delivers a notification queue message to every
+	// subscription to its event type
+	HandleNotification(ctx context.Context, message []byte) error
+	// RetryDue attempts the pending deliveries whose retry is due
+	RetryDue(ctx context.Context) (int, error)
+}
+
+// ExportJobService defines the interface for transaction exports written in
+// the background
+type ExportJobService interface {
+	// CreateExportJob queues an export of an account's transactions
+	CreateExportJob(ctx context.Context, req *ExportJobRequest) (*ExportJob, error)
+	GetExportJob(ctx context.Context, id string) (*ExportJob, error)
+	// CancelExportJob cancels a job that has not started
+	CancelExportJob(ctx context.Context, id string) (*ExportJob, error)
+	// OpenExportFile opens a completed job's file, failing with
+	// ErrExportNotReady for a job in any other state
+	OpenExportFile(ctx context.Context, id string) (*ExportJob, io.ReadCloser, error)
+	// HandleExportJob writes the file of the job named by an export queue
+	// message
+	HandleExportJob(ctx context.Context, message []byte) error
+	// ExpireExports removes the files of jobs past their retention
+	ExpireExports(ctx context.Context) (int, error)
+}
+
+// DeadLetterService defines the interface for inspecting and replaying the
+// transaction queue's dead letters
+type DeadLetterService interface {
+	// ListDeadLetters returns up to MaxDeadLetterPageSize dead letters, oldest
+	// first, leaving them in place
+	ListDeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error)
+	// RequeueDeadLetters moves the dead letters with the IDs back onto the
+	// transaction queue, failing with ErrInvalidDeadLetterRequeue unless
+	// given between 1 and MaxDeadLetterRequeueIDs IDs, none blank
+	RequeueDeadLetters(ctx context.Context, ids []string) (*DeadLetterRequeue, error)
+}
+
+// UserVerificationService defines the interface for managing users'
+// verification levels
+type UserVerificationService interface {
+	GetVerification(ctx context.Context, userID string) (*UserVerification, error)
+	SetVerification(ctx context.Context, userID string, level VerificationLevel) (*UserVerification, error)
 }
 
 // NotificationService defines the interface for notifications
@@ -65,4 +667,6 @@ type NotificationService interface {
 	NotifyTransactionCompleted(ctx context.Context, transaction *Transaction) error
 	NotifyTransactionFailed(ctx context.Context, transaction *Transaction, error error) error
 	NotifyLowBalance(ctx context.Context, account *Account) error
+	NotifyAccountCreated(ctx context.Context, account *Account) error
+	NotifyStatementGenerated(ctx context.Context, account *Account, statementID string) error
 }
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BalanceTotal is the sum of the balances, and of the opening balances, of
+// every account in one currency
+type BalanceTotal struct {
+	Currency       string `db:"currency"`
+	Balance        Money  `db:"balance"`
+	InitialBalance Money  `db:"initial_balance"`
+}
+
+// MovementTotal is the sum of the balance changes that the transactions of
+// one type account for in one currency, as Transaction.Movements defines
+// them. Debited is the total taken from accounts and Credited the total added
+// to them; a transfer within a currency adds the same to both.
+type MovementTotal struct {
+	Type     TransactionType `json:"type" bson:"type"`
+	Currency string          `json:"currency" bson:"currency"`
+	Debited  Money           `json:"debited" bson:"debited"`
+	Credited Money           `json:"credited" bson:"credited"`
+}
+
+// Net returns the change the transactions made to the currency's total
+func (t *MovementTotal) Net() Money {
+	return t.Credited - t.Debited
+}
+
+// MarshalJSON emits the totals as decimal strings in the currency
+func (t MovementTotal) MarshalJSON() ([]byte, error) {
+	type movementTotal MovementTotal
+	return json.Marshal(struct {
+		movementTotal
+		Debited  string `json:"debited"`
+		Credited string `json:"credited"`
+		Net      string `json:"net"`
+	}{movementTotal(t), t.Debited.Format(t.Currency), t.Credited.Format(t.Currency), t.Net().Format(t.Currency)})
+}
+
+// CurrencyInvariant compares the total balance of a currency's accounts with
+// the total their opening balances and transactions account for
+type CurrencyInvariant struct {
+	Currency       string           `json:"currency"`
+	Balance        Money            `json:"balance"`
+	InitialBalance Money            `json:"initial_balance"`
+	Expected       Money            `json:"expected"`
+	Difference     Money            `json:"difference"`
+	Balanced       bool             `json:"balanced"`
+	Movements      []*MovementTotal `json:"movements"`
+}
+
+// MarshalJSON emits the amounts as decimal strings in the currency
+func (i CurrencyInvariant) MarshalJSON() ([]byte, error) {
+	type currencyInvariant CurrencyInvariant
+	return json.Marshal(struct {
+		currencyInvariant
+		Balance        string `json:"balance"`
+		InitialBalance string `json:"initial_balance"`
+		Expected       string `json:"expected"`
+		Difference     string `json:"difference"`
+	}{
+		currencyInvariant(i),
+		i.Balance.Format(i.Currency),
+		i.InitialBalance.Format(i.Currency),
+		i.Expected.Format(i.Currency),
+		i.Difference.Format(i.Currency),
+	})
+}
+
+// InvariantReport is the outcome of checking that the books balance in every
+// currency. The totals are read between StartedAt and CompletedAt, so a
+// transaction processed in that window, or still in flight, may explain a
+// small difference.
+type InvariantReport struct {
+	StartedAt   time.Time            `json:"started_at"`
+	CompletedAt time.Time            `json:"completed_at"`
+	Balanced    bool                 `json:"balanced"`
+	InFlight    int64                `json:"in_flight_transactions"`
+	Currencies  []*CurrencyInvariant `json:"currencies"`
+}
@@ -0,0 +1,136 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EntryDirection is the side of the ledger an entry is booked on
+type EntryDirection string
+
+const (
+	EntryDirectionDebit  EntryDirection = "debit"
+	EntryDirectionCredit EntryDirection = "credit"
+)
+
+// LedgerEntry records one balance change of one account. Every completed
+// deposit and withdrawal has one entry and every transfer has two, a debit
+// and a credit of the same amount.
+type LedgerEntry struct {
+	ID               string         `json:"id" db:"id"`
+	AccountID        string         `json:"account_id" db:"account_id"`
+	TransactionID    string         `json:"transaction_id" db:"transaction_id"`
+	Direction        EntryDirection `json:"direction" db:"direction"`
+	Amount           Money          `json:"amount" db:"amount"`
+	Currency         string         `json:"currency" db:"currency"`
+	ResultingBalance Money          `json:"resulting_balance" db:"resulting_balance"`
+	// AccountVersion is the account's version after the entry, which orders
+	// an account's entries exactly
+	AccountVersion int64     `json:"account_version" db:"account_version"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewLedgerEntry builds the entry moving amount into or out of an account,
+// computing the balance it leaves behind
+func NewLedgerEntry(transactionID string, account *Account, direction EntryDirection, amount Money) *LedgerEntry {
+	resultingBalance := account.Balance + amount
+	if direction == EntryDirectionDebit {
+		resultingBalance = account.Balance - amount
+	}
+
+	return &LedgerEntry{
+		// An account is only debited or credited once per transaction, so
+		// replaying a transaction collides with its earlier entries
+		ID:               transactionID + "-" + string(direction),
+		AccountID:        account.ID,
+		TransactionID:    transactionID,
+		Direction:        direction,
+		Amount:           amount,
+		Currency:         account.Currency,
+		ResultingBalance: resultingBalance,
+		AccountVersion:   account.Version + 1,
+	}
+}
+
+// MarshalJSON emits the amounts as decimal strings in the entry's currency
+func (e LedgerEntry) MarshalJSON() ([]byte, error) {
+	type ledgerEntry LedgerEntry
+	return json.Marshal(struct {
+		ledgerEntry
+		Amount           string `json:"amount"`
+		ResultingBalance string `json:"resulting_balance"`
+	}{ledgerEntry(e), e.Amount.Format(e.Currency), e.ResultingBalance.Format(e.Currency)})
+}
+
+// LedgerPosting is a set of entries applied together with the balances they
+// produce. Each entry's account must still be at AccountVersion-1.
+type LedgerPosting []*LedgerEntry
+
+// BalanceChange records how a transaction moved one account's balance, so
+// the balance before and after it can be shown in a dispute
+type BalanceChange struct {
+	AccountID     string `json:"account_id" bson:"account_id"`
+	BalanceBefore Money  `json:"balance_before" bson:"balance_before"`
+	BalanceAfter  Money  `json:"balance_after" bson:"balance_after"`
+	Currency      string `json:"currency" bson:"currency"`
+	// AccountVersion is the version the balance update was conditioned on
+	AccountVersion int64 `json:"account_version" bson:"account_version"`
+}
+
+// MarshalJSON emits the balances as decimal strings in the account's currency
+func (c BalanceChange) MarshalJSON() ([]byte, error) {
+	type balanceChange BalanceChange
+	return json.Marshal(struct {
+		balanceChange
+		BalanceBefore string `json:"balance_before"`
+		BalanceAfter  string `json:"balance_after"`
+	}{balanceChange(c), c.BalanceBefore.Format(c.Currency), c.BalanceAfter.Format(c.Currency)})
+}
+
+// UnmarshalJSON reads the balances as decimals in the account's currency
+func (c *BalanceChange) UnmarshalJSON(data []byte) error {
+	type balanceChange BalanceChange
+	var decoded struct {
+		balanceChange
+		BalanceBefore Decimal `json:"balance_before"`
+		BalanceAfter  Decimal `json:"balance_after"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*c = BalanceChange(decoded.balanceChange)
+	before, err := decoded.BalanceBefore.Money(c.Currency)
+	if err != nil {
+		return err
+	}
+	after, err := decoded.BalanceAfter.Money(c.Currency)
+	if err != nil {
+		return err
+	}
+	c.BalanceBefore, c.BalanceAfter = before, after
+	return nil
+}
+
+// BalanceChanges returns the balance changes a posting makes for one
+// transaction, leaving out entries it carries for others, such as a fee
+func (p LedgerPosting) BalanceChanges(transactionID string) []*BalanceChange {
+	var changes []*BalanceChange
+	for _, entry := range p {
+		if entry.TransactionID != transactionID {
+			continue
+		}
+		before := entry.ResultingBalance + entry.Amount
+		if entry.Direction == EntryDirectionCredit {
+			before = entry.ResultingBalance - entry.Amount
+		}
+		changes = append(changes, &BalanceChange{
+			AccountID:      entry.AccountID,
+			BalanceBefore:  before,
+			BalanceAfter:   entry.ResultingBalance,
+			Currency:       entry.Currency,
+			AccountVersion: entry.AccountVersion - 1,
+		})
+	}
+	return changes
+}
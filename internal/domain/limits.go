@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// TransactionLimits bounds the size of a transaction request. A zero limit is
+// not enforced.
+type TransactionLimits struct {
+	// MaxAmount is the largest amount of a single transaction, in whole units
+	// of its currency
+	MaxAmount        int64
+	MaxMetadataKeys  int
+	MaxMetadataBytes int
+}
+
+// DefaultTransactionLimits are the limits used unless a deployment overrides them
+var DefaultTransactionLimits = TransactionLimits{
+	MaxAmount:        1_000_000,
+	MaxMetadataKeys:  50,
+	MaxMetadataBytes: 8 << 10,
+}
+
+// ActiveTransactionLimits are the limits TransactionRequest.IsValid enforces.
+// Replace them at startup to apply a deployment's configuration.
+var ActiveTransactionLimits = DefaultTransactionLimits
+
+// MaxAmountIn returns the largest amount allowed in a currency, in its minor
+// units, and false if amounts are unbounded
+func (l TransactionLimits) MaxAmountIn(currency string) (Money, bool) {
+	if l.MaxAmount <= 0 {
+		return 0, false
+	}
+
+	scale := int64(math.Pow10(CurrencyExponent(currency)))
+	if l.MaxAmount > math.MaxInt64/scale {
+		return 0, false
+	}
+	return Money(l.MaxAmount * scale), true
+}
+
+// Check reports whether the request's amount and metadata are within the limits
+func (l TransactionLimits) Check(tr *TransactionRequest) error {
+	// Reversals and refunds return an amount that was within the limits when
+	// it was accepted, and must stay possible if the limits are lowered since
+	returned := tr.Type == TransactionTypeReversal || tr.Type == TransactionTypeRefund
+	if limit, ok := l.MaxAmountIn(tr.Currency); ok && !returned && tr.Amount > limit {
+		return ErrAmountTooLarge
+	}
+
+	if len(tr.Metadata) == 0 {
+		return nil
+	}
+	if l.MaxMetadataKeys > 0 && len(tr.Metadata) > l.MaxMetadataKeys {
+		return ErrMetadataTooLarge
+	}
+	if l.MaxMetadataBytes > 0 {
+		encoded, err := json.Marshal(tr.Metadata)
+		if err != nil {
+			return ErrInvalidInput
+		}
+		if len(encoded) > l.MaxMetadataBytes {
+			return ErrMetadataTooLarge
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+	"time"
+)
+
+// MicroDepositStatus represents the state of a micro-deposit challenge
+type MicroDepositStatus string
+
+const (
+	MicroDepositStatusPending  MicroDepositStatus = "pending"
+	MicroDepositStatusVerified MicroDepositStatus = "verified"
+	MicroDepositStatusFailed   MicroDepositStatus = "failed"
+	MicroDepositStatusExpired  MicroDepositStatus = "expired"
+)
+
+// MicroDepositChallenge proves ownership of an account: two small random
+// credits are made and the owner confirms their amounts. The amounts are
+// never serialized to API responses.
+type MicroDepositChallenge struct {
+	ID           string             `json:"id" db:"id"`
+	AccountID    string             `json:"account_id" db:"account_id"`
+	AmountOne    Money              `json:"-" db:"amount_one"`
+	AmountTwo    Money              `json:"-" db:"amount_two"`
+	Currency     string             `json:"currency" db:"currency"`
+	Status       MicroDepositStatus `json:"status" db:"status"`
+	Attempts     int                `json:"attempts" db:"attempts"`
+	ExpiresAt    time.Time          `json:"expires_at" db:"expires_at"`
+	ClawedBackAt *time.Time         `json:"clawed_back_at,omitempty" db:"clawed_back_at"`
+	CreatedAt    time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// DepositIDs returns the IDs of the challenge's two credit transactions
+func (c *MicroDepositChallenge) DepositIDs() [2]string {
+	return [2]string{c.ID + "-deposit-1", c.ID + "-deposit-2"}
+}
+
+// ClawbackIDs returns the IDs of the withdrawals reversing each credit
+func (c *MicroDepositChallenge) ClawbackIDs() [2]string {
+	return [2]string{c.ID + "-clawback-1", c.ID + "-clawback-2"}
+}
+
+// Amounts returns the two credited amounts
+func (c *MicroDepositChallenge) Amounts() [2]Money {
+	return [2]Money{c.AmountOne, c.AmountTwo}
+}
+
+// IsExpired reports whether the confirmation window has closed
+func (c *MicroDepositChallenge) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// Matches reports whether the confirmed amounts equal the credits, in any order
+func (c *MicroDepositChallenge) Matches(amounts []Money) bool {
+	if len(amounts) != 2 {
+		return false
+	}
+	one, two := c.AmountOne, c.AmountTwo
+	first, second := amounts[0], amounts[1]
+	return (first == one && second == two) || (first == two && second == one)
+}
+
+// GenerateMicroDepositAmounts draws two distinct amounts between 1 and 99
+// minor units from r, or from crypto/rand when r is nil
+func GenerateMicroDepositAmounts(r io.Reader) ([2]Money, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	var amounts [2]Money
+	for i := range amounts {
+		for {
+			n, err := rand.Int(r, big.NewInt(99))
+			if err != nil {
+				return [2]Money{}, err
+			}
+			amounts[i] = Money(n.Int64() + 1)
+			if i == 0 || amounts[i] != amounts[0] {
+				break
+			}
+		}
+	}
+
+	return amounts, nil
+}
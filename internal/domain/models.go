@@ -1,6 +1,11 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -8,49 +13,507 @@ import (
 type TransactionType string
 
 const (
-	TransactionTypeDeposit    TransactionType = "deposit"
-	TransactionTypeWithdrawal TransactionType = "withdrawal"
-	TransactionTypeTransfer   TransactionType = "transfer"
+	TransactionTypeDeposit      TransactionType = "deposit"
+	TransactionTypeWithdrawal   TransactionType = "withdrawal"
+	TransactionTypeTransfer     TransactionType = "transfer"
+	TransactionTypeVerification TransactionType = "verification"
+	// TransactionTypeExternalTransfer records money settled outside the ledger
+	// and never changes account balances
+	TransactionTypeExternalTransfer TransactionType = "external_transfer"
+	// TransactionTypeReversal undoes a completed deposit, withdrawal or
+	// transfer by moving its amount back
+	TransactionTypeReversal TransactionType = "reversal"
+	// TransactionTypeHold records funds reserved by a hold; it never changes
+	// account balances
+	TransactionTypeHold TransactionType = "hold"
+	// TransactionTypeCapture debits the captured part of a hold
+	TransactionTypeCapture TransactionType = "capture"
+	// TransactionTypeFee charges a fee for a withdrawal or transfer, moving
+	// it from the source account to the fee collection account
+	TransactionTypeFee TransactionType = "fee"
+	// TransactionTypeAdjustment is a manual balance correction made by an
+	// operator through the admin API
+	TransactionTypeAdjustment TransactionType = "adjustment"
+	// TransactionTypeRefund returns all or part of a completed payment,
+	// moving the refunded amount back
+	TransactionTypeRefund TransactionType = "refund"
+	// TransactionTypeCompensation returns the debited amount of a transfer
+	// whose credit failed after its debit was posted
+	TransactionTypeCompensation TransactionType = "compensation"
 )
 
+// IsReversible reports whether completed transactions of this type may be reversed
+func (t TransactionType) IsReversible() bool {
+	switch t {
+	case TransactionTypeDeposit, TransactionTypeWithdrawal, TransactionTypeTransfer:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRefundable reports whether completed transactions of this type may be refunded
+func (t TransactionType) IsRefundable() bool {
+	return t.IsReversible()
+}
+
 // TransactionStatus represents the status of a transaction
 type TransactionStatus string
 
 const (
-	TransactionStatusPending   TransactionStatus = "pending"
-	TransactionStatusCompleted TransactionStatus = "completed"
-	TransactionStatusFailed    TransactionStatus = "failed"
-	TransactionStatusCancelled TransactionStatus = "cancelled"
+	// TransactionStatusScheduled marks a transaction waiting for its
+	// scheduled time before it is queued for processing
+	TransactionStatusScheduled TransactionStatus = "scheduled"
+	// TransactionStatusPendingApproval marks a large transfer waiting for an
+	// approver before it is queued or scheduled
+	TransactionStatusPendingApproval TransactionStatus = "pending_approval"
+	TransactionStatusPending         TransactionStatus = "pending"
+	// TransactionStatusProcessing marks a transaction picked up by the processor
+	TransactionStatusProcessing TransactionStatus = "processing"
+	TransactionStatusCompleted  TransactionStatus = "completed"
+	TransactionStatusFailed     TransactionStatus = "failed"
+	TransactionStatusCancelled  TransactionStatus = "cancelled"
 )
 
+// IsInFlight reports whether a transaction in this status has not yet
+// reached an outcome
+func (s TransactionStatus) IsInFlight() bool {
+	return s == TransactionStatusPending || s == TransactionStatusProcessing
+}
+
+// FinalTransactionStatuses are the statuses a transaction does not leave
+var FinalTransactionStatuses = []TransactionStatus{
+	TransactionStatusCompleted,
+	TransactionStatusFailed,
+	TransactionStatusCancelled,
+}
+
+// IsFinal reports whether a transaction in this status is done with
+func (s TransactionStatus) IsFinal() bool {
+	return s == TransactionStatusCompleted || s == TransactionStatusFailed || s == TransactionStatusCancelled
+}
+
+// StatusChange records a transaction entering a status
+type StatusChange struct {
+	Status       TransactionStatus `json:"status" bson:"status"`
+	Timestamp    time.Time         `json:"timestamp" bson:"timestamp"`
+	ErrorMessage string            `json:"error_message,omitempty" bson:"error_message,omitempty"`
+	// RequeuedBy is who queued the transaction again, for a return to
+	// pending by a requeue
+	RequeuedBy string `json:"requeued_by,omitempty" bson:"requeued_by,omitempty"`
+}
+
 // Account represents a bank account
 type Account struct {
-	ID        string    `json:"id" db:"id"`
-	UserID    string    `json:"user_id" db:"user_id"`
-	Balance   float64   `json:"balance" db:"balance"`
-	Currency  string    `json:"currency" db:"currency"`
-	Status    string    `json:"status" db:"status"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
-	Version   int64     `json:"version" db:"version"` // For optimistic locking
+	ID        string        `json:"id" db:"id"`
+	UserID    string        `json:"user_id" db:"user_id"`
+	Balance   Money         `json:"balance" db:"balance"`
+	Currency  string        `json:"currency" db:"currency"`
+	Type      AccountType   `json:"type" db:"type"`
+	Status    AccountStatus `json:"status" db:"status"`
+	Verified  bool          `json:"verified" db:"verified"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" db:"updated_at"`
+	Version   int64         `json:"version" db:"version"` // For optimistic locking
+
+	// OverdraftLimit is how far below zero the balance may be drawn
+	OverdraftLimit Money `json:"overdraft_limit" db:"overdraft_limit"`
+	// HeldAmount is the total of the account's active holds
+	HeldAmount Money `json:"held_amount" db:"held_amount"`
+	// MinimumBalance is the balance withdrawals and outgoing transfers may
+	// not take the account below
+	MinimumBalance Money `json:"minimum_balance" db:"minimum_balance"`
+	// InitialBalance is the balance the account was opened with, which
+	// reconciliation counts its transactions from
+	InitialBalance Money `json:"-" db:"initial_balance"`
+	// LowBalanceThreshold is the balance below which a debit sends a low
+	// balance notification; nil leaves it to the processor's default
+	LowBalanceThreshold *Money `json:"-" db:"low_balance_threshold"`
+	// BelowThreshold is set when a low balance notification is sent and
+	// cleared once the balance recovers, so each crossing notifies once
+	BelowThreshold bool `json:"below_threshold" db:"below_threshold"`
+	// StatusChangedAt and StatusChangedBy record when and by whom the status
+	// was last changed, and StatusReason why, as given for a freeze
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty" db:"status_changed_at"`
+	StatusChangedBy string     `json:"status_changed_by,omitempty" db:"status_changed_by"`
+	StatusReason    string     `json:"status_reason,omitempty" db:"status_reason"`
+	// Nickname, Labels and Metadata are the owner's own name, groupings and
+	// tags for the account; none affects how it is processed
+	Nickname string          `json:"nickname,omitempty" db:"nickname"`
+	Labels   AccountLabels   `json:"labels,omitempty" db:"labels"`
+	Metadata AccountMetadata `json:"metadata,omitempty" db:"metadata"`
+
+	AccountLimits
+}
+
+// AccountDetails are the owner's optional name and labels for a new account
+type AccountDetails struct {
+	Nickname string
+	Labels   AccountLabels
 }
 
+// AccountUpdate changes the editable settings of an account; nil fields are
+// left unchanged. The threshold is read in the account's currency, and
+// ClearLowBalanceThreshold removes it. An empty nickname clears it, and
+// ReplaceLabels and ReplaceMetadata swap in Labels and Metadata wholesale. Version is the version the
+// client last read, so an update based on a stale copy is refused.
+type AccountUpdate struct {
+	Version                  int64
+	LowBalanceThreshold      *Decimal
+	ClearLowBalanceThreshold bool
+	Nickname                 *string
+	Labels                   AccountLabels
+	ReplaceLabels            bool
+	Metadata                 AccountMetadata
+	ReplaceMetadata          bool
+}
+
+// lowBalanceThresholdJSON formats the account's low balance threshold, if
+// any, in its currency
+func (a Account) lowBalanceThresholdJSON() *Decimal {
+	if a.LowBalanceThreshold == nil {
+		return nil
+	}
+	threshold := Decimal(a.LowBalanceThreshold.Format(a.Currency))
+	return &threshold
+}
+
+// MarshalJSON emits the balance, overdraft limit, held amount, minimum balance,
+// limits and low balance threshold as decimal strings in the account's currency
+func (a Account) MarshalJSON() ([]byte, error) {
+	type account Account
+	return json.Marshal(struct {
+		account
+		Balance             string            `json:"balance"`
+		OverdraftLimit      string            `json:"overdraft_limit"`
+		HeldAmount          string            `json:"held_amount"`
+		MinimumBalance      string            `json:"minimum_balance"`
+		Limits              accountLimitsJSON `json:"limits"`
+		LowBalanceThreshold *Decimal          `json:"low_balance_threshold"`
+	}{account(a), a.Balance.Format(a.Currency), a.OverdraftLimit.Format(a.Currency), a.HeldAmount.Format(a.Currency), a.MinimumBalance.Format(a.Currency), a.AccountLimits.toJSON(a.Currency), a.lowBalanceThresholdJSON()})
+}
+
+// UnmarshalJSON reads the balance, overdraft limit, held amount, minimum
+// balance, limits and low balance threshold as decimals in the account's
+// currency
+func (a *Account) UnmarshalJSON(data []byte) error {
+	type account Account
+	var decoded struct {
+		account
+		Balance             Decimal           `json:"balance"`
+		OverdraftLimit      Decimal           `json:"overdraft_limit"`
+		HeldAmount          Decimal           `json:"held_amount"`
+		MinimumBalance      Decimal           `json:"minimum_balance"`
+		Limits              accountLimitsJSON `json:"limits"`
+		LowBalanceThreshold *Decimal          `json:"low_balance_threshold"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*a = Account(decoded.account)
+	balance, err := decoded.Balance.Money(a.Currency)
+	if err != nil {
+		return err
+	}
+	overdraftLimit, err := decoded.OverdraftLimit.Money(a.Currency)
+	if err != nil {
+		return err
+	}
+	heldAmount, err := decoded.HeldAmount.Money(a.Currency)
+	if err != nil {
+		return err
+	}
+	minimumBalance, err := decoded.MinimumBalance.Money(a.Currency)
+	if err != nil {
+		return err
+	}
+	limits, err := decoded.Limits.limits(a.Currency)
+	if err != nil {
+		return err
+	}
+	a.Balance = balance
+	a.OverdraftLimit = overdraftLimit
+	a.HeldAmount = heldAmount
+	a.MinimumBalance = minimumBalance
+	a.AccountLimits = limits
+	if decoded.LowBalanceThreshold != nil {
+		threshold, err := decoded.LowBalanceThreshold.Money(a.Currency)
+		if err != nil {
+			return err
+		}
+		a.LowBalanceThreshold = &threshold
+	}
+	return nil
+}
+
+// WithoutStatusAudit returns a copy of the account without who changed its
+// status and why, for callers other than admins
+func (a *Account) WithoutStatusAudit() *Account {
+	redacted := *a
+	redacted.StatusChangedBy = ""
+	redacted.StatusReason = ""
+	return &redacted
+}
+
+// IsSelfTransfer reports whether moving money from one account to another
+// stays with the same user
+func IsSelfTransfer(from, to *Account) bool {
+	return from.ID != to.ID && from.UserID == to.UserID
+}
+
+// AvailableBalance returns the amount that can be debited: the balance less
+// active holds and the minimum balance, plus any overdraft
+func (a *Account) AvailableBalance() Money {
+	return a.Balance - a.HeldAmount + a.OverdraftLimit - a.MinimumBalance
+}
+
+// CheckFunds reports whether the account can be debited by the amount without
+// exceeding its overdraft limit or taking it below its minimum balance. A
+// debit the funds would cover but for the minimum fails with a
+// BelowMinimumBalanceError carrying the shortfall.
+func (a *Account) CheckFunds(amount Money) error {
+	available := a.AvailableBalance()
+	if amount <= available {
+		return nil
+	}
+	if a.MinimumBalance > 0 && amount <= available+a.MinimumBalance {
+		return &BelowMinimumBalanceError{
+			Minimum:   a.MinimumBalance,
+			Shortfall: amount - available,
+			Currency:  a.Currency,
+		}
+	}
+	return &InsufficientFundsError{
+		Available: available,
+		Currency:  a.Currency,
+		Overdraft: a.OverdraftLimit > 0,
+	}
+}
+
+// TransactionIDSource says who chose a transaction's ID
+type TransactionIDSource string
+
+const (
+	// TransactionIDClient IDs were supplied by the client submitting the
+	// transaction, for correlation with its own records
+	TransactionIDClient TransactionIDSource = "client"
+	// TransactionIDServer IDs were generated by the ledger
+	TransactionIDServer TransactionIDSource = "server"
+)
+
 // Transaction represents a transaction in the system
 type Transaction struct {
-	ID            string                 `json:"id" bson:"_id"`
-	Type          TransactionType        `json:"type" bson:"type"`
-	FromAccountID *string                `json:"from_account_id,omitempty" bson:"from_account_id,omitempty"`
-	ToAccountID   *string                `json:"to_account_id,omitempty" bson:"to_account_id,omitempty"`
-	Amount        float64                `json:"amount" bson:"amount"`
-	Currency      string                 `json:"currency" bson:"currency"`
-	Status        TransactionStatus      `json:"status" bson:"status"`
-	Description   string                 `json:"description" bson:"description"`
-	Reference     string                 `json:"reference" bson:"reference"`
-	Metadata      map[string]interface{} `json:"metadata,omitempty" bson:"metadata,omitempty"`
-	CreatedAt     time.Time              `json:"created_at" bson:"created_at"`
-	UpdatedAt     time.Time              `json:"updated_at" bson:"updated_at"`
-	ProcessedAt   *time.Time             `json:"processed_at,omitempty" bson:"processed_at,omitempty"`
-	ErrorMessage  string                 `json:"error_message,omitempty" bson:"error_message,omitempty"`
+	ID                string                 `json:"id" bson:"_id"`
+	Type              TransactionType        `json:"type" bson:"type"`
+	FromAccountID     *string                `json:"from_account_id,omitempty" bson:"from_account_id,omitempty"`
+	ToAccountID       *string                `json:"to_account_id,omitempty" bson:"to_account_id,omitempty"`
+	Amount            Money                  `json:"amount" bson:"amount"`
+	Currency          string                 `json:"currency" bson:"currency"`
+	Status            TransactionStatus      `json:"status" bson:"status"`
+	Description       string                 `json:"description" bson:"description"`
+	Reference         string                 `json:"reference" bson:"reference"`
+	Metadata          map[string]interface{} `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	Tags              []string               `json:"tags,omitempty" bson:"tags,omitempty"`
+	CreatedAt         time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at" bson:"updated_at"`
+	ProcessedAt       *time.Time             `json:"processed_at,omitempty" bson:"processed_at,omitempty"`
+	ErrorMessage      string                 `json:"error_message,omitempty" bson:"error_message,omitempty"`
+	FailureCode       FailureCode            `json:"failure_code,omitempty" bson:"failure_code,omitempty"`
+	SettlementBatchID string                 `json:"settlement_batch_id,omitempty" bson:"settlement_batch_id,omitempty"`
+	System            bool                   `json:"system,omitempty" bson:"system,omitempty"`
+	ScheduledAt       *time.Time             `json:"scheduled_at,omitempty" bson:"scheduled_at,omitempty"`
+	// RequeuedAt is when a transaction was last queued again, by the pending
+	// sweeper or an admin; the sweeper requeues only those never requeued
+	RequeuedAt *time.Time `json:"requeued_at,omitempty" bson:"requeued_at,omitempty"`
+	// BatchID is the batch the transaction is a leg of
+	BatchID string `json:"batch_id,omitempty" bson:"batch_id,omitempty"`
+	// SelfTransfer marks a transfer between two accounts of the same user
+	SelfTransfer bool `json:"self_transfer,omitempty" bson:"self_transfer,omitempty"`
+	// ExternalSource is where a deposit's money came from
+	ExternalSource *ExternalParty `json:"external_source,omitempty" bson:"external_source,omitempty"`
+	// ExternalDestination is where a withdrawal's money went
+	ExternalDestination *ExternalParty `json:"external_destination,omitempty" bson:"external_destination,omitempty"`
+	// Outbox is the queue message saved with the transaction until it has
+	// been published
+	Outbox *OutboxMessage `json:"-" bson:"outbox,omitempty"`
+	// QueueSequence orders the transaction's queue message among all those
+	// published to the transaction queue, assigned when it is published
+	QueueSequence int64 `json:"queue_sequence,omitempty" bson:"queue_sequence,omitempty"`
+	// IDSource says whether a submitted transaction's ID was supplied by the
+	// client or generated
+	IDSource TransactionIDSource `json:"id_source,omitempty" bson:"id_source,omitempty"`
+
+	// Compensation tracks returning the debited amount of a transfer whose
+	// credit failed after its debit was posted
+	Compensation *Compensation `json:"compensation,omitempty" bson:"compensation,omitempty"`
+	// CompensatedTransactionID is the transfer a compensation returns the
+	// debited amount of
+	CompensatedTransactionID string `json:"compensated_transaction_id,omitempty" bson:"compensated_transaction_id,omitempty"`
+
+	// Exchange records the rate and credited amount of a transfer between
+	// accounts in different currencies
+	Exchange *Exchange `json:"exchange,omitempty" bson:"exchange,omitempty"`
+
+	// BalanceChanges records each account's balance before and after the
+	// transaction was applied
+	BalanceChanges []*BalanceChange `json:"balance_changes,omitempty" bson:"balance_changes,omitempty"`
+
+	// IdempotencyKey is the key the transaction was submitted with
+	IdempotencyKey string `json:"idempotency_key,omitempty" bson:"idempotency_key,omitempty"`
+	// Replayed marks a transaction returned for a repeated idempotency key
+	// rather than created by the request
+	Replayed bool `json:"-" bson:"-"`
+
+	// StatusHistory lists every status the transaction has entered, oldest first
+	StatusHistory []StatusChange `json:"status_history,omitempty" bson:"status_history,omitempty"`
+
+	// ReversedTransactionID is the transaction a reversal undoes
+	ReversedTransactionID string `json:"reversed_transaction_id,omitempty" bson:"reversed_transaction_id,omitempty"`
+	// ReversalID is the reversal claimed for this transaction while it is in
+	// flight or once it has completed
+	ReversalID string `json:"reversal_id,omitempty" bson:"reversal_id,omitempty"`
+	// ReversedBy is the reversal that undid this transaction
+	ReversedBy string `json:"reversed_by,omitempty" bson:"reversed_by,omitempty"`
+
+	// RefundedTransactionID is the payment a refund returns
+	RefundedTransactionID string `json:"refunded_transaction_id,omitempty" bson:"refunded_transaction_id,omitempty"`
+	// RefundedAmount is the total of the refunds applied to this transaction
+	RefundedAmount Money `json:"refunded_amount,omitempty" bson:"refunded_amount,omitempty"`
+	// RefundIDs lists the refunds counted in RefundedAmount, so a redelivered
+	// refund is counted once
+	RefundIDs []string `json:"-" bson:"refund_ids,omitempty"`
+
+	// ReferenceReserved marks a transaction holding its reference against
+	// reuse from the same account; it is cleared when the transaction is
+	// cancelled
+	ReferenceReserved bool `json:"-" bson:"reference_reserved,omitempty"`
+
+	// Archived marks a transaction read from the archive, where transactions
+	// in a final status are moved once old enough; it can no longer change
+	Archived   bool       `json:"archived,omitempty" bson:"archived,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty" bson:"archived_at,omitempty"`
+}
+
+// CompensationStatus is the progress of returning a transfer's debited amount
+type CompensationStatus string
+
+const (
+	// CompensationStatusPending compensations are queued and retried until
+	// the amount is back in the source account
+	CompensationStatusPending CompensationStatus = "pending"
+	// CompensationStatusCompleted compensations have credited the source
+	// account
+	CompensationStatusCompleted CompensationStatus = "completed"
+)
+
+// Compensation records why a transfer's debit is being returned and how far
+// that has got
+type Compensation struct {
+	// TransactionID is the compensating credit of the source account
+	TransactionID string             `json:"transaction_id" bson:"transaction_id"`
+	Status        CompensationStatus `json:"status" bson:"status"`
+	// FailureCode and Reason describe why the credit failed
+	FailureCode FailureCode `json:"failure_code" bson:"failure_code"`
+	Reason      string      `json:"reason" bson:"reason"`
+	CreatedAt   time.Time   `json:"created_at" bson:"created_at"`
+	CompletedAt *time.Time  `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// CancellationTombstone is the message published when a queued transaction
+// is cancelled, so the processor can skip its message without reading it
+type CancellationTombstone struct {
+	TransactionID string `json:"transaction_id"`
+}
+
+// OutboxMessage is a queue message waiting to be published
+type OutboxMessage struct {
+	Payload   []byte    `bson:"payload"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Archivable reports whether the transaction is in a final status with
+// nothing left to do: no message waiting in the outbox, no reversal in
+// flight and no compensation still being returned
+func (t *Transaction) Archivable() bool {
+	if !t.Status.IsFinal() || t.Outbox != nil {
+		return false
+	}
+	if t.ReversalID != "" && t.ReversedBy == "" {
+		return false
+	}
+	return t.Compensation == nil || t.Compensation.Status == CompensationStatusCompleted
+}
+
+// ReservesReference reports whether the transaction's reference must be
+// unique for its source account. Ledger-initiated transactions share
+// references by design and are exempt.
+func (t *Transaction) ReservesReference() bool {
+	return t.Reference != "" && !t.System
+}
+
+// WithoutBalanceChanges returns a copy of the transaction without its balance
+// changes, which show other accounts' balances and are for admins only
+func (t *Transaction) WithoutBalanceChanges() *Transaction {
+	copied := *t
+	copied.BalanceChanges = nil
+	return &copied
+}
+
+// MarshalJSON emits the amount and any refunded amount as decimal strings in
+// the transaction's currency
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type transaction Transaction
+	refundedAmount := ""
+	if t.RefundedAmount != 0 {
+		refundedAmount = t.RefundedAmount.Format(t.Currency)
+	}
+	return json.Marshal(struct {
+		transaction
+		Amount         string `json:"amount"`
+		RefundedAmount string `json:"refunded_amount,omitempty"`
+	}{transaction(t), t.Amount.Format(t.Currency), refundedAmount})
+}
+
+// UnmarshalJSON reads the amount and any refunded amount as decimals in the
+// transaction's currency
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	type transaction Transaction
+	var decoded struct {
+		transaction
+		Amount         Decimal `json:"amount"`
+		RefundedAmount Decimal `json:"refunded_amount"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*t = Transaction(decoded.transaction)
+	amount, err := decoded.Amount.Money(t.Currency)
+	if err != nil {
+		return err
+	}
+	refundedAmount, err := decoded.RefundedAmount.Money(t.Currency)
+	if err != nil {
+		return err
+	}
+	t.Amount = amount
+	t.RefundedAmount = refundedAmount
+	return nil
+}
+
+// RefundableAmount returns how much of the transaction is left to refund
+func (t *Transaction) RefundableAmount() Money {
+	return t.Amount - t.RefundedAmount
+}
+
+// IdempotencyRecord is the transaction an idempotency key was first used to
+// create, with a fingerprint of the payload it was used with
+type IdempotencyRecord struct {
+	Key           string    `bson:"_id"`
+	Fingerprint   string    `bson:"fingerprint"`
+	TransactionID string    `bson:"transaction_id"`
+	CreatedAt     time.Time `bson:"created_at"`
 }
 
 // TransactionRequest represents a request to process a transaction
@@ -59,22 +522,162 @@ type TransactionRequest struct {
 	Type          TransactionType        `json:"type"`
 	FromAccountID *string                `json:"from_account_id,omitempty"`
 	ToAccountID   *string                `json:"to_account_id,omitempty"`
-	Amount        float64                `json:"amount"`
+	Amount        Money                  `json:"amount"`
 	Currency      string                 `json:"currency"`
 	Description   string                 `json:"description"`
 	Reference     string                 `json:"reference"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	Tags          []string               `json:"tags,omitempty"`
+
+	// AllowDuplicate skips duplicate-submission detection for payments that
+	// are intentionally repeated
+	AllowDuplicate bool `json:"allow_duplicate,omitempty"`
+
+	// System marks transactions initiated by the ledger itself rather than a
+	// customer, such as micro-deposits and their clawbacks
+	System bool `json:"system,omitempty"`
+
+	// ReversedTransactionID is the transaction a reversal undoes
+	ReversedTransactionID string `json:"reversed_transaction_id,omitempty"`
+
+	// RefundedTransactionID is the payment a refund returns
+	RefundedTransactionID string `json:"refunded_transaction_id,omitempty"`
+
+	// CompensatedTransactionID is the transfer a compensation returns the
+	// debited amount of
+	CompensatedTransactionID string `json:"compensated_transaction_id,omitempty"`
+
+	// IdempotencyKey makes retries of the submission return the transaction
+	// it first created
+	IdempotencyKey string `json:"-"`
+	// IDSource says whether ID was supplied by the client, which requires it
+	// to be new, or generated
+	IDSource TransactionIDSource `json:"-"`
+
+	// ScheduledAt defers processing until the given time when it is in the
+	// future
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+
+	// BatchID is the batch the request is a leg of
+	BatchID string `json:"batch_id,omitempty"`
+
+	// SelfTransfer marks a transfer between two accounts of the same user;
+	// it is set by the ledger, never by the client
+	SelfTransfer bool `json:"self_transfer,omitempty"`
+
+	// ExternalSource is where a deposit's money came from
+	ExternalSource *ExternalParty `json:"external_source,omitempty"`
+	// ExternalDestination is where a withdrawal's money went
+	ExternalDestination *ExternalParty `json:"external_destination,omitempty"`
 }
 
-// IsValid validates the transaction request
+// MarshalJSON emits the amount as a decimal string in the request's currency
+func (tr TransactionRequest) MarshalJSON() ([]byte, error) {
+	type transactionRequest TransactionRequest
+	return json.Marshal(struct {
+		transactionRequest
+		Amount string `json:"amount"`
+	}{transactionRequest(tr), tr.Amount.Format(tr.Currency)})
+}
+
+// UnmarshalJSON reads the amount as a decimal in the request's currency.
+// Requests queued before amounts were kept in minor units carry a JSON
+// number in major units, which is read the same way.
+func (tr *TransactionRequest) UnmarshalJSON(data []byte) error {
+	type transactionRequest TransactionRequest
+	var decoded struct {
+		transactionRequest
+		Amount Decimal `json:"amount"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*tr = TransactionRequest(decoded.transactionRequest)
+	amount, err := decoded.Amount.Money(tr.Currency)
+	if err != nil {
+		return err
+	}
+	tr.Amount = amount
+	return nil
+}
+
+// Fingerprint identifies requests that would produce identical transactions
+func (tr *TransactionRequest) Fingerprint() string {
+	fromAccountID, toAccountID := "", ""
+	if tr.FromAccountID != nil {
+		fromAccountID = *tr.FromAccountID
+	}
+	if tr.ToAccountID != nil {
+		toAccountID = *tr.ToAccountID
+	}
+
+	parts := []string{
+		string(tr.Type),
+		fromAccountID,
+		toAccountID,
+		strconv.FormatInt(int64(tr.Amount), 10),
+		tr.Currency,
+		tr.Reference,
+	}
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// PayloadFingerprint identifies the full payload of a submission, so that an
+// idempotency key reused with a different payload can be told apart from a
+// retry
+func (tr *TransactionRequest) PayloadFingerprint() string {
+	var scheduledAt string
+	if tr.ScheduledAt != nil {
+		scheduledAt = tr.ScheduledAt.UTC().Format(time.RFC3339Nano)
+	}
+	// Map keys are marshalled in order, so equal metadata hashes alike
+	metadata, _ := json.Marshal(tr.Metadata)
+
+	parts := []string{
+		tr.Fingerprint(),
+		tr.Description,
+		string(metadata),
+		scheduledAt,
+		strconv.FormatBool(tr.AllowDuplicate),
+	}
+	// Appended only when set, so fingerprints of requests without external
+	// parties are unchanged
+	if tr.ExternalSource != nil || tr.ExternalDestination != nil {
+		parties, _ := json.Marshal([]*ExternalParty{tr.ExternalSource, tr.ExternalDestination})
+		parts = append(parts, string(parties))
+	}
+	if len(tr.Tags) > 0 {
+		parts = append(parts, strings.Join(tr.Tags, ","))
+	}
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// IsValid validates the transaction request and normalizes its currency code
+// and tags
 func (tr *TransactionRequest) IsValid() error {
-	if tr.Amount <= 0 {
+	if tr.Type == TransactionTypeVerification {
+		if tr.Amount != 0 {
+			return ErrInvalidAmount
+		}
+		// Verifications complete on submission, so there is nothing to defer
+		if tr.ScheduledAt != nil {
+			return ErrInvalidSchedule
+		}
+	} else if tr.Amount <= 0 {
 		return ErrInvalidAmount
 	}
 
 	if tr.Currency == "" {
 		return ErrMissingCurrency
 	}
+	currency, err := SupportedCurrencies.Normalize(tr.Currency)
+	if err != nil {
+		return err
+	}
+	tr.Currency = currency
 
 	switch tr.Type {
 	case TransactionTypeDeposit:
@@ -92,29 +695,302 @@ func (tr *TransactionRequest) IsValid() error {
 		if *tr.FromAccountID == *tr.ToAccountID {
 			return ErrSameAccount
 		}
+	case TransactionTypeVerification:
+		if tr.VerifiedAccountID() == nil {
+			return ErrMissingAccounts
+		}
+	case TransactionTypeReversal:
+		if tr.ReversedTransactionID == "" {
+			return ErrTransactionNotReversible
+		}
+		if tr.FromAccountID == nil && tr.ToAccountID == nil {
+			return ErrMissingAccounts
+		}
+		if tr.FromAccountID != nil && tr.ToAccountID != nil && *tr.FromAccountID == *tr.ToAccountID {
+			return ErrSameAccount
+		}
+	case TransactionTypeRefund:
+		if tr.RefundedTransactionID == "" {
+			return ErrTransactionNotRefundable
+		}
+		if tr.FromAccountID == nil && tr.ToAccountID == nil {
+			return ErrMissingAccounts
+		}
+		if tr.FromAccountID != nil && tr.ToAccountID != nil && *tr.FromAccountID == *tr.ToAccountID {
+			return ErrSameAccount
+		}
+	case TransactionTypeCompensation:
+		// Only the ledger issues compensations, always for a transfer
+		if tr.CompensatedTransactionID == "" {
+			return ErrInvalidTransactionType
+		}
+		if tr.ToAccountID == nil {
+			return ErrMissingToAccount
+		}
 	default:
 		return ErrInvalidTransactionType
 	}
 
+	if err := tr.checkExternalParties(); err != nil {
+		return err
+	}
+
+	tags, err := NormalizeTags(tr.Tags)
+	if err != nil {
+		return err
+	}
+	tr.Tags = tags
+
+	return ActiveTransactionLimits.Check(tr)
+}
+
+// checkExternalParties allows an external source only on a deposit and an
+// external destination only on a withdrawal, and checks any given
+func (tr *TransactionRequest) checkExternalParties() error {
+	if tr.ExternalSource != nil {
+		if tr.Type != TransactionTypeDeposit {
+			return ErrInvalidExternalParty
+		}
+		if err := tr.ExternalSource.IsValid(); err != nil {
+			return err
+		}
+	}
+	if tr.ExternalDestination != nil {
+		if tr.Type != TransactionTypeWithdrawal {
+			return ErrInvalidExternalParty
+		}
+		if err := tr.ExternalDestination.IsValid(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// AccountSummary represents account summary information
+// MissingExternalParty reports whether a customer's deposit lacks its
+// external source or their withdrawal its external destination. Transactions
+// the ledger initiates itself are exempt.
+func (tr *TransactionRequest) MissingExternalParty() bool {
+	if tr.System {
+		return false
+	}
+	switch tr.Type {
+	case TransactionTypeDeposit:
+		return tr.ExternalSource == nil
+	case TransactionTypeWithdrawal:
+		return tr.ExternalDestination == nil
+	}
+	return false
+}
+
+// VerifiedAccountID returns the account a verification request checks,
+// preferring the from account when both are given
+func (tr *TransactionRequest) VerifiedAccountID() *string {
+	if tr.FromAccountID != nil {
+		return tr.FromAccountID
+	}
+	return tr.ToAccountID
+}
+
+// AccountSummary represents account summary information. The counts and
+// totals cover the transactions created between From and To, or all of them
+// when neither is given.
 type AccountSummary struct {
-	Account           *Account   `json:"account"`
-	TransactionCount  int64      `json:"transaction_count"`
-	LastTransactionAt *time.Time `json:"last_transaction_at"`
+	Account *Account   `json:"account"`
+	From    *time.Time `json:"from_date,omitempty"`
+	To      *time.Time `json:"to_date,omitempty"`
+	AccountTotals
+}
+
+// AccountTotals is what an account's transactions add up to. The totals
+// count completed transactions only, and an incoming transfer counts what was
+// credited after any conversion.
+type AccountTotals struct {
+	TransactionCount  int64            `json:"transaction_count"`
+	LastTransactionAt *time.Time       `json:"last_transaction_at"`
+	Deposits          TransactionTotal `json:"deposits"`
+	Withdrawals       TransactionTotal `json:"withdrawals"`
+	IncomingTransfers TransactionTotal `json:"incoming_transfers"`
+	OutgoingTransfers TransactionTotal `json:"outgoing_transfers"`
+	// PendingCount counts the transactions still pending or processing
+	PendingCount int64 `json:"pending_count"`
+	FailedCount  int64 `json:"failed_count"`
+}
+
+// TransactionTotal is the amount and number of one kind of transaction
+type TransactionTotal struct {
+	Amount Money `json:"amount"`
+	Count  int64 `json:"count"`
+}
+
+// MarshalJSON emits the totals' amounts as decimal strings in the account's
+// currency
+func (s AccountSummary) MarshalJSON() ([]byte, error) {
+	type accountSummary AccountSummary
+	type transactionTotal struct {
+		Amount string `json:"amount"`
+		Count  int64  `json:"count"`
+	}
+	var currency string
+	if s.Account != nil {
+		currency = s.Account.Currency
+	}
+	format := func(t TransactionTotal) transactionTotal {
+		return transactionTotal{t.Amount.Format(currency), t.Count}
+	}
+	return json.Marshal(struct {
+		accountSummary
+		Deposits          transactionTotal `json:"deposits"`
+		Withdrawals       transactionTotal `json:"withdrawals"`
+		IncomingTransfers transactionTotal `json:"incoming_transfers"`
+		OutgoingTransfers transactionTotal `json:"outgoing_transfers"`
+	}{
+		accountSummary(s),
+		format(s.Deposits),
+		format(s.Withdrawals),
+		format(s.IncomingTransfers),
+		format(s.OutgoingTransfers),
+	})
 }
 
 // TransactionFilter represents filters for transaction queries
 type TransactionFilter struct {
-	AccountID *string            `json:"account_id,omitempty"`
-	Type      *TransactionType   `json:"type,omitempty"`
-	Status    *TransactionStatus `json:"status,omitempty"`
-	FromDate  *time.Time         `json:"from_date,omitempty"`
-	ToDate    *time.Time         `json:"to_date,omitempty"`
-	MinAmount *float64           `json:"min_amount,omitempty"`
-	MaxAmount *float64           `json:"max_amount,omitempty"`
-	Limit     int                `json:"limit,omitempty"`
-	Offset    int                `json:"offset,omitempty"`
+	AccountID   *string            `json:"account_id,omitempty"`
+	AccountIDs  []string           `json:"account_ids,omitempty"`
+	Type        *TransactionType   `json:"type,omitempty"`
+	Status      *TransactionStatus `json:"status,omitempty"`
+	FailureCode *FailureCode       `json:"failure_code,omitempty"`
+	FromDate    *time.Time         `json:"from_date,omitempty"`
+	ToDate      *time.Time         `json:"to_date,omitempty"`
+	MinAmount   *Decimal           `json:"min_amount,omitempty"`
+	MaxAmount   *Decimal           `json:"max_amount,omitempty"`
+	Reference   *string            `json:"reference,omitempty"`
+	Limit       int                `json:"limit,omitempty"`
+	Offset      int                `json:"offset,omitempty"`
+
+	// SortBy orders the results by a field, newest created first by default
+	SortBy TransactionSortField `json:"sort_by,omitempty"`
+	// SortOrder orders the results ascending or descending, descending by
+	// default
+	SortOrder SortOrder `json:"sort_order,omitempty"`
+
+	// FromAccountID matches only transactions debiting the account
+	FromAccountID *string `json:"from_account_id,omitempty"`
+	// FromAccountIDs matches only transactions debiting any of the accounts
+	FromAccountIDs []string `json:"from_account_ids,omitempty"`
+	// Types matches any of the types; Type takes precedence when both are set
+	Types []TransactionType `json:"types,omitempty"`
+	// ExternalSource matches deposits from the external party reference
+	ExternalSource *string `json:"external_source,omitempty"`
+	// ExternalDestination matches withdrawals to the external party reference
+	ExternalDestination *string `json:"external_destination,omitempty"`
+	// SelfTransfer matches only transfers between a user's own accounts when
+	// true, and only other transactions when false
+	SelfTransfer *bool `json:"self_transfer,omitempty"`
+	// Currency matches only transactions in the currency
+	Currency *string `json:"currency,omitempty"`
+	// ReferencePrefix matches references starting with the prefix; Reference
+	// takes precedence when both are set
+	ReferencePrefix *string `json:"reference_prefix,omitempty"`
+	// Metadata matches transactions whose top-level metadata holds each key
+	// with the string value given
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Tags matches transactions carrying every one of the tags, and AnyTags
+	// those carrying at least one
+	Tags    []string `json:"tags,omitempty"`
+	AnyTags []string `json:"any_tags,omitempty"`
+
+	// IncludeVerifications includes zero-amount verification pings, which are
+	// hidden unless requested or filtered for explicitly by type
+	IncludeVerifications bool `json:"include_verifications,omitempty"`
+
+	// Projection limits the fields read of each transaction; nil reads them
+	// whole
+	Projection *TransactionProjection `json:"-"`
+}
+
+// TransactionSortField names a field transactions can be listed in order of
+type TransactionSortField string
+
+const (
+	TransactionSortCreatedAt   TransactionSortField = "created_at"
+	TransactionSortAmount      TransactionSortField = "amount"
+	TransactionSortProcessedAt TransactionSortField = "processed_at"
+)
+
+// IsValid checks if the sort field is one transactions can be listed by
+func (f TransactionSortField) IsValid() bool {
+	switch f {
+	case TransactionSortCreatedAt, TransactionSortAmount, TransactionSortProcessedAt:
+		return true
+	}
+	return false
+}
+
+// SortOrder is the direction a listing is sorted in
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// IsValid checks if the sort order is ascending or descending
+func (o SortOrder) IsValid() bool {
+	return o == SortAscending || o == SortDescending
+}
+
+// MaxTransactionMetadataFilters bounds how many metadata keys one listing may
+// filter on
+const MaxTransactionMetadataFilters = 5
+
+// IsMetadataFilterKey reports whether a metadata key can be filtered on: a
+// top-level key of letters, digits, underscores and hyphens
+func IsMetadataFilterKey(key string) bool {
+	if key == "" || len(key) > 64 {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxTransactionPageSize bounds how many transactions one listing page holds
+const MaxTransactionPageSize = 100
+
+// TransactionPage is one page of a transaction listing with the count of
+// every transaction the filter matches
+type TransactionPage struct {
+	Transactions []*Transaction
+	Total        int64
+	HasMore      bool
+}
+
+// MinSearchQueryLength is the fewest characters a transaction search may
+// look for
+const MinSearchQueryLength = 3
+
+// TransactionMatch is a transaction found by a text search with its relevance
+// score, higher for closer matches
+type TransactionMatch struct {
+	Transaction *Transaction `json:"transaction"`
+	Score       float64      `json:"score"`
+}
+
+// ExcludesVerifications reports whether verification transactions should be
+// left out of the results of this filter
+func (f *TransactionFilter) ExcludesVerifications() bool {
+	return f.Type == nil && len(f.Types) == 0 && !f.IncludeVerifications
+}
+
+// TransactionDiagnostics aggregates everything on-call needs to investigate a
+// transaction. Sections whose backing store is unavailable are left empty and
+// explained in Notes.
+type TransactionDiagnostics struct {
+	Transaction *Transaction `json:"transaction"`
+	Accounts    []*Account   `json:"accounts,omitempty"`
+	Notes       []string     `json:"notes,omitempty"`
+	GeneratedAt time.Time    `json:"generated_at"`
 }
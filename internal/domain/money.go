@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in a currency's minor units, such as cents. The
+// currency is held by the struct carrying the amount.
+type Money int64
+
+// maxMoneyDigits bounds parsed amounts so they always fit in an int64
+const maxMoneyDigits = 18
+
+// ParseMoney parses a decimal string such as "10.25" into minor units of the
+// currency. More decimal places than the currency supports are rejected with
+// a PrecisionError unless they are trailing zeros.
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	if negative {
+		value = value[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(value, ".")
+	if whole == "" && fraction == "" || !isDigits(whole) || !isDigits(fraction) || hasPoint && fraction == "" {
+		return 0, ErrInvalidAmount
+	}
+
+	exponent := CurrencyExponent(currency)
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return 0, &PrecisionError{Currency: NormalizeCurrency(currency), Exponent: exponent}
+	}
+
+	digits := strings.TrimLeft(whole+fraction+strings.Repeat("0", exponent-len(fraction)), "0")
+	if len(digits) > maxMoneyDigits {
+		return 0, ErrInvalidAmount
+	}
+	if digits == "" {
+		return 0, nil
+	}
+
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	if negative {
+		units = -units
+	}
+
+	return Money(units), nil
+}
+
+// MoneyFromFloat converts an amount in major units, as stored before amounts
+// were kept in minor units, rounding to the currency's precision
+func MoneyFromFloat(amount float64, currency string) Money {
+	return Money(math.Round(amount * math.Pow10(CurrencyExponent(currency))))
+}
+
+// Format renders the amount as a decimal string in the currency's precision
+func (m Money) Format(currency string) string {
+	exponent := CurrencyExponent(currency)
+
+	units := int64(m)
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(uint64(abs64(units)), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Abs returns the amount without its sign
+func (m Money) Abs() Money {
+	return Money(abs64(int64(m)))
+}
+
+// Decimal is a decimal amount as sent by clients. It accepts a JSON string or
+// number and keeps the digits exactly as written, so no precision is lost
+// before the amount is parsed against its currency.
+type Decimal string
+
+// UnmarshalJSON accepts "10.25" as well as 10.25
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = ""
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*d = Decimal(value)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	// Plain decimals only; exponent notation would need rounding to parse
+	if strings.ContainsAny(string(number), "eE") {
+		return ErrInvalidAmount
+	}
+	*d = Decimal(number)
+	return nil
+}
+
+// Money parses the decimal into minor units of the currency. An empty
+// decimal, as left by an omitted field, is zero.
+func (d Decimal) Money(currency string) (Money, error) {
+	if d == "" {
+		return 0, nil
+	}
+	return ParseMoney(string(d), currency)
+}
+
+// Bound converts the decimal into minor units of a currency with the given
+// exponent for use as a range bound, rounding lower bounds up and upper
+// bounds down so the range never widens. It reports false if the decimal is
+// malformed.
+func (d Decimal) Bound(exponent int, lower bool) (Money, bool) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(string(d)))
+	if !ok || strings.ContainsAny(string(d), "eE/") {
+		return 0, false
+	}
+
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)))
+	units, remainder := new(big.Int).DivMod(value.Num(), value.Denom(), new(big.Int))
+	// DivMod floors, so only lower bounds with a remainder need adjusting
+	if lower && remainder.Sign() != 0 {
+		units.Add(units, big.NewInt(1))
+	}
+	if !units.IsInt64() {
+		return 0, false
+	}
+
+	return Money(units.Int64()), true
+}
+
+// Compare compares the decimal with another exactly, returning -1, 0 or +1.
+// It reports false if either is malformed.
+func (d Decimal) Compare(other Decimal) (int, bool) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(string(d)))
+	if !ok || strings.ContainsAny(string(d), "eE/") {
+		return 0, false
+	}
+	otherValue, ok := new(big.Rat).SetString(strings.TrimSpace(string(other)))
+	if !ok || strings.ContainsAny(string(other), "eE/") {
+		return 0, false
+	}
+	return value.Cmp(otherValue), true
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func abs64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
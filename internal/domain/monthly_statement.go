@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// StatementMonthLayout is the layout of a monthly statement's month
+const StatementMonthLayout = "2006-01"
+
+// StatementTotal sums the lines of one transaction type on a monthly
+// statement. Credits and Debits are both positive.
+type StatementTotal struct {
+	Type    TransactionType `json:"type" bson:"type"`
+	Count   int             `json:"count" bson:"count"`
+	Credits Money           `json:"credits" bson:"credits"`
+	Debits  Money           `json:"debits" bson:"debits"`
+}
+
+// MonthlyStatement is an account's statement of a calendar month in UTC,
+// generated once the month has closed. NotifiedAt is set once its
+// statement.generated event has been published.
+type MonthlyStatement struct {
+	ID               string           `json:"id" bson:"_id"`
+	AccountID        string           `json:"account_id" bson:"account_id"`
+	Month            string           `json:"month" bson:"month"`
+	From             string           `json:"from" bson:"from"`
+	To               string           `json:"to" bson:"to"`
+	Currency         string           `json:"currency" bson:"currency"`
+	OpeningBalance   Money            `json:"opening_balance" bson:"opening_balance"`
+	ClosingBalance   Money            `json:"closing_balance" bson:"closing_balance"`
+	TransactionCount int              `json:"transaction_count" bson:"transaction_count"`
+	Totals           []StatementTotal `json:"totals" bson:"totals"`
+	Lines            []StatementLine  `json:"lines,omitempty" bson:"lines"`
+	GeneratedAt      time.Time        `json:"generated_at" bson:"generated_at"`
+	NotifiedAt       *time.Time       `json:"notified_at,omitempty" bson:"notified_at,omitempty"`
+}
+
+// NewMonthlyStatement summarises the statement of a month, counting and
+// totalling its lines by type in the order each type first appears
+func NewMonthlyStatement(id string, statement *AccountStatement, month time.Time, generatedAt time.Time) *MonthlyStatement {
+	monthly := &MonthlyStatement{
+		ID:               id,
+		AccountID:        statement.AccountID,
+		Month:            month.Format(StatementMonthLayout),
+		From:             statement.From,
+		To:               statement.To,
+		Currency:         statement.Currency,
+		OpeningBalance:   statement.OpeningBalance,
+		ClosingBalance:   statement.ClosingBalance,
+		TransactionCount: len(statement.Lines),
+		Totals:           []StatementTotal{},
+		Lines:            statement.Lines,
+		GeneratedAt:      generatedAt,
+	}
+
+	index := make(map[TransactionType]int)
+	for _, line := range statement.Lines {
+		i, seen := index[line.Type]
+		if !seen {
+			i = len(monthly.Totals)
+			index[line.Type] = i
+			monthly.Totals = append(monthly.Totals, StatementTotal{Type: line.Type})
+		}
+		monthly.Totals[i].Count++
+		if line.Amount > 0 {
+			monthly.Totals[i].Credits += line.Amount
+		} else {
+			monthly.Totals[i].Debits -= line.Amount
+		}
+	}
+
+	return monthly
+}
+
+// WithoutLines returns a copy of the statement without its lines, as it is
+// listed
+func (s *MonthlyStatement) WithoutLines() *MonthlyStatement {
+	summary := *s
+	summary.Lines = nil
+	return &summary
+}
+
+// MarshalJSON emits the balances, totals and every line's amounts as decimal
+// strings in the account's currency
+func (s MonthlyStatement) MarshalJSON() ([]byte, error) {
+	type statementTotal struct {
+		StatementTotal
+		Credits string `json:"credits"`
+		Debits  string `json:"debits"`
+	}
+	totals := make([]statementTotal, len(s.Totals))
+	for i, total := range s.Totals {
+		totals[i] = statementTotal{total, total.Credits.Format(s.Currency), total.Debits.Format(s.Currency)}
+	}
+
+	type statementLine struct {
+		StatementLine
+		Amount  string `json:"amount"`
+		Balance string `json:"balance"`
+	}
+	// A listed statement has no lines, while a full one with none has an
+	// empty list
+	var lines *[]statementLine
+	if s.Lines != nil {
+		formatted := make([]statementLine, len(s.Lines))
+		for i, line := range s.Lines {
+			formatted[i] = statementLine{line, line.Amount.Format(s.Currency), line.Balance.Format(s.Currency)}
+		}
+		lines = &formatted
+	}
+
+	type monthlyStatement MonthlyStatement
+	return json.Marshal(struct {
+		monthlyStatement
+		OpeningBalance string           `json:"opening_balance"`
+		ClosingBalance string           `json:"closing_balance"`
+		Totals         []statementTotal `json:"totals"`
+		Lines          *[]statementLine `json:"lines,omitempty"`
+	}{monthlyStatement(s), s.OpeningBalance.Format(s.Currency), s.ClosingBalance.Format(s.Currency), totals, lines})
+}
+
+// StatementMonth returns the first instant of the UTC month a time falls in
+func StatementMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// NotificationType names a notification event
+type NotificationType string
+
+const (
+	NotificationTransactionCompleted NotificationType = "transaction.completed"
+	NotificationTransactionFailed    NotificationType = "transaction.failed"
+	NotificationTransferInternal     NotificationType = "transfer.internal"
+	NotificationAccountLowBalance    NotificationType = "account.low_balance"
+	NotificationAccountCreated       NotificationType = "account.created"
+	NotificationStatementGenerated   NotificationType = "statement.generated"
+)
+
+// NotificationEvent is the message published for a notification. Transaction
+// events carry the transaction and, when failed, the error; a completed
+// transfer between a user's own accounts is sent as transfer.internal rather
+// than transaction.completed. Low balance and account created events carry the
+// account, and statement generated events the account and the ID of its new
+// monthly statement.
+type NotificationEvent struct {
+	EventID     string           `json:"event_id"`
+	Type        NotificationType `json:"type"`
+	OccurredAt  time.Time        `json:"occurred_at"`
+	Transaction *Transaction     `json:"transaction,omitempty"`
+	Account     *Account         `json:"account,omitempty"`
+	StatementID string           `json:"statement_id,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "context"
+
+// Principal is the authenticated caller of a request. An admin may act on
+// every account; a user only on their own.
+type Principal struct {
+	UserID string
+	Admin  bool
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a context carrying the caller
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller a context carries, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// AuthorizeUser fails with ErrForbidden unless the context's caller may act
+// for the user. A context without a caller, as in the processor or before
+// authentication is required, is not scoped.
+func AuthorizeUser(ctx context.Context, userID string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Admin || principal.UserID == userID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// Unscoped reports whether the context's caller may see every account: an
+// admin, or no caller at all, as in the processor or before authentication is
+// required
+func Unscoped(ctx context.Context) bool {
+	principal, ok := PrincipalFromContext(ctx)
+	return !ok || principal.Admin
+}
+
+// Actor names the context's caller for audit records: the user's ID, "admin"
+// for an admin, or "system" when there is no caller
+func Actor(ctx context.Context) string {
+	principal, ok := PrincipalFromContext(ctx)
+	switch {
+	case !ok:
+		return "system"
+	case principal.Admin:
+		return "admin"
+	default:
+		return principal.UserID
+	}
+}
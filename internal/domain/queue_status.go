@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// QueueEstimateNote labels the queue position and wait of a pending
+// transaction as estimates
+const QueueEstimateNote = "Approximate: the position counts pending transactions submitted earlier, and the wait assumes the processors keep their recent pace"
+
+// ProcessingRate is how fast one processor has recently been finishing
+// transactions from a queue, shared so the API can estimate waits
+type ProcessingRate struct {
+	Queue     string  `json:"queue" bson:"queue"`
+	Worker    string  `json:"worker" bson:"worker"`
+	PerSecond float64 `json:"per_second" bson:"per_second"`
+	Samples   int     `json:"samples" bson:"samples"`
+	// SkippedCancelled counts the messages of cancelled transactions the
+	// processor has skipped on a tombstone since it started
+	SkippedCancelled int64     `json:"skipped_cancelled" bson:"skipped_cancelled"`
+	UpdatedAt        time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// QueueStatsStore keeps the enqueue sequence of a queue and the processing
+// rates its processors report
+type QueueStatsStore interface {
+	// NextSequence returns the queue's next enqueue sequence, starting at 1
+	NextSequence(ctx context.Context, queue string) (int64, error)
+	// SaveProcessingRate replaces the rate its worker last reported
+	SaveProcessingRate(ctx context.Context, rate *ProcessingRate) error
+	// ProcessingRates lists the rates reported for the queue since a time
+	ProcessingRates(ctx context.Context, queue string, since time.Time) ([]*ProcessingRate, error)
+}
+
+// QueueEstimate is where a pending transaction stands in the queue and
+// roughly how long it has left to wait
+type QueueEstimate struct {
+	// Ahead counts the pending transactions submitted before this one
+	Ahead int64 `json:"ahead"`
+	// Position is the transaction's place in line, Ahead plus one
+	Position int64 `json:"position"`
+	// ProcessingRate is the transactions finished per second across the
+	// processors, when they have reported one recently
+	ProcessingRate        *float64   `json:"processing_rate_per_second,omitempty"`
+	EstimatedWaitSeconds  *int64     `json:"estimated_wait_seconds,omitempty"`
+	EstimatedCompletionAt *time.Time `json:"estimated_completion_at,omitempty"`
+	Approximate           bool       `json:"approximate"`
+	Note                  string     `json:"note"`
+}
+
+// TransactionStatusReport is a transaction's status, with a queue estimate
+// while it is pending
+type TransactionStatusReport struct {
+	TransactionID string            `json:"transaction_id"`
+	Status        TransactionStatus `json:"status"`
+	ProcessedAt   *time.Time        `json:"processed_at,omitempty"`
+	QueueSequence int64             `json:"queue_sequence,omitempty"`
+	Queue         *QueueEstimate    `json:"queue,omitempty"`
+}
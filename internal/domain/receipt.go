@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// ReceiptParty is an account on either side of a receipted transaction and
+// the user holding it
+type ReceiptParty struct {
+	AccountID string `json:"account_id"`
+	UserID    string `json:"user_id"`
+}
+
+// ReceiptFee is a fee charged for a receipted transaction
+type ReceiptFee struct {
+	TransactionID string            `json:"transaction_id"`
+	Amount        Decimal           `json:"amount"`
+	Currency      string            `json:"currency"`
+	Status        TransactionStatus `json:"status"`
+}
+
+// Receipt documents a completed transaction. Hash is an HMAC of every field
+// but IssuedAt, so a receipt handed back can be checked against what was
+// issued.
+type Receipt struct {
+	TransactionID string            `json:"transaction_id"`
+	Type          TransactionType   `json:"type"`
+	Status        TransactionStatus `json:"status"`
+	Amount        Decimal           `json:"amount"`
+	Currency      string            `json:"currency"`
+	Description   string            `json:"description,omitempty"`
+	Reference     string            `json:"reference,omitempty"`
+	From          *ReceiptParty     `json:"from,omitempty"`
+	To            *ReceiptParty     `json:"to,omitempty"`
+	Fees          []ReceiptFee      `json:"fees"`
+	CreatedAt     time.Time         `json:"created_at"`
+	ProcessedAt   *time.Time        `json:"processed_at,omitempty"`
+	IssuedAt      time.Time         `json:"issued_at"`
+	Hash          string            `json:"verification_hash"`
+}
+
+// NewReceipt builds the receipt of a transaction, less its hash, from the
+// transaction, the accounts it moved money between, either of which may be
+// nil, and its fees
+func NewReceipt(transaction *Transaction, from, to *Account, fees []*Transaction, issuedAt time.Time) *Receipt {
+	receipt := &Receipt{
+		TransactionID: transaction.ID,
+		Type:          transaction.Type,
+		Status:        transaction.Status,
+		Amount:        Decimal(transaction.Amount.Format(transaction.Currency)),
+		Currency:      transaction.Currency,
+		Description:   transaction.Description,
+		Reference:     transaction.Reference,
+		From:          receiptParty(from),
+		To:            receiptParty(to),
+		Fees:          make([]ReceiptFee, 0, len(fees)),
+		CreatedAt:     transaction.CreatedAt,
+		ProcessedAt:   transaction.ProcessedAt,
+		IssuedAt:      issuedAt,
+	}
+	for _, fee := range fees {
+		receipt.Fees = append(receipt.Fees, ReceiptFee{
+			TransactionID: fee.ID,
+			Amount:        Decimal(fee.Amount.Format(fee.Currency)),
+			Currency:      fee.Currency,
+			Status:        fee.Status,
+		})
+	}
+	return receipt
+}
+
+func receiptParty(account *Account) *ReceiptParty {
+	if account == nil {
+		return nil
+	}
+	return &ReceiptParty{AccountID: account.ID, UserID: account.UserID}
+}
+
+// Sign returns the receipt's hash under a secret. Times are hashed in UTC
+// and an empty fee list like a missing one, so a receipt hashes alike after
+// a round trip through JSON.
+func (r Receipt) Sign(secret string) string {
+	r.IssuedAt, r.Hash = time.Time{}, ""
+	r.CreatedAt = r.CreatedAt.UTC()
+	if r.ProcessedAt != nil {
+		processedAt := r.ProcessedAt.UTC()
+		r.ProcessedAt = &processedAt
+	}
+	if len(r.Fees) == 0 {
+		r.Fees = nil
+	}
+
+	// Struct fields are marshalled in a fixed order
+	payload, _ := json.Marshal(r)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether the receipt's hash matches its fields under a
+// secret, comparing in constant time
+func (r Receipt) Verify(secret string) bool {
+	return r.Hash != "" && hmac.Equal([]byte(r.Sign(secret)), []byte(r.Hash))
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Movements returns the balance change a stored transaction accounts for on
+// each of its accounts. A completed transaction debits its source by its
+// amount and credits its destination by its amount, or by the converted
+// amount of a cross-currency transfer. A failed transfer whose credit was
+// compensated had its debit posted. Holds, external transfers and
+// verifications never change balances.
+func (t *Transaction) Movements() map[string]Money {
+	movements := make(map[string]Money)
+
+	switch t.Type {
+	case TransactionTypeHold, TransactionTypeExternalTransfer, TransactionTypeVerification:
+		return movements
+	}
+
+	switch {
+	case t.Status == TransactionStatusCompleted:
+		if t.FromAccountID != nil {
+			movements[*t.FromAccountID] -= t.Amount
+		}
+		if t.ToAccountID != nil {
+			credit := t.Amount
+			if t.Exchange != nil {
+				credit = t.Exchange.CreditedAmount
+			}
+			movements[*t.ToAccountID] += credit
+		}
+	case t.Status == TransactionStatusFailed && t.Compensation != nil && t.FromAccountID != nil:
+		movements[*t.FromAccountID] -= t.Amount
+	}
+
+	return movements
+}
+
+// Discrepancy is an account whose balance differs from the balance its
+// opening balance and transactions account for. Delta is the balance less
+// the expected balance.
+type Discrepancy struct {
+	AccountID string `json:"account_id" bson:"account_id"`
+	Currency  string `json:"currency" bson:"currency"`
+	Expected  Money  `json:"expected" bson:"expected"`
+	Actual    Money  `json:"actual" bson:"actual"`
+	Delta     Money  `json:"delta" bson:"delta"`
+	// AdjustmentID is the adjustment recorded for the delta once the
+	// discrepancy was approved and fixed
+	AdjustmentID string `json:"adjustment_id,omitempty" bson:"adjustment_id,omitempty"`
+}
+
+// MarshalJSON emits the amounts as decimal strings in the account's currency
+func (d Discrepancy) MarshalJSON() ([]byte, error) {
+	type discrepancy Discrepancy
+	return json.Marshal(struct {
+		discrepancy
+		Expected string `json:"expected"`
+		Actual   string `json:"actual"`
+		Delta    string `json:"delta"`
+	}{discrepancy(d), d.Expected.Format(d.Currency), d.Actual.Format(d.Currency), d.Delta.Format(d.Currency)})
+}
+
+// ReconciliationReport lists the accounts whose balances did not match their
+// transactions in one reconciliation run
+type ReconciliationReport struct {
+	ID              string         `json:"id" bson:"_id"`
+	StartedAt       time.Time      `json:"started_at" bson:"started_at"`
+	CompletedAt     time.Time      `json:"completed_at" bson:"completed_at"`
+	AccountsChecked int64          `json:"accounts_checked" bson:"accounts_checked"`
+	Discrepancies   []*Discrepancy `json:"discrepancies" bson:"discrepancies"`
+}
+
+// Discrepancy returns the report's discrepancy for an account, or nil if the
+// account matched
+func (r *ReconciliationReport) Discrepancy(accountID string) *Discrepancy {
+	for _, discrepancy := range r.Discrepancies {
+		if discrepancy.AccountID == accountID {
+			return discrepancy
+		}
+	}
+	return nil
+}
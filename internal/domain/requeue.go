@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// Requeueable reports whether an admin may queue the transaction again: one
+// still pending, or one failed with a retryable code. Batch legs, transfers
+// whose debit was compensated and archived transactions are never requeued,
+// nor are failed reversals, whose claim on the original was released, or
+// transactions that expired awaiting approval, which would skip it.
+func (t *Transaction) Requeueable() bool {
+	if t.BatchID != "" || t.Compensation != nil || t.Archived {
+		return false
+	}
+	switch t.Status {
+	case TransactionStatusPending:
+		return true
+	case TransactionStatusFailed:
+		if !t.FailureCode.Retryable() || t.Type == TransactionTypeReversal {
+			return false
+		}
+		for _, change := range t.StatusHistory {
+			if change.Status == TransactionStatusPendingApproval {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// RequeueCount is how many times the transaction has been queued again
+func (t *Transaction) RequeueCount() int {
+	count := 0
+	for _, change := range t.StatusHistory {
+		if change.RequeuedBy != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// TransactionRequeue reports a transaction queued again by an admin
+type TransactionRequeue struct {
+	TransactionID string            `json:"transaction_id"`
+	Status        TransactionStatus `json:"status"`
+	RequeueCount  int               `json:"requeue_count"`
+	RequeuedBy    string            `json:"requeued_by"`
+	RequeuedAt    time.Time         `json:"requeued_at"`
+}
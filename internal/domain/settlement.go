@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// SettlementGroup is a named set of accounts settled externally on their net
+// position against the rest of the ledger
+type SettlementGroup struct {
+	ID         string    `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	AccountIDs []string  `json:"account_ids" db:"account_ids"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// HasAccount reports whether the account belongs to the group
+func (g *SettlementGroup) HasAccount(accountID string) bool {
+	for _, id := range g.AccountIDs {
+		if id == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// SettlementPosition is a group's net position in one currency. Net is
+// positive when the group received more than it sent.
+type SettlementPosition struct {
+	Currency         string `json:"currency"`
+	Inflow           Money  `json:"inflow"`
+	Outflow          Money  `json:"outflow"`
+	Net              Money  `json:"net"`
+	TransactionCount int    `json:"transaction_count"`
+}
+
+// MarshalJSON emits the amounts as decimal strings in the position's currency
+func (p SettlementPosition) MarshalJSON() ([]byte, error) {
+	type settlementPosition SettlementPosition
+	return json.Marshal(struct {
+		settlementPosition
+		Inflow  string `json:"inflow"`
+		Outflow string `json:"outflow"`
+		Net     string `json:"net"`
+	}{
+		settlementPosition(p),
+		p.Inflow.Format(p.Currency),
+		p.Outflow.Format(p.Currency),
+		p.Net.Format(p.Currency),
+	})
+}
+
+// SettlementNet is the netting result for a group on a given day
+type SettlementNet struct {
+	GroupID        string               `json:"group_id"`
+	Date           string               `json:"date"`
+	Positions      []SettlementPosition `json:"positions"`
+	TransactionIDs []string             `json:"transaction_ids"`
+}
+
+// Settlement is the outcome of settling a group's net position for a day
+type Settlement struct {
+	BatchID        string         `json:"batch_id"`
+	Net            *SettlementNet `json:"net"`
+	Transactions   []*Transaction `json:"transactions"`
+	AlreadySettled bool           `json:"already_settled"`
+}
+
+// SettlementDay returns the UTC bounds of the day a settlement covers
+func SettlementDay(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.Add(24 * time.Hour)
+}
+
+// ComputeSettlementNet nets the completed transfers processed on the given
+// UTC day between the group's accounts and accounts outside the group.
+// Transfers between two members of the group cancel out and are ignored.
+func ComputeSettlementNet(group *SettlementGroup, date time.Time, transactions []*Transaction) *SettlementNet {
+	dayStart, dayEnd := SettlementDay(date)
+
+	positions := make(map[string]*SettlementPosition)
+	net := &SettlementNet{
+		GroupID:        group.ID,
+		Date:           dayStart.Format("2006-01-02"),
+		Positions:      []SettlementPosition{},
+		TransactionIDs: []string{},
+	}
+
+	for _, tx := range transactions {
+		if tx.Type != TransactionTypeTransfer || tx.Status != TransactionStatusCompleted {
+			continue
+		}
+		if tx.FromAccountID == nil || tx.ToAccountID == nil {
+			continue
+		}
+
+		settledAt := tx.CreatedAt
+		if tx.ProcessedAt != nil {
+			settledAt = *tx.ProcessedAt
+		}
+		if settledAt.Before(dayStart) || !settledAt.Before(dayEnd) {
+			continue
+		}
+
+		fromMember := group.HasAccount(*tx.FromAccountID)
+		toMember := group.HasAccount(*tx.ToAccountID)
+		if fromMember == toMember {
+			continue
+		}
+
+		position, exists := positions[tx.Currency]
+		if !exists {
+			position = &SettlementPosition{Currency: tx.Currency}
+			positions[tx.Currency] = position
+		}
+
+		if toMember {
+			position.Inflow += tx.Amount
+		} else {
+			position.Outflow += tx.Amount
+		}
+		position.TransactionCount++
+		net.TransactionIDs = append(net.TransactionIDs, tx.ID)
+	}
+
+	for _, position := range positions {
+		position.Net = position.Inflow - position.Outflow
+		net.Positions = append(net.Positions, *position)
+	}
+	sort.Slice(net.Positions, func(i, j int) bool {
+		return net.Positions[i].Currency < net.Positions[j].Currency
+	})
+	sort.Strings(net.TransactionIDs)
+
+	return net
+}
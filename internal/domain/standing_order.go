@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// StandingOrderStatus represents the state of a standing order
+type StandingOrderStatus string
+
+const (
+	StandingOrderStatusActive StandingOrderStatus = "active"
+	// StandingOrderStatusPaused orders keep their schedule but skip runs
+	StandingOrderStatusPaused StandingOrderStatus = "paused"
+	// StandingOrderStatusCompleted orders have passed their end date
+	StandingOrderStatusCompleted StandingOrderStatus = "completed"
+	StandingOrderStatusCancelled StandingOrderStatus = "cancelled"
+)
+
+// IsClosed reports whether an order in this status will never run again
+func (s StandingOrderStatus) IsClosed() bool {
+	return s == StandingOrderStatusCompleted || s == StandingOrderStatusCancelled
+}
+
+// StandingOrderFrequency is the unit a standing order repeats in
+type StandingOrderFrequency string
+
+const (
+	StandingOrderFrequencyDaily   StandingOrderFrequency = "daily"
+	StandingOrderFrequencyWeekly  StandingOrderFrequency = "weekly"
+	StandingOrderFrequencyMonthly StandingOrderFrequency = "monthly"
+)
+
+// IsValid reports whether the frequency is a known frequency
+func (f StandingOrderFrequency) IsValid() bool {
+	switch f {
+	case StandingOrderFrequencyDaily, StandingOrderFrequencyWeekly, StandingOrderFrequencyMonthly:
+		return true
+	default:
+		return false
+	}
+}
+
+// StandingOrder transfers a fixed amount between two accounts on a recurring
+// schedule. Runs fall every Interval days, weeks or months from StartAt;
+// monthly runs keep StartAt's day of the month, moving to the last day of
+// shorter months.
+type StandingOrder struct {
+	ID            string                 `json:"id" db:"id"`
+	FromAccountID string                 `json:"from_account_id" db:"from_account_id"`
+	ToAccountID   string                 `json:"to_account_id" db:"to_account_id"`
+	Amount        Money                  `json:"amount" db:"amount"`
+	Currency      string                 `json:"currency" db:"currency"`
+	Description   string                 `json:"description" db:"description"`
+	Frequency     StandingOrderFrequency `json:"frequency" db:"frequency"`
+	Interval      int                    `json:"interval" db:"interval"`
+	StartAt       time.Time              `json:"start_at" db:"start_at"`
+	EndAt         *time.Time             `json:"end_at,omitempty" db:"end_at"`
+	// RunCount is the number of scheduled runs that have passed, including
+	// skipped ones, and NextRunAt is the run after them
+	RunCount  int                 `json:"run_count" db:"run_count"`
+	NextRunAt time.Time           `json:"next_run_at" db:"next_run_at"`
+	Status    StandingOrderStatus `json:"status" db:"status"`
+	// TransactionIDs lists the transactions the order has submitted, oldest first
+	TransactionIDs []string  `json:"transaction_ids" db:"transaction_ids"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// MarshalJSON emits the amount as a decimal string in the order's currency
+func (o StandingOrder) MarshalJSON() ([]byte, error) {
+	type standingOrder StandingOrder
+	return json.Marshal(struct {
+		standingOrder
+		Amount string `json:"amount"`
+	}{standingOrder(o), o.Amount.Format(o.Currency)})
+}
+
+// RunAt returns the time of the order's nth run, counting from zero
+func (o *StandingOrder) RunAt(n int) time.Time {
+	steps := n * o.Interval
+	switch o.Frequency {
+	case StandingOrderFrequencyDaily:
+		return o.StartAt.AddDate(0, 0, steps)
+	case StandingOrderFrequencyWeekly:
+		return o.StartAt.AddDate(0, 0, 7*steps)
+	default:
+		return addMonthsClamped(o.StartAt, steps)
+	}
+}
+
+// RunIDFor returns the ID of the transaction submitted for the nth run, so a
+// run can never submit two transactions
+func (o *StandingOrder) RunIDFor(n int) string {
+	return o.ID + "-run-" + strconv.Itoa(n)
+}
+
+// Advance moves the order past every run due at now, completing it when the
+// next run would fall after its end date. A processor that was down for
+// several runs resumes with the next future run rather than catching up.
+func (o *StandingOrder) Advance(now time.Time) {
+	for !o.NextRunAt.After(now) {
+		o.RunCount++
+		o.NextRunAt = o.RunAt(o.RunCount)
+	}
+	if o.EndAt != nil && o.NextRunAt.After(*o.EndAt) {
+		o.Status = StandingOrderStatusCompleted
+	}
+}
+
+// addMonthsClamped adds months to t, keeping its day of the month where the
+// target month has it and using the month's last day otherwise
+func addMonthsClamped(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// StandingOrderRequest represents a request to create a standing order
+type StandingOrderRequest struct {
+	FromAccountID string
+	ToAccountID   string
+	Amount        Money
+	Currency      string
+	Description   string
+	Frequency     StandingOrderFrequency
+	Interval      int
+	StartAt       time.Time
+	EndAt         *time.Time
+}
+
+// IsValid validates the standing order request, normalizing its currency and
+// defaulting its interval to one
+func (r *StandingOrderRequest) IsValid() error {
+	if r.FromAccountID == "" || r.ToAccountID == "" {
+		return ErrMissingAccounts
+	}
+	if r.FromAccountID == r.ToAccountID {
+		return ErrSameAccount
+	}
+	if r.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+
+	if r.Currency == "" {
+		return ErrMissingCurrency
+	}
+	currency, err := SupportedCurrencies.Normalize(r.Currency)
+	if err != nil {
+		return err
+	}
+	r.Currency = currency
+
+	if limit, ok := ActiveTransactionLimits.MaxAmountIn(r.Currency); ok && r.Amount > limit {
+		return ErrAmountTooLarge
+	}
+
+	if r.Interval == 0 {
+		r.Interval = 1
+	}
+	if !r.Frequency.IsValid() || r.Interval < 0 {
+		return ErrInvalidStandingOrder
+	}
+	if r.EndAt != nil && r.EndAt.Before(r.StartAt) {
+		return ErrInvalidStandingOrder
+	}
+
+	return nil
+}
+
+// StandingOrderUpdate changes the editable fields of a standing order; nil
+// fields are left unchanged. The amount is read in the order's currency.
+type StandingOrderUpdate struct {
+	Amount      *Decimal
+	Description *string
+	Status      *StandingOrderStatus
+}
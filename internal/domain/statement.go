@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// StatementDateLayout is the layout of a statement's from and to dates
+const StatementDateLayout = "2006-01-02"
+
+// StatementLine is one transaction on a statement. Amount is signed,
+// negative for money leaving the account, and Balance is the balance after it.
+type StatementLine struct {
+	TransactionID string            `json:"transaction_id" bson:"transaction_id"`
+	Type          TransactionType   `json:"type" bson:"type"`
+	Status        TransactionStatus `json:"status" bson:"status"`
+	Description   string            `json:"description" bson:"description"`
+	Reference     string            `json:"reference" bson:"reference"`
+	Date          time.Time         `json:"date" bson:"date"`
+	Amount        Money             `json:"amount" bson:"amount"`
+	Balance       Money             `json:"balance" bson:"balance"`
+}
+
+// AccountStatement lists the transactions that moved an account's balance
+// between two dates, both included, in the order they were made
+type AccountStatement struct {
+	AccountID      string          `json:"account_id"`
+	Currency       string          `json:"currency"`
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	OpeningBalance Money           `json:"opening_balance"`
+	ClosingBalance Money           `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+}
+
+// MarshalJSON emits the balances and every line's amounts as decimal strings
+// in the account's currency
+func (s AccountStatement) MarshalJSON() ([]byte, error) {
+	type statementLine struct {
+		StatementLine
+		Amount  string `json:"amount"`
+		Balance string `json:"balance"`
+	}
+	lines := make([]statementLine, len(s.Lines))
+	for i, line := range s.Lines {
+		lines[i] = statementLine{line, line.Amount.Format(s.Currency), line.Balance.Format(s.Currency)}
+	}
+
+	type accountStatement AccountStatement
+	return json.Marshal(struct {
+		accountStatement
+		OpeningBalance string          `json:"opening_balance"`
+		ClosingBalance string          `json:"closing_balance"`
+		Lines          []statementLine `json:"lines"`
+	}{accountStatement(s), s.OpeningBalance.Format(s.Currency), s.ClosingBalance.Format(s.Currency), lines})
+}
+
+// StatementPeriod returns the UTC bounds of the days from and to, given as
+// YYYY-MM-DD, with the end exclusive. A missing or invalid date, or a from
+// after to, gives the month of now instead.
+func StatementPeriod(from, to string, now time.Time) (time.Time, time.Time) {
+	start, startErr := time.Parse(StatementDateLayout, from)
+	end, endErr := time.Parse(StatementDateLayout, to)
+	if startErr != nil || endErr != nil || start.After(end) {
+		now = now.UTC()
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+	return start, end.AddDate(0, 0, 1)
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// transactionFields maps each transaction field a listing may select, by its
+// JSON name, to its document field
+var transactionFields = map[string]string{
+	"id":                         "_id",
+	"type":                       "type",
+	"from_account_id":            "from_account_id",
+	"to_account_id":              "to_account_id",
+	"amount":                     "amount",
+	"currency":                   "currency",
+	"status":                     "status",
+	"description":                "description",
+	"reference":                  "reference",
+	"metadata":                   "metadata",
+	"tags":                       "tags",
+	"created_at":                 "created_at",
+	"updated_at":                 "updated_at",
+	"processed_at":               "processed_at",
+	"error_message":              "error_message",
+	"failure_code":               "failure_code",
+	"settlement_batch_id":        "settlement_batch_id",
+	"system":                     "system",
+	"scheduled_at":               "scheduled_at",
+	"requeued_at":                "requeued_at",
+	"batch_id":                   "batch_id",
+	"self_transfer":              "self_transfer",
+	"external_source":            "external_source",
+	"external_destination":       "external_destination",
+	"queue_sequence":             "queue_sequence",
+	"id_source":                  "id_source",
+	"compensation":               "compensation",
+	"compensated_transaction_id": "compensated_transaction_id",
+	"exchange":                   "exchange",
+	"balance_changes":            "balance_changes",
+	"idempotency_key":            "idempotency_key",
+	"status_history":             "status_history",
+	"reversed_transaction_id":    "reversed_transaction_id",
+	"reversal_id":                "reversal_id",
+	"reversed_by":                "reversed_by",
+	"refunded_transaction_id":    "refunded_transaction_id",
+	"refunded_amount":            "refunded_amount",
+	"archived":                   "archived",
+	"archived_at":                "archived_at",
+}
+
+// DefaultExcludedTransactionFields are the heavyweight fields left out of
+// transaction listings unless asked for
+var DefaultExcludedTransactionFields = []string{"metadata", "error_message"}
+
+// TransactionFieldNames lists the fields a listing may select, sorted
+func TransactionFieldNames() []string {
+	names := make([]string, 0, len(transactionFields))
+	for name := range transactionFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseTransactionFields splits a comma-separated list of field names,
+// dropping blanks and repeats, and returns any it does not know separately
+func ParseTransactionFields(value string) (fields, unknown []string) {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "" || slices.Contains(fields, name) || slices.Contains(unknown, name):
+		case transactionFields[name] == "":
+			unknown = append(unknown, name)
+		default:
+			fields = append(fields, name)
+		}
+	}
+	return fields, unknown
+}
+
+// TransactionProjection limits the fields of the transactions a listing
+// reads and returns. Include names the only fields kept; without it every
+// field is kept but those in Exclude.
+type TransactionProjection struct {
+	Include []string
+	Exclude []string
+}
+
+// DefaultTransactionProjection is the projection of a listing that selects
+// no fields, leaving out DefaultExcludedTransactionFields
+func DefaultTransactionProjection() *TransactionProjection {
+	return &TransactionProjection{Exclude: slices.Clone(DefaultExcludedTransactionFields)}
+}
+
+// DocumentFields maps the document fields to read to 1, or those to leave
+// out to 0. An amount is read with its currency, which formats it.
+func (p *TransactionProjection) DocumentFields() map[string]int {
+	fields := make(map[string]int)
+	if len(p.Include) > 0 {
+		for _, name := range p.Include {
+			fields[transactionFields[name]] = 1
+			if name == "amount" || name == "refunded_amount" {
+				fields["currency"] = 1
+			}
+		}
+		return fields
+	}
+	for _, name := range p.Exclude {
+		fields[transactionFields[name]] = 0
+	}
+	return fields
+}
+
+// Select renders a transaction as JSON with only the projected fields
+func (p *TransactionProjection) Select(transaction *Transaction) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(transaction)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil, err
+	}
+
+	if len(p.Include) == 0 {
+		for _, name := range p.Exclude {
+			delete(all, name)
+		}
+		return all, nil
+	}
+	selected := make(map[string]json.RawMessage, len(p.Include))
+	for _, name := range p.Include {
+		if value, ok := all[name]; ok {
+			selected[name] = value
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,12 @@
+package domain
+
+// MaxTransactionLookupIDs is how many transactions one lookup may fetch
+const MaxTransactionLookupIDs = 500
+
+// TransactionLookup is the result of fetching transactions by ID. Transactions
+// holds those found in the order their IDs were first asked for, and Missing
+// the IDs of those not found, in the same order.
+type TransactionLookup struct {
+	Transactions []*Transaction
+	Missing      []string
+}
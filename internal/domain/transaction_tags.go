@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTransactionTags is how many tags one transaction may carry
+	MaxTransactionTags = 10
+	// MaxTransactionTagLength is how many characters one tag may have
+	MaxTransactionTagLength = 32
+)
+
+// NormalizeTags trims and lowercases tags and drops repeated ones, keeping
+// their order. It fails with ErrInvalidTags if there are more than
+// MaxTransactionTags, or one is empty, too long or has characters other than
+// letters, digits, spaces, '-' and '_'.
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > MaxTransactionTagLength {
+			return nil, ErrInvalidTags
+		}
+		for _, r := range tag {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && r != '-' && r != '_' {
+				return nil, ErrInvalidTags
+			}
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTransactionTags {
+		return nil, ErrInvalidTags
+	}
+
+	return normalized, nil
+}
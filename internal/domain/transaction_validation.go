@@ -0,0 +1,43 @@
+package domain
+
+import "encoding/json"
+
+// TransactionValidation is the outcome of a dry run: whether a transaction
+// would succeed if it were submitted now, and what it would do
+type TransactionValidation struct {
+	WouldSucceed  bool        `json:"would_succeed"`
+	FailureCode   FailureCode `json:"failure_code,omitempty"`
+	FailureReason string      `json:"failure_reason,omitempty"`
+	// Fee is the fee that would be charged on the transaction, in its
+	// currency
+	Fee      Money  `json:"-"`
+	Currency string `json:"currency"`
+	// ResultingBalances estimates the balance each account would be left
+	// with, which changes if other transactions post first
+	ResultingBalances []*BalanceEstimate `json:"resulting_balance_estimate,omitempty"`
+}
+
+// MarshalJSON emits the fee as a decimal string in the transaction's currency
+func (v TransactionValidation) MarshalJSON() ([]byte, error) {
+	type validation TransactionValidation
+	return json.Marshal(struct {
+		validation
+		Fee string `json:"fees_that_would_apply"`
+	}{validation(v), v.Fee.Format(v.Currency)})
+}
+
+// BalanceEstimate is the balance an account would be left with
+type BalanceEstimate struct {
+	AccountID string `json:"account_id"`
+	Balance   Money  `json:"balance"`
+	Currency  string `json:"currency"`
+}
+
+// MarshalJSON emits the balance as a decimal string in the account's currency
+func (e BalanceEstimate) MarshalJSON() ([]byte, error) {
+	type estimate BalanceEstimate
+	return json.Marshal(struct {
+		estimate
+		Balance string `json:"balance"`
+	}{estimate(e), e.Balance.Format(e.Currency)})
+}
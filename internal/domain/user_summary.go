@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AccountActivity is what a set of accounts' transactions add up to: when
+// the latest of them was created, and the completed deposits and withdrawals
+// since a time in each currency
+type AccountActivity struct {
+	LastTransactionAt *time.Time
+	Currencies        []*CurrencyActivity
+}
+
+// CurrencyActivity totals completed deposits and withdrawals in one currency
+type CurrencyActivity struct {
+	Currency  string `bson:"_id"`
+	Deposited Money  `bson:"deposited"`
+	Withdrawn Money  `bson:"withdrawn"`
+}
+
+// UserSummary gathers a user's accounts by currency, with when they last
+// transacted and what they deposited and withdrew since MonthStart
+type UserSummary struct {
+	UserID            string                 `json:"user_id"`
+	AccountCount      int                    `json:"account_count"`
+	LastTransactionAt *time.Time             `json:"last_transaction_at"`
+	MonthStart        time.Time              `json:"month_start"`
+	Currencies        []*UserCurrencySummary `json:"currencies"`
+}
+
+// UserCurrencySummary is a user's accounts in one currency and their total
+// balance
+type UserCurrencySummary struct {
+	Currency           string     `json:"currency"`
+	Balance            Money      `json:"balance"`
+	DepositedThisMonth Money      `json:"deposited_this_month"`
+	WithdrawnThisMonth Money      `json:"withdrawn_this_month"`
+	Accounts           []*Account `json:"accounts"`
+}
+
+// MarshalJSON emits the amounts as decimal strings in the currency
+func (s UserCurrencySummary) MarshalJSON() ([]byte, error) {
+	type userCurrencySummary UserCurrencySummary
+	return json.Marshal(struct {
+		userCurrencySummary
+		Balance            string `json:"balance"`
+		DepositedThisMonth string `json:"deposited_this_month"`
+		WithdrawnThisMonth string `json:"withdrawn_this_month"`
+	}{
+		userCurrencySummary(s),
+		s.Balance.Format(s.Currency),
+		s.DepositedThisMonth.Format(s.Currency),
+		s.WithdrawnThisMonth.Format(s.Currency),
+	})
+}
+
+// UserFilter pages through the users holding accounts, only those whose ID
+// starts with UserIDPrefix if it is given
+type UserFilter struct {
+	UserIDPrefix string
+	Limit        int
+	Offset       int
+}
+
+// UserOverview is one user of the admin user listing: how many accounts
+// they hold, their total balance in each currency and how many of their
+// accounts are in each status
+type UserOverview struct {
+	UserID       string                `json:"user_id"`
+	AccountCount int                   `json:"account_count"`
+	Balances     []*CurrencyBalance    `json:"balances"`
+	Statuses     map[AccountStatus]int `json:"statuses"`
+}
+
+// CurrencyBalance is the total balance of a user's accounts in one currency
+type CurrencyBalance struct {
+	Currency string `json:"currency"`
+	Balance  Money  `json:"balance"`
+}
+
+// MarshalJSON emits the balance as a decimal string in the currency
+func (b CurrencyBalance) MarshalJSON() ([]byte, error) {
+	type currencyBalance CurrencyBalance
+	return json.Marshal(struct {
+		currencyBalance
+		Balance string `json:"balance"`
+	}{
+		currencyBalance(b),
+		b.Balance.Format(b.Currency),
+	})
+}
+
+// UserPage is one page of the user listing, in user ID order
+type UserPage struct {
+	Users   []*UserOverview
+	HasMore bool
+}
@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// VerificationLevel is how far a user has got through identity verification
+type VerificationLevel string
+
+const (
+	// VerificationLevelUnverified users may only deposit, up to a limit
+	VerificationLevelUnverified VerificationLevel = "unverified"
+	// VerificationLevelVerified users are held to the normal limits
+	VerificationLevelVerified VerificationLevel = "verified"
+)
+
+// VerificationLevels lists every verification level
+var VerificationLevels = []VerificationLevel{
+	VerificationLevelUnverified,
+	VerificationLevelVerified,
+}
+
+// IsValid reports whether the level is a known verification level
+func (l VerificationLevel) IsValid() bool {
+	for _, level := range VerificationLevels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// UserVerification is a user's verification level. Users without one are
+// unverified.
+type UserVerification struct {
+	UserID    string            `json:"user_id" db:"user_id"`
+	Level     VerificationLevel `json:"level" db:"level"`
+	UpdatedAt time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// Verified reports whether the user has been verified
+func (v *UserVerification) Verified() bool {
+	return v.Level == VerificationLevelVerified
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"time"
+)
+
+// WebhookSignatureHeader carries the HMAC-SHA256 of a webhook's body, keyed by
+// its subscription's secret, as "sha256=<hex>"
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// WebhookEventTypes lists the events a webhook may subscribe to
+var WebhookEventTypes = []NotificationType{
+	NotificationTransactionCompleted,
+	NotificationTransactionFailed,
+	NotificationTransferInternal,
+	NotificationAccountCreated,
+	NotificationAccountLowBalance,
+	NotificationStatementGenerated,
+}
+
+// IsWebhookEventType reports whether a webhook may subscribe to the event
+func IsWebhookEventType(eventType NotificationType) bool {
+	for _, known := range WebhookEventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription is a URL that is posted every event of its types. The
+// secret signs each delivery and is only shown when the subscription is
+// registered.
+type WebhookSubscription struct {
+	ID         string             `json:"id"`
+	URL        string             `json:"url"`
+	EventTypes []NotificationType `json:"event_types"`
+	Secret     string             `json:"-"`
+	Active     bool               `json:"active"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// Validate checks the subscription's URL is an absolute http or https URL and
+// that it subscribes to at least one known event type
+func (s *WebhookSubscription) Validate() error {
+	parsed, err := url.Parse(s.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidWebhook
+	}
+	if len(s.EventTypes) == 0 {
+		return ErrInvalidWebhook
+	}
+	for _, eventType := range s.EventTypes {
+		if !IsWebhookEventType(eventType) {
+			return ErrInvalidWebhook
+		}
+	}
+	return nil
+}
+
+// Subscribes reports whether the subscription wants events of the type
+func (s *WebhookSubscription) Subscribes(eventType NotificationType) bool {
+	for _, subscribed := range s.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is waiting for its first or next attempt
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered was answered with a 2xx status
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryFailed used up its attempts
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event posted to one subscription, with the outcome
+// of its latest attempt
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	SubscriptionID string                `json:"subscription_id"`
+	EventID        string                `json:"event_id"`
+	EventType      NotificationType      `json:"event_type"`
+	Payload        []byte                `json:"-"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	LastStatusCode *int                  `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// SignWebhook returns the signature header value of a webhook body
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook reports whether a signature header value matches a body,
+// comparing in constant time
+func VerifyWebhook(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(SignWebhook(secret, body)), []byte(signature))
+}
@@ -0,0 +1,82 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+type pair struct {
+	from string
+	to   string
+}
+
+type quote struct {
+	rate     domain.Decimal
+	quotedAt time.Time
+}
+
+// StaticRateProvider quotes fixed rates held in memory, as configured at
+// startup
+type StaticRateProvider struct {
+	mu     sync.RWMutex
+	quotes map[pair]quote
+}
+
+// NewStaticRateProvider creates a provider without rates
+func NewStaticRateProvider() *StaticRateProvider {
+	return &StaticRateProvider{
+		quotes: make(map[pair]quote),
+	}
+}
+
+// LoadStaticRates builds a provider from rates written as "from:to:rate",
+// such as "EUR:USD:1.0842", all quoted at quotedAt
+func LoadStaticRates(entries []string, quotedAt time.Time) (*StaticRateProvider, error) {
+	provider := NewStaticRateProvider()
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid exchange rate %q: want from:to:rate", entry)
+		}
+
+		from, err := domain.SupportedCurrencies.Normalize(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange rate %q: %w", entry, err)
+		}
+		to, err := domain.SupportedCurrencies.Normalize(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange rate %q: %w", entry, err)
+		}
+		rate := domain.Decimal(strings.TrimSpace(parts[2]))
+		if _, err := domain.Convert(1, from, to, rate); err != nil || from == to {
+			return nil, fmt.Errorf("invalid exchange rate %q: bad rate", entry)
+		}
+
+		provider.SetRate(from, to, rate, quotedAt)
+	}
+
+	return provider, nil
+}
+
+// SetRate sets the rate from one currency to another
+func (p *StaticRateProvider) SetRate(from, to string, rate domain.Decimal, quotedAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quotes[pair{domain.NormalizeCurrency(from), domain.NormalizeCurrency(to)}] = quote{rate: rate, quotedAt: quotedAt}
+}
+
+// GetRate returns the rate set from one currency to another
+func (p *StaticRateProvider) GetRate(ctx context.Context, from, to string) (domain.Decimal, time.Time, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	quote, ok := p.quotes[pair{domain.NormalizeCurrency(from), domain.NormalizeCurrency(to)}]
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("no %s to %s rate", from, to)
+	}
+	return quote.rate, quote.quotedAt, nil
+}
@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Errors a publish waiting for its broker confirmation can fail with
+var (
+	ErrPublishNacked      = errors.New("broker refused the message")
+	ErrPublishUnconfirmed = errors.New("broker did not confirm the message in time")
+	ErrConfirmsClosed     = errors.New("channel closed before the broker confirmed the message")
+)
+
+// Confirmer matches the publisher confirms of a channel in confirm mode to
+// the publishes waiting on them. The broker numbers the messages published
+// on a channel from 1, so publishes must all go through one Confirmer.
+type Confirmer struct {
+	// publishMu keeps publishes in the order of their delivery tags; mu
+	// guards the waiting publishes, and is never held over a network write
+	// so that confirms keep being handed out while the broker holds one up
+	publishMu sync.Mutex
+	mu        sync.Mutex
+	timeout   time.Duration
+	tag       uint64
+	pending   map[uint64]chan bool
+	closed    bool
+}
+
+// NewConfirmer reads confirms until the channel closes, waiting up to
+// timeout for each publish to be confirmed. A timeout of zero waits as long
+// as the publish's context allows.
+func NewConfirmer(confirms <-chan amqp.Confirmation, timeout time.Duration) *Confirmer {
+	c := &Confirmer{
+		timeout: timeout,
+		pending: make(map[uint64]chan bool),
+	}
+	go c.listen(confirms)
+	return c
+}
+
+// listen hands each confirm to the publish waiting on it, if it still is,
+// and fails those left waiting once the channel closes
+func (c *Confirmer) listen(confirms <-chan amqp.Confirmation) {
+	for confirm := range confirms {
+		c.mu.Lock()
+		if confirmed, ok := c.pending[confirm.DeliveryTag]; ok {
+			confirmed <- confirm.Ack
+			delete(c.pending, confirm.DeliveryTag)
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for tag, confirmed := range c.pending {
+		close(confirmed)
+		delete(c.pending, tag)
+	}
+}
+
+// Publish calls publish and waits for the broker to confirm the message,
+// returning an error if it refuses the message, does not confirm it within
+// the timeout or ctx is done first
+func (c *Confirmer) Publish(ctx context.Context, publish func() error) error {
+	tag, confirmed, err := c.send(publish)
+	if err != nil {
+		return err
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	select {
+	case ack, ok := <-confirmed:
+		if !ok {
+			return ErrConfirmsClosed
+		}
+		if !ack {
+			return ErrPublishNacked
+		}
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, tag)
+		c.mu.Unlock()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrPublishUnconfirmed
+		}
+		return ctx.Err()
+	}
+}
+
+// send waits for the publishes ahead of it, then registers to wait for the
+// next delivery tag, so that a confirm arriving straight after the write
+// finds it, and calls publish
+func (c *Confirmer) send(publish func() error) (uint64, chan bool, error) {
+	c.publishMu.Lock()
+	defer c.publishMu.Unlock()
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return 0, nil, ErrConfirmsClosed
+	}
+	c.tag++
+	tag := c.tag
+	confirmed := make(chan bool, 1)
+	c.pending[tag] = confirmed
+	c.mu.Unlock()
+
+	if err := publish(); err != nil {
+		// The broker only numbers the messages the channel accepted, and no
+		// other publish has taken a tag since
+		c.mu.Lock()
+		delete(c.pending, tag)
+		c.tag--
+		c.mu.Unlock()
+		return 0, nil, err
+	}
+	return tag, confirmed, nil
+}
@@ -0,0 +1,103 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Dispatch runs handle on the deliveries with up to workers of them in hand
+// at a time, each worker taking the next delivery once it has handled the
+// last. The workers stop taking deliveries once ctx is done or the channel
+// closes, and the returned channel closes when they have all finished the
+// deliveries they hold.
+func Dispatch[T any](ctx context.Context, deliveries <-chan T, workers int, handle func(T)) <-chan struct{} {
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				// Checked first so that a cancelled worker takes nothing
+				// more even with deliveries ready
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case delivery, ok := <-deliveries:
+					if !ok {
+						return
+					}
+					handle(delivery)
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// Subscription is a consumer's workers, which stop taking deliveries once the
+// context they were started with is done and finish the ones in hand
+type Subscription struct {
+	stopped chan struct{}
+	abort   context.CancelFunc
+	drained atomic.Int64
+}
+
+// Serve runs handle on the deliveries as Dispatch does, giving it a context
+// that outlives ctx so that the deliveries in hand when ctx is done are
+// handled in full. Once ctx is done and those are, stop is called to end the
+// deliveries, and those delivered but never taken are handed to release.
+// Without a release the deliveries are left where they are.
+func Serve[T any](ctx context.Context, deliveries <-chan T, workers int, handle func(context.Context, T), stop func(), release func(T)) *Subscription {
+	handlerCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
+	s := &Subscription{stopped: make(chan struct{}), abort: abort}
+
+	done := Dispatch(ctx, deliveries, workers, func(delivery T) {
+		handle(handlerCtx, delivery)
+		if ctx.Err() != nil && handlerCtx.Err() == nil {
+			s.drained.Add(1)
+		}
+	})
+	go func() {
+		defer close(s.stopped)
+		defer abort()
+		select {
+		case <-done:
+			// The deliveries ended without ctx, as with a lost connection
+			return
+		case <-ctx.Done():
+		}
+		<-done
+		if release == nil {
+			return
+		}
+		stop()
+		for delivery := range deliveries {
+			release(delivery)
+		}
+	}()
+	return s
+}
+
+// Drain waits for the subscription to stop once its context is done, and
+// returns how many deliveries were handled in full after that. If ctx is
+// done first, the handlers still running have their context cancelled and
+// ctx's error is returned.
+func (s *Subscription) Drain(ctx context.Context) (int, error) {
+	select {
+	case <-s.stopped:
+		return int(s.drained.Load()), nil
+	case <-ctx.Done():
+		s.abort()
+		return int(s.drained.Load()), ctx.Err()
+	}
+}
@@ -0,0 +1,195 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"banking-ledger/internal/domain"
+)
+
+// inMemoryCapacity bounds the messages waiting in each in-memory queue; a
+// publish to a full queue waits for room
+const inMemoryCapacity = 10000
+
+// ErrQueueClosed is returned when publishing to a closed in-memory queue
+var ErrQueueClosed = errors.New("queue is closed")
+
+// ErrSubscriberFull is returned when a subscriber to an in-memory fanout
+// queue has no room for a message, which it misses
+var ErrSubscriberFull = errors.New("subscriber is full")
+
+// InMemoryQueue implements the MessageQueue interface on channels within one
+// process, for tests and for running the API and processor as one binary.
+// Subscribers to a queue take turns at its messages, as RabbitMQ consumers
+// do, unless it is a fanout queue, whose subscribers each get every message
+// published while they are subscribed. A handler failing transiently is retried by the retry policy.
+// There are no dead letters: a message whose handler fails for good is
+// dropped, after telling the queue's failure handler, and messages still
+// queued are lost when the process exits.
+type InMemoryQueue struct {
+	options
+	mu     sync.Mutex
+	queues map[string]chan []byte
+	// subscribers holds the messages of each subscriber to a fanout queue
+	subscribers map[string][]chan []byte
+	closed      bool
+}
+
+// NewInMemoryQueue creates an in-memory queue. The retry, timeout, consumer,
+// failure handler and fanout options apply; the confirm timeout and dead letter
+// queues do not.
+func NewInMemoryQueue(opts ...Option) domain.MessageQueue {
+	return &InMemoryQueue{
+		options:     newOptions(opts),
+		queues:      make(map[string]chan []byte),
+		subscribers: make(map[string][]chan []byte),
+	}
+}
+
+// queue returns the channel holding a queue's messages, creating it first
+func (q *InMemoryQueue) queue(queueName string) (chan []byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+	messages, ok := q.queues[queueName]
+	if !ok {
+		messages = make(chan []byte, inMemoryCapacity)
+		q.queues[queueName] = messages
+	}
+	return messages, nil
+}
+
+// Publish adds a copy of a message to a queue, waiting for room until ctx is
+// done or the publish timeout has passed
+func (q *InMemoryQueue) Publish(ctx context.Context, queueName string, message []byte) error {
+	if q.fanouts[queueName] {
+		return q.broadcast(queueName, message)
+	}
+	messages, err := q.queue(queueName)
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.publishTimeout)
+	defer cancel()
+	select {
+	case messages <- append([]byte(nil), message...):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to publish message: %w", ctx.Err())
+	}
+}
+
+// Subscribe handles a queue's messages on the queue's workers until ctx is
+// done, as RabbitMQQueue.Subscribe does. The messages not taken stay queued
+// for later subscribers, except on a fanout queue.
+func (q *InMemoryQueue) Subscribe(ctx context.Context, queueName string, handler func(ctx context.Context, data []byte) error) (domain.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if q.fanouts[queueName] {
+		return q.subscribeFanout(ctx, queueName, handler)
+	}
+	messages, err := q.queue(queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	consumer := q.consumerFor(queueName)
+	return Serve(ctx, messages, consumer.workers,
+		func(ctx context.Context, message []byte) {
+			q.handle(ctx, queueName, messages, message, handler)
+		},
+		nil, nil,
+	), nil
+}
+
+// broadcast adds a copy of a message to each subscriber of a fanout queue
+// without waiting, so that one falling behind holds up neither the publisher
+// nor the others
+func (q *InMemoryQueue) broadcast(queueName string, message []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return fmt.Errorf("failed to publish message: %w", ErrQueueClosed)
+	}
+	var err error
+	for _, messages := range q.subscribers[queueName] {
+		select {
+		case messages <- append([]byte(nil), message...):
+		default:
+			err = fmt.Errorf("failed to publish message: %w", ErrSubscriberFull)
+		}
+	}
+	return err
+}
+
+// subscribeFanout handles the messages published to a fanout queue from now
+// until ctx is done, then stops receiving them and drops those not taken
+func (q *InMemoryQueue) subscribeFanout(ctx context.Context, queueName string, handler func(context.Context, []byte) error) (domain.Subscription, error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil, ErrQueueClosed
+	}
+	messages := make(chan []byte, inMemoryCapacity)
+	q.subscribers[queueName] = append(q.subscribers[queueName], messages)
+	q.mu.Unlock()
+
+	consumer := q.consumerFor(queueName)
+	return Serve(ctx, messages, consumer.workers,
+		func(ctx context.Context, message []byte) {
+			q.handle(ctx, queueName, messages, message, handler)
+		},
+		func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.subscribers[queueName] = slices.DeleteFunc(q.subscribers[queueName], func(subscriber chan []byte) bool {
+				return subscriber == messages
+			})
+			close(messages)
+		},
+		func([]byte) {},
+	), nil
+}
+
+// handle processes a message with retry logic. A message whose processing
+// was cut short by ctx is put back on the queue; one that failed for good is
+// dropped, telling the queue's failure handler.
+func (q *InMemoryQueue) handle(ctx context.Context, queueName string, messages chan []byte, message []byte, handler func(context.Context, []byte) error) {
+	logger := slog.With("queue", queueName)
+	_, err := q.retryPolicy.Retry(ctx, logger, func(ctx context.Context) error {
+		return q.attempt(ctx, message, handler)
+	})
+	if err == nil {
+		return
+	}
+
+	if ctx.Err() != nil && !domain.IsPermanent(err) {
+		select {
+		case messages <- message:
+			return
+		default:
+			logger.Error("Dropping message from full queue on shutdown", "error", err)
+			q.dropped(ctx, queueName, message, err)
+			return
+		}
+	}
+	logger.Error("Dropping message", "error", err)
+	q.dropped(ctx, queueName, message, err)
+}
+
+// Close stops the queue taking messages. Subscriptions run until their
+// context is done.
+func (q *InMemoryQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	return nil
+}
@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// Drivers naming the MessageQueue implementations
+const (
+	DriverRabbitMQ = "rabbitmq"
+	DriverMemory   = "memory"
+)
+
+// New creates the queue driver names, connecting to the RabbitMQ broker at
+// url if it is RabbitMQ
+func New(driver, url string, opts ...Option) (domain.MessageQueue, error) {
+	switch driver {
+	case DriverRabbitMQ:
+		return NewRabbitMQQueue(url, opts...)
+	case DriverMemory:
+		return NewInMemoryQueue(opts...), nil
+	}
+	return nil, fmt.Errorf("unknown queue driver %q, expected %q or %q", driver, DriverRabbitMQ, DriverMemory)
+}
+
+// Default bounds on publishing a message and on each attempt at handling one
+const (
+	defaultPublishTimeout    = 10 * time.Second
+	defaultConfirmTimeout    = 5 * time.Second
+	defaultProcessingTimeout = 30 * time.Second
+)
+
+// options are the settings shared by the queue implementations; each ignores
+// those that do not apply to it
+type options struct {
+	retryPolicy       RetryPolicy
+	confirmTimeout    time.Duration
+	publishTimeout    time.Duration
+	processingTimeout time.Duration
+	// deadLetters names the dead letter queue of each queue that has one
+	deadLetters map[string]string
+	consumers   map[string]consumerConfig
+	// failureHandlers are told of the messages each queue drops
+	failureHandlers map[string]FailureHandler
+	// fanouts are the queues delivering each message to every subscriber
+	fanouts map[string]bool
+}
+
+// newOptions applies opts over the defaults
+func newOptions(opts []Option) options {
+	o := options{
+		retryPolicy:       DefaultRetryPolicy,
+		confirmTimeout:    defaultConfirmTimeout,
+		publishTimeout:    defaultPublishTimeout,
+		processingTimeout: defaultProcessingTimeout,
+		deadLetters:       make(map[string]string),
+		consumers:         make(map[string]consumerConfig),
+		failureHandlers:   make(map[string]FailureHandler),
+		fanouts:           make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Option configures a queue
+type Option func(*options)
+
+// WithRetryPolicy sets how a message whose handler fails with a transient
+// error is retried
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithConfirmTimeout bounds how long a publish waits for the broker to
+// confirm it has stored the message
+func WithConfirmTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.confirmTimeout = timeout
+		}
+	}
+}
+
+// WithTimeouts bounds how long a publish, from declaring the queue to the
+// broker's confirm, may take, and how long a handler is given for each
+// attempt at a message. A processing timeout of zero leaves handlers bounded
+// only by the subscription's context.
+func WithTimeouts(publishTimeout, processingTimeout time.Duration) Option {
+	return func(o *options) {
+		if publishTimeout > 0 {
+			o.publishTimeout = publishTimeout
+		}
+		if processingTimeout >= 0 {
+			o.processingTimeout = processingTimeout
+		}
+	}
+}
+
+// WithDeadLetterQueue sets aside the messages of queueName whose handler
+// fails permanently or runs out of retries in deadLetterQueue, bound to a
+// dead letter exchange of the same name. Every process publishing to or
+// consuming queueName must be given the same option, since RabbitMQ refuses
+// to declare a queue with other arguments than it already has.
+func WithDeadLetterQueue(queueName, deadLetterQueue string) Option {
+	return func(o *options) {
+		if deadLetterQueue != "" {
+			o.deadLetters[queueName] = deadLetterQueue
+		}
+	}
+}
+
+// WithConsumers lets workers handle the messages of queueName at once, with
+// up to prefetch of them delivered ahead of the workers, at least one each.
+// Queues not named are consumed one message at a time.
+func WithConsumers(queueName string, prefetch, workers int) Option {
+	return func(o *options) {
+		workers = max(workers, 1)
+		o.consumers[queueName] = consumerConfig{
+			prefetch: max(prefetch, workers),
+			workers:  workers,
+		}
+	}
+}
+
+// WithFanout delivers each message published to queueName to every
+// subscriber rather than to one of them. On RabbitMQ the messages go to a
+// fanout exchange named queueName, which binds an exclusive queue for each
+// subscriber that is deleted once it stops consuming. A subscriber only
+// receives the messages published while it is subscribed.
+func WithFanout(queueName string) Option {
+	return func(o *options) {
+		o.fanouts[queueName] = true
+	}
+}
+
+// FailureHandler is told of a message dropped for good after failing, with
+// the error it last failed with
+type FailureHandler func(ctx context.Context, message []byte, err error)
+
+// WithFailureHandler tells handler of each message of queueName dropped for
+// good after failing permanently or running out of retries, as happens when
+// there is no dead letter queue to set it aside in, so that its work is not
+// left half done unnoticed
+func WithFailureHandler(queueName string, handler FailureHandler) Option {
+	return func(o *options) {
+		o.failureHandlers[queueName] = handler
+	}
+}
+
+// dropped tells a queue's failure handler, if it has one, of a message it
+// has dropped. The handler runs to completion even once ctx is done.
+func (o *options) dropped(ctx context.Context, queueName string, message []byte, err error) {
+	if handler, ok := o.failureHandlers[queueName]; ok {
+		handler(context.WithoutCancel(ctx), message, err)
+	}
+}
+
+// consumerConfig is how many messages of a queue are delivered ahead and
+// handled at once
+type consumerConfig struct {
+	prefetch int
+	workers  int
+}
+
+// consumerFor returns how a queue is consumed
+func (o *options) consumerFor(queueName string) consumerConfig {
+	if consumer, ok := o.consumers[queueName]; ok {
+		return consumer
+	}
+	return consumerConfig{prefetch: 1, workers: 1}
+}
+
+// attempt runs the handler on a message, bounded by the processing timeout
+func (o *options) attempt(ctx context.Context, body []byte, handler func(context.Context, []byte) error) error {
+	if o.processingTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.processingTimeout)
+		defer cancel()
+	}
+	return handler(ctx, body)
+}
@@ -2,24 +2,40 @@ package queue
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
+	"slices"
 	"time"
 
 	"banking-ledger/internal/domain"
 
+	"github.com/google/uuid"
 	"github.com/streadway/amqp"
 )
 
-// RabbitMQQueue implements the MessageQueue interface
+// Headers a message gains when it is dead-lettered
+const (
+	FailureCountHeader = "x-failure-count"
+	LastErrorHeader    = "x-last-error"
+)
+
+// maxLastErrorLength bounds the error recorded on a dead letter
+const maxLastErrorLength = 1024
+
+// RabbitMQQueue implements the MessageQueue and DeadLetterQueue interfaces
 type RabbitMQQueue struct {
+	options
 	conn    *amqp.Connection
 	channel *amqp.Channel
 	url     string
+	// confirmer waits for the broker to confirm each publish on channel
+	confirmer *Confirmer
 }
 
 // NewRabbitMQQueue creates a new RabbitMQ queue
-func NewRabbitMQQueue(url string) (domain.MessageQueue, error) {
+func NewRabbitMQQueue(url string, opts ...Option) (domain.MessageQueue, error) {
 	conn, err := amqp.Dial(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
@@ -31,43 +47,156 @@ func NewRabbitMQQueue(url string) (domain.MessageQueue, error) {
 		return nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
-	return &RabbitMQQueue{
+	q := &RabbitMQQueue{
+		options: newOptions(opts),
 		conn:    conn,
 		channel: channel,
 		url:     url,
-	}, nil
+	}
+
+	if q.confirmer, err = q.confirm(channel); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return q, nil
 }
 
-// Publish publishes a message to a queue
-func (q *RabbitMQQueue) Publish(ctx context.Context, queueName string, message []byte) error {
-	// Declare queue to ensure it exists
-	_, err := q.channel.QueueDeclare(
+// confirm puts a channel into confirm mode, so that the broker acknowledges
+// each message it has stored, and returns the Confirmer to publish through
+func (q *RabbitMQQueue) confirm(channel *amqp.Channel) (*Confirmer, error) {
+	if err := channel.Confirm(false); err != nil {
+		return nil, fmt.Errorf("failed to put channel into confirm mode: %w", err)
+	}
+	return NewConfirmer(channel.NotifyPublish(make(chan amqp.Confirmation, 1)), q.confirmTimeout), nil
+}
+
+// declareQueue declares a durable queue, along with its dead letter exchange
+// and queue if it has one
+func (q *RabbitMQQueue) declareQueue(channel *amqp.Channel, queueName string) (amqp.Queue, error) {
+	var args amqp.Table
+	if deadLetterQueue, ok := q.deadLetters[queueName]; ok {
+		if err := channel.ExchangeDeclare(
+			deadLetterQueue, // name
+			"direct",        // kind
+			true,            // durable
+			false,           // delete when unused
+			false,           // internal
+			false,           // no-wait
+			nil,             // arguments
+		); err != nil {
+			return amqp.Queue{}, fmt.Errorf("failed to declare dead letter exchange: %w", err)
+		}
+		if _, err := channel.QueueDeclare(deadLetterQueue, true, false, false, false, nil); err != nil {
+			return amqp.Queue{}, fmt.Errorf("failed to declare dead letter queue: %w", err)
+		}
+		// Dead letters keep the routing key of the queue they came from
+		if err := channel.QueueBind(deadLetterQueue, queueName, deadLetterQueue, false, nil); err != nil {
+			return amqp.Queue{}, fmt.Errorf("failed to bind dead letter queue: %w", err)
+		}
+		args = amqp.Table{"x-dead-letter-exchange": deadLetterQueue}
+	}
+
+	return channel.QueueDeclare(
 		queueName, // name
 		true,      // durable
 		false,     // delete when unused
 		false,     // exclusive
 		false,     // no-wait
+		args,      // arguments
+	)
+}
+
+// declareFanout declares the durable fanout exchange of a fanout queue
+func (q *RabbitMQQueue) declareFanout(channel *amqp.Channel, queueName string) error {
+	if err := channel.ExchangeDeclare(
+		queueName, // name
+		"fanout",  // kind
+		true,      // durable
+		false,     // delete when unused
+		false,     // internal
+		false,     // no-wait
 		nil,       // arguments
+	); err != nil {
+		return fmt.Errorf("failed to declare fanout exchange: %w", err)
+	}
+	return nil
+}
+
+// declareSubscriberQueue declares a fanout queue's exchange and a queue of
+// the subscriber's own bound to it, exclusive to the connection and deleted
+// once its consumer is cancelled
+func (q *RabbitMQQueue) declareSubscriberQueue(channel *amqp.Channel, queueName string) (amqp.Queue, error) {
+	if err := q.declareFanout(channel, queueName); err != nil {
+		return amqp.Queue{}, err
+	}
+	queue, err := channel.QueueDeclare(
+		"",    // name, chosen by the broker
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
 	)
 	if err != nil {
-		return fmt.Errorf("failed to declare queue: %w", err)
+		return amqp.Queue{}, fmt.Errorf("failed to declare subscriber queue: %w", err)
 	}
+	if err := channel.QueueBind(queue.Name, "", queueName, false, nil); err != nil {
+		return amqp.Queue{}, fmt.Errorf("failed to bind subscriber queue: %w", err)
+	}
+	return queue, nil
+}
 
-	// Set message properties for persistence
+// declareRoute declares where messages for a queue go, returning the exchange
+// and routing key to publish them with: the default exchange and the queue's
+// name, or a fanout queue's exchange
+func (q *RabbitMQQueue) declareRoute(channel *amqp.Channel, queueName string) (string, string, error) {
+	if q.fanouts[queueName] {
+		if err := q.declareFanout(channel, queueName); err != nil {
+			return "", "", err
+		}
+		return queueName, "", nil
+	}
+	if _, err := q.declareQueue(channel, queueName); err != nil {
+		return "", "", fmt.Errorf("failed to declare queue: %w", err)
+	}
+	return "", queueName, nil
+}
+
+// Publish publishes a message to a queue and waits for the broker to confirm
+// it has stored it, returning once ctx is done or the publish timeout has
+// passed. A publish given up on may still reach the queue, as one the outbox
+// relay publishes again does.
+func (q *RabbitMQQueue) Publish(ctx context.Context, queueName string, message []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, q.publishTimeout)
+	defer cancel()
+
+	// Set message properties for persistence. The ID names the message
+	// should it be dead-lettered.
 	msg := amqp.Publishing{
 		DeliveryMode: amqp.Persistent,
 		ContentType:  "application/json",
+		MessageId:    uuid.New().String(),
 		Body:         message,
 		Timestamp:    time.Now(),
 	}
 
-	err = q.channel.Publish(
-		"",        // exchange
-		queueName, // routing key
-		false,     // mandatory
-		false,     // immediate
-		msg,
-	)
+	err := withContext(ctx, func() error {
+		// Declare the queue, or fanout exchange, to ensure it exists
+		exchange, routingKey, err := q.declareRoute(q.channel, queueName)
+		if err != nil {
+			return err
+		}
+
+		return q.confirmer.Publish(ctx, func() error {
+			return q.channel.Publish(
+				exchange,   // exchange
+				routingKey, // routing key
+				false,      // mandatory
+				false,      // immediate
+				msg,
+			)
+		})
+	})
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
@@ -75,35 +204,102 @@ func (q *RabbitMQQueue) Publish(ctx context.Context, queueName string, message [
 	return nil
 }
 
-// Subscribe subscribes to a queue and processes messages
-func (q *RabbitMQQueue) Subscribe(ctx context.Context, queueName string, handler func([]byte) error) error {
-	// Declare queue to ensure it exists
-	queue, err := q.channel.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
+// withContext runs fn and returns its error, or ctx's error once ctx is done.
+// The AMQP client cannot abandon a call to the broker, so fn carries on
+// without being waited for.
+func withContext(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- fn()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// subscription is the outcome of setting up a consumer
+type subscription struct {
+	tag  string
+	msgs <-chan amqp.Delivery
+	err  error
+}
+
+// Subscribe subscribes to a queue and processes messages on the queue's
+// workers, each acknowledging the deliveries it handles. The handler is given
+// a context for each attempt at a message, bounded by the processing timeout.
+// Setting up the consumer returns early with ctx's error once ctx is done.
+// Once ctx is done the workers take no more deliveries and finish those they
+// hold, unless the subscription's drain runs out first, then the consumer is
+// cancelled and the deliveries not taken are returned to the queue. A fanout
+// queue's subscriber consumes a queue of its own, deleted with the consumer.
+func (q *RabbitMQQueue) Subscribe(ctx context.Context, queueName string, handler func(ctx context.Context, data []byte) error) (domain.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	consumer := q.consumerFor(queueName)
+	subscribed := make(chan subscription, 1)
+	go func() {
+		subscribed <- q.consume(queueName, consumer)
+	}()
+
+	select {
+	case sub := <-subscribed:
+		if sub.err != nil {
+			return nil, sub.err
+		}
+		return q.serve(ctx, queueName, consumer, sub, handler), nil
+	case <-ctx.Done():
+		// A consumer set up after all is cancelled straight away, its
+		// deliveries returned to the queue
+		go func() {
+			if sub := <-subscribed; sub.err == nil {
+				q.serve(ctx, queueName, consumer, sub, handler)
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// consume declares a queue and starts a consumer on it
+func (q *RabbitMQQueue) consume(queueName string, consumer consumerConfig) subscription {
+	// Declare queue to ensure it exists. A fanout queue's subscriber
+	// consumes a queue of its own.
+	var queue amqp.Queue
+	var err error
+	if q.fanouts[queueName] {
+		queue, err = q.declareSubscriberQueue(q.channel, queueName)
+	} else {
+		queue, err = q.declareQueue(q.channel, queueName)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to declare queue: %w", err)
+		return subscription{err: fmt.Errorf("failed to declare queue: %w", err)}
 	}
 
-	// Set QoS to process one message at a time
+	// Set QoS to bound how many messages are delivered ahead of the workers.
+	// It applies to the consumers started after it on the channel.
 	err = q.channel.Qos(
-		1,     // prefetch count
-		0,     // prefetch size
-		false, // global
+		consumer.prefetch, // prefetch count
+		0,                 // prefetch size
+		false,             // global
 	)
 	if err != nil {
-		return fmt.Errorf("failed to set QoS: %w", err)
+		return subscription{err: fmt.Errorf("failed to set QoS: %w", err)}
 	}
 
 	// Start consuming messages
+	tag := fmt.Sprintf("%s-%s", queueName, uuid.New().String())
 	msgs, err := q.channel.Consume(
 		queue.Name, // queue
-		"",         // consumer
+		tag,        // consumer
 		false,      // auto-ack
 		false,      // exclusive
 		false,      // no-local
@@ -111,59 +307,270 @@ func (q *RabbitMQQueue) Subscribe(ctx context.Context, queueName string, handler
 		nil,        // args
 	)
 	if err != nil {
-		return fmt.Errorf("failed to register consumer: %w", err)
+		return subscription{err: fmt.Errorf("failed to register consumer: %w", err)}
 	}
+	return subscription{tag: tag, msgs: msgs}
+}
 
-	// Process messages in a goroutine
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case msg, ok := <-msgs:
-				if !ok {
-					return
-				}
-
-				// Process message with retry logic
-				err := q.processMessageWithRetry(msg, handler)
-				if err != nil {
-					log.Printf("Failed to process message after retries: %v", err)
-					// Reject message and don't requeue (send to DLQ if configured)
-					msg.Nack(false, false)
-				} else {
-					// Acknowledge successful processing
-					msg.Ack(false)
-				}
+// serve hands a consumer's deliveries to the queue's workers until ctx is
+// done, then cancels the consumer and returns the deliveries not taken
+func (q *RabbitMQQueue) serve(ctx context.Context, queueName string, consumer consumerConfig, sub subscription, handler func(context.Context, []byte) error) *Subscription {
+	return Serve(ctx, sub.msgs, consumer.workers,
+		func(ctx context.Context, msg amqp.Delivery) {
+			q.handleDelivery(ctx, queueName, msg, handler)
+		},
+		func() {
+			if err := q.channel.Cancel(sub.tag, false); err != nil {
+				log.Printf("Failed to cancel consumer %s: %v", sub.tag, err)
 			}
+		},
+		func(msg amqp.Delivery) {
+			msg.Nack(false, true)
+		},
+	)
+}
+
+// handleDelivery processes a message with retry logic, acknowledging it on
+// success and dead-lettering it otherwise. A message whose processing was cut
+// short by ctx, as when a drain runs out, is returned to the queue instead.
+func (q *RabbitMQQueue) handleDelivery(ctx context.Context, queueName string, msg amqp.Delivery, handler func(context.Context, []byte) error) {
+	failures, err := q.processMessageWithRetry(ctx, queueName, msg, handler)
+	if err == nil {
+		// Acknowledge successful processing
+		msg.Ack(false)
+		return
+	}
+
+	if ctx.Err() != nil && !domain.IsPermanent(err) {
+		log.Printf("Returning message %s to the queue on shutdown: %v", msg.MessageId, err)
+		msg.Nack(false, true)
+		return
+	}
+
+	log.Printf("Failed to process message: %v", err)
+	if dlErr := q.deadLetter(queueName, msg, failures, err); dlErr != nil {
+		if dlErr != domain.ErrDeadLetterQueueNotConfigured {
+			log.Printf("Failed to dead-letter message %s: %v", msg.MessageId, dlErr)
+		} else {
+			q.dropped(ctx, queueName, msg.Body, err)
 		}
-	}()
+		// Reject message and don't requeue, leaving the broker to
+		// dead-letter it, without the failure headers, if the queue has a
+		// dead letter exchange
+		msg.Nack(false, false)
+		return
+	}
+	msg.Ack(false)
+}
 
-	return nil
+// processMessageWithRetry processes a message, retrying transient failures
+// as the retry policy says, and returns how many attempts failed
+func (q *RabbitMQQueue) processMessageWithRetry(ctx context.Context, queueName string, msg amqp.Delivery, handler func(context.Context, []byte) error) (int, error) {
+	logger := slog.With("queue", queueName, "message_id", msg.MessageId)
+	return q.retryPolicy.Retry(ctx, logger, func(ctx context.Context) error {
+		return q.attempt(ctx, msg.Body, handler)
+	})
+}
+
+// deadLetter publishes a message that failed to its queue's dead letter
+// exchange with headers recording its failures, adding to those of earlier
+// deliveries. Without a dead letter queue the message is left to be rejected.
+func (q *RabbitMQQueue) deadLetter(queueName string, msg amqp.Delivery, failures int, cause error) error {
+	deadLetterQueue, ok := q.deadLetters[queueName]
+	if !ok {
+		return domain.ErrDeadLetterQueueNotConfigured
+	}
+
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	lastError := cause.Error()
+	if len(lastError) > maxLastErrorLength {
+		lastError = lastError[:maxLastErrorLength]
+	}
+	headers[FailureCountHeader] = int32(headerInt(msg.Headers[FailureCountHeader]) + failures)
+	headers[LastErrorHeader] = lastError
+
+	messageID := msg.MessageId
+	if messageID == "" {
+		messageID = uuid.New().String()
+	}
+
+	// The delivery is only acknowledged once its dead letter is stored
+	return q.confirmer.Publish(context.Background(), func() error {
+		return q.channel.Publish(
+			deadLetterQueue, // exchange
+			queueName,       // routing key
+			false,           // mandatory
+			false,           // immediate
+			amqp.Publishing{
+				Headers:      headers,
+				DeliveryMode: amqp.Persistent,
+				ContentType:  msg.ContentType,
+				MessageId:    messageID,
+				Body:         msg.Body,
+				Timestamp:    time.Now(),
+			},
+		)
+	})
 }
 
-// processMessageWithRetry processes a message with retry logic
-func (q *RabbitMQQueue) processMessageWithRetry(msg amqp.Delivery, handler func([]byte) error) error {
-	const maxRetries = 3
-	var lastErr error
+// headerInt reads an integer header, which AMQP may carry in any integer type
+func headerInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	}
+	return 0
+}
 
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err := handler(msg.Body)
-		if err == nil {
-			return nil
+// PeekDeadLetters reads up to limit of a queue's dead letters on a channel of
+// its own and returns them all to the dead letter queue, in their order,
+// without acknowledging any
+func (q *RabbitMQQueue) PeekDeadLetters(ctx context.Context, queueName string, limit int) ([]*domain.DeadLetter, error) {
+	deadLetterQueue, ok := q.deadLetters[queueName]
+	if !ok {
+		return nil, domain.ErrDeadLetterQueueNotConfigured
+	}
+
+	channel, err := q.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer channel.Close()
+	if _, err := q.declareQueue(channel, queueName); err != nil {
+		return nil, fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	deadLetters := []*domain.DeadLetter{}
+	var last *amqp.Delivery
+	for len(deadLetters) < limit && ctx.Err() == nil {
+		msg, ok, err := channel.Get(deadLetterQueue, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dead letter: %w", err)
 		}
+		if !ok {
+			break
+		}
+		last = &msg
+		deadLetters = append(deadLetters, newDeadLetter(msg))
+	}
 
-		lastErr = err
-		log.Printf("Message processing failed (attempt %d/%d): %v", attempt, maxRetries, err)
+	if last != nil {
+		if err := last.Nack(true, true); err != nil {
+			return nil, fmt.Errorf("failed to return dead letters: %w", err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return deadLetters, nil
+}
+
+// RequeueDeadLetters walks a queue's dead letters on a channel of its own,
+// publishing those with the IDs back onto the queue, with their failure
+// headers, and returning the rest to the dead letter queue
+func (q *RabbitMQQueue) RequeueDeadLetters(ctx context.Context, queueName string, ids []string) (*domain.DeadLetterRequeue, error) {
+	deadLetterQueue, ok := q.deadLetters[queueName]
+	if !ok {
+		return nil, domain.ErrDeadLetterQueueNotConfigured
+	}
+
+	channel, err := q.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	// Closing the channel returns whatever is still unacknowledged
+	defer channel.Close()
+	if _, err := q.declareQueue(channel, queueName); err != nil {
+		return nil, fmt.Errorf("failed to declare queue: %w", err)
+	}
+	// A dead letter is only removed once the broker has stored its replay
+	confirmer, err := q.confirm(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.DeadLetterRequeue{Requeued: []string{}, Missing: []string{}}
+	var kept []amqp.Delivery
+	for len(result.Requeued) < len(ids) && ctx.Err() == nil {
+		msg, ok, err := channel.Get(deadLetterQueue, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+		if !slices.Contains(ids, msg.MessageId) || slices.Contains(result.Requeued, msg.MessageId) {
+			kept = append(kept, msg)
+			continue
+		}
 
-		if attempt < maxRetries {
-			// Exponential backoff
-			backoff := time.Duration(attempt) * time.Second
-			time.Sleep(backoff)
+		err = confirmer.Publish(ctx, func() error {
+			return channel.Publish("", queueName, false, false, amqp.Publishing{
+				Headers:      msg.Headers,
+				DeliveryMode: amqp.Persistent,
+				ContentType:  msg.ContentType,
+				MessageId:    msg.MessageId,
+				Body:         msg.Body,
+				Timestamp:    time.Now(),
+			})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to requeue dead letter %s: %w", msg.MessageId, err)
+		}
+		if err := msg.Ack(false); err != nil {
+			return nil, fmt.Errorf("failed to remove dead letter %s: %w", msg.MessageId, err)
+		}
+		result.Requeued = append(result.Requeued, msg.MessageId)
+	}
+
+	for _, msg := range kept {
+		if err := msg.Nack(false, true); err != nil {
+			return nil, fmt.Errorf("failed to return dead letter %s: %w", msg.MessageId, err)
 		}
 	}
+	for _, id := range ids {
+		if !slices.Contains(result.Requeued, id) && !slices.Contains(result.Missing, id) {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	return result, nil
+}
 
-	return fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+// newDeadLetter describes a delivery from a dead letter queue. A message
+// rejected by the broker rather than dead-lettered here has no failure
+// headers, only the broker's x-death record of when it was rejected.
+func newDeadLetter(msg amqp.Delivery) *domain.DeadLetter {
+	deadLetter := &domain.DeadLetter{
+		ID:             msg.MessageId,
+		Queue:          msg.RoutingKey,
+		FailureCount:   headerInt(msg.Headers[FailureCountHeader]),
+		DeadLetteredAt: msg.Timestamp,
+		Body:           msg.Body,
+	}
+	if lastError, ok := msg.Headers[LastErrorHeader].(string); ok {
+		deadLetter.LastError = lastError
+	} else if deaths, ok := msg.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			if at, ok := death["time"].(time.Time); ok {
+				deadLetter.DeadLetteredAt = at
+			}
+		}
+	}
+	if !json.Valid(msg.Body) {
+		deadLetter.Body, _ = json.Marshal(string(msg.Body))
+	}
+	return deadLetter
 }
 
 // Close closes the connection
@@ -200,8 +607,15 @@ func (q *RabbitMQQueue) reconnect() error {
 		return fmt.Errorf("failed to open channel on reconnection: %w", err)
 	}
 
+	confirmer, err := q.confirm(channel)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
 	q.conn = conn
 	q.channel = channel
+	q.confirmer = confirmer
 
 	return nil
 }
@@ -0,0 +1,127 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// Backoff is how the delay between retries grows
+type Backoff string
+
+// Supported backoff strategies
+const (
+	// BackoffLinear waits the base delay times the retry number
+	BackoffLinear Backoff = "linear"
+	// BackoffExponential doubles the base delay on each retry
+	BackoffExponential Backoff = "exponential"
+)
+
+// RetryPolicy is how a message whose handler fails with a transient error is
+// retried
+type RetryPolicy struct {
+	// MaxRetries is how many times a message is retried after its first
+	// attempt; zero means it is not retried
+	MaxRetries int
+	// BaseDelay is the delay before the first retry
+	BaseDelay time.Duration
+	// Backoff is how the delay grows, exponentially if empty
+	Backoff Backoff
+	// Jitter spreads each delay at random over its upper half, so that
+	// messages failing together are not all retried together
+	Jitter bool
+	// After waits out a delay, time.After if nil
+	After func(time.Duration) <-chan time.Time
+	// Random returns a number in [0, 1) to jitter delays by, rand.Float64 if
+	// nil
+	Random func() float64
+}
+
+// DefaultRetryPolicy is the retry policy of a queue not given one
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  time.Second,
+	Backoff:    BackoffExponential,
+}
+
+// NewRetryPolicy builds a retry policy from configuration, rejecting negative
+// retries or delays and unknown backoff strategies
+func NewRetryPolicy(maxRetries int, baseDelay time.Duration, backoff string, jitter bool) (RetryPolicy, error) {
+	if maxRetries < 0 {
+		return RetryPolicy{}, fmt.Errorf("max retries must not be negative, got %d", maxRetries)
+	}
+	if baseDelay < 0 {
+		return RetryPolicy{}, fmt.Errorf("retry delay must not be negative, got %s", baseDelay)
+	}
+	switch Backoff(backoff) {
+	case BackoffLinear, BackoffExponential:
+	default:
+		return RetryPolicy{}, fmt.Errorf("unknown retry backoff %q, expected %q or %q", backoff, BackoffLinear, BackoffExponential)
+	}
+	return RetryPolicy{
+		MaxRetries: maxRetries,
+		BaseDelay:  baseDelay,
+		Backoff:    Backoff(backoff),
+		Jitter:     jitter,
+	}, nil
+}
+
+// Delay returns how long to wait before the given retry, counted from 1
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	var delay time.Duration
+	switch p.Backoff {
+	case BackoffLinear:
+		delay = p.BaseDelay * time.Duration(retry)
+	default:
+		delay = p.BaseDelay << (retry - 1)
+	}
+
+	if p.Jitter && delay > 0 {
+		random := p.Random
+		if random == nil {
+			random = rand.Float64
+		}
+		delay = delay/2 + time.Duration(random()*float64(delay/2))
+	}
+	return delay
+}
+
+// Retry runs attempt until it succeeds, fails permanently or has been retried
+// MaxRetries times, and returns how many attempts failed. Each failed attempt
+// is logged to logger with the delay chosen before the next. Retrying stops
+// once ctx is done.
+func (p RetryPolicy) Retry(ctx context.Context, logger *slog.Logger, attempt func(context.Context) error) (int, error) {
+	after := p.After
+	if after == nil {
+		after = time.After
+	}
+	attempts := p.MaxRetries + 1
+
+	for n := 1; ; n++ {
+		err := attempt(ctx)
+		if err == nil {
+			return n - 1, nil
+		}
+
+		if domain.IsPermanent(err) {
+			logger.Warn("Message processing failed permanently", "attempt", n, "max_attempts", attempts, "error", err)
+			return n, fmt.Errorf("permanent failure: %w", err)
+		}
+		if n == attempts {
+			logger.Warn("Message processing failed", "attempt", n, "max_attempts", attempts, "error", err)
+			return n, fmt.Errorf("failed after %d attempts: %w", n, err)
+		}
+
+		delay := p.Delay(n)
+		logger.Warn("Message processing failed, retrying", "attempt", n, "max_attempts", attempts, "delay", delay, "error", err)
+		select {
+		case <-after(delay):
+		case <-ctx.Done():
+			return n, fmt.Errorf("stopped after %d attempts: %w", n, err)
+		}
+	}
+}
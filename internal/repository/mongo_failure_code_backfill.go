@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"banking-ledger/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// uncodedFailure is the part of a failed transaction document the backfill reads
+type uncodedFailure struct {
+	ID            string `bson:"_id"`
+	ErrorMessage  string `bson:"error_message"`
+	FailureReason string `bson:"failure_reason"`
+}
+
+// BackfillFailureCodes sets failure_code on failed transactions recorded
+// before codes existed. Codes come from the earlier failure_reason field when
+// present and are otherwise inferred from the error message, so the result is
+// best-effort. It returns the number of transactions updated.
+func BackfillFailureCodes(ctx context.Context, db *mongo.Database, collectionName string) (int64, error) {
+	collection := db.Collection(collectionName)
+
+	filter := bson.M{
+		"status":       domain.TransactionStatusFailed,
+		"failure_code": bson.M{"$exists": false},
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find uncoded failures: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var updated int64
+	for cursor.Next(ctx) {
+		var failure uncodedFailure
+		if err := cursor.Decode(&failure); err != nil {
+			return updated, fmt.Errorf("failed to decode transaction: %w", err)
+		}
+
+		code := domain.FailureCode(failure.FailureReason)
+		if !code.IsValid() {
+			code = domain.FailureCodeFromMessage(failure.ErrorMessage)
+		}
+
+		// Only fill the code if it is still missing, so concurrent writes win
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"_id": failure.ID, "failure_code": bson.M{"$exists": false}},
+			bson.M{
+				"$set":   bson.M{"failure_code": code},
+				"$unset": bson.M{"failure_reason": ""},
+			},
+		)
+		if err != nil {
+			return updated, fmt.Errorf("failed to backfill transaction %s: %w", failure.ID, err)
+		}
+		updated += result.ModifiedCount
+	}
+
+	if err := cursor.Err(); err != nil {
+		return updated, fmt.Errorf("failed to iterate uncoded failures: %w", err)
+	}
+
+	return updated, nil
+}
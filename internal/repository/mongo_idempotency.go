@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MongoIdempotencyStore implements the IdempotencyStore interface
+type MongoIdempotencyStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoIdempotencyStore creates a new MongoDB idempotency store
+func NewMongoIdempotencyStore(db *mongo.Database, collectionName string) domain.IdempotencyStore {
+	return &MongoIdempotencyStore{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Claim stores a key's record, relying on the unique _id so that concurrent
+// requests with the same key cannot both win
+func (s *MongoIdempotencyStore) Claim(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	_, err := s.collection.InsertOne(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	// A record older than the TTL no longer counts, so replace it. The
+	// created_at condition makes the replacement atomic.
+	filter := bson.M{
+		"_id":        record.Key,
+		"created_at": bson.M{"$lte": record.CreatedAt.Add(-ttl)},
+	}
+	result, err := s.collection.ReplaceOne(ctx, filter, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if result.MatchedCount == 1 {
+		return nil, nil
+	}
+
+	var existing domain.IdempotencyRecord
+	err = s.collection.FindOne(ctx, bson.M{"_id": record.Key}).Decode(&existing)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// Released in the meantime, so claim it again
+			return s.Claim(ctx, record, ttl)
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &existing, nil
+}
+
+// Release deletes a key's record while it still belongs to the transaction
+func (s *MongoIdempotencyStore) Release(ctx context.Context, key, transactionID string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key, "transaction_id": transactionID})
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoMonthlyStatementRepository implements the MonthlyStatementRepository
+// interface. The collection's unique index on account and month keeps a
+// month from being generated twice.
+type MongoMonthlyStatementRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoMonthlyStatementRepository creates a new MongoDB monthly statement repository
+func NewMongoMonthlyStatementRepository(db *mongo.Database, collectionName string) domain.MonthlyStatementRepository {
+	return &MongoMonthlyStatementRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Create inserts the statement, reporting false if the account's month is
+// already stored
+func (r *MongoMonthlyStatementRepository) Create(ctx context.Context, statement *domain.MonthlyStatement) (bool, error) {
+	if _, err := r.collection.InsertOne(ctx, statement); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create statement: %w", err)
+	}
+	return true, nil
+}
+
+// GetByID retrieves a statement with its lines
+func (r *MongoMonthlyStatementRepository) GetByID(ctx context.Context, id string) (*domain.MonthlyStatement, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetForMonth retrieves an account's statement of a month
+func (r *MongoMonthlyStatementRepository) GetForMonth(ctx context.Context, accountID, month string) (*domain.MonthlyStatement, error) {
+	return r.findOne(ctx, bson.M{"account_id": accountID, "month": month})
+}
+
+func (r *MongoMonthlyStatementRepository) findOne(ctx context.Context, filter bson.M) (*domain.MonthlyStatement, error) {
+	var statement domain.MonthlyStatement
+	if err := r.collection.FindOne(ctx, filter).Decode(&statement); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrStatementNotFound
+		}
+		return nil, fmt.Errorf("failed to get statement: %w", err)
+	}
+	return &statement, nil
+}
+
+// ListByAccount pages through an account's statements, leaving their lines
+// unread
+func (r *MongoMonthlyStatementRepository) ListByAccount(ctx context.Context, accountID string, limit, offset int) ([]*domain.MonthlyStatement, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "month", Value: -1}}).
+		SetProjection(bson.M{"lines": 0}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, bson.M{"account_id": accountID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statements: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	statements := []*domain.MonthlyStatement{}
+	if err := cursor.All(ctx, &statements); err != nil {
+		return nil, fmt.Errorf("failed to decode statements: %w", err)
+	}
+	return statements, nil
+}
+
+// MarkNotified sets when the statement's event was published
+func (r *MongoMonthlyStatementRepository) MarkNotified(ctx context.Context, id string, at time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"notified_at": at}})
+	if err != nil {
+		return fmt.Errorf("failed to mark statement notified: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoQueueStatsStore implements the QueueStatsStore interface, keeping each
+// queue's sequence counter and its processors' rates in one collection
+type MongoQueueStatsStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoQueueStatsStore creates a new MongoDB queue stats store
+func NewMongoQueueStatsStore(db *mongo.Database, collectionName string) domain.QueueStatsStore {
+	return &MongoQueueStatsStore{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// NextSequence increments the queue's counter, creating it on first use
+func (s *MongoQueueStatsStore) NextSequence(ctx context.Context, queue string) (int64, error) {
+	var counter struct {
+		Value int64 `bson:"value"`
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := s.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": "sequence:" + queue},
+		bson.M{"$inc": bson.M{"value": int64(1)}},
+		opts,
+	).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment queue sequence: %w", err)
+	}
+	return counter.Value, nil
+}
+
+// SaveProcessingRate upserts the rate by queue and worker
+func (s *MongoQueueStatsStore) SaveProcessingRate(ctx context.Context, rate *domain.ProcessingRate) error {
+	id := fmt.Sprintf("rate:%s:%s", rate.Queue, rate.Worker)
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": id}, rate, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save processing rate: %w", err)
+	}
+	return nil
+}
+
+// ProcessingRates lists the rates reported for the queue since a time
+func (s *MongoQueueStatsStore) ProcessingRates(ctx context.Context, queue string, since time.Time) ([]*domain.ProcessingRate, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{
+		"queue":      queue,
+		"updated_at": bson.M{"$gte": since},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processing rates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rates []*domain.ProcessingRate
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, fmt.Errorf("failed to decode processing rates: %w", err)
+	}
+	return rates, nil
+}
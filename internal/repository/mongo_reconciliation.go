@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"banking-ledger/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoReconciliationReportRepository implements the
+// ReconciliationReportRepository interface
+type MongoReconciliationReportRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoReconciliationReportRepository creates a new MongoDB reconciliation report repository
+func NewMongoReconciliationReportRepository(db *mongo.Database, collectionName string) domain.ReconciliationReportRepository {
+	return &MongoReconciliationReportRepository{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Save upserts the report by ID
+func (r *MongoReconciliationReportRepository) Save(ctx context.Context, report *domain.ReconciliationReport) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": report.ID}, report, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save reconciliation report: %w", err)
+	}
+	return nil
+}
+
+// GetLatest returns the report started last
+func (r *MongoReconciliationReportRepository) GetLatest(ctx context.Context) (*domain.ReconciliationReport, error) {
+	var report domain.ReconciliationReport
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	err := r.collection.FindOne(ctx, bson.M{}, opts).Decode(&report)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrReconciliationReportNotFound
+		}
+		return nil, fmt.Errorf("failed to get reconciliation report: %w", err)
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// submissionGuard is the document stored per fingerprint
+type submissionGuard struct {
+	Fingerprint   string    `bson:"_id"`
+	TransactionID string    `bson:"transaction_id"`
+	CreatedAt     time.Time `bson:"created_at"`
+}
+
+// MongoSubmissionGuard implements the SubmissionGuard interface
+type MongoSubmissionGuard struct {
+	collection *mongo.Collection
+}
+
+// NewMongoSubmissionGuard creates a new MongoDB submission guard
+func NewMongoSubmissionGuard(db *mongo.Database, collectionName string) domain.SubmissionGuard {
+	return &MongoSubmissionGuard{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Claim registers a submission, relying on the unique _id so that concurrent
+// identical submissions cannot both win
+func (g *MongoSubmissionGuard) Claim(ctx context.Context, fingerprint, transactionID string, now time.Time, window time.Duration) (string, error) {
+	_, err := g.collection.InsertOne(ctx, submissionGuard{
+		Fingerprint:   fingerprint,
+		TransactionID: transactionID,
+		CreatedAt:     now,
+	})
+	if err == nil {
+		return "", nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return "", fmt.Errorf("failed to claim submission: %w", err)
+	}
+
+	// A guard older than the window no longer counts, so replace it. The
+	// created_at condition makes the replacement atomic.
+	filter := bson.M{
+		"_id":        fingerprint,
+		"created_at": bson.M{"$lte": now.Add(-window)},
+	}
+	update := bson.M{"$set": bson.M{"transaction_id": transactionID, "created_at": now}}
+
+	result, err := g.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", fmt.Errorf("failed to claim submission: %w", err)
+	}
+	if result.MatchedCount == 1 {
+		return "", nil
+	}
+
+	return g.holder(ctx, fingerprint)
+}
+
+// Takeover hands an existing guard over to a new transaction
+func (g *MongoSubmissionGuard) Takeover(ctx context.Context, fingerprint, previousID, transactionID string, now time.Time) (string, error) {
+	filter := bson.M{"_id": fingerprint, "transaction_id": previousID}
+	update := bson.M{"$set": bson.M{"transaction_id": transactionID, "created_at": now}}
+
+	result, err := g.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", fmt.Errorf("failed to take over submission: %w", err)
+	}
+	if result.MatchedCount == 1 {
+		return "", nil
+	}
+
+	return g.holder(ctx, fingerprint)
+}
+
+// holder returns the transaction currently holding a fingerprint
+func (g *MongoSubmissionGuard) holder(ctx context.Context, fingerprint string) (string, error) {
+	var guard submissionGuard
+	err := g.collection.FindOne(ctx, bson.M{"_id": fingerprint}).Decode(&guard)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// The guard expired in the meantime, so nothing holds it
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get submission guard: %w", err)
+	}
+	return guard.TransactionID, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"time"
 
 	"banking-ledger/internal/domain"
@@ -16,13 +17,42 @@ import (
 // MongoTransactionRepository implements the TransactionRepository interface
 type MongoTransactionRepository struct {
 	collection *mongo.Collection
+	// archive holds the transactions moved out of collection, nil when
+	// archiving is disabled
+	archive      *mongo.Collection
+	archiveAfter time.Duration
+}
+
+// MongoTransactionOption configures optional MongoTransactionRepository behaviour
+type MongoTransactionOption func(*MongoTransactionRepository)
+
+// WithArchive reads transactions moved to the archive collection as well,
+// the collection's name suffixed with _archive. Transactions are archived
+// once they are older than after, so filters starting later than that skip
+// the archive.
+func WithArchive(after time.Duration) MongoTransactionOption {
+	return func(r *MongoTransactionRepository) {
+		r.archiveAfter = after
+	}
+}
+
+// ArchiveCollectionName is the name of the archive of a transaction collection
+func ArchiveCollectionName(collectionName string) string {
+	return collectionName + "_archive"
 }
 
 // NewMongoTransactionRepository creates a new MongoDB transaction repository
-func NewMongoTransactionRepository(db *mongo.Database, collectionName string) domain.TransactionRepository {
-	return &MongoTransactionRepository{
+func NewMongoTransactionRepository(db *mongo.Database, collectionName string, opts ...MongoTransactionOption) domain.TransactionRepository {
+	r := &MongoTransactionRepository{
 		collection: db.Collection(collectionName),
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.archiveAfter > 0 {
+		r.archive = db.Collection(ArchiveCollectionName(collectionName))
+	}
+	return r
 }
 
 // Create creates a new transaction
@@ -31,23 +61,68 @@ func (r *MongoTransactionRepository) Create(ctx context.Context, transaction *do
 		transaction.ID = uuid.New().String()
 	}
 
-	transaction.CreatedAt = time.Now()
-	transaction.UpdatedAt = time.Now()
+	now := time.Now()
+	transaction.CreatedAt = now
+	transaction.UpdatedAt = now
+	transaction.ReferenceReserved = transaction.ReservesReference()
+	if len(transaction.StatusHistory) == 0 {
+		transaction.StatusHistory = []domain.StatusChange{{
+			Status:       transaction.Status,
+			Timestamp:    now,
+			ErrorMessage: transaction.ErrorMessage,
+		}}
+	}
 
 	_, err := r.collection.InsertOne(ctx, transaction)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) && r.idTaken(ctx, transaction.ID) {
+			return domain.ErrTransactionIDTaken
+		}
+		if mongo.IsDuplicateKeyError(err) && transaction.ReferenceReserved {
+			if existingID, findErr := r.findReferenceHolder(ctx, transaction); findErr == nil {
+				return &domain.DuplicateReferenceError{ExistingTransactionID: existingID}
+			}
+		}
 		return fmt.Errorf("failed to create transaction: %w", err)
 	}
 
 	return nil
 }
 
+// idTaken reports whether a transaction already has the ID, telling an ID
+// collision from a reference one
+func (r *MongoTransactionRepository) idTaken(ctx context.Context, id string) bool {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	return err == nil && count > 0
+}
+
+// findReferenceHolder returns the ID of the transaction holding the
+// reference that the given transaction collided with
+func (r *MongoTransactionRepository) findReferenceHolder(ctx context.Context, transaction *domain.Transaction) (string, error) {
+	filter := bson.M{
+		"reference":          transaction.Reference,
+		"from_account_id":    transaction.FromAccountID,
+		"reference_reserved": true,
+		"_id":                bson.M{"$ne": transaction.ID},
+	}
+
+	var existing domain.Transaction
+	if err := r.collection.FindOne(ctx, filter).Decode(&existing); err != nil {
+		return "", err
+	}
+
+	return existing.ID, nil
+}
+
 // GetByID retrieves a transaction by ID
 func (r *MongoTransactionRepository) GetByID(ctx context.Context, id string) (*domain.Transaction, error) {
 	var transaction domain.Transaction
 
 	filter := bson.M{"_id": id}
 	err := r.collection.FindOne(ctx, filter).Decode(&transaction)
+	if err == mongo.ErrNoDocuments && r.archive != nil {
+		err = r.archive.FindOne(ctx, filter).Decode(&transaction)
+	}
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, domain.ErrTransactionNotFound
@@ -58,6 +133,52 @@ func (r *MongoTransactionRepository) GetByID(ctx context.Context, id string) (*d
 	return &transaction, nil
 }
 
+// GetByIDs retrieves the transactions with the IDs with a single $in query
+func (r *MongoTransactionRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Transaction, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	transactions, err := findByIDs(ctx, r.collection, ids)
+	if err != nil || r.archive == nil || len(transactions) == len(ids) {
+		return transactions, err
+	}
+
+	// Look for the rest in the archive
+	found := make(map[string]bool, len(transactions))
+	for _, transaction := range transactions {
+		found[transaction.ID] = true
+	}
+	var missing []string
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	archived, err := findByIDs(ctx, r.archive, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(transactions, archived...), nil
+}
+
+// findByIDs retrieves the transactions with the IDs from a collection
+func findByIDs(ctx context.Context, collection *mongo.Collection, ids []string) ([]*domain.Transaction, error) {
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
 // GetByAccountID retrieves transactions by account ID
 func (r *MongoTransactionRepository) GetByAccountID(ctx context.Context, accountID string, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
 	if filter == nil {
@@ -68,40 +189,77 @@ func (r *MongoTransactionRepository) GetByAccountID(ctx context.Context, account
 	return r.GetByFilter(ctx, filter)
 }
 
-// GetByFilter retrieves transactions by filter
-func (r *MongoTransactionRepository) GetByFilter(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
-	mongoFilter := r.buildMongoFilter(filter)
+// EachByAccountID walks an account's transactions oldest first with a
+// cursor, so that an export of any size holds one transaction at a time
+func (r *MongoTransactionRepository) EachByAccountID(ctx context.Context, accountID string, filter *domain.TransactionFilter, fn func(*domain.Transaction) error) error {
+	scoped := domain.TransactionFilter{}
+	if filter != nil {
+		scoped = *filter
+	}
+	scoped.AccountID = &accountID
 
-	opts := options.Find()
-	opts.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	sort := bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+	cursor, err := r.find(ctx, &scoped, sort, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to find transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
 
-	if filter.Limit > 0 {
-		opts.SetLimit(int64(filter.Limit))
+	for cursor.Next(ctx) {
+		var transaction domain.Transaction
+		if err := cursor.Decode(&transaction); err != nil {
+			return fmt.Errorf("failed to decode transaction: %w", err)
+		}
+		if err := fn(&transaction); err != nil {
+			return err
+		}
 	}
-	if filter.Offset > 0 {
-		opts.SetSkip(int64(filter.Offset))
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("cursor error: %w", err)
 	}
 
-	cursor, err := r.collection.Find(ctx, mongoFilter, opts)
+	return nil
+}
+
+// GetByFilter retrieves transactions by filter
+func (r *MongoTransactionRepository) GetByFilter(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	var transactions []*domain.Transaction
+	err := r.EachByFilter(ctx, filter, func(transaction *domain.Transaction) error {
+		transactions = append(transactions, transaction)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to find transactions: %w", err)
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// EachByFilter walks the page of transactions the filter selects with a
+// cursor, handing each to fn as it is decoded
+func (r *MongoTransactionRepository) EachByFilter(ctx context.Context, filter *domain.TransactionFilter, fn func(*domain.Transaction) error) error {
+	cursor, err := r.find(ctx, filter, transactionSort(filter), filter.Limit, filter.Offset)
+	if err != nil {
+		return fmt.Errorf("failed to find transactions: %w", err)
 	}
 	defer cursor.Close(ctx)
 
-	var transactions []*domain.Transaction
 	for cursor.Next(ctx) {
 		var transaction domain.Transaction
 		if err := cursor.Decode(&transaction); err != nil {
-			return nil, fmt.Errorf("failed to decode transaction: %w", err)
+			return fmt.Errorf("failed to decode transaction: %w", err)
+		}
+		if err := fn(&transaction); err != nil {
+			return err
 		}
-		transactions = append(transactions, &transaction)
 	}
 
 	if err := cursor.Err(); err != nil {
-		return nil, fmt.Errorf("cursor error: %w", err)
+		return fmt.Errorf("cursor error: %w", err)
 	}
 
-	return transactions, nil
+	return nil
 }
 
 // Update updates a transaction
@@ -125,22 +283,101 @@ func (r *MongoTransactionRepository) Update(ctx context.Context, transaction *do
 
 // UpdateStatus updates transaction status
 func (r *MongoTransactionRepository) UpdateStatus(ctx context.Context, id string, status domain.TransactionStatus, errorMessage string) error {
-	filter := bson.M{"_id": id}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, statusUpdate(status, errorMessage))
+	if err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// UpdateStatusIf updates the status of a transaction only while it is in the
+// expected status, so that of two racing transitions exactly one wins
+func (r *MongoTransactionRepository) UpdateStatusIf(ctx context.Context, id string, expected, status domain.TransactionStatus, errorMessage string) error {
+	filter := bson.M{"_id": id, "status": expected}
+	result, err := r.collection.UpdateOne(ctx, filter, statusUpdate(status, errorMessage))
+	if err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return domain.ErrTransactionAlreadyProcessed
+	}
+
+	return nil
+}
+
+// statusUpdate builds the update moving a transaction to status
+func statusUpdate(status domain.TransactionStatus, errorMessage string) bson.M {
+	now := time.Now()
 	update := bson.M{
 		"$set": bson.M{
 			"status":        status,
 			"error_message": errorMessage,
-			"updated_at":    time.Now(),
+			"updated_at":    now,
 		},
+		"$push": bson.M{"status_history": statusChange(status, now, errorMessage)},
 	}
 
 	if status == domain.TransactionStatusCompleted {
-		update["$set"].(bson.M)["processed_at"] = time.Now()
+		update["$set"].(bson.M)["processed_at"] = now
+	}
+
+	// Cancelled transactions release their reference for reuse
+	if status == domain.TransactionStatusCancelled {
+		update["$unset"] = bson.M{"reference_reserved": ""}
+	}
+
+	return update
+}
+
+// statusChange builds the status history entry recorded with a status update
+func statusChange(status domain.TransactionStatus, at time.Time, errorMessage string) domain.StatusChange {
+	return domain.StatusChange{Status: status, Timestamp: at, ErrorMessage: errorMessage}
+}
+
+// MarkFailed marks a transaction as failed with its failure code
+func (r *MongoTransactionRepository) MarkFailed(ctx context.Context, id string, code domain.FailureCode, errorMessage string) error {
+	now := time.Now()
+	filter := bson.M{"_id": id}
+	update := bson.M{
+		"$set": bson.M{
+			"status":        domain.TransactionStatusFailed,
+			"failure_code":  code,
+			"error_message": errorMessage,
+			"updated_at":    now,
+		},
+		"$push": bson.M{"status_history": statusChange(domain.TransactionStatusFailed, now, errorMessage)},
 	}
 
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return fmt.Errorf("failed to update transaction status: %w", err)
+		return fmt.Errorf("failed to mark transaction failed: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// MarkCompleted marks a transaction completed, recording the balance changes
+// it was applied with
+func (r *MongoTransactionRepository) MarkCompleted(ctx context.Context, id string, changes []*domain.BalanceChange) error {
+	update := statusUpdate(domain.TransactionStatusCompleted, "")
+	update["$set"].(bson.M)["balance_changes"] = changes
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to mark transaction completed: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
@@ -150,57 +387,1285 @@ func (r *MongoTransactionRepository) UpdateStatus(ctx context.Context, id string
 	return nil
 }
 
+// Search finds transactions with the text index on description and
+// reference, sorted by relevance score
+func (r *MongoTransactionRepository) Search(ctx context.Context, query string, filter *domain.TransactionFilter) ([]*domain.TransactionMatch, error) {
+	mongoFilter := r.buildMongoFilter(filter)
+	mongoFilter["$text"] = bson.M{"$search": query}
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "created_at", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+	if filter.Offset > 0 {
+		opts.SetSkip(int64(filter.Offset))
+	}
+
+	cursor, err := r.collection.Find(ctx, mongoFilter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var matches []*domain.TransactionMatch
+	for cursor.Next(ctx) {
+		var transaction domain.Transaction
+		if err := cursor.Decode(&transaction); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction: %w", err)
+		}
+		match := &domain.TransactionMatch{Transaction: &transaction}
+		if value, ok := cursor.Current.Lookup("score").DoubleOK(); ok {
+			match.Score = value
+		}
+		matches = append(matches, match)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	return matches, nil
+}
+
+// transactionSort orders a listing by the filter's sort field and order,
+// newest created first by default, breaking ties by ID so pages are stable
+func transactionSort(filter *domain.TransactionFilter) bson.D {
+	field := domain.TransactionSortCreatedAt
+	if filter.SortBy.IsValid() {
+		field = filter.SortBy
+	}
+	direction := -1
+	if filter.SortOrder == domain.SortAscending {
+		direction = 1
+	}
+	return bson.D{{Key: string(field), Value: direction}, {Key: "_id", Value: direction}}
+}
+
 // Count counts transactions by filter
 func (r *MongoTransactionRepository) Count(ctx context.Context, filter *domain.TransactionFilter) (int64, error) {
 	mongoFilter := r.buildMongoFilter(filter)
+	if !r.readsArchive(filter) {
+		count, err := r.collection.CountDocuments(ctx, mongoFilter)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count transactions: %w", err)
+		}
+		return count, nil
+	}
 
-	count, err := r.collection.CountDocuments(ctx, mongoFilter)
+	pipeline := r.withArchive(mongo.Pipeline{
+		{{Key: "$match", Value: mongoFilter}},
+		{{Key: "$count", Value: "count"}},
+	})
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count transactions: %w", err)
 	}
+	defer cursor.Close(ctx)
 
-	return count, nil
-}
+	var results []struct {
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, fmt.Errorf("failed to decode transaction count: %w", err)
+	}
 
-func (r *MongoTransactionRepository) buildMongoFilter(filter *domain.TransactionFilter) bson.M {
-	mongoFilter := bson.M{}
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Count, nil
+}
 
-	if filter.AccountID != nil {
-		mongoFilter["$or"] = []bson.M{
-			{"from_account_id": *filter.AccountID},
-			{"to_account_id": *filter.AccountID},
+// find runs a filter over the collection, and over the archive as well
+// unless the filter starts after the archive's cutoff, reading only the
+// fields of any projection. A limit or skip of zero is ignored.
+func (r *MongoTransactionRepository) find(ctx context.Context, filter *domain.TransactionFilter, sort bson.D, limit, skip int) (*mongo.Cursor, error) {
+	mongoFilter := r.buildMongoFilter(filter)
+	var projection bson.M
+	if filter != nil && filter.Projection != nil {
+		projection = bson.M{}
+		for field, keep := range filter.Projection.DocumentFields() {
+			projection[field] = keep
+		}
+	}
+	if !r.readsArchive(filter) {
+		opts := options.Find().SetSort(sort)
+		if limit > 0 {
+			opts.SetLimit(int64(limit))
 		}
+		if skip > 0 {
+			opts.SetSkip(int64(skip))
+		}
+		if len(projection) > 0 {
+			opts.SetProjection(projection)
+		}
+		return r.collection.Find(ctx, mongoFilter, opts)
 	}
 
-	if filter.Type != nil {
-		mongoFilter["type"] = *filter.Type
+	pipeline := r.withArchive(mongo.Pipeline{
+		{{Key: "$match", Value: mongoFilter}},
+		{{Key: "$sort", Value: sort}},
+	})
+	if skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: int64(skip)}})
 	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: int64(limit)}})
+	}
+	if len(projection) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+	}
+	return r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+}
 
-	if filter.Status != nil {
-		mongoFilter["status"] = *filter.Status
+// readsArchive reports whether a filter may match archived transactions,
+// which it cannot when it starts after the archive's cutoff
+func (r *MongoTransactionRepository) readsArchive(filter *domain.TransactionFilter) bool {
+	if r.archive == nil {
+		return false
 	}
+	return filter == nil || filter.FromDate == nil || filter.FromDate.Before(time.Now().Add(-r.archiveAfter))
+}
 
-	if filter.FromDate != nil || filter.ToDate != nil {
-		dateFilter := bson.M{}
-		if filter.FromDate != nil {
-			dateFilter["$gte"] = *filter.FromDate
-		}
-		if filter.ToDate != nil {
-			dateFilter["$lte"] = *filter.ToDate
+// withArchive adds the archive's transactions matching the pipeline's
+// leading $match to it, straight after that stage, so the rest of the
+// pipeline sees both collections. It needs MongoDB 4.4 or later.
+func (r *MongoTransactionRepository) withArchive(pipeline mongo.Pipeline) mongo.Pipeline {
+	if r.archive == nil {
+		return pipeline
+	}
+
+	union := bson.D{{Key: "$unionWith", Value: bson.M{
+		"coll":     r.archive.Name(),
+		"pipeline": mongo.Pipeline{pipeline[0]},
+	}}}
+	combined := make(mongo.Pipeline, 0, len(pipeline)+1)
+	combined = append(combined, pipeline[0], union)
+	return append(combined, pipeline[1:]...)
+}
+
+// ArchiveBefore moves up to limit archivable transactions created before a
+// time to the archive, oldest first, returning how many were moved. Each is
+// upserted into the archive before it is deleted, so a run cut short is
+// finished by the next one. A transaction changed in between is left where
+// it is and its archive copy removed again.
+func (r *MongoTransactionRepository) ArchiveBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	if r.archive == nil {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"status":     bson.M{"$in": domain.FinalTransactionStatuses},
+		"created_at": bson.M{"$lt": before},
+		"outbox":     bson.M{"$exists": false},
+		"$and": bson.A{
+			// No reversal in flight
+			bson.M{"$or": bson.A{
+				bson.M{"reversal_id": bson.M{"$exists": false}},
+				bson.M{"reversed_by": bson.M{"$exists": true}},
+			}},
+			// No compensation still being returned
+			bson.M{"$or": bson.A{
+				bson.M{"compensation": bson.M{"$exists": false}},
+				bson.M{"compensation.status": domain.CompensationStatusCompleted},
+			}},
+		},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find transactions to archive: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// Copy the documents as stored, so fields survive the move unchanged
+	var documents []bson.M
+	if err := cursor.All(ctx, &documents); err != nil {
+		return 0, fmt.Errorf("failed to decode transactions to archive: %w", err)
+	}
+	if len(documents) == 0 {
+		return 0, nil
+	}
+
+	archivedAt := time.Now()
+	copies := make([]mongo.WriteModel, 0, len(documents))
+	deletes := make([]mongo.WriteModel, 0, len(documents))
+	ids := make([]interface{}, 0, len(documents))
+	for _, document := range documents {
+		id := document["_id"]
+		ids = append(ids, id)
+		// The archive does not hold references against reuse
+		delete(document, "reference_reserved")
+		document["archived"] = true
+		document["archived_at"] = archivedAt
+		copies = append(copies, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetReplacement(document).
+			SetUpsert(true))
+		deletes = append(deletes, mongo.NewDeleteOneModel().
+			SetFilter(bson.M{"_id": id, "updated_at": document["updated_at"]}))
+	}
+
+	if _, err := r.archive.BulkWrite(ctx, copies); err != nil {
+		return 0, fmt.Errorf("failed to copy transactions to archive: %w", err)
+	}
+
+	result, err := r.collection.BulkWrite(ctx, deletes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete archived transactions: %w", err)
+	}
+	moved := int(result.DeletedCount)
+	if moved == len(documents) {
+		return moved, nil
+	}
+
+	// Drop the copies of transactions changed since they were read, which
+	// stay in the collection
+	changed, err := r.collection.Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return moved, fmt.Errorf("failed to find changed transactions: %w", err)
+	}
+	if len(changed) > 0 {
+		stale := bson.M{"_id": bson.M{"$in": changed}, "archived_at": archivedAt}
+		if _, err := r.archive.DeleteMany(ctx, stale); err != nil {
+			return moved, fmt.Errorf("failed to drop archive copies of changed transactions: %w", err)
 		}
-		mongoFilter["created_at"] = dateFilter
 	}
 
-	if filter.MinAmount != nil || filter.MaxAmount != nil {
-		amountFilter := bson.M{}
-		if filter.MinAmount != nil {
-			amountFilter["$gte"] = *filter.MinAmount
+	return moved, nil
+}
+
+// SetSettlementBatch marks transactions as settled in a batch, leaving
+// transactions already marked with a batch untouched
+func (r *MongoTransactionRepository) SetSettlementBatch(ctx context.Context, ids []string, batchID string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"_id":                 bson.M{"$in": ids},
+		"settlement_batch_id": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"settlement_batch_id": batchID,
+			"updated_at":          time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set settlement batch: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// ClaimReversal records reversalID on a completed, unreversed transaction
+// that has not been refunded
+func (r *MongoTransactionRepository) ClaimReversal(ctx context.Context, id, reversalID string) error {
+	filter := bson.M{
+		"_id":          id,
+		"status":       domain.TransactionStatusCompleted,
+		"reversal_id":  bson.M{"$exists": false},
+		"refund_ids.0": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"reversal_id": reversalID,
+			"updated_at":  time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to claim reversal: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		transaction, err := r.GetByID(ctx, id)
+		if err != nil {
+			return err
 		}
-		if filter.MaxAmount != nil {
-			amountFilter["$lte"] = *filter.MaxAmount
+		if transaction.Status != domain.TransactionStatusCompleted || len(transaction.RefundIDs) > 0 {
+			return domain.ErrTransactionNotReversible
 		}
-		mongoFilter["amount"] = amountFilter
+		return domain.ErrTransactionAlreadyReversed
 	}
 
-	return mongoFilter
+	return nil
+}
+
+// RecordRefund counts a refund against a completed, unreversed transaction
+// while the total refunded stays within the transaction's amount
+func (r *MongoTransactionRepository) RecordRefund(ctx context.Context, id, refundID string, amount domain.Money) error {
+	filter := bson.M{
+		"_id":         id,
+		"status":      domain.TransactionStatusCompleted,
+		"reversal_id": bson.M{"$exists": false},
+		"refund_ids":  bson.M{"$ne": refundID},
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$refunded_amount", 0}}, amount}},
+			"$amount",
+		}},
+	}
+	update := bson.M{
+		"$inc":  bson.M{"refunded_amount": amount},
+		"$push": bson.M{"refund_ids": refundID},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to record refund: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		transaction, err := r.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, recorded := range transaction.RefundIDs {
+			if recorded == refundID {
+				return nil
+			}
+		}
+		switch {
+		case transaction.Status != domain.TransactionStatusCompleted:
+			return domain.ErrTransactionNotCompleted
+		case transaction.ReversalID != "":
+			return domain.ErrTransactionAlreadyReversed
+		default:
+			return domain.ErrRefundExceedsOriginal
+		}
+	}
+
+	return nil
+}
+
+// ReleaseRefund removes a recorded refund and its amount
+func (r *MongoTransactionRepository) ReleaseRefund(ctx context.Context, id, refundID string, amount domain.Money) error {
+	filter := bson.M{"_id": id, "refund_ids": refundID}
+	update := bson.M{
+		"$inc":  bson.M{"refunded_amount": -amount},
+		"$pull": bson.M{"refund_ids": refundID},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to release refund: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveReversal completes or releases a claimed reversal. A claim held by
+// a different reversal is left alone.
+func (r *MongoTransactionRepository) ResolveReversal(ctx context.Context, id, reversalID string, completed bool) error {
+	filter := bson.M{"_id": id, "reversal_id": reversalID}
+	update := bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"reversal_id": ""},
+	}
+	if completed {
+		update = bson.M{
+			"$set": bson.M{
+				"reversed_by": reversalID,
+				"updated_at":  time.Now(),
+			},
+		}
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to resolve reversal: %w", err)
+	}
+
+	return nil
+}
+
+// ListDueScheduled lists scheduled transactions whose time has come
+func (r *MongoTransactionRepository) ListDueScheduled(ctx context.Context, now time.Time, limit int) ([]*domain.Transaction, error) {
+	filter := bson.M{
+		"status":       domain.TransactionStatusScheduled,
+		"scheduled_at": bson.M{"$lte": now},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "scheduled_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find scheduled transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode scheduled transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// ReleaseScheduled moves a transaction that is still scheduled to pending
+func (r *MongoTransactionRepository) ReleaseScheduled(ctx context.Context, id string) error {
+	now := time.Now()
+	filter := bson.M{"_id": id, "status": domain.TransactionStatusScheduled}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     domain.TransactionStatusPending,
+			"updated_at": now,
+		},
+		"$push": bson.M{"status_history": statusChange(domain.TransactionStatusPending, now, "")},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to release scheduled transaction: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return domain.ErrTransactionAlreadyProcessed
+	}
+
+	return nil
+}
+
+// ClaimProcessing moves a pending transaction to processing, so that only one
+// consumer processes a transaction delivered more than once, and a
+// transaction cancelled meanwhile is not processed at all
+func (r *MongoTransactionRepository) ClaimProcessing(ctx context.Context, id string) error {
+	return r.UpdateStatusIf(ctx, id, domain.TransactionStatusPending, domain.TransactionStatusProcessing, "")
+}
+
+// ListStalePending lists transactions pending since before the cutoff. A
+// requeued transaction counts from when it was requeued, which also moves
+// updated_at; created_at narrows the scan to the index.
+func (r *MongoTransactionRepository) ListStalePending(ctx context.Context, before time.Time, limit int) ([]*domain.Transaction, error) {
+	filter := bson.M{
+		"status":     domain.TransactionStatusPending,
+		"created_at": bson.M{"$lt": before},
+		"updated_at": bson.M{"$lt": before},
+		"outbox":     bson.M{"$exists": false},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stale pending transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode stale pending transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// ListAwaitingApproval lists transactions awaiting approval since before the
+// cutoff, oldest first
+func (r *MongoTransactionRepository) ListAwaitingApproval(ctx context.Context, before time.Time, limit int) ([]*domain.Transaction, error) {
+	filter := bson.M{
+		"status":     domain.TransactionStatusPendingApproval,
+		"created_at": bson.M{"$lt": before},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transactions awaiting approval: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions awaiting approval: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// GetByBatchID retrieves the legs of a batch, ordered by their IDs
+func (r *MongoTransactionRepository) GetByBatchID(ctx context.Context, batchID string) ([]*domain.Transaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"batch_id": batchID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find batch transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode batch transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// FailPending marks a transaction failed if it is still pending, leaving one
+// the processor has picked up alone
+func (r *MongoTransactionRepository) FailPending(ctx context.Context, id string, code domain.FailureCode, errorMessage string) error {
+	return r.FailIf(ctx, id, domain.TransactionStatusPending, code, errorMessage)
+}
+
+// FailIf marks a transaction failed only while it is in the expected status
+func (r *MongoTransactionRepository) FailIf(ctx context.Context, id string, expected domain.TransactionStatus, code domain.FailureCode, errorMessage string) error {
+	now := time.Now()
+	filter := bson.M{"_id": id, "status": expected}
+	update := bson.M{
+		"$set": bson.M{
+			"status":        domain.TransactionStatusFailed,
+			"failure_code":  code,
+			"error_message": errorMessage,
+			"updated_at":    now,
+		},
+		"$push": bson.M{"status_history": statusChange(domain.TransactionStatusFailed, now, errorMessage)},
+	}
+
+	return r.updatePending(ctx, id, filter, update, "failed to fail transaction")
+}
+
+// RequeuePending records a pending transaction being queued again, once
+func (r *MongoTransactionRepository) RequeuePending(ctx context.Context, id string) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id":         id,
+		"status":      domain.TransactionStatusPending,
+		"requeued_at": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"requeued_at": now,
+			"updated_at":  now,
+		},
+		"$push": bson.M{"status_history": requeueChange(now, "system")},
+	}
+
+	return r.updatePending(ctx, id, filter, update, "failed to requeue pending transaction")
+}
+
+// Requeue moves a pending or failed transaction to pending for its message
+// to be published again. A failed transaction loses its failure.
+func (r *MongoTransactionRepository) Requeue(ctx context.Context, id string, expected domain.TransactionStatus, code domain.FailureCode, actor string) (*domain.Transaction, error) {
+	now := time.Now()
+	filter := bson.M{"_id": id, "status": expected}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      domain.TransactionStatusPending,
+			"requeued_at": now,
+			"updated_at":  now,
+		},
+		"$push": bson.M{"status_history": requeueChange(now, actor)},
+	}
+	if expected == domain.TransactionStatusFailed {
+		filter["failure_code"] = code
+		update["$unset"] = bson.M{"failure_code": "", "error_message": "", "processed_at": ""}
+	}
+
+	var transaction domain.Transaction
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&transaction)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("failed to requeue transaction: %w", err)
+		}
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrTransactionAlreadyProcessed
+	}
+
+	return &transaction, nil
+}
+
+// requeueChange records a transaction returning to pending to be queued again
+func requeueChange(at time.Time, actor string) domain.StatusChange {
+	change := statusChange(domain.TransactionStatusPending, at, "")
+	change.RequeuedBy = actor
+	return change
+}
+
+// updatePending applies an update conditional on the transaction's state,
+// reporting ErrTransactionAlreadyProcessed when the condition no longer holds
+func (r *MongoTransactionRepository) updatePending(ctx context.Context, id string, filter, update bson.M, msg string) error {
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return domain.ErrTransactionAlreadyProcessed
+	}
+
+	return nil
+}
+
+func (r *MongoTransactionRepository) buildMongoFilter(filter *domain.TransactionFilter) bson.M {
+	mongoFilter := bson.M{}
+
+	if filter.AccountID != nil {
+		mongoFilter["$or"] = []bson.M{
+			{"from_account_id": *filter.AccountID},
+			{"to_account_id": *filter.AccountID},
+		}
+	} else if len(filter.AccountIDs) > 0 {
+		mongoFilter["$or"] = []bson.M{
+			{"from_account_id": bson.M{"$in": filter.AccountIDs}},
+			{"to_account_id": bson.M{"$in": filter.AccountIDs}},
+		}
+	}
+
+	if filter.FromAccountID != nil {
+		mongoFilter["from_account_id"] = *filter.FromAccountID
+	} else if len(filter.FromAccountIDs) > 0 {
+		mongoFilter["from_account_id"] = bson.M{"$in": filter.FromAccountIDs}
+	}
+
+	if filter.Type != nil {
+		mongoFilter["type"] = *filter.Type
+	} else if len(filter.Types) > 0 {
+		mongoFilter["type"] = bson.M{"$in": filter.Types}
+	} else if filter.ExcludesVerifications() {
+		mongoFilter["type"] = bson.M{"$ne": domain.TransactionTypeVerification}
+	}
+
+	if filter.Status != nil {
+		mongoFilter["status"] = *filter.Status
+	}
+
+	if filter.FailureCode != nil {
+		mongoFilter["failure_code"] = *filter.FailureCode
+	}
+
+	if filter.Currency != nil {
+		mongoFilter["currency"] = *filter.Currency
+	}
+
+	// An anchored prefix expression can use the reference index
+	if filter.Reference != nil {
+		mongoFilter["reference"] = *filter.Reference
+	} else if filter.ReferencePrefix != nil {
+		mongoFilter["reference"] = bson.M{"$regex": "^" + regexp.QuoteMeta(*filter.ReferencePrefix)}
+	}
+
+	// Only string values match, as the filter value is always a string
+	for key, value := range filter.Metadata {
+		mongoFilter["metadata."+key] = value
+	}
+
+	if len(filter.Tags) > 0 || len(filter.AnyTags) > 0 {
+		tags := bson.M{}
+		if len(filter.Tags) > 0 {
+			tags["$all"] = filter.Tags
+		}
+		if len(filter.AnyTags) > 0 {
+			tags["$in"] = filter.AnyTags
+		}
+		mongoFilter["tags"] = tags
+	}
+
+	if filter.ExternalSource != nil {
+		mongoFilter["external_source.reference"] = *filter.ExternalSource
+	}
+
+	if filter.ExternalDestination != nil {
+		mongoFilter["external_destination.reference"] = *filter.ExternalDestination
+	}
+
+	// Transactions recorded before self transfers were flagged have no field
+	if filter.SelfTransfer != nil {
+		if *filter.SelfTransfer {
+			mongoFilter["self_transfer"] = true
+		} else {
+			mongoFilter["self_transfer"] = bson.M{"$ne": true}
+		}
+	}
+
+	if filter.FromDate != nil || filter.ToDate != nil {
+		dateFilter := bson.M{}
+		if filter.FromDate != nil {
+			dateFilter["$gte"] = *filter.FromDate
+		}
+		if filter.ToDate != nil {
+			dateFilter["$lte"] = *filter.ToDate
+		}
+		mongoFilter["created_at"] = dateFilter
+	}
+
+	if filter.MinAmount != nil || filter.MaxAmount != nil {
+		// Amounts are stored in minor units, so the bounds are scaled per
+		// currency exponent
+		var amountConditions []bson.M
+		var listed []string
+		for exponent, currencies := range domain.CurrencyExponents() {
+			listed = append(listed, currencies...)
+			amountConditions = append(amountConditions, bson.M{
+				"currency": bson.M{"$in": currencies},
+				"amount":   amountRange(filter, exponent),
+			})
+		}
+		amountConditions = append(amountConditions, bson.M{
+			"currency": bson.M{"$nin": listed},
+			"amount":   amountRange(filter, domain.CurrencyExponent("")),
+		})
+		mongoFilter["$and"] = []bson.M{{"$or": amountConditions}}
+	}
+
+	return mongoFilter
+}
+
+// amountRange builds the amount bounds of a filter in minor units of
+// currencies with the given exponent
+func amountRange(filter *domain.TransactionFilter, exponent int) bson.M {
+	amountFilter := bson.M{}
+	if filter.MinAmount != nil {
+		if minAmount, ok := filter.MinAmount.Bound(exponent, true); ok {
+			amountFilter["$gte"] = minAmount
+		}
+	}
+	if filter.MaxAmount != nil {
+		if maxAmount, ok := filter.MaxAmount.Bound(exponent, false); ok {
+			amountFilter["$lte"] = maxAmount
+		}
+	}
+	return amountFilter
+}
+
+// UpdateTags replaces a transaction's tags, leaving the rest of it untouched
+func (r *MongoTransactionRepository) UpdateTags(ctx context.Context, id string, tags []string) error {
+	set := bson.M{"updated_at": time.Now()}
+	update := bson.M{"$set": set}
+	if len(tags) > 0 {
+		set["tags"] = tags
+	} else {
+		update["$unset"] = bson.M{"tags": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction tags: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// SetOutbox saves a message to publish for a stored transaction
+func (r *MongoTransactionRepository) SetOutbox(ctx context.Context, id string, message *domain.OutboxMessage) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"outbox": message}})
+	if err != nil {
+		return fmt.Errorf("failed to save outbox message: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// ListUndispatched lists transactions with an outbox message saved before the
+// cutoff, oldest first
+func (r *MongoTransactionRepository) ListUndispatched(ctx context.Context, before time.Time, limit int) ([]*domain.Transaction, error) {
+	filter := bson.M{"outbox.created_at": bson.M{"$lt": before}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "outbox.created_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find undispatched transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode undispatched transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// MarkDispatched clears a transaction's outbox message once it is published,
+// recording its enqueue sequence unless that is zero
+func (r *MongoTransactionRepository) MarkDispatched(ctx context.Context, id string, sequence int64) error {
+	update := bson.M{"$unset": bson.M{"outbox": ""}}
+	if sequence > 0 {
+		update["$set"] = bson.M{"queue_sequence": sequence}
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message dispatched: %w", err)
+	}
+
+	return nil
+}
+
+// CountUndispatched counts the outbox messages waiting to be published
+func (r *MongoTransactionRepository) CountUndispatched(ctx context.Context) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"outbox": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count undispatched transactions: %w", err)
+	}
+
+	return count, nil
+}
+
+// RecordCompensation records a compensation on a transfer and flags it
+// compensated in its metadata, leaving an existing compensation untouched
+func (r *MongoTransactionRepository) RecordCompensation(ctx context.Context, id string, compensation *domain.Compensation) error {
+	filter := bson.M{"_id": id, "compensation": bson.M{"$exists": false}}
+	update := bson.M{
+		"$set": bson.M{
+			"compensation":         compensation,
+			"metadata.compensated": true,
+			"updated_at":           time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to record compensation: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		// Already recorded by an earlier delivery
+		_, err := r.GetByID(ctx, id)
+		return err
+	}
+
+	return nil
+}
+
+// CompleteCompensation marks a transfer's compensation completed
+func (r *MongoTransactionRepository) CompleteCompensation(ctx context.Context, id string, completedAt time.Time) error {
+	filter := bson.M{"_id": id, "compensation": bson.M{"$exists": true}}
+	update := bson.M{
+		"$set": bson.M{
+			"compensation.status":       domain.CompensationStatusCompleted,
+			"compensation.completed_at": completedAt,
+			"updated_at":                time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to complete compensation: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// RecordExchange records the conversion a cross-currency transfer is posted
+// with, replacing one recorded by an earlier delivery that did not post
+func (r *MongoTransactionRepository) RecordExchange(ctx context.Context, id string, exchange *domain.Exchange) error {
+	update := bson.M{
+		"$set": bson.M{
+			"exchange":   exchange,
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to record exchange: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return domain.ErrTransactionNotFound
+	}
+
+	return nil
+}
+
+// SumOutgoing totals the completed and processing withdrawals and transfers
+// from an account created since a time, leaving out one transaction
+func (r *MongoTransactionRepository) SumOutgoing(ctx context.Context, accountID string, since time.Time, excludeID string) (domain.Money, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"from_account_id": accountID,
+			"created_at":      bson.M{"$gte": since},
+			"type":            bson.M{"$in": []domain.TransactionType{domain.TransactionTypeWithdrawal, domain.TransactionTypeTransfer}},
+			"status":          bson.M{"$in": []domain.TransactionStatus{domain.TransactionStatusCompleted, domain.TransactionStatusProcessing}},
+			"_id":             bson.M{"$ne": excludeID},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": "$amount"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum outgoing transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total domain.Money `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, fmt.Errorf("failed to decode outgoing total: %w", err)
+	}
+
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
+
+// SumDeposits totals the completed deposits into any of the accounts
+func (r *MongoTransactionRepository) SumDeposits(ctx context.Context, accountIDs []string) (domain.Money, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"to_account_id": bson.M{"$in": accountIDs},
+			"type":          domain.TransactionTypeDeposit,
+			"status":        domain.TransactionStatusCompleted,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": "$amount"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, r.withArchive(pipeline))
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum deposits: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total domain.Money `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return 0, fmt.Errorf("failed to decode deposit total: %w", err)
+	}
+
+	if len(results) == 0 {
+		return 0, nil
+	}
+	return results[0].Total, nil
+}
+
+// SummarizeByAccount totals an account's transactions in a single $group,
+// counting and summing each kind of transaction under its own condition
+func (r *MongoTransactionRepository) SummarizeByAccount(ctx context.Context, accountID string, from, to *time.Time) (*domain.AccountTotals, error) {
+	match := bson.M{"$or": bson.A{
+		bson.M{"from_account_id": accountID},
+		bson.M{"to_account_id": accountID},
+	}}
+	if from != nil || to != nil {
+		created := bson.M{}
+		if from != nil {
+			created["$gte"] = *from
+		}
+		if to != nil {
+			created["$lte"] = *to
+		}
+		match["created_at"] = created
+	}
+
+	completed := func(transactionType domain.TransactionType, side string) bson.A {
+		return bson.A{
+			bson.M{"$eq": bson.A{"$status", domain.TransactionStatusCompleted}},
+			bson.M{"$eq": bson.A{"$type", transactionType}},
+			bson.M{"$eq": bson.A{"$" + side, accountID}},
+		}
+	}
+	count := func(conditions bson.A) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$and": conditions}, 1, 0}}}
+	}
+	sum := func(conditions bson.A, amount interface{}) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$and": conditions}, amount, 0}}}
+	}
+	deposits := completed(domain.TransactionTypeDeposit, "to_account_id")
+	withdrawals := completed(domain.TransactionTypeWithdrawal, "from_account_id")
+	incoming := completed(domain.TransactionTypeTransfer, "to_account_id")
+	outgoing := completed(domain.TransactionTypeTransfer, "from_account_id")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":                       nil,
+			"transaction_count":         bson.M{"$sum": 1},
+			"last_transaction_at":       bson.M{"$max": "$created_at"},
+			"deposits_amount":           sum(deposits, "$amount"),
+			"deposits_count":            count(deposits),
+			"withdrawals_amount":        sum(withdrawals, "$amount"),
+			"withdrawals_count":         count(withdrawals),
+			"incoming_transfers_amount": sum(incoming, bson.M{"$ifNull": bson.A{"$exchange.credited_amount", "$amount"}}),
+			"incoming_transfers_count":  count(incoming),
+			"outgoing_transfers_amount": sum(outgoing, "$amount"),
+			"outgoing_transfers_count":  count(outgoing),
+			"pending_count": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$in": bson.A{"$status", bson.A{domain.TransactionStatusPending, domain.TransactionStatusProcessing}}}, 1, 0,
+			}}},
+			"failed_count": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$status", domain.TransactionStatusFailed}}, 1, 0,
+			}}},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, r.withArchive(pipeline))
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize account transactions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		TransactionCount        int64        `bson:"transaction_count"`
+		LastTransactionAt       time.Time    `bson:"last_transaction_at"`
+		DepositsAmount          domain.Money `bson:"deposits_amount"`
+		DepositsCount           int64        `bson:"deposits_count"`
+		WithdrawalsAmount       domain.Money `bson:"withdrawals_amount"`
+		WithdrawalsCount        int64        `bson:"withdrawals_count"`
+		IncomingTransfersAmount domain.Money `bson:"incoming_transfers_amount"`
+		IncomingTransfersCount  int64        `bson:"incoming_transfers_count"`
+		OutgoingTransfersAmount domain.Money `bson:"outgoing_transfers_amount"`
+		OutgoingTransfersCount  int64        `bson:"outgoing_transfers_count"`
+		PendingCount            int64        `bson:"pending_count"`
+		FailedCount             int64        `bson:"failed_count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode account totals: %w", err)
+	}
+
+	totals := &domain.AccountTotals{}
+	if len(results) == 0 {
+		return totals, nil
+	}
+	result := results[0]
+	totals.TransactionCount = result.TransactionCount
+	totals.LastTransactionAt = &result.LastTransactionAt
+	totals.Deposits = domain.TransactionTotal{Amount: result.DepositsAmount, Count: result.DepositsCount}
+	totals.Withdrawals = domain.TransactionTotal{Amount: result.WithdrawalsAmount, Count: result.WithdrawalsCount}
+	totals.IncomingTransfers = domain.TransactionTotal{Amount: result.IncomingTransfersAmount, Count: result.IncomingTransfersCount}
+	totals.OutgoingTransfers = domain.TransactionTotal{Amount: result.OutgoingTransfersAmount, Count: result.OutgoingTransfersCount}
+	totals.PendingCount = result.PendingCount
+	totals.FailedCount = result.FailedCount
+	return totals, nil
+}
+
+// SummarizeActivity runs one aggregation over the accounts' transactions,
+// faceted into the latest creation time and the per-currency totals of
+// completed deposits and withdrawals since a time
+func (r *MongoTransactionRepository) SummarizeActivity(ctx context.Context, accountIDs []string, since time.Time) (*domain.AccountActivity, error) {
+	amountIf := func(transactionType domain.TransactionType) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$type", transactionType}}, "$amount", 0}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"from_account_id": bson.M{"$in": accountIDs}},
+			bson.M{"to_account_id": bson.M{"$in": accountIDs}},
+		}}}},
+		{{Key: "$facet", Value: bson.M{
+			"latest": bson.A{
+				bson.M{"$group": bson.M{"_id": nil, "created_at": bson.M{"$max": "$created_at"}}},
+			},
+			"currencies": bson.A{
+				bson.M{"$match": bson.M{
+					"status":     domain.TransactionStatusCompleted,
+					"type":       bson.M{"$in": []domain.TransactionType{domain.TransactionTypeDeposit, domain.TransactionTypeWithdrawal}},
+					"created_at": bson.M{"$gte": since},
+				}},
+				bson.M{"$group": bson.M{
+					"_id":       "$currency",
+					"deposited": amountIf(domain.TransactionTypeDeposit),
+					"withdrawn": amountIf(domain.TransactionTypeWithdrawal),
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize account activity: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Latest []struct {
+			CreatedAt time.Time `bson:"created_at"`
+		} `bson:"latest"`
+		Currencies []*domain.CurrencyActivity `bson:"currencies"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode account activity: %w", err)
+	}
+
+	activity := &domain.AccountActivity{}
+	if len(results) == 0 {
+		return activity, nil
+	}
+	if latest := results[0].Latest; len(latest) > 0 {
+		activity.LastTransactionAt = &latest[0].CreatedAt
+	}
+	activity.Currencies = results[0].Currencies
+	return activity, nil
+}
+
+// SumMovements nets the accounts' balance changes in one aggregation, which
+// splits each transaction into a debit of its source and a credit of its
+// destination. The results are read from the cursor as they stream in, one
+// per account.
+func (r *MongoTransactionRepository) SumMovements(ctx context.Context, accountIDs []string) (map[string]domain.Money, error) {
+	completed := bson.M{"$eq": bson.A{"$status", domain.TransactionStatusCompleted}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"from_account_id": bson.M{"$in": accountIDs}},
+				bson.M{"to_account_id": bson.M{"$in": accountIDs}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{
+					"status": domain.TransactionStatusCompleted,
+					"type": bson.M{"$nin": []domain.TransactionType{
+						domain.TransactionTypeHold,
+						domain.TransactionTypeExternalTransfer,
+						domain.TransactionTypeVerification,
+					}},
+				},
+				// A compensated transfer's debit was posted
+				bson.M{
+					"status":       domain.TransactionStatusFailed,
+					"compensation": bson.M{"$exists": true},
+				},
+			}},
+		}}}},
+		{{Key: "$project", Value: bson.M{
+			"movements": bson.A{
+				bson.M{"account_id": "$from_account_id", "amount": bson.M{"$subtract": bson.A{0, "$amount"}}},
+				bson.M{"account_id": "$to_account_id", "amount": bson.M{"$cond": bson.A{
+					completed, bson.M{"$ifNull": bson.A{"$exchange.credited_amount", "$amount"}}, 0,
+				}}},
+			},
+		}}},
+		{{Key: "$unwind", Value: "$movements"}},
+		{{Key: "$match", Value: bson.M{"movements.account_id": bson.M{"$in": accountIDs}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": "$movements.account_id",
+			"net": bson.M{"$sum": "$movements.amount"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, r.withArchive(pipeline), options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum account movements: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	movements := make(map[string]domain.Money, len(accountIDs))
+	for cursor.Next(ctx) {
+		var result struct {
+			AccountID string       `bson:"_id"`
+			Net       domain.Money `bson:"net"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, fmt.Errorf("failed to decode account movements: %w", err)
+		}
+		movements[result.AccountID] = result.Net
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read account movements: %w", err)
+	}
+
+	return movements, nil
+}
+
+// SumMovementsByType totals the balance changes of every transaction by type
+// and currency. Each transaction is unwound into its debit and credit, so a
+// conversion's credit counts in the credited currency.
+func (r *MongoTransactionRepository) SumMovementsByType(ctx context.Context) ([]*domain.MovementTotal, error) {
+	completed := bson.M{"$eq": bson.A{"$status", domain.TransactionStatusCompleted}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{
+				"status": domain.TransactionStatusCompleted,
+				"type": bson.M{"$nin": []domain.TransactionType{
+					domain.TransactionTypeHold,
+					domain.TransactionTypeExternalTransfer,
+					domain.TransactionTypeVerification,
+				}},
+			},
+			// A compensated transfer's debit was posted
+			bson.M{
+				"status":       domain.TransactionStatusFailed,
+				"compensation": bson.M{"$exists": true},
+			},
+		}}}},
+		{{Key: "$project", Value: bson.M{
+			"type": 1,
+			"movements": bson.A{
+				bson.M{"account_id": "$from_account_id", "currency": "$currency", "debited": "$amount", "credited": 0},
+				bson.M{"account_id": "$to_account_id", "currency": bson.M{"$ifNull": bson.A{"$exchange.credited_currency", "$currency"}}, "debited": 0, "credited": bson.M{"$cond": bson.A{
+					completed, bson.M{"$ifNull": bson.A{"$exchange.credited_amount", "$amount"}}, 0,
+				}}},
+			},
+		}}},
+		{{Key: "$unwind", Value: "$movements"}},
+		{{Key: "$match", Value: bson.M{"movements.account_id": bson.M{"$ne": nil}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"type": "$type", "currency": "$movements.currency"},
+			"debited":  bson.M{"$sum": "$movements.debited"},
+			"credited": bson.M{"$sum": "$movements.credited"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.currency", Value: 1}, {Key: "_id.type", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, r.withArchive(pipeline), options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum movements by type: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var totals []*domain.MovementTotal
+	for cursor.Next(ctx) {
+		var result struct {
+			ID struct {
+				Type     domain.TransactionType `bson:"type"`
+				Currency string                 `bson:"currency"`
+			} `bson:"_id"`
+			Debited  domain.Money `bson:"debited"`
+			Credited domain.Money `bson:"credited"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, fmt.Errorf("failed to decode movements by type: %w", err)
+		}
+		totals = append(totals, &domain.MovementTotal{
+			Type:     result.ID.Type,
+			Currency: result.ID.Currency,
+			Debited:  result.Debited,
+			Credited: result.Credited,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read movements by type: %w", err)
+	}
+
+	return totals, nil
 }
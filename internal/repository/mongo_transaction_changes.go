@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoTransactionChangeSource lists transaction changes for the change feed
+type MongoTransactionChangeSource struct {
+	collection *mongo.Collection
+}
+
+// NewMongoTransactionChangeSource creates a new MongoDB transaction change source
+func NewMongoTransactionChangeSource(db *mongo.Database, collectionName string) domain.ChangeSource {
+	return &MongoTransactionChangeSource{
+		collection: db.Collection(collectionName),
+	}
+}
+
+// Changes returns transactions written after the cursor. Transactions are
+// never deleted, so no tombstones are produced.
+func (s *MongoTransactionChangeSource) Changes(ctx context.Context, after domain.ChangeCursor, until time.Time, limit int) ([]*domain.ChangeEvent, error) {
+	includeTies, afterID := after.TieBreak(domain.ChangeEntityTransaction)
+
+	lowerBound := []bson.M{
+		{"updated_at": bson.M{"$gt": after.UpdatedAt}},
+	}
+	if includeTies {
+		lowerBound = append(lowerBound, bson.M{
+			"updated_at": after.UpdatedAt,
+			"_id":        bson.M{"$gt": afterID},
+		})
+	}
+
+	filter := bson.M{
+		"$and": []bson.M{
+			{"updated_at": bson.M{"$lt": until}},
+			{"$or": lowerBound},
+		},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transaction changes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var transactions []*domain.Transaction
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction changes: %w", err)
+	}
+
+	events := make([]*domain.ChangeEvent, 0, len(transactions))
+	for _, transaction := range transactions {
+		events = append(events, &domain.ChangeEvent{
+			Entity:    domain.ChangeEntityTransaction,
+			Operation: domain.ChangeOperationFor(transaction.CreatedAt, transaction.UpdatedAt),
+			ID:        transaction.ID,
+			UpdatedAt: transaction.UpdatedAt,
+			Document:  transaction,
+		})
+	}
+
+	return events, nil
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"banking-ledger/internal/domain"
@@ -23,23 +25,30 @@ func NewPostgreSQLAccountRepository(db *sqlx.DB) domain.AccountRepository {
 	return &PostgreSQLAccountRepository{db: db}
 }
 
-// Create creates a new account
-func (r *PostgreSQLAccountRepository) Create(ctx context.Context, account *domain.Account) error {
+// Create creates a new account, recording its events in the same database
+// transaction
+func (r *PostgreSQLAccountRepository) Create(ctx context.Context, account *domain.Account, events ...*domain.AccountEvent) error {
 	if account.ID == "" {
 		account.ID = uuid.New().String()
 	}
 
-	account.CreatedAt = time.Now()
-	account.UpdatedAt = time.Now()
+	now := time.Now()
+	account.CreatedAt = now
+	account.UpdatedAt = now
 	account.Version = 1
 
 	query := `
-		INSERT INTO accounts (id, user_id, balance, currency, status, created_at, updated_at, version)
-		VALUES (:id, :user_id, :balance, :currency, :status, :created_at, :updated_at, :version)
+		INSERT INTO accounts (id, user_id, balance, initial_balance, currency, type, status, verified, overdraft_limit, held_amount, minimum_balance, max_transaction_amount, daily_outgoing_limit, low_balance_threshold, below_threshold, status_changed_at, status_changed_by, status_reason, nickname, labels, metadata, created_at, updated_at, version)
+		VALUES (:id, :user_id, :balance, :initial_balance, :currency, :type, :status, :verified, :overdraft_limit, :held_amount, :minimum_balance, :max_transaction_amount, :daily_outgoing_limit, :low_balance_threshold, :below_threshold, :status_changed_at, :status_changed_by, :status_reason, :nickname, :labels, :metadata, :created_at, :updated_at, :version)
 	`
 
-	_, err := r.db.NamedExecContext(ctx, query, account)
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExecContext(ctx, query, account); err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code {
 			case "23505": // unique_violation
@@ -49,15 +58,98 @@ func (r *PostgreSQLAccountRepository) Create(ctx context.Context, account *domai
 		return fmt.Errorf("failed to create account: %w", err)
 	}
 
+	if err := insertAccountEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account: %w", err)
+	}
+
 	return nil
 }
 
+// accountInsertBatchSize bounds the rows of each multi-row insert, keeping
+// its parameters well under the 65535 Postgres allows per statement
+const accountInsertBatchSize = 1000
+
+// CreateMany inserts the accounts with multi-row inserts in one database
+// transaction. Accounts that would repeat a user's currency and type are
+// skipped by the unique constraint rather than failing the transaction, and
+// so are their events.
+func (r *PostgreSQLAccountRepository) CreateMany(ctx context.Context, accounts []*domain.Account, events ...*domain.AccountEvent) (map[string]bool, error) {
+	now := time.Now()
+	for _, account := range accounts {
+		if account.ID == "" {
+			account.ID = uuid.New().String()
+		}
+		account.CreatedAt = now
+		account.UpdatedAt = now
+		account.Version = 1
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO accounts (id, user_id, balance, initial_balance, currency, type, status, verified, overdraft_limit, held_amount, minimum_balance, max_transaction_amount, daily_outgoing_limit, low_balance_threshold, below_threshold, status_changed_at, status_changed_by, status_reason, nickname, labels, metadata, created_at, updated_at, version)
+		VALUES (:id, :user_id, :balance, :initial_balance, :currency, :type, :status, :verified, :overdraft_limit, :held_amount, :minimum_balance, :max_transaction_amount, :daily_outgoing_limit, :low_balance_threshold, :below_threshold, :status_changed_at, :status_changed_by, :status_reason, :nickname, :labels, :metadata, :created_at, :updated_at, :version)
+		ON CONFLICT (user_id, currency, type) DO NOTHING
+		RETURNING id
+	`
+
+	created := make(map[string]bool, len(accounts))
+	for start := 0; start < len(accounts); start += accountInsertBatchSize {
+		end := start + accountInsertBatchSize
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+
+		rows, err := sqlx.NamedQueryContext(ctx, tx, query, accounts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create accounts: %w", err)
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan created account: %w", err)
+			}
+			created[id] = true
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to create accounts: %w", err)
+		}
+		rows.Close()
+	}
+
+	recorded := make([]*domain.AccountEvent, 0, len(events))
+	for _, event := range events {
+		if created[event.AccountID] {
+			recorded = append(recorded, event)
+		}
+	}
+	if err := insertAccountEvents(ctx, tx, recorded); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit accounts: %w", err)
+	}
+
+	return created, nil
+}
+
 // GetByID retrieves an account by ID
 func (r *PostgreSQLAccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
 	var account domain.Account
 
 	query := `
-		SELECT id, user_id, balance, currency, status, created_at, updated_at, version
+		SELECT id, user_id, balance, initial_balance, currency, type, status, verified, overdraft_limit, held_amount, minimum_balance, max_transaction_amount, daily_outgoing_limit, low_balance_threshold, below_threshold, status_changed_at, status_changed_by, status_reason, nickname, labels, metadata, created_at, updated_at, version
 		FROM accounts
 		WHERE id = $1
 	`
@@ -78,7 +170,7 @@ func (r *PostgreSQLAccountRepository) GetByUserID(ctx context.Context, userID st
 	var accounts []*domain.Account
 
 	query := `
-		SELECT id, user_id, balance, currency, status, created_at, updated_at, version
+		SELECT id, user_id, balance, initial_balance, currency, type, status, verified, overdraft_limit, held_amount, minimum_balance, max_transaction_amount, daily_outgoing_limit, low_balance_threshold, below_threshold, status_changed_at, status_changed_by, status_reason, nickname, labels, metadata, created_at, updated_at, version
 		FROM accounts
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -92,18 +184,30 @@ func (r *PostgreSQLAccountRepository) GetByUserID(ctx context.Context, userID st
 	return accounts, nil
 }
 
-// Update updates an account
-func (r *PostgreSQLAccountRepository) Update(ctx context.Context, account *domain.Account) error {
+// Update updates an account, recording its events in the same database
+// transaction
+func (r *PostgreSQLAccountRepository) Update(ctx context.Context, account *domain.Account, events ...*domain.AccountEvent) error {
 	account.UpdatedAt = time.Now()
 
 	query := `
 		UPDATE accounts
-		SET user_id = :user_id, balance = :balance, currency = :currency, 
-		    status = :status, updated_at = :updated_at, version = version + 1
+		SET user_id = :user_id, balance = :balance, currency = :currency,
+		    type = :type, status = :status, verified = :verified, overdraft_limit = :overdraft_limit,
+		    held_amount = :held_amount, minimum_balance = :minimum_balance, max_transaction_amount = :max_transaction_amount,
+		    daily_outgoing_limit = :daily_outgoing_limit, low_balance_threshold = :low_balance_threshold,
+		    status_changed_at = :status_changed_at, status_changed_by = :status_changed_by, status_reason = :status_reason,
+		    nickname = :nickname, labels = :labels, metadata = :metadata,
+		    updated_at = :updated_at, version = version + 1
 		WHERE id = :id AND version = :version
 	`
 
-	result, err := r.db.NamedExecContext(ctx, query, account)
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.NamedExecContext(ctx, query, account)
 	if err != nil {
 		return fmt.Errorf("failed to update account: %w", err)
 	}
@@ -117,12 +221,20 @@ func (r *PostgreSQLAccountRepository) Update(ctx context.Context, account *domai
 		return domain.ErrConcurrentUpdate
 	}
 
+	if err := insertAccountEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account: %w", err)
+	}
+
 	account.Version++
 	return nil
 }
 
 // UpdateBalance updates account balance with optimistic locking
-func (r *PostgreSQLAccountRepository) UpdateBalance(ctx context.Context, id string, newBalance float64, version int64) error {
+func (r *PostgreSQLAccountRepository) UpdateBalance(ctx context.Context, id string, newBalance domain.Money, version int64) error {
 	query := `
 		UPDATE accounts
 		SET balance = $1, updated_at = $2, version = version + 1
@@ -146,11 +258,38 @@ func (r *PostgreSQLAccountRepository) UpdateBalance(ctx context.Context, id stri
 	return nil
 }
 
-// Delete deletes an account
+// SetBelowThreshold records whether an account is below its low balance
+// threshold, reporting whether that changed. The flag is not versioned, so
+// it never conflicts with balance updates.
+func (r *PostgreSQLAccountRepository) SetBelowThreshold(ctx context.Context, id string, below bool) (bool, error) {
+	query := `
+		UPDATE accounts
+		SET below_threshold = $1
+		WHERE id = $2 AND below_threshold <> $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, below, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to update account threshold flag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// Delete deletes an account, leaving a tombstone for the change feed
 func (r *PostgreSQLAccountRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM accounts WHERE id = $1`
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, `DELETE FROM accounts WHERE id = $1`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete account: %w", err)
 	}
@@ -164,24 +303,208 @@ func (r *PostgreSQLAccountRepository) Delete(ctx context.Context, id string) err
 		return domain.ErrAccountNotFound
 	}
 
+	tombstone := `
+		INSERT INTO account_tombstones (id, deleted_at)
+		VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at
+	`
+
+	if _, err := tx.ExecContext(ctx, tombstone, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to record account tombstone: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit account deletion: %w", err)
+	}
+
 	return nil
 }
 
-// List retrieves accounts with pagination
-func (r *PostgreSQLAccountRepository) List(ctx context.Context, limit, offset int) ([]*domain.Account, error) {
+// List retrieves the accounts the filter matches with pagination
+func (r *PostgreSQLAccountRepository) List(ctx context.Context, filter *domain.AccountFilter) ([]*domain.Account, error) {
 	var accounts []*domain.Account
 
+	where, args := accountFilterClause(filter)
+	args = append(args, filter.Limit, filter.Offset)
 	query := `
-		SELECT id, user_id, balance, currency, status, created_at, updated_at, version
+		SELECT id, user_id, balance, initial_balance, currency, type, status, verified, overdraft_limit, held_amount, minimum_balance, max_transaction_amount, daily_outgoing_limit, low_balance_threshold, below_threshold, status_changed_at, status_changed_by, status_reason, nickname, labels, metadata, created_at, updated_at, version
 		FROM accounts
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+	` + where + fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	err := r.db.SelectContext(ctx, &accounts, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// CountAccounts counts the accounts the filter matches, ignoring its limit
+// and offset
+func (r *PostgreSQLAccountRepository) CountAccounts(ctx context.Context, filter *domain.AccountFilter) (int64, error) {
+	var count int64
+
+	where, args := accountFilterClause(filter)
+	query := "SELECT COUNT(*) FROM accounts " + where
+
+	if err := r.db.GetContext(ctx, &count, query, args...); err != nil {
+		return 0, fmt.Errorf("failed to count accounts: %w", err)
+	}
+
+	return count, nil
+}
+
+// accountFilterClause builds the WHERE clause List and CountAccounts share,
+// binding each filter value as a parameter
+func accountFilterClause(filter *domain.AccountFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+	switch {
+	case filter.Status != "":
+		where("status = $%d", filter.Status)
+	case !filter.IncludeClosed:
+		where("status <> $%d", domain.AccountStatusClosed)
+	}
+	if filter.Currency != "" {
+		where("currency = $%d", filter.Currency)
+	}
+	if filter.UserID != "" {
+		where("user_id = $%d", filter.UserID)
+	}
+	if filter.UserIDPrefix != "" {
+		where("user_id LIKE $%d", likePrefix.Replace(filter.UserIDPrefix)+"%")
+	}
+	if filter.Label != "" {
+		where("labels @> jsonb_build_array($%d::text)", filter.Label)
+	}
+	if filter.CreatedFrom != nil {
+		where("created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		where("created_at <= $%d", *filter.CreatedTo)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// likePrefix escapes the characters LIKE treats specially, so that a prefix
+// matches literally
+var likePrefix = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListAfter retrieves the accounts after an ID in ID order
+func (r *PostgreSQLAccountRepository) ListAfter(ctx context.Context, afterID string, limit int) ([]*domain.Account, error) {
+	var accounts []*domain.Account
+
+	query := `
+		SELECT id, user_id, balance, initial_balance, currency, type, status, verified, overdraft_limit, held_amount, minimum_balance, max_transaction_amount, daily_outgoing_limit, low_balance_threshold, below_threshold, status_changed_at, status_changed_by, status_reason, nickname, labels, metadata, created_at, updated_at, version
+		FROM accounts
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
 	`
 
-	err := r.db.SelectContext(ctx, &accounts, query, limit, offset)
+	err := r.db.SelectContext(ctx, &accounts, query, afterID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list accounts: %w", err)
 	}
 
 	return accounts, nil
 }
+
+// SumBalances totals the balances of every account by currency
+func (r *PostgreSQLAccountRepository) SumBalances(ctx context.Context) ([]*domain.BalanceTotal, error) {
+	var totals []*domain.BalanceTotal
+
+	query := `
+		SELECT currency, SUM(balance) AS balance, SUM(initial_balance) AS initial_balance
+		FROM accounts
+		GROUP BY currency
+		ORDER BY currency
+	`
+
+	err := r.db.SelectContext(ctx, &totals, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum account balances: %w", err)
+	}
+
+	return totals, nil
+}
+
+// GetByIDs retrieves the accounts with the IDs
+func (r *PostgreSQLAccountRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Account, error) {
+	var accounts []*domain.Account
+	if len(ids) == 0 {
+		return accounts, nil
+	}
+
+	query := `
+		SELECT id, user_id, balance, initial_balance, currency, type, status, verified, overdraft_limit, held_amount, minimum_balance, max_transaction_amount, daily_outgoing_limit, low_balance_threshold, below_threshold, status_changed_at, status_changed_by, status_reason, nickname, labels, metadata, created_at, updated_at, version
+		FROM accounts
+		WHERE id = ANY($1)
+	`
+
+	err := r.db.SelectContext(ctx, &accounts, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// ListUsers groups the accounts by user, a page of users at a time, with
+// each user's balances by currency and account counts by status aggregated
+// as JSON
+func (r *PostgreSQLAccountRepository) ListUsers(ctx context.Context, filter *domain.UserFilter) ([]*domain.UserOverview, error) {
+	query := `
+		WITH page AS (
+			SELECT user_id, COUNT(*) AS account_count
+			FROM accounts
+			WHERE user_id LIKE $1
+			GROUP BY user_id
+			ORDER BY user_id
+			LIMIT $2 OFFSET $3
+		)
+		SELECT p.user_id, p.account_count,
+			(SELECT COALESCE(json_agg(json_build_object('currency', b.currency, 'balance', b.balance) ORDER BY b.currency), '[]')
+			 FROM (SELECT currency, SUM(balance) AS balance FROM accounts WHERE user_id = p.user_id GROUP BY currency) b) AS balances,
+			(SELECT COALESCE(json_object_agg(s.status, s.count), '{}')
+			 FROM (SELECT status, COUNT(*) AS count FROM accounts WHERE user_id = p.user_id GROUP BY status) s) AS statuses
+		FROM page p
+		ORDER BY p.user_id
+	`
+
+	var rows []struct {
+		UserID       string `db:"user_id"`
+		AccountCount int    `db:"account_count"`
+		Balances     []byte `db:"balances"`
+		Statuses     []byte `db:"statuses"`
+	}
+	err := r.db.SelectContext(ctx, &rows, query, likePrefix.Replace(filter.UserIDPrefix)+"%", filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	users := make([]*domain.UserOverview, len(rows))
+	for i, row := range rows {
+		user := &domain.UserOverview{UserID: row.UserID, AccountCount: row.AccountCount}
+		if err := json.Unmarshal(row.Balances, &user.Balances); err != nil {
+			return nil, fmt.Errorf("failed to decode user balances: %w", err)
+		}
+		if err := json.Unmarshal(row.Statuses, &user.Statuses); err != nil {
+			return nil, fmt.Errorf("failed to decode user statuses: %w", err)
+		}
+		users[i] = user
+	}
+
+	return users, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// accountTombstone maps an account_tombstones row
+type accountTombstone struct {
+	ID        string    `db:"id"`
+	DeletedAt time.Time `db:"deleted_at"`
+}
+
+// PostgreSQLAccountChangeSource lists account changes for the change feed
+type PostgreSQLAccountChangeSource struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLAccountChangeSource creates a new PostgreSQL account change source
+func NewPostgreSQLAccountChangeSource(db *sqlx.DB) domain.ChangeSource {
+	return &PostgreSQLAccountChangeSource{db: db}
+}
+
+// Changes returns live accounts and deletion tombstones after the cursor
+func (s *PostgreSQLAccountChangeSource) Changes(ctx context.Context, after domain.ChangeCursor, until time.Time, limit int) ([]*domain.ChangeEvent, error) {
+	includeTies, afterID := after.TieBreak(domain.ChangeEntityAccount)
+
+	var accounts []*domain.Account
+	accountsQuery := `
+		SELECT id, user_id, balance, initial_balance, currency, type, status, verified, overdraft_limit, held_amount, minimum_balance, max_transaction_amount, daily_outgoing_limit, low_balance_threshold, below_threshold, status_changed_at, status_changed_by, status_reason, nickname, labels, metadata, created_at, updated_at, version
+		FROM accounts
+		WHERE updated_at < $1
+		  AND (updated_at > $2 OR ($3 AND updated_at = $2 AND id > $4))
+		ORDER BY updated_at, id
+		LIMIT $5
+	`
+
+	err := s.db.SelectContext(ctx, &accounts, accountsQuery, until, after.UpdatedAt, includeTies, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account changes: %w", err)
+	}
+
+	var tombstones []accountTombstone
+	tombstonesQuery := `
+		SELECT id, deleted_at
+		FROM account_tombstones
+		WHERE deleted_at < $1
+		  AND (deleted_at > $2 OR ($3 AND deleted_at = $2 AND id > $4))
+		ORDER BY deleted_at, id
+		LIMIT $5
+	`
+
+	err = s.db.SelectContext(ctx, &tombstones, tombstonesQuery, until, after.UpdatedAt, includeTies, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account tombstones: %w", err)
+	}
+
+	events := make([]*domain.ChangeEvent, 0, len(accounts)+len(tombstones))
+	for _, account := range accounts {
+		events = append(events, &domain.ChangeEvent{
+			Entity:    domain.ChangeEntityAccount,
+			Operation: domain.ChangeOperationFor(account.CreatedAt, account.UpdatedAt),
+			ID:        account.ID,
+			UpdatedAt: account.UpdatedAt,
+			Document:  account,
+		})
+	}
+	for _, tombstone := range tombstones {
+		events = append(events, &domain.ChangeEvent{
+			Entity:    domain.ChangeEntityAccount,
+			Operation: domain.ChangeOperationDelete,
+			ID:        tombstone.ID,
+			UpdatedAt: tombstone.DeletedAt,
+		})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Position().Less(events[j].Position())
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events, nil
+}
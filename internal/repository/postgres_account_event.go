@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// insertAccountEvents records account events within the database transaction
+// changing their accounts, in batches of accountInsertBatchSize
+func insertAccountEvents(ctx context.Context, tx *sqlx.Tx, events []*domain.AccountEvent) error {
+	for _, event := range events {
+		if event.ID == "" {
+			event.ID = uuid.New().String()
+		}
+	}
+
+	query := `
+		INSERT INTO account_events (id, account_id, event_type, actor, reason, old_value, new_value, created_at)
+		VALUES (:id, :account_id, :event_type, :actor, :reason, :old_value, :new_value, :created_at)
+	`
+
+	for start := 0; start < len(events); start += accountInsertBatchSize {
+		end := start + accountInsertBatchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		if _, err := tx.NamedExecContext(ctx, query, events[start:end]); err != nil {
+			return fmt.Errorf("failed to record account events: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListEvents pages through an account's events, newest first
+func (r *PostgreSQLAccountRepository) ListEvents(ctx context.Context, accountID string, limit, offset int) ([]*domain.AccountEvent, error) {
+	events := []*domain.AccountEvent{}
+
+	query := `
+		SELECT id, account_id, event_type, actor, reason, old_value, new_value, created_at
+		FROM account_events
+		WHERE account_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	if err := r.db.SelectContext(ctx, &events, query, accountID, limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to list account events: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgreSQLBalanceSnapshotRepository implements the BalanceSnapshotRepository
+// interface. Days are passed as YYYY-MM-DD so that the session's time zone
+// cannot move them.
+type PostgreSQLBalanceSnapshotRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLBalanceSnapshotRepository creates a new PostgreSQL balance snapshot repository
+func NewPostgreSQLBalanceSnapshotRepository(db *sqlx.DB) domain.BalanceSnapshotRepository {
+	return &PostgreSQLBalanceSnapshotRepository{db: db}
+}
+
+// Save upserts the snapshots in one database transaction
+func (r *PostgreSQLBalanceSnapshotRepository) Save(ctx context.Context, snapshots []*domain.BalanceSnapshot) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, snapshot := range snapshots {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO balance_snapshots (account_id, as_of_date, balance, currency, created_at)
+			VALUES ($1, $2::date, $3, $4, $5)
+			ON CONFLICT (account_id, as_of_date) DO UPDATE
+			SET balance = EXCLUDED.balance, currency = EXCLUDED.currency, created_at = EXCLUDED.created_at
+		`, snapshot.AccountID, snapshot.AsOfDate.Format(domain.StatementDateLayout), snapshot.Balance, snapshot.Currency, snapshot.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save balance snapshot: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit balance snapshots: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves an account's snapshot for a day
+func (r *PostgreSQLBalanceSnapshotRepository) Get(ctx context.Context, accountID string, day time.Time) (*domain.BalanceSnapshot, error) {
+	var snapshot domain.BalanceSnapshot
+
+	query := `
+		SELECT account_id, as_of_date, balance, currency, created_at
+		FROM balance_snapshots
+		WHERE account_id = $1 AND as_of_date = $2::date
+	`
+
+	err := r.db.GetContext(ctx, &snapshot, query, accountID, day.Format(domain.StatementDateLayout))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrBalanceSnapshotNotFound
+		}
+		return nil, fmt.Errorf("failed to get balance snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// GetLatestBefore retrieves an account's last snapshot for a day before the given one
+func (r *PostgreSQLBalanceSnapshotRepository) GetLatestBefore(ctx context.Context, accountID string, day time.Time) (*domain.BalanceSnapshot, error) {
+	var snapshot domain.BalanceSnapshot
+
+	query := `
+		SELECT account_id, as_of_date, balance, currency, created_at
+		FROM balance_snapshots
+		WHERE account_id = $1 AND as_of_date < $2::date
+		ORDER BY as_of_date DESC
+		LIMIT 1
+	`
+
+	err := r.db.GetContext(ctx, &snapshot, query, accountID, day.Format(domain.StatementDateLayout))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrBalanceSnapshotNotFound
+		}
+		return nil, fmt.Errorf("failed to get balance snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// LatestDay returns the last day snapshotted
+func (r *PostgreSQLBalanceSnapshotRepository) LatestDay(ctx context.Context) (*time.Time, error) {
+	var latest sql.NullString
+	if err := r.db.GetContext(ctx, &latest, `SELECT MAX(as_of_date)::text FROM balance_snapshots`); err != nil {
+		return nil, fmt.Errorf("failed to get latest balance snapshot day: %w", err)
+	}
+	if !latest.Valid {
+		return nil, nil
+	}
+
+	day, err := time.Parse(domain.StatementDateLayout, latest.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse latest balance snapshot day: %w", err)
+	}
+	return &day, nil
+}
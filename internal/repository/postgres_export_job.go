@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// exportJobColumns lists the columns scanned into a domain.ExportJob
+const exportJobColumns = `id, account_id, user_id, format, filter, status, row_count, total_rows,
+	file_location, error, started_at, completed_at, expires_at, created_at, updated_at`
+
+// exportJobRow maps an export_jobs row, holding the filter as JSON
+type exportJobRow struct {
+	ID           string                 `db:"id"`
+	AccountID    string                 `db:"account_id"`
+	UserID       string                 `db:"user_id"`
+	Format       string                 `db:"format"`
+	Filter       []byte                 `db:"filter"`
+	Status       domain.ExportJobStatus `db:"status"`
+	RowCount     int64                  `db:"row_count"`
+	TotalRows    *int64                 `db:"total_rows"`
+	FileLocation string                 `db:"file_location"`
+	Error        string                 `db:"error"`
+	StartedAt    *time.Time             `db:"started_at"`
+	CompletedAt  *time.Time             `db:"completed_at"`
+	ExpiresAt    *time.Time             `db:"expires_at"`
+	CreatedAt    time.Time              `db:"created_at"`
+	UpdatedAt    time.Time              `db:"updated_at"`
+}
+
+func (row *exportJobRow) toDomain() (*domain.ExportJob, error) {
+	job := &domain.ExportJob{
+		ID:           row.ID,
+		AccountID:    row.AccountID,
+		UserID:       row.UserID,
+		Format:       row.Format,
+		Status:       row.Status,
+		RowCount:     row.RowCount,
+		TotalRows:    row.TotalRows,
+		FileLocation: row.FileLocation,
+		Error:        row.Error,
+		StartedAt:    row.StartedAt,
+		CompletedAt:  row.CompletedAt,
+		ExpiresAt:    row.ExpiresAt,
+		CreatedAt:    row.CreatedAt,
+		UpdatedAt:    row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Filter, &job.Filter); err != nil {
+		return nil, fmt.Errorf("failed to decode export job filter: %w", err)
+	}
+	return job, nil
+}
+
+// PostgreSQLExportJobRepository implements the ExportJobRepository interface
+type PostgreSQLExportJobRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLExportJobRepository creates a new PostgreSQL export job
+// repository
+func NewPostgreSQLExportJobRepository(db *sqlx.DB) domain.ExportJobRepository {
+	return &PostgreSQLExportJobRepository{db: db}
+}
+
+// Create creates a new export job
+func (r *PostgreSQLExportJobRepository) Create(ctx context.Context, job *domain.ExportJob) error {
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = time.Now()
+
+	filter, err := json.Marshal(job.Filter)
+	if err != nil {
+		return fmt.Errorf("failed to encode export job filter: %w", err)
+	}
+
+	query := `
+		INSERT INTO export_jobs (
+			id, account_id, user_id, format, filter, status, row_count, total_rows,
+			file_location, error, started_at, completed_at, expires_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+
+	_, err = r.db.ExecContext(ctx, query,
+		job.ID, job.AccountID, job.UserID, job.Format, filter, job.Status, job.RowCount, job.TotalRows,
+		job.FileLocation, job.Error, job.StartedAt, job.CompletedAt, job.ExpiresAt, job.CreatedAt, job.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves an export job by ID
+func (r *PostgreSQLExportJobRepository) Get(ctx context.Context, id string) (*domain.ExportJob, error) {
+	var row exportJobRow
+
+	query := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &row, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrExportJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+
+	return row.toDomain()
+}
+
+// Claim marks a queued job, or a running one left stale, as running and
+// returns it. The row is updated in one statement, so only one worker claims
+// a job.
+func (r *PostgreSQLExportJobRepository) Claim(ctx context.Context, id string, now, staleBefore time.Time) (*domain.ExportJob, error) {
+	var row exportJobRow
+
+	query := `
+		UPDATE export_jobs
+		SET status = 'running', row_count = 0, started_at = $2, updated_at = $2
+		WHERE id = $1 AND (status = 'queued' OR (status = 'running' AND updated_at < $3))
+		RETURNING ` + exportJobColumns
+
+	err := r.db.GetContext(ctx, &row, query, id, now, staleBefore)
+	if err == sql.ErrNoRows {
+		if _, err := r.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim export job: %w", err)
+	}
+
+	return row.toDomain()
+}
+
+// UpdateProgress records a running job's rows written and total
+func (r *PostgreSQLExportJobRepository) UpdateProgress(ctx context.Context, job *domain.ExportJob) error {
+	job.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE export_jobs
+		SET row_count = $1, total_rows = $2, updated_at = $3
+		WHERE id = $4 AND status = 'running'
+	`
+
+	_, err := r.db.ExecContext(ctx, query, job.RowCount, job.TotalRows, job.UpdatedAt, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update export job progress: %w", err)
+	}
+
+	return nil
+}
+
+// Finish records a job's outcome
+func (r *PostgreSQLExportJobRepository) Finish(ctx context.Context, job *domain.ExportJob) error {
+	job.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE export_jobs
+		SET status = $1, row_count = $2, total_rows = $3, file_location = $4, error = $5,
+			completed_at = $6, expires_at = $7, updated_at = $8
+		WHERE id = $9
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		job.Status, job.RowCount, job.TotalRows, job.FileLocation, job.Error,
+		job.CompletedAt, job.ExpiresAt, job.UpdatedAt, job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish export job: %w", err)
+	}
+
+	return nil
+}
+
+// Cancel cancels a queued job
+func (r *PostgreSQLExportJobRepository) Cancel(ctx context.Context, id string) (*domain.ExportJob, error) {
+	var row exportJobRow
+
+	query := `
+		UPDATE export_jobs
+		SET status = 'cancelled', updated_at = NOW()
+		WHERE id = $1 AND status = 'queued'
+		RETURNING ` + exportJobColumns
+
+	err := r.db.GetContext(ctx, &row, query, id)
+	if err == sql.ErrNoRows {
+		if _, err := r.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrExportJobNotCancellable
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel export job: %w", err)
+	}
+
+	return row.toDomain()
+}
+
+// ListExpired lists completed jobs whose files expire by now, oldest first
+func (r *PostgreSQLExportJobRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*domain.ExportJob, error) {
+	var rows []exportJobRow
+
+	query := `
+		SELECT ` + exportJobColumns + `
+		FROM export_jobs
+		WHERE status = 'completed' AND expires_at <= $1
+		ORDER BY expires_at
+		LIMIT $2
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired export jobs: %w", err)
+	}
+
+	jobs := make([]*domain.ExportJob, 0, len(rows))
+	for i := range rows {
+		job, err := rows[i].toDomain()
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// Expire marks a completed job expired and forgets its file
+func (r *PostgreSQLExportJobRepository) Expire(ctx context.Context, id string) error {
+	query := `
+		UPDATE export_jobs
+		SET status = 'expired', file_location = '', updated_at = NOW()
+		WHERE id = $1 AND status = 'completed'
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to expire export job: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgreSQLHoldRepository implements the HoldRepository interface. Holds
+// live beside accounts so a hold and the account's held amount change together.
+type PostgreSQLHoldRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLHoldRepository creates a new PostgreSQL hold repository
+func NewPostgreSQLHoldRepository(db *sqlx.DB) domain.HoldRepository {
+	return &PostgreSQLHoldRepository{db: db}
+}
+
+// Place records the hold and reserves its amount on the account in one
+// database transaction
+func (r *PostgreSQLHoldRepository) Place(ctx context.Context, hold *domain.Hold, account *domain.Account) error {
+	if hold.ID == "" {
+		hold.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	hold.CreatedAt = now
+	hold.UpdatedAt = now
+	hold.Version = 1
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET held_amount = held_amount + $1, updated_at = $2, version = version + 1
+		WHERE id = $3 AND version = $4
+	`, hold.Amount, now, account.ID, account.Version)
+	if err := checkUpdated(result, err, "failed to reserve held amount"); err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+		INSERT INTO holds (id, account_id, amount, captured_amount, currency, status, description,
+			reference, expires_at, created_at, updated_at, version)
+		VALUES (:id, :account_id, :amount, :captured_amount, :currency, :status, :description,
+			:reference, :expires_at, :created_at, :updated_at, :version)
+	`, hold)
+	if err != nil {
+		return fmt.Errorf("failed to create hold: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit hold: %w", err)
+	}
+
+	account.HeldAmount += hold.Amount
+	account.Version++
+	return nil
+}
+
+// GetByID retrieves a hold by ID
+func (r *PostgreSQLHoldRepository) GetByID(ctx context.Context, id string) (*domain.Hold, error) {
+	var hold domain.Hold
+
+	query := `
+		SELECT id, account_id, amount, captured_amount, currency, status, description,
+			reference, expires_at, created_at, updated_at, version
+		FROM holds
+		WHERE id = $1
+	`
+
+	err := r.db.GetContext(ctx, &hold, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrHoldNotFound
+		}
+		return nil, fmt.Errorf("failed to get hold: %w", err)
+	}
+
+	return &hold, nil
+}
+
+// Resolve closes an active hold and releases its amount from the account,
+// applying the capture posting if there is one, in one database transaction
+func (r *PostgreSQLHoldRepository) Resolve(ctx context.Context, hold *domain.Hold, account *domain.Account, posting domain.LedgerPosting) error {
+	now := time.Now()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE holds
+		SET status = $1, captured_amount = $2, updated_at = $3, version = version + 1
+		WHERE id = $4 AND version = $5 AND status = $6
+	`, hold.Status, hold.CapturedAmount, now, hold.ID, hold.Version, domain.HoldStatusActive)
+	if err := checkUpdated(result, err, "failed to resolve hold"); err != nil {
+		return err
+	}
+
+	if len(posting) > 0 {
+		if err := postEntries(ctx, tx, posting, -hold.Amount); err != nil {
+			return err
+		}
+	} else {
+		result, err = tx.ExecContext(ctx, `
+			UPDATE accounts
+			SET held_amount = held_amount - $1, updated_at = $2, version = version + 1
+			WHERE id = $3 AND version = $4
+		`, hold.Amount, now, account.ID, account.Version)
+		if err := checkUpdated(result, err, "failed to release held amount"); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit hold resolution: %w", err)
+	}
+
+	hold.UpdatedAt = now
+	hold.Version++
+	return nil
+}
+
+// ListExpiredActive lists active holds whose window has closed
+func (r *PostgreSQLHoldRepository) ListExpiredActive(ctx context.Context, now time.Time, limit int) ([]*domain.Hold, error) {
+	var holds []*domain.Hold
+
+	query := `
+		SELECT id, account_id, amount, captured_amount, currency, status, description,
+			reference, expires_at, created_at, updated_at, version
+		FROM holds
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+	`
+
+	err := r.db.SelectContext(ctx, &holds, query, domain.HoldStatusActive, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired holds: %w", err)
+	}
+
+	return holds, nil
+}
+
+// checkUpdated turns an optimistic update that matched no rows into
+// ErrConcurrentUpdate
+func checkUpdated(result sql.Result, err error, message string) error {
+	if err != nil {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrConcurrentUpdate
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgreSQLLedgerEntryRepository implements the LedgerEntryRepository
+// interface. Entries live beside accounts so they commit with the balances.
+type PostgreSQLLedgerEntryRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLLedgerEntryRepository creates a new PostgreSQL ledger entry repository
+func NewPostgreSQLLedgerEntryRepository(db *sqlx.DB) domain.LedgerEntryRepository {
+	return &PostgreSQLLedgerEntryRepository{db: db}
+}
+
+// Post writes the entries and their resulting balances in one database transaction
+func (r *PostgreSQLLedgerEntryRepository) Post(ctx context.Context, posting domain.LedgerPosting) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := postEntries(ctx, tx, posting, 0); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ledger posting: %w", err)
+	}
+
+	return nil
+}
+
+// postEntries marks the posting's transactions applied, writes the entries and
+// moves each account to its entry's resulting balance within tx, adjusting the
+// accounts' held amounts by heldDelta as well
+func postEntries(ctx context.Context, tx *sqlx.Tx, posting domain.LedgerPosting, heldDelta domain.Money) error {
+	now := time.Now()
+	if err := markApplied(ctx, tx, posting, now); err != nil {
+		return err
+	}
+
+	for _, entry := range posting {
+		entry.CreatedAt = now
+
+		result, err := tx.ExecContext(ctx, `
+			UPDATE accounts
+			SET balance = $1, held_amount = held_amount + $2, updated_at = $3, version = $4
+			WHERE id = $5 AND version = $6
+		`, entry.ResultingBalance, heldDelta, now, entry.AccountVersion, entry.AccountID, entry.AccountVersion-1)
+		if err != nil {
+			return fmt.Errorf("failed to update account balance: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return domain.ErrConcurrentUpdate
+		}
+
+		_, err = tx.NamedExecContext(ctx, `
+			INSERT INTO ledger_entries (id, account_id, transaction_id, direction, amount, currency,
+				resulting_balance, account_version, created_at)
+			VALUES (:id, :account_id, :transaction_id, :direction, :amount, :currency,
+				:resulting_balance, :account_version, :created_at)
+		`, entry)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return domain.ErrTransactionAlreadyProcessed
+			}
+			return fmt.Errorf("failed to create ledger entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// markApplied records each of the posting's transactions as applied, failing
+// with ErrTransactionAlreadyProcessed if any has been posted before
+func markApplied(ctx context.Context, tx *sqlx.Tx, posting domain.LedgerPosting, now time.Time) error {
+	marked := make(map[string]bool)
+	for _, entry := range posting {
+		if marked[entry.TransactionID] {
+			continue
+		}
+		marked[entry.TransactionID] = true
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO applied_transactions (transaction_id, applied_at)
+			VALUES ($1, $2)
+			ON CONFLICT (transaction_id) DO NOTHING
+		`, entry.TransactionID, now)
+		if err != nil {
+			return fmt.Errorf("failed to mark transaction applied: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return domain.ErrTransactionAlreadyProcessed
+		}
+	}
+
+	return nil
+}
+
+// IsApplied reports whether a transaction's entries have been posted
+func (r *PostgreSQLLedgerEntryRepository) IsApplied(ctx context.Context, transactionID string) (bool, error) {
+	var applied bool
+	query := `SELECT EXISTS (SELECT 1 FROM applied_transactions WHERE transaction_id = $1)`
+	if err := r.db.GetContext(ctx, &applied, query, transactionID); err != nil {
+		return false, fmt.Errorf("failed to check applied transaction: %w", err)
+	}
+	return applied, nil
+}
+
+// GetByAccountID lists an account's entries, newest first
+func (r *PostgreSQLLedgerEntryRepository) GetByAccountID(ctx context.Context, accountID string, limit, offset int) ([]*domain.LedgerEntry, error) {
+	var entries []*domain.LedgerEntry
+
+	query := `
+		SELECT id, account_id, transaction_id, direction, amount, currency,
+			resulting_balance, account_version, created_at
+		FROM ledger_entries
+		WHERE account_id = $1
+		ORDER BY account_version DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	err := r.db.SelectContext(ctx, &entries, query, accountID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// BalancesAt returns the resulting balance of each account's last entry before at
+func (r *PostgreSQLLedgerEntryRepository) BalancesAt(ctx context.Context, accountIDs []string, at time.Time) (map[string]domain.Money, error) {
+	balances := make(map[string]domain.Money)
+	if len(accountIDs) == 0 {
+		return balances, nil
+	}
+
+	var rows []struct {
+		AccountID        string       `db:"account_id"`
+		ResultingBalance domain.Money `db:"resulting_balance"`
+	}
+	query := `
+		SELECT DISTINCT ON (account_id) account_id, resulting_balance
+		FROM ledger_entries
+		WHERE account_id = ANY($1) AND created_at < $2
+		ORDER BY account_id, account_version DESC
+	`
+	if err := r.db.SelectContext(ctx, &rows, query, pq.Array(accountIDs), at); err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	for _, row := range rows {
+		balances[row.AccountID] = row.ResultingBalance
+	}
+	return balances, nil
+}
+
+// SumSince returns the net change of an account's entries since a time
+func (r *PostgreSQLLedgerEntryRepository) SumSince(ctx context.Context, accountID string, since time.Time) (domain.Money, error) {
+	var sum domain.Money
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)
+		FROM ledger_entries
+		WHERE account_id = $1 AND created_at >= $2
+	`
+	if err := r.db.GetContext(ctx, &sum, query, accountID, since); err != nil {
+		return 0, fmt.Errorf("failed to sum ledger entries: %w", err)
+	}
+	return sum, nil
+}
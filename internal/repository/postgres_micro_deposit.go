@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// PostgreSQLMicroDepositRepository implements the MicroDepositRepository interface
+type PostgreSQLMicroDepositRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLMicroDepositRepository creates a new PostgreSQL micro-deposit repository
+func NewPostgreSQLMicroDepositRepository(db *sqlx.DB) domain.MicroDepositRepository {
+	return &PostgreSQLMicroDepositRepository{db: db}
+}
+
+// Create creates a new micro-deposit challenge
+func (r *PostgreSQLMicroDepositRepository) Create(ctx context.Context, challenge *domain.MicroDepositChallenge) error {
+	if challenge.ID == "" {
+		challenge.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	challenge.CreatedAt = now
+	challenge.UpdatedAt = now
+
+	query := `
+		INSERT INTO micro_deposit_challenges (id, account_id, amount_one, amount_two, currency, status,
+			attempts, expires_at, clawed_back_at, created_at, updated_at)
+		VALUES (:id, :account_id, :amount_one, :amount_two, :currency, :status,
+			:attempts, :expires_at, :clawed_back_at, :created_at, :updated_at)
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, challenge)
+	if err != nil {
+		// The partial unique index allows one pending challenge per account
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return domain.ErrMicroDepositPending
+		}
+		return fmt.Errorf("failed to create micro-deposit challenge: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingByAccountID retrieves an account's pending challenge
+func (r *PostgreSQLMicroDepositRepository) GetPendingByAccountID(ctx context.Context, accountID string) (*domain.MicroDepositChallenge, error) {
+	var challenge domain.MicroDepositChallenge
+
+	query := `
+		SELECT id, account_id, amount_one, amount_two, currency, status, attempts,
+			expires_at, clawed_back_at, created_at, updated_at
+		FROM micro_deposit_challenges
+		WHERE account_id = $1 AND status = $2
+	`
+
+	err := r.db.GetContext(ctx, &challenge, query, accountID, domain.MicroDepositStatusPending)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrMicroDepositNotFound
+		}
+		return nil, fmt.Errorf("failed to get micro-deposit challenge: %w", err)
+	}
+
+	return &challenge, nil
+}
+
+// RecordAttempt counts a confirmation attempt against a pending challenge
+// and returns the new attempt count
+func (r *PostgreSQLMicroDepositRepository) RecordAttempt(ctx context.Context, id string) (int, error) {
+	var attempts int
+
+	query := `
+		UPDATE micro_deposit_challenges
+		SET attempts = attempts + 1, updated_at = $1
+		WHERE id = $2 AND status = $3
+		RETURNING attempts
+	`
+
+	err := r.db.GetContext(ctx, &attempts, query, time.Now(), id, domain.MicroDepositStatusPending)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domain.ErrMicroDepositNotFound
+		}
+		return 0, fmt.Errorf("failed to record micro-deposit attempt: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// Resolve moves a pending challenge to a final status. Only one caller can
+// resolve a challenge; later callers get ErrMicroDepositNotFound.
+func (r *PostgreSQLMicroDepositRepository) Resolve(ctx context.Context, id string, status domain.MicroDepositStatus) error {
+	query := `
+		UPDATE micro_deposit_challenges
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`
+
+	result, err := r.db.ExecContext(ctx, query, status, time.Now(), id, domain.MicroDepositStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to resolve micro-deposit challenge: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrMicroDepositNotFound
+	}
+
+	return nil
+}
+
+// MarkClawedBack records when a challenge's credits were reversed
+func (r *PostgreSQLMicroDepositRepository) MarkClawedBack(ctx context.Context, id string, at time.Time) error {
+	query := `
+		UPDATE micro_deposit_challenges
+		SET clawed_back_at = $1, updated_at = $2
+		WHERE id = $3 AND clawed_back_at IS NULL
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, at, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to mark micro-deposit clawback: %w", err)
+	}
+
+	return nil
+}
+
+// ListExpiredPending lists pending challenges whose window has closed
+func (r *PostgreSQLMicroDepositRepository) ListExpiredPending(ctx context.Context, now time.Time, limit int) ([]*domain.MicroDepositChallenge, error) {
+	var challenges []*domain.MicroDepositChallenge
+
+	query := `
+		SELECT id, account_id, amount_one, amount_two, currency, status, attempts,
+			expires_at, clawed_back_at, created_at, updated_at
+		FROM micro_deposit_challenges
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+	`
+
+	err := r.db.SelectContext(ctx, &challenges, query, domain.MicroDepositStatusPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired micro-deposit challenges: %w", err)
+	}
+
+	return challenges, nil
+}
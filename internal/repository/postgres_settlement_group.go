@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// settlementGroupRow maps a settlement_groups row, scanning the account ID
+// array through pq.StringArray
+type settlementGroupRow struct {
+	ID         string         `db:"id"`
+	Name       string         `db:"name"`
+	AccountIDs pq.StringArray `db:"account_ids"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+func (row *settlementGroupRow) toDomain() *domain.SettlementGroup {
+	return &domain.SettlementGroup{
+		ID:         row.ID,
+		Name:       row.Name,
+		AccountIDs: []string(row.AccountIDs),
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+}
+
+// PostgreSQLSettlementGroupRepository implements the SettlementGroupRepository interface
+type PostgreSQLSettlementGroupRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLSettlementGroupRepository creates a new PostgreSQL settlement group repository
+func NewPostgreSQLSettlementGroupRepository(db *sqlx.DB) domain.SettlementGroupRepository {
+	return &PostgreSQLSettlementGroupRepository{db: db}
+}
+
+// Create creates a new settlement group
+func (r *PostgreSQLSettlementGroupRepository) Create(ctx context.Context, group *domain.SettlementGroup) error {
+	if group.ID == "" {
+		group.ID = uuid.New().String()
+	}
+
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO settlement_groups (id, name, account_ids, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, group.ID, group.Name, pq.Array(group.AccountIDs), group.CreatedAt, group.UpdatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return domain.ErrInvalidSettlementGroup
+		}
+		return fmt.Errorf("failed to create settlement group: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a settlement group by ID
+func (r *PostgreSQLSettlementGroupRepository) GetByID(ctx context.Context, id string) (*domain.SettlementGroup, error) {
+	var row settlementGroupRow
+
+	query := `
+		SELECT id, name, account_ids, created_at, updated_at
+		FROM settlement_groups
+		WHERE id = $1
+	`
+
+	err := r.db.GetContext(ctx, &row, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrSettlementGroupNotFound
+		}
+		return nil, fmt.Errorf("failed to get settlement group: %w", err)
+	}
+
+	return row.toDomain(), nil
+}
+
+// List retrieves all settlement groups
+func (r *PostgreSQLSettlementGroupRepository) List(ctx context.Context) ([]*domain.SettlementGroup, error) {
+	var rows []settlementGroupRow
+
+	query := `
+		SELECT id, name, account_ids, created_at, updated_at
+		FROM settlement_groups
+		ORDER BY name
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settlement groups: %w", err)
+	}
+
+	groups := make([]*domain.SettlementGroup, 0, len(rows))
+	for i := range rows {
+		groups = append(groups, rows[i].toDomain())
+	}
+
+	return groups, nil
+}
+
+// Update updates a settlement group's name and accounts
+func (r *PostgreSQLSettlementGroupRepository) Update(ctx context.Context, group *domain.SettlementGroup) error {
+	group.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE settlement_groups
+		SET name = $1, account_ids = $2, updated_at = $3
+		WHERE id = $4
+	`
+
+	result, err := r.db.ExecContext(ctx, query, group.Name, pq.Array(group.AccountIDs), group.UpdatedAt, group.ID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return domain.ErrInvalidSettlementGroup
+		}
+		return fmt.Errorf("failed to update settlement group: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSettlementGroupNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// standingOrderColumns lists the columns scanned into a standingOrderRow
+const standingOrderColumns = `id, from_account_id, to_account_id, amount, currency, description,
+	frequency, run_interval, start_at, end_at, run_count, next_run_at, status, transaction_ids,
+	created_at, updated_at`
+
+// standingOrderRow maps a standing_orders row, scanning the transaction ID
+// array through pq.StringArray
+type standingOrderRow struct {
+	ID             string                        `db:"id"`
+	FromAccountID  string                        `db:"from_account_id"`
+	ToAccountID    string                        `db:"to_account_id"`
+	Amount         domain.Money                  `db:"amount"`
+	Currency       string                        `db:"currency"`
+	Description    string                        `db:"description"`
+	Frequency      domain.StandingOrderFrequency `db:"frequency"`
+	Interval       int                           `db:"run_interval"`
+	StartAt        time.Time                     `db:"start_at"`
+	EndAt          *time.Time                    `db:"end_at"`
+	RunCount       int                           `db:"run_count"`
+	NextRunAt      time.Time                     `db:"next_run_at"`
+	Status         domain.StandingOrderStatus    `db:"status"`
+	TransactionIDs pq.StringArray                `db:"transaction_ids"`
+	CreatedAt      time.Time                     `db:"created_at"`
+	UpdatedAt      time.Time                     `db:"updated_at"`
+}
+
+func (row *standingOrderRow) toDomain() *domain.StandingOrder {
+	return &domain.StandingOrder{
+		ID:             row.ID,
+		FromAccountID:  row.FromAccountID,
+		ToAccountID:    row.ToAccountID,
+		Amount:         row.Amount,
+		Currency:       row.Currency,
+		Description:    row.Description,
+		Frequency:      row.Frequency,
+		Interval:       row.Interval,
+		StartAt:        row.StartAt,
+		EndAt:          row.EndAt,
+		RunCount:       row.RunCount,
+		NextRunAt:      row.NextRunAt,
+		Status:         row.Status,
+		TransactionIDs: []string(row.TransactionIDs),
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+}
+
+// PostgreSQLStandingOrderRepository implements the StandingOrderRepository interface
+type PostgreSQLStandingOrderRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLStandingOrderRepository creates a new PostgreSQL standing order repository
+func NewPostgreSQLStandingOrderRepository(db *sqlx.DB) domain.StandingOrderRepository {
+	return &PostgreSQLStandingOrderRepository{db: db}
+}
+
+// Create creates a new standing order
+func (r *PostgreSQLStandingOrderRepository) Create(ctx context.Context, order *domain.StandingOrder) error {
+	if order.ID == "" {
+		order.ID = uuid.New().String()
+	}
+	if order.TransactionIDs == nil {
+		order.TransactionIDs = []string{}
+	}
+
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO standing_orders (id, from_account_id, to_account_id, amount, currency, description,
+			frequency, run_interval, start_at, end_at, run_count, next_run_at, status, transaction_ids,
+			created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		order.ID, order.FromAccountID, order.ToAccountID, order.Amount, order.Currency, order.Description,
+		order.Frequency, order.Interval, order.StartAt, order.EndAt, order.RunCount, order.NextRunAt,
+		order.Status, pq.Array(order.TransactionIDs), order.CreatedAt, order.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create standing order: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a standing order by ID
+func (r *PostgreSQLStandingOrderRepository) GetByID(ctx context.Context, id string) (*domain.StandingOrder, error) {
+	var row standingOrderRow
+
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &row, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrStandingOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get standing order: %w", err)
+	}
+
+	return row.toDomain(), nil
+}
+
+// List retrieves the standing orders of an account, or all of them
+func (r *PostgreSQLStandingOrderRepository) List(ctx context.Context, accountID string) ([]*domain.StandingOrder, error) {
+	var rows []standingOrderRow
+
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE $1 = '' OR from_account_id = $1 OR to_account_id = $1
+		ORDER BY created_at DESC
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list standing orders: %w", err)
+	}
+
+	return toStandingOrders(rows), nil
+}
+
+// Update saves a standing order's amount, description and status unless the
+// order has been cancelled or completed meanwhile
+func (r *PostgreSQLStandingOrderRepository) Update(ctx context.Context, order *domain.StandingOrder) error {
+	order.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE standing_orders
+		SET amount = $1, description = $2, status = $3, updated_at = $4
+		WHERE id = $5 AND status NOT IN ($6, $7)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, order.Amount, order.Description, order.Status, order.UpdatedAt,
+		order.ID, domain.StandingOrderStatusCompleted, domain.StandingOrderStatusCancelled)
+	if err != nil {
+		return fmt.Errorf("failed to update standing order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		if _, err := r.GetByID(ctx, order.ID); err != nil {
+			return err
+		}
+		return domain.ErrStandingOrderClosed
+	}
+
+	return nil
+}
+
+// ListDue lists active standing orders whose next run has come, earliest first
+func (r *PostgreSQLStandingOrderRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.StandingOrder, error) {
+	var rows []standingOrderRow
+
+	query := `
+		SELECT ` + standingOrderColumns + `
+		FROM standing_orders
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at
+		LIMIT $3
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query, domain.StandingOrderStatusActive, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due standing orders: %w", err)
+	}
+
+	return toStandingOrders(rows), nil
+}
+
+// Claim saves an advanced schedule for an order still due on the run it was
+// listed with
+func (r *PostgreSQLStandingOrderRepository) Claim(ctx context.Context, order *domain.StandingOrder, previousRunAt, now time.Time) error {
+	order.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE standing_orders
+		SET run_count = $1, next_run_at = $2, status = $3, updated_at = $4
+		WHERE id = $5 AND status = $6 AND next_run_at = $7 AND next_run_at <= $8
+	`, order.RunCount, order.NextRunAt, order.Status, order.UpdatedAt,
+		order.ID, domain.StandingOrderStatusActive, previousRunAt, now)
+
+	return checkUpdated(result, err, "failed to claim standing order")
+}
+
+// AppendTransaction records a transaction submitted by a standing order
+func (r *PostgreSQLStandingOrderRepository) AppendTransaction(ctx context.Context, id, transactionID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE standing_orders
+		SET transaction_ids = array_append(transaction_ids, $1), updated_at = $2
+		WHERE id = $3
+	`, transactionID, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to record standing order transaction: %w", err)
+	}
+
+	return nil
+}
+
+func toStandingOrders(rows []standingOrderRow) []*domain.StandingOrder {
+	orders := make([]*domain.StandingOrder, 0, len(rows))
+	for i := range rows {
+		orders = append(orders, rows[i].toDomain())
+	}
+	return orders
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgreSQLUserVerificationRepository implements the
+// UserVerificationRepository interface
+type PostgreSQLUserVerificationRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLUserVerificationRepository creates a new PostgreSQL user verification repository
+func NewPostgreSQLUserVerificationRepository(db *sqlx.DB) domain.UserVerificationRepository {
+	return &PostgreSQLUserVerificationRepository{db: db}
+}
+
+// Get retrieves a user's verification, defaulting to unverified
+func (r *PostgreSQLUserVerificationRepository) Get(ctx context.Context, userID string) (*domain.UserVerification, error) {
+	var verification domain.UserVerification
+
+	query := `
+		SELECT user_id, level, updated_at
+		FROM user_verifications
+		WHERE user_id = $1
+	`
+
+	err := r.db.GetContext(ctx, &verification, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &domain.UserVerification{UserID: userID, Level: domain.VerificationLevelUnverified}, nil
+		}
+		return nil, fmt.Errorf("failed to get user verification: %w", err)
+	}
+
+	return &verification, nil
+}
+
+// Set upserts a user's verification level
+func (r *PostgreSQLUserVerificationRepository) Set(ctx context.Context, verification *domain.UserVerification) error {
+	verification.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO user_verifications (user_id, level, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET level = EXCLUDED.level, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, verification.UserID, verification.Level, verification.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to set user verification: %w", err)
+	}
+
+	return nil
+}
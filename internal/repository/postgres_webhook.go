@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// webhookDeliveryColumns lists the columns scanned into a
+// domain.WebhookDelivery
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts,
+	last_status_code, last_error, next_attempt_at, delivered_at, created_at, updated_at`
+
+// webhookSubscriptionRow maps a webhook_subscriptions row, scanning the event
+// type array through pq.StringArray
+type webhookSubscriptionRow struct {
+	ID         string         `db:"id"`
+	URL        string         `db:"url"`
+	EventTypes pq.StringArray `db:"event_types"`
+	Secret     string         `db:"secret"`
+	Active     bool           `db:"active"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+func (row *webhookSubscriptionRow) toDomain() *domain.WebhookSubscription {
+	eventTypes := make([]domain.NotificationType, len(row.EventTypes))
+	for i, eventType := range row.EventTypes {
+		eventTypes[i] = domain.NotificationType(eventType)
+	}
+	return &domain.WebhookSubscription{
+		ID:         row.ID,
+		URL:        row.URL,
+		EventTypes: eventTypes,
+		Secret:     row.Secret,
+		Active:     row.Active,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+}
+
+// webhookDeliveryRow maps a webhook_deliveries row
+type webhookDeliveryRow struct {
+	ID             string                       `db:"id"`
+	SubscriptionID string                       `db:"subscription_id"`
+	EventID        string                       `db:"event_id"`
+	EventType      domain.NotificationType      `db:"event_type"`
+	Payload        []byte                       `db:"payload"`
+	Status         domain.WebhookDeliveryStatus `db:"status"`
+	Attempts       int                          `db:"attempts"`
+	LastStatusCode *int                         `db:"last_status_code"`
+	LastError      string                       `db:"last_error"`
+	NextAttemptAt  *time.Time                   `db:"next_attempt_at"`
+	DeliveredAt    *time.Time                   `db:"delivered_at"`
+	CreatedAt      time.Time                    `db:"created_at"`
+	UpdatedAt      time.Time                    `db:"updated_at"`
+}
+
+func (row *webhookDeliveryRow) toDomain() *domain.WebhookDelivery {
+	return &domain.WebhookDelivery{
+		ID:             row.ID,
+		SubscriptionID: row.SubscriptionID,
+		EventID:        row.EventID,
+		EventType:      row.EventType,
+		Payload:        row.Payload,
+		Status:         row.Status,
+		Attempts:       row.Attempts,
+		LastStatusCode: row.LastStatusCode,
+		LastError:      row.LastError,
+		NextAttemptAt:  row.NextAttemptAt,
+		DeliveredAt:    row.DeliveredAt,
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+}
+
+func webhookDeliveriesToDomain(rows []webhookDeliveryRow) []*domain.WebhookDelivery {
+	deliveries := make([]*domain.WebhookDelivery, 0, len(rows))
+	for i := range rows {
+		deliveries = append(deliveries, rows[i].toDomain())
+	}
+	return deliveries
+}
+
+// PostgreSQLWebhookRepository implements the WebhookRepository interface
+type PostgreSQLWebhookRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgreSQLWebhookRepository creates a new PostgreSQL webhook repository
+func NewPostgreSQLWebhookRepository(db *sqlx.DB) domain.WebhookRepository {
+	return &PostgreSQLWebhookRepository{db: db}
+}
+
+// CreateSubscription creates a new webhook subscription
+func (r *PostgreSQLWebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if subscription.ID == "" {
+		subscription.ID = uuid.New().String()
+	}
+
+	subscription.CreatedAt = time.Now()
+	subscription.UpdatedAt = time.Now()
+
+	eventTypes := make([]string, len(subscription.EventTypes))
+	for i, eventType := range subscription.EventTypes {
+		eventTypes[i] = string(eventType)
+	}
+
+	query := `
+		INSERT INTO webhook_subscriptions (id, url, event_types, secret, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		subscription.ID, subscription.URL, pq.Array(eventTypes), subscription.Secret,
+		subscription.Active, subscription.CreatedAt, subscription.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubscription retrieves a webhook subscription by ID
+func (r *PostgreSQLWebhookRepository) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	var row webhookSubscriptionRow
+
+	query := `
+		SELECT id, url, event_types, secret, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE id = $1
+	`
+
+	err := r.db.GetContext(ctx, &row, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	return row.toDomain(), nil
+}
+
+// ListSubscriptionsFor lists the active subscriptions to an event type
+func (r *PostgreSQLWebhookRepository) ListSubscriptionsFor(ctx context.Context, eventType domain.NotificationType) ([]*domain.WebhookSubscription, error) {
+	var rows []webhookSubscriptionRow
+
+	query := `
+		SELECT id, url, event_types, secret, active, created_at, updated_at
+		FROM webhook_subscriptions
+		WHERE active AND $1 = ANY(event_types)
+		ORDER BY created_at
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query, string(eventType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	subscriptions := make([]*domain.WebhookSubscription, 0, len(rows))
+	for i := range rows {
+		subscriptions = append(subscriptions, rows[i].toDomain())
+	}
+
+	return subscriptions, nil
+}
+
+// DeactivateSubscription stops a subscription receiving events, keeping its
+// delivery log
+func (r *PostgreSQLWebhookRepository) DeactivateSubscription(ctx context.Context, id string) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET active = FALSE, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate webhook subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery records a delivery unless the subscription already has one
+// for the event, as when a notification is redelivered by the queue
+func (r *PostgreSQLWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error) {
+	if delivery.ID == "" {
+		delivery.ID = uuid.New().String()
+	}
+
+	delivery.CreatedAt = time.Now()
+	delivery.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO webhook_deliveries (
+			id, subscription_id, event_id, event_type, payload, status, attempts,
+			last_status_code, last_error, next_attempt_at, delivered_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		delivery.ID, delivery.SubscriptionID, delivery.EventID, delivery.EventType, delivery.Payload,
+		delivery.Status, delivery.Attempts, delivery.LastStatusCode, delivery.LastError,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.CreatedAt, delivery.UpdatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *PostgreSQLWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, last_status_code = $3, last_error = $4,
+			next_attempt_at = $5, delivered_at = $6, updated_at = $7
+		WHERE id = $8
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.Status, delivery.Attempts, delivery.LastStatusCode, delivery.LastError,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.UpdatedAt, delivery.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries returns pending deliveries due by now, leasing them until
+// lease. Rows locked by another worker are skipped.
+func (r *PostgreSQLWebhookRepository) ClaimDueDeliveries(ctx context.Context, now, lease time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	var rows []webhookDeliveryRow
+
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $2, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	err := r.db.SelectContext(ctx, &rows, query, now, lease, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return webhookDeliveriesToDomain(rows), nil
+}
+
+// ListDeliveries lists a subscription's latest deliveries, newest first
+func (r *PostgreSQLWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*domain.WebhookDelivery, error) {
+	var rows []webhookDeliveryRow
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	err := r.db.SelectContext(ctx, &rows, query, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return webhookDeliveriesToDomain(rows), nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"banking-ledger/internal/domain"
+)
+
+// LocalExportStorage keeps export files in a directory on the local disk. The
+// API serves the files the processor writes, so both must see the same
+// directory.
+type LocalExportStorage struct {
+	dir string
+}
+
+// NewLocalExportStorage creates a storage in dir, creating the directory if
+// it does not exist
+func NewLocalExportStorage(dir string) (domain.ExportStorage, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve export directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	return &LocalExportStorage{dir: dir}, nil
+}
+
+// Create opens the named file in the directory, truncating any file a
+// previous attempt left
+func (s *LocalExportStorage) Create(ctx context.Context, name string) (io.WriteCloser, string, error) {
+	location := filepath.Join(s.dir, filepath.Base(name))
+	file, err := os.OpenFile(location, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create export file: %w", err)
+	}
+	return file, location, nil
+}
+
+// Open reads back a file, refusing locations outside the directory
+func (s *LocalExportStorage) Open(ctx context.Context, location string) (io.ReadCloser, error) {
+	if err := s.contains(location); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+	return file, nil
+}
+
+// Delete removes a file; one already gone is not an error
+func (s *LocalExportStorage) Delete(ctx context.Context, location string) error {
+	if err := s.contains(location); err != nil {
+		return err
+	}
+	if err := os.Remove(location); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete export file: %w", err)
+	}
+	return nil
+}
+
+// contains checks a location is a file directly in the directory
+func (s *LocalExportStorage) contains(location string) error {
+	if filepath.Dir(filepath.Clean(location)) != s.dir {
+		return fmt.Errorf("export file %q is outside %s", location, s.dir)
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"banking-ledger/internal/domain"
@@ -13,119 +16,792 @@ import (
 type AccountUseCase struct {
 	accountRepo     domain.AccountRepository
 	transactionRepo domain.TransactionRepository
+	notifier        domain.NotificationService
+	maxBulkAccounts int
+}
+
+// AccountOption configures optional AccountUseCase behaviour
+type AccountOption func(*AccountUseCase)
+
+// WithAccountNotifications sends an event when an account is opened
+func WithAccountNotifications(notifier domain.NotificationService) AccountOption {
+	return func(uc *AccountUseCase) {
+		uc.notifier = notifier
+	}
+}
+
+// WithMaxBulkAccounts bounds how many accounts one bulk creation may open
+func WithMaxBulkAccounts(max int) AccountOption {
+	return func(uc *AccountUseCase) {
+		uc.maxBulkAccounts = max
+	}
 }
 
 // NewAccountUseCase creates a new account use case
 func NewAccountUseCase(
 	accountRepo domain.AccountRepository,
 	transactionRepo domain.TransactionRepository,
+	opts ...AccountOption,
 ) domain.AccountService {
-	return &AccountUseCase{
+	uc := &AccountUseCase{
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
+		maxBulkAccounts: domain.DefaultMaxBulkAccounts,
 	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
 }
 
 // CreateAccount creates a new account
-func (uc *AccountUseCase) CreateAccount(ctx context.Context, userID string, initialBalance float64, currency string) (*domain.Account, error) {
+func (uc *AccountUseCase) CreateAccount(ctx context.Context, userID string, initialBalance domain.Money, currency string, accountType domain.AccountType, details domain.AccountDetails) (*domain.Account, error) {
+	account, err := newAccount(userID, initialBalance, currency, accountType, details)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.accountRepo.Create(ctx, account, createdEvent(ctx, account)); err != nil {
+		return nil, err
+	}
+
+	uc.notifyCreated(ctx, account)
+	return account, nil
+}
+
+// CreateAccounts opens many accounts at once. Each is validated as
+// CreateAccount would, except that internal types are refused, and the valid
+// ones are inserted together. An account repeating the user, currency and
+// type of an existing account or of an earlier one in the request is skipped
+// as a duplicate.
+func (uc *AccountUseCase) CreateAccounts(ctx context.Context, requests []*domain.NewAccount, dryRun bool) ([]*domain.BulkAccountResult, error) {
+	if len(requests) == 0 || len(requests) > uc.maxBulkAccounts {
+		return nil, &domain.BulkAccountsError{Max: uc.maxBulkAccounts}
+	}
+
+	results := make([]*domain.BulkAccountResult, len(requests))
+	pending := make([]*domain.Account, 0, len(requests))
+	seen := make(map[string]bool, len(requests))
+	for i, request := range requests {
+		results[i] = &domain.BulkAccountResult{Index: i}
+
+		account, err := newBulkAccount(request)
+		if err != nil {
+			results[i].Status = domain.BulkAccountInvalid
+			results[i].Err = err
+			continue
+		}
+
+		key := account.UserID + "\x00" + account.Currency + "\x00" + string(account.Type)
+		if seen[key] {
+			results[i].Status = domain.BulkAccountDuplicate
+			continue
+		}
+		seen[key] = true
+
+		if dryRun {
+			results[i].Status = domain.BulkAccountValid
+			continue
+		}
+		results[i].Account = account
+		pending = append(pending, account)
+	}
+
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	events := make([]*domain.AccountEvent, len(pending))
+	for i, account := range pending {
+		events[i] = createdEvent(ctx, account)
+	}
+	created, err := uc.accountRepo.CreateMany(ctx, pending, events...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Account == nil {
+			continue
+		}
+		if !created[result.Account.ID] {
+			result.Status = domain.BulkAccountDuplicate
+			result.Account = nil
+			continue
+		}
+		result.Status = domain.BulkAccountCreated
+		uc.notifyCreated(ctx, result.Account)
+	}
+
+	return results, nil
+}
+
+// newBulkAccount builds one account of a bulk creation, refusing internal types
+func newBulkAccount(request *domain.NewAccount) (*domain.Account, error) {
+	if strings.TrimSpace(request.UserID) == "" {
+		return nil, domain.ErrMissingUserID
+	}
+	if request.Type.IsInternal() {
+		return nil, domain.ErrInternalAccountType
+	}
+	if request.Currency == "" {
+		return nil, domain.ErrMissingCurrency
+	}
+
+	initialBalance, err := request.InitialBalance.Money(request.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return newAccount(request.UserID, initialBalance, request.Currency, request.Type, request.AccountDetails)
+}
+
+// newAccount validates and builds an active account, defaulting its type to
+// checking
+func newAccount(userID string, initialBalance domain.Money, currency string, accountType domain.AccountType, details domain.AccountDetails) (*domain.Account, error) {
 	if initialBalance < 0 {
 		return nil, domain.ErrInvalidAmount
 	}
 
+	if accountType == "" {
+		accountType = domain.AccountTypeChecking
+	}
+	if !accountType.IsValid() {
+		return nil, domain.ErrInvalidAccountType
+	}
+
 	if currency == "" {
 		return nil, domain.ErrMissingCurrency
 	}
-
-	account := &domain.Account{
-		ID:        uuid.New().String(),
-		UserID:    userID,
-		Balance:   initialBalance,
-		Currency:  currency,
-		Status:    "active",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		Version:   1,
+	currency, err := domain.SupportedCurrencies.Normalize(currency)
+	if err != nil {
+		return nil, err
 	}
 
-	err := uc.accountRepo.Create(ctx, account)
+	nickname, err := domain.NormalizeNickname(details.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := details.Labels.Normalize()
 	if err != nil {
 		return nil, err
 	}
 
-	return account, nil
+	now := time.Now()
+	return &domain.Account{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Balance:        initialBalance,
+		InitialBalance: initialBalance,
+		Currency:       currency,
+		Type:           accountType,
+		Status:         domain.AccountStatusActive,
+		Nickname:       nickname,
+		Labels:         labels,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Version:        1,
+	}, nil
 }
 
-// GetAccount retrieves an account by ID
-func (uc *AccountUseCase) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
-	return uc.accountRepo.GetByID(ctx, id)
+// createdEvent records an account being opened by the caller in ctx
+func createdEvent(ctx context.Context, account *domain.Account) *domain.AccountEvent {
+	return domain.NewAccountEvent(ctx, account.ID, domain.AccountEventCreated, "", string(account.Status))
 }
 
-// GetAccountsByUser retrieves accounts by user ID
-func (uc *AccountUseCase) GetAccountsByUser(ctx context.Context, userID string) ([]*domain.Account, error) {
-	return uc.accountRepo.GetByUserID(ctx, userID)
+// limitEvents appends the change of one of an account's limits from old to
+// updated, formatted in its currency, unless it is unchanged
+func limitEvents(ctx context.Context, events []*domain.AccountEvent, account *domain.Account, eventType domain.AccountEventType, old, updated domain.Money) []*domain.AccountEvent {
+	if old == updated {
+		return events
+	}
+	return append(events, domain.NewAccountEvent(ctx, account.ID, eventType, old.Format(account.Currency), updated.Format(account.Currency)))
+}
+
+// notifyCreated sends the event for an opened account. The account is open
+// whether or not the event can be sent.
+func (uc *AccountUseCase) notifyCreated(ctx context.Context, account *domain.Account) {
+	if uc.notifier == nil {
+		return
+	}
+	if err := uc.notifier.NotifyAccountCreated(ctx, account); err != nil {
+		log.Printf("Failed to notify creation of account %s: %v", account.ID, err)
+	}
 }
 
-// GetAccountSummary retrieves account summary with transaction statistics
-func (uc *AccountUseCase) GetAccountSummary(ctx context.Context, id string) (*domain.AccountSummary, error) {
-	// Get account
+// GetAccount retrieves an account by ID
+func (uc *AccountUseCase) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
 	account, err := uc.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetAccountsByUser retrieves accounts by user ID, only those of the type and
+// carrying the label if they are given
+func (uc *AccountUseCase) GetAccountsByUser(ctx context.Context, userID string, accountType domain.AccountType, label string) ([]*domain.Account, error) {
+	if accountType != "" && !accountType.IsValid() {
+		return nil, domain.ErrInvalidAccountType
+	}
+	if err := domain.AuthorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
 
-	// Get transaction count
-	filter := &domain.TransactionFilter{
-		AccountID: &id,
+	accounts, err := uc.accountRepo.GetByUserID(ctx, userID)
+	if err != nil || (accountType == "" && label == "") {
+		return accounts, err
 	}
 
-	count, err := uc.transactionRepo.Count(ctx, filter)
+	matching := make([]*domain.Account, 0, len(accounts))
+	for _, account := range accounts {
+		if (accountType == "" || account.Type == accountType) && (label == "" || account.Labels.Has(label)) {
+			matching = append(matching, account)
+		}
+	}
+	return matching, nil
+}
+
+// ListAccountEvents lists the changes to an account's status and limits,
+// newest first
+func (uc *AccountUseCase) ListAccountEvents(ctx context.Context, id string, limit, offset int) ([]*domain.AccountEvent, error) {
+	account, err := uc.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
 
-	// Get last transaction
-	filter.Limit = 1
-	transactions, err := uc.transactionRepo.GetByAccountID(ctx, id, filter)
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return uc.accountRepo.ListEvents(ctx, id, limit, offset)
+}
+
+// GetAccountSummary retrieves account summary with transaction statistics,
+// totalled over the transactions created between from and to
+func (uc *AccountUseCase) GetAccountSummary(ctx context.Context, id string, from, to *time.Time) (*domain.AccountSummary, error) {
+	account, err := uc.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
 
-	var lastTransactionAt *time.Time
-	if len(transactions) > 0 {
-		lastTransactionAt = &transactions[0].CreatedAt
+	totals, err := uc.transactionRepo.SummarizeByAccount(ctx, id, from, to)
+	if err != nil {
+		return nil, err
 	}
 
 	return &domain.AccountSummary{
-		Account:           account,
-		TransactionCount:  count,
-		LastTransactionAt: lastTransactionAt,
+		Account:       account,
+		From:          from,
+		To:            to,
+		AccountTotals: *totals,
 	}, nil
 }
 
-// ListAccounts retrieves accounts with pagination
-func (uc *AccountUseCase) ListAccounts(ctx context.Context, limit, offset int) ([]*domain.Account, error) {
-	if limit <= 0 {
-		limit = 10
+// ListAccounts retrieves the accounts the filter matches with pagination,
+// bounding the filter's limit and offset in place. One account more than the
+// page holds is fetched to tell whether another page follows, so HasMore does
+// not depend on the total. A caller who is not an admin only sees their own
+// accounts.
+func (uc *AccountUseCase) ListAccounts(ctx context.Context, filter *domain.AccountFilter, includeTotal bool) (*domain.AccountPage, error) {
+	if err := filter.IsValid(); err != nil {
+		return nil, err
 	}
-	if limit > 100 {
-		limit = 100
+	if !domain.Unscoped(ctx) {
+		principal, _ := domain.PrincipalFromContext(ctx)
+		filter.UserID = principal.UserID
 	}
-	if offset < 0 {
-		offset = 0
+	if filter.Currency != "" {
+		currency, err := domain.SupportedCurrencies.Normalize(filter.Currency)
+		if err != nil {
+			return nil, err
+		}
+		filter.Currency = currency
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	probe := *filter
+	probe.Limit++
+	accounts, err := uc.accountRepo.List(ctx, &probe)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.AccountPage{Accounts: accounts}
+	if len(accounts) > filter.Limit {
+		page.Accounts, page.HasMore = accounts[:filter.Limit], true
+	}
+	if includeTotal {
+		total, err := uc.accountRepo.CountAccounts(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+	return page, nil
+}
+
+// ListUsers pages through the users holding accounts in user ID order,
+// bounding the filter's limit and offset in place as ListAccounts does
+func (uc *AccountUseCase) ListUsers(ctx context.Context, filter *domain.UserFilter) (*domain.UserPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
 	}
 
-	return uc.accountRepo.List(ctx, limit, offset)
+	probe := *filter
+	probe.Limit++
+	users, err := uc.accountRepo.ListUsers(ctx, &probe)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.UserPage{Users: users}
+	if len(users) > filter.Limit {
+		page.Users, page.HasMore = users[:filter.Limit], true
+	}
+	return page, nil
+}
+
+// GetUserSummary gathers a user's accounts by currency, with the balance of
+// each currency, the time of the user's latest transaction and the completed
+// deposits and withdrawals this month
+func (uc *AccountUseCase) GetUserSummary(ctx context.Context, userID string) (*domain.UserSummary, error) {
+	if err := domain.AuthorizeUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	accounts, err := uc.accountRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, domain.ErrAccountNotFound
+	}
+
+	ids := make([]string, len(accounts))
+	byCurrency := make(map[string]*domain.UserCurrencySummary)
+	for i, account := range accounts {
+		ids[i] = account.ID
+		currency := byCurrency[account.Currency]
+		if currency == nil {
+			currency = &domain.UserCurrencySummary{Currency: account.Currency}
+			byCurrency[account.Currency] = currency
+		}
+		currency.Balance += account.Balance
+		currency.Accounts = append(currency.Accounts, account)
+	}
+
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	activity, err := uc.transactionRepo.SummarizeActivity(ctx, ids, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	for _, totals := range activity.Currencies {
+		if currency := byCurrency[totals.Currency]; currency != nil {
+			currency.DepositedThisMonth = totals.Deposited
+			currency.WithdrawnThisMonth = totals.Withdrawn
+		}
+	}
+
+	summary := &domain.UserSummary{
+		UserID:            userID,
+		AccountCount:      len(accounts),
+		LastTransactionAt: activity.LastTransactionAt,
+		MonthStart:        monthStart,
+		Currencies:        make([]*domain.UserCurrencySummary, 0, len(byCurrency)),
+	}
+	for _, currency := range byCurrency {
+		summary.Currencies = append(summary.Currencies, currency)
+	}
+	sort.Slice(summary.Currencies, func(i, j int) bool {
+		return summary.Currencies[i].Currency < summary.Currencies[j].Currency
+	})
+	return summary, nil
+}
+
+// openTransactionStatuses are the statuses of transactions that may still
+// move money
+var openTransactionStatuses = []domain.TransactionStatus{
+	domain.TransactionStatusPendingApproval,
+	domain.TransactionStatusScheduled,
+	domain.TransactionStatusPending,
+	domain.TransactionStatusProcessing,
+}
+
+// hasOpenTransactions reports whether any transaction the filter matches may
+// still move money
+func (uc *AccountUseCase) hasOpenTransactions(ctx context.Context, filter domain.TransactionFilter) (bool, error) {
+	for _, status := range openTransactionStatuses {
+		filter.Status = &status
+		count, err := uc.transactionRepo.Count(ctx, &filter)
+		if err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-// DeactivateAccount deactivates an account
-func (uc *AccountUseCase) DeactivateAccount(ctx context.Context, id string) error {
+// DeactivateAccount deactivates an account. An account with outgoing
+// transactions still to be processed is refused with ErrPendingDebits unless
+// force is set, since they would fail once it is inactive.
+func (uc *AccountUseCase) DeactivateAccount(ctx context.Context, id string, force bool) error {
 	account, err := uc.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return err
+	}
+
+	if !force {
+		open, err := uc.hasOpenTransactions(ctx, domain.TransactionFilter{FromAccountID: &id})
+		if err != nil {
+			return err
+		}
+		if open {
+			return domain.ErrPendingDebits
+		}
+	}
+
+	_, err = uc.UpdateAccountStatus(ctx, id, domain.AccountStatusInactive)
+	return err
+}
+
+// CloseAccount closes an account for good. Its balance must be exactly zero,
+// or a ResidualBalanceError reports what remains, and no transaction to or
+// from it may still be awaiting processing.
+func (uc *AccountUseCase) CloseAccount(ctx context.Context, id string) (*domain.Account, error) {
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+	if account.Status == domain.AccountStatusClosed {
+		return nil, domain.ErrAccountClosed
+	}
+	if account.Balance != 0 {
+		return nil, &domain.ResidualBalanceError{Balance: account.Balance, Currency: account.Currency}
+	}
+
+	open, err := uc.hasOpenTransactions(ctx, domain.TransactionFilter{AccountID: &id})
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, domain.ErrPendingTransactions
+	}
+
+	return uc.changeStatus(ctx, account, domain.AccountStatusClosed, "")
+}
+
+// FreezeAccount blocks debits from an account while still accepting credits,
+// recording why
+func (uc *AccountUseCase) FreezeAccount(ctx context.Context, id, reason string) (*domain.Account, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.ErrStatusReasonRequired
+	}
+
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return uc.changeStatus(ctx, account, domain.AccountStatusFrozen, reason)
+}
+
+// UnfreezeAccount returns a frozen account to active, recording why. An
+// account that is not frozen is refused with ErrInvalidStatusTransition.
+func (uc *AccountUseCase) UnfreezeAccount(ctx context.Context, id, reason string) (*domain.Account, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.ErrStatusReasonRequired
+	}
+
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status != domain.AccountStatusFrozen {
+		return nil, domain.ErrInvalidStatusTransition
+	}
+	return uc.changeStatus(ctx, account, domain.AccountStatusActive, reason)
+}
+
+// ActivateAccount returns an inactive account to active. An account that is
+// already active is refused with ErrAccountAlreadyActive, a frozen one with
+// ErrStatusReasonRequired, as only UnfreezeAccount lifts a freeze, and a
+// closed one with ErrInvalidStatusTransition.
+func (uc *AccountUseCase) ActivateAccount(ctx context.Context, id string) (*domain.Account, error) {
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+	switch account.Status {
+	case domain.AccountStatusActive:
+		return nil, domain.ErrAccountAlreadyActive
+	case domain.AccountStatusFrozen:
+		return nil, domain.ErrStatusReasonRequired
+	}
+	return uc.changeStatus(ctx, account, domain.AccountStatusActive, "")
+}
+
+// UpdateAccountStatus moves an account to a new status. Moving to the status
+// the account already has is a no-op. Moving into or out of frozen is refused
+// with ErrStatusReasonRequired, as only FreezeAccount and UnfreezeAccount
+// record why.
+func (uc *AccountUseCase) UpdateAccountStatus(ctx context.Context, id string, status domain.AccountStatus) (*domain.Account, error) {
+	if !status.IsValid() {
+		return nil, domain.ErrInvalidInput
+	}
+
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+
+	if account.Status == status {
+		return account, nil
+	}
+	if account.Status == domain.AccountStatusFrozen || status == domain.AccountStatusFrozen {
+		return nil, domain.ErrStatusReasonRequired
+	}
+	return uc.changeStatus(ctx, account, status, "")
+}
+
+// changeStatus moves an account to a new status the state machine allows,
+// recording who changed it, when and why on the account and in its events
+func (uc *AccountUseCase) changeStatus(ctx context.Context, account *domain.Account, status domain.AccountStatus, reason string) (*domain.Account, error) {
+	if !account.Status.CanTransitionTo(status) {
+		return nil, domain.ErrInvalidStatusTransition
+	}
+
+	eventType := domain.AccountEventStatusChanged
+	if status == domain.AccountStatusClosed {
+		eventType = domain.AccountEventClosed
+	}
+	event := domain.NewAccountEvent(ctx, account.ID, eventType, string(account.Status), string(status))
+	event.Reason = reason
+
+	now := time.Now()
+	account.Status = status
+	account.StatusChangedAt = &now
+	account.StatusChangedBy = domain.Actor(ctx)
+	account.StatusReason = reason
+	account.UpdatedAt = now
+
+	if err := uc.accountRepo.Update(ctx, account, event); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// UpdateOverdraftLimit sets how far below zero an account's balance may be
+// drawn. Lowering the limit below an existing overdraft is allowed; it only
+// blocks further debits.
+func (uc *AccountUseCase) UpdateOverdraftLimit(ctx context.Context, id string, limit domain.Decimal) (*domain.Account, error) {
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	overdraftLimit, err := limit.Money(account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if overdraftLimit < 0 {
+		return nil, domain.ErrInvalidOverdraft
+	}
+
+	events := limitEvents(ctx, nil, account, domain.AccountEventOverdraftLimitChanged, account.OverdraftLimit, overdraftLimit)
+	account.OverdraftLimit = overdraftLimit
+	account.UpdatedAt = time.Now()
+
+	if err := uc.accountRepo.Update(ctx, account, events...); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// UpdateMinimumBalance sets the balance withdrawals and outgoing transfers may
+// not take an account below. An account already under a raised minimum keeps
+// its balance; only further debits are blocked.
+func (uc *AccountUseCase) UpdateMinimumBalance(ctx context.Context, id string, minimum domain.Decimal) (*domain.Account, error) {
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	minimumBalance, err := minimum.Money(account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if minimumBalance < 0 {
+		return nil, domain.ErrInvalidMinimumBalance
+	}
+
+	events := limitEvents(ctx, nil, account, domain.AccountEventMinimumBalanceChanged, account.MinimumBalance, minimumBalance)
+	account.MinimumBalance = minimumBalance
+	account.UpdatedAt = time.Now()
+
+	if err := uc.accountRepo.Update(ctx, account, events...); err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// UpdateAccount changes an account's settings for its owner. The update
+// must carry the version the client last read; a stale version returns
+// ErrConcurrentUpdate so the client refetches before retrying. A new or
+// removed low balance threshold clears the account's below-threshold flag, so
+// that the next debit under the new threshold notifies.
+func (uc *AccountUseCase) UpdateAccount(ctx context.Context, id string, update *domain.AccountUpdate) (*domain.Account, error) {
+	if err := update.IsValid(); err != nil {
+		return nil, err
+	}
+
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+	if account.Version != update.Version {
+		return nil, domain.ErrConcurrentUpdate
+	}
 
-	account.Status = "inactive"
+	threshold := account.LowBalanceThreshold
+	thresholdChanged := true
+	switch {
+	case update.ClearLowBalanceThreshold:
+		threshold = nil
+	case update.LowBalanceThreshold != nil:
+		value, err := update.LowBalanceThreshold.Money(account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		threshold = &value
+	default:
+		thresholdChanged = false
+	}
+	labels := account.Labels
+	if update.ReplaceLabels {
+		if labels, err = update.Labels.Normalize(); err != nil {
+			return nil, err
+		}
+	}
+	if !thresholdChanged && update.Nickname == nil && !update.ReplaceLabels && !update.ReplaceMetadata {
+		return account, nil
+	}
+
+	account.LowBalanceThreshold = threshold
+	if update.Nickname != nil {
+		account.Nickname = strings.TrimSpace(*update.Nickname)
+	}
+	account.Labels = labels
+	if update.ReplaceMetadata {
+		account.Metadata = update.Metadata
+	}
 	account.UpdatedAt = time.Now()
 
-	return uc.accountRepo.Update(ctx, account)
+	if err := uc.accountRepo.Update(ctx, account); err != nil {
+		return nil, err
+	}
+
+	if thresholdChanged {
+		if _, err := uc.accountRepo.SetBelowThreshold(ctx, id, false); err != nil {
+			return nil, err
+		}
+		account.BelowThreshold = false
+	}
+
+	return account, nil
+}
+
+// UpdateLimits sets the most a single withdrawal or outgoing transfer may
+// move and the most that may leave the account in a day. A nil limit is left
+// as it is and a zero limit removes it. Transactions already completed are
+// not affected.
+func (uc *AccountUseCase) UpdateLimits(ctx context.Context, id string, maxTransaction, dailyOutgoing *domain.Decimal) (*domain.Account, error) {
+	account, err := uc.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := account.AccountLimits
+	for _, limit := range []struct {
+		value  *domain.Decimal
+		target *domain.Money
+	}{
+		{maxTransaction, &limits.MaxTransactionAmount},
+		{dailyOutgoing, &limits.DailyOutgoing},
+	} {
+		if limit.value == nil {
+			continue
+		}
+		amount, err := limit.value.Money(account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if amount < 0 {
+			return nil, domain.ErrInvalidLimit
+		}
+		*limit.target = amount
+	}
+
+	events := limitEvents(ctx, nil, account, domain.AccountEventMaxTransactionChanged, account.MaxTransactionAmount, limits.MaxTransactionAmount)
+	events = limitEvents(ctx, events, account, domain.AccountEventDailyOutgoingChanged, account.DailyOutgoing, limits.DailyOutgoing)
+	account.AccountLimits = limits
+	account.UpdatedAt = time.Now()
+
+	if err := uc.accountRepo.Update(ctx, account, events...); err != nil {
+		return nil, err
+	}
+
+	return account, nil
 }
@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// expiredMicroDepositBatch is how many expired challenges one sweep handles
+const expiredMicroDepositBatch = 100
+
+// AccountVerificationUseCase implements the AccountVerificationService interface
+type AccountVerificationUseCase struct {
+	accountRepo        domain.AccountRepository
+	challengeRepo      domain.MicroDepositRepository
+	transactionService domain.TransactionService
+	ttl                time.Duration
+	maxAttempts        int
+	now                func() time.Time
+}
+
+// AccountVerificationOption configures optional behaviour of the account verification use case
+type AccountVerificationOption func(*AccountVerificationUseCase)
+
+// WithVerificationClock overrides the clock used for challenge expiry
+func WithVerificationClock(now func() time.Time) AccountVerificationOption {
+	return func(uc *AccountVerificationUseCase) {
+		uc.now = now
+	}
+}
+
+// NewAccountVerificationUseCase creates a new account verification use case.
+// Challenges expire after ttl and fail after maxAttempts wrong confirmations.
+func NewAccountVerificationUseCase(
+	accountRepo domain.AccountRepository,
+	challengeRepo domain.MicroDepositRepository,
+	transactionService domain.TransactionService,
+	ttl time.Duration,
+	maxAttempts int,
+	opts ...AccountVerificationOption,
+) domain.AccountVerificationService {
+	uc := &AccountVerificationUseCase{
+		accountRepo:        accountRepo,
+		challengeRepo:      challengeRepo,
+		transactionService: transactionService,
+		ttl:                ttl,
+		maxAttempts:        maxAttempts,
+		now:                time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// StartMicroDeposits credits two random small amounts to the account and
+// opens a challenge for the owner to confirm them
+func (uc *AccountVerificationUseCase) StartMicroDeposits(ctx context.Context, accountID string) (*domain.MicroDepositChallenge, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := account.Status.CanDebit(); err != nil {
+		return nil, err
+	}
+	if account.Verified {
+		return nil, domain.ErrAccountAlreadyVerified
+	}
+
+	// An open challenge blocks a new one until it expires
+	existing, err := uc.challengeRepo.GetPendingByAccountID(ctx, accountID)
+	switch {
+	case err == nil && !existing.IsExpired(uc.now()):
+		return nil, domain.ErrMicroDepositPending
+	case err == nil:
+		if err := uc.expire(ctx, existing); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, domain.ErrMicroDepositNotFound):
+		return nil, err
+	}
+
+	amounts, err := domain.GenerateMicroDepositAmounts(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate micro-deposit amounts: %w", err)
+	}
+
+	challenge := &domain.MicroDepositChallenge{
+		ID:        uuid.New().String(),
+		AccountID: account.ID,
+		AmountOne: amounts[0],
+		AmountTwo: amounts[1],
+		Currency:  account.Currency,
+		Status:    domain.MicroDepositStatusPending,
+		ExpiresAt: uc.now().Add(uc.ttl),
+	}
+
+	if err := uc.challengeRepo.Create(ctx, challenge); err != nil {
+		return nil, err
+	}
+
+	depositIDs := challenge.DepositIDs()
+	for i, amount := range amounts {
+		_, err := uc.transactionService.ProcessTransaction(ctx, &domain.TransactionRequest{
+			ID:             depositIDs[i],
+			Type:           domain.TransactionTypeDeposit,
+			ToAccountID:    &account.ID,
+			Amount:         amount,
+			Currency:       account.Currency,
+			Description:    "Account verification micro-deposit",
+			Reference:      challenge.ID,
+			System:         true,
+			AllowDuplicate: true,
+		})
+		if err != nil {
+			// Abandon the challenge and reverse any credit already submitted
+			if resolveErr := uc.challengeRepo.Resolve(ctx, challenge.ID, domain.MicroDepositStatusFailed); resolveErr == nil {
+				if clawbackErr := uc.clawback(ctx, challenge); clawbackErr != nil {
+					log.Printf("Failed to claw back micro-deposits for challenge %s: %v", challenge.ID, clawbackErr)
+				}
+			}
+			return nil, fmt.Errorf("failed to submit micro-deposit: %w", err)
+		}
+	}
+
+	return challenge, nil
+}
+
+// VerifyMicroDeposits checks the confirmed amounts against the account's open
+// challenge, marking the account verified on a match
+func (uc *AccountVerificationUseCase) VerifyMicroDeposits(ctx context.Context, accountID string, amounts []domain.Decimal) (*domain.Account, error) {
+	challenge, err := uc.challengeRepo.GetPendingByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Malformed amounts are rejected without using up an attempt
+	confirmed := make([]domain.Money, len(amounts))
+	for i, amount := range amounts {
+		if confirmed[i], err = amount.Money(challenge.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	if challenge.IsExpired(uc.now()) {
+		if err := uc.expire(ctx, challenge); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrMicroDepositExpired
+	}
+
+	attempts, err := uc.challengeRepo.RecordAttempt(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !challenge.Matches(confirmed) {
+		if attempts < uc.maxAttempts {
+			return nil, domain.ErrMicroDepositMismatch
+		}
+		if err := uc.resolve(ctx, challenge, domain.MicroDepositStatusFailed); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrMicroDepositAttemptsExceeded
+	}
+
+	// Resolving first means only one concurrent confirmation can succeed
+	if err := uc.challengeRepo.Resolve(ctx, challenge.ID, domain.MicroDepositStatusVerified); err != nil {
+		return nil, err
+	}
+
+	account, err := uc.markVerified(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.clawback(ctx, challenge); err != nil {
+		log.Printf("Failed to claw back micro-deposits for challenge %s: %v", challenge.ID, err)
+	}
+
+	return account, nil
+}
+
+// ExpireMicroDeposits closes challenges whose confirmation window has passed
+// and claws back their credits
+func (uc *AccountVerificationUseCase) ExpireMicroDeposits(ctx context.Context) (int, error) {
+	challenges, err := uc.challengeRepo.ListExpiredPending(ctx, uc.now(), expiredMicroDepositBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, challenge := range challenges {
+		if err := uc.expire(ctx, challenge); err != nil {
+			log.Printf("Failed to expire micro-deposit challenge %s: %v", challenge.ID, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// expire resolves a challenge as expired and claws back its credits
+func (uc *AccountVerificationUseCase) expire(ctx context.Context, challenge *domain.MicroDepositChallenge) error {
+	return uc.resolve(ctx, challenge, domain.MicroDepositStatusExpired)
+}
+
+// resolve closes a challenge and claws back its credits. A challenge already
+// resolved by a concurrent caller is left to that caller.
+func (uc *AccountVerificationUseCase) resolve(ctx context.Context, challenge *domain.MicroDepositChallenge, status domain.MicroDepositStatus) error {
+	if err := uc.challengeRepo.Resolve(ctx, challenge.ID, status); err != nil {
+		if errors.Is(err, domain.ErrMicroDepositNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return uc.clawback(ctx, challenge)
+}
+
+// markVerified sets the account's verified flag, retrying when a concurrent
+// balance update bumps the version
+func (uc *AccountVerificationUseCase) markVerified(ctx context.Context, accountID string) (*domain.Account, error) {
+	const maxRetries = 3
+
+	for attempt := 1; ; attempt++ {
+		account, err := uc.accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+
+		account.Verified = true
+		account.UpdatedAt = time.Now()
+
+		err = uc.accountRepo.Update(ctx, account)
+		if err == nil {
+			return account, nil
+		}
+		if !errors.Is(err, domain.ErrConcurrentUpdate) || attempt == maxRetries {
+			return nil, err
+		}
+	}
+}
+
+// clawback withdraws each credit that was not rejected. Clawbacks use fixed
+// IDs, so running it again after a partial failure does not withdraw twice.
+func (uc *AccountVerificationUseCase) clawback(ctx context.Context, challenge *domain.MicroDepositChallenge) error {
+	depositIDs := challenge.DepositIDs()
+	clawbackIDs := challenge.ClawbackIDs()
+
+	for i, depositID := range depositIDs {
+		deposit, err := uc.transactionService.GetTransaction(ctx, depositID)
+		if err != nil {
+			if errors.Is(err, domain.ErrTransactionNotFound) {
+				continue
+			}
+			return err
+		}
+		if deposit.Status == domain.TransactionStatusFailed || deposit.Status == domain.TransactionStatusCancelled {
+			continue
+		}
+
+		if _, err := uc.transactionService.GetTransaction(ctx, clawbackIDs[i]); err == nil {
+			continue
+		} else if !errors.Is(err, domain.ErrTransactionNotFound) {
+			return err
+		}
+
+		_, err = uc.transactionService.ProcessTransaction(ctx, &domain.TransactionRequest{
+			ID:             clawbackIDs[i],
+			Type:           domain.TransactionTypeWithdrawal,
+			FromAccountID:  &challenge.AccountID,
+			Amount:         deposit.Amount,
+			Currency:       deposit.Currency,
+			Description:    "Account verification micro-deposit clawback",
+			Reference:      challenge.ID,
+			System:         true,
+			AllowDuplicate: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to submit micro-deposit clawback: %w", err)
+		}
+	}
+
+	return uc.challengeRepo.MarkClawedBack(ctx, challenge.ID, uc.now())
+}
@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+const (
+	// defaultSnapshotPageSize is how many accounts are snapshotted per query
+	defaultSnapshotPageSize = 500
+	// defaultSnapshotCatchUpDays is how many missed days are snapshotted at most
+	defaultSnapshotCatchUpDays = 31
+)
+
+// BalanceSnapshotUseCase implements the BalanceSnapshotService interface.
+// Snapshots are taken from the ledger rather than the live balances, so a day
+// can be snapshotted, or snapshotted again, at any time after it closed.
+type BalanceSnapshotUseCase struct {
+	accountRepo  domain.AccountRepository
+	ledgerRepo   domain.LedgerEntryRepository
+	snapshotRepo domain.BalanceSnapshotRepository
+	pageSize     int
+	catchUpDays  int
+	now          func() time.Time
+
+	// lastDay is the last day this process snapshotted in full
+	mu      sync.Mutex
+	lastDay *time.Time
+}
+
+// BalanceSnapshotOption configures optional behaviour of the balance snapshot use case
+type BalanceSnapshotOption func(*BalanceSnapshotUseCase)
+
+// WithSnapshotPageSize sets how many accounts are snapshotted per query
+func WithSnapshotPageSize(size int) BalanceSnapshotOption {
+	return func(uc *BalanceSnapshotUseCase) {
+		if size > 0 {
+			uc.pageSize = size
+		}
+	}
+}
+
+// WithSnapshotCatchUpDays sets how many missed days are snapshotted at most
+func WithSnapshotCatchUpDays(days int) BalanceSnapshotOption {
+	return func(uc *BalanceSnapshotUseCase) {
+		if days > 0 {
+			uc.catchUpDays = days
+		}
+	}
+}
+
+// WithSnapshotClock sets the clock deciding which days have closed
+func WithSnapshotClock(now func() time.Time) BalanceSnapshotOption {
+	return func(uc *BalanceSnapshotUseCase) {
+		uc.now = now
+	}
+}
+
+// NewBalanceSnapshotUseCase creates a new balance snapshot use case
+func NewBalanceSnapshotUseCase(
+	accountRepo domain.AccountRepository,
+	ledgerRepo domain.LedgerEntryRepository,
+	snapshotRepo domain.BalanceSnapshotRepository,
+	opts ...BalanceSnapshotOption,
+) domain.BalanceSnapshotService {
+	uc := &BalanceSnapshotUseCase{
+		accountRepo:  accountRepo,
+		ledgerRepo:   ledgerRepo,
+		snapshotRepo: snapshotRepo,
+		pageSize:     defaultSnapshotPageSize,
+		catchUpDays:  defaultSnapshotCatchUpDays,
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// SnapshotDay snapshots every account opened by the close of a day at the
+// balance its last ledger entry of the day left, or its initial balance if it
+// had none. Accounts opened later are left out.
+func (uc *BalanceSnapshotUseCase) SnapshotDay(ctx context.Context, day time.Time) (int, error) {
+	day = domain.SnapshotDay(day)
+	end := day.AddDate(0, 0, 1)
+	if end.After(uc.now()) {
+		return 0, domain.ErrInvalidInput
+	}
+
+	taken := 0
+	afterID := ""
+	for {
+		accounts, err := uc.accountRepo.ListAfter(ctx, afterID, uc.pageSize)
+		if err != nil {
+			return taken, err
+		}
+		if len(accounts) == 0 {
+			break
+		}
+
+		var open []*domain.Account
+		ids := make([]string, 0, len(accounts))
+		for _, account := range accounts {
+			if account.CreatedAt.Before(end) {
+				open = append(open, account)
+				ids = append(ids, account.ID)
+			}
+		}
+
+		if len(open) > 0 {
+			balances, err := uc.ledgerRepo.BalancesAt(ctx, ids, end)
+			if err != nil {
+				return taken, err
+			}
+
+			now := uc.now()
+			snapshots := make([]*domain.BalanceSnapshot, len(open))
+			for i, account := range open {
+				balance, posted := balances[account.ID]
+				if !posted {
+					balance = account.InitialBalance
+				}
+				snapshots[i] = &domain.BalanceSnapshot{
+					AccountID: account.ID,
+					AsOfDate:  day,
+					Balance:   balance,
+					Currency:  account.Currency,
+					CreatedAt: now,
+				}
+			}
+			if err := uc.snapshotRepo.Save(ctx, snapshots); err != nil {
+				return taken, err
+			}
+			taken += len(snapshots)
+		}
+
+		afterID = accounts[len(accounts)-1].ID
+		if len(accounts) < uc.pageSize {
+			break
+		}
+	}
+
+	return taken, nil
+}
+
+// SnapshotDue snapshots each closed day since the last one snapshotted, up to
+// the catch-up limit, or yesterday if there has been none. The last day found
+// on disk is snapshotted again, since a run may have stopped partway through
+// it.
+func (uc *BalanceSnapshotUseCase) SnapshotDue(ctx context.Context) (int, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	yesterday := domain.SnapshotDay(uc.now()).AddDate(0, 0, -1)
+	first := yesterday
+	if uc.lastDay != nil {
+		first = uc.lastDay.AddDate(0, 0, 1)
+	} else {
+		latest, err := uc.snapshotRepo.LatestDay(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if latest != nil {
+			first = *latest
+		}
+	}
+	if earliest := yesterday.AddDate(0, 0, 1-uc.catchUpDays); first.Before(earliest) {
+		first = earliest
+	}
+
+	taken := 0
+	for day := first; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+		n, err := uc.SnapshotDay(ctx, day)
+		taken += n
+		if err != nil {
+			return taken, err
+		}
+		snapshotted := day
+		uc.lastDay = &snapshotted
+	}
+
+	return taken, nil
+}
+
+// GetBalanceAsOf returns an account's snapshot for a closed day. For today it
+// returns the last snapshot plus the ledger's movements since, or the initial
+// balance plus every movement if the account has no snapshot yet.
+func (uc *BalanceSnapshotUseCase) GetBalanceAsOf(ctx context.Context, accountID, day string) (*domain.BalanceSnapshot, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+
+	asOf, err := time.Parse(domain.StatementDateLayout, day)
+	if err != nil {
+		return nil, domain.ErrInvalidInput
+	}
+
+	now := uc.now()
+	today := domain.SnapshotDay(now)
+	switch {
+	case asOf.After(today):
+		return nil, domain.ErrInvalidInput
+	case asOf.Before(today):
+		return uc.snapshotRepo.Get(ctx, accountID, asOf)
+	}
+
+	balance := account.InitialBalance
+	var since time.Time
+	snapshot, err := uc.snapshotRepo.GetLatestBefore(ctx, accountID, today)
+	switch {
+	case err == nil:
+		balance = snapshot.Balance
+		since = domain.SnapshotDay(snapshot.AsOfDate).AddDate(0, 0, 1)
+	case !errors.Is(err, domain.ErrBalanceSnapshotNotFound):
+		return nil, err
+	}
+
+	moved, err := uc.ledgerRepo.SumSince(ctx, accountID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.BalanceSnapshot{
+		AccountID: account.ID,
+		AsOfDate:  today,
+		Balance:   balance + moved,
+		Currency:  account.Currency,
+		CreatedAt: now,
+	}, nil
+}
@@ -0,0 +1,442 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// ProcessBatch validates every leg of a batch against its accounts, saves the
+// legs as pending transactions sharing a batch ID and queues them as one
+// message. Each account's total debit across the batch must be covered by its
+// available balance, and each user's debiting legs must fit in their quota. A
+// leg that would need an approver is refused, as approvals release
+// transactions one at a time.
+func (uc *TransactionUseCase) ProcessBatch(ctx context.Context, request *domain.BatchRequest) (*domain.TransactionBatch, error) {
+	if err := request.IsValid(); err != nil {
+		return nil, err
+	}
+	for i, leg := range request.Legs {
+		if err := uc.checkExternalParty(leg); err != nil {
+			return nil, &domain.BatchLegError{Index: i, Err: err}
+		}
+		if err := uc.authorizeDebit(ctx, leg); err != nil {
+			return nil, err
+		}
+		if err := uc.checkVerification(ctx, leg); err != nil {
+			return nil, &domain.BatchLegError{Index: i, Err: err}
+		}
+		held, err := uc.legRequiresApproval(ctx, leg)
+		if err != nil {
+			return nil, err
+		}
+		if held {
+			return nil, &domain.BatchLegError{Index: i, Err: domain.ErrApprovalRequired}
+		}
+	}
+	if err := uc.checkBatch(ctx, request); err != nil {
+		return nil, err
+	}
+	if err := uc.checkBatchQuota(ctx, request); err != nil {
+		return nil, err
+	}
+
+	request.ID = uuid.New().String()
+	for i, leg := range request.Legs {
+		leg.ID = domain.LegID(request.ID, i)
+		leg.BatchID = request.ID
+	}
+
+	// The batch's message is saved with its last leg, so it is only
+	// published once every leg is stored
+	message, err := uc.outboxMessage(request)
+	if err != nil {
+		return nil, err
+	}
+
+	legs := make([]*domain.Transaction, 0, len(request.Legs))
+	for i, leg := range request.Legs {
+		transaction := &domain.Transaction{
+			ID:            leg.ID,
+			Type:          leg.Type,
+			FromAccountID: leg.FromAccountID,
+			ToAccountID:   leg.ToAccountID,
+			Amount:        leg.Amount,
+			Currency:      leg.Currency,
+			Status:        domain.TransactionStatusPending,
+			Description:   leg.Description,
+			Reference:     leg.Reference,
+			Metadata:      leg.Metadata,
+			BatchID:       request.ID,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+
+			ExternalSource:      leg.ExternalSource,
+			ExternalDestination: leg.ExternalDestination,
+		}
+		if i == len(request.Legs)-1 {
+			transaction.Outbox = message
+		}
+		if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+			// Release the legs already saved, references included
+			for _, saved := range legs {
+				uc.transactionRepo.UpdateStatus(ctx, saved.ID, domain.TransactionStatusCancelled, "Batch submission failed")
+			}
+
+			var referenceErr *domain.DuplicateReferenceError
+			if errors.As(err, &referenceErr) {
+				return nil, &domain.BatchLegError{Index: i, Err: err}
+			}
+			return nil, fmt.Errorf("failed to create transaction: %w", err)
+		}
+		legs = append(legs, transaction)
+	}
+
+	uc.dispatch(ctx, legs[len(legs)-1].ID, message)
+
+	return domain.NewTransactionBatch(request.ID, legs), nil
+}
+
+// legRequiresApproval reports whether a batch leg is a transfer large enough
+// to need an approver, leaving the leg itself untouched
+func (uc *TransactionUseCase) legRequiresApproval(ctx context.Context, leg *domain.TransactionRequest) (bool, error) {
+	if !uc.requiresApproval(leg) {
+		return false, nil
+	}
+	probe := *leg
+	if err := uc.markSelfTransfer(ctx, &probe); err != nil {
+		return false, err
+	}
+	return uc.requiresApproval(&probe), nil
+}
+
+// GetBatch retrieves a batch and the status of each of its legs. The caller
+// must hold an account on either side of every leg.
+func (uc *TransactionUseCase) GetBatch(ctx context.Context, batchID string) (*domain.TransactionBatch, error) {
+	legs, err := uc.transactionRepo.GetByBatchID(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if len(legs) == 0 {
+		return nil, domain.ErrBatchNotFound
+	}
+	for _, leg := range legs {
+		if err := uc.authorizeParty(ctx, leg); err != nil {
+			return nil, err
+		}
+	}
+
+	return domain.NewTransactionBatch(batchID, legs), nil
+}
+
+// checkBatch checks every leg against its accounts' statuses, types and
+// currencies, then each debited account's total against its available balance
+func (uc *TransactionUseCase) checkBatch(ctx context.Context, batch *domain.BatchRequest) error {
+	accounts := make(map[string]*domain.Account)
+	account := func(id string) (*domain.Account, error) {
+		if cached, ok := accounts[id]; ok {
+			return cached, nil
+		}
+		loaded, err := uc.accountRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = loaded
+		return loaded, nil
+	}
+
+	for i, leg := range batch.Legs {
+		if leg.FromAccountID != nil {
+			from, err := account(*leg.FromAccountID)
+			if err == nil {
+				err = from.Status.CanDebit()
+			}
+			if err == nil && from.Currency != leg.Currency {
+				err = domain.ErrCurrencyMismatch
+			}
+			if err != nil {
+				return &domain.BatchLegError{Index: i, Err: err}
+			}
+		}
+		if leg.ToAccountID != nil {
+			to, err := account(*leg.ToAccountID)
+			if err == nil {
+				err = to.Status.CanCredit()
+			}
+			if err == nil && leg.Type == domain.TransactionTypeTransfer && to.Type == domain.AccountTypeFeeCollection {
+				err = domain.ErrAccountRestricted
+			}
+			if err == nil && to.Currency != leg.Currency {
+				err = domain.ErrCurrencyMismatch
+			}
+			if err != nil {
+				return &domain.BatchLegError{Index: i, Err: err}
+			}
+		}
+	}
+
+	for accountID, total := range batch.Debits() {
+		if err := accounts[accountID].CheckFunds(total); err != nil {
+			return fmt.Errorf("account %s: %w", accountID, err)
+		}
+	}
+
+	return nil
+}
+
+// processBatch applies a queued batch leg by leg. Legs completed or posted by
+// an earlier delivery are skipped. If a leg fails permanently, the legs
+// applied before it are compensated and every leg is marked failed; the
+// failure is final, so it is not returned for redelivery. A transient failure
+// is returned so a redelivery resumes the batch from that leg.
+func (uc *TransactionUseCase) processBatch(ctx context.Context, batch *domain.BatchRequest) error {
+	const maxRetries = 3
+
+	stored, err := uc.transactionRepo.GetByBatchID(ctx, batch.ID)
+	if err != nil {
+		return err
+	}
+	legs := make(map[string]*domain.Transaction, len(stored))
+	started := false
+	for _, leg := range stored {
+		// A leg posted by an interrupted delivery only needs its outcome
+		// recorded
+		if leg.Status == domain.TransactionStatusProcessing {
+			applied, err := uc.isApplied(ctx, leg.ID)
+			if err != nil {
+				return err
+			}
+			if applied {
+				if err := uc.transactionRepo.UpdateStatus(ctx, leg.ID, domain.TransactionStatusCompleted, ""); err != nil {
+					return err
+				}
+				leg.Status = domain.TransactionStatusCompleted
+			}
+		}
+		legs[leg.ID] = leg
+		started = started || leg.Status == domain.TransactionStatusCompleted
+	}
+
+	// A leg cancelled or expired while queued fails the batch, as does a
+	// failure recorded by an earlier delivery
+	for _, leg := range stored {
+		if leg.Status == domain.TransactionStatusFailed || leg.Status == domain.TransactionStatusCancelled {
+			message := leg.ErrorMessage
+			if message == "" {
+				message = string(leg.Status)
+			}
+			uc.failBatch(ctx, batch, legs, leg.ID, leg.FailureCode, message)
+			return nil
+		}
+	}
+
+	// Check the total debits before the first leg is applied
+	if !started {
+		if err := uc.checkBatch(ctx, batch); err != nil {
+			failedLegID := ""
+			var legErr *domain.BatchLegError
+			if errors.As(err, &legErr) {
+				failedLegID = batch.Legs[legErr.Index].ID
+				err = legErr.Err
+			}
+			if !domain.IsPermanent(err) {
+				return err
+			}
+			uc.failBatch(ctx, batch, legs, failedLegID, domain.FailureCodeFor(err), err.Error())
+			return nil
+		}
+	}
+
+	for _, leg := range batch.Legs {
+		if current, ok := legs[leg.ID]; ok && current.Status == domain.TransactionStatusCompleted {
+			continue
+		}
+
+		// A leg is claimed like any transaction, so one cancelled since the
+		// legs were loaded fails the batch rather than being applied
+		if current, ok := legs[leg.ID]; !ok || current.Status != domain.TransactionStatusProcessing {
+			err := uc.transactionRepo.ClaimProcessing(ctx, leg.ID)
+			if errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+				if current, getErr := uc.transactionRepo.GetByID(ctx, leg.ID); getErr == nil && current.Status == domain.TransactionStatusCancelled {
+					legs[leg.ID] = current
+					uc.failBatch(ctx, batch, legs, leg.ID, "", string(current.Status))
+					return nil
+				}
+			} else if err != nil {
+				log.Printf("Failed to mark transaction %s processing: %v", leg.ID, err)
+			}
+		}
+
+		var err error
+		for attempt := 1; ; attempt++ {
+			err = uc.ProcessTransactionSync(ctx, leg)
+			if !errors.Is(err, domain.ErrConcurrentUpdate) || attempt == maxRetries {
+				break
+			}
+		}
+		// A posted leg whose status was lost is left for manual repair
+		// rather than posted again or compensated
+		var unrecorded *domain.UnrecordedStatusError
+		if errors.As(err, &unrecorded) {
+			return &domain.PermanentError{Err: err}
+		}
+		if err != nil && !domain.IsPermanent(err) {
+			log.Printf("Transient failure processing transaction %s of batch %s, will retry: %v", leg.ID, batch.ID, err)
+			if updateErr := uc.transactionRepo.UpdateStatus(ctx, leg.ID, domain.TransactionStatusProcessing, "retrying after transient error: "+err.Error()); updateErr != nil {
+				log.Printf("Failed to record transient failure on transaction %s: %v", leg.ID, updateErr)
+			}
+			return err
+		}
+		if err != nil {
+			log.Printf("Failed to process transaction %s of batch %s: %v", leg.ID, batch.ID, err)
+			uc.transactionRepo.MarkFailed(ctx, leg.ID, domain.FailureCodeFor(err), err.Error())
+			uc.notifyFailed(ctx, leg, err)
+			legs[leg.ID] = &domain.Transaction{ID: leg.ID, Status: domain.TransactionStatusFailed}
+			uc.failBatch(ctx, batch, legs, leg.ID, domain.FailureCodeFor(err), err.Error())
+			return nil
+		}
+		legs[leg.ID] = &domain.Transaction{ID: leg.ID, Status: domain.TransactionStatusCompleted}
+	}
+
+	log.Printf("Successfully processed batch: %s", batch.ID)
+	return nil
+}
+
+// failUndeliveredBatch fails a dropped batch from its first unfinished leg,
+// compensating the legs completed before it. A batch with a leg that may
+// have been posted without being recorded is left for repair.
+func (uc *TransactionUseCase) failUndeliveredBatch(ctx context.Context, batch *domain.BatchRequest, code domain.FailureCode, cause error) {
+	stored, err := uc.transactionRepo.GetByBatchID(ctx, batch.ID)
+	if err != nil {
+		log.Printf("Failed to load dropped batch %s: %v", batch.ID, err)
+		return
+	}
+	legs := make(map[string]*domain.Transaction, len(stored))
+	for _, leg := range stored {
+		if leg.Status == domain.TransactionStatusProcessing {
+			if applied, err := uc.isApplied(ctx, leg.ID); err != nil || applied {
+				log.Printf("Leaving dropped batch %s for repair, as leg %s may have been applied: %v", batch.ID, leg.ID, err)
+				return
+			}
+		}
+		legs[leg.ID] = leg
+	}
+
+	failedLegID := ""
+	for _, leg := range batch.Legs {
+		if current, ok := legs[leg.ID]; !ok || current.Status != domain.TransactionStatusCompleted {
+			failedLegID = leg.ID
+			break
+		}
+	}
+	if failedLegID == "" {
+		return
+	}
+	log.Printf("Failing dropped batch %s: %v", batch.ID, cause)
+	uc.failBatch(ctx, batch, legs, failedLegID, code, cause.Error())
+}
+
+// failBatch compensates a failed batch's completed legs, latest first, and
+// marks the remaining legs failed with the reason. A leg that cannot be
+// compensated is left completed so its status stays truthful.
+func (uc *TransactionUseCase) failBatch(ctx context.Context, batch *domain.BatchRequest, legs map[string]*domain.Transaction, failedLegID string, code domain.FailureCode, message string) {
+	reason := message
+	if failedLegID != "" {
+		reason = fmt.Sprintf("%v: leg %s: %s", domain.ErrBatchFailed, failedLegID, message)
+	}
+
+	for i := len(batch.Legs) - 1; i >= 0; i-- {
+		leg := batch.Legs[i]
+		current, ok := legs[leg.ID]
+		if ok && (current.Status == domain.TransactionStatusFailed || current.Status == domain.TransactionStatusCancelled) {
+			continue
+		}
+
+		if ok && current.Status == domain.TransactionStatusCompleted {
+			if err := uc.compensateLeg(ctx, leg); err != nil {
+				log.Printf("Failed to compensate transaction %s of failed batch %s: %v", leg.ID, batch.ID, err)
+				continue
+			}
+		}
+
+		if err := uc.transactionRepo.MarkFailed(ctx, leg.ID, code, reason); err != nil {
+			log.Printf("Failed to mark transaction %s of batch %s failed: %v", leg.ID, batch.ID, err)
+			continue
+		}
+		uc.notifyFailed(ctx, leg, errors.New(reason))
+	}
+}
+
+// compensateLeg undoes a completed leg, refunding any fee charged on it first
+func (uc *TransactionUseCase) compensateLeg(ctx context.Context, leg *domain.TransactionRequest) error {
+	fee, err := uc.transactionRepo.GetByID(ctx, FeeTransactionID(leg.ID))
+	if err != nil && !errors.Is(err, domain.ErrTransactionNotFound) {
+		return err
+	}
+	if err == nil && fee.Status == domain.TransactionStatusCompleted {
+		if err := uc.compensate(ctx, fee, leg.BatchID); err != nil {
+			return err
+		}
+	}
+
+	original, err := uc.transactionRepo.GetByID(ctx, leg.ID)
+	if err != nil {
+		return err
+	}
+	return uc.compensate(ctx, original, leg.BatchID)
+}
+
+// compensate reverses a completed transaction of a failed batch with a
+// system reversal. A transaction that has already been reversed, as by an
+// earlier delivery, is left alone.
+func (uc *TransactionUseCase) compensate(ctx context.Context, original *domain.Transaction, batchID string) error {
+	reversalID := original.ID + "-compensation"
+	if err := uc.transactionRepo.ClaimReversal(ctx, original.ID, reversalID); err != nil {
+		if errors.Is(err, domain.ErrTransactionAlreadyReversed) {
+			return nil
+		}
+		return err
+	}
+
+	request := &domain.TransactionRequest{
+		ID:                    reversalID,
+		Type:                  domain.TransactionTypeReversal,
+		FromAccountID:         original.ToAccountID,
+		ToAccountID:           original.FromAccountID,
+		Amount:                original.Amount,
+		Currency:              original.Currency,
+		Description:           "Compensation of " + original.ID + " in failed batch " + batchID,
+		System:                true,
+		ReversedTransactionID: original.ID,
+	}
+	reversal := &domain.Transaction{
+		ID:                    request.ID,
+		Type:                  request.Type,
+		FromAccountID:         request.FromAccountID,
+		ToAccountID:           request.ToAccountID,
+		Amount:                request.Amount,
+		Currency:              request.Currency,
+		Status:                domain.TransactionStatusProcessing,
+		Description:           request.Description,
+		System:                true,
+		ReversedTransactionID: original.ID,
+	}
+	if err := uc.transactionRepo.Create(ctx, reversal); err != nil {
+		uc.transactionRepo.ResolveReversal(ctx, original.ID, reversalID, false)
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	if err := uc.processReversal(ctx, request); err != nil {
+		uc.transactionRepo.MarkFailed(ctx, reversalID, domain.FailureCodeFor(err), err.Error())
+		uc.transactionRepo.ResolveReversal(ctx, original.ID, reversalID, false)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"banking-ledger/internal/domain"
+)
+
+// defaultTombstoneLimit is how many cancelled transactions the processor
+// remembers
+const defaultTombstoneLimit = 10000
+
+// tombstoneSet remembers the most recently cancelled transactions, forgetting
+// the oldest beyond its limit
+type tombstoneSet struct {
+	mu    sync.Mutex
+	ids   map[string]struct{}
+	order []string
+	limit int
+}
+
+func newTombstoneSet(limit int) *tombstoneSet {
+	if limit <= 0 {
+		limit = defaultTombstoneLimit
+	}
+	return &tombstoneSet{ids: make(map[string]struct{}), limit: limit}
+}
+
+func (s *tombstoneSet) add(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ids[id]; ok {
+		return
+	}
+	if len(s.order) == s.limit {
+		delete(s.ids, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.ids[id] = struct{}{}
+	s.order = append(s.order, id)
+}
+
+func (s *tombstoneSet) contains(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.ids[id]
+	return ok
+}
+
+// WithCancellationTombstones publishes a tombstone to queueName when a queued
+// transaction is cancelled. The processor remembers up to limit tombstones
+// and acknowledges a remembered transaction's message without reading it.
+// A tombstone that arrives after the message only costs the usual read.
+func WithCancellationTombstones(queueName string, limit int) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.tombstoneQueue = queueName
+		uc.tombstones = newTombstoneSet(limit)
+	}
+}
+
+// SkippedCancelled returns how many messages of cancelled transactions the
+// processor has skipped on a tombstone
+func (uc *TransactionUseCase) SkippedCancelled() int64 {
+	return uc.skippedCancelled.Load()
+}
+
+// publishTombstone tells the processor a queued transaction was cancelled.
+// The tombstone only saves the processor a read, so a failure is logged.
+func (uc *TransactionUseCase) publishTombstone(ctx context.Context, id string) {
+	if uc.tombstoneQueue == "" {
+		return
+	}
+	message, err := json.Marshal(&domain.CancellationTombstone{TransactionID: id})
+	if err == nil {
+		err = uc.queue.Publish(ctx, uc.tombstoneQueue, message)
+	}
+	if err != nil {
+		log.Printf("Failed to publish cancellation tombstone for transaction %s: %v", id, err)
+	}
+}
+
+// subscribeTombstones remembers the transactions cancelled while queued. The
+// tombstone queue is a fanout, so every processor hears of each cancellation.
+func (uc *TransactionUseCase) subscribeTombstones(ctx context.Context) (domain.Subscription, error) {
+	if uc.tombstoneQueue == "" {
+		return nil, nil
+	}
+	return uc.queue.Subscribe(ctx, uc.tombstoneQueue, func(ctx context.Context, data []byte) error {
+		var tombstone domain.CancellationTombstone
+		if err := json.Unmarshal(data, &tombstone); err != nil || tombstone.TransactionID == "" {
+			log.Printf("Ignoring malformed cancellation tombstone: %s", data)
+			return nil
+		}
+		uc.tombstones.add(tombstone.TransactionID)
+		return nil
+	})
+}
+
+// processorSubscription is the transaction processor's consumers: of the
+// transaction queue and, if it has one, of the tombstone queue
+type processorSubscription struct {
+	transactions domain.Subscription
+	tombstones   domain.Subscription
+}
+
+// Drain drains the transaction consumer, which relies on the tombstones, and
+// then the tombstone consumer, returning how many transactions were drained
+func (s *processorSubscription) Drain(ctx context.Context) (int, error) {
+	drained, err := s.transactions.Drain(ctx)
+	if s.tombstones != nil {
+		if _, tombstoneErr := s.tombstones.Drain(ctx); tombstoneErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to drain cancellation tombstones: %w", tombstoneErr))
+		}
+	}
+	return drained, err
+}
+
+// skipCancelled reports whether a delivered transaction was cancelled while
+// queued, as its tombstone says, counting the skipped message
+func (uc *TransactionUseCase) skipCancelled(id string) bool {
+	if uc.tombstones == nil || !uc.tombstones.contains(id) {
+		return false
+	}
+	skipped := uc.skippedCancelled.Add(1)
+	log.Printf("Skipping cancelled transaction: %s (%d skipped on tombstones)", id, skipped)
+	return true
+}
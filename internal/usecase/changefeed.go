@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+const (
+	defaultChangeFeedLimit = 100
+	maxChangeFeedLimit     = 1000
+)
+
+// ChangeFeedUseCase implements the ChangeFeedService interface.
+//
+// The feed is a watermark over (updated_at, entity, id). Two things keep it
+// from skipping events while writes are in flight:
+//
+//   - Ties: many documents can share an updated_at, so the cursor also records
+//     the entity and ID of the last event and resumes strictly after that
+//     triple rather than after the timestamp alone.
+//   - Late commits: a write stamped at time T may commit after a reader has
+//     already moved past T. Events newer than now minus the settle window are
+//     therefore held back until every write that could carry their timestamp
+//     has committed. The window must exceed the longest write transaction.
+type ChangeFeedUseCase struct {
+	sources      []domain.ChangeSource
+	settleWindow time.Duration
+}
+
+// NewChangeFeedUseCase creates a new change feed use case
+func NewChangeFeedUseCase(sources []domain.ChangeSource, settleWindow time.Duration) domain.ChangeFeedService {
+	return &ChangeFeedUseCase{
+		sources:      sources,
+		settleWindow: settleWindow,
+	}
+}
+
+// GetChanges returns the next page of events after the cursor
+func (uc *ChangeFeedUseCase) GetChanges(ctx context.Context, cursor string, limit int) (*domain.ChangeFeed, error) {
+	after, err := domain.DecodeChangeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultChangeFeedLimit
+	}
+	if limit > maxChangeFeedLimit {
+		limit = maxChangeFeedLimit
+	}
+
+	until := time.Now().Add(-uc.settleWindow)
+
+	// Each source returns its own first limit+1 events, so the merged first
+	// limit events are exact and anything beyond them means there is more
+	var events []*domain.ChangeEvent
+	for _, source := range uc.sources {
+		sourceEvents, err := source.Changes(ctx, after, until, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, sourceEvents...)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Position().Less(events[j].Position())
+	})
+
+	feed := &domain.ChangeFeed{
+		Events:     []*domain.ChangeEvent{},
+		NextCursor: cursor,
+	}
+	if len(events) > limit {
+		events = events[:limit]
+		feed.HasMore = true
+	}
+
+	for _, event := range events {
+		event.Cursor = event.Position().Encode()
+		feed.Events = append(feed.Events, event)
+	}
+	if len(feed.Events) > 0 {
+		feed.NextCursor = feed.Events[len(feed.Events)-1].Cursor
+	}
+
+	return feed, nil
+}
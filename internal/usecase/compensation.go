@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"banking-ledger/internal/domain"
+)
+
+// CompensationTransactionID returns the ID of the compensation returning a
+// split transfer's debit, which stays the same when the transfer is
+// redelivered
+func CompensationTransactionID(transactionID string) string {
+	return transactionID + "-compensating-credit"
+}
+
+// TransferLegID returns the ID a split transfer's debit or credit is posted
+// under, so that each leg is applied once
+func TransferLegID(transactionID string, direction domain.EntryDirection) string {
+	return transactionID + "-" + string(direction) + "-leg"
+}
+
+// splitsTransfer reports whether a transfer's debit and credit are posted
+// separately
+func (uc *TransactionUseCase) splitsTransfer(request *domain.TransactionRequest, from, to *domain.Account) bool {
+	return request.Type == domain.TransactionTypeTransfer &&
+		uc.splitTransfers != nil && uc.ledgerRepo != nil &&
+		uc.splitTransfers(from, to)
+}
+
+// processSplitTransfer debits the source and then credits the destination in
+// separate postings. Only the source is checked before the debit. A delivery
+// resuming after the debit goes straight to the credit, and one resuming
+// after the compensation was recorded finishes it. Split transfers are not
+// charged fees, and only record the balance changes of the legs posted by
+// the delivery that completes them; the ledger entries hold the rest.
+func (uc *TransactionUseCase) processSplitTransfer(ctx context.Context, request *domain.TransactionRequest, from *domain.Account) error {
+	stored, err := uc.transactionRepo.GetByID(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+	if stored.Compensation != nil {
+		return uc.compensateTransfer(ctx, request, stored.Compensation)
+	}
+
+	debitID := TransferLegID(request.ID, domain.EntryDirectionDebit)
+	debited, err := uc.isApplied(ctx, debitID)
+	if err != nil {
+		return err
+	}
+	var changes []*domain.BalanceChange
+	if !debited {
+		if err := uc.checkDebit(ctx, request, from); err != nil {
+			return err
+		}
+
+		posting := domain.LedgerPosting{
+			domain.NewLedgerEntry(debitID, from, domain.EntryDirectionDebit, request.Amount),
+		}
+		if err := uc.post(ctx, posting); err != nil {
+			return err
+		}
+		changes = posting.BalanceChanges(debitID)
+	}
+
+	credited, err := uc.creditTransfer(ctx, request)
+	if err != nil {
+		// A transient failure is retried; the debit is not posted again
+		if !domain.IsPermanent(err) {
+			return err
+		}
+		return uc.compensateTransfer(ctx, request, &domain.Compensation{
+			TransactionID: CompensationTransactionID(request.ID),
+			Status:        domain.CompensationStatusPending,
+			FailureCode:   domain.FailureCodeFor(err),
+			Reason:        err.Error(),
+			CreatedAt:     uc.now(),
+		})
+	}
+
+	return uc.recordCompleted(ctx, request.ID, append(changes, credited...))
+}
+
+// creditTransfer posts the credit leg of a split transfer, returning the
+// balance change it made
+func (uc *TransactionUseCase) creditTransfer(ctx context.Context, request *domain.TransactionRequest) ([]*domain.BalanceChange, error) {
+	to, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := to.Status.CanCredit(); err != nil {
+		return nil, err
+	}
+	if to.Type == domain.AccountTypeFeeCollection {
+		return nil, domain.ErrAccountRestricted
+	}
+	credit, err := uc.creditAmount(ctx, request, to)
+	if err != nil {
+		return nil, err
+	}
+
+	creditID := TransferLegID(request.ID, domain.EntryDirectionCredit)
+	posting := domain.LedgerPosting{
+		domain.NewLedgerEntry(creditID, to, domain.EntryDirectionCredit, credit),
+	}
+	if err := uc.post(ctx, posting); err != nil {
+		return nil, err
+	}
+	return posting.BalanceChanges(creditID), nil
+}
+
+// compensateTransfer records a compensation on a split transfer whose credit
+// failed and queues the credit returning the debited amount. It returns the
+// error that fails the transfer.
+func (uc *TransactionUseCase) compensateTransfer(ctx context.Context, request *domain.TransactionRequest, compensation *domain.Compensation) error {
+	log.Printf("Compensating transfer %s: %s", request.ID, compensation.Reason)
+	if err := uc.transactionRepo.RecordCompensation(ctx, request.ID, compensation); err != nil {
+		return err
+	}
+
+	if err := uc.queueCompensation(ctx, request, compensation); err != nil {
+		return err
+	}
+
+	return &domain.CompensatedError{Code: compensation.FailureCode, Reason: compensation.Reason}
+}
+
+// queueCompensation saves the compensating credit with its queue message and
+// publishes it, unless an earlier delivery already saved it
+func (uc *TransactionUseCase) queueCompensation(ctx context.Context, request *domain.TransactionRequest, compensation *domain.Compensation) error {
+	_, err := uc.transactionRepo.GetByID(ctx, compensation.TransactionID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, domain.ErrTransactionNotFound) {
+		return err
+	}
+
+	credit := &domain.TransactionRequest{
+		ID:                       compensation.TransactionID,
+		Type:                     domain.TransactionTypeCompensation,
+		ToAccountID:              request.FromAccountID,
+		Amount:                   request.Amount,
+		Currency:                 request.Currency,
+		Description:              "Compensation for transfer " + request.ID,
+		System:                   true,
+		CompensatedTransactionID: request.ID,
+	}
+	message, err := uc.outboxMessage(credit)
+	if err != nil {
+		return err
+	}
+
+	now := uc.now()
+	transaction := &domain.Transaction{
+		ID:                       credit.ID,
+		Type:                     credit.Type,
+		ToAccountID:              credit.ToAccountID,
+		Amount:                   credit.Amount,
+		Currency:                 credit.Currency,
+		Status:                   domain.TransactionStatusPending,
+		Description:              credit.Description,
+		System:                   true,
+		CompensatedTransactionID: request.ID,
+		Outbox:                   message,
+		CreatedAt:                now,
+		UpdatedAt:                now,
+	}
+	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+		// A concurrent delivery may have saved it first
+		if _, getErr := uc.transactionRepo.GetByID(ctx, transaction.ID); getErr == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to create compensation: %w", err)
+	}
+
+	uc.dispatch(ctx, transaction.ID, message)
+	return nil
+}
+
+// processCompensation credits a transfer's source account with the debited
+// amount and marks the transfer's compensation completed. The account is
+// credited whatever its status, since the amount has just left it.
+func (uc *TransactionUseCase) processCompensation(ctx context.Context, request *domain.TransactionRequest) error {
+	account, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
+	if err != nil {
+		return err
+	}
+
+	err = uc.post(ctx, domain.LedgerPosting{
+		domain.NewLedgerEntry(request.ID, account, domain.EntryDirectionCredit, request.Amount),
+	})
+	if err != nil {
+		return err
+	}
+
+	return uc.completeCompensation(ctx, request)
+}
+
+// completeCompensation records that a compensation's credit was posted
+func (uc *TransactionUseCase) completeCompensation(ctx context.Context, request *domain.TransactionRequest) error {
+	if err := uc.transactionRepo.CompleteCompensation(ctx, request.CompensatedTransactionID, uc.now()); err != nil {
+		return err
+	}
+	return uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusCompleted, "")
+}
+
+// retryCompensation leaves a compensation that failed to process in the
+// outbox, so the relay queues it again until it succeeds
+func (uc *TransactionUseCase) retryCompensation(ctx context.Context, request *domain.TransactionRequest, cause error) error {
+	log.Printf("Failed to process compensation %s, will retry: %v", request.ID, cause)
+	if err := uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusProcessing, "retrying compensation: "+cause.Error()); err != nil {
+		return err
+	}
+
+	message, err := uc.outboxMessage(request)
+	if err != nil {
+		return err
+	}
+	return uc.transactionRepo.SetOutbox(ctx, request.ID, message)
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"banking-ledger/internal/domain"
+)
+
+// DeadLetterUseCase implements the DeadLetterService interface over the
+// transaction queue
+type DeadLetterUseCase struct {
+	queue     domain.DeadLetterQueue
+	queueName string
+}
+
+// NewDeadLetterUseCase creates a new dead letter use case. A nil queue has no
+// dead letters to serve.
+func NewDeadLetterUseCase(queue domain.DeadLetterQueue, queueName string) domain.DeadLetterService {
+	return &DeadLetterUseCase{
+		queue:     queue,
+		queueName: queueName,
+	}
+}
+
+// ListDeadLetters peeks at the oldest dead letters, clamping the limit
+func (uc *DeadLetterUseCase) ListDeadLetters(ctx context.Context, limit int) ([]*domain.DeadLetter, error) {
+	if uc.queue == nil {
+		return nil, domain.ErrDeadLetterQueueNotConfigured
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > domain.MaxDeadLetterPageSize {
+		limit = domain.MaxDeadLetterPageSize
+	}
+
+	return uc.queue.PeekDeadLetters(ctx, uc.queueName, limit)
+}
+
+// RequeueDeadLetters moves the dead letters with the IDs back onto the
+// transaction queue, where the processor handles them as on first delivery
+func (uc *DeadLetterUseCase) RequeueDeadLetters(ctx context.Context, ids []string) (*domain.DeadLetterRequeue, error) {
+	if uc.queue == nil {
+		return nil, domain.ErrDeadLetterQueueNotConfigured
+	}
+	if len(ids) == 0 || len(ids) > domain.MaxDeadLetterRequeueIDs {
+		return nil, domain.ErrInvalidDeadLetterRequeue
+	}
+	for _, id := range ids {
+		if strings.TrimSpace(id) == "" {
+			return nil, domain.ErrInvalidDeadLetterRequeue
+		}
+	}
+
+	return uc.queue.RequeueDeadLetters(ctx, uc.queueName, ids)
+}
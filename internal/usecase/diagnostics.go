@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// DiagnosticsUseCase implements the DiagnosticsService interface
+type DiagnosticsUseCase struct {
+	accountRepo     domain.AccountRepository
+	transactionRepo domain.TransactionRepository
+}
+
+// NewDiagnosticsUseCase creates a new diagnostics use case
+func NewDiagnosticsUseCase(
+	accountRepo domain.AccountRepository,
+	transactionRepo domain.TransactionRepository,
+) domain.DiagnosticsService {
+	return &DiagnosticsUseCase{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// GetTransactionDiagnostics assembles the diagnostics document for a transaction
+func (uc *DiagnosticsUseCase) GetTransactionDiagnostics(ctx context.Context, id string) (*domain.TransactionDiagnostics, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnostics := &domain.TransactionDiagnostics{
+		Transaction: transaction,
+		GeneratedAt: time.Now(),
+	}
+
+	// Load the current state of every involved account
+	for _, accountID := range []*string{transaction.FromAccountID, transaction.ToAccountID} {
+		if accountID == nil {
+			continue
+		}
+
+		account, err := uc.accountRepo.GetByID(ctx, *accountID)
+		if err != nil {
+			if errors.Is(err, domain.ErrAccountNotFound) {
+				diagnostics.Notes = append(diagnostics.Notes, fmt.Sprintf("account %s not found", *accountID))
+				continue
+			}
+			diagnostics.Accounts = nil
+			diagnostics.Notes = append(diagnostics.Notes, fmt.Sprintf("accounts omitted: account store unavailable: %v", err))
+			break
+		}
+		diagnostics.Accounts = append(diagnostics.Accounts, account)
+	}
+
+	// Sections without a backing store in this deployment
+	diagnostics.Notes = append(diagnostics.Notes,
+		"queue delivery history omitted: no delivery tracking store configured",
+		"dead-letter entries omitted: no dead-letter store configured",
+		"audit events omitted: no audit store configured",
+	)
+
+	return diagnostics, nil
+}
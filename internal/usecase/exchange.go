@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// creditAmount returns the amount a transfer credits its destination with,
+// recording any conversion on the transaction before it is posted
+func (uc *TransactionUseCase) creditAmount(ctx context.Context, request *domain.TransactionRequest, to *domain.Account) (domain.Money, error) {
+	amount, exchange, err := uc.quoteCredit(ctx, request, to)
+	if err != nil {
+		return 0, err
+	}
+	if exchange != nil {
+		if err := uc.transactionRepo.RecordExchange(ctx, request.ID, exchange); err != nil {
+			return 0, err
+		}
+	}
+	return amount, nil
+}
+
+// quoteCredit works out the amount a transfer would credit its destination
+// with. A transfer into an account in another currency is converted at the
+// current rate when exchange rates are enabled, and the conversion is
+// returned for the caller to record.
+func (uc *TransactionUseCase) quoteCredit(ctx context.Context, request *domain.TransactionRequest, to *domain.Account) (domain.Money, *domain.Exchange, error) {
+	if to.Currency == request.Currency {
+		return request.Amount, nil, nil
+	}
+	if uc.exchangeRates == nil || request.Type != domain.TransactionTypeTransfer {
+		return 0, nil, domain.ErrCurrencyMismatch
+	}
+
+	rate, quotedAt, err := uc.exchangeRates.GetRate(ctx, request.Currency, to.Currency)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", domain.ErrRateUnavailable, err)
+	}
+	if age := uc.now().Sub(quotedAt); uc.maxRateAge > 0 && age > uc.maxRateAge {
+		return 0, nil, fmt.Errorf("%w: %s to %s rate is %s old", domain.ErrRateUnavailable, request.Currency, to.Currency, age.Round(time.Second))
+	}
+
+	amount, err := domain.Convert(request.Amount, request.Currency, to.Currency, rate)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return amount, &domain.Exchange{
+		Rate:             rate,
+		QuotedAt:         quotedAt,
+		CreditedAmount:   amount,
+		CreditedCurrency: to.Currency,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+
+	"banking-ledger/internal/domain"
+)
+
+// defaultExportMaxRows is how many transactions one export may hold unless
+// configured otherwise
+const defaultExportMaxRows = 100000
+
+// WithExportMaxRows refuses exports of more than max transactions; zero
+// allows any number
+func WithExportMaxRows(max int64) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.exportMaxRows = max
+	}
+}
+
+// ExportAccountTransactions streams an account's transactions as seen from
+// the account, oldest first. The matching transactions are counted first so
+// that an export too large is refused before anything is written.
+func (uc *TransactionUseCase) ExportAccountTransactions(ctx context.Context, accountID string, filter *domain.TransactionFilter, fn func(*domain.AccountTransaction) error) error {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return err
+	}
+
+	scoped := *filter
+	scoped.AccountID = &accountID
+	scoped.Limit, scoped.Offset = 0, 0
+	if uc.exportMaxRows > 0 {
+		count, err := uc.transactionRepo.Count(ctx, &scoped)
+		if err != nil {
+			return err
+		}
+		if count > uc.exportMaxRows {
+			return &domain.ExportTooLargeError{Count: count, Max: uc.exportMaxRows}
+		}
+	}
+
+	return uc.transactionRepo.EachByAccountID(ctx, accountID, &scoped, func(transaction *domain.Transaction) error {
+		return fn(domain.NewAccountTransaction(transaction, account))
+	})
+}
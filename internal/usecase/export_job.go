@@ -0,0 +1,317 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultExportRetention is how long a finished export's file is kept
+	defaultExportRetention = 24 * time.Hour
+	// defaultExportProgressRows is how many rows are written between
+	// progress updates
+	defaultExportProgressRows = 1000
+	// exportJobStaleAfter is how long a running job may go without progress
+	// before another worker takes it over
+	exportJobStaleAfter = 10 * time.Minute
+	// exportExpiryBatch is how many expired jobs are read at a time
+	exportExpiryBatch = 100
+)
+
+// exportJobMessage is the export queue message naming a job to write
+type exportJobMessage struct {
+	JobID string `json:"job_id"`
+}
+
+// ExportJobUseCase implements the ExportJobService interface. Jobs are
+// recorded when requested and queued for the processor, which streams the
+// matching transactions into a file in the export storage.
+type ExportJobUseCase struct {
+	repo            domain.ExportJobRepository
+	accountRepo     domain.AccountRepository
+	transactionRepo domain.TransactionRepository
+	storage         domain.ExportStorage
+	queue           domain.MessageQueue
+	queueName       string
+	retention       time.Duration
+	progressRows    int64
+	now             func() time.Time
+}
+
+// ExportJobOption configures optional behaviour of the export job use case
+type ExportJobOption func(*ExportJobUseCase)
+
+// WithExportRetention sets how long a finished export's file is kept; zero
+// keeps it until removed by hand
+func WithExportRetention(retention time.Duration) ExportJobOption {
+	return func(uc *ExportJobUseCase) {
+		uc.retention = retention
+	}
+}
+
+// WithExportProgressRows sets how many rows are written between progress
+// updates
+func WithExportProgressRows(rows int64) ExportJobOption {
+	return func(uc *ExportJobUseCase) {
+		if rows > 0 {
+			uc.progressRows = rows
+		}
+	}
+}
+
+// WithExportJobClock sets the clock stamping jobs and expiring their files
+func WithExportJobClock(now func() time.Time) ExportJobOption {
+	return func(uc *ExportJobUseCase) {
+		uc.now = now
+	}
+}
+
+// NewExportJobUseCase creates a new export job use case queueing jobs on
+// queueName
+func NewExportJobUseCase(
+	repo domain.ExportJobRepository,
+	accountRepo domain.AccountRepository,
+	transactionRepo domain.TransactionRepository,
+	storage domain.ExportStorage,
+	queue domain.MessageQueue,
+	queueName string,
+	opts ...ExportJobOption,
+) domain.ExportJobService {
+	uc := &ExportJobUseCase{
+		repo:            repo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		storage:         storage,
+		queue:           queue,
+		queueName:       queueName,
+		retention:       defaultExportRetention,
+		progressRows:    defaultExportProgressRows,
+		now:             time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// CreateExportJob records a job exporting an account's transactions and
+// queues it. A job that cannot be queued is failed straight away.
+func (uc *ExportJobUseCase) CreateExportJob(ctx context.Context, req *domain.ExportJobRequest) (*domain.ExportJob, error) {
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+
+	account, err := uc.accountRepo.GetByID(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+
+	job := &domain.ExportJob{
+		ID:        uuid.New().String(),
+		AccountID: account.ID,
+		UserID:    account.UserID,
+		Format:    req.Format,
+		Filter:    req.Filter,
+		Status:    domain.ExportJobQueued,
+	}
+	if err := uc.repo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	message, err := json.Marshal(exportJobMessage{JobID: job.ID})
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.queue.Publish(ctx, uc.queueName, message); err != nil {
+		job.Status = domain.ExportJobFailed
+		job.Error = "export job could not be queued"
+		uc.finish(ctx, job)
+		return nil, fmt.Errorf("%w: %v", domain.ErrQueueError, err)
+	}
+
+	return job, nil
+}
+
+// GetExportJob retrieves an export job with its progress
+func (uc *ExportJobUseCase) GetExportJob(ctx context.Context, id string) (*domain.ExportJob, error) {
+	job, err := uc.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, job.UserID); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CancelExportJob cancels a job the processor has not started. The queued
+// message is dropped when the processor finds the job cancelled.
+func (uc *ExportJobUseCase) CancelExportJob(ctx context.Context, id string) (*domain.ExportJob, error) {
+	if _, err := uc.GetExportJob(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.repo.Cancel(ctx, id)
+}
+
+// OpenExportFile opens a completed job's file
+func (uc *ExportJobUseCase) OpenExportFile(ctx context.Context, id string) (*domain.ExportJob, io.ReadCloser, error) {
+	job, err := uc.GetExportJob(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != domain.ExportJobCompleted {
+		return nil, nil, domain.ErrExportNotReady
+	}
+
+	file, err := uc.storage.Open(ctx, job.FileLocation)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, file, nil
+}
+
+// HandleExportJob writes the file of the job named by a queue message. A job
+// that was cancelled, has finished or is being written by another worker is
+// skipped, so a redelivered message does nothing.
+func (uc *ExportJobUseCase) HandleExportJob(ctx context.Context, message []byte) error {
+	var msg exportJobMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return &domain.PermanentError{Err: fmt.Errorf("invalid export job message: %w", err)}
+	}
+
+	now := uc.now()
+	job, err := uc.repo.Claim(ctx, msg.JobID, now, now.Add(-exportJobStaleAfter))
+	if errors.Is(err, domain.ErrExportJobNotFound) {
+		return &domain.PermanentError{Err: err}
+	}
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return nil
+	}
+
+	location, err := uc.write(ctx, job)
+	completedAt := uc.now()
+	job.CompletedAt = &completedAt
+	if err != nil {
+		log.Printf("Export job %s failed: %v", job.ID, err)
+		if location != "" {
+			if err := uc.storage.Delete(ctx, location); err != nil {
+				log.Printf("Failed to remove partial export file of job %s: %v", job.ID, err)
+			}
+		}
+		job.Status = domain.ExportJobFailed
+		job.Error = "export could not be written"
+		return uc.repo.Finish(ctx, job)
+	}
+
+	job.Status = domain.ExportJobCompleted
+	job.FileLocation = location
+	if uc.retention > 0 {
+		expiresAt := completedAt.Add(uc.retention)
+		job.ExpiresAt = &expiresAt
+	}
+	return uc.repo.Finish(ctx, job)
+}
+
+// write streams the job's transactions, oldest first, into a new file and
+// returns where it is kept. The total is counted first and the rows written
+// are recorded every progressRows rows.
+func (uc *ExportJobUseCase) write(ctx context.Context, job *domain.ExportJob) (string, error) {
+	account, err := uc.accountRepo.GetByID(ctx, job.AccountID)
+	if err != nil {
+		return "", err
+	}
+
+	filter := job.Filter
+	filter.AccountID = &job.AccountID
+	total, err := uc.transactionRepo.Count(ctx, &filter)
+	if err != nil {
+		return "", err
+	}
+	job.TotalRows = &total
+	if err := uc.repo.UpdateProgress(ctx, job); err != nil {
+		return "", err
+	}
+
+	file, location, err := uc.storage.Create(ctx, fmt.Sprintf("export-%s.%s", job.ID, domain.ExportFileExtension(job.Format)))
+	if err != nil {
+		return "", err
+	}
+	buffered := bufio.NewWriter(file)
+	encoder, err := domain.NewExportEncoder(buffered, job.Format)
+	if err == nil {
+		err = uc.transactionRepo.EachByAccountID(ctx, job.AccountID, &filter, func(transaction *domain.Transaction) error {
+			if err := encoder.Encode(domain.NewAccountTransaction(transaction, account)); err != nil {
+				return err
+			}
+			job.RowCount++
+			if job.RowCount%uc.progressRows != 0 {
+				return nil
+			}
+			return uc.repo.UpdateProgress(ctx, job)
+		})
+	}
+	if err == nil {
+		err = encoder.Flush()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return location, err
+}
+
+// ExpireExports removes the files of completed jobs past their retention and
+// marks the jobs expired
+func (uc *ExportJobUseCase) ExpireExports(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		jobs, err := uc.repo.ListExpired(ctx, uc.now(), exportExpiryBatch)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, job := range jobs {
+			if err := uc.storage.Delete(ctx, job.FileLocation); err != nil {
+				return expired, err
+			}
+			if err := uc.repo.Expire(ctx, job.ID); err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(jobs) < exportExpiryBatch {
+			return expired, nil
+		}
+	}
+}
+
+// finish records a job's outcome, logging rather than returning a failure
+// since the caller is already reporting one
+func (uc *ExportJobUseCase) finish(ctx context.Context, job *domain.ExportJob) {
+	completedAt := uc.now()
+	job.CompletedAt = &completedAt
+	if err := uc.repo.Finish(ctx, job); err != nil {
+		log.Printf("Failed to record export job %s: %v", job.ID, err)
+	}
+}
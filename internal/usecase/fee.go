@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// FeeFailureMode decides what happens to a transaction whose fee cannot be
+// charged, such as when the source account cannot cover it
+type FeeFailureMode string
+
+const (
+	// FeeFailureFail fails the whole transaction
+	FeeFailureFail FeeFailureMode = "fail"
+	// FeeFailureRecord completes the transaction and records the fee as failed
+	FeeFailureRecord FeeFailureMode = "record"
+)
+
+// FeeRule charges a flat amount plus a percentage of a transaction's amount
+type FeeRule struct {
+	Flat domain.Money
+	// BasisPoints is the percentage part in hundredths of a percent
+	BasisPoints int64
+}
+
+// FeeFor returns the fee on amount, rounding the percentage part half up
+func (r FeeRule) FeeFor(amount domain.Money) domain.Money {
+	return r.Flat + domain.Money((int64(amount)*r.BasisPoints+5000)/10000)
+}
+
+type feeKey struct {
+	transactionType domain.TransactionType
+	currency        string
+}
+
+// FeePolicy decides the fee charged on completed withdrawals and transfers
+// and the account that collects it
+type FeePolicy struct {
+	rules               map[feeKey]FeeRule
+	collectionAccountID string
+	failureMode         FeeFailureMode
+	exemptSelfTransfers bool
+}
+
+// NewFeePolicy creates a policy without rules that pays fees into the
+// collection account
+func NewFeePolicy(collectionAccountID string, failureMode FeeFailureMode) *FeePolicy {
+	return &FeePolicy{
+		rules:               make(map[feeKey]FeeRule),
+		collectionAccountID: collectionAccountID,
+		failureMode:         failureMode,
+	}
+}
+
+// LoadFeePolicy builds a policy from rules written as
+// "type:currency:flat:percent", such as "withdrawal:USD:0.50:1.5"
+func LoadFeePolicy(entries []string, collectionAccountID, failureMode string) (*FeePolicy, error) {
+	mode := FeeFailureMode(strings.ToLower(failureMode))
+	if mode != FeeFailureFail && mode != FeeFailureRecord {
+		return nil, fmt.Errorf("unknown fee failure mode %q", failureMode)
+	}
+
+	policy := NewFeePolicy(collectionAccountID, mode)
+	if len(entries) > 0 && collectionAccountID == "" {
+		return nil, fmt.Errorf("fee rules need a fee collection account")
+	}
+
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("invalid fee rule %q: want type:currency:flat:percent", entry)
+		}
+
+		transactionType := domain.TransactionType(strings.ToLower(parts[0]))
+		if transactionType != domain.TransactionTypeWithdrawal && transactionType != domain.TransactionTypeTransfer {
+			return nil, fmt.Errorf("invalid fee rule %q: fees apply to withdrawals and transfers", entry)
+		}
+		currency, err := domain.SupportedCurrencies.Normalize(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee rule %q: %w", entry, err)
+		}
+		flat, err := domain.Decimal(parts[2]).Money(currency)
+		if err != nil || flat < 0 {
+			return nil, fmt.Errorf("invalid fee rule %q: bad flat amount", entry)
+		}
+		percent, err := strconv.ParseFloat(parts[3], 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid fee rule %q: bad percentage", entry)
+		}
+
+		policy.SetRule(transactionType, currency, FeeRule{
+			Flat:        flat,
+			BasisPoints: int64(math.Round(percent * 100)),
+		})
+	}
+
+	return policy, nil
+}
+
+// SetRule sets the fee charged on transactions of a type in a currency
+func (p *FeePolicy) SetRule(transactionType domain.TransactionType, currency string, rule FeeRule) {
+	p.rules[feeKey{transactionType, currency}] = rule
+}
+
+// ExemptSelfTransfers sets whether transfers between a user's own accounts
+// are charged fees
+func (p *FeePolicy) ExemptSelfTransfers(exempt bool) {
+	p.exemptSelfTransfers = exempt
+}
+
+// FeeFor returns the fee due on a request, or zero when none applies
+func (p *FeePolicy) FeeFor(request *domain.TransactionRequest) domain.Money {
+	if request.SelfTransfer && p.exemptSelfTransfers {
+		return 0
+	}
+	rule, ok := p.rules[feeKey{request.Type, request.Currency}]
+	if !ok {
+		return 0
+	}
+	return rule.FeeFor(request.Amount)
+}
+
+// FeeTransactionID returns the ID of the fee charged on a transaction, which
+// stays the same when the transaction is redelivered
+func FeeTransactionID(transactionID string) string {
+	return transactionID + "-fee"
+}
+
+// feeCharge is the fee due on a transaction being processed
+type feeCharge struct {
+	transaction *domain.Transaction
+	collection  *domain.Account
+	// failure is why the fee cannot be taken when the policy lets the
+	// transaction complete without it
+	failure error
+}
+
+// prepareFee works out the fee on a request debiting source. It returns nil
+// when no fee applies, and an error when the fee cannot be charged and the
+// policy fails the whole transaction.
+func (uc *TransactionUseCase) prepareFee(ctx context.Context, request *domain.TransactionRequest, source *domain.Account) (*feeCharge, error) {
+	if uc.fees == nil {
+		return nil, nil
+	}
+	amount := uc.fees.FeeFor(request)
+	if amount <= 0 || source.ID == uc.fees.collectionAccountID {
+		return nil, nil
+	}
+
+	charge := &feeCharge{
+		transaction: &domain.Transaction{
+			ID:            FeeTransactionID(request.ID),
+			Type:          domain.TransactionTypeFee,
+			FromAccountID: &source.ID,
+			ToAccountID:   &uc.fees.collectionAccountID,
+			Amount:        amount,
+			Currency:      request.Currency,
+			Description:   "Fee for " + string(request.Type) + " " + request.ID,
+			Metadata:      map[string]interface{}{"parent_transaction_id": request.ID},
+			System:        true,
+		},
+	}
+
+	collection, err := uc.accountRepo.GetByID(ctx, uc.fees.collectionAccountID)
+	if err == nil {
+		charge.collection = collection
+		err = collection.Status.CanCredit()
+	}
+	if err == nil && collection.Currency != request.Currency {
+		err = domain.ErrCurrencyMismatch
+	}
+	if err == nil {
+		err = source.CheckFunds(request.Amount + amount)
+	}
+	if err != nil {
+		// Unexpected errors are returned so the transaction is retried
+		// rather than its fee being written off
+		if uc.fees.failureMode == FeeFailureFail || domain.FailureCodeFor(err) == domain.FailureCodeInternal {
+			return nil, err
+		}
+		charge.failure = err
+	}
+
+	return charge, nil
+}
+
+// appendTo adds the fee's entries to a posting: a debit of the source account
+// as the posting leaves it, and a credit of the collection account
+func (c *feeCharge) appendTo(posting domain.LedgerPosting, source *domain.Account) domain.LedgerPosting {
+	if c == nil || c.failure != nil {
+		return posting
+	}
+
+	posting = append(posting, domain.NewLedgerEntry(c.transaction.ID, settled(source, posting), domain.EntryDirectionDebit, c.transaction.Amount))
+	return append(posting, domain.NewLedgerEntry(c.transaction.ID, settled(c.collection, posting), domain.EntryDirectionCredit, c.transaction.Amount))
+}
+
+// settled returns a copy of an account as it stands after a posting's entries
+func settled(account *domain.Account, posting domain.LedgerPosting) *domain.Account {
+	copied := *account
+	for _, entry := range posting {
+		if entry.AccountID == account.ID {
+			copied.Balance = entry.ResultingBalance
+			copied.Version = entry.AccountVersion
+		}
+	}
+	return &copied
+}
+
+// recordFee saves the fee transaction once its parent has been posted. The
+// ledger entries are authoritative, so a failure here is logged rather than
+// returned.
+func (uc *TransactionUseCase) recordFee(ctx context.Context, charge *feeCharge) {
+	if charge == nil {
+		return
+	}
+
+	now := time.Now()
+	transaction := charge.transaction
+	transaction.Status = domain.TransactionStatusCompleted
+	transaction.ProcessedAt = &now
+	if charge.failure != nil {
+		transaction.Status = domain.TransactionStatusFailed
+		transaction.ErrorMessage = charge.failure.Error()
+		transaction.FailureCode = domain.FailureCodeFor(charge.failure)
+	}
+
+	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+		log.Printf("Failed to record fee transaction %s: %v", transaction.ID, err)
+	}
+}
@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// expiredHoldBatch is how many expired holds one sweep handles
+const expiredHoldBatch = 100
+
+// HoldUseCase implements the HoldService interface
+type HoldUseCase struct {
+	accountRepo     domain.AccountRepository
+	holdRepo        domain.HoldRepository
+	transactionRepo domain.TransactionRepository
+	ttl             time.Duration
+	now             func() time.Time
+}
+
+// HoldOption configures optional behaviour of the hold use case
+type HoldOption func(*HoldUseCase)
+
+// WithHoldClock overrides the clock used for hold expiry
+func WithHoldClock(now func() time.Time) HoldOption {
+	return func(uc *HoldUseCase) {
+		uc.now = now
+	}
+}
+
+// NewHoldUseCase creates a new hold use case. Holds expire after ttl unless
+// captured or released first.
+func NewHoldUseCase(
+	accountRepo domain.AccountRepository,
+	holdRepo domain.HoldRepository,
+	transactionRepo domain.TransactionRepository,
+	ttl time.Duration,
+	opts ...HoldOption,
+) domain.HoldService {
+	uc := &HoldUseCase{
+		accountRepo:     accountRepo,
+		holdRepo:        holdRepo,
+		transactionRepo: transactionRepo,
+		ttl:             ttl,
+		now:             time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// PlaceHold reserves funds on an account the caller owns, retrying when a
+// concurrent balance update bumps the account's version
+func (uc *HoldUseCase) PlaceHold(ctx context.Context, request *domain.HoldRequest) (*domain.Hold, error) {
+	const maxRetries = 3
+
+	if err := request.IsValid(); err != nil {
+		return nil, err
+	}
+
+	hold := &domain.Hold{
+		ID:          uuid.New().String(),
+		AccountID:   request.AccountID,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		Status:      domain.HoldStatusActive,
+		Description: request.Description,
+		Reference:   request.Reference,
+		ExpiresAt:   uc.now().Add(uc.ttl),
+	}
+
+	for attempt := 1; ; attempt++ {
+		account, err := uc.accountRepo.GetByID(ctx, request.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+			return nil, err
+		}
+		if err := account.Status.CanDebit(); err != nil {
+			return nil, err
+		}
+		if account.Currency != request.Currency {
+			return nil, domain.ErrCurrencyMismatch
+		}
+		if err := account.CheckFunds(request.Amount); err != nil {
+			return nil, err
+		}
+
+		err = uc.holdRepo.Place(ctx, hold, account)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, domain.ErrConcurrentUpdate) || attempt == maxRetries {
+			return nil, err
+		}
+	}
+
+	uc.record(ctx, &domain.Transaction{
+		ID:            hold.ID,
+		Type:          domain.TransactionTypeHold,
+		FromAccountID: &hold.AccountID,
+		Amount:        hold.Amount,
+		Currency:      hold.Currency,
+		Description:   hold.Description,
+		Metadata:      map[string]interface{}{"expires_at": hold.ExpiresAt, "reference": hold.Reference},
+	})
+
+	return hold, nil
+}
+
+// GetHold retrieves a hold by ID, failing with ErrForbidden unless the caller
+// owns its account
+func (uc *HoldUseCase) GetHold(ctx context.Context, id string) (*domain.Hold, error) {
+	hold, err := uc.holdRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if domain.Unscoped(ctx) {
+		return hold, nil
+	}
+
+	account, err := uc.accountRepo.GetByID(ctx, hold.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// CaptureHold debits the captured amount and releases the whole hold. A hold
+// past its expiry is expired instead and cannot be captured.
+func (uc *HoldUseCase) CaptureHold(ctx context.Context, id string, amount domain.Decimal) (*domain.Hold, error) {
+	expired := false
+	hold, err := uc.resolve(ctx, id, func(hold *domain.Hold, account *domain.Account) (domain.LedgerPosting, error) {
+		if hold.IsExpired(uc.now()) {
+			expired = true
+			hold.Status = domain.HoldStatusExpired
+			return nil, nil
+		}
+
+		captured := hold.Amount
+		if amount != "" {
+			parsed, err := amount.Money(hold.Currency)
+			if err != nil {
+				return nil, err
+			}
+			if parsed <= 0 {
+				return nil, domain.ErrInvalidAmount
+			}
+			if parsed > hold.Amount {
+				return nil, domain.ErrCaptureExceedsHold
+			}
+			captured = parsed
+		}
+		if err := account.Status.CanDebit(); err != nil {
+			return nil, err
+		}
+
+		hold.Status = domain.HoldStatusCaptured
+		hold.CapturedAmount = captured
+		return domain.LedgerPosting{
+			domain.NewLedgerEntry(hold.CaptureID(), account, domain.EntryDirectionDebit, captured),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, domain.ErrHoldNotActive
+	}
+
+	now := uc.now()
+	uc.record(ctx, &domain.Transaction{
+		ID:            hold.CaptureID(),
+		Type:          domain.TransactionTypeCapture,
+		FromAccountID: &hold.AccountID,
+		Amount:        hold.CapturedAmount,
+		Currency:      hold.Currency,
+		Description:   hold.Description,
+		Metadata:      map[string]interface{}{"hold_id": hold.ID},
+		ProcessedAt:   &now,
+	})
+
+	return hold, nil
+}
+
+// ReleaseHold returns a hold's funds to the account without debiting them
+func (uc *HoldUseCase) ReleaseHold(ctx context.Context, id string) (*domain.Hold, error) {
+	return uc.resolve(ctx, id, func(hold *domain.Hold, account *domain.Account) (domain.LedgerPosting, error) {
+		hold.Status = domain.HoldStatusReleased
+		return nil, nil
+	})
+}
+
+// ExpireHolds releases active holds whose window has closed
+func (uc *HoldUseCase) ExpireHolds(ctx context.Context) (int, error) {
+	holds, err := uc.holdRepo.ListExpiredActive(ctx, uc.now(), expiredHoldBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, hold := range holds {
+		_, err := uc.resolve(ctx, hold.ID, func(hold *domain.Hold, account *domain.Account) (domain.LedgerPosting, error) {
+			hold.Status = domain.HoldStatusExpired
+			return nil, nil
+		})
+		if err != nil {
+			// A hold captured or released since it was listed is not an error
+			if !errors.Is(err, domain.ErrHoldNotActive) {
+				log.Printf("Failed to expire hold %s: %v", hold.ID, err)
+			}
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// resolve closes an active hold as decided by apply, which sets the hold's
+// new status and returns any posting to apply with it. The hold and account
+// are re-read and apply is called again when either changes concurrently, so
+// a capture racing expiry or release resolves the hold exactly once. Only the
+// owner of the hold's account may resolve it.
+func (uc *HoldUseCase) resolve(
+	ctx context.Context,
+	id string,
+	apply func(hold *domain.Hold, account *domain.Account) (domain.LedgerPosting, error),
+) (*domain.Hold, error) {
+	const maxRetries = 3
+
+	for attempt := 1; ; attempt++ {
+		hold, err := uc.holdRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		account, err := uc.accountRepo.GetByID(ctx, hold.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+			return nil, err
+		}
+		if hold.Status != domain.HoldStatusActive {
+			return nil, domain.ErrHoldNotActive
+		}
+
+		posting, err := apply(hold, account)
+		if err != nil {
+			return nil, err
+		}
+
+		err = uc.holdRepo.Resolve(ctx, hold, account, posting)
+		if err == nil {
+			return hold, nil
+		}
+		if !errors.Is(err, domain.ErrConcurrentUpdate) || attempt == maxRetries {
+			return nil, err
+		}
+	}
+}
+
+// record saves the transaction history entry for a hold or capture. The hold
+// itself is authoritative, so a failure here is logged rather than returned.
+func (uc *HoldUseCase) record(ctx context.Context, transaction *domain.Transaction) {
+	transaction.Status = domain.TransactionStatusCompleted
+	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+		log.Printf("Failed to record %s transaction %s: %v", transaction.Type, transaction.ID, err)
+	}
+}
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// AccountLedgerUseCase implements the AccountLedgerService interface
+type AccountLedgerUseCase struct {
+	accountRepo domain.AccountRepository
+	ledgerRepo  domain.LedgerEntryRepository
+}
+
+// NewAccountLedgerUseCase creates a new account ledger use case
+func NewAccountLedgerUseCase(accountRepo domain.AccountRepository, ledgerRepo domain.LedgerEntryRepository) domain.AccountLedgerService {
+	return &AccountLedgerUseCase{
+		accountRepo: accountRepo,
+		ledgerRepo:  ledgerRepo,
+	}
+}
+
+// GetAccountLedger lists an account's ledger entries with their running
+// balances, newest first
+func (uc *AccountLedgerUseCase) GetAccountLedger(ctx context.Context, accountID string, limit, offset int) ([]*domain.LedgerEntry, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return uc.ledgerRepo.GetByAccountID(ctx, accountID, limit, offset)
+}
+
+// historyPageSize is how many transactions are read per query when walking
+// an account's history back from its balance
+const historyPageSize = 500
+
+// LedgerUseCase implements the LedgerService interface over the stored
+// transactions
+type LedgerUseCase struct {
+	accountRepo      domain.AccountRepository
+	transactionRepo  domain.TransactionRepository
+	maxStatementDays int
+	now              func() time.Time
+}
+
+// LedgerOption configures optional behaviour of the ledger use case
+type LedgerOption func(*LedgerUseCase)
+
+// WithStatementMaxDays rejects statements covering more than days days; zero
+// allows any period
+func WithStatementMaxDays(days int) LedgerOption {
+	return func(uc *LedgerUseCase) {
+		uc.maxStatementDays = days
+	}
+}
+
+// WithLedgerClock sets the clock the current month is taken from
+func WithLedgerClock(now func() time.Time) LedgerOption {
+	return func(uc *LedgerUseCase) {
+		uc.now = now
+	}
+}
+
+// NewLedgerUseCase creates a new ledger use case
+func NewLedgerUseCase(accountRepo domain.AccountRepository, transactionRepo domain.TransactionRepository, opts ...LedgerOption) domain.LedgerService {
+	uc := &LedgerUseCase{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		now:             time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// RecordTransaction stores a transaction
+func (uc *LedgerUseCase) RecordTransaction(ctx context.Context, transaction *domain.Transaction) error {
+	return uc.transactionRepo.Create(ctx, transaction)
+}
+
+// GetAccountBalance retrieves an account's current balance
+func (uc *LedgerUseCase) GetAccountBalance(ctx context.Context, accountID string) (domain.Money, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	return account.Balance, nil
+}
+
+// GetTransactionHistory retrieves transaction history for an account
+func (uc *LedgerUseCase) GetTransactionHistory(ctx context.Context, accountID string, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	return uc.transactionRepo.GetByAccountID(ctx, accountID, filter)
+}
+
+// GetAccountStatement lists the transactions that moved the account's
+// balance in the period, oldest first, with a running balance. Balances are
+// not stored by date, so the closing balance is the current balance less
+// every movement since the period and the opening balance is the closing
+// balance less the period's movements.
+func (uc *LedgerUseCase) GetAccountStatement(ctx context.Context, accountID string, fromDate, toDate string) (*domain.AccountStatement, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+
+	start, end := domain.StatementPeriod(fromDate, toDate, uc.now())
+	if uc.maxStatementDays > 0 && end.Sub(start) > time.Duration(uc.maxStatementDays)*24*time.Hour {
+		return nil, domain.ErrStatementPeriodTooLong
+	}
+
+	return buildStatement(ctx, uc.transactionRepo, account, start, end)
+}
+
+// buildStatement builds an account's statement of the days from start up to
+// end, walking back from its current balance
+func buildStatement(ctx context.Context, transactionRepo domain.TransactionRepository, account *domain.Account, start, end time.Time) (*domain.AccountStatement, error) {
+	transactions, err := transactionsSince(ctx, transactionRepo, account.ID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &domain.AccountStatement{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		From:      start.Format(domain.StatementDateLayout),
+		To:        end.AddDate(0, 0, -1).Format(domain.StatementDateLayout),
+		Lines:     []domain.StatementLine{},
+	}
+
+	closing := account.Balance
+	for _, transaction := range transactions {
+		amount := transaction.Movements()[account.ID]
+		if amount == 0 {
+			continue
+		}
+		if !transaction.CreatedAt.Before(end) {
+			closing -= amount
+			continue
+		}
+		statement.Lines = append(statement.Lines, domain.StatementLine{
+			TransactionID: transaction.ID,
+			Type:          transaction.Type,
+			Status:        transaction.Status,
+			Description:   transaction.Description,
+			Reference:     transaction.Reference,
+			Date:          transaction.CreatedAt,
+			Amount:        amount,
+		})
+	}
+
+	// Lines are newest first, so walk back from the closing balance
+	balance := closing
+	for i := range statement.Lines {
+		statement.Lines[i].Balance = balance
+		balance -= statement.Lines[i].Amount
+	}
+	slices.Reverse(statement.Lines)
+
+	statement.OpeningBalance = balance
+	statement.ClosingBalance = closing
+	return statement, nil
+}
+
+// transactionsSince reads every transaction of the account created since
+// start, newest first. Transactions created while paging shift later pages,
+// so any read twice are skipped.
+func transactionsSince(ctx context.Context, transactionRepo domain.TransactionRepository, accountID string, start time.Time) ([]*domain.Transaction, error) {
+	var transactions []*domain.Transaction
+	seen := make(map[string]bool)
+
+	for offset := 0; ; offset += historyPageSize {
+		page, err := transactionRepo.GetByAccountID(ctx, accountID, &domain.TransactionFilter{
+			FromDate: &start,
+			Limit:    historyPageSize,
+			Offset:   offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, transaction := range page {
+			if !seen[transaction.ID] {
+				seen[transaction.ID] = true
+				transactions = append(transactions, transaction)
+			}
+		}
+		if len(page) < historyPageSize {
+			break
+		}
+	}
+
+	slices.SortStableFunc(transactions, func(a, b *domain.Transaction) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return transactions, nil
+}
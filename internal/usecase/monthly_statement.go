@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultStatementPageSize is how many accounts are read per query
+	defaultStatementPageSize = 500
+	// defaultStatementDay is the day of the month the last month's
+	// statements are generated on
+	defaultStatementDay = 1
+)
+
+// MonthlyStatementUseCase implements the MonthlyStatementService interface.
+// A statement is generated once per account and month; generating a month
+// again only publishes the events of statements whose events were not
+// published.
+type MonthlyStatementUseCase struct {
+	accountRepo     domain.AccountRepository
+	transactionRepo domain.TransactionRepository
+	statementRepo   domain.MonthlyStatementRepository
+	notifier        domain.NotificationService
+	pageSize        int
+	day             int
+	now             func() time.Time
+
+	// lastMonth is the last month this process generated in full
+	mu        sync.Mutex
+	lastMonth *time.Time
+}
+
+// MonthlyStatementOption configures optional behaviour of the monthly statement use case
+type MonthlyStatementOption func(*MonthlyStatementUseCase)
+
+// WithStatementPageSize sets how many accounts are read per query
+func WithStatementPageSize(size int) MonthlyStatementOption {
+	return func(uc *MonthlyStatementUseCase) {
+		if size > 0 {
+			uc.pageSize = size
+		}
+	}
+}
+
+// WithStatementDay sets the day of the month, from 1 to 28, on which the last
+// month's statements are due
+func WithStatementDay(day int) MonthlyStatementOption {
+	return func(uc *MonthlyStatementUseCase) {
+		if day >= 1 && day <= 28 {
+			uc.day = day
+		}
+	}
+}
+
+// WithStatementClock sets the clock deciding which months are due
+func WithStatementClock(now func() time.Time) MonthlyStatementOption {
+	return func(uc *MonthlyStatementUseCase) {
+		uc.now = now
+	}
+}
+
+// WithStatementNotifications publishes a statement.generated event for each
+// statement generated
+func WithStatementNotifications(notifier domain.NotificationService) MonthlyStatementOption {
+	return func(uc *MonthlyStatementUseCase) {
+		uc.notifier = notifier
+	}
+}
+
+// NewMonthlyStatementUseCase creates a new monthly statement use case
+func NewMonthlyStatementUseCase(
+	accountRepo domain.AccountRepository,
+	transactionRepo domain.TransactionRepository,
+	statementRepo domain.MonthlyStatementRepository,
+	opts ...MonthlyStatementOption,
+) domain.MonthlyStatementService {
+	uc := &MonthlyStatementUseCase{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		statementRepo:   statementRepo,
+		pageSize:        defaultStatementPageSize,
+		day:             defaultStatementDay,
+		now:             time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// GenerateMonth generates the statement of the month holding month for every
+// account opened by its end, leaving out accounts closed before it began.
+// Events that fail to publish are retried when the month is generated again,
+// and fail the run once every account has been seen to.
+func (uc *MonthlyStatementUseCase) GenerateMonth(ctx context.Context, month time.Time) (int, error) {
+	start := domain.StatementMonth(month)
+	end := start.AddDate(0, 1, 0)
+	if end.After(uc.now()) {
+		return 0, domain.ErrInvalidInput
+	}
+
+	generated := 0
+	unpublished := 0
+	var publishErr error
+	afterID := ""
+	for {
+		accounts, err := uc.accountRepo.ListAfter(ctx, afterID, uc.pageSize)
+		if err != nil {
+			return generated, err
+		}
+		if len(accounts) == 0 {
+			break
+		}
+
+		for _, account := range accounts {
+			if !account.CreatedAt.Before(end) || closedBefore(account, start) {
+				continue
+			}
+			created, err := uc.generate(ctx, account.ID, start, end)
+			if created {
+				generated++
+			}
+			var failed *statementPublishError
+			switch {
+			case errors.As(err, &failed):
+				unpublished++
+				publishErr = failed.err
+			case err != nil:
+				return generated, err
+			}
+		}
+
+		afterID = accounts[len(accounts)-1].ID
+		if len(accounts) < uc.pageSize {
+			break
+		}
+	}
+
+	if publishErr != nil {
+		return generated, fmt.Errorf("failed to publish %d statement events: %w", unpublished, publishErr)
+	}
+	return generated, nil
+}
+
+// GenerateDue generates the statements of the last month whose statement day
+// has come, unless this process already has. Earlier months missed are not
+// caught up; GenerateMonth generates them.
+func (uc *MonthlyStatementUseCase) GenerateDue(ctx context.Context) (int, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	now := uc.now().UTC()
+	due := domain.StatementMonth(now).AddDate(0, -1, 0)
+	if now.Day() < uc.day {
+		due = due.AddDate(0, -1, 0)
+	}
+	if uc.lastMonth != nil && uc.lastMonth.Equal(due) {
+		return 0, nil
+	}
+
+	generated, err := uc.GenerateMonth(ctx, due)
+	if err != nil {
+		return generated, err
+	}
+	uc.lastMonth = &due
+	return generated, nil
+}
+
+// ListStatements lists an account's statements without their lines, newest
+// month first
+func (uc *MonthlyStatementUseCase) ListStatements(ctx context.Context, accountID string, limit, offset int) ([]*domain.MonthlyStatement, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return uc.statementRepo.ListByAccount(ctx, accountID, limit, offset)
+}
+
+// GetStatement returns one of an account's statements with its lines. The
+// statement of another account is not found.
+func (uc *MonthlyStatementUseCase) GetStatement(ctx context.Context, accountID, statementID string) (*domain.MonthlyStatement, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+
+	statement, err := uc.statementRepo.GetByID(ctx, statementID)
+	if err != nil {
+		return nil, err
+	}
+	if statement.AccountID != account.ID {
+		return nil, domain.ErrStatementNotFound
+	}
+	if statement.Lines == nil {
+		statement.Lines = []domain.StatementLine{}
+	}
+	return statement, nil
+}
+
+// statementPublishError is a statement stored without its event published
+type statementPublishError struct {
+	err error
+}
+
+func (e *statementPublishError) Error() string {
+	return e.err.Error()
+}
+
+// generate stores the account's statement of the month from start up to end
+// if it has none, reporting whether it did, and publishes the statement's
+// event if it has not been
+func (uc *MonthlyStatementUseCase) generate(ctx context.Context, accountID string, start, end time.Time) (bool, error) {
+	month := start.Format(domain.StatementMonthLayout)
+	created := false
+
+	// The account is read again so that its balance is as current as the
+	// transactions the statement walks back through
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return false, err
+	}
+
+	statement, err := uc.statementRepo.GetForMonth(ctx, accountID, month)
+	switch {
+	case errors.Is(err, domain.ErrStatementNotFound):
+		built, err := buildStatement(ctx, uc.transactionRepo, account, start, end)
+		if err != nil {
+			return false, err
+		}
+		statement = domain.NewMonthlyStatement(uuid.New().String(), built, start, uc.now().UTC())
+		created, err = uc.statementRepo.Create(ctx, statement)
+		if err != nil {
+			return false, err
+		}
+		if !created {
+			// Another run stored it first and publishes its event
+			return false, nil
+		}
+	case err != nil:
+		return false, err
+	case statement.NotifiedAt != nil:
+		return false, nil
+	}
+
+	if uc.notifier == nil {
+		return created, nil
+	}
+	if err := uc.notifier.NotifyStatementGenerated(ctx, account, statement.ID); err != nil {
+		log.Printf("Failed to publish statement %s of account %s: %v", statement.ID, accountID, err)
+		return created, &statementPublishError{err: err}
+	}
+	if err := uc.statementRepo.MarkNotified(ctx, statement.ID, uc.now().UTC()); err != nil {
+		return created, err
+	}
+	return created, nil
+}
+
+// closedBefore reports whether the account was closed before a time
+func closedBefore(account *domain.Account, at time.Time) bool {
+	return account.Status == domain.AccountStatusClosed &&
+		account.StatusChangedAt != nil && account.StatusChangedAt.Before(at)
+}
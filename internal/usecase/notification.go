@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// NotificationUseCase implements the NotificationService interface by
+// publishing events to a queue
+type NotificationUseCase struct {
+	queue     domain.MessageQueue
+	queueName string
+	now       func() time.Time
+}
+
+// NewNotificationUseCase creates a notification service publishing to
+// queueName
+func NewNotificationUseCase(queue domain.MessageQueue, queueName string) domain.NotificationService {
+	return &NotificationUseCase{
+		queue:     queue,
+		queueName: queueName,
+		now:       time.Now,
+	}
+}
+
+// NotifyTransactionCompleted publishes a transaction.completed event, or a
+// transfer.internal event for a transfer between a user's own accounts
+func (uc *NotificationUseCase) NotifyTransactionCompleted(ctx context.Context, transaction *domain.Transaction) error {
+	eventType := domain.NotificationTransactionCompleted
+	if transaction.SelfTransfer {
+		eventType = domain.NotificationTransferInternal
+	}
+	return uc.publish(ctx, &domain.NotificationEvent{
+		Type:        eventType,
+		Transaction: transaction.WithoutBalanceChanges(),
+	})
+}
+
+// NotifyTransactionFailed publishes a transaction.failed event
+func (uc *NotificationUseCase) NotifyTransactionFailed(ctx context.Context, transaction *domain.Transaction, err error) error {
+	event := &domain.NotificationEvent{
+		Type:        domain.NotificationTransactionFailed,
+		Transaction: transaction.WithoutBalanceChanges(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return uc.publish(ctx, event)
+}
+
+// NotifyLowBalance publishes an account.low_balance event
+func (uc *NotificationUseCase) NotifyLowBalance(ctx context.Context, account *domain.Account) error {
+	return uc.publish(ctx, &domain.NotificationEvent{
+		Type:    domain.NotificationAccountLowBalance,
+		Account: account,
+	})
+}
+
+// NotifyAccountCreated publishes an account.created event
+func (uc *NotificationUseCase) NotifyAccountCreated(ctx context.Context, account *domain.Account) error {
+	return uc.publish(ctx, &domain.NotificationEvent{
+		Type:    domain.NotificationAccountCreated,
+		Account: account,
+	})
+}
+
+// NotifyStatementGenerated publishes a statement.generated event
+func (uc *NotificationUseCase) NotifyStatementGenerated(ctx context.Context, account *domain.Account, statementID string) error {
+	return uc.publish(ctx, &domain.NotificationEvent{
+		Type:        domain.NotificationStatementGenerated,
+		Account:     account,
+		StatementID: statementID,
+	})
+}
+
+func (uc *NotificationUseCase) publish(ctx context.Context, event *domain.NotificationEvent) error {
+	event.EventID = uuid.New().String()
+	event.OccurredAt = uc.now().UTC()
+
+	message, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s notification: %w", event.Type, err)
+	}
+
+	if err := uc.queue.Publish(ctx, uc.queueName, message); err != nil {
+		return fmt.Errorf("failed to publish %s notification: %w", event.Type, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+const (
+	// processingRateWindow is how far back completions count toward a
+	// processor's rate, and how recently a rate must have been reported for
+	// estimates to use it
+	processingRateWindow = 5 * time.Minute
+	// maxCompletionSamples caps the completions a processor remembers
+	maxCompletionSamples = 1000
+)
+
+// completionTracker remembers when a processor recently finished
+// transactions, to work out its rolling processing rate
+type completionTracker struct {
+	mu    sync.Mutex
+	times []time.Time
+}
+
+// record notes a completion at now, dropping those outside the window
+func (t *completionTracker) record(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.times = append(t.prune(now), now)
+	if len(t.times) > maxCompletionSamples {
+		t.times = t.times[len(t.times)-maxCompletionSamples:]
+	}
+}
+
+// rate returns the completions per second across those in the window, zero
+// with fewer than two to measure between
+func (t *completionTracker) rate(now time.Time) (float64, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.times = t.prune(now)
+	samples := len(t.times)
+	if samples < 2 {
+		return 0, samples
+	}
+	span := now.Sub(t.times[0]).Seconds()
+	if span <= 0 {
+		return 0, samples
+	}
+	return float64(samples) / span, samples
+}
+
+func (t *completionTracker) prune(now time.Time) []time.Time {
+	cutoff := now.Add(-processingRateWindow)
+	i := 0
+	for i < len(t.times) && t.times[i].Before(cutoff) {
+		i++
+	}
+	return t.times[i:]
+}
+
+// WithQueueStats numbers the messages published to the transaction queue and
+// shares this process's processing rate through stats, so pending
+// transactions can report their place in the queue and an estimated wait
+func WithQueueStats(stats domain.QueueStatsStore) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.queueStats = stats
+		uc.completions = &completionTracker{}
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "processor"
+		}
+		uc.workerID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+}
+
+// nextQueueSequence numbers a message just published to the transaction
+// queue, returning zero if it cannot be numbered
+func (uc *TransactionUseCase) nextQueueSequence(ctx context.Context, id string) int64 {
+	if uc.queueStats == nil {
+		return 0
+	}
+	sequence, err := uc.queueStats.NextSequence(ctx, uc.queueName)
+	if err != nil {
+		log.Printf("Failed to number queue message of transaction %s: %v", id, err)
+		return 0
+	}
+	return sequence
+}
+
+// recordProcessed notes that a transaction was finished, successfully or not
+func (uc *TransactionUseCase) recordProcessed() {
+	if uc.completions != nil {
+		uc.completions.record(uc.now())
+	}
+}
+
+// PublishProcessingRate saves this processor's rolling processing rate for
+// the API to estimate waits from, along with its count of cancelled
+// transactions skipped on a tombstone
+func (uc *TransactionUseCase) PublishProcessingRate(ctx context.Context) error {
+	if uc.queueStats == nil {
+		return nil
+	}
+	now := uc.now()
+	perSecond, samples := uc.completions.rate(now)
+	return uc.queueStats.SaveProcessingRate(ctx, &domain.ProcessingRate{
+		Queue:            uc.queueName,
+		Worker:           uc.workerID,
+		PerSecond:        perSecond,
+		Samples:          samples,
+		SkippedCancelled: uc.SkippedCancelled(),
+		UpdatedAt:        now,
+	})
+}
+
+// GetTransactionStatus reports a transaction's status. A pending transaction
+// also reports how many pending transactions were submitted before it and,
+// when the processors have reported their rates recently, roughly when it
+// will be processed.
+func (uc *TransactionUseCase) GetTransactionStatus(ctx context.Context, id string) (*domain.TransactionStatusReport, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.authorizeParty(ctx, transaction); err != nil {
+		return nil, err
+	}
+
+	report := &domain.TransactionStatusReport{
+		TransactionID: transaction.ID,
+		Status:        transaction.Status,
+		ProcessedAt:   transaction.ProcessedAt,
+	}
+	if transaction.Status != domain.TransactionStatusPending {
+		return report, nil
+	}
+	report.QueueSequence = transaction.QueueSequence
+
+	status := domain.TransactionStatusPending
+	before := transaction.CreatedAt.Add(-time.Nanosecond)
+	ahead, err := uc.transactionRepo.Count(ctx, &domain.TransactionFilter{Status: &status, ToDate: &before})
+	if err != nil {
+		return nil, err
+	}
+	estimate := &domain.QueueEstimate{
+		Ahead:       ahead,
+		Position:    ahead + 1,
+		Approximate: true,
+		Note:        domain.QueueEstimateNote,
+	}
+	report.Queue = estimate
+
+	perSecond, err := uc.processingRate(ctx)
+	if err != nil {
+		// The position stands without the wait
+		log.Printf("Failed to read processing rates for transaction %s: %v", id, err)
+		return report, nil
+	}
+	if perSecond <= 0 {
+		return report, nil
+	}
+	wait := time.Duration(float64(estimate.Position) / perSecond * float64(time.Second)).Round(time.Second)
+	waitSeconds := int64(wait / time.Second)
+	completionAt := uc.now().Add(wait)
+	estimate.ProcessingRate = &perSecond
+	estimate.EstimatedWaitSeconds = &waitSeconds
+	estimate.EstimatedCompletionAt = &completionAt
+	return report, nil
+}
+
+// processingRate sums the rates the processors reported within the window
+func (uc *TransactionUseCase) processingRate(ctx context.Context) (float64, error) {
+	if uc.queueStats == nil {
+		return 0, nil
+	}
+	rates, err := uc.queueStats.ProcessingRates(ctx, uc.queueName, uc.now().Add(-processingRateWindow))
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, rate := range rates {
+		total += rate.PerSecond
+	}
+	return total, nil
+}
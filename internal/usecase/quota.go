@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// TransactionQuota caps how many transactions debiting a user's accounts the
+// user may submit within a rolling window. Deposits, reversals, refunds and
+// system transactions are exempt.
+type TransactionQuota struct {
+	limit     int
+	window    time.Duration
+	overrides map[string]int
+}
+
+// NewTransactionQuota creates a quota of limit submissions per window for
+// every user; a limit of zero leaves users without an override unlimited
+func NewTransactionQuota(limit int, window time.Duration) *TransactionQuota {
+	return &TransactionQuota{
+		limit:     limit,
+		window:    window,
+		overrides: make(map[string]int),
+	}
+}
+
+// SetLimit overrides the limit of one user; zero makes the user unlimited
+func (q *TransactionQuota) SetLimit(userID string, limit int) {
+	q.overrides[userID] = limit
+}
+
+// LimitFor returns the limit of a user, zero meaning unlimited
+func (q *TransactionQuota) LimitFor(userID string) int {
+	if limit, ok := q.overrides[userID]; ok {
+		return limit
+	}
+	return q.limit
+}
+
+// Enabled reports whether any user is limited
+func (q *TransactionQuota) Enabled() bool {
+	if q == nil || q.window <= 0 {
+		return false
+	}
+	if q.limit > 0 {
+		return true
+	}
+	for _, limit := range q.overrides {
+		if limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTransactionQuota builds a quota from a global limit and per-user
+// overrides written as "user:limit", such as "user-123:500"
+func LoadTransactionQuota(limit int, window time.Duration, overrides []string) (*TransactionQuota, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("transaction quota must not be negative")
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("transaction quota window must be positive")
+	}
+
+	quota := NewTransactionQuota(limit, window)
+	for _, entry := range overrides {
+		userID, value, found := strings.Cut(entry, ":")
+		if !found || userID == "" {
+			return nil, fmt.Errorf("invalid quota override %q: want user:limit", entry)
+		}
+		userLimit, err := strconv.Atoi(value)
+		if err != nil || userLimit < 0 {
+			return nil, fmt.Errorf("invalid quota override %q: bad limit", entry)
+		}
+		quota.SetLimit(userID, userLimit)
+	}
+
+	return quota, nil
+}
+
+// quotaExempt reports whether a request does not count against its user's
+// quota
+func quotaExempt(request *domain.TransactionRequest) bool {
+	if request.System || request.FromAccountID == nil {
+		return true
+	}
+	switch request.Type {
+	case domain.TransactionTypeDeposit, domain.TransactionTypeReversal, domain.TransactionTypeRefund:
+		return true
+	}
+	return false
+}
+
+// quotaTypes are the types of transactions counted against a user's quota
+var quotaTypes = []domain.TransactionType{
+	domain.TransactionTypeWithdrawal,
+	domain.TransactionTypeTransfer,
+	domain.TransactionTypeExternalTransfer,
+}
+
+// checkQuota fails with a QuotaExceededError if the owner of the account a
+// request debits has already submitted their quota of withdrawals and
+// outgoing transfers, from any of their accounts, within the window
+func (uc *TransactionUseCase) checkQuota(ctx context.Context, request *domain.TransactionRequest) error {
+	if !uc.quota.Enabled() || quotaExempt(request) {
+		return nil
+	}
+
+	account, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
+	if err != nil {
+		return err
+	}
+	return uc.checkUserQuota(ctx, account, 1)
+}
+
+// checkBatchQuota fails with a QuotaExceededError if the legs of a batch
+// debiting a user's accounts would take the user past their quota
+func (uc *TransactionUseCase) checkBatchQuota(ctx context.Context, batch *domain.BatchRequest) error {
+	if !uc.quota.Enabled() {
+		return nil
+	}
+
+	// Legs are counted per user, or per account for an account without one
+	var owners []string
+	accounts := make(map[string]*domain.Account)
+	submissions := make(map[string]int)
+	for _, leg := range batch.Legs {
+		if quotaExempt(leg) {
+			continue
+		}
+		account, err := uc.accountRepo.GetByID(ctx, *leg.FromAccountID)
+		if err != nil {
+			return err
+		}
+		owner := account.UserID
+		if owner == "" {
+			owner = "account:" + account.ID
+		}
+		if _, ok := accounts[owner]; !ok {
+			owners = append(owners, owner)
+			accounts[owner] = account
+		}
+		submissions[owner]++
+	}
+
+	for _, owner := range owners {
+		if err := uc.checkUserQuota(ctx, accounts[owner], submissions[owner]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkUserQuota fails with a QuotaExceededError unless the owner of an
+// account may submit n more withdrawals and outgoing transfers within the
+// window. An account without an owner has a quota of its own.
+func (uc *TransactionUseCase) checkUserQuota(ctx context.Context, account *domain.Account, n int) error {
+	limit := uc.quota.LimitFor(account.UserID)
+	if limit <= 0 {
+		return nil
+	}
+
+	accountIDs := []string{account.ID}
+	if account.UserID != "" {
+		accounts, err := uc.accountRepo.GetByUserID(ctx, account.UserID)
+		if err != nil {
+			return err
+		}
+		accountIDs = make([]string, len(accounts))
+		for i, owned := range accounts {
+			accountIDs[i] = owned.ID
+		}
+	}
+
+	now := uc.now()
+	since := now.Add(-uc.quota.window)
+	filter := &domain.TransactionFilter{
+		FromAccountIDs: accountIDs,
+		Types:          quotaTypes,
+		FromDate:       &since,
+	}
+	count, err := uc.transactionRepo.Count(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if count+int64(n) <= int64(limit) {
+		return nil
+	}
+
+	// Enough slots free up when the (limit-n+1)-th newest submission leaves
+	// the window. More submissions than the limit never fit, so they are
+	// told to wait out the whole window.
+	retryAfter := uc.quota.window
+	if n <= limit {
+		filter.Limit = 1
+		filter.Offset = limit - n
+		oldest, err := uc.transactionRepo.GetByFilter(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if len(oldest) > 0 {
+			retryAfter = oldest[0].CreatedAt.Add(uc.quota.window).Sub(now)
+		}
+	}
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+
+	return &domain.QuotaExceededError{
+		Limit:      limit,
+		Window:     uc.quota.window,
+		RetryAfter: retryAfter,
+	}
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"banking-ledger/internal/domain"
+)
+
+// WithReceiptSecret signs transaction receipts with secret; receipts are
+// unavailable without one
+func WithReceiptSecret(secret string) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.receiptSecret = secret
+	}
+}
+
+// GetTransactionReceipt issues the signed receipt of a completed transaction,
+// naming the users on both sides and the fee charged for it, if any. A user
+// may only have receipts of their own transactions.
+func (uc *TransactionUseCase) GetTransactionReceipt(ctx context.Context, id string) (*domain.Receipt, error) {
+	if uc.receiptSecret == "" {
+		return nil, domain.ErrReceiptsNotConfigured
+	}
+
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Status != domain.TransactionStatusCompleted {
+		return nil, domain.ErrReceiptUnavailable
+	}
+
+	from, err := uc.receiptAccount(ctx, transaction.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := uc.receiptAccount(ctx, transaction.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.Unscoped(ctx) && !receiptHolder(ctx, from) && !receiptHolder(ctx, to) {
+		return nil, domain.ErrForbidden
+	}
+
+	var fees []*domain.Transaction
+	if transaction.Type != domain.TransactionTypeFee {
+		fee, err := uc.transactionRepo.GetByID(ctx, FeeTransactionID(transaction.ID))
+		switch {
+		case err == nil:
+			fees = append(fees, fee)
+		case !errors.Is(err, domain.ErrTransactionNotFound):
+			return nil, err
+		}
+	}
+
+	receipt := domain.NewReceipt(transaction, from, to, fees, uc.now())
+	receipt.Hash = receipt.Sign(uc.receiptSecret)
+	return receipt, nil
+}
+
+// VerifyReceipt reports whether a receipt is unchanged from one issued here
+func (uc *TransactionUseCase) VerifyReceipt(ctx context.Context, receipt *domain.Receipt) (bool, error) {
+	if uc.receiptSecret == "" {
+		return false, domain.ErrReceiptsNotConfigured
+	}
+	return receipt.Verify(uc.receiptSecret), nil
+}
+
+// receiptAccount loads an account named on a receipt; an account that has
+// since been removed is left off rather than failing the receipt
+func (uc *TransactionUseCase) receiptAccount(ctx context.Context, id *string) (*domain.Account, error) {
+	if id == nil {
+		return nil, nil
+	}
+	account, err := uc.accountRepo.GetByID(ctx, *id)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return &domain.Account{ID: *id}, nil
+	}
+	return account, err
+}
+
+// receiptHolder reports whether the caller holds an account
+func receiptHolder(ctx context.Context, account *domain.Account) bool {
+	return account != nil && account.UserID != "" && domain.AuthorizeUser(ctx, account.UserID) == nil
+}
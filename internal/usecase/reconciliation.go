@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// defaultReconciliationPageSize is how many accounts are reconciled per query
+const defaultReconciliationPageSize = 500
+
+// ReconciliationUseCase implements the ReconciliationService interface. An
+// account's expected balance is its initial balance plus the movements of
+// its transactions in MongoDB; the balance in PostgreSQL moves with the
+// ledger entries, so where the two differ it is the transactions that are
+// missing or wrong.
+type ReconciliationUseCase struct {
+	accountRepo     domain.AccountRepository
+	transactionRepo domain.TransactionRepository
+	reportRepo      domain.ReconciliationReportRepository
+	pageSize        int
+	now             func() time.Time
+}
+
+// ReconciliationOption configures optional behaviour of the reconciliation use case
+type ReconciliationOption func(*ReconciliationUseCase)
+
+// WithReconciliationPageSize sets how many accounts are reconciled per query
+func WithReconciliationPageSize(size int) ReconciliationOption {
+	return func(uc *ReconciliationUseCase) {
+		if size > 0 {
+			uc.pageSize = size
+		}
+	}
+}
+
+// WithReconciliationClock sets the clock reports are timed with
+func WithReconciliationClock(now func() time.Time) ReconciliationOption {
+	return func(uc *ReconciliationUseCase) {
+		uc.now = now
+	}
+}
+
+// NewReconciliationUseCase creates a new reconciliation use case
+func NewReconciliationUseCase(
+	accountRepo domain.AccountRepository,
+	transactionRepo domain.TransactionRepository,
+	reportRepo domain.ReconciliationReportRepository,
+	opts ...ReconciliationOption,
+) domain.ReconciliationService {
+	uc := &ReconciliationUseCase{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		reportRepo:      reportRepo,
+		pageSize:        defaultReconciliationPageSize,
+		now:             time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// Reconcile walks every account a page at a time and saves the report of
+// those whose balance does not match their transactions
+func (uc *ReconciliationUseCase) Reconcile(ctx context.Context) (*domain.ReconciliationReport, error) {
+	report := &domain.ReconciliationReport{
+		ID:            uuid.New().String(),
+		StartedAt:     uc.now(),
+		Discrepancies: []*domain.Discrepancy{},
+	}
+
+	afterID := ""
+	for {
+		accounts, err := uc.accountRepo.ListAfter(ctx, afterID, uc.pageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(accounts) == 0 {
+			break
+		}
+
+		discrepancies, err := uc.compare(ctx, accounts)
+		if err != nil {
+			return nil, err
+		}
+		discrepancies, err = uc.recheck(ctx, discrepancies)
+		if err != nil {
+			return nil, err
+		}
+
+		report.AccountsChecked += int64(len(accounts))
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+		afterID = accounts[len(accounts)-1].ID
+		if len(accounts) < uc.pageSize {
+			break
+		}
+	}
+
+	report.CompletedAt = uc.now()
+	if err := uc.reportRepo.Save(ctx, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// compare returns the discrepancies of the accounts
+func (uc *ReconciliationUseCase) compare(ctx context.Context, accounts []*domain.Account) ([]*domain.Discrepancy, error) {
+	ids := make([]string, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+
+	movements, err := uc.transactionRepo.SumMovements(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var discrepancies []*domain.Discrepancy
+	for _, account := range accounts {
+		expected := account.InitialBalance + movements[account.ID]
+		if expected != account.Balance {
+			discrepancies = append(discrepancies, &domain.Discrepancy{
+				AccountID: account.ID,
+				Currency:  account.Currency,
+				Expected:  expected,
+				Actual:    account.Balance,
+				Delta:     account.Balance - expected,
+			})
+		}
+	}
+
+	return discrepancies, nil
+}
+
+// recheck compares the accounts of the discrepancies again, dropping those
+// that were only a transaction caught between its posting and its completion
+func (uc *ReconciliationUseCase) recheck(ctx context.Context, discrepancies []*domain.Discrepancy) ([]*domain.Discrepancy, error) {
+	if len(discrepancies) == 0 {
+		return nil, nil
+	}
+
+	accounts := make([]*domain.Account, 0, len(discrepancies))
+	for _, discrepancy := range discrepancies {
+		account, err := uc.accountRepo.GetByID(ctx, discrepancy.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return uc.compare(ctx, accounts)
+}
+
+// CheckInvariants compares the total balance of each currency's accounts with
+// their opening balances plus the net movements of every transaction.
+// Transfers within a currency move nothing between currencies, so the books
+// balance when every currency's difference is zero. Both totals are summed by
+// the stores rather than loaded.
+func (uc *ReconciliationUseCase) CheckInvariants(ctx context.Context) (*domain.InvariantReport, error) {
+	report := &domain.InvariantReport{
+		StartedAt:  uc.now(),
+		Balanced:   true,
+		Currencies: []*domain.CurrencyInvariant{},
+	}
+
+	balances, err := uc.accountRepo.SumBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := uc.transactionRepo.SumMovementsByType(ctx)
+	if err != nil {
+		return nil, err
+	}
+	processing := domain.TransactionStatusProcessing
+	report.InFlight, err = uc.transactionRepo.Count(ctx, &domain.TransactionFilter{Status: &processing})
+	if err != nil {
+		return nil, err
+	}
+	report.CompletedAt = uc.now()
+
+	currencies := make(map[string]*domain.CurrencyInvariant)
+	currency := func(code string) *domain.CurrencyInvariant {
+		invariant, ok := currencies[code]
+		if !ok {
+			invariant = &domain.CurrencyInvariant{Currency: code, Movements: []*domain.MovementTotal{}}
+			currencies[code] = invariant
+		}
+		return invariant
+	}
+	for _, total := range balances {
+		invariant := currency(total.Currency)
+		invariant.Balance = total.Balance
+		invariant.InitialBalance = total.InitialBalance
+	}
+	for _, total := range movements {
+		invariant := currency(total.Currency)
+		invariant.Movements = append(invariant.Movements, total)
+	}
+
+	codes := make([]string, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		invariant := currencies[code]
+		invariant.Expected = invariant.InitialBalance
+		for _, total := range invariant.Movements {
+			invariant.Expected += total.Net()
+		}
+		invariant.Difference = invariant.Balance - invariant.Expected
+		invariant.Balanced = invariant.Difference == 0
+		report.Balanced = report.Balanced && invariant.Balanced
+		report.Currencies = append(report.Currencies, invariant)
+	}
+
+	return report, nil
+}
+
+// GetLatestReport retrieves the report of the most recent reconciliation
+func (uc *ReconciliationUseCase) GetLatestReport(ctx context.Context) (*domain.ReconciliationReport, error) {
+	return uc.reportRepo.GetLatest(ctx)
+}
+
+// FixDiscrepancies records an adjustment transaction for the delta of each
+// approved account in the latest report, so that its transactions account
+// for its balance. The balance itself is left alone. A discrepancy whose
+// delta has changed since the report is skipped until it is reconciled and
+// approved again, and one already fixed is not fixed twice.
+func (uc *ReconciliationUseCase) FixDiscrepancies(ctx context.Context, accountIDs []string, operatorID string) (*domain.ReconciliationReport, error) {
+	if len(accountIDs) == 0 || operatorID == "" {
+		return nil, domain.ErrInvalidInput
+	}
+
+	report, err := uc.reportRepo.GetLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	approved := make([]*domain.Discrepancy, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		discrepancy := report.Discrepancy(id)
+		if discrepancy == nil {
+			return nil, fmt.Errorf("%w: %s", domain.ErrDiscrepancyNotFound, id)
+		}
+		approved = append(approved, discrepancy)
+	}
+
+	for _, discrepancy := range approved {
+		if discrepancy.AdjustmentID != "" {
+			continue
+		}
+
+		current, err := uc.recheck(ctx, []*domain.Discrepancy{discrepancy})
+		if err != nil {
+			return nil, err
+		}
+		if len(current) == 0 || current[0].Delta != discrepancy.Delta {
+			log.Printf("Skipping discrepancy on account %s: it has changed since report %s", discrepancy.AccountID, report.ID)
+			continue
+		}
+
+		adjustment := uc.adjustment(report, discrepancy, operatorID)
+		if err := uc.transactionRepo.Create(ctx, adjustment); err != nil {
+			return nil, fmt.Errorf("failed to record adjustment: %w", err)
+		}
+		discrepancy.AdjustmentID = adjustment.ID
+
+		// Save after each fix so that a later failure does not lose it
+		if err := uc.reportRepo.Save(ctx, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// adjustment builds the completed adjustment transaction accounting for a
+// discrepancy's delta, crediting the account if its balance is higher than
+// expected and debiting it otherwise
+func (uc *ReconciliationUseCase) adjustment(report *domain.ReconciliationReport, discrepancy *domain.Discrepancy, operatorID string) *domain.Transaction {
+	now := uc.now()
+	accountID := discrepancy.AccountID
+
+	transaction := &domain.Transaction{
+		ID:          uuid.New().String(),
+		Type:        domain.TransactionTypeAdjustment,
+		Amount:      discrepancy.Delta.Abs(),
+		Currency:    discrepancy.Currency,
+		Status:      domain.TransactionStatusCompleted,
+		Description: "Reconciliation " + report.ID,
+		Metadata: map[string]interface{}{
+			"reason":                   "reconciliation",
+			"operator_id":              operatorID,
+			"reconciliation_report_id": report.ID,
+		},
+		ProcessedAt: &now,
+	}
+	if discrepancy.Delta > 0 {
+		transaction.ToAccountID = &accountID
+	} else {
+		transaction.FromAccountID = &accountID
+	}
+
+	return transaction
+}
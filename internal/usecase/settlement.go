@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/pkg/calendar"
+)
+
+// settlementLookback is how far before the settlement day transactions are
+// loaded, so transfers created late and processed on the day are included
+const settlementLookback = 24 * time.Hour
+
+// SettlementUseCase implements the SettlementService interface
+type SettlementUseCase struct {
+	groupRepo       domain.SettlementGroupRepository
+	accountRepo     domain.AccountRepository
+	transactionRepo domain.TransactionRepository
+	calendars       *calendar.Set
+	rollConvention  calendar.Convention
+}
+
+// SettlementOption configures optional behaviour of the settlement use case
+type SettlementOption func(*SettlementUseCase)
+
+// WithSettlementCalendar rolls each settlement's value date onto a business
+// day of its currency's calendar using the given convention
+func WithSettlementCalendar(calendars *calendar.Set, convention calendar.Convention) SettlementOption {
+	return func(uc *SettlementUseCase) {
+		uc.calendars = calendars
+		uc.rollConvention = convention
+	}
+}
+
+// NewSettlementUseCase creates a new settlement use case
+func NewSettlementUseCase(
+	groupRepo domain.SettlementGroupRepository,
+	accountRepo domain.AccountRepository,
+	transactionRepo domain.TransactionRepository,
+	opts ...SettlementOption,
+) domain.SettlementService {
+	uc := &SettlementUseCase{
+		groupRepo:       groupRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// CreateGroup creates a new settlement group
+func (uc *SettlementUseCase) CreateGroup(ctx context.Context, name string, accountIDs []string) (*domain.SettlementGroup, error) {
+	group := &domain.SettlementGroup{Name: strings.TrimSpace(name)}
+	if err := uc.setAccounts(ctx, group, accountIDs); err != nil {
+		return nil, err
+	}
+
+	if err := uc.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// GetGroup retrieves a settlement group by ID
+func (uc *SettlementUseCase) GetGroup(ctx context.Context, id string) (*domain.SettlementGroup, error) {
+	return uc.groupRepo.GetByID(ctx, id)
+}
+
+// ListGroups retrieves all settlement groups
+func (uc *SettlementUseCase) ListGroups(ctx context.Context) ([]*domain.SettlementGroup, error) {
+	return uc.groupRepo.List(ctx)
+}
+
+// UpdateGroup replaces a settlement group's name and accounts
+func (uc *SettlementUseCase) UpdateGroup(ctx context.Context, id, name string, accountIDs []string) (*domain.SettlementGroup, error) {
+	group, err := uc.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := strings.TrimSpace(name); trimmed != "" {
+		group.Name = trimmed
+	}
+	if err := uc.setAccounts(ctx, group, accountIDs); err != nil {
+		return nil, err
+	}
+
+	if err := uc.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// ComputeNet computes a group's net positions for a UTC day without settling
+func (uc *SettlementUseCase) ComputeNet(ctx context.Context, groupID string, date time.Time) (*domain.SettlementNet, error) {
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.computeNet(ctx, group, date)
+}
+
+// Settle books one external transfer per currency for the group's net
+// position on a UTC day and marks the netted transfers as settled. Batch and
+// transaction IDs are derived from the group and day, so settling the same
+// day again returns the existing settlement instead of booking it twice.
+func (uc *SettlementUseCase) Settle(ctx context.Context, groupID string, date time.Time) (*domain.Settlement, error) {
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	net, err := uc.computeNet(ctx, group, date)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement := &domain.Settlement{
+		BatchID:      fmt.Sprintf("stl-%s-%s", group.ID, net.Date),
+		Net:          net,
+		Transactions: []*domain.Transaction{},
+	}
+
+	for _, position := range net.Positions {
+		if position.Net == 0 {
+			continue
+		}
+
+		transactionID := settlement.BatchID + "-" + position.Currency
+		existing, err := uc.transactionRepo.GetByID(ctx, transactionID)
+		if err == nil {
+			settlement.AlreadySettled = true
+			settlement.Transactions = append(settlement.Transactions, existing)
+			continue
+		}
+		if !errors.Is(err, domain.ErrTransactionNotFound) {
+			return nil, fmt.Errorf("failed to check settlement transaction: %w", err)
+		}
+
+		now := time.Now()
+		transaction := &domain.Transaction{
+			ID:                transactionID,
+			Type:              domain.TransactionTypeExternalTransfer,
+			Amount:            position.Net.Abs(),
+			Currency:          position.Currency,
+			Status:            domain.TransactionStatusCompleted,
+			Description:       fmt.Sprintf("Settlement of %s for %s", group.Name, net.Date),
+			Reference:         settlement.BatchID,
+			SettlementBatchID: settlement.BatchID,
+			ProcessedAt:       &now,
+			System:            true,
+			Metadata: map[string]interface{}{
+				"settlement_group_id": group.ID,
+				"settlement_date":     net.Date,
+				"value_date":          uc.valueDate(date, position.Currency),
+				"inflow":              position.Inflow.Format(position.Currency),
+				"outflow":             position.Outflow.Format(position.Currency),
+				"net":                 position.Net.Format(position.Currency),
+			},
+		}
+
+		if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+			return nil, fmt.Errorf("failed to create settlement transaction: %w", err)
+		}
+		settlement.Transactions = append(settlement.Transactions, transaction)
+	}
+
+	// Transfers already carrying a batch are left alone, which keeps a retry
+	// after a partial failure from reassigning them
+	if _, err := uc.transactionRepo.SetSettlementBatch(ctx, net.TransactionIDs, settlement.BatchID); err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// valueDate returns the business day a currency's settlement for the given
+// day takes effect on
+func (uc *SettlementUseCase) valueDate(date time.Time, currency string) string {
+	day, _ := domain.SettlementDay(date)
+	if uc.calendars != nil {
+		day = uc.calendars.For(currency).Roll(day, uc.rollConvention)
+	}
+	return day.Format("2006-01-02")
+}
+
+// computeNet loads the transfers touching the group's accounts around the day
+// and nets them
+func (uc *SettlementUseCase) computeNet(ctx context.Context, group *domain.SettlementGroup, date time.Time) (*domain.SettlementNet, error) {
+	dayStart, dayEnd := domain.SettlementDay(date)
+	fromDate := dayStart.Add(-settlementLookback)
+	transferType := domain.TransactionTypeTransfer
+	completed := domain.TransactionStatusCompleted
+
+	transactions, err := uc.transactionRepo.GetByFilter(ctx, &domain.TransactionFilter{
+		AccountIDs: group.AccountIDs,
+		Type:       &transferType,
+		Status:     &completed,
+		FromDate:   &fromDate,
+		ToDate:     &dayEnd,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settlement transactions: %w", err)
+	}
+
+	return domain.ComputeSettlementNet(group, date, transactions), nil
+}
+
+// setAccounts validates and assigns a group's accounts, dropping duplicates
+func (uc *SettlementUseCase) setAccounts(ctx context.Context, group *domain.SettlementGroup, accountIDs []string) error {
+	if group.Name == "" || len(accountIDs) == 0 {
+		return domain.ErrInvalidSettlementGroup
+	}
+
+	seen := make(map[string]bool, len(accountIDs))
+	group.AccountIDs = make([]string, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		if accountID == "" || seen[accountID] {
+			continue
+		}
+		seen[accountID] = true
+
+		if _, err := uc.accountRepo.GetByID(ctx, accountID); err != nil {
+			if errors.Is(err, domain.ErrAccountNotFound) {
+				return domain.ErrInvalidSettlementGroup
+			}
+			return err
+		}
+		group.AccountIDs = append(group.AccountIDs, accountID)
+	}
+
+	if len(group.AccountIDs) == 0 {
+		return domain.ErrInvalidSettlementGroup
+	}
+
+	return nil
+}
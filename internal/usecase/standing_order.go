@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+// dueStandingOrderBatch is how many due standing orders one poll executes
+const dueStandingOrderBatch = 100
+
+// StandingOrderUseCase implements the StandingOrderService interface
+type StandingOrderUseCase struct {
+	orderRepo          domain.StandingOrderRepository
+	accountRepo        domain.AccountRepository
+	transactionService domain.TransactionService
+	now                func() time.Time
+}
+
+// StandingOrderOption configures optional behaviour of the standing order use case
+type StandingOrderOption func(*StandingOrderUseCase)
+
+// WithStandingOrderClock overrides the clock used to decide which runs are due
+func WithStandingOrderClock(now func() time.Time) StandingOrderOption {
+	return func(uc *StandingOrderUseCase) {
+		uc.now = now
+	}
+}
+
+// NewStandingOrderUseCase creates a new standing order use case. Each run is
+// submitted through transactionService as an ordinary transfer.
+func NewStandingOrderUseCase(
+	orderRepo domain.StandingOrderRepository,
+	accountRepo domain.AccountRepository,
+	transactionService domain.TransactionService,
+	opts ...StandingOrderOption,
+) domain.StandingOrderService {
+	uc := &StandingOrderUseCase{
+		orderRepo:          orderRepo,
+		accountRepo:        accountRepo,
+		transactionService: transactionService,
+		now:                time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// CreateStandingOrder creates a standing order whose first run is at its
+// start time, or now if it has none. Only the owner of the account it pays
+// from may create it.
+func (uc *StandingOrderUseCase) CreateStandingOrder(ctx context.Context, request *domain.StandingOrderRequest) (*domain.StandingOrder, error) {
+	now := uc.now()
+	if request.StartAt.IsZero() {
+		request.StartAt = now
+	} else if request.StartAt.Before(now) {
+		return nil, domain.ErrInvalidStandingOrder
+	}
+
+	if err := request.IsValid(); err != nil {
+		return nil, err
+	}
+
+	for _, accountID := range []string{request.FromAccountID, request.ToAccountID} {
+		account, err := uc.accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if accountID == request.FromAccountID {
+			if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+				return nil, err
+			}
+		}
+		if account.Currency != request.Currency {
+			return nil, domain.ErrCurrencyMismatch
+		}
+	}
+
+	order := &domain.StandingOrder{
+		FromAccountID: request.FromAccountID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+		Description:   request.Description,
+		Frequency:     request.Frequency,
+		Interval:      request.Interval,
+		StartAt:       request.StartAt,
+		EndAt:         request.EndAt,
+		NextRunAt:     request.StartAt,
+		Status:        domain.StandingOrderStatusActive,
+	}
+
+	if err := uc.orderRepo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// GetStandingOrder retrieves a standing order by ID
+func (uc *StandingOrderUseCase) GetStandingOrder(ctx context.Context, id string) (*domain.StandingOrder, error) {
+	return uc.authorizedOrder(ctx, id)
+}
+
+// authorizedOrder retrieves a standing order, failing with ErrForbidden
+// unless the caller owns the account it pays from
+func (uc *StandingOrderUseCase) authorizedOrder(ctx context.Context, id string) (*domain.StandingOrder, error) {
+	order, err := uc.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if domain.Unscoped(ctx) {
+		return order, nil
+	}
+
+	account, err := uc.accountRepo.GetByID(ctx, order.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ListStandingOrders retrieves the standing orders of an account, or all of
+// them when accountID is empty. A user may only list their own account's.
+func (uc *StandingOrderUseCase) ListStandingOrders(ctx context.Context, accountID string) ([]*domain.StandingOrder, error) {
+	if !domain.Unscoped(ctx) {
+		if accountID == "" {
+			return nil, domain.ErrForbidden
+		}
+		account, err := uc.accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+			return nil, err
+		}
+	}
+	return uc.orderRepo.List(ctx, accountID)
+}
+
+// UpdateStandingOrder changes an open order's amount or description, or
+// pauses or resumes it
+func (uc *StandingOrderUseCase) UpdateStandingOrder(ctx context.Context, id string, update *domain.StandingOrderUpdate) (*domain.StandingOrder, error) {
+	order, err := uc.authorizedOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status.IsClosed() {
+		return nil, domain.ErrStandingOrderClosed
+	}
+
+	if update.Amount != nil {
+		amount, err := update.Amount.Money(order.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if amount <= 0 {
+			return nil, domain.ErrInvalidAmount
+		}
+		if limit, ok := domain.ActiveTransactionLimits.MaxAmountIn(order.Currency); ok && amount > limit {
+			return nil, domain.ErrAmountTooLarge
+		}
+		order.Amount = amount
+	}
+	if update.Description != nil {
+		order.Description = *update.Description
+	}
+	if update.Status != nil {
+		if *update.Status != domain.StandingOrderStatusActive && *update.Status != domain.StandingOrderStatusPaused {
+			return nil, domain.ErrInvalidInput
+		}
+		order.Status = *update.Status
+	}
+
+	if err := uc.orderRepo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// CancelStandingOrder stops an order from running again. Transfers it has
+// already submitted are unaffected.
+func (uc *StandingOrderUseCase) CancelStandingOrder(ctx context.Context, id string) (*domain.StandingOrder, error) {
+	order, err := uc.authorizedOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status.IsClosed() {
+		return nil, domain.ErrStandingOrderClosed
+	}
+
+	order.Status = domain.StandingOrderStatusCancelled
+	if err := uc.orderRepo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// ExecuteDue claims each due order's run and submits its transfer. A run is
+// claimed before its transfer is submitted, so a processor failing in between
+// misses the run rather than a second processor paying it twice.
+func (uc *StandingOrderUseCase) ExecuteDue(ctx context.Context) (int, error) {
+	now := uc.now()
+	orders, err := uc.orderRepo.ListDue(ctx, now, dueStandingOrderBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	executed := 0
+	for _, order := range orders {
+		previousRunAt, run := order.NextRunAt, order.RunCount
+		order.Advance(now)
+		if err := uc.orderRepo.Claim(ctx, order, previousRunAt, now); err != nil {
+			// Another processor claimed the run, or the order was paused or cancelled
+			if !errors.Is(err, domain.ErrConcurrentUpdate) {
+				log.Printf("Failed to claim standing order %s: %v", order.ID, err)
+			}
+			continue
+		}
+
+		if err := uc.execute(ctx, order, run); err != nil {
+			log.Printf("Skipped run %d of standing order %s: %v", run, order.ID, err)
+			continue
+		}
+		executed++
+	}
+
+	return executed, nil
+}
+
+// execute submits the transfer for an order's run if both accounts can still
+// take part in it
+func (uc *StandingOrderUseCase) execute(ctx context.Context, order *domain.StandingOrder, run int) error {
+	from, err := uc.accountRepo.GetByID(ctx, order.FromAccountID)
+	if err != nil {
+		return err
+	}
+	if err := from.Status.CanDebit(); err != nil {
+		return err
+	}
+	to, err := uc.accountRepo.GetByID(ctx, order.ToAccountID)
+	if err != nil {
+		return err
+	}
+	if err := to.Status.CanCredit(); err != nil {
+		return err
+	}
+
+	transaction, err := uc.transactionService.ProcessTransaction(ctx, &domain.TransactionRequest{
+		ID:            order.RunIDFor(run),
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &order.FromAccountID,
+		ToAccountID:   &order.ToAccountID,
+		Amount:        order.Amount,
+		Currency:      order.Currency,
+		Description:   order.Description,
+		Metadata:      map[string]interface{}{"standing_order_id": order.ID},
+		// Every run repeats the same transfer on purpose
+		AllowDuplicate: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := uc.orderRepo.AppendTransaction(ctx, order.ID, transaction.ID); err != nil {
+		log.Printf("Failed to record transaction %s on standing order %s: %v", transaction.ID, order.ID, err)
+	} else {
+		order.TransactionIDs = append(order.TransactionIDs, transaction.ID)
+	}
+
+	return nil
+}
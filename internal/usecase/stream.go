@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+
+	"banking-ledger/internal/domain"
+)
+
+// StreamTransactions walks the page of transactions ListTransactions would
+// return, clamped the same way, handing each to fn as it is read rather
+// than collecting the page
+func (uc *TransactionUseCase) StreamTransactions(ctx context.Context, filter *domain.TransactionFilter, fn func(*domain.Transaction) error) error {
+	clampTransactionPage(filter)
+	if ok, err := uc.scopeFilter(ctx, filter); err != nil || !ok {
+		return err
+	}
+	return uc.transactionRepo.EachByFilter(ctx, filter, fn)
+}
+
+// StreamAccountTransactions walks a page of an account's transaction history
+// as seen from the account, handing each to fn as it is read. Running
+// balances and counterparty owners need the whole page, so, as in an export,
+// they are left out.
+func (uc *TransactionUseCase) StreamAccountTransactions(ctx context.Context, accountID string, filter *domain.TransactionFilter, fn func(*domain.AccountTransaction) error) error {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return err
+	}
+
+	scoped := *filter
+	scoped.AccountID = &accountID
+	return uc.transactionRepo.EachByFilter(ctx, &scoped, func(transaction *domain.Transaction) error {
+		return fn(domain.NewAccountTransaction(transaction, account))
+	})
+}
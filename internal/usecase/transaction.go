@@ -3,21 +3,253 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"banking-ledger/internal/domain"
 
 	"github.com/google/uuid"
 )
 
+// dueScheduledBatch is how many due scheduled transactions one poll releases
+const dueScheduledBatch = 100
+
+// stalePendingBatch is how many stale pending transactions one sweep handles
+const stalePendingBatch = 100
+
+// defaultArchiveBatch is how many transactions are archived at a time when
+// no batch size is configured
+const defaultArchiveBatch = 500
+
+// outboxBatch caps how many outbox messages the relay publishes per run
+const outboxBatch = 100
+
+// defaultOutboxGrace is how long a message may wait in the outbox before the
+// relay publishes it, leaving time for the submitting process to publish it
+const defaultOutboxGrace = 30 * time.Second
+
 // TransactionUseCase implements the TransactionService interface
 type TransactionUseCase struct {
 	accountRepo     domain.AccountRepository
 	transactionRepo domain.TransactionRepository
 	queue           domain.MessageQueue
 	queueName       string
+	submissionGuard domain.SubmissionGuard
+	duplicateWindow time.Duration
+	ledgerRepo      domain.LedgerEntryRepository
+	fees            *FeePolicy
+	pendingTTL      time.Duration
+	requeueStale    bool
+	now             func() time.Time
+
+	savingsWithdrawalLimit int
+	validateSubmissions    bool
+	validateFunds          bool
+	outboxGrace            time.Duration
+	splitTransfers         func(from, to *domain.Account) bool
+	idempotency            domain.IdempotencyStore
+	idempotencyTTL         time.Duration
+	exchangeRates          domain.ExchangeRateProvider
+	maxRateAge             time.Duration
+	limitLocation          *time.Location
+	notifier               domain.NotificationService
+	lowBalance             domain.Decimal
+	approvalThreshold      domain.Decimal
+	approvalTTL            time.Duration
+	approvalExemptSelf     bool
+	quota                  *TransactionQuota
+	verifications          domain.UserVerificationRepository
+	unverifiedDepositLimit domain.Decimal
+	requireExternalParty   bool
+	tombstoneQueue         string
+	tombstones             *tombstoneSet
+	exportMaxRows          int64
+	archiveAfter           time.Duration
+	receiptSecret          string
+	archiveBatch           int
+	queueStats             domain.QueueStatsStore
+	completions            *completionTracker
+	workerID               string
+	skippedCancelled       atomic.Int64
+}
+
+// TransactionOption configures optional TransactionUseCase behaviour
+type TransactionOption func(*TransactionUseCase)
+
+// WithDuplicateGuard enables rejection of submissions identical to a pending
+// transaction created less than window ago
+func WithDuplicateGuard(guard domain.SubmissionGuard, window time.Duration) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.submissionGuard = guard
+		uc.duplicateWindow = window
+	}
+}
+
+// WithLedgerEntries records a ledger entry for every balance change, written
+// atomically with the balance itself
+func WithLedgerEntries(ledgerRepo domain.LedgerEntryRepository) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.ledgerRepo = ledgerRepo
+	}
+}
+
+// WithFeePolicy charges fees on completed withdrawals and transfers as a
+// linked fee transaction
+func WithFeePolicy(policy *FeePolicy) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.fees = policy
+	}
+}
+
+// WithPendingExpiry fails transactions still pending ttl after they were
+// queued. With requeue set, each is queued once more before it is failed.
+func WithPendingExpiry(ttl time.Duration, requeue bool) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.pendingTTL = ttl
+		uc.requeueStale = requeue
+	}
+}
+
+// WithSavingsWithdrawalLimit caps how many withdrawals and outgoing transfers
+// a savings account may make each calendar month; zero means no limit
+func WithSavingsWithdrawalLimit(limit int) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.savingsWithdrawalLimit = limit
+	}
+}
+
+// WithSubmissionValidation checks the accounts of a deposit, withdrawal or
+// transfer when it is submitted, rejecting it straight away if an account is
+// missing, cannot be debited or credited, or has another currency. With
+// validateFunds set, the source account must also cover the amount; this can
+// race with other transactions, so processing checks funds again.
+func WithSubmissionValidation(validateFunds bool) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.validateSubmissions = true
+		uc.validateFunds = validateFunds
+	}
+}
+
+// WithArchival moves transactions in a final status to the archive once they
+// are older than after, batchSize at a time
+func WithArchival(after time.Duration, batchSize int) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.archiveAfter = after
+		uc.archiveBatch = batchSize
+	}
+}
+
+// WithExternalPartiesRequired rejects customer deposits without an external
+// source and withdrawals without an external destination
+func WithExternalPartiesRequired() TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.requireExternalParty = true
+	}
+}
+
+// WithOutboxGrace sets how long a message may wait in the outbox before the
+// relay publishes it
+func WithOutboxGrace(grace time.Duration) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.outboxGrace = grace
+	}
+}
+
+// WithSplitTransfers posts the debit and credit of transfers between accounts
+// for which split reports true separately, as for accounts that cannot share
+// a database transaction. If the credit fails permanently, the debit is
+// returned by a compensation. It requires ledger entries.
+func WithSplitTransfers(split func(from, to *domain.Account) bool) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.splitTransfers = split
+	}
+}
+
+// WithIdempotency makes a submission repeated with the same idempotency key
+// within ttl return the transaction the key first created
+func WithIdempotency(store domain.IdempotencyStore, ttl time.Duration) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.idempotency = store
+		uc.idempotencyTTL = ttl
+	}
+}
+
+// WithExchangeRates allows transfers into accounts in another currency,
+// crediting the amount converted at the provider's rate. A rate quoted more
+// than maxAge ago fails the transfer; zero accepts rates of any age.
+func WithExchangeRates(provider domain.ExchangeRateProvider, maxAge time.Duration) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.exchangeRates = provider
+		uc.maxRateAge = maxAge
+	}
+}
+
+// WithLimitTimezone sets the timezone whose midnight starts the day for
+// accounts' daily outgoing limits, UTC by default
+func WithLimitTimezone(location *time.Location) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.limitLocation = location
+	}
+}
+
+// WithNotifications sends events when transactions complete or fail, and
+// when a withdrawal or outgoing transfer takes its account's balance below
+// its low balance threshold. lowBalance, in each account's currency, is the
+// threshold of accounts without their own; empty leaves them without one.
+func WithNotifications(notifier domain.NotificationService, lowBalance domain.Decimal) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.notifier = notifier
+		uc.lowBalance = lowBalance
+	}
+}
+
+// WithApproval holds transfers above threshold, in their currency, for an
+// approver before they are queued. The pending sweeper fails those left
+// unapproved for longer than ttl; zero leaves them waiting.
+func WithApproval(threshold domain.Decimal, ttl time.Duration) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.approvalThreshold = threshold
+		uc.approvalTTL = ttl
+	}
+}
+
+// WithSelfTransferApprovalExemption lets transfers between a user's own
+// accounts skip approval whatever their amount
+func WithSelfTransferApprovalExemption() TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.approvalExemptSelf = true
+	}
+}
+
+// WithTransactionQuota rejects withdrawals and outgoing transfers from users
+// who have already submitted their quota of them within its rolling window
+func WithTransactionQuota(quota *TransactionQuota) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.quota = quota
+	}
+}
+
+// WithVerificationGate holds users who are not verified to deposits, which
+// may total at most depositLimit in each currency, and refuses their
+// withdrawals and transfers
+func WithVerificationGate(verifications domain.UserVerificationRepository, depositLimit domain.Decimal) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.verifications = verifications
+		uc.unverifiedDepositLimit = depositLimit
+	}
+}
+
+// WithClock overrides the clock used for time-based checks
+func WithClock(now func() time.Time) TransactionOption {
+	return func(uc *TransactionUseCase) {
+		uc.now = now
+	}
 }
 
 // NewTransactionUseCase creates a new transaction use case
@@ -26,13 +258,24 @@ func NewTransactionUseCase(
 	transactionRepo domain.TransactionRepository,
 	queue domain.MessageQueue,
 	queueName string,
+	opts ...TransactionOption,
 ) domain.TransactionService {
-	return &TransactionUseCase{
+	uc := &TransactionUseCase{
 		accountRepo:     accountRepo,
 		transactionRepo: transactionRepo,
 		queue:           queue,
 		queueName:       queueName,
+		outboxGrace:     defaultOutboxGrace,
+		exportMaxRows:   defaultExportMaxRows,
+		limitLocation:   time.UTC,
+		now:             time.Now,
 	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
 }
 
 // ProcessTransaction processes a transaction request
@@ -41,232 +284,1883 @@ func (uc *TransactionUseCase) ProcessTransaction(ctx context.Context, request *d
 	if err := request.IsValid(); err != nil {
 		return nil, err
 	}
+	if err := uc.checkExternalParty(request); err != nil {
+		return nil, err
+	}
+	if err := uc.authorizeDebit(ctx, request); err != nil {
+		return nil, err
+	}
 
 	// Generate transaction ID if not provided
 	if request.ID == "" {
 		request.ID = uuid.New().String()
+		request.IDSource = domain.TransactionIDServer
 	}
 
-	// Create transaction record
-	transaction := &domain.Transaction{
-		ID:            request.ID,
-		Type:          request.Type,
-		FromAccountID: request.FromAccountID,
-		ToAccountID:   request.ToAccountID,
-		Amount:        request.Amount,
-		Currency:      request.Currency,
-		Status:        domain.TransactionStatusPending,
-		Description:   request.Description,
-		Reference:     request.Reference,
-		Metadata:      request.Metadata,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-	}
-
-	// Save transaction to ledger
-	err := uc.transactionRepo.Create(ctx, transaction)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	if request.IdempotencyKey == "" || uc.idempotency == nil {
+		return uc.createTransaction(ctx, request)
 	}
 
-	// Publish transaction to queue for async processing
-	requestBytes, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal transaction request: %w", err)
+	// A retried submission returns the transaction its key first created
+	existing, err := uc.claimIdempotencyKey(ctx, request)
+	if err != nil || existing != nil {
+		return existing, err
 	}
 
-	err = uc.queue.Publish(ctx, uc.queueName, requestBytes)
+	transaction, err := uc.createTransaction(ctx, request)
 	if err != nil {
-		// Update transaction status to failed
-		uc.transactionRepo.UpdateStatus(ctx, transaction.ID, domain.TransactionStatusFailed, err.Error())
-		return nil, fmt.Errorf("failed to publish transaction: %w", err)
+		// A rejected submission leaves nothing to replay, so the key is
+		// free for the corrected retry
+		if releaseErr := uc.idempotency.Release(ctx, request.IdempotencyKey, request.ID); releaseErr != nil {
+			log.Printf("Failed to release idempotency key for transaction %s: %v", request.ID, releaseErr)
+		}
+		return nil, err
 	}
-
 	return transaction, nil
 }
 
-// ProcessTransactionSync processes a transaction synchronously with ACID consistency
-func (uc *TransactionUseCase) ProcessTransactionSync(ctx context.Context, request *domain.TransactionRequest) error {
-	// Validate request
-	if err := request.IsValid(); err != nil {
-		return err
+// checkExternalParty fails with ErrExternalPartyRequired if external parties
+// are required and the request lacks its own
+func (uc *TransactionUseCase) checkExternalParty(request *domain.TransactionRequest) error {
+	if uc.requireExternalParty && request.MissingExternalParty() {
+		return domain.ErrExternalPartyRequired
 	}
+	return nil
+}
 
-	switch request.Type {
-	case domain.TransactionTypeDeposit:
-		return uc.processDeposit(ctx, request)
-	case domain.TransactionTypeWithdrawal:
-		return uc.processWithdrawal(ctx, request)
-	case domain.TransactionTypeTransfer:
-		return uc.processTransfer(ctx, request)
-	default:
-		return domain.ErrInvalidTransactionType
+// authorizeDebit fails with ErrForbidden if the caller does not own the
+// account a request debits. Deposits may be made into anyone's account, and
+// an account that does not exist is left for validation to report.
+func (uc *TransactionUseCase) authorizeDebit(ctx context.Context, request *domain.TransactionRequest) error {
+	if _, ok := domain.PrincipalFromContext(ctx); !ok || request.FromAccountID == nil {
+		return nil
 	}
-}
 
-// processDeposit processes a deposit transaction
-func (uc *TransactionUseCase) processDeposit(ctx context.Context, request *domain.TransactionRequest) error {
-	// Get account
-	account, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
+	account, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	return domain.AuthorizeUser(ctx, account.UserID)
+}
 
-	// Check account status
-	if account.Status != "active" {
-		return domain.ErrAccountInactive
+// authorizeParty fails with ErrForbidden unless the caller may see every
+// account or holds an account on either side of the transaction
+func (uc *TransactionUseCase) authorizeParty(ctx context.Context, transaction *domain.Transaction) error {
+	if domain.Unscoped(ctx) {
+		return nil
 	}
 
-	// Check currency match
-	if account.Currency != request.Currency {
-		return domain.ErrCurrencyMismatch
+	for _, id := range []*string{transaction.FromAccountID, transaction.ToAccountID} {
+		if id == nil {
+			continue
+		}
+		account, err := uc.accountRepo.GetByID(ctx, *id)
+		if errors.Is(err, domain.ErrAccountNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if domain.AuthorizeUser(ctx, account.UserID) == nil {
+			return nil
+		}
 	}
+	return domain.ErrForbidden
+}
 
-	// Update balance with optimistic locking
-	newBalance := account.Balance + request.Amount
-	err = uc.accountRepo.UpdateBalance(ctx, account.ID, newBalance, account.Version)
+// authorizeOwner fails with ErrForbidden if the caller does not own the
+// account a transaction debits, or for a deposit the account it credits
+func (uc *TransactionUseCase) authorizeOwner(ctx context.Context, transaction *domain.Transaction) error {
+	if domain.Unscoped(ctx) {
+		return nil
+	}
+
+	id := transaction.FromAccountID
+	if id == nil {
+		id = transaction.ToAccountID
+	}
+	if id == nil {
+		return domain.ErrForbidden
+	}
+	account, err := uc.accountRepo.GetByID(ctx, *id)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return domain.ErrForbidden
+	}
 	if err != nil {
 		return err
 	}
-
-	// Update transaction status
-	return uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusCompleted, "")
+	return domain.AuthorizeUser(ctx, account.UserID)
 }
 
-// processWithdrawal processes a withdrawal transaction
-func (uc *TransactionUseCase) processWithdrawal(ctx context.Context, request *domain.TransactionRequest) error {
-	// Get account
-	account, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
+// scopeFilter restricts a listing to the caller's accounts unless the caller
+// may see every account, failing with ErrForbidden for an account_id the
+// caller does not own. It reports false when the caller holds none of the
+// accounts asked for, leaving nothing to list.
+func (uc *TransactionUseCase) scopeFilter(ctx context.Context, filter *domain.TransactionFilter) (bool, error) {
+	if domain.Unscoped(ctx) {
+		return true, nil
+	}
+	principal, _ := domain.PrincipalFromContext(ctx)
+
+	accounts, err := uc.accountRepo.GetByUserID(ctx, principal.UserID)
 	if err != nil {
-		return err
+		return false, err
+	}
+	owned := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if len(filter.AccountIDs) == 0 || slices.Contains(filter.AccountIDs, account.ID) {
+			owned = append(owned, account.ID)
+		}
 	}
 
-	// Check account status
-	if account.Status != "active" {
-		return domain.ErrAccountInactive
+	if filter.AccountID != nil {
+		if !slices.Contains(owned, *filter.AccountID) {
+			return false, domain.ErrForbidden
+		}
+		return true, nil
 	}
+	filter.AccountIDs = owned
+	return len(owned) > 0, nil
+}
 
-	// Check currency match
-	if account.Currency != request.Currency {
-		return domain.ErrCurrencyMismatch
+// createTransaction saves a validated request as a new transaction and queues
+// it
+func (uc *TransactionUseCase) createTransaction(ctx context.Context, request *domain.TransactionRequest) (*domain.Transaction, error) {
+	// A client-supplied ID must be new; the repository's unique ID is the
+	// backstop for a concurrent submission with the same one
+	if request.IDSource == domain.TransactionIDClient {
+		if err := uc.checkTransactionID(ctx, request.ID); err != nil {
+			return nil, err
+		}
 	}
 
-	// Check sufficient funds
-	if account.Balance < request.Amount {
-		return domain.ErrInsufficientFunds
+	transaction := newTransaction(request)
+
+	// Verifications never touch balances, so they complete immediately
+	if request.Type == domain.TransactionTypeVerification {
+		return uc.completeVerification(ctx, request, transaction)
 	}
 
-	// Update balance with optimistic locking
-	newBalance := account.Balance - request.Amount
-	err = uc.accountRepo.UpdateBalance(ctx, account.ID, newBalance, account.Version)
-	if err != nil {
-		return err
+	// A future-dated transaction waits for the scheduler instead of the queue
+	scheduled := request.ScheduledAt != nil && request.ScheduledAt.After(uc.now())
+	if scheduled {
+		transaction.Status = domain.TransactionStatusScheduled
 	}
 
-	// Update transaction status
-	return uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusCompleted, "")
-}
+	if err := uc.markSelfTransfer(ctx, request); err != nil {
+		return nil, err
+	}
+	transaction.SelfTransfer = request.SelfTransfer
 
-// processTransfer processes a transfer transaction
-func (uc *TransactionUseCase) processTransfer(ctx context.Context, request *domain.TransactionRequest) error {
-	// Get both accounts
-	fromAccount, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
-	if err != nil {
-		return err
+	// A large transfer waits for an approver, who queues or schedules it
+	awaitingApproval := uc.requiresApproval(request)
+	if awaitingApproval {
+		transaction.Status = domain.TransactionStatusPendingApproval
 	}
 
-	toAccount, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
-	if err != nil {
-		return err
+	// Checked before the duplicate guard so a rejected submission does not
+	// block a corrected resubmission
+	if err := uc.validateSubmission(ctx, request, scheduled); err != nil {
+		return nil, err
 	}
 
-	// Validate accounts
-	if fromAccount.Status != "active" || toAccount.Status != "active" {
-		return domain.ErrAccountInactive
+	// Reject accidental back-to-back duplicates of a pending transaction
+	if err := uc.checkDuplicateSubmission(ctx, request); err != nil {
+		return nil, err
 	}
 
-	// Check currency match
-	if fromAccount.Currency != request.Currency || toAccount.Currency != request.Currency {
-		return domain.ErrCurrencyMismatch
+	if err := uc.checkVerification(ctx, request); err != nil {
+		return nil, err
 	}
 
-	// Check sufficient funds
-	if fromAccount.Balance < request.Amount {
-		return domain.ErrInsufficientFunds
+	if err := uc.checkQuota(ctx, request); err != nil {
+		return nil, err
 	}
 
-	// Process transfer atomically (in a real implementation, use database transactions)
-	// Update from account balance
-	newFromBalance := fromAccount.Balance - request.Amount
-	err = uc.accountRepo.UpdateBalance(ctx, fromAccount.ID, newFromBalance, fromAccount.Version)
-	if err != nil {
-		return err
+	// Save the queue message with the transaction so it is published even
+	// if this process fails before publishing it
+	queued := !scheduled && !awaitingApproval
+	if queued {
+		message, err := uc.outboxMessage(request)
+		if err != nil {
+			return nil, err
+		}
+		transaction.Outbox = message
 	}
 
-	// Update to account balance
-	newToBalance := toAccount.Balance + request.Amount
-	err = uc.accountRepo.UpdateBalance(ctx, toAccount.ID, newToBalance, toAccount.Version)
+	// Save transaction to ledger
+	err := uc.transactionRepo.Create(ctx, transaction)
 	if err != nil {
-		// Rollback from account balance (simplified - in production use database transactions)
-		uc.accountRepo.UpdateBalance(ctx, fromAccount.ID, fromAccount.Balance, fromAccount.Version+1)
-		return err
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	// Update transaction status
-	return uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusCompleted, "")
-}
+	if queued {
+		uc.dispatch(ctx, transaction.ID, transaction.Outbox)
+	}
 
-// GetTransaction retrieves a transaction by ID
-func (uc *TransactionUseCase) GetTransaction(ctx context.Context, id string) (*domain.Transaction, error) {
-	return uc.transactionRepo.GetByID(ctx, id)
+	return transaction, nil
 }
 
-// GetTransactionHistory retrieves transaction history for an account
-func (uc *TransactionUseCase) GetTransactionHistory(ctx context.Context, accountID string, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
-	return uc.transactionRepo.GetByAccountID(ctx, accountID, filter)
+// checkTransactionID fails with ErrTransactionIDTaken if a transaction,
+// archived or not, already has the ID
+func (uc *TransactionUseCase) checkTransactionID(ctx context.Context, id string) error {
+	_, err := uc.transactionRepo.GetByID(ctx, id)
+	switch {
+	case err == nil:
+		return domain.ErrTransactionIDTaken
+	case errors.Is(err, domain.ErrTransactionNotFound):
+		return nil
+	default:
+		return err
+	}
 }
 
-// GetTransactionsByFilter retrieves transactions by filter
-func (uc *TransactionUseCase) GetTransactionsByFilter(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
-	return uc.transactionRepo.GetByFilter(ctx, filter)
+// newTransaction builds the pending transaction record for a request
+func newTransaction(request *domain.TransactionRequest) *domain.Transaction {
+	return &domain.Transaction{
+		ID:            request.ID,
+		Type:          request.Type,
+		FromAccountID: request.FromAccountID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+		Status:        domain.TransactionStatusPending,
+		Description:   request.Description,
+		Reference:     request.Reference,
+		Metadata:      request.Metadata,
+		Tags:          request.Tags,
+		System:        request.System,
+		ScheduledAt:   request.ScheduledAt,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+
+		ReversedTransactionID: request.ReversedTransactionID,
+		RefundedTransactionID: request.RefundedTransactionID,
+		IdempotencyKey:        request.IdempotencyKey,
+		IDSource:              request.IDSource,
+		ExternalSource:        request.ExternalSource,
+		ExternalDestination:   request.ExternalDestination,
+		SelfTransfer:          request.SelfTransfer,
+	}
 }
 
-// CancelTransaction cancels a pending transaction
-func (uc *TransactionUseCase) CancelTransaction(ctx context.Context, id string) error {
-	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+// markSelfTransfer flags a transfer between two accounts of the same user. A
+// missing account is left for validation or processing to report.
+func (uc *TransactionUseCase) markSelfTransfer(ctx context.Context, request *domain.TransactionRequest) error {
+	if request.Type != domain.TransactionTypeTransfer {
+		return nil
+	}
+
+	from, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-
-	if transaction.Status != domain.TransactionStatusPending {
-		return domain.ErrTransactionAlreadyProcessed
+	to, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
+	if errors.Is(err, domain.ErrAccountNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	return uc.transactionRepo.UpdateStatus(ctx, id, domain.TransactionStatusCancelled, "Cancelled by user")
+	request.SelfTransfer = domain.IsSelfTransfer(from, to)
+	return nil
 }
 
-// StartTransactionProcessor starts the transaction processor
-func (uc *TransactionUseCase) StartTransactionProcessor(ctx context.Context) error {
-	handler := func(data []byte) error {
-		var request domain.TransactionRequest
-		if err := json.Unmarshal(data, &request); err != nil {
-			log.Printf("Failed to unmarshal transaction request: %v", err)
+// requiresApproval reports whether a transfer is large enough to need an
+// approver. Transfers the ledger initiates itself never do, nor, when they
+// are exempt, transfers between a user's own accounts.
+func (uc *TransactionUseCase) requiresApproval(request *domain.TransactionRequest) bool {
+	if uc.approvalThreshold == "" || request.Type != domain.TransactionTypeTransfer || request.System {
+		return false
+	}
+	if request.SelfTransfer && uc.approvalExemptSelf {
+		return false
+	}
+	threshold, ok := uc.approvalThreshold.Bound(domain.CurrencyExponent(request.Currency), false)
+	return ok && request.Amount > threshold
+}
+
+// validateSubmission runs the submission checks enabled by
+// WithSubmissionValidation. Funds are not checked for a scheduled transaction,
+// since the account may be funded before it runs.
+func (uc *TransactionUseCase) validateSubmission(ctx context.Context, request *domain.TransactionRequest, scheduled bool) error {
+	if !uc.validateSubmissions {
+		return nil
+	}
+	switch request.Type {
+	case domain.TransactionTypeDeposit, domain.TransactionTypeWithdrawal, domain.TransactionTypeTransfer:
+	default:
+		// Reversals and refunds are checked against their original
+		return nil
+	}
+
+	if request.FromAccountID != nil {
+		account, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
+		if err != nil {
 			return err
 		}
+		if err := account.Status.CanDebit(); err != nil {
+			return err
+		}
+		if account.Currency != request.Currency {
+			return domain.ErrCurrencyMismatch
+		}
+		if uc.validateFunds && !scheduled {
+			if err := account.CheckFunds(request.Amount); err != nil {
+				return err
+			}
+		}
+		// The day a scheduled transaction counts against is the day it runs
+		if scheduled {
+			if err := account.CheckTransaction(request.Amount, account.Currency); err != nil {
+				return err
+			}
+		} else if err := uc.checkAccountLimits(ctx, request, account); err != nil {
+			return err
+		}
+	}
 
-		log.Printf("Processing transaction: %s", request.ID)
-
-		err := uc.ProcessTransactionSync(ctx, &request)
+	if request.ToAccountID != nil {
+		account, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
 		if err != nil {
-			log.Printf("Failed to process transaction %s: %v", request.ID, err)
-			// Update transaction status to failed
-			uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusFailed, err.Error())
 			return err
 		}
+		if err := account.Status.CanCredit(); err != nil {
+			return err
+		}
+		// The rate is only fetched when the transfer is processed
+		convertible := uc.exchangeRates != nil && request.Type == domain.TransactionTypeTransfer
+		if account.Currency != request.Currency && !convertible {
+			return domain.ErrCurrencyMismatch
+		}
+	}
 
-		log.Printf("Successfully processed transaction: %s", request.ID)
-		return nil
+	return nil
+}
+
+// publish queues a saved transaction for async processing through its
+// outbox. If the message cannot be published now, the outbox relay publishes
+// it later.
+func (uc *TransactionUseCase) publish(ctx context.Context, request *domain.TransactionRequest) error {
+	message, err := uc.outboxMessage(request)
+	if err != nil {
+		return err
 	}
 
-	return uc.queue.Subscribe(ctx, uc.queueName, handler)
+	if err := uc.transactionRepo.SetOutbox(ctx, request.ID, message); err != nil {
+		return err
+	}
+
+	uc.dispatch(ctx, request.ID, message)
+	return nil
+}
+
+// outboxMessage serializes a message for a transaction's outbox
+func (uc *TransactionUseCase) outboxMessage(message interface{}) (*domain.OutboxMessage, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal queue message: %w", err)
+	}
+	return &domain.OutboxMessage{Payload: payload, CreatedAt: uc.now()}, nil
+}
+
+// dispatch publishes a transaction's outbox message and clears it. A message
+// that cannot be published stays in the outbox for the relay.
+func (uc *TransactionUseCase) dispatch(ctx context.Context, id string, message *domain.OutboxMessage) bool {
+	if err := uc.queue.Publish(ctx, uc.queueName, message.Payload); err != nil {
+		log.Printf("Failed to publish transaction %s, leaving it in the outbox: %v", id, err)
+		return false
+	}
+
+	if err := uc.transactionRepo.MarkDispatched(ctx, id, uc.nextQueueSequence(ctx, id)); err != nil {
+		// The relay publishes it again, which the processor tolerates
+		log.Printf("Failed to mark transaction %s dispatched: %v", id, err)
+	}
+	return true
+}
+
+// DispatchOutbox publishes outbox messages left unpublished for longer than
+// the outbox grace period, as when the broker was down or the process
+// publishing them failed. It stops at the first message that cannot be
+// published and returns how many were published and how many remain.
+func (uc *TransactionUseCase) DispatchOutbox(ctx context.Context) (dispatched int, backlog int64, err error) {
+	transactions, err := uc.transactionRepo.ListUndispatched(ctx, uc.now().Add(-uc.outboxGrace), outboxBatch)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, transaction := range transactions {
+		if !uc.dispatch(ctx, transaction.ID, transaction.Outbox) {
+			break
+		}
+		dispatched++
+	}
+
+	backlog, err = uc.transactionRepo.CountUndispatched(ctx)
+	if err != nil {
+		return dispatched, 0, err
+	}
+	return dispatched, backlog, nil
+}
+
+// ReleaseDueTransactions queues scheduled transactions whose time has come,
+// moving them to pending. It returns how many were queued.
+func (uc *TransactionUseCase) ReleaseDueTransactions(ctx context.Context) (int, error) {
+	transactions, err := uc.transactionRepo.ListDueScheduled(ctx, uc.now(), dueScheduledBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, transaction := range transactions {
+		if err := uc.transactionRepo.ReleaseScheduled(ctx, transaction.ID); err != nil {
+			// A transaction cancelled since it was listed is not an error
+			if !errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+				log.Printf("Failed to release scheduled transaction %s: %v", transaction.ID, err)
+			}
+			continue
+		}
+
+		if err := uc.publish(ctx, storedRequest(transaction)); err != nil {
+			log.Printf("Failed to queue scheduled transaction %s: %v", transaction.ID, err)
+			continue
+		}
+		released++
+	}
+
+	return released, nil
+}
+
+// ExpireStalePending handles transactions left pending longer than the
+// pending TTL, as when their message was lost. Each is requeued once if
+// requeueing is enabled and failed otherwise; transactions the processor has
+// picked up are left alone. Transactions left awaiting approval longer than
+// the approval TTL are failed too. It returns how many were failed and
+// requeued, even when it stops early with an error.
+func (uc *TransactionUseCase) ExpireStalePending(ctx context.Context) (expired, requeued int, err error) {
+	expired, err = uc.expireUnapproved(ctx)
+	if err != nil || uc.pendingTTL <= 0 {
+		return expired, 0, err
+	}
+
+	transactions, err := uc.transactionRepo.ListStalePending(ctx, uc.now().Add(-uc.pendingTTL), stalePendingBatch)
+	if err != nil {
+		return expired, 0, err
+	}
+
+	for _, transaction := range transactions {
+		// A batch leg cannot be queued on its own; expiring it fails the batch
+		if uc.requeueStale && transaction.RequeuedAt == nil && transaction.BatchID == "" {
+			if err := uc.transactionRepo.RequeuePending(ctx, transaction.ID); err != nil {
+				if !errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+					log.Printf("Failed to requeue pending transaction %s: %v", transaction.ID, err)
+				}
+				continue
+			}
+			if err := uc.publish(ctx, storedRequest(transaction)); err != nil {
+				log.Printf("Failed to requeue pending transaction %s: %v", transaction.ID, err)
+				continue
+			}
+			requeued++
+			continue
+		}
+
+		err := uc.transactionRepo.FailPending(ctx, transaction.ID, domain.FailureCodeExpired, domain.ErrTransactionExpired.Error())
+		if err != nil {
+			// A transaction picked up since it was listed is not an error
+			if !errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+				log.Printf("Failed to expire pending transaction %s: %v", transaction.ID, err)
+			}
+			continue
+		}
+		expired++
+
+		// Let the original be reversed again, as when a reversal fails
+		if transaction.Type == domain.TransactionTypeReversal {
+			if err := uc.transactionRepo.ResolveReversal(ctx, transaction.ReversedTransactionID, transaction.ID, false); err != nil {
+				log.Printf("Failed to release reversal claim on transaction %s: %v", transaction.ReversedTransactionID, err)
+			}
+		}
+	}
+
+	return expired, requeued, nil
+}
+
+// ArchiveTransactions moves the transactions that have been in a final
+// status past the archive age to the archive, batch by batch until one comes
+// up short, returning how many were moved. A run that stops early is picked
+// up by the next.
+func (uc *TransactionUseCase) ArchiveTransactions(ctx context.Context) (int, error) {
+	if uc.archiveAfter <= 0 {
+		return 0, nil
+	}
+	batchSize := uc.archiveBatch
+	if batchSize <= 0 {
+		batchSize = defaultArchiveBatch
+	}
+
+	before := uc.now().Add(-uc.archiveAfter)
+	archived := 0
+	for ctx.Err() == nil {
+		moved, err := uc.transactionRepo.ArchiveBefore(ctx, before, batchSize)
+		archived += moved
+		if err != nil {
+			return archived, err
+		}
+		if moved < batchSize {
+			break
+		}
+	}
+
+	return archived, nil
+}
+
+// expireUnapproved fails transactions left awaiting approval for longer than
+// the approval TTL, returning how many were failed
+func (uc *TransactionUseCase) expireUnapproved(ctx context.Context) (int, error) {
+	if uc.approvalTTL <= 0 {
+		return 0, nil
+	}
+
+	transactions, err := uc.transactionRepo.ListAwaitingApproval(ctx, uc.now().Add(-uc.approvalTTL), stalePendingBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, transaction := range transactions {
+		err := uc.transactionRepo.FailIf(ctx, transaction.ID, domain.TransactionStatusPendingApproval, domain.FailureCodeExpired, domain.ErrApprovalExpired.Error())
+		if err != nil {
+			// A transaction approved or rejected since it was listed is
+			// not an error
+			if !errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+				log.Printf("Failed to expire unapproved transaction %s: %v", transaction.ID, err)
+			}
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// storedRequest rebuilds the request for a stored transaction so it can be
+// queued
+func storedRequest(transaction *domain.Transaction) *domain.TransactionRequest {
+	return &domain.TransactionRequest{
+		ID:            transaction.ID,
+		Type:          transaction.Type,
+		FromAccountID: transaction.FromAccountID,
+		ToAccountID:   transaction.ToAccountID,
+		Amount:        transaction.Amount,
+		Currency:      transaction.Currency,
+		Description:   transaction.Description,
+		Reference:     transaction.Reference,
+		Metadata:      transaction.Metadata,
+		System:        transaction.System,
+		ScheduledAt:   transaction.ScheduledAt,
+		BatchID:       transaction.BatchID,
+
+		ReversedTransactionID: transaction.ReversedTransactionID,
+		RefundedTransactionID: transaction.RefundedTransactionID,
+		ExternalSource:        transaction.ExternalSource,
+		ExternalDestination:   transaction.ExternalDestination,
+	}
+}
+
+// claimIdempotencyKey claims the request's idempotency key for it, returning
+// the transaction an earlier request with the key created instead. A key used
+// with a different payload, or by a request still being created, is rejected.
+func (uc *TransactionUseCase) claimIdempotencyKey(ctx context.Context, request *domain.TransactionRequest) (*domain.Transaction, error) {
+	fingerprint := request.PayloadFingerprint()
+	record, err := uc.idempotency.Claim(ctx, &domain.IdempotencyRecord{
+		Key:           request.IdempotencyKey,
+		Fingerprint:   fingerprint,
+		TransactionID: request.ID,
+		CreatedAt:     uc.now(),
+	}, uc.idempotencyTTL)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, nil
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	existing, err := uc.transactionRepo.GetByID(ctx, record.TransactionID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTransactionNotFound) {
+			return nil, domain.ErrIdempotencyKeyInUse
+		}
+		return nil, err
+	}
+	replay := *existing
+	replay.Replayed = true
+	return &replay, nil
+}
+
+// checkDuplicateSubmission claims the request's fingerprint and rejects the
+// request if an identical transaction submitted within the duplicate window is
+// still pending or still being created
+func (uc *TransactionUseCase) checkDuplicateSubmission(ctx context.Context, request *domain.TransactionRequest) error {
+	if uc.submissionGuard == nil || request.AllowDuplicate {
+		return nil
+	}
+
+	fingerprint := request.Fingerprint()
+	now := uc.now()
+
+	holderID, err := uc.submissionGuard.Claim(ctx, fingerprint, request.ID, now, uc.duplicateWindow)
+	if err != nil {
+		return fmt.Errorf("failed to check for duplicate submission: %w", err)
+	}
+
+	for holderID != "" {
+		existing, err := uc.transactionRepo.GetByID(ctx, holderID)
+		if err != nil && !errors.Is(err, domain.ErrTransactionNotFound) {
+			return fmt.Errorf("failed to check for duplicate submission: %w", err)
+		}
+
+		// A holder without a document is a concurrent submission that has not
+		// been saved yet, so it counts as pending
+		if err != nil || existing.Status.IsInFlight() {
+			return &domain.DuplicateTransactionError{ExistingTransactionID: holderID}
+		}
+
+		// The earlier transaction has reached an outcome, so it no longer blocks this one
+		holderID, err = uc.submissionGuard.Takeover(ctx, fingerprint, holderID, request.ID, now)
+		if err != nil {
+			return fmt.Errorf("failed to check for duplicate submission: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ProcessTransactionSync processes a transaction synchronously with ACID consistency
+func (uc *TransactionUseCase) ProcessTransactionSync(ctx context.Context, request *domain.TransactionRequest) error {
+	if err := uc.processSync(ctx, request); err != nil {
+		return err
+	}
+
+	uc.notifyCompleted(ctx, request)
+	return nil
+}
+
+// processSync applies a transaction according to its type
+func (uc *TransactionUseCase) processSync(ctx context.Context, request *domain.TransactionRequest) error {
+	// Validate request
+	if err := request.IsValid(); err != nil {
+		return err
+	}
+
+	// Checked again as the transaction is applied, since the user's level or
+	// deposits may have changed since it was submitted
+	if err := uc.checkVerification(ctx, request); err != nil {
+		return err
+	}
+
+	switch request.Type {
+	case domain.TransactionTypeDeposit:
+		return uc.processDeposit(ctx, request)
+	case domain.TransactionTypeWithdrawal:
+		return uc.processWithdrawal(ctx, request)
+	case domain.TransactionTypeTransfer:
+		return uc.processTransfer(ctx, request)
+	case domain.TransactionTypeVerification:
+		if err := uc.verifyAccount(ctx, request); err != nil {
+			return err
+		}
+		return uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusCompleted, "")
+	case domain.TransactionTypeReversal:
+		return uc.processReversal(ctx, request)
+	case domain.TransactionTypeRefund:
+		return uc.processRefund(ctx, request)
+	case domain.TransactionTypeCompensation:
+		return uc.processCompensation(ctx, request)
+	default:
+		return domain.ErrInvalidTransactionType
+	}
+}
+
+// completeVerification runs the account checks for a verification request and
+// records the outcome without publishing it for async processing
+func (uc *TransactionUseCase) completeVerification(ctx context.Context, request *domain.TransactionRequest, transaction *domain.Transaction) (*domain.Transaction, error) {
+	now := time.Now()
+	transaction.ProcessedAt = &now
+
+	if err := uc.verifyAccount(ctx, request); err != nil {
+		transaction.Status = domain.TransactionStatusFailed
+		transaction.ErrorMessage = err.Error()
+		transaction.FailureCode = domain.FailureCodeFor(err)
+	} else {
+		transaction.Status = domain.TransactionStatusCompleted
+	}
+
+	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// verifyAccount performs the existence, status and currency checks for a
+// verification request
+func (uc *TransactionUseCase) verifyAccount(ctx context.Context, request *domain.TransactionRequest) error {
+	account, err := uc.accountRepo.GetByID(ctx, *request.VerifiedAccountID())
+	if err != nil {
+		return err
+	}
+
+	// Verification pings check the account could be charged
+	if err := account.Status.CanDebit(); err != nil {
+		return err
+	}
+
+	if account.Currency != request.Currency {
+		return domain.ErrCurrencyMismatch
+	}
+
+	return nil
+}
+
+// processDeposit processes a deposit transaction
+func (uc *TransactionUseCase) processDeposit(ctx context.Context, request *domain.TransactionRequest) error {
+	plan, err := uc.planDeposit(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	// Update balance with optimistic locking
+	posting := domain.LedgerPosting{
+		domain.NewLedgerEntry(request.ID, plan.to, domain.EntryDirectionCredit, plan.credit),
+	}
+	if err := uc.post(ctx, posting); err != nil {
+		return err
+	}
+
+	// Update transaction status
+	return uc.recordCompleted(ctx, request.ID, posting.BalanceChanges(request.ID))
+}
+
+// processWithdrawal processes a withdrawal transaction
+func (uc *TransactionUseCase) processWithdrawal(ctx context.Context, request *domain.TransactionRequest) error {
+	plan, err := uc.planWithdrawal(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	// Update balance with optimistic locking, taking any fee with it
+	posting := domain.LedgerPosting{
+		domain.NewLedgerEntry(request.ID, plan.from, domain.EntryDirectionDebit, request.Amount),
+	}
+	if err := uc.post(ctx, plan.fee.appendTo(posting, plan.from)); err != nil {
+		return err
+	}
+	uc.recordFee(ctx, plan.fee)
+
+	// Update transaction status
+	return uc.recordCompleted(ctx, request.ID, posting.BalanceChanges(request.ID))
+}
+
+// processTransfer processes a transfer transaction
+func (uc *TransactionUseCase) processTransfer(ctx context.Context, request *domain.TransactionRequest) error {
+	// Get both accounts
+	fromAccount, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
+	if err != nil {
+		return err
+	}
+
+	toAccount, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
+	if err != nil {
+		return err
+	}
+
+	if uc.splitsTransfer(request, fromAccount, toAccount) {
+		return uc.processSplitTransfer(ctx, request, fromAccount)
+	}
+
+	plan, err := uc.planTransfer(ctx, request, fromAccount, toAccount)
+	if err != nil {
+		return err
+	}
+
+	// The conversion is recorded before the transfer is posted
+	if plan.exchange != nil {
+		if err := uc.transactionRepo.RecordExchange(ctx, request.ID, plan.exchange); err != nil {
+			return err
+		}
+	}
+
+	// Debit and credit both accounts together, taking any fee with them
+	posting := domain.LedgerPosting{
+		domain.NewLedgerEntry(request.ID, plan.from, domain.EntryDirectionDebit, request.Amount),
+		domain.NewLedgerEntry(request.ID, plan.to, domain.EntryDirectionCredit, plan.credit),
+	}
+	if err := uc.post(ctx, plan.fee.appendTo(posting, plan.from)); err != nil {
+		return err
+	}
+	uc.recordFee(ctx, plan.fee)
+
+	// Update transaction status
+	return uc.recordCompleted(ctx, request.ID, posting.BalanceChanges(request.ID))
+}
+
+// checkWithdrawalLimit reports whether a withdrawal or transfer would exceed
+// a savings account's withdrawals and outgoing transfers for the current
+// calendar month. Reversals and refunds are not limited.
+func (uc *TransactionUseCase) checkWithdrawalLimit(ctx context.Context, request *domain.TransactionRequest, account *domain.Account) error {
+	if account.Type != domain.AccountTypeSavings || uc.savingsWithdrawalLimit <= 0 {
+		return nil
+	}
+	if request.Type != domain.TransactionTypeWithdrawal && request.Type != domain.TransactionTypeTransfer {
+		return nil
+	}
+
+	now := uc.now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	completed := domain.TransactionStatusCompleted
+	count, err := uc.transactionRepo.Count(ctx, &domain.TransactionFilter{
+		FromAccountID: &account.ID,
+		Types:         []domain.TransactionType{domain.TransactionTypeWithdrawal, domain.TransactionTypeTransfer},
+		Status:        &completed,
+		FromDate:      &monthStart,
+	})
+	if err != nil {
+		return err
+	}
+	if count >= int64(uc.savingsWithdrawalLimit) {
+		return domain.ErrWithdrawalLimitExceeded
+	}
+	return nil
+}
+
+// checkAccountLimits reports whether a withdrawal or transfer would exceed
+// its account's maximum transaction amount, or take the day's withdrawals and
+// outgoing transfers over its daily limit. Transactions still processing
+// count towards the day, so that concurrent ones cannot both slip under it.
+func (uc *TransactionUseCase) checkAccountLimits(ctx context.Context, request *domain.TransactionRequest, account *domain.Account) error {
+	if request.Type != domain.TransactionTypeWithdrawal && request.Type != domain.TransactionTypeTransfer {
+		return nil
+	}
+	if err := account.CheckTransaction(request.Amount, account.Currency); err != nil {
+		return err
+	}
+	if account.DailyOutgoing <= 0 {
+		return nil
+	}
+
+	now := uc.now().In(uc.limitLocation)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, uc.limitLocation)
+	used, err := uc.transactionRepo.SumOutgoing(ctx, account.ID, dayStart, request.ID)
+	if err != nil {
+		return err
+	}
+	return account.CheckDaily(request.Amount, used, account.Currency)
+}
+
+// post applies a posting's balances. With a ledger entry repository the
+// entries and balances are written atomically and a replayed transaction is
+// recognised by its existing entries; without one only the balances are
+// updated, one account at a time.
+func (uc *TransactionUseCase) post(ctx context.Context, posting domain.LedgerPosting) error {
+	if uc.ledgerRepo != nil {
+		err := uc.ledgerRepo.Post(ctx, posting)
+		if errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+			return nil
+		}
+		return err
+	}
+
+	for i, entry := range posting {
+		err := uc.accountRepo.UpdateBalance(ctx, entry.AccountID, entry.ResultingBalance, entry.AccountVersion-1)
+		if err != nil {
+			// Roll back earlier balance updates (simplified - the ledger entry
+			// repository posts atomically)
+			for _, applied := range posting[:i] {
+				previous := applied.ResultingBalance + applied.Amount
+				if applied.Direction == domain.EntryDirectionCredit {
+					previous = applied.ResultingBalance - applied.Amount
+				}
+				uc.accountRepo.UpdateBalance(ctx, applied.AccountID, previous, applied.AccountVersion)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// processReversal moves a reversed transaction's amount back and records the
+// reversal on the original
+func (uc *TransactionUseCase) processReversal(ctx context.Context, request *domain.TransactionRequest) error {
+	if err := uc.moveBack(ctx, request); err != nil {
+		return err
+	}
+
+	return uc.transactionRepo.ResolveReversal(ctx, request.ReversedTransactionID, request.ID, true)
+}
+
+// processRefund counts a refund against the refunded payment, which fails if
+// the payment's refunds would exceed its amount, then moves the refunded
+// amount back. The count is released if the refund fails.
+func (uc *TransactionUseCase) processRefund(ctx context.Context, request *domain.TransactionRequest) error {
+	if err := uc.transactionRepo.RecordRefund(ctx, request.RefundedTransactionID, request.ID, request.Amount); err != nil {
+		return err
+	}
+
+	if err := uc.moveBack(ctx, request); err != nil {
+		// The refund was posted, so it still counts
+		var unrecorded *domain.UnrecordedStatusError
+		if errors.As(err, &unrecorded) {
+			return err
+		}
+		if releaseErr := uc.transactionRepo.ReleaseRefund(ctx, request.RefundedTransactionID, request.ID, request.Amount); releaseErr != nil {
+			log.Printf("Failed to release refund %s on transaction %s: %v", request.ID, request.RefundedTransactionID, releaseErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// moveBack applies a reversal or refund, which swaps the original's accounts.
+// Balance conflicts are retried here because the processor does not retry
+// failed reversals and refunds.
+func (uc *TransactionUseCase) moveBack(ctx context.Context, request *domain.TransactionRequest) error {
+	const maxRetries = 3
+
+	var err error
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		switch {
+		case request.FromAccountID != nil && request.ToAccountID != nil:
+			err = uc.processTransfer(ctx, request)
+		case request.FromAccountID != nil:
+			err = uc.processWithdrawal(ctx, request)
+		default:
+			err = uc.processDeposit(ctx, request)
+		}
+		if !errors.Is(err, domain.ErrConcurrentUpdate) {
+			break
+		}
+	}
+	return err
+}
+
+// ReverseTransaction submits a reversal of a completed transaction. The
+// reversal swaps the original's accounts and is processed asynchronously; a
+// transaction can have only one reversal unless an earlier one failed.
+func (uc *TransactionUseCase) ReverseTransaction(ctx context.Context, id string) (*domain.Transaction, error) {
+	original, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// A refunded payment is refunded further rather than reversed
+	if original.Status != domain.TransactionStatusCompleted || !original.Type.IsReversible() || original.RefundedAmount > 0 {
+		return nil, domain.ErrTransactionNotReversible
+	}
+	if original.ReversalID != "" {
+		return nil, domain.ErrTransactionAlreadyReversed
+	}
+	if original.Archived {
+		return nil, domain.ErrTransactionArchived
+	}
+
+	reversalID := uuid.New().String()
+	if err := uc.transactionRepo.ClaimReversal(ctx, original.ID, reversalID); err != nil {
+		return nil, err
+	}
+
+	reversal, err := uc.ProcessTransaction(ctx, &domain.TransactionRequest{
+		ID:                    reversalID,
+		Type:                  domain.TransactionTypeReversal,
+		FromAccountID:         original.ToAccountID,
+		ToAccountID:           original.FromAccountID,
+		Amount:                original.Amount,
+		Currency:              original.Currency,
+		Description:           "Reversal of " + original.ID,
+		ReversedTransactionID: original.ID,
+		AllowDuplicate:        true,
+	})
+	if err != nil {
+		if releaseErr := uc.transactionRepo.ResolveReversal(ctx, original.ID, reversalID, false); releaseErr != nil {
+			log.Printf("Failed to release reversal claim on transaction %s: %v", original.ID, releaseErr)
+		}
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+// RefundTransaction submits a refund of a completed payment, of the amount
+// given or else of everything not yet refunded. The refund swaps the
+// payment's accounts and is processed asynchronously; a payment's refunds may
+// not add up to more than its amount.
+func (uc *TransactionUseCase) RefundTransaction(ctx context.Context, id string, amount domain.Decimal) (*domain.Transaction, error) {
+	original, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !original.Type.IsRefundable() {
+		return nil, domain.ErrTransactionNotRefundable
+	}
+	if original.Status != domain.TransactionStatusCompleted {
+		return nil, domain.ErrTransactionNotCompleted
+	}
+	if original.ReversalID != "" {
+		return nil, domain.ErrTransactionAlreadyReversed
+	}
+	if original.Archived {
+		return nil, domain.ErrTransactionArchived
+	}
+
+	refunded := original.RefundableAmount()
+	if amount != "" {
+		parsed, err := amount.Money(original.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if parsed <= 0 {
+			return nil, domain.ErrInvalidAmount
+		}
+		refunded = parsed
+	}
+	if refunded <= 0 || refunded > original.RefundableAmount() {
+		return nil, domain.ErrRefundExceedsOriginal
+	}
+
+	return uc.ProcessTransaction(ctx, &domain.TransactionRequest{
+		ID:                    uuid.New().String(),
+		Type:                  domain.TransactionTypeRefund,
+		FromAccountID:         original.ToAccountID,
+		ToAccountID:           original.FromAccountID,
+		Amount:                refunded,
+		Currency:              original.Currency,
+		Description:           "Refund of " + original.ID,
+		RefundedTransactionID: original.ID,
+		AllowDuplicate:        true,
+	})
+}
+
+// AdjustBalance applies an operator's balance correction immediately and
+// records it as an adjustment transaction, failed adjustments included. A
+// debit may not take the balance below zero unless the request allows it.
+func (uc *TransactionUseCase) AdjustBalance(ctx context.Context, request *domain.AdjustmentRequest) (*domain.Transaction, error) {
+	const maxRetries = 3
+
+	if err := request.IsValid(); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{
+		"reason":      request.Reason,
+		"operator_id": request.OperatorID,
+	}
+	if request.AllowNegativeBalance {
+		metadata["allow_negative_balance"] = true
+	}
+
+	transaction := &domain.Transaction{
+		ID:          uuid.New().String(),
+		Type:        domain.TransactionTypeAdjustment,
+		Amount:      request.Amount.Abs(),
+		Currency:    request.Currency,
+		Status:      domain.TransactionStatusProcessing,
+		Description: request.Reason,
+		Reference:   request.Reference,
+		Metadata:    metadata,
+	}
+	if request.Direction() == domain.EntryDirectionDebit {
+		transaction.FromAccountID = &request.AccountID
+	} else {
+		transaction.ToAccountID = &request.AccountID
+	}
+
+	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = uc.applyAdjustment(ctx, request, transaction)
+		if !errors.Is(err, domain.ErrConcurrentUpdate) || attempt == maxRetries {
+			break
+		}
+	}
+	if err != nil {
+		if markErr := uc.transactionRepo.MarkFailed(ctx, transaction.ID, domain.FailureCodeFor(err), err.Error()); markErr != nil {
+			log.Printf("Failed to mark adjustment %s failed: %v", transaction.ID, markErr)
+		}
+		return nil, err
+	}
+
+	if err := uc.transactionRepo.UpdateStatus(ctx, transaction.ID, domain.TransactionStatusCompleted, ""); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	transaction.Status = domain.TransactionStatusCompleted
+	transaction.ProcessedAt = &now
+	return transaction, nil
+}
+
+// applyAdjustment posts an adjustment against the account's current balance.
+// Corrections may debit frozen accounts, but not inactive or closed ones.
+func (uc *TransactionUseCase) applyAdjustment(ctx context.Context, request *domain.AdjustmentRequest, transaction *domain.Transaction) error {
+	account, err := uc.accountRepo.GetByID(ctx, request.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if err := account.Status.CanCredit(); err != nil {
+		return err
+	}
+	if account.Currency != request.Currency {
+		return domain.ErrCurrencyMismatch
+	}
+	if account.Balance+request.Amount < 0 && !request.AllowNegativeBalance {
+		return domain.ErrNegativeBalance
+	}
+
+	return uc.post(ctx, domain.LedgerPosting{
+		domain.NewLedgerEntry(transaction.ID, account, request.Direction(), transaction.Amount),
+	})
+}
+
+// GetTransaction retrieves a transaction by ID, failing with ErrForbidden if
+// the caller holds neither of its accounts
+func (uc *TransactionUseCase) GetTransaction(ctx context.Context, id string) (*domain.Transaction, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.authorizeParty(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// UpdateTransactionTags replaces a transaction's tags. Only the tags change,
+// so they can be edited in any status short of being archived, by the owner
+// of the account the transaction debits.
+func (uc *TransactionUseCase) UpdateTransactionTags(ctx context.Context, id string, tags []string) (*domain.Transaction, error) {
+	normalized, err := domain.NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.authorizeOwner(ctx, transaction); err != nil {
+		return nil, err
+	}
+	if transaction.Archived {
+		return nil, domain.ErrTransactionArchived
+	}
+
+	if err := uc.transactionRepo.UpdateTags(ctx, id, normalized); err != nil {
+		return nil, err
+	}
+
+	transaction.Tags = normalized
+	return transaction, nil
+}
+
+// LookupTransactions fetches transactions by ID in one query, keeping the
+// order asked for and dropping repeated IDs. Transactions the caller may not
+// see are reported missing.
+func (uc *TransactionUseCase) LookupTransactions(ctx context.Context, ids []string) (*domain.TransactionLookup, error) {
+	if len(ids) == 0 || len(ids) > domain.MaxTransactionLookupIDs {
+		return nil, domain.ErrInvalidTransactionLookup
+	}
+
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if strings.TrimSpace(id) == "" {
+			return nil, domain.ErrInvalidTransactionLookup
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	transactions, err := uc.transactionRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]*domain.Transaction, len(transactions))
+	for _, transaction := range transactions {
+		found[transaction.ID] = transaction
+	}
+
+	lookup := &domain.TransactionLookup{Transactions: make([]*domain.Transaction, 0, len(transactions))}
+	for _, id := range unique {
+		transaction, ok := found[id]
+		if ok {
+			switch err := uc.authorizeParty(ctx, transaction); {
+			case errors.Is(err, domain.ErrForbidden):
+				ok = false
+			case err != nil:
+				return nil, err
+			}
+		}
+		if ok {
+			lookup.Transactions = append(lookup.Transactions, transaction)
+		} else {
+			lookup.Missing = append(lookup.Missing, id)
+		}
+	}
+	return lookup, nil
+}
+
+// GetTransactionHistory retrieves transaction history for an account,
+// returning ErrAccountNotFound for an account that does not exist
+func (uc *TransactionUseCase) GetTransactionHistory(ctx context.Context, accountID string, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+	transactions, err := uc.transactionRepo.GetByAccountID(ctx, accountID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if transactions == nil {
+		transactions = []*domain.Transaction{}
+	}
+	return transactions, nil
+}
+
+// GetAccountTransactions retrieves transaction history for an account with
+// each transaction's direction, counterparty and running balance, looking up
+// the owners of every counterparty in a single query
+func (uc *TransactionUseCase) GetAccountTransactions(ctx context.Context, accountID string, filter *domain.TransactionFilter) ([]*domain.AccountTransaction, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUser(ctx, account.UserID); err != nil {
+		return nil, err
+	}
+
+	transactions, err := uc.transactionRepo.GetByAccountID(ctx, accountID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*domain.AccountTransaction, len(transactions))
+	var counterpartyIDs []string
+	seen := make(map[string]bool)
+	for i, transaction := range transactions {
+		views[i] = domain.NewAccountTransaction(transaction, account)
+		if id := views[i].CounterpartyAccountID; id != nil && !seen[*id] {
+			seen[*id] = true
+			counterpartyIDs = append(counterpartyIDs, *id)
+		}
+	}
+	if err := uc.setRunningBalances(ctx, account, views); err != nil {
+		return nil, err
+	}
+	if len(counterpartyIDs) == 0 {
+		return views, nil
+	}
+
+	counterparties, err := uc.accountRepo.GetByIDs(ctx, counterpartyIDs)
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string]string, len(counterparties))
+	for _, account := range counterparties {
+		owners[account.ID] = account.UserID
+	}
+	for _, view := range views {
+		if view.CounterpartyAccountID == nil {
+			continue
+		}
+		if owner, ok := owners[*view.CounterpartyAccountID]; ok {
+			view.CounterpartyUserID = &owner
+		}
+	}
+
+	return views, nil
+}
+
+// setRunningBalances gives each completed transaction the account's balance
+// after it. The walk back from the current balance covers every transaction
+// since the oldest of the page, whatever the filter, so that the balances are
+// right on any page.
+func (uc *TransactionUseCase) setRunningBalances(ctx context.Context, account *domain.Account, views []*domain.AccountTransaction) error {
+	if len(views) == 0 {
+		return nil
+	}
+
+	oldest := views[0].CreatedAt
+	for _, view := range views {
+		if view.CreatedAt.Before(oldest) {
+			oldest = view.CreatedAt
+		}
+	}
+
+	transactions, err := transactionsSince(ctx, uc.transactionRepo, account.ID, oldest)
+	if err != nil {
+		return err
+	}
+
+	balances := domain.RunningBalances(account.ID, account.Balance, transactions)
+	for _, view := range views {
+		if view.Status != domain.TransactionStatusCompleted {
+			continue
+		}
+		if balance, ok := balances[view.ID]; ok {
+			view.RunningBalance = &balance
+		}
+	}
+
+	return nil
+}
+
+// GetTransactionsByFilter retrieves transactions by filter, among the
+// caller's accounts' unless the caller may see every account
+func (uc *TransactionUseCase) GetTransactionsByFilter(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	if ok, err := uc.scopeFilter(ctx, filter); err != nil || !ok {
+		return []*domain.Transaction{}, err
+	}
+	return uc.transactionRepo.GetByFilter(ctx, filter)
+}
+
+// ListTransactions pages through the transactions the filter matches,
+// clamping the page size, and counts every match. A user only sees their own
+// accounts' transactions.
+func (uc *TransactionUseCase) ListTransactions(ctx context.Context, filter *domain.TransactionFilter) (*domain.TransactionPage, error) {
+	clampTransactionPage(filter)
+	if ok, err := uc.scopeFilter(ctx, filter); err != nil {
+		return nil, err
+	} else if !ok {
+		return &domain.TransactionPage{Transactions: []*domain.Transaction{}}, nil
+	}
+
+	transactions, err := uc.transactionRepo.GetByFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	total, err := uc.transactionRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TransactionPage{
+		Transactions: transactions,
+		Total:        total,
+		HasMore:      int64(filter.Offset+len(transactions)) < total,
+	}, nil
+}
+
+// clampTransactionPage applies the default page size of a listing and bounds
+// its limit and offset
+func clampTransactionPage(filter *domain.TransactionFilter) {
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Limit > domain.MaxTransactionPageSize {
+		filter.Limit = domain.MaxTransactionPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+}
+
+// SearchTransactions finds transactions by words of their description or
+// reference among those the filter matches, clamping the page size
+func (uc *TransactionUseCase) SearchTransactions(ctx context.Context, query string, filter *domain.TransactionFilter) ([]*domain.TransactionMatch, error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) < domain.MinSearchQueryLength {
+		return nil, domain.ErrSearchQueryTooShort
+	}
+
+	clampTransactionPage(filter)
+
+	return uc.transactionRepo.Search(ctx, query, filter)
+}
+
+// CancelTransaction cancels a pending, scheduled or unapproved transaction
+// for the owner of the account it debits
+func (uc *TransactionUseCase) CancelTransaction(ctx context.Context, id string) error {
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.authorizeOwner(ctx, transaction); err != nil {
+		return err
+	}
+
+	// Once the processor has picked a transaction up it can no longer be cancelled
+	switch transaction.Status {
+	case domain.TransactionStatusPending, domain.TransactionStatusScheduled, domain.TransactionStatusPendingApproval:
+	default:
+		return domain.ErrTransactionAlreadyProcessed
+	}
+
+	// The processor claims a transaction by the same conditional transition,
+	// so a cancellation racing it either wins or is refused
+	previous := transaction.Status
+	err = uc.transactionRepo.UpdateStatusIf(ctx, id, previous, domain.TransactionStatusCancelled, "Cancelled by user")
+	if err != nil {
+		return err
+	}
+
+	// Only a pending transaction has a message waiting in the queue
+	if previous == domain.TransactionStatusPending {
+		uc.publishTombstone(ctx, id)
+	}
+	return nil
+}
+
+// ApproveTransaction releases a transaction awaiting approval: it is queued,
+// or scheduled if its time has not yet come
+func (uc *TransactionUseCase) ApproveTransaction(ctx context.Context, id string) (*domain.Transaction, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Status != domain.TransactionStatusPendingApproval {
+		return nil, domain.ErrNotAwaitingApproval
+	}
+
+	next := domain.TransactionStatusPending
+	if transaction.ScheduledAt != nil && transaction.ScheduledAt.After(uc.now()) {
+		next = domain.TransactionStatusScheduled
+	}
+	// A rejection, cancellation or expiry racing the approval wins or loses
+	// by the same conditional transition
+	if err := uc.transactionRepo.UpdateStatusIf(ctx, id, domain.TransactionStatusPendingApproval, next, ""); err != nil {
+		if errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+			return nil, domain.ErrNotAwaitingApproval
+		}
+		return nil, err
+	}
+
+	if next == domain.TransactionStatusPending {
+		// Left without a message, the pending sweeper fails or requeues it
+		if err := uc.publish(ctx, storedRequest(transaction)); err != nil {
+			log.Printf("Failed to queue approved transaction %s: %v", id, err)
+		}
+	}
+
+	return uc.transactionRepo.GetByID(ctx, id)
+}
+
+// RequeueTransaction publishes a transaction stuck in pending, or failed
+// with a retryable code, to the transaction queue again, returning it to
+// pending first. Requeueing twice publishes twice, which the processor
+// tolerates: a delivery of a transaction it has picked up is skipped.
+func (uc *TransactionUseCase) RequeueTransaction(ctx context.Context, id string) (*domain.TransactionRequeue, error) {
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !transaction.Requeueable() {
+		return nil, domain.ErrTransactionNotRequeueable
+	}
+
+	actor := domain.Actor(ctx)
+	requeued, err := uc.transactionRepo.Requeue(ctx, id, transaction.Status, transaction.FailureCode, actor)
+	if err != nil {
+		if errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+			return nil, domain.ErrTransactionNotRequeueable
+		}
+		return nil, err
+	}
+
+	// A message that cannot be published stays in the outbox for the relay
+	if err := uc.publish(ctx, storedRequest(requeued)); err != nil {
+		return nil, err
+	}
+
+	return &domain.TransactionRequeue{
+		TransactionID: requeued.ID,
+		Status:        requeued.Status,
+		RequeueCount:  requeued.RequeueCount(),
+		RequeuedBy:    actor,
+		RequeuedAt:    *requeued.RequeuedAt,
+	}, nil
+}
+
+// RejectTransaction cancels a transaction awaiting approval, recording the
+// reason
+func (uc *TransactionUseCase) RejectTransaction(ctx context.Context, id, reason string) (*domain.Transaction, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, domain.ErrInvalidInput
+	}
+
+	transaction, err := uc.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transaction.Status != domain.TransactionStatusPendingApproval {
+		return nil, domain.ErrNotAwaitingApproval
+	}
+
+	err = uc.transactionRepo.UpdateStatusIf(ctx, id, domain.TransactionStatusPendingApproval, domain.TransactionStatusCancelled, "Rejected: "+strings.TrimSpace(reason))
+	if err != nil {
+		if errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+			return nil, domain.ErrNotAwaitingApproval
+		}
+		return nil, err
+	}
+
+	return uc.transactionRepo.GetByID(ctx, id)
+}
+
+// StartTransactionProcessor starts the transaction processor, which stops
+// taking transactions once ctx is done. Draining the returned subscription
+// waits for the transactions in hand to be processed, then for the
+// cancellation tombstones in hand.
+func (uc *TransactionUseCase) StartTransactionProcessor(ctx context.Context) (domain.Subscription, error) {
+	handler := func(ctx context.Context, data []byte) error {
+		// A batch arrives as one message carrying all of its legs
+		var batch domain.BatchRequest
+		if err := json.Unmarshal(data, &batch); err == nil && len(batch.Legs) > 0 {
+			log.Printf("Processing batch: %s", batch.ID)
+			return uc.processBatch(ctx, &batch)
+		}
+
+		var request domain.TransactionRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			log.Printf("Failed to unmarshal transaction request: %v", err)
+			return &domain.PermanentError{Err: err}
+		}
+
+		// Acknowledged without a read, so the message is not redelivered
+		if uc.skipCancelled(request.ID) {
+			return nil
+		}
+
+		log.Printf("Processing transaction: %s", request.ID)
+		return uc.processDelivery(ctx, &request)
+	}
+
+	tombstones, err := uc.subscribeTombstones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := uc.queue.Subscribe(ctx, uc.queueName, handler)
+	if err != nil {
+		return nil, err
+	}
+	return &processorSubscription{transactions: transactions, tombstones: tombstones}, nil
+}
+
+// FailUndelivered fails the transactions of a message the queue has dropped
+// for good, as it does with no dead letter queue to set it aside in, so that
+// they are not left processing. One that ran out of retries fails with the
+// retryable queue_error code, so ops can requeue it. A transaction that may
+// already have been posted is left for repair, as failing it would misreport
+// the balances, and a failed batch's completed legs are compensated.
+func (uc *TransactionUseCase) FailUndelivered(ctx context.Context, data []byte, cause error) {
+	var unrecorded *domain.UnrecordedStatusError
+	if errors.As(cause, &unrecorded) {
+		log.Printf("Leaving dropped transaction %s for repair: %v", unrecorded.TransactionID, cause)
+		return
+	}
+	code := domain.FailureCodeQueueError
+	if domain.IsPermanent(cause) {
+		code = domain.FailureCodeFor(cause)
+	}
+
+	var batch domain.BatchRequest
+	if err := json.Unmarshal(data, &batch); err == nil && len(batch.Legs) > 0 {
+		uc.failUndeliveredBatch(ctx, &batch, code, cause)
+		return
+	}
+	var request domain.TransactionRequest
+	if err := json.Unmarshal(data, &request); err != nil || request.ID == "" {
+		return
+	}
+
+	transaction, err := uc.transactionRepo.GetByID(ctx, request.ID)
+	if err != nil {
+		log.Printf("Failed to load dropped transaction %s: %v", request.ID, err)
+		return
+	}
+	switch transaction.Status {
+	case domain.TransactionStatusPending, domain.TransactionStatusProcessing:
+	default:
+		return
+	}
+	if applied, err := uc.isApplied(ctx, request.ID); err != nil || applied {
+		log.Printf("Leaving dropped transaction %s for repair, as it may have been applied: %v", request.ID, err)
+		return
+	}
+
+	log.Printf("Failing dropped transaction %s: %v", request.ID, cause)
+	if err := uc.transactionRepo.MarkFailed(ctx, request.ID, code, cause.Error()); err != nil {
+		log.Printf("Failed to mark dropped transaction %s failed: %v", request.ID, err)
+		return
+	}
+	uc.notifyFailed(ctx, &request, cause)
+}
+
+// processDelivery processes one delivery of a queued transaction. A delivery
+// of a transaction that already has an outcome, such as one redelivered after
+// its ack was lost, is acknowledged without processing. Permanent failures
+// fail the transaction and are final; transient failures are recorded on the
+// still-processing transaction and returned so the queue retries the message.
+// A message with no transaction record, such as one replayed by tooling, is
+// recorded before anything is applied so its outcome can be saved.
+func (uc *TransactionUseCase) processDelivery(ctx context.Context, request *domain.TransactionRequest) error {
+	const maxRetries = 3
+
+	current, err := uc.transactionRepo.GetByID(ctx, request.ID)
+	if errors.Is(err, domain.ErrTransactionNotFound) {
+		current, err = uc.recordDelivery(ctx, request)
+	}
+	if err != nil {
+		return err
+	}
+	switch current.Status {
+	case domain.TransactionStatusCompleted, domain.TransactionStatusFailed, domain.TransactionStatusCancelled:
+		// Includes transactions cancelled or expired while queued
+		log.Printf("Skipping %s transaction: %s", current.Status, request.ID)
+		return nil
+	case domain.TransactionStatusProcessing:
+		// An earlier delivery was interrupted; if it got as far as
+		// posting, only its outcome is left to record
+		applied, err := uc.isApplied(ctx, request.ID)
+		if err != nil {
+			return err
+		}
+		if applied {
+			return uc.completeApplied(ctx, request)
+		}
+	default:
+		// Only one consumer moves a transaction to processing
+		if err := uc.transactionRepo.ClaimProcessing(ctx, request.ID); err != nil {
+			if errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+				log.Printf("Skipping transaction claimed by another delivery: %s", request.ID)
+				return nil
+			}
+			return err
+		}
+	}
+	for attempt := 1; ; attempt++ {
+		err = uc.ProcessTransactionSync(ctx, request)
+		if !errors.Is(err, domain.ErrConcurrentUpdate) || attempt == maxRetries {
+			break
+		}
+	}
+	if err == nil {
+		log.Printf("Successfully processed transaction: %s", request.ID)
+		uc.recordProcessed()
+		return nil
+	}
+
+	// A concurrent delivery may have posted the transaction first
+	if applied, appliedErr := uc.isApplied(ctx, request.ID); appliedErr == nil && applied {
+		return uc.completeApplied(ctx, request)
+	}
+
+	// Posted without a ledger to tell a redelivery so; retrying would post
+	// it again, and failing it would misreport the balances
+	var unrecorded *domain.UnrecordedStatusError
+	if errors.As(err, &unrecorded) {
+		return &domain.PermanentError{Err: err}
+	}
+
+	// Compensations return money already taken, so they never fail
+	if request.Type == domain.TransactionTypeCompensation {
+		return uc.retryCompensation(ctx, request, err)
+	}
+
+	if !domain.IsPermanent(err) {
+		log.Printf("Transient failure processing transaction %s, will retry: %v", request.ID, err)
+		if updateErr := uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusProcessing, "retrying after transient error: "+err.Error()); updateErr != nil {
+			log.Printf("Failed to record transient failure on transaction %s: %v", request.ID, updateErr)
+		}
+		return err
+	}
+
+	log.Printf("Failed to process transaction %s: %v", request.ID, err)
+	if markErr := uc.transactionRepo.MarkFailed(ctx, request.ID, domain.FailureCodeFor(err), err.Error()); markErr != nil {
+		log.Printf("Failed to mark transaction %s failed: %v", request.ID, markErr)
+		return err
+	}
+	uc.recordProcessed()
+	uc.notifyFailed(ctx, request, err)
+
+	// A failed reversal, such as when the beneficiary has already spent the
+	// funds, releases the original so it can be reversed again
+	if request.Type == domain.TransactionTypeReversal {
+		if releaseErr := uc.transactionRepo.ResolveReversal(ctx, request.ReversedTransactionID, request.ID, false); releaseErr != nil {
+			log.Printf("Failed to release reversal claim on transaction %s: %v", request.ReversedTransactionID, releaseErr)
+		}
+	}
+	return nil
+}
+
+// notifyCompleted sends the completion event for a transaction and tracks
+// whether it took its accounts across their low balance thresholds.
+// Notifications are best effort: failures are logged and the transaction
+// stands.
+func (uc *TransactionUseCase) notifyCompleted(ctx context.Context, request *domain.TransactionRequest) {
+	if uc.notifier == nil {
+		return
+	}
+
+	transaction, err := uc.transactionRepo.GetByID(ctx, request.ID)
+	if err != nil {
+		log.Printf("Failed to load transaction %s for notification: %v", request.ID, err)
+		return
+	}
+	if err := uc.notifier.NotifyTransactionCompleted(ctx, transaction); err != nil {
+		log.Printf("Failed to notify completion of transaction %s: %v", request.ID, err)
+	}
+
+	debit := request.Type == domain.TransactionTypeWithdrawal || request.Type == domain.TransactionTypeTransfer
+	if request.FromAccountID != nil {
+		uc.trackLowBalance(ctx, *request.FromAccountID, debit)
+	}
+	if request.ToAccountID != nil {
+		uc.trackLowBalance(ctx, *request.ToAccountID, false)
+	}
+}
+
+// trackLowBalance compares an account's balance with its low balance
+// threshold, or the default one if it has none. A withdrawal or transfer
+// debit that leaves it below sends a notification, once, until the balance
+// recovers and clears the account's flag.
+func (uc *TransactionUseCase) trackLowBalance(ctx context.Context, accountID string, debit bool) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		log.Printf("Failed to load account %s for notification: %v", accountID, err)
+		return
+	}
+
+	threshold, ok := uc.lowBalanceThreshold(account)
+	below := ok && account.Balance < threshold
+	switch {
+	case below && debit && !account.BelowThreshold:
+		changed, err := uc.accountRepo.SetBelowThreshold(ctx, account.ID, true)
+		if err != nil {
+			log.Printf("Failed to flag account %s below its threshold: %v", account.ID, err)
+			return
+		}
+		// A concurrent debit may have crossed first and notified
+		if !changed {
+			return
+		}
+		account.BelowThreshold = true
+		if err := uc.notifier.NotifyLowBalance(ctx, account); err != nil {
+			log.Printf("Failed to notify low balance on account %s: %v", account.ID, err)
+		}
+	case !below && account.BelowThreshold:
+		if _, err := uc.accountRepo.SetBelowThreshold(ctx, account.ID, false); err != nil {
+			log.Printf("Failed to clear account %s's threshold flag: %v", account.ID, err)
+		}
+	}
+}
+
+// lowBalanceThreshold returns an account's low balance threshold, falling
+// back to the default, and false if neither applies
+func (uc *TransactionUseCase) lowBalanceThreshold(account *domain.Account) (domain.Money, bool) {
+	if account.LowBalanceThreshold != nil {
+		return *account.LowBalanceThreshold, true
+	}
+	if uc.lowBalance == "" {
+		return 0, false
+	}
+	threshold, err := uc.lowBalance.Money(account.Currency)
+	if err != nil {
+		log.Printf("Low balance threshold %s does not apply to %s: %v", uc.lowBalance, account.Currency, err)
+		return 0, false
+	}
+	return threshold, true
+}
+
+// notifyFailed sends the failure event for a transaction, logging any
+// failure to send it
+func (uc *TransactionUseCase) notifyFailed(ctx context.Context, request *domain.TransactionRequest, cause error) {
+	if uc.notifier == nil {
+		return
+	}
+
+	transaction, err := uc.transactionRepo.GetByID(ctx, request.ID)
+	if err != nil {
+		log.Printf("Failed to load transaction %s for notification: %v", request.ID, err)
+		return
+	}
+	if err := uc.notifier.NotifyTransactionFailed(ctx, transaction, cause); err != nil {
+		log.Printf("Failed to notify failure of transaction %s: %v", request.ID, err)
+	}
+}
+
+// recordDelivery saves the transaction record for a delivered request that
+// has none, so the processor has a record to claim and complete
+func (uc *TransactionUseCase) recordDelivery(ctx context.Context, request *domain.TransactionRequest) (*domain.Transaction, error) {
+	if request.ID == "" {
+		return nil, &domain.PermanentError{Err: errors.New("transaction request has no ID")}
+	}
+
+	log.Printf("No record of delivered transaction %s, creating it from the message", request.ID)
+	transaction := newTransaction(request)
+	if err := uc.transactionRepo.Create(ctx, transaction); err != nil {
+		// A concurrent delivery may have created it first
+		if current, getErr := uc.transactionRepo.GetByID(ctx, request.ID); getErr == nil {
+			return current, nil
+		}
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+	return transaction, nil
+}
+
+// recordCompleted marks a transaction completed once its balance changes are
+// posted, recording them for audit. A failure is logged for manual repair and
+// returned as an UnrecordedStatusError, since the balances no longer match the
+// record.
+func (uc *TransactionUseCase) recordCompleted(ctx context.Context, id string, changes []*domain.BalanceChange) error {
+	if err := uc.transactionRepo.MarkCompleted(ctx, id, changes); err != nil {
+		log.Printf("MANUAL REPAIR NEEDED: transaction %s was applied but could not be marked completed: %v", id, err)
+		return &domain.UnrecordedStatusError{TransactionID: id, Err: err}
+	}
+	return nil
+}
+
+// isApplied reports whether a transaction's balance changes have been posted.
+// Without ledger entries nothing records this, so it reports false.
+func (uc *TransactionUseCase) isApplied(ctx context.Context, id string) (bool, error) {
+	if uc.ledgerRepo == nil {
+		return false, nil
+	}
+	return uc.ledgerRepo.IsApplied(ctx, id)
+}
+
+// completeApplied records the outcome of a transaction whose balance changes
+// were posted by an earlier or concurrent delivery
+func (uc *TransactionUseCase) completeApplied(ctx context.Context, request *domain.TransactionRequest) error {
+	log.Printf("Transaction %s was already applied", request.ID)
+	if request.Type == domain.TransactionTypeCompensation {
+		return uc.completeCompensation(ctx, request)
+	}
+	if request.Type == domain.TransactionTypeReversal {
+		if err := uc.transactionRepo.ResolveReversal(ctx, request.ReversedTransactionID, request.ID, true); err != nil {
+			return err
+		}
+	}
+	return uc.transactionRepo.UpdateStatus(ctx, request.ID, domain.TransactionStatusCompleted, "")
 }
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+
+	"banking-ledger/internal/domain"
+)
+
+// UserVerificationUseCase implements the UserVerificationService interface
+type UserVerificationUseCase struct {
+	repo domain.UserVerificationRepository
+}
+
+// NewUserVerificationUseCase creates a new user verification use case
+func NewUserVerificationUseCase(repo domain.UserVerificationRepository) domain.UserVerificationService {
+	return &UserVerificationUseCase{repo: repo}
+}
+
+// GetVerification retrieves a user's verification level
+func (uc *UserVerificationUseCase) GetVerification(ctx context.Context, userID string) (*domain.UserVerification, error) {
+	return uc.repo.Get(ctx, userID)
+}
+
+// SetVerification moves a user to a verification level
+func (uc *UserVerificationUseCase) SetVerification(ctx context.Context, userID string, level domain.VerificationLevel) (*domain.UserVerification, error) {
+	if !level.IsValid() {
+		return nil, domain.ErrInvalidVerificationLevel
+	}
+
+	verification := &domain.UserVerification{UserID: userID, Level: level}
+	if err := uc.repo.Set(ctx, verification); err != nil {
+		return nil, err
+	}
+
+	return verification, nil
+}
+
+// checkVerification fails with a VerificationRequiredError if the gate set by
+// WithVerificationGate refuses a request. Unverified users may not withdraw or
+// transfer, and may only deposit until their completed deposits in the
+// currency reach the limit. System requests, internal accounts and the types
+// that undo or verify earlier transactions are not gated.
+func (uc *TransactionUseCase) checkVerification(ctx context.Context, request *domain.TransactionRequest) error {
+	if uc.verifications == nil || request.System {
+		return nil
+	}
+
+	switch request.Type {
+	case domain.TransactionTypeDeposit:
+		return uc.checkUnverifiedDeposit(ctx, request)
+	case domain.TransactionTypeWithdrawal, domain.TransactionTypeTransfer:
+		account, verified, err := uc.accountVerified(ctx, *request.FromAccountID)
+		if err != nil || verified || account.Type.IsInternal() {
+			return err
+		}
+		return &domain.VerificationRequiredError{TransactionType: request.Type}
+	default:
+		return nil
+	}
+}
+
+// checkUnverifiedDeposit refuses a deposit that would take an unverified
+// user's completed deposits, into any of their accounts in its currency, over
+// the limit
+func (uc *TransactionUseCase) checkUnverifiedDeposit(ctx context.Context, request *domain.TransactionRequest) error {
+	account, verified, err := uc.accountVerified(ctx, *request.ToAccountID)
+	if err != nil || verified || account.Type.IsInternal() {
+		return err
+	}
+
+	// A limit that is empty or malformed allows no deposits at all
+	limit, _ := uc.unverifiedDepositLimit.Bound(domain.CurrencyExponent(request.Currency), false)
+
+	accounts, err := uc.accountRepo.GetByUserID(ctx, account.UserID)
+	if err != nil {
+		return err
+	}
+	accountIDs := make([]string, 0, len(accounts))
+	for _, owned := range accounts {
+		if owned.Currency == request.Currency {
+			accountIDs = append(accountIDs, owned.ID)
+		}
+	}
+	if len(accountIDs) == 0 {
+		accountIDs = append(accountIDs, account.ID)
+	}
+
+	deposited, err := uc.transactionRepo.SumDeposits(ctx, accountIDs)
+	if err != nil {
+		return err
+	}
+	if deposited+request.Amount <= limit {
+		return nil
+	}
+
+	return &domain.VerificationRequiredError{
+		TransactionType: request.Type,
+		DepositLimit:    limit,
+		Deposited:       deposited,
+		Currency:        request.Currency,
+	}
+}
+
+// accountVerified loads an account and reports whether its owner is verified
+func (uc *TransactionUseCase) accountVerified(ctx context.Context, accountID string) (*domain.Account, bool, error) {
+	account, err := uc.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	verification, err := uc.verifications.Get(ctx, account.UserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return account, verification.Verified(), nil
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+
+	"banking-ledger/internal/domain"
+)
+
+// transactionPlan is what posting a deposit, withdrawal or transfer would do,
+// as worked out by the checks it passed. Processing posts it; a dry run only
+// reports it.
+type transactionPlan struct {
+	from *domain.Account
+	to   *domain.Account
+	// credit is the amount the destination is credited with, converted if
+	// it is in another currency
+	credit   domain.Money
+	exchange *domain.Exchange
+	fee      *feeCharge
+}
+
+// planDeposit checks a deposit against its account
+func (uc *TransactionUseCase) planDeposit(ctx context.Context, request *domain.TransactionRequest) (*transactionPlan, error) {
+	account, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Frozen accounts still accept credits
+	if err := account.Status.CanCredit(); err != nil {
+		return nil, err
+	}
+	if account.Currency != request.Currency {
+		return nil, domain.ErrCurrencyMismatch
+	}
+
+	return &transactionPlan{to: account, credit: request.Amount}, nil
+}
+
+// planWithdrawal checks a withdrawal against its account and works out its
+// fee
+func (uc *TransactionUseCase) planWithdrawal(ctx context.Context, request *domain.TransactionRequest) (*transactionPlan, error) {
+	account, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkDebit(ctx, request, account); err != nil {
+		return nil, err
+	}
+
+	fee, err := uc.prepareFee(ctx, request, account)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transactionPlan{from: account, fee: fee}, nil
+}
+
+// planTransfer checks a transfer against both its accounts and works out its
+// fee and the amount it credits
+func (uc *TransactionUseCase) planTransfer(ctx context.Context, request *domain.TransactionRequest, from, to *domain.Account) (*transactionPlan, error) {
+	if err := to.Status.CanCredit(); err != nil {
+		return nil, err
+	}
+
+	// Fee collection accounts are only credited by the fee engine, though
+	// reversals and refunds may still return money to them
+	if request.Type == domain.TransactionTypeTransfer && to.Type == domain.AccountTypeFeeCollection {
+		return nil, domain.ErrAccountRestricted
+	}
+
+	if err := uc.checkDebit(ctx, request, from); err != nil {
+		return nil, err
+	}
+
+	// Checked against the accounts as processed, which the fee depends on
+	if request.Type == domain.TransactionTypeTransfer {
+		request.SelfTransfer = domain.IsSelfTransfer(from, to)
+	}
+
+	fee, err := uc.prepareFee(ctx, request, from)
+	if err != nil {
+		return nil, err
+	}
+
+	// Quoted last, so that a rate is only recorded for a transfer that
+	// passed every other check
+	credit, exchange, err := uc.quoteCredit(ctx, request, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transactionPlan{from: from, to: to, credit: credit, exchange: exchange, fee: fee}, nil
+}
+
+// checkDebit checks that an account may be debited by a request: that it is
+// open for debits in the request's currency, can cover the amount, allowing
+// for any overdraft, and stays within its limits
+func (uc *TransactionUseCase) checkDebit(ctx context.Context, request *domain.TransactionRequest, account *domain.Account) error {
+	if err := account.Status.CanDebit(); err != nil {
+		return err
+	}
+	if account.Currency != request.Currency {
+		return domain.ErrCurrencyMismatch
+	}
+	if err := account.CheckFunds(request.Amount); err != nil {
+		return err
+	}
+	if err := uc.checkWithdrawalLimit(ctx, request, account); err != nil {
+		return err
+	}
+	return uc.checkAccountLimits(ctx, request, account)
+}
+
+// ValidateTransaction runs the checks a deposit, withdrawal or transfer would
+// face if it were submitted and processed now, without saving or queueing
+// anything. A transaction the checks refuse is reported as a validation that
+// would not succeed; malformed and unauthorized requests, and checks that
+// could not be run, are returned as errors. Duplicate and idempotency checks
+// are skipped since nothing is submitted.
+func (uc *TransactionUseCase) ValidateTransaction(ctx context.Context, request *domain.TransactionRequest) (*domain.TransactionValidation, error) {
+	if err := request.IsValid(); err != nil {
+		return nil, err
+	}
+	if err := uc.checkExternalParty(request); err != nil {
+		return nil, err
+	}
+	switch request.Type {
+	case domain.TransactionTypeDeposit, domain.TransactionTypeWithdrawal, domain.TransactionTypeTransfer:
+	default:
+		return nil, domain.ErrInvalidTransactionType
+	}
+	if err := uc.authorizeDebit(ctx, request); err != nil {
+		return nil, err
+	}
+
+	validation := &domain.TransactionValidation{Currency: request.Currency}
+	plan, err := uc.planDryRun(ctx, request)
+	if err != nil {
+		if !domain.IsPermanent(err) {
+			return nil, err
+		}
+		validation.FailureCode = domain.FailureCodeFor(err)
+		validation.FailureReason = err.Error()
+		return validation, nil
+	}
+
+	validation.WouldSucceed = true
+	fee := domain.Money(0)
+	if plan.fee != nil && plan.fee.failure == nil {
+		fee = plan.fee.transaction.Amount
+		validation.Fee = fee
+	}
+	if plan.from != nil {
+		validation.ResultingBalances = append(validation.ResultingBalances, &domain.BalanceEstimate{
+			AccountID: plan.from.ID,
+			Balance:   plan.from.Balance - request.Amount - fee,
+			Currency:  plan.from.Currency,
+		})
+	}
+	if plan.to != nil {
+		validation.ResultingBalances = append(validation.ResultingBalances, &domain.BalanceEstimate{
+			AccountID: plan.to.ID,
+			Balance:   plan.to.Balance + plan.credit,
+			Currency:  plan.to.Currency,
+		})
+	}
+
+	return validation, nil
+}
+
+// planDryRun runs the submission checks of a request and then plans it as it
+// would be processed
+func (uc *TransactionUseCase) planDryRun(ctx context.Context, request *domain.TransactionRequest) (*transactionPlan, error) {
+	if err := uc.checkVerification(ctx, request); err != nil {
+		return nil, err
+	}
+	if err := uc.checkQuota(ctx, request); err != nil {
+		return nil, err
+	}
+
+	switch request.Type {
+	case domain.TransactionTypeDeposit:
+		return uc.planDeposit(ctx, request)
+	case domain.TransactionTypeWithdrawal:
+		return uc.planWithdrawal(ctx, request)
+	default:
+		from, err := uc.accountRepo.GetByID(ctx, *request.FromAccountID)
+		if err != nil {
+			return nil, err
+		}
+		to, err := uc.accountRepo.GetByID(ctx, *request.ToAccountID)
+		if err != nil {
+			return nil, err
+		}
+		return uc.planTransfer(ctx, request, from, to)
+	}
+}
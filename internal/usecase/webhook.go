@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"banking-ledger/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultWebhookMaxAttempts is how many times a delivery is attempted
+	defaultWebhookMaxAttempts = 8
+	// defaultWebhookRetryDelay is the wait before the first retry, doubling
+	// on each later one
+	defaultWebhookRetryDelay = 30 * time.Second
+	// defaultWebhookTimeout bounds each delivery attempt
+	defaultWebhookTimeout = 10 * time.Second
+	// webhookRetryBatch is how many due deliveries one retry run attempts
+	webhookRetryBatch = 100
+	// webhookClaimLease is how long a claimed delivery is hidden from other
+	// workers while it is attempted
+	webhookClaimLease = 5 * time.Minute
+	// defaultDeliveryPageSize and maxDeliveryPageSize bound the delivery log
+	defaultDeliveryPageSize = 50
+	maxDeliveryPageSize     = 200
+)
+
+// WebhookUseCase implements the WebhookService interface. Each event is
+// recorded as a delivery per subscription and posted straight away; failed
+// deliveries are retried with exponential backoff until they succeed or run
+// out of attempts.
+type WebhookUseCase struct {
+	repo        domain.WebhookRepository
+	client      *http.Client
+	maxAttempts int
+	retryDelay  time.Duration
+	now         func() time.Time
+}
+
+// WebhookOption configures optional behaviour of the webhook use case
+type WebhookOption func(*WebhookUseCase)
+
+// WithWebhookHTTPClient sets the client deliveries are posted with
+func WithWebhookHTTPClient(client *http.Client) WebhookOption {
+	return func(uc *WebhookUseCase) {
+		uc.client = client
+	}
+}
+
+// WithWebhookRetryPolicy sets how many times a delivery is attempted and the
+// wait before the first retry, which doubles on each later one
+func WithWebhookRetryPolicy(maxAttempts int, retryDelay time.Duration) WebhookOption {
+	return func(uc *WebhookUseCase) {
+		if maxAttempts > 0 {
+			uc.maxAttempts = maxAttempts
+		}
+		if retryDelay > 0 {
+			uc.retryDelay = retryDelay
+		}
+	}
+}
+
+// WithWebhookClock sets the clock scheduling retries
+func WithWebhookClock(now func() time.Time) WebhookOption {
+	return func(uc *WebhookUseCase) {
+		uc.now = now
+	}
+}
+
+// NewWebhookUseCase creates a new webhook use case
+func NewWebhookUseCase(repo domain.WebhookRepository, opts ...WebhookOption) domain.WebhookService {
+	uc := &WebhookUseCase{
+		repo:        repo,
+		client:      &http.Client{Timeout: defaultWebhookTimeout},
+		maxAttempts: defaultWebhookMaxAttempts,
+		retryDelay:  defaultWebhookRetryDelay,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(uc)
+	}
+
+	return uc
+}
+
+// RegisterWebhook subscribes a URL to event types with a new signing secret
+func (uc *WebhookUseCase) RegisterWebhook(ctx context.Context, url string, eventTypes []domain.NotificationType) (*domain.WebhookSubscription, error) {
+	subscription := &domain.WebhookSubscription{
+		ID:         uuid.New().String(),
+		URL:        url,
+		EventTypes: eventTypes,
+		Active:     true,
+	}
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	subscription.Secret = "whsec_" + hex.EncodeToString(secret)
+
+	if err := uc.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// GetWebhook retrieves a webhook subscription
+func (uc *WebhookUseCase) GetWebhook(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	return uc.repo.GetSubscription(ctx, id)
+}
+
+// DeleteWebhook stops a subscription receiving events. Its pending
+// deliveries fail at their next attempt.
+func (uc *WebhookUseCase) DeleteWebhook(ctx context.Context, id string) error {
+	return uc.repo.DeactivateSubscription(ctx, id)
+}
+
+// GetDeliveries lists a subscription's latest deliveries, newest first
+func (uc *WebhookUseCase) GetDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*domain.WebhookDelivery, error) {
+	if _, err := uc.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultDeliveryPageSize
+	}
+	if limit > maxDeliveryPageSize {
+		limit = maxDeliveryPageSize
+	}
+
+	return uc.repo.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+// HandleNotification records a delivery of a notification for each active
+// subscription to its type and attempts it. An event redelivered by the
+// queue is not delivered again to subscriptions that already have it.
+func (uc *WebhookUseCase) HandleNotification(ctx context.Context, message []byte) error {
+	var event domain.NotificationEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		return &domain.PermanentError{Err: fmt.Errorf("invalid notification: %w", err)}
+	}
+	if event.EventID == "" || event.Type == "" {
+		return &domain.PermanentError{Err: fmt.Errorf("notification has no event ID or type")}
+	}
+
+	subscriptions, err := uc.repo.ListSubscriptionsFor(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		now := uc.now()
+		delivery := &domain.WebhookDelivery{
+			ID:             uuid.New().String(),
+			SubscriptionID: subscription.ID,
+			EventID:        event.EventID,
+			EventType:      event.Type,
+			Payload:        message,
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+		}
+		created, err := uc.repo.CreateDelivery(ctx, delivery)
+		if err != nil {
+			return err
+		}
+		if !created {
+			continue
+		}
+
+		uc.attempt(ctx, subscription, delivery)
+	}
+
+	return nil
+}
+
+// RetryDue attempts the pending deliveries whose next attempt is due. Those
+// of deleted subscriptions are failed instead.
+func (uc *WebhookUseCase) RetryDue(ctx context.Context) (int, error) {
+	now := uc.now()
+	deliveries, err := uc.repo.ClaimDueDeliveries(ctx, now, now.Add(webhookClaimLease), webhookRetryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := make(map[string]*domain.WebhookSubscription)
+	attempted := 0
+	for _, delivery := range deliveries {
+		subscription, cached := subscriptions[delivery.SubscriptionID]
+		if !cached {
+			subscription, err = uc.repo.GetSubscription(ctx, delivery.SubscriptionID)
+			if err != nil && !errors.Is(err, domain.ErrWebhookNotFound) {
+				return attempted, err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		if subscription == nil || !subscription.Active {
+			delivery.Status = domain.WebhookDeliveryFailed
+			delivery.LastError = "webhook subscription deleted"
+			delivery.NextAttemptAt = nil
+			uc.saveDelivery(ctx, delivery)
+			continue
+		}
+
+		uc.attempt(ctx, subscription, delivery)
+		attempted++
+	}
+
+	return attempted, nil
+}
+
+// attempt posts a delivery's payload to its subscription, signed with the
+// subscription's secret, and records the outcome. A 2xx response delivers
+// it; anything else schedules a retry, or fails it once it is out of
+// attempts.
+func (uc *WebhookUseCase) attempt(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.LastStatusCode = nil
+	delivery.LastError = ""
+
+	statusCode, err := uc.post(ctx, subscription, delivery)
+	now := uc.now()
+	switch {
+	case err != nil:
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastStatusCode = &statusCode
+		if statusCode < 200 || statusCode > 299 {
+			delivery.LastError = fmt.Sprintf("endpoint responded %d", statusCode)
+		}
+	}
+
+	switch {
+	case delivery.LastError == "":
+		delivery.Status = domain.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= uc.maxAttempts:
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(uc.retryDelay << (delivery.Attempts - 1))
+		delivery.Status = domain.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+	}
+
+	uc.saveDelivery(ctx, delivery)
+}
+
+// post sends a delivery and returns the response status
+func (uc *WebhookUseCase) post(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "banking-ledger-webhooks")
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set(domain.WebhookSignatureHeader, domain.SignWebhook(subscription.Secret, delivery.Payload))
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
+
+// saveDelivery records a delivery's outcome. A delivery that cannot be saved
+// is left pending, so it may be attempted again.
+func (uc *WebhookUseCase) saveDelivery(ctx context.Context, delivery *domain.WebhookDelivery) {
+	if err := uc.repo.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+}
@@ -0,0 +1,493 @@
+// Package worker runs the transaction processor: the queue consumers and the
+// periodic jobs behind queued work. The processor binary runs it, as does the
+// API on the in-memory queue, which nothing outside its process can consume.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"banking-ledger/internal/config"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/exchange"
+	"banking-ledger/internal/queue"
+	"banking-ledger/internal/repository"
+	"banking-ledger/internal/storage"
+	"banking-ledger/internal/usecase"
+	"banking-ledger/pkg/database"
+
+	"github.com/jmoiron/sqlx"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Processor processes queued transactions, export jobs and notifications,
+// and runs the sweeps, relays and schedules they depend on
+type Processor struct {
+	cfg                     *config.Config
+	messageQueue            domain.MessageQueue
+	transactionService      *usecase.TransactionUseCase
+	verificationService     domain.AccountVerificationService
+	holdService             domain.HoldService
+	standingOrderService    domain.StandingOrderService
+	snapshotService         domain.BalanceSnapshotService
+	monthlyStatementService domain.MonthlyStatementService
+	webhookService          domain.WebhookService
+	exportService           domain.ExportJobService
+
+	transactionSubscription domain.Subscription
+	workerSubscriptions     []domain.Subscription
+}
+
+// New builds the processor's services over the databases and message queue
+func New(cfg *config.Config, postgresDB *sqlx.DB, mongoDB *mongo.Database, messageQueue domain.MessageQueue) (*Processor, error) {
+	if err := database.CreateMonthlyStatementIndexes(mongoDB, cfg.MongoDB.StatementCollection); err != nil {
+		return nil, fmt.Errorf("failed to create monthly statement indexes: %w", err)
+	}
+
+	// Initialize repositories
+	accountRepo := repository.NewPostgreSQLAccountRepository(postgresDB)
+	transactionRepo := repository.NewMongoTransactionRepository(mongoDB, cfg.MongoDB.Collection, repository.WithArchive(cfg.Archive.After))
+	microDepositRepo := repository.NewPostgreSQLMicroDepositRepository(postgresDB)
+	ledgerRepo := repository.NewPostgreSQLLedgerEntryRepository(postgresDB)
+	holdRepo := repository.NewPostgreSQLHoldRepository(postgresDB)
+	standingOrderRepo := repository.NewPostgreSQLStandingOrderRepository(postgresDB)
+	userVerificationRepo := repository.NewPostgreSQLUserVerificationRepository(postgresDB)
+	queueStats := repository.NewMongoQueueStatsStore(mongoDB, cfg.MongoDB.QueueStatsCollection)
+
+	// Load the fees charged on withdrawals and transfers
+	feePolicy, err := usecase.LoadFeePolicy(cfg.Fee.Rules, cfg.Fee.CollectionAccountID, cfg.Fee.FailureMode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fee configuration: %w", err)
+	}
+	feePolicy.ExemptSelfTransfers(cfg.Fee.ExemptSelfTransfers)
+
+	limitLocation, err := time.LoadLocation(cfg.Transaction.LimitsTimezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid limits timezone: %w", err)
+	}
+
+	transactionOptions := []usecase.TransactionOption{
+		usecase.WithLedgerEntries(ledgerRepo),
+		usecase.WithFeePolicy(feePolicy),
+		usecase.WithPendingExpiry(cfg.Transaction.PendingTTL, cfg.Transaction.RequeueStale),
+		usecase.WithSavingsWithdrawalLimit(cfg.Transaction.SavingsWithdrawalLimit),
+		usecase.WithOutboxGrace(cfg.Transaction.OutboxGrace),
+		usecase.WithLimitTimezone(limitLocation),
+		usecase.WithApproval(domain.Decimal(cfg.Transaction.ApprovalThreshold), cfg.Transaction.ApprovalTTL),
+		usecase.WithCancellationTombstones(cfg.RabbitMQ.CancellationQueue, 0),
+		usecase.WithArchival(cfg.Archive.After, cfg.Archive.BatchSize),
+		usecase.WithQueueStats(queueStats),
+	}
+
+	// Load the exchange rates for cross-currency transfers
+	if cfg.Exchange.Enabled {
+		rates, err := exchange.LoadStaticRates(cfg.Exchange.Rates, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("invalid exchange rate configuration: %w", err)
+		}
+		transactionOptions = append(transactionOptions, usecase.WithExchangeRates(rates, cfg.Exchange.MaxRateAge))
+	}
+
+	// Hold unverified users to capped deposits
+	if cfg.Transaction.RequireVerification {
+		depositLimit := domain.Decimal(cfg.Transaction.UnverifiedDepositLimit)
+		if _, ok := depositLimit.Bound(0, false); !ok {
+			return nil, fmt.Errorf("invalid unverified deposit limit: %q", depositLimit)
+		}
+		transactionOptions = append(transactionOptions, usecase.WithVerificationGate(userVerificationRepo, depositLimit))
+	}
+
+	statementOptions := []usecase.MonthlyStatementOption{
+		usecase.WithStatementDay(cfg.Statement.MonthlyDay),
+	}
+
+	// Publish transaction, balance and statement events to the notification
+	// queue
+	if cfg.Notification.Enabled {
+		notifier := usecase.NewNotificationUseCase(messageQueue, cfg.RabbitMQ.NotificationQueue)
+		transactionOptions = append(transactionOptions, usecase.WithNotifications(notifier, domain.Decimal(cfg.Notification.LowBalanceThreshold)))
+		statementOptions = append(statementOptions, usecase.WithStatementNotifications(notifier))
+	}
+
+	// Initialize transaction service
+	transactionService := usecase.NewTransactionUseCase(
+		accountRepo,
+		transactionRepo,
+		messageQueue,
+		cfg.RabbitMQ.TransactionQueue,
+		transactionOptions...,
+	)
+
+	// Initialize account verification service
+	verificationService := usecase.NewAccountVerificationUseCase(
+		accountRepo,
+		microDepositRepo,
+		transactionService,
+		cfg.MicroDeposit.TTL,
+		cfg.MicroDeposit.MaxAttempts,
+	)
+
+	// Initialize export job service
+	exportStorage, err := storage.NewLocalExportStorage(cfg.Export.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export storage: %w", err)
+	}
+
+	return &Processor{
+		cfg:                 cfg,
+		messageQueue:        messageQueue,
+		transactionService:  transactionService.(*usecase.TransactionUseCase),
+		verificationService: verificationService,
+		holdService:         usecase.NewHoldUseCase(accountRepo, holdRepo, transactionRepo, cfg.Hold.TTL),
+		standingOrderService: usecase.NewStandingOrderUseCase(
+			standingOrderRepo,
+			accountRepo,
+			transactionService,
+		),
+		snapshotService: usecase.NewBalanceSnapshotUseCase(
+			accountRepo,
+			ledgerRepo,
+			repository.NewPostgreSQLBalanceSnapshotRepository(postgresDB),
+			usecase.WithSnapshotCatchUpDays(cfg.Snapshot.CatchUpDays),
+		),
+		monthlyStatementService: usecase.NewMonthlyStatementUseCase(
+			accountRepo,
+			transactionRepo,
+			repository.NewMongoMonthlyStatementRepository(mongoDB, cfg.MongoDB.StatementCollection),
+			statementOptions...,
+		),
+		webhookService: usecase.NewWebhookUseCase(
+			repository.NewPostgreSQLWebhookRepository(postgresDB),
+			usecase.WithWebhookHTTPClient(&http.Client{Timeout: cfg.Webhook.Timeout}),
+			usecase.WithWebhookRetryPolicy(cfg.Webhook.MaxAttempts, cfg.Webhook.RetryDelay),
+		),
+		exportService: usecase.NewExportJobUseCase(
+			repository.NewPostgreSQLExportJobRepository(postgresDB),
+			accountRepo,
+			transactionRepo,
+			exportStorage,
+			messageQueue,
+			cfg.RabbitMQ.ExportQueue,
+			usecase.WithExportRetention(cfg.Export.Retention),
+		),
+	}, nil
+}
+
+// FailUndelivered fails the transactions of a message the transaction queue
+// has dropped for good, for the queue's failure handler
+func (p *Processor) FailUndelivered(ctx context.Context, message []byte, err error) {
+	p.transactionService.FailUndelivered(ctx, message, err)
+}
+
+// Start subscribes to the transaction, export and notification queues and
+// starts the periodic jobs, which run until ctx is done
+func (p *Processor) Start(ctx context.Context) error {
+	cfg := p.cfg
+
+	// Start transaction processor
+	transactionSubscription, err := p.transactionService.StartTransactionProcessor(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction processor: %w", err)
+	}
+	p.transactionSubscription = transactionSubscription
+
+	log.Println("Transaction processor started and listening for messages...")
+
+	// Periodically queue scheduled transactions and standing orders that have
+	// come due
+	go func() {
+		ticker := time.NewTicker(cfg.Transaction.SchedulerInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				released, err := p.transactionService.ReleaseDueTransactions(ctx)
+				if err != nil {
+					log.Printf("Failed to release scheduled transactions: %v", err)
+				} else if released > 0 {
+					log.Printf("Queued %d scheduled transactions", released)
+				}
+
+				executed, err := p.standingOrderService.ExecuteDue(ctx)
+				if err != nil {
+					log.Printf("Failed to execute standing orders: %v", err)
+				} else if executed > 0 {
+					log.Printf("Executed %d standing orders", executed)
+				}
+			}
+		}
+	}()
+
+	// Periodically fail or requeue transactions left pending too long, and
+	// fail those left awaiting approval
+	if cfg.Transaction.PendingTTL > 0 || cfg.Transaction.ApprovalTTL > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Transaction.PendingSweepInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					expired, requeued, err := p.transactionService.ExpireStalePending(ctx)
+					if err != nil {
+						log.Printf("Failed to sweep stale pending transactions after expiring %d and requeueing %d: %v", expired, requeued, err)
+					} else if expired > 0 || requeued > 0 {
+						log.Printf("Expired %d and requeued %d stale pending transactions", expired, requeued)
+					}
+				}
+			}
+		}()
+	}
+
+	// Periodically move old transactions in a final status to the archive
+	if cfg.Archive.After > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Archive.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					archived, err := p.transactionService.ArchiveTransactions(ctx)
+					if err != nil {
+						log.Printf("Failed to archive transactions: %v", err)
+					}
+					if archived > 0 {
+						log.Printf("Archived %d transactions", archived)
+					}
+				}
+			}
+		}()
+	}
+
+	// Periodically publish queue messages left in the outbox, as when the
+	// broker was down when a transaction was submitted
+	go func() {
+		ticker := time.NewTicker(cfg.Transaction.OutboxRelayInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				dispatched, backlog, err := p.transactionService.DispatchOutbox(ctx)
+				if err != nil {
+					log.Printf("Failed to relay outbox messages: %v", err)
+					continue
+				}
+				if dispatched > 0 {
+					log.Printf("Published %d outbox messages", dispatched)
+				}
+				if backlog > 0 {
+					log.Printf("Outbox backlog: %d messages waiting to be published", backlog)
+				}
+			}
+		}
+	}()
+
+	// Periodically share this processor's rate for pending transactions'
+	// wait estimates
+	go func() {
+		ticker := time.NewTicker(cfg.Transaction.QueueRateInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.transactionService.PublishProcessingRate(ctx); err != nil {
+					log.Printf("Failed to publish processing rate: %v", err)
+				}
+			}
+		}
+	}()
+
+	// Periodically expire unconfirmed micro-deposits and claw them back
+	go func() {
+		ticker := time.NewTicker(cfg.MicroDeposit.SweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expired, err := p.verificationService.ExpireMicroDeposits(ctx)
+				if err != nil {
+					log.Printf("Failed to expire micro-deposits: %v", err)
+				} else if expired > 0 {
+					log.Printf("Expired %d micro-deposit verifications", expired)
+				}
+			}
+		}
+	}()
+
+	// Periodically expire holds that were neither captured nor released
+	go func() {
+		ticker := time.NewTicker(cfg.Hold.SweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				expired, err := p.holdService.ExpireHolds(ctx)
+				if err != nil {
+					log.Printf("Failed to expire holds: %v", err)
+				} else if expired > 0 {
+					log.Printf("Expired %d holds", expired)
+				}
+			}
+		}
+	}()
+
+	// Periodically snapshot the balances of each day that has closed since
+	// the last snapshot
+	go func() {
+		ticker := time.NewTicker(cfg.Snapshot.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				taken, err := p.snapshotService.SnapshotDue(ctx)
+				if err != nil {
+					log.Printf("Failed to snapshot balances: %v", err)
+				} else if taken > 0 {
+					log.Printf("Took %d balance snapshots", taken)
+				}
+			}
+		}
+	}()
+
+	// Periodically generate the last month's statements once their day of
+	// the month has come
+	go func() {
+		ticker := time.NewTicker(cfg.Statement.MonthlyInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				generated, err := p.monthlyStatementService.GenerateDue(ctx)
+				if err != nil {
+					log.Printf("Failed to generate monthly statements: %v", err)
+				} else if generated > 0 {
+					log.Printf("Generated %d monthly statements", generated)
+				}
+			}
+		}
+	}()
+
+	// Write queued export jobs to files, and periodically remove the files
+	// past their retention
+	exportSubscription, err := p.messageQueue.Subscribe(ctx, cfg.RabbitMQ.ExportQueue, func(ctx context.Context, message []byte) error {
+		return p.exportService.HandleExportJob(ctx, message)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start export worker: %w", err)
+	}
+	p.workerSubscriptions = append(p.workerSubscriptions, exportSubscription)
+
+	if cfg.Export.Retention > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Export.ExpiryInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					expired, err := p.exportService.ExpireExports(ctx)
+					if err != nil {
+						log.Printf("Failed to expire export files: %v", err)
+					}
+					if expired > 0 {
+						log.Printf("Removed %d expired export files", expired)
+					}
+				}
+			}
+		}()
+	}
+
+	// Post notification events to webhook subscriptions, and periodically
+	// retry the deliveries that failed
+	if cfg.Webhook.Enabled {
+		webhookSubscription, err := p.messageQueue.Subscribe(ctx, cfg.RabbitMQ.NotificationQueue, func(ctx context.Context, message []byte) error {
+			return p.webhookService.HandleNotification(ctx, message)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start webhook delivery worker: %w", err)
+		}
+		p.workerSubscriptions = append(p.workerSubscriptions, webhookSubscription)
+
+		go func() {
+			ticker := time.NewTicker(cfg.Webhook.RetryInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					retried, err := p.webhookService.RetryDue(ctx)
+					if err != nil {
+						log.Printf("Failed to retry webhook deliveries: %v", err)
+					} else if retried > 0 {
+						log.Printf("Retried %d webhook deliveries", retried)
+					}
+				}
+			}
+		}()
+	} else if cfg.Notification.Enabled && cfg.Queue.Driver == queue.DriverMemory {
+		// Nothing outside the process can read the in-memory notification
+		// queue, so its events are discarded rather than left to fill it
+		notificationSubscription, err := p.messageQueue.Subscribe(ctx, cfg.RabbitMQ.NotificationQueue, func(ctx context.Context, message []byte) error {
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start notification worker: %w", err)
+		}
+		p.workerSubscriptions = append(p.workerSubscriptions, notificationSubscription)
+	}
+
+	return nil
+}
+
+// Drain waits, until ctx is done, for the messages in hand to be processed
+// once the context Start was given is done, logging how many transactions
+// it drained
+func (p *Processor) Drain(ctx context.Context) {
+	if p.transactionSubscription == nil {
+		return
+	}
+	drained, err := p.transactionSubscription.Drain(ctx)
+	if err != nil {
+		log.Printf("Drained %d in-flight transactions before timing out, returning the rest to the queue: %v", drained, err)
+	} else {
+		log.Printf("Drained %d in-flight transactions", drained)
+	}
+	for _, subscription := range p.workerSubscriptions {
+		if _, err := subscription.Drain(ctx); err != nil {
+			log.Printf("Failed to drain worker: %v", err)
+		}
+	}
+}
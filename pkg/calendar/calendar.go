@@ -0,0 +1,178 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Convention decides how a date that is not a business day is moved
+type Convention string
+
+const (
+	// ConventionNone keeps the date unchanged
+	ConventionNone Convention = "none"
+	// ConventionFollowing moves to the next business day
+	ConventionFollowing Convention = "following"
+	// ConventionPreceding moves to the previous business day
+	ConventionPreceding Convention = "preceding"
+	// ConventionModifiedFollowing moves to the next business day unless that
+	// falls in the next month, in which case it moves to the previous one
+	ConventionModifiedFollowing Convention = "modified_following"
+)
+
+const dateLayout = "2006-01-02"
+
+// ParseConvention parses a roll convention name
+func ParseConvention(name string) (Convention, error) {
+	switch convention := Convention(strings.ToLower(strings.TrimSpace(name))); convention {
+	case ConventionNone, ConventionFollowing, ConventionPreceding, ConventionModifiedFollowing:
+		return convention, nil
+	default:
+		return "", fmt.Errorf("unknown roll convention %q", name)
+	}
+}
+
+// Calendar defines which days are business days
+type Calendar struct {
+	weekend  map[time.Weekday]bool
+	holidays map[string]bool
+}
+
+// New creates a calendar from its weekend days and holidays
+func New(weekend []time.Weekday, holidays []time.Time) (*Calendar, error) {
+	c := &Calendar{
+		weekend:  make(map[time.Weekday]bool),
+		holidays: make(map[string]bool),
+	}
+	for _, day := range weekend {
+		c.weekend[day] = true
+	}
+	if len(c.weekend) == 7 {
+		return nil, fmt.Errorf("calendar must have at least one weekday")
+	}
+	for _, holiday := range holidays {
+		c.holidays[holiday.Format(dateLayout)] = true
+	}
+	return c, nil
+}
+
+// IsBusinessDay reports whether the date of t is neither a weekend day nor a holiday
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	return !c.weekend[t.Weekday()] && !c.holidays[t.Format(dateLayout)]
+}
+
+// NextBusinessDay returns the first business day strictly after t
+func (c *Calendar) NextBusinessDay(t time.Time) time.Time {
+	return c.step(t.AddDate(0, 0, 1), 1)
+}
+
+// PreviousBusinessDay returns the last business day strictly before t
+func (c *Calendar) PreviousBusinessDay(t time.Time) time.Time {
+	return c.step(t.AddDate(0, 0, -1), -1)
+}
+
+// Roll moves t to a business day using the convention. Business days are
+// returned unchanged.
+func (c *Calendar) Roll(t time.Time, convention Convention) time.Time {
+	if convention == ConventionNone || c.IsBusinessDay(t) {
+		return t
+	}
+
+	switch convention {
+	case ConventionPreceding:
+		return c.step(t, -1)
+	case ConventionModifiedFollowing:
+		following := c.step(t, 1)
+		if following.Month() != t.Month() {
+			return c.step(t, -1)
+		}
+		return following
+	default:
+		return c.step(t, 1)
+	}
+}
+
+// step walks from t in direction until it reaches a business day
+func (c *Calendar) step(t time.Time, direction int) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, direction)
+	}
+	return t
+}
+
+// Set holds calendars per currency or region with a default for the rest
+type Set struct {
+	fallback  *Calendar
+	calendars map[string]*Calendar
+}
+
+// NewSet creates a calendar set with the fallback used for unlisted regions
+func NewSet(fallback *Calendar, calendars map[string]*Calendar) *Set {
+	if calendars == nil {
+		calendars = make(map[string]*Calendar)
+	}
+	return &Set{fallback: fallback, calendars: calendars}
+}
+
+// For returns the calendar of a currency or region
+func (s *Set) For(region string) *Calendar {
+	if calendar, ok := s.calendars[strings.ToUpper(region)]; ok {
+		return calendar
+	}
+	return s.fallback
+}
+
+// Load builds a calendar set from weekday names such as "saturday" and
+// holiday entries of the form "USD:2024-12-25". Every region shares the
+// weekend and gets its own holidays.
+func Load(weekendNames, holidayEntries []string) (*Set, error) {
+	weekend := make([]time.Weekday, 0, len(weekendNames))
+	for _, name := range weekendNames {
+		day, err := parseWeekday(name)
+		if err != nil {
+			return nil, err
+		}
+		weekend = append(weekend, day)
+	}
+
+	holidays := make(map[string][]time.Time)
+	for _, entry := range holidayEntries {
+		region, date, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid holiday %q: expected REGION:YYYY-MM-DD", entry)
+		}
+		day, err := time.Parse(dateLayout, strings.TrimSpace(date))
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: %w", entry, err)
+		}
+		region = strings.ToUpper(strings.TrimSpace(region))
+		holidays[region] = append(holidays[region], day)
+	}
+
+	fallback, err := New(weekend, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	calendars := make(map[string]*Calendar, len(holidays))
+	for region, days := range holidays {
+		calendar, err := New(weekend, days)
+		if err != nil {
+			return nil, err
+		}
+		calendars[region] = calendar
+	}
+
+	return NewSet(fallback, calendars), nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.ToLower(day.String()) == normalized {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
+}
@@ -3,9 +3,12 @@ package database
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"banking-ledger/internal/config"
+	"banking-ledger/internal/domain"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -62,13 +65,28 @@ func MigratePostgreSQL(db *sqlx.DB) error {
 		CREATE TABLE IF NOT EXISTS accounts (
 			id VARCHAR(36) PRIMARY KEY,
 			user_id VARCHAR(255) NOT NULL,
-			balance DECIMAL(20,8) NOT NULL DEFAULT 0,
+			balance BIGINT NOT NULL DEFAULT 0,
+			initial_balance BIGINT NOT NULL DEFAULT 0,
 			currency VARCHAR(3) NOT NULL,
+			type VARCHAR(20) NOT NULL DEFAULT 'checking',
 			status VARCHAR(20) NOT NULL DEFAULT 'active',
+			verified BOOLEAN NOT NULL DEFAULT FALSE,
+			overdraft_limit BIGINT NOT NULL DEFAULT 0,
+			held_amount BIGINT NOT NULL DEFAULT 0,
+			minimum_balance BIGINT NOT NULL DEFAULT 0,
+			max_transaction_amount BIGINT NOT NULL DEFAULT 0,
+			daily_outgoing_limit BIGINT NOT NULL DEFAULT 0,
+			low_balance_threshold BIGINT,
+			below_threshold BOOLEAN NOT NULL DEFAULT FALSE,
+			status_changed_at TIMESTAMP WITH TIME ZONE,
+			status_changed_by VARCHAR(255) NOT NULL DEFAULT '',
+			status_reason TEXT NOT NULL DEFAULT '',
+			nickname VARCHAR(100) NOT NULL DEFAULT '',
+			labels JSONB NOT NULL DEFAULT '[]',
+			metadata JSONB,
 			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-			version BIGINT NOT NULL DEFAULT 1,
-			UNIQUE(user_id, currency)
+			version BIGINT NOT NULL DEFAULT 1
 		);
 	`
 
@@ -76,11 +94,377 @@ func MigratePostgreSQL(db *sqlx.DB) error {
 		return fmt.Errorf("failed to create accounts table: %w", err)
 	}
 
+	// Add columns and constraints introduced after the accounts table was first created
+	alterAccountsTable := `
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS overdraft_limit BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS held_amount BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS minimum_balance BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS max_transaction_amount BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS daily_outgoing_limit BIGINT NOT NULL DEFAULT 0;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS low_balance_threshold BIGINT;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS below_threshold BOOLEAN NOT NULL DEFAULT FALSE;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP WITH TIME ZONE;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_changed_by VARCHAR(255) NOT NULL DEFAULT '';
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS status_reason TEXT NOT NULL DEFAULT '';
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS nickname VARCHAR(100) NOT NULL DEFAULT '';
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '[]';
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS metadata JSONB;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS initial_balance BIGINT;
+		ALTER TABLE accounts ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'checking';
+		DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_type_check') THEN
+				ALTER TABLE accounts ADD CONSTRAINT accounts_type_check
+					CHECK (type IN ('checking', 'savings', 'fee_collection', 'settlement'));
+			END IF;
+			-- A user may hold one account of each type per currency
+			ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_user_id_currency_key;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_user_id_currency_type_key') THEN
+				ALTER TABLE accounts ADD CONSTRAINT accounts_user_id_currency_type_key
+					UNIQUE (user_id, currency, type);
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_status_check') THEN
+				ALTER TABLE accounts ADD CONSTRAINT accounts_status_check
+					CHECK (status IN ('active', 'frozen', 'inactive', 'closed'));
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_overdraft_limit_check') THEN
+				ALTER TABLE accounts ADD CONSTRAINT accounts_overdraft_limit_check
+					CHECK (overdraft_limit >= 0);
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_held_amount_check') THEN
+				ALTER TABLE accounts ADD CONSTRAINT accounts_held_amount_check
+					CHECK (held_amount >= 0);
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_minimum_balance_check') THEN
+				ALTER TABLE accounts ADD CONSTRAINT accounts_minimum_balance_check
+					CHECK (minimum_balance >= 0);
+			END IF;
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'accounts_limits_check') THEN
+				ALTER TABLE accounts ADD CONSTRAINT accounts_limits_check
+					CHECK (max_transaction_amount >= 0 AND daily_outgoing_limit >= 0);
+			END IF;
+		END $$;
+	`
+
+	if _, err := db.Exec(alterAccountsTable); err != nil {
+		return fmt.Errorf("failed to alter accounts table: %w", err)
+	}
+
+	// Create micro-deposit challenges table
+	createMicroDepositsTable := `
+		CREATE TABLE IF NOT EXISTS micro_deposit_challenges (
+			id VARCHAR(36) PRIMARY KEY,
+			account_id VARCHAR(36) NOT NULL,
+			amount_one BIGINT NOT NULL,
+			amount_two BIGINT NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			clawed_back_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createMicroDepositsTable); err != nil {
+		return fmt.Errorf("failed to create micro_deposit_challenges table: %w", err)
+	}
+
+	// Convert amounts stored as decimals of the major unit into minor units
+	minorUnits := []struct {
+		table   string
+		columns []string
+	}{
+		{"accounts", []string{"balance"}},
+		{"micro_deposit_challenges", []string{"amount_one", "amount_two"}},
+	}
+
+	for _, target := range minorUnits {
+		if err := migrateToMinorUnits(db, target.table, target.columns); err != nil {
+			return err
+		}
+	}
+
+	// Create ledger entries table
+	createLedgerEntriesTable := `
+		CREATE TABLE IF NOT EXISTS ledger_entries (
+			id VARCHAR(255) PRIMARY KEY,
+			account_id VARCHAR(36) NOT NULL,
+			transaction_id VARCHAR(255) NOT NULL,
+			direction VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+			amount BIGINT NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			resulting_balance BIGINT NOT NULL,
+			account_version BIGINT NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createLedgerEntriesTable); err != nil {
+		return fmt.Errorf("failed to create ledger_entries table: %w", err)
+	}
+
+	// Create applied transactions table, the marker of every transaction
+	// whose balance changes have been posted
+	var appliedExists bool
+	if err := db.Get(&appliedExists, "SELECT to_regclass('applied_transactions') IS NOT NULL"); err != nil {
+		return fmt.Errorf("failed to inspect applied_transactions table: %w", err)
+	}
+
+	createAppliedTransactionsTable := `
+		CREATE TABLE IF NOT EXISTS applied_transactions (
+			transaction_id VARCHAR(255) PRIMARY KEY,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createAppliedTransactionsTable); err != nil {
+		return fmt.Errorf("failed to create applied_transactions table: %w", err)
+	}
+
+	// Transactions posted before the table existed are known by their entries
+	if !appliedExists {
+		backfill := `
+			INSERT INTO applied_transactions (transaction_id, applied_at)
+			SELECT transaction_id, MIN(created_at) FROM ledger_entries GROUP BY transaction_id
+			ON CONFLICT (transaction_id) DO NOTHING
+		`
+		if _, err := db.Exec(backfill); err != nil {
+			return fmt.Errorf("failed to backfill applied_transactions: %w", err)
+		}
+	}
+
+	// Accounts opened before initial balances were recorded are given the
+	// balance before their first ledger entry, or their balance if they have
+	// none
+	backfillInitialBalances := `
+		UPDATE accounts a SET initial_balance = COALESCE((
+			SELECT CASE WHEN e.direction = 'credit' THEN e.resulting_balance - e.amount
+				ELSE e.resulting_balance + e.amount END
+			FROM ledger_entries e
+			WHERE e.account_id = a.id
+			ORDER BY e.account_version
+			LIMIT 1
+		), a.balance)
+		WHERE initial_balance IS NULL;
+		ALTER TABLE accounts ALTER COLUMN initial_balance SET DEFAULT 0;
+		ALTER TABLE accounts ALTER COLUMN initial_balance SET NOT NULL;
+	`
+
+	if _, err := db.Exec(backfillInitialBalances); err != nil {
+		return fmt.Errorf("failed to backfill initial balances: %w", err)
+	}
+
+	// Create holds table
+	createHoldsTable := `
+		CREATE TABLE IF NOT EXISTS holds (
+			id VARCHAR(36) PRIMARY KEY,
+			account_id VARCHAR(36) NOT NULL,
+			amount BIGINT NOT NULL CHECK (amount > 0),
+			captured_amount BIGINT NOT NULL DEFAULT 0,
+			currency VARCHAR(3) NOT NULL,
+			status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'captured', 'released', 'expired')),
+			description TEXT NOT NULL DEFAULT '',
+			reference VARCHAR(255) NOT NULL DEFAULT '',
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			version BIGINT NOT NULL DEFAULT 1
+		);
+	`
+
+	if _, err := db.Exec(createHoldsTable); err != nil {
+		return fmt.Errorf("failed to create holds table: %w", err)
+	}
+
+	// Create balance snapshots table. Snapshots outlive their accounts, so
+	// there is no foreign key.
+	createBalanceSnapshotsTable := `
+		CREATE TABLE IF NOT EXISTS balance_snapshots (
+			account_id VARCHAR(36) NOT NULL,
+			as_of_date DATE NOT NULL,
+			balance BIGINT NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (account_id, as_of_date)
+		);
+	`
+
+	if _, err := db.Exec(createBalanceSnapshotsTable); err != nil {
+		return fmt.Errorf("failed to create balance_snapshots table: %w", err)
+	}
+
+	// Create account tombstones table, recording deletions for the change feed
+	createAccountTombstonesTable := `
+		CREATE TABLE IF NOT EXISTS account_tombstones (
+			id VARCHAR(36) PRIMARY KEY,
+			deleted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createAccountTombstonesTable); err != nil {
+		return fmt.Errorf("failed to create account_tombstones table: %w", err)
+	}
+
+	// Create account events table, the history of account status and limit
+	// changes. Events outlive their accounts, so there is no foreign key.
+	createAccountEventsTable := `
+		CREATE TABLE IF NOT EXISTS account_events (
+			id VARCHAR(36) PRIMARY KEY,
+			account_id VARCHAR(36) NOT NULL,
+			event_type VARCHAR(50) NOT NULL,
+			actor VARCHAR(255) NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			old_value TEXT NOT NULL DEFAULT '',
+			new_value TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createAccountEventsTable); err != nil {
+		return fmt.Errorf("failed to create account_events table: %w", err)
+	}
+
+	// Create settlement groups table
+	createSettlementGroupsTable := `
+		CREATE TABLE IF NOT EXISTS settlement_groups (
+			id VARCHAR(36) PRIMARY KEY,
+			name VARCHAR(255) NOT NULL UNIQUE,
+			account_ids TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createSettlementGroupsTable); err != nil {
+		return fmt.Errorf("failed to create settlement_groups table: %w", err)
+	}
+
+	// Create standing orders table
+	createStandingOrdersTable := `
+		CREATE TABLE IF NOT EXISTS standing_orders (
+			id VARCHAR(36) PRIMARY KEY,
+			from_account_id VARCHAR(36) NOT NULL,
+			to_account_id VARCHAR(36) NOT NULL,
+			amount BIGINT NOT NULL CHECK (amount > 0),
+			currency VARCHAR(3) NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('daily', 'weekly', 'monthly')),
+			run_interval INTEGER NOT NULL DEFAULT 1 CHECK (run_interval > 0),
+			start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			end_at TIMESTAMP WITH TIME ZONE,
+			run_count INTEGER NOT NULL DEFAULT 0,
+			next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+			status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'paused', 'completed', 'cancelled')),
+			transaction_ids TEXT[] NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createStandingOrdersTable); err != nil {
+		return fmt.Errorf("failed to create standing_orders table: %w", err)
+	}
+
+	// Create webhook tables. A subscription is deactivated rather than
+	// deleted, so its delivery log is kept.
+	createWebhookTables := `
+		CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+			id VARCHAR(36) PRIMARY KEY,
+			url TEXT NOT NULL,
+			event_types TEXT[] NOT NULL,
+			secret VARCHAR(255) NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id VARCHAR(36) PRIMARY KEY,
+			subscription_id VARCHAR(36) NOT NULL REFERENCES webhook_subscriptions(id),
+			event_id VARCHAR(36) NOT NULL,
+			event_type VARCHAR(50) NOT NULL,
+			payload TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL CHECK (status IN ('pending', 'delivered', 'failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_status_code INTEGER,
+			last_error TEXT NOT NULL DEFAULT '',
+			next_attempt_at TIMESTAMP WITH TIME ZONE,
+			delivered_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			UNIQUE (subscription_id, event_id)
+		);
+	`
+
+	if _, err := db.Exec(createWebhookTables); err != nil {
+		return fmt.Errorf("failed to create webhook tables: %w", err)
+	}
+
+	// Create export jobs table. A job's file lives in the export storage;
+	// file_location is cleared once the file expires.
+	createExportJobsTable := `
+		CREATE TABLE IF NOT EXISTS export_jobs (
+			id VARCHAR(36) PRIMARY KEY,
+			account_id VARCHAR(36) NOT NULL,
+			user_id VARCHAR(255) NOT NULL,
+			format VARCHAR(20) NOT NULL,
+			filter JSONB NOT NULL DEFAULT '{}',
+			status VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'running', 'completed', 'failed', 'cancelled', 'expired')),
+			row_count BIGINT NOT NULL DEFAULT 0,
+			total_rows BIGINT,
+			file_location TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			started_at TIMESTAMP WITH TIME ZONE,
+			completed_at TIMESTAMP WITH TIME ZONE,
+			expires_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createExportJobsTable); err != nil {
+		return fmt.Errorf("failed to create export_jobs table: %w", err)
+	}
+
+	// Create user verifications table. Users without a row are unverified.
+	createUserVerificationsTable := `
+		CREATE TABLE IF NOT EXISTS user_verifications (
+			user_id VARCHAR(255) PRIMARY KEY,
+			level VARCHAR(20) NOT NULL CHECK (level IN ('unverified', 'verified')),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);
+	`
+
+	if _, err := db.Exec(createUserVerificationsTable); err != nil {
+		return fmt.Errorf("failed to create user_verifications table: %w", err)
+	}
+
 	// Create indexes
 	createIndexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_accounts_user_id ON accounts(user_id);",
 		"CREATE INDEX IF NOT EXISTS idx_accounts_status ON accounts(status);",
 		"CREATE INDEX IF NOT EXISTS idx_accounts_created_at ON accounts(created_at);",
+		"CREATE INDEX IF NOT EXISTS idx_accounts_updated_at_id ON accounts(updated_at, id);",
+		"CREATE INDEX IF NOT EXISTS idx_accounts_labels ON accounts USING GIN (labels);",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_micro_deposit_challenges_pending ON micro_deposit_challenges(account_id) WHERE status = 'pending';",
+		"CREATE INDEX IF NOT EXISTS idx_micro_deposit_challenges_expires_at ON micro_deposit_challenges(expires_at) WHERE status = 'pending';",
+		"CREATE INDEX IF NOT EXISTS idx_holds_account_id ON holds(account_id);",
+		"CREATE INDEX IF NOT EXISTS idx_holds_expires_at ON holds(expires_at) WHERE status = 'active';",
+		"CREATE INDEX IF NOT EXISTS idx_standing_orders_from_account_id ON standing_orders(from_account_id);",
+		"CREATE INDEX IF NOT EXISTS idx_standing_orders_to_account_id ON standing_orders(to_account_id);",
+		"CREATE INDEX IF NOT EXISTS idx_standing_orders_next_run_at ON standing_orders(next_run_at) WHERE status = 'active';",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_account_version ON ledger_entries(account_id, account_version);",
+		"CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries(transaction_id);",
+		"CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_created_at ON ledger_entries(account_id, created_at);",
+		"CREATE INDEX IF NOT EXISTS idx_balance_snapshots_as_of_date ON balance_snapshots(as_of_date);",
+		"CREATE INDEX IF NOT EXISTS idx_account_tombstones_deleted_at_id ON account_tombstones(deleted_at, id);",
+		"CREATE INDEX IF NOT EXISTS idx_account_events_account_created_at ON account_events(account_id, created_at DESC, id DESC);",
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';",
+		"CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_created_at ON webhook_deliveries(subscription_id, created_at DESC);",
+		"CREATE INDEX IF NOT EXISTS idx_export_jobs_expires_at ON export_jobs(expires_at) WHERE status = 'completed';",
 	}
 
 	for _, index := range createIndexes {
@@ -92,41 +476,312 @@ func MigratePostgreSQL(db *sqlx.DB) error {
 	return nil
 }
 
+// migrateToMinorUnits converts DECIMAL amount columns to BIGINT minor units
+// using each row's currency exponent. Columns already converted are skipped.
+func migrateToMinorUnits(db *sqlx.DB, table string, columns []string) error {
+	for _, column := range columns {
+		var dataType string
+		query := `
+			SELECT data_type FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+		`
+		if err := db.Get(&dataType, query, table, column); err != nil {
+			return fmt.Errorf("failed to inspect %s.%s: %w", table, column, err)
+		}
+		if dataType != "numeric" {
+			continue
+		}
+
+		alter := fmt.Sprintf(
+			"ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT, ALTER COLUMN %s TYPE BIGINT USING ROUND(%s * POWER(10, %s))::BIGINT",
+			table, column, column, column, currencyExponentSQL(),
+		)
+		if _, err := db.Exec(alter); err != nil {
+			return fmt.Errorf("failed to convert %s.%s to minor units: %w", table, column, err)
+		}
+	}
+
+	if table == "accounts" {
+		if _, err := db.Exec("ALTER TABLE accounts ALTER COLUMN balance SET DEFAULT 0"); err != nil {
+			return fmt.Errorf("failed to set balance default: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// currencyExponentSQL builds a CASE expression giving the exponent of the
+// row's currency column
+func currencyExponentSQL() string {
+	grouped := domain.CurrencyExponents()
+	exponents := make([]int, 0, len(grouped))
+	for exponent := range grouped {
+		exponents = append(exponents, exponent)
+	}
+	sort.Ints(exponents)
+
+	var expression strings.Builder
+	expression.WriteString("CASE")
+	for _, exponent := range exponents {
+		currencies := append([]string(nil), grouped[exponent]...)
+		sort.Strings(currencies)
+		fmt.Fprintf(&expression, " WHEN currency IN ('%s') THEN %d", strings.Join(currencies, "', '"), exponent)
+	}
+	fmt.Fprintf(&expression, " ELSE %d END", domain.CurrencyExponent(""))
+
+	return expression.String()
+}
+
 // CreateMongoDBIndexes creates MongoDB indexes
 func CreateMongoDBIndexes(db *mongo.Database, collectionName string) error {
+	indexes := append(transactionIndexes(), mongo.IndexModel{
+		// A reference may be used once per source account until the
+		// transaction holding it is cancelled
+		Keys: bson.D{{Key: "reference", Value: 1}, {Key: "from_account_id", Value: 1}},
+		Options: options.Index().
+			SetName("unique_reference").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"reference_reserved": true}),
+	})
+
+	return createIndexes(db.Collection(collectionName), indexes)
+}
+
+// CreateMongoDBArchiveIndexes creates the indexes for the archive of a
+// transaction collection, which are those of the collection less the unique
+// reference, as archived transactions no longer hold their references
+func CreateMongoDBArchiveIndexes(db *mongo.Database, collectionName string) error {
+	return createIndexes(db.Collection(collectionName), transactionIndexes())
+}
+
+func createIndexes(collection *mongo.Collection, indexes []mongo.IndexModel) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	collection := db.Collection(collectionName)
+	_, err := collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("failed to create MongoDB indexes: %w", err)
+	}
 
-	// Create indexes
-	indexes := []mongo.IndexModel{
+	return nil
+}
+
+// transactionIndexes are the indexes that transactions are read by
+func transactionIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "from_account_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "to_account_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "type", Value: 1}},
+		},
 		{
-			Keys: bson.D{{"from_account_id", 1}},
+			Keys: bson.D{{Key: "status", Value: 1}},
 		},
 		{
-			Keys: bson.D{{"to_account_id", 1}},
+			Keys: bson.D{{Key: "created_at", Value: -1}},
 		},
 		{
-			Keys: bson.D{{"type", 1}},
+			Keys: bson.D{{Key: "from_account_id", Value: 1}, {Key: "created_at", Value: -1}},
 		},
 		{
-			Keys: bson.D{{"status", 1}},
+			Keys: bson.D{{Key: "to_account_id", Value: 1}, {Key: "created_at", Value: -1}},
 		},
 		{
-			Keys: bson.D{{"created_at", -1}},
+			Keys: bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}},
 		},
 		{
-			Keys: bson.D{{"from_account_id", 1}, {"created_at", -1}},
+			Keys: bson.D{{Key: "failure_code", Value: 1}},
 		},
 		{
-			Keys: bson.D{{"to_account_id", 1}, {"created_at", -1}},
+			// The scheduler polls for scheduled transactions that are due
+			Keys: bson.D{{Key: "scheduled_at", Value: 1}},
+			Options: options.Index().
+				SetPartialFilterExpression(bson.M{"status": domain.TransactionStatusScheduled}),
+		},
+		{
+			// Approvers list, and the sweeper expires, transactions awaiting
+			// approval oldest first
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+		},
+		{
+			// The sweeper polls for transactions left pending too long
+			Keys: bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().
+				SetName("stale_pending").
+				SetPartialFilterExpression(bson.M{"status": domain.TransactionStatusPending}),
+		},
+		{
+			// The outbox relay polls for messages not yet published
+			Keys: bson.D{{Key: "outbox.created_at", Value: 1}},
+			Options: options.Index().
+				SetName("undispatched_outbox").
+				SetPartialFilterExpression(bson.M{"outbox": bson.M{"$exists": true}}),
+		},
+		{
+			// Batch legs are looked up together
+			Keys: bson.D{{Key: "batch_id", Value: 1}},
+			Options: options.Index().
+				SetPartialFilterExpression(bson.M{"batch_id": bson.M{"$exists": true}}),
+		},
+		{
+			// Deposits are looked up by their external source and
+			// withdrawals by their external destination
+			Keys: bson.D{{Key: "external_source.reference", Value: 1}},
+			Options: options.Index().
+				SetPartialFilterExpression(bson.M{"external_source": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "external_destination.reference", Value: 1}},
+			Options: options.Index().
+				SetPartialFilterExpression(bson.M{"external_destination": bson.M{"$exists": true}}),
+		},
+		{
+			// Transactions are listed by the tags they carry
+			Keys: bson.D{{Key: "tags", Value: 1}},
+			Options: options.Index().
+				SetPartialFilterExpression(bson.M{"tags": bson.M{"$exists": true}}),
+		},
+		{
+			// Transactions are listed by an exact reference or a prefix of one
+			Keys: bson.D{{Key: "reference", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			// Support searches words of descriptions and references; a
+			// collection may have only one text index
+			Keys: bson.D{{Key: "description", Value: "text"}, {Key: "reference", Value: "text"}},
+			Options: options.Index().
+				SetName("description_reference_text"),
 		},
 	}
+}
 
-	_, err := collection.Indexes().CreateMany(ctx, indexes)
+// CreateSubmissionGuardIndexes creates the indexes for the duplicate-submission
+// guard collection. Guards are looked up by _id; the TTL index only keeps the
+// collection small, since expiry itself is decided by the guard's created_at.
+func CreateSubmissionGuardIndexes(db *mongo.Database, collectionName string, retention time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.Collection(collectionName)
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds())),
+	}
+
+	_, err := collection.Indexes().CreateOne(ctx, index)
 	if err != nil {
-		return fmt.Errorf("failed to create MongoDB indexes: %w", err)
+		return fmt.Errorf("failed to create submission guard indexes: %w", err)
+	}
+
+	return nil
+}
+
+// CreateIdempotencyIndexes creates the indexes for the idempotency key
+// collection. Keys are looked up by _id; the TTL index removes records once
+// they can no longer be replayed.
+func CreateIdempotencyIndexes(db *mongo.Database, collectionName string, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.Collection(collectionName)
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(ttl.Seconds())),
+	}
+
+	_, err := collection.Indexes().CreateOne(ctx, index)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency indexes: %w", err)
+	}
+
+	return nil
+}
+
+// CreateReconciliationIndexes creates the index the latest reconciliation
+// report is found by
+func CreateReconciliationIndexes(db *mongo.Database, collectionName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.Collection(collectionName)
+
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "started_at", Value: -1}},
+	}
+
+	_, err := collection.Indexes().CreateOne(ctx, index)
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation indexes: %w", err)
+	}
+
+	return nil
+}
+
+// CreateMonthlyStatementIndexes creates the indexes for the monthly statement
+// collection. The unique index keeps one statement per account and month,
+// and serves an account's statements newest month first.
+func CreateMonthlyStatementIndexes(db *mongo.Database, collectionName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.Collection(collectionName)
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "month", Value: -1}},
+		Options: options.Index().SetName("unique_account_month").SetUnique(true),
+	}
+
+	_, err := collection.Indexes().CreateOne(ctx, index)
+	if err != nil {
+		return fmt.Errorf("failed to create monthly statement indexes: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateMongoDBAmounts converts transaction amounts stored as doubles in the
+// major unit into int64 minor units of each transaction's currency
+func MigrateMongoDBAmounts(db *mongo.Database, collectionName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	collection := db.Collection(collectionName)
+	legacy := bson.M{"amount": bson.M{"$type": "double"}}
+
+	cursor, err := collection.Find(ctx, legacy, options.Find().SetProjection(bson.M{"amount": 1, "currency": 1}))
+	if err != nil {
+		return fmt.Errorf("failed to find legacy amounts: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var document struct {
+			ID       string  `bson:"_id"`
+			Amount   float64 `bson:"amount"`
+			Currency string  `bson:"currency"`
+		}
+		if err := cursor.Decode(&document); err != nil {
+			return fmt.Errorf("failed to decode legacy amount: %w", err)
+		}
+
+		// Only convert the amount if it is still a double, so reruns are safe
+		_, err := collection.UpdateOne(ctx,
+			bson.M{"_id": document.ID, "amount": bson.M{"$type": "double"}},
+			bson.M{"$set": bson.M{"amount": domain.MoneyFromFloat(document.Amount, document.Currency)}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to migrate amount of transaction %s: %w", document.ID, err)
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to iterate legacy amounts: %w", err)
 	}
 
 	return nil
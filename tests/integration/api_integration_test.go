@@ -83,7 +83,10 @@ func setupTestServer(t *testing.T) (*echo.Echo, func()) {
 
 	// Setup Echo server
 	e := echo.New()
-	routes.SetupRoutes(e, accountService, transactionService)
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     accountService,
+		TransactionService: transactionService,
+	})
 
 	// Cleanup function
 	cleanup := func() {
@@ -129,8 +132,8 @@ func TestAccountIntegration(t *testing.T) {
 		if account.UserID != "test-user-1" {
 			t.Errorf("Expected user_id 'test-user-1', got '%s'", account.UserID)
 		}
-		if account.Balance != 1000.0 {
-			t.Errorf("Expected balance 1000.0, got %f", account.Balance)
+		if account.Balance != 100000 {
+			t.Errorf("Expected balance 1000.00, got %s", account.Balance.Format(account.Currency))
 		}
 		if account.Currency != "USD" {
 			t.Errorf("Expected currency 'USD', got '%s'", account.Currency)
@@ -252,8 +255,8 @@ func TestTransactionIntegration(t *testing.T) {
 		if transaction.Type != domain.TransactionTypeDeposit {
 			t.Errorf("Expected type 'deposit', got '%s'", transaction.Type)
 		}
-		if transaction.Amount != 200.0 {
-			t.Errorf("Expected amount 200.0, got %f", transaction.Amount)
+		if transaction.Amount != 20000 {
+			t.Errorf("Expected amount 200.00, got %s", transaction.Amount.Format(transaction.Currency))
 		}
 		if transaction.Status != domain.TransactionStatusPending {
 			t.Errorf("Expected status 'pending', got '%s'", transaction.Status)
@@ -0,0 +1,344 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/config"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/repository"
+	"banking-ledger/pkg/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// setupTransactionRepository connects to the test MongoDB and returns a
+// repository over an empty collection and archive, dropped again on cleanup,
+// and the collection itself for seeding
+func setupTransactionRepository(t *testing.T, opts ...repository.MongoTransactionOption) (domain.TransactionRepository, *mongo.Collection) {
+	mongoDB, err := database.NewMongoDBConnection(config.MongoDBConfig{
+		URL:      getTestConfig().MongoURL,
+		Database: "ledger_test",
+	})
+	if err != nil {
+		t.Skipf("Skipping integration test: MongoDB not available: %v", err)
+	}
+
+	collection := "transactions_summary_test"
+	archive := repository.ArchiveCollectionName(collection)
+	ctx := context.Background()
+	for _, name := range []string{collection, archive} {
+		if err := mongoDB.Collection(name).Drop(ctx); err != nil {
+			t.Fatalf("Failed to drop collection: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		mongoDB.Collection(collection).Drop(ctx)
+		mongoDB.Collection(archive).Drop(ctx)
+		mongoDB.Client().Disconnect(ctx)
+	})
+
+	return repository.NewMongoTransactionRepository(mongoDB, collection, opts...), mongoDB.Collection(collection)
+}
+
+func TestMongoTransactionRepository_SummarizeByAccount(t *testing.T) {
+	repo, collection := setupTransactionRepository(t)
+	ctx := context.Background()
+
+	alice, bob := "alice", "bob"
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, transaction := range []*domain.Transaction{
+		{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 5000, Status: domain.TransactionStatusCompleted},
+		{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 700, Status: domain.TransactionStatusCompleted},
+		{Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 1200, Status: domain.TransactionStatusCompleted},
+		{Type: domain.TransactionTypeTransfer, FromAccountID: &bob, ToAccountID: &alice, Amount: 300, Status: domain.TransactionStatusCompleted,
+			Exchange: &domain.Exchange{CreditedAmount: 280, CreditedCurrency: "USD"}},
+		{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 100, Status: domain.TransactionStatusProcessing},
+		{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 9900, Status: domain.TransactionStatusFailed},
+		{Type: domain.TransactionTypeDeposit, ToAccountID: &bob, Amount: 4000, Status: domain.TransactionStatusCompleted},
+	} {
+		// Seeded directly, as Create stamps the current time
+		transaction.ID = fmt.Sprintf("summary-%d", i)
+		transaction.Currency = "USD"
+		transaction.CreatedAt = base.AddDate(0, 0, i)
+		if _, err := collection.InsertOne(ctx, transaction); err != nil {
+			t.Fatalf("Failed to seed transaction: %v", err)
+		}
+	}
+
+	totals, err := repo.SummarizeByAccount(ctx, alice, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if totals.LastTransactionAt == nil || !totals.LastTransactionAt.Equal(base.AddDate(0, 0, 5)) {
+		t.Errorf("Expected the last of alice's transactions, got %v", totals.LastTransactionAt)
+	}
+	totals.LastTransactionAt = nil
+	expected := domain.AccountTotals{
+		TransactionCount:  6,
+		Deposits:          domain.TransactionTotal{Amount: 5000, Count: 1},
+		Withdrawals:       domain.TransactionTotal{Amount: 700, Count: 1},
+		IncomingTransfers: domain.TransactionTotal{Amount: 280, Count: 1},
+		OutgoingTransfers: domain.TransactionTotal{Amount: 1200, Count: 1},
+		PendingCount:      1,
+		FailedCount:       1,
+	}
+	if *totals != expected {
+		t.Errorf("Expected totals %+v, got %+v", expected, *totals)
+	}
+
+	from, to := base.AddDate(0, 0, 1), base.AddDate(0, 0, 3)
+	totals, err = repo.SummarizeByAccount(ctx, alice, &from, &to)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if totals.TransactionCount != 3 || totals.Deposits.Count != 0 || totals.IncomingTransfers.Amount != 280 {
+		t.Errorf("Expected only the three transactions in range, got %+v", *totals)
+	}
+
+	totals, err = repo.SummarizeByAccount(ctx, "carol", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if totals.TransactionCount != 0 || totals.LastTransactionAt != nil {
+		t.Errorf("Expected no totals for an account without transactions, got %+v", *totals)
+	}
+}
+
+func TestMongoTransactionRepository_GetByFilterDetails(t *testing.T) {
+	repo, collection := setupTransactionRepository(t)
+	ctx := context.Background()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, transaction := range []*domain.Transaction{
+		{ID: "match", Currency: "EUR", Amount: 100, Reference: "INV-2024-0042", Metadata: map[string]interface{}{"order_id": "123"}},
+		{ID: "other-currency", Currency: "USD", Amount: 200, Reference: "INV-2024-0043", Metadata: map[string]interface{}{"order_id": "123"}},
+		{ID: "other-year", Currency: "EUR", Amount: 300, Reference: "INV-2023-0001", Metadata: map[string]interface{}{"order_id": "123"}},
+		{ID: "other-order", Currency: "EUR", Amount: 400, Reference: "INV-2024-0044", Metadata: map[string]interface{}{"order_id": "124"}},
+		{ID: "numeric-order", Currency: "EUR", Amount: 500, Reference: "INV-2024-0045", Metadata: map[string]interface{}{"order_id": 123}},
+		{ID: "similar-reference", Currency: "EUR", Amount: 600, Reference: "INVX2024-0046", Metadata: map[string]interface{}{"order_id": "123"}},
+	} {
+		transaction.Type = domain.TransactionTypeTransfer
+		transaction.Status = domain.TransactionStatusCompleted
+		transaction.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if _, err := collection.InsertOne(ctx, transaction); err != nil {
+			t.Fatalf("Failed to seed transaction: %v", err)
+		}
+	}
+
+	eur, prefix, exact := "EUR", "INV-2024-", "INV-2024-0043"
+	tests := []struct {
+		name   string
+		filter *domain.TransactionFilter
+		ids    []string
+	}{
+		{"currency, prefix and metadata", &domain.TransactionFilter{Currency: &eur, ReferencePrefix: &prefix, Metadata: map[string]string{"order_id": "123"}}, []string{"match"}},
+		{"currency and prefix", &domain.TransactionFilter{Currency: &eur, ReferencePrefix: &prefix}, []string{"numeric-order", "other-order", "match"}},
+		{"exact reference", &domain.TransactionFilter{Reference: &exact}, []string{"other-currency"}},
+		{"string metadata only", &domain.TransactionFilter{Metadata: map[string]string{"order_id": "123"}}, []string{"similar-reference", "other-year", "other-currency", "match"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, err := repo.GetByFilter(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			var ids []string
+			for _, transaction := range transactions {
+				ids = append(ids, transaction.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.ids) {
+				t.Errorf("Expected %v, got %v", tt.ids, ids)
+			}
+
+			count, err := repo.Count(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if count != int64(len(tt.ids)) {
+				t.Errorf("Expected a count of %d, got %d", len(tt.ids), count)
+			}
+		})
+	}
+}
+
+func TestMongoTransactionRepository_GetByIDs(t *testing.T) {
+	repo, collection := setupTransactionRepository(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"lookup-1", "lookup-2", "lookup-3"} {
+		transaction := &domain.Transaction{ID: id, Type: domain.TransactionTypeDeposit, Amount: 100, Currency: "USD", Status: domain.TransactionStatusCompleted}
+		if _, err := collection.InsertOne(ctx, transaction); err != nil {
+			t.Fatalf("Failed to seed transaction: %v", err)
+		}
+	}
+
+	transactions, err := repo.GetByIDs(ctx, []string{"lookup-3", "missing", "lookup-1"})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	found := make(map[string]bool)
+	for _, transaction := range transactions {
+		found[transaction.ID] = true
+	}
+	if len(transactions) != 2 || !found["lookup-1"] || !found["lookup-3"] {
+		t.Errorf("Expected lookup-1 and lookup-3 only, got %v", found)
+	}
+}
+
+func TestMongoTransactionRepository_CreateRejectsTakenID(t *testing.T) {
+	repo, _ := setupTransactionRepository(t)
+	ctx := context.Background()
+
+	alice := "alice"
+	for i, expected := range []error{nil, domain.ErrTransactionIDTaken} {
+		transaction := &domain.Transaction{
+			ID: "client-chosen", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 100, Currency: "USD",
+			Status: domain.TransactionStatusPending, Reference: fmt.Sprintf("REF-%d", i), IDSource: domain.TransactionIDClient,
+		}
+		if err := repo.Create(ctx, transaction); !errors.Is(err, expected) {
+			t.Errorf("Expected error %v creating attempt %d, got %v", expected, i, err)
+		}
+	}
+}
+
+func TestMongoTransactionRepository_Requeue(t *testing.T) {
+	repo, _ := setupTransactionRepository(t)
+	ctx := context.Background()
+
+	alice := "alice"
+	transaction := &domain.Transaction{
+		ID: "requeue-1", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD",
+		Status: domain.TransactionStatusPending, Reference: "REF-REQUEUE",
+	}
+	if err := repo.Create(ctx, transaction); err != nil {
+		t.Fatalf("Failed to create transaction: %v", err)
+	}
+	if err := repo.FailPending(ctx, transaction.ID, domain.FailureCodeExpired, domain.ErrTransactionExpired.Error()); err != nil {
+		t.Fatalf("Failed to expire transaction: %v", err)
+	}
+
+	// A stale failure code no longer matches
+	if _, err := repo.Requeue(ctx, transaction.ID, domain.TransactionStatusFailed, domain.FailureCodeQueueError, "admin"); !errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+		t.Errorf("Expected ErrTransactionAlreadyProcessed for another failure, got %v", err)
+	}
+
+	requeued, err := repo.Requeue(ctx, transaction.ID, domain.TransactionStatusFailed, domain.FailureCodeExpired, "admin")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if requeued.Status != domain.TransactionStatusPending || requeued.FailureCode != "" || requeued.ErrorMessage != "" || requeued.RequeuedAt == nil {
+		t.Errorf("Expected a pending transaction without its failure, got %+v", requeued)
+	}
+
+	requeued, err = repo.Requeue(ctx, transaction.ID, domain.TransactionStatusPending, "", "admin")
+	if err != nil || requeued.RequeueCount() != 2 {
+		t.Errorf("Expected a second requeue counted, got %+v, %v", requeued, err)
+	}
+
+	if _, err := repo.Requeue(ctx, "missing", domain.TransactionStatusPending, "", "admin"); !errors.Is(err, domain.ErrTransactionNotFound) {
+		t.Errorf("Expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestMongoTransactionRepository_ArchiveBefore(t *testing.T) {
+	repo, collection := setupTransactionRepository(t, repository.WithArchive(24*time.Hour))
+	ctx := context.Background()
+
+	alice := "alice"
+	old := time.Now().Add(-48 * time.Hour)
+	for i, status := range []domain.TransactionStatus{
+		domain.TransactionStatusCompleted,
+		domain.TransactionStatusCompleted,
+		domain.TransactionStatusProcessing,
+	} {
+		transaction := &domain.Transaction{
+			ID: fmt.Sprintf("archive-%d", i), Type: domain.TransactionTypeDeposit, ToAccountID: &alice,
+			Amount: 100, Currency: "USD", Status: status, CreatedAt: old.Add(time.Duration(i) * time.Minute), UpdatedAt: old,
+		}
+		if _, err := collection.InsertOne(ctx, transaction); err != nil {
+			t.Fatalf("Failed to seed transaction: %v", err)
+		}
+	}
+
+	// Two batches of one, the second finding nothing it may move
+	for _, expected := range []int{1, 1, 0} {
+		moved, err := repo.ArchiveBefore(ctx, time.Now().Add(-24*time.Hour), 1)
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if moved != expected {
+			t.Errorf("Expected %d transactions moved, got %d", expected, moved)
+		}
+	}
+
+	if count, _ := collection.CountDocuments(ctx, bson.M{}); count != 1 {
+		t.Errorf("Expected only the processing transaction left, got %d", count)
+	}
+
+	transaction, err := repo.GetByID(ctx, "archive-0")
+	if err != nil {
+		t.Fatalf("Expected the archived transaction found, got %v", err)
+	}
+	if !transaction.Archived || transaction.ArchivedAt == nil {
+		t.Errorf("Expected the transaction flagged as archived")
+	}
+
+	transactions, err := repo.GetByAccountID(ctx, alice, &domain.TransactionFilter{SortBy: domain.TransactionSortCreatedAt, SortOrder: domain.SortAscending})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(transactions) != 3 || transactions[0].ID != "archive-0" || transactions[2].Archived {
+		t.Errorf("Expected the history across both collections in order, got %d transactions", len(transactions))
+	}
+	if deposits, err := repo.SumDeposits(ctx, []string{alice}); err != nil || deposits != 200 {
+		t.Errorf("Expected the archived deposits counted, got %d and %v", deposits, err)
+	}
+
+	recent := time.Now().Add(-time.Hour)
+	if count, err := repo.Count(ctx, &domain.TransactionFilter{AccountID: &alice, FromDate: &recent}); err != nil || count != 0 {
+		t.Errorf("Expected a recent range to skip the archive, got %d and %v", count, err)
+	}
+}
+
+func TestMongoTransactionRepository_GetByFilterProjection(t *testing.T) {
+	for _, archived := range []bool{false, true} {
+		t.Run(fmt.Sprintf("archive %v", archived), func(t *testing.T) {
+			var opts []repository.MongoTransactionOption
+			if archived {
+				opts = append(opts, repository.WithArchive(24*time.Hour))
+			}
+			repo, collection := setupTransactionRepository(t, opts...)
+			ctx := context.Background()
+
+			transaction := &domain.Transaction{
+				ID: "heavy", Type: domain.TransactionTypeDeposit, Amount: 1250, Currency: "USD", Status: domain.TransactionStatusFailed,
+				Description: "Payroll", ErrorMessage: "account frozen", Metadata: map[string]interface{}{"payload": "large"},
+			}
+			if _, err := collection.InsertOne(ctx, transaction); err != nil {
+				t.Fatalf("Failed to seed transaction: %v", err)
+			}
+
+			transactions, err := repo.GetByFilter(ctx, &domain.TransactionFilter{Projection: domain.DefaultTransactionProjection()})
+			if err != nil || len(transactions) != 1 {
+				t.Fatalf("Expected the transaction, got %d and %v", len(transactions), err)
+			}
+			if got := transactions[0]; got.Metadata != nil || got.ErrorMessage != "" || got.Description != "Payroll" {
+				t.Errorf("Expected the metadata and error message left unread, got %+v", got)
+			}
+
+			transactions, err = repo.GetByFilter(ctx, &domain.TransactionFilter{Projection: &domain.TransactionProjection{Include: []string{"id", "amount"}}})
+			if err != nil || len(transactions) != 1 {
+				t.Fatalf("Expected the transaction, got %d and %v", len(transactions), err)
+			}
+			if got := transactions[0]; got.ID != "heavy" || got.Amount != 1250 || got.Currency != "USD" || got.Description != "" || got.Metadata != nil {
+				t.Errorf("Expected only the ID and amount with its currency read, got %+v", got)
+			}
+		})
+	}
+}
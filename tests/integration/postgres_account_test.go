@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/repository"
+	"banking-ledger/pkg/database"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// setupAccountRepository connects to the test PostgreSQL database and returns
+// a repository over an empty accounts table, emptied again on cleanup
+func setupAccountRepository(t *testing.T) domain.AccountRepository {
+	postgresDB, err := sqlx.Connect("postgres", getTestConfig().PostgresURL)
+	if err != nil {
+		t.Skipf("Skipping integration test: PostgreSQL not available: %v", err)
+	}
+	if err := database.MigratePostgreSQL(postgresDB); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	postgresDB.Exec("DELETE FROM accounts")
+	t.Cleanup(func() {
+		postgresDB.Exec("DELETE FROM accounts")
+		postgresDB.Close()
+	})
+
+	return repository.NewPostgreSQLAccountRepository(postgresDB)
+}
+
+func TestPostgreSQLAccountRepository_CreateMany(t *testing.T) {
+	repo := setupAccountRepository(t)
+	ctx := context.Background()
+
+	existing := &domain.Account{UserID: "carol", Currency: "USD", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive}
+	if err := repo.Create(ctx, existing); err != nil {
+		t.Fatalf("Failed to create account: %v", err)
+	}
+
+	accounts := []*domain.Account{
+		{ID: "bulk-1", UserID: "alice", Balance: 1000, InitialBalance: 1000, Currency: "USD", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive, Labels: domain.AccountLabels{"Imported"}},
+		{ID: "bulk-2", UserID: "alice", Currency: "USD", Type: domain.AccountTypeSavings, Status: domain.AccountStatusActive},
+		{ID: "bulk-3", UserID: "carol", Currency: "USD", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive},
+	}
+	created, err := repo.CreateMany(ctx, accounts)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if len(created) != 2 || !created["bulk-1"] || !created["bulk-2"] {
+		t.Errorf("Expected the two new accounts created, got %v", created)
+	}
+	account, err := repo.GetByID(ctx, "bulk-1")
+	if err != nil {
+		t.Fatalf("Failed to get account: %v", err)
+	}
+	if account.Balance != 1000 || !account.Labels.Has("Imported") || account.Version != 1 {
+		t.Errorf("Expected the account stored as given, got %+v", account)
+	}
+	if _, err := repo.GetByID(ctx, "bulk-3"); err != domain.ErrAccountNotFound {
+		t.Errorf("Expected the duplicate skipped, got %v", err)
+	}
+}
+
+func TestPostgreSQLAccountRepository_ListUsers(t *testing.T) {
+	repo := setupAccountRepository(t)
+	ctx := context.Background()
+
+	for _, account := range []*domain.Account{
+		{UserID: "cust-alice", Balance: 10000, Currency: "USD", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive},
+		{UserID: "cust-alice", Balance: 2550, Currency: "USD", Type: domain.AccountTypeSavings, Status: domain.AccountStatusFrozen},
+		{UserID: "cust-alice", Balance: 700, Currency: "EUR", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive},
+		{UserID: "cust_bob", Currency: "GBP", Type: domain.AccountTypeChecking, Status: domain.AccountStatusClosed},
+	} {
+		if err := repo.Create(ctx, account); err != nil {
+			t.Fatalf("Failed to create account: %v", err)
+		}
+	}
+
+	users, err := repo.ListUsers(ctx, &domain.UserFilter{UserIDPrefix: "cust-", Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("Expected the prefix matched literally, got %d users", len(users))
+	}
+
+	alice := users[0]
+	if alice.AccountCount != 3 || len(alice.Balances) != 2 || alice.Balances[0].Currency != "EUR" || alice.Balances[1].Balance != 12550 {
+		t.Errorf("Expected 3 accounts holding 7.00 EUR and 125.50 USD, got %d accounts and %+v %+v", alice.AccountCount, alice.Balances[0], alice.Balances[1])
+	}
+	if alice.Statuses[domain.AccountStatusActive] != 2 || alice.Statuses[domain.AccountStatusFrozen] != 1 {
+		t.Errorf("Expected two active accounts and one frozen, got %v", alice.Statuses)
+	}
+}
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/queue"
+)
+
+func TestRabbitMQQueue_Context(t *testing.T) {
+	queueName := fmt.Sprintf("test_context_%d", time.Now().UnixNano())
+	messageQueue, err := queue.NewRabbitMQQueue(getTestConfig().RabbitMQURL,
+		queue.WithRetryPolicy(queue.RetryPolicy{MaxRetries: 0}),
+		queue.WithTimeouts(time.Second, 200*time.Millisecond),
+	)
+	if err != nil {
+		t.Skipf("Skipping integration test: RabbitMQ not available: %v", err)
+	}
+	defer messageQueue.Close()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := messageQueue.Publish(cancelled, queueName, []byte(`{}`)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled publish to fail, got %v", err)
+	}
+	if _, err := messageQueue.Subscribe(cancelled, queueName, func(ctx context.Context, data []byte) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled subscribe to fail, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The handler's context runs out after the processing timeout
+	handled := make(chan error, 1)
+	_, err = messageQueue.Subscribe(ctx, queueName, func(ctx context.Context, data []byte) error {
+		if _, ok := ctx.Deadline(); !ok {
+			handled <- errors.New("no deadline")
+			return nil
+		}
+		<-ctx.Done()
+		handled <- ctx.Err()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if err := messageQueue.Publish(ctx, queueName, []byte(`{"id":"tx-1"}`)); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	select {
+	case err := <-handled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the handler's context to time out, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the message")
+	}
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/queue"
+)
+
+func TestRabbitMQQueue_DeadLetters(t *testing.T) {
+	queueName := fmt.Sprintf("test_dead_letters_%d", time.Now().UnixNano())
+	messageQueue, err := queue.NewRabbitMQQueue(getTestConfig().RabbitMQURL,
+		queue.WithRetryPolicy(queue.RetryPolicy{MaxRetries: 1}),
+		queue.WithDeadLetterQueue(queueName, queueName+"_dlq"),
+	)
+	if err != nil {
+		t.Skipf("Skipping integration test: RabbitMQ not available: %v", err)
+	}
+	defer messageQueue.Close()
+	deadLetters := messageQueue.(domain.DeadLetterQueue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The handler fails transiently until the message is replayed
+	var calls, healed atomic.Int32
+	_, err = messageQueue.Subscribe(ctx, queueName, func(ctx context.Context, message []byte) error {
+		calls.Add(1)
+		if healed.Load() == 0 {
+			return domain.ErrDatabaseError
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	if err := messageQueue.Publish(ctx, queueName, []byte(`{"id":"tx-1"}`)); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	var peeked []*domain.DeadLetter
+	for deadline := time.Now().Add(10 * time.Second); len(peeked) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the message to be dead-lettered")
+		}
+		time.Sleep(100 * time.Millisecond)
+		if peeked, err = deadLetters.PeekDeadLetters(ctx, queueName, 10); err != nil {
+			t.Fatalf("Failed to peek: %v", err)
+		}
+	}
+
+	deadLetter := peeked[0]
+	if deadLetter.ID == "" || deadLetter.Queue != queueName || deadLetter.FailureCount != 2 || deadLetter.LastError == "" || string(deadLetter.Body) != `{"id":"tx-1"}` {
+		t.Errorf("Expected the dead letter with its failures, got %+v", deadLetter)
+	}
+
+	// Peeking leaves the dead letter in place
+	again, err := deadLetters.PeekDeadLetters(ctx, queueName, 10)
+	if err != nil || len(again) != 1 || again[0].ID != deadLetter.ID {
+		t.Fatalf("Expected the dead letter still queued, got %v %v", again, err)
+	}
+
+	healed.Store(1)
+	result, err := deadLetters.RequeueDeadLetters(ctx, queueName, []string{deadLetter.ID, "missing"})
+	if err != nil {
+		t.Fatalf("Failed to requeue: %v", err)
+	}
+	if len(result.Requeued) != 1 || result.Requeued[0] != deadLetter.ID || len(result.Missing) != 1 || result.Missing[0] != "missing" {
+		t.Errorf("Expected the dead letter requeued and the other ID missing, got %+v", result)
+	}
+
+	for deadline := time.Now().Add(10 * time.Second); calls.Load() < 3; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the replay, handled %d times", calls.Load())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if remaining, err := deadLetters.PeekDeadLetters(ctx, queueName, 10); err != nil || len(remaining) != 0 {
+		t.Errorf("Expected no dead letters left, got %v %v", remaining, err)
+	}
+}
@@ -0,0 +1,152 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"banking-ledger/pkg/calendar"
+)
+
+func date(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func usdCalendar(t *testing.T) *calendar.Calendar {
+	t.Helper()
+	set, err := calendar.Load(
+		[]string{"Saturday", "sunday"},
+		[]string{
+			"USD:2023-01-02", // New Year observed
+			"USD:2024-09-02", // Labor Day
+			"USD:2024-12-25",
+			"USD:2024-12-26", // Consecutive holidays
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to load calendar: %v", err)
+	}
+	return set.For("usd")
+}
+
+func TestCalendar_IsBusinessDay(t *testing.T) {
+	cal := usdCalendar(t)
+
+	tests := []struct {
+		date     string
+		expected bool
+	}{
+		{"2024-12-24", true},
+		{"2024-12-25", false},
+		{"2024-12-28", false},
+		{"2024-12-29", false},
+		{"2023-01-02", false},
+		{"2023-01-03", true},
+	}
+
+	for _, tt := range tests {
+		if got := cal.IsBusinessDay(date(tt.date)); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.date, tt.expected, got)
+		}
+	}
+}
+
+func TestCalendar_NextAndPreviousBusinessDay(t *testing.T) {
+	cal := usdCalendar(t)
+
+	tests := []struct {
+		name     string
+		from     string
+		next     string
+		previous string
+	}{
+		{"midweek", "2024-12-18", "2024-12-19", "2024-12-17"},
+		{"consecutive holidays", "2024-12-24", "2024-12-27", "2024-12-23"},
+		{"across the weekend", "2024-12-27", "2024-12-30", "2024-12-24"},
+		{"year boundary", "2022-12-30", "2023-01-03", "2022-12-29"},
+		{"into the previous year", "2023-01-03", "2023-01-04", "2022-12-30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.NextBusinessDay(date(tt.from)); !got.Equal(date(tt.next)) {
+				t.Errorf("Expected next business day %s, got %s", tt.next, got.Format("2006-01-02"))
+			}
+			if got := cal.PreviousBusinessDay(date(tt.from)); !got.Equal(date(tt.previous)) {
+				t.Errorf("Expected previous business day %s, got %s", tt.previous, got.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestCalendar_Roll(t *testing.T) {
+	cal := usdCalendar(t)
+
+	tests := []struct {
+		name       string
+		date       string
+		convention calendar.Convention
+		expected   string
+	}{
+		{"business day is kept", "2024-12-24", calendar.ConventionFollowing, "2024-12-24"},
+		{"none keeps holidays", "2024-12-25", calendar.ConventionNone, "2024-12-25"},
+		{"following over consecutive holidays", "2024-12-25", calendar.ConventionFollowing, "2024-12-27"},
+		{"preceding over consecutive holidays", "2024-12-26", calendar.ConventionPreceding, "2024-12-24"},
+		{"modified following within the month", "2024-12-25", calendar.ConventionModifiedFollowing, "2024-12-27"},
+		{"following across the year", "2022-12-31", calendar.ConventionFollowing, "2023-01-03"},
+		{"preceding across the year", "2023-01-02", calendar.ConventionPreceding, "2022-12-30"},
+		{"modified following at year end", "2022-12-31", calendar.ConventionModifiedFollowing, "2022-12-30"},
+		{"modified following over a month-start holiday", "2024-08-31", calendar.ConventionModifiedFollowing, "2024-08-30"},
+		{"following over a month-start holiday", "2024-08-31", calendar.ConventionFollowing, "2024-09-03"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.Roll(date(tt.date), tt.convention); !got.Equal(date(tt.expected)) {
+				t.Errorf("Expected %s, got %s", tt.expected, got.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestLoad_RegionsAndValidation(t *testing.T) {
+	set, err := calendar.Load([]string{"friday", "saturday"}, []string{"EUR:2024-05-01"})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if set.For("EUR").IsBusinessDay(date("2024-05-01")) {
+		t.Errorf("Expected EUR holiday to be observed")
+	}
+	if !set.For("USD").IsBusinessDay(date("2024-05-01")) {
+		t.Errorf("Expected other regions to ignore EUR holidays")
+	}
+	if set.For("USD").IsBusinessDay(date("2024-05-03")) || !set.For("USD").IsBusinessDay(date("2024-05-05")) {
+		t.Errorf("Expected the configured Friday-Saturday weekend")
+	}
+
+	invalid := []struct {
+		weekend  []string
+		holidays []string
+	}{
+		{[]string{"caturday"}, nil},
+		{nil, []string{"2024-05-01"}},
+		{nil, []string{"EUR:01/05/2024"}},
+		{[]string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}, nil},
+	}
+	for _, tt := range invalid {
+		if _, err := calendar.Load(tt.weekend, tt.holidays); err == nil {
+			t.Errorf("Expected error for weekend %v and holidays %v", tt.weekend, tt.holidays)
+		}
+	}
+
+	if _, err := calendar.ParseConvention("Modified_Following"); err != nil {
+		t.Errorf("Expected convention to parse, got %v", err)
+	}
+	if _, err := calendar.ParseConvention("nearest"); err == nil {
+		t.Errorf("Expected unknown convention to fail")
+	}
+}
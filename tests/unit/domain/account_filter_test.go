@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestAccountFilter_Matches(t *testing.T) {
+	created := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	before, after := created.Add(-time.Hour), created.Add(time.Hour)
+	account := &domain.Account{
+		UserID:    "corp-acme-1",
+		Currency:  "EUR",
+		Type:      domain.AccountTypeChecking,
+		Status:    domain.AccountStatusFrozen,
+		Labels:    domain.AccountLabels{"payroll"},
+		CreatedAt: created,
+	}
+	closed := *account
+	closed.Status = domain.AccountStatusClosed
+
+	tests := []struct {
+		name     string
+		filter   domain.AccountFilter
+		account  *domain.Account
+		expected bool
+	}{
+		{"empty", domain.AccountFilter{}, account, true},
+		{"status", domain.AccountFilter{Status: domain.AccountStatusFrozen, Currency: "EUR"}, account, true},
+		{"other status", domain.AccountFilter{Status: domain.AccountStatusActive}, account, false},
+		{"other currency", domain.AccountFilter{Currency: "USD"}, account, false},
+		{"user prefix", domain.AccountFilter{UserIDPrefix: "corp-"}, account, true},
+		{"other user prefix", domain.AccountFilter{UserIDPrefix: "acme"}, account, false},
+		{"created in range", domain.AccountFilter{CreatedFrom: &before, CreatedTo: &after}, account, true},
+		{"created before range", domain.AccountFilter{CreatedFrom: &after}, account, false},
+		{"closed left out", domain.AccountFilter{}, &closed, false},
+		{"closed included", domain.AccountFilter{IncludeClosed: true}, &closed, true},
+		{"closed asked for", domain.AccountFilter{Status: domain.AccountStatusClosed}, &closed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matches := tt.filter.Matches(tt.account); matches != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, matches)
+			}
+		})
+	}
+}
+
+func TestAccountFilter_IsValid(t *testing.T) {
+	from, to := time.Now(), time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		filter   domain.AccountFilter
+		expected error
+	}{
+		{"empty", domain.AccountFilter{}, nil},
+		{"unknown type", domain.AccountFilter{Type: "brokerage"}, domain.ErrInvalidAccountType},
+		{"unknown status", domain.AccountFilter{Status: "dormant"}, domain.ErrInvalidInput},
+		{"reversed range", domain.AccountFilter{CreatedFrom: &from, CreatedTo: &to}, domain.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.filter.IsValid(); err != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
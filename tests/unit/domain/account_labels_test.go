@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestAccountLabels_Normalize(t *testing.T) {
+	tooMany := make(domain.AccountLabels, domain.MaxAccountLabels+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("x", i+1)
+	}
+
+	tests := []struct {
+		name          string
+		labels        domain.AccountLabels
+		expected      domain.AccountLabels
+		expectedError error
+	}{
+		{"none", nil, nil, nil},
+		{"trimmed and deduplicated", domain.AccountLabels{" Rent ", "Bills", "Rent"}, domain.AccountLabels{"Rent", "Bills"}, nil},
+		{"blank", domain.AccountLabels{"Rent", " "}, nil, domain.ErrInvalidAccountLabels},
+		{"too long", domain.AccountLabels{strings.Repeat("x", domain.MaxAccountLabelLength+1)}, nil, domain.ErrInvalidAccountLabels},
+		{"too many", tooMany, nil, domain.ErrInvalidAccountLabels},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, err := tt.labels.Normalize()
+			if err != tt.expectedError {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if !reflect.DeepEqual(labels, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, labels)
+			}
+		})
+	}
+}
+
+func TestAccountLabels_ScanAndValue(t *testing.T) {
+	value, err := domain.AccountLabels(nil).Value()
+	if err != nil || string(value.([]byte)) != "[]" {
+		t.Errorf("Expected no labels stored as an empty array, got %s %v", value, err)
+	}
+
+	var labels domain.AccountLabels
+	if err := labels.Scan([]byte(`["Rent","Savings EUR"]`)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !labels.Has("Savings EUR") || labels.Has("rent") {
+		t.Errorf("Expected the stored labels matched exactly, got %v", labels)
+	}
+	if err := labels.Scan([]byte(`[]`)); err != nil || labels != nil {
+		t.Errorf("Expected an empty array scanned as no labels, got %v %v", labels, err)
+	}
+}
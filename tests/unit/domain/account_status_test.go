@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestAccountStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from     domain.AccountStatus
+		to       domain.AccountStatus
+		expected bool
+	}{
+		{domain.AccountStatusActive, domain.AccountStatusFrozen, true},
+		{domain.AccountStatusActive, domain.AccountStatusInactive, true},
+		{domain.AccountStatusActive, domain.AccountStatusClosed, true},
+		{domain.AccountStatusFrozen, domain.AccountStatusActive, true},
+		{domain.AccountStatusInactive, domain.AccountStatusActive, true},
+		{domain.AccountStatusInactive, domain.AccountStatusFrozen, false},
+		{domain.AccountStatusClosed, domain.AccountStatusActive, false},
+		{domain.AccountStatusClosed, domain.AccountStatusInactive, false},
+		{domain.AccountStatusActive, domain.AccountStatus("actve"), false},
+		{domain.AccountStatus("actve"), domain.AccountStatusActive, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.expected {
+			t.Errorf("%s -> %s: expected %v, got %v", tt.from, tt.to, tt.expected, got)
+		}
+	}
+}
+
+func TestAccountStatus_MovementRules(t *testing.T) {
+	tests := []struct {
+		status      domain.AccountStatus
+		creditError error
+		debitError  error
+	}{
+		{domain.AccountStatusActive, nil, nil},
+		{domain.AccountStatusFrozen, nil, domain.ErrAccountFrozen},
+		{domain.AccountStatusInactive, domain.ErrAccountInactive, domain.ErrAccountInactive},
+		{domain.AccountStatusClosed, domain.ErrAccountClosed, domain.ErrAccountClosed},
+	}
+
+	for _, tt := range tests {
+		if err := tt.status.CanCredit(); err != tt.creditError {
+			t.Errorf("%s credit: expected %v, got %v", tt.status, tt.creditError, err)
+		}
+		if err := tt.status.CanDebit(); err != tt.debitError {
+			t.Errorf("%s debit: expected %v, got %v", tt.status, tt.debitError, err)
+		}
+	}
+}
+
+func TestAccountStatus_IsValid(t *testing.T) {
+	for _, status := range domain.AccountStatuses {
+		if !status.IsValid() {
+			t.Errorf("Expected %s to be valid", status)
+		}
+	}
+	if domain.AccountStatus("actve").IsValid() {
+		t.Errorf("Expected a misspelled status to be invalid")
+	}
+}
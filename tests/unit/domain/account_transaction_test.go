@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestAccountTransaction_MarshalJSON(t *testing.T) {
+	alice, bob := "alice", "bob"
+	account := &domain.Account{ID: "alice", Currency: "USD"}
+	transaction := &domain.Transaction{ID: "t1", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 4000, Currency: "USD"}
+
+	data, err := json.Marshal(domain.NewAccountTransaction(transaction, account))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !strings.Contains(string(data), `"id":"t1"`) || !strings.Contains(string(data), `"amount":"40.00"`) {
+		t.Errorf("Expected the transaction's own fields, got %s", data)
+	}
+	if !strings.HasSuffix(string(data), `"direction":"debit","signed_amount":"-40.00","counterparty_account_id":"bob","counterparty_user_id":null,"running_balance":null}`) {
+		t.Errorf("Expected the debit from alice's side, got %s", data)
+	}
+
+	deposit := &domain.Transaction{ID: "t2", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 250, Currency: "USD"}
+	view := domain.NewAccountTransaction(deposit, account)
+	balance := domain.Money(12050)
+	view.RunningBalance = &balance
+	data, err = json.Marshal(view)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !strings.HasSuffix(string(data), `"direction":"credit","signed_amount":"2.50","counterparty_account_id":null,"counterparty_user_id":null,"running_balance":"120.50"}`) {
+		t.Errorf("Expected a credit without a counterparty, got %s", data)
+	}
+}
+
+func TestRunningBalances(t *testing.T) {
+	alice, bob := "alice", "bob"
+	transactions := []*domain.Transaction{
+		{ID: "newest", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 500, Status: domain.TransactionStatusCompleted},
+		{ID: "pending", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 200, Status: domain.TransactionStatusPending},
+		{ID: "transfer", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 1000, Status: domain.TransactionStatusCompleted},
+		{ID: "oldest", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 300, Status: domain.TransactionStatusCompleted},
+	}
+
+	balances := domain.RunningBalances("alice", 8000, transactions)
+
+	expected := map[string]domain.Money{"newest": 8000, "pending": 7500, "transfer": 7500, "oldest": 8500}
+	for id, balance := range expected {
+		if balances[id] != balance {
+			t.Errorf("Expected balance %d after %s, got %d", balance, id, balances[id])
+		}
+	}
+}
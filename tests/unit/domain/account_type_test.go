@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestAccountType_IsValid(t *testing.T) {
+	for _, accountType := range domain.AccountTypes {
+		if !accountType.IsValid() {
+			t.Errorf("Expected %s to be valid", accountType)
+		}
+	}
+	for _, accountType := range []domain.AccountType{"", "Checking", "brokerage"} {
+		if accountType.IsValid() {
+			t.Errorf("Expected %q to be invalid", accountType)
+		}
+	}
+}
+
+func TestAccountType_IsInternal(t *testing.T) {
+	tests := []struct {
+		accountType domain.AccountType
+		expected    bool
+	}{
+		{domain.AccountTypeChecking, false},
+		{domain.AccountTypeSavings, false},
+		{domain.AccountTypeFeeCollection, true},
+		{domain.AccountTypeSettlement, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.accountType.IsInternal(); got != tt.expected {
+			t.Errorf("Expected IsInternal() of %s to be %v, got %v", tt.accountType, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestBalanceSnapshot_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(domain.BalanceSnapshot{
+		AccountID: "a1",
+		AsOfDate:  time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC),
+		Balance:   10300,
+		Currency:  "USD",
+		CreatedAt: time.Date(2024, time.March, 4, 0, 5, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `{"account_id":"a1","currency":"USD","created_at":"2024-03-04T00:05:00Z","as_of_date":"2024-03-03","balance":"103.00"}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
+
+func TestSnapshotDay(t *testing.T) {
+	day := domain.SnapshotDay(time.Date(2024, time.March, 3, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)))
+
+	if expected := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC); !day.Equal(expected) {
+		t.Errorf("Expected the UTC day %v, got %v", expected, day)
+	}
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestBatchRequest_IsValid(t *testing.T) {
+	alice, bob := "alice", "bob"
+	transfer := func() *domain.TransactionRequest {
+		return &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 100, Currency: "usd"}
+	}
+
+	valid := &domain.BatchRequest{Legs: []*domain.TransactionRequest{transfer(), transfer()}}
+	if err := valid.IsValid(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if valid.Legs[1].Currency != "USD" {
+		t.Errorf("Expected leg currencies normalized, got %s", valid.Legs[1].Currency)
+	}
+
+	if err := (&domain.BatchRequest{}).IsValid(); err != domain.ErrInvalidBatch {
+		t.Errorf("Expected %v for an empty batch, got %v", domain.ErrInvalidBatch, err)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*domain.TransactionRequest)
+		expected error
+	}{
+		{"zero amount", func(r *domain.TransactionRequest) { r.Amount = 0 }, domain.ErrInvalidAmount},
+		{"verification", func(r *domain.TransactionRequest) { r.Type = domain.TransactionTypeVerification }, domain.ErrInvalidTransactionType},
+		{"scheduled", func(r *domain.TransactionRequest) { at := time.Now().Add(time.Hour); r.ScheduledAt = &at }, domain.ErrInvalidSchedule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leg := transfer()
+			tt.modify(leg)
+			err := (&domain.BatchRequest{Legs: []*domain.TransactionRequest{transfer(), leg}}).IsValid()
+
+			var legErr *domain.BatchLegError
+			if !errors.As(err, &legErr) || legErr.Index != 1 || !errors.Is(err, tt.expected) {
+				t.Errorf("Expected leg 1 rejected with %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestBatchRequest_Debits(t *testing.T) {
+	alice, bob, carol := "alice", "bob", "carol"
+	batch := &domain.BatchRequest{Legs: []*domain.TransactionRequest{
+		{Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 100},
+		{Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &carol, Amount: 250},
+		{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 1000},
+		{Type: domain.TransactionTypeWithdrawal, FromAccountID: &bob, Amount: 40},
+	}}
+
+	debits := batch.Debits()
+	if len(debits) != 2 || debits["alice"] != 350 || debits["bob"] != 40 {
+		t.Errorf("Unexpected debits %v", debits)
+	}
+}
+
+func TestNewTransactionBatch_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []domain.TransactionStatus
+		expected domain.TransactionStatus
+	}{
+		{"queued", []domain.TransactionStatus{domain.TransactionStatusPending, domain.TransactionStatusPending}, domain.TransactionStatusPending},
+		{"part applied", []domain.TransactionStatus{domain.TransactionStatusCompleted, domain.TransactionStatusPending}, domain.TransactionStatusProcessing},
+		{"all applied", []domain.TransactionStatus{domain.TransactionStatusCompleted, domain.TransactionStatusCompleted}, domain.TransactionStatusCompleted},
+		{"one failed", []domain.TransactionStatus{domain.TransactionStatusCompleted, domain.TransactionStatusFailed}, domain.TransactionStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var legs []*domain.Transaction
+			for _, status := range tt.statuses {
+				legs = append(legs, &domain.Transaction{Status: status})
+			}
+			if got := domain.NewTransactionBatch("b", legs).Status; got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestChangeCursor_EncodeDecode(t *testing.T) {
+	cursor := domain.ChangeCursor{
+		UpdatedAt: time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC),
+		Entity:    domain.ChangeEntityTransaction,
+		ID:        "tx|with-separator",
+	}
+
+	decoded, err := domain.DecodeChangeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !decoded.UpdatedAt.Equal(cursor.UpdatedAt) || decoded.Entity != cursor.Entity || decoded.ID != cursor.ID {
+		t.Errorf("Expected %+v, got %+v", cursor, decoded)
+	}
+
+	start, err := domain.DecodeChangeCursor("")
+	if err != nil || !start.IsZero() {
+		t.Errorf("Expected empty token to decode to the start of the feed, got %+v, %v", start, err)
+	}
+
+	for _, token := range []string{"not-hex", "6162", "787c6163636f756e747c6964"} {
+		if _, err := domain.DecodeChangeCursor(token); err != domain.ErrInvalidCursor {
+			t.Errorf("Expected %v for token %q, got %v", domain.ErrInvalidCursor, token, err)
+		}
+	}
+}
+
+func TestChangeCursor_TokensFollowFeedOrder(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ordered := []domain.ChangeCursor{
+		{UpdatedAt: at, Entity: domain.ChangeEntityAccount, ID: "b"},
+		{UpdatedAt: at, Entity: domain.ChangeEntityAccount, ID: "c"},
+		{UpdatedAt: at, Entity: domain.ChangeEntityTransaction, ID: "a"},
+		{UpdatedAt: at.Add(time.Nanosecond), Entity: domain.ChangeEntityAccount, ID: "a"},
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		if !ordered[i-1].Less(ordered[i]) {
+			t.Errorf("Expected %+v before %+v", ordered[i-1], ordered[i])
+		}
+		if ordered[i-1].Encode() >= ordered[i].Encode() {
+			t.Errorf("Expected token of %+v to sort before %+v", ordered[i-1], ordered[i])
+		}
+	}
+}
+
+func TestChangeCursor_TieBreak(t *testing.T) {
+	cursor := domain.ChangeCursor{UpdatedAt: time.Now(), Entity: domain.ChangeEntityAccount, ID: "m"}
+
+	if includeTies, afterID := cursor.TieBreak(domain.ChangeEntityAccount); !includeTies || afterID != "m" {
+		t.Errorf("Expected same-entity ties after m, got %v %q", includeTies, afterID)
+	}
+	if includeTies, afterID := cursor.TieBreak(domain.ChangeEntityTransaction); !includeTies || afterID != "" {
+		t.Errorf("Expected all later-entity ties, got %v %q", includeTies, afterID)
+	}
+
+	cursor.Entity = domain.ChangeEntityTransaction
+	if includeTies, _ := cursor.TieBreak(domain.ChangeEntityAccount); includeTies {
+		t.Errorf("Expected no earlier-entity ties")
+	}
+}
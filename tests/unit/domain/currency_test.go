@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestCurrencyRegistry_Normalize(t *testing.T) {
+	tests := []struct {
+		code          string
+		expected      string
+		expectedError error
+	}{
+		{"USD", "USD", nil},
+		{"usd", "USD", nil},
+		{" eur ", "EUR", nil},
+		{"XXX", "", domain.ErrUnsupportedCurrency},
+		{"ABC", "", domain.ErrUnsupportedCurrency},
+		{"", "", domain.ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		got, err := domain.ISO4217.Normalize(tt.code)
+		if err != tt.expectedError {
+			t.Errorf("Normalize(%q): expected error %v, got %v", tt.code, tt.expectedError, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Normalize(%q): expected %q, got %q", tt.code, tt.expected, got)
+		}
+	}
+}
+
+func TestCurrencyRegistry_Only(t *testing.T) {
+	registry := domain.ISO4217.Only("usd", "EUR", "XXX")
+
+	if codes := registry.Codes(); len(codes) != 2 || codes[0] != "EUR" || codes[1] != "USD" {
+		t.Fatalf("Expected [EUR USD], got %v", codes)
+	}
+	if _, err := registry.Normalize("GBP"); err != domain.ErrUnsupportedCurrency {
+		t.Errorf("Expected GBP to be rejected, got %v", err)
+	}
+	if currency, ok := registry.Lookup("usd"); !ok || currency.Exponent != 2 {
+		t.Errorf("Expected USD with exponent 2, got %+v", currency)
+	}
+}
+
+func TestTransactionRequest_IsValidNormalizesCurrency(t *testing.T) {
+	accountID := "account1"
+	request := domain.TransactionRequest{
+		Type:        domain.TransactionTypeDeposit,
+		ToAccountID: &accountID,
+		Amount:      100,
+		Currency:    "gbp",
+	}
+
+	if err := request.IsValid(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if request.Currency != "GBP" {
+		t.Errorf("Expected currency GBP, got %s", request.Currency)
+	}
+}
+
+func TestCurrencyExponent(t *testing.T) {
+	tests := map[string]int{"USD": 2, "JPY": 0, "KWD": 3, "jpy": 0}
+
+	for code, expected := range tests {
+		if got := domain.CurrencyExponent(code); got != expected {
+			t.Errorf("CurrencyExponent(%q): expected %d, got %d", code, expected, got)
+		}
+	}
+}
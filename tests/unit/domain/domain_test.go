@@ -3,9 +3,12 @@ package domain_test
 import (
 	"banking-ledger/internal/domain"
 	"testing"
+	"time"
 )
 
 func TestTransactionRequest_IsValid(t *testing.T) {
+	scheduledAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
 	tests := []struct {
 		name        string
 		request     domain.TransactionRequest
@@ -43,6 +46,17 @@ func TestTransactionRequest_IsValid(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "unsupported currency",
+			request: domain.TransactionRequest{
+				Type:        domain.TransactionTypeDeposit,
+				ToAccountID: stringPtr("account1"),
+				Amount:      100.0,
+				Currency:    "XXX",
+			},
+			expectError: true,
+			expectedErr: domain.ErrUnsupportedCurrency,
+		},
 		{
 			name: "invalid amount - zero",
 			request: domain.TransactionRequest{
@@ -129,6 +143,72 @@ func TestTransactionRequest_IsValid(t *testing.T) {
 			expectError: true,
 			expectedErr: domain.ErrSameAccount,
 		},
+		{
+			name: "valid zero-amount verification",
+			request: domain.TransactionRequest{
+				Type:          domain.TransactionTypeVerification,
+				FromAccountID: stringPtr("account1"),
+				Amount:        0,
+				Currency:      "USD",
+			},
+			expectError: false,
+		},
+		{
+			name: "verification with non-zero amount",
+			request: domain.TransactionRequest{
+				Type:        domain.TransactionTypeVerification,
+				ToAccountID: stringPtr("account1"),
+				Amount:      1.0,
+				Currency:    "USD",
+			},
+			expectError: true,
+			expectedErr: domain.ErrInvalidAmount,
+		},
+		{
+			name: "scheduled verification",
+			request: domain.TransactionRequest{
+				Type:          domain.TransactionTypeVerification,
+				FromAccountID: stringPtr("account1"),
+				Amount:        0,
+				Currency:      "USD",
+				ScheduledAt:   &scheduledAt,
+			},
+			expectError: true,
+			expectedErr: domain.ErrInvalidSchedule,
+		},
+		{
+			name: "verification missing account",
+			request: domain.TransactionRequest{
+				Type:     domain.TransactionTypeVerification,
+				Amount:   0,
+				Currency: "USD",
+			},
+			expectError: true,
+			expectedErr: domain.ErrMissingAccounts,
+		},
+		{
+			name: "valid reversal",
+			request: domain.TransactionRequest{
+				Type:                  domain.TransactionTypeReversal,
+				FromAccountID:         stringPtr("account2"),
+				ToAccountID:           stringPtr("account1"),
+				Amount:                75.0,
+				Currency:              "USD",
+				ReversedTransactionID: "tx1",
+			},
+			expectError: false,
+		},
+		{
+			name: "reversal without original",
+			request: domain.TransactionRequest{
+				Type:        domain.TransactionTypeReversal,
+				ToAccountID: stringPtr("account1"),
+				Amount:      75.0,
+				Currency:    "USD",
+			},
+			expectError: true,
+			expectedErr: domain.ErrTransactionNotReversible,
+		},
 		{
 			name: "invalid transaction type",
 			request: domain.TransactionRequest{
@@ -163,6 +243,30 @@ func TestTransactionRequest_IsValid(t *testing.T) {
 	}
 }
 
+func TestTransactionFilter_ExcludesVerifications(t *testing.T) {
+	verification := domain.TransactionTypeVerification
+	deposit := domain.TransactionTypeDeposit
+
+	tests := []struct {
+		name     string
+		filter   domain.TransactionFilter
+		excludes bool
+	}{
+		{"default filter", domain.TransactionFilter{}, true},
+		{"explicit include", domain.TransactionFilter{IncludeVerifications: true}, false},
+		{"filtered by verification type", domain.TransactionFilter{Type: &verification}, false},
+		{"filtered by other type", domain.TransactionFilter{Type: &deposit}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.ExcludesVerifications(); got != tt.excludes {
+				t.Errorf("Expected ExcludesVerifications %v, got %v", tt.excludes, got)
+			}
+		})
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
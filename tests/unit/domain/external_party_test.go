@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestTransactionRequest_ExternalParties(t *testing.T) {
+	alice, bob := "alice", "bob"
+	bank := func() *domain.ExternalParty {
+		return &domain.ExternalParty{Type: domain.ExternalPartyBankAccount, Reference: "GB29NWBK60161331926819"}
+	}
+
+	tests := []struct {
+		name     string
+		request  *domain.TransactionRequest
+		expected error
+	}{
+		{"deposit with source", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD", ExternalSource: bank()}, nil},
+		{"withdrawal with destination", &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 100, Currency: "USD", ExternalDestination: bank()}, nil},
+		{"deposit with destination", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD", ExternalDestination: bank()}, domain.ErrInvalidExternalParty},
+		{"withdrawal with source", &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 100, Currency: "USD", ExternalSource: bank()}, domain.ErrInvalidExternalParty},
+		{"transfer with source", &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 100, Currency: "USD", ExternalSource: bank()}, domain.ErrInvalidExternalParty},
+		{"unknown type", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD", ExternalSource: &domain.ExternalParty{Type: "cheque", Reference: "1"}}, domain.ErrInvalidExternalParty},
+		{"missing reference", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD", ExternalSource: &domain.ExternalParty{Type: domain.ExternalPartyCard}}, domain.ErrInvalidExternalParty},
+		{"reference too long", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD", ExternalSource: &domain.ExternalParty{Type: domain.ExternalPartyCard, Reference: strings.Repeat("x", domain.MaxExternalReferenceLength+1)}}, domain.ErrInvalidExternalParty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.request.IsValid(); err != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestTransactionRequest_MissingExternalParty(t *testing.T) {
+	alice, bob := "alice", "bob"
+	source := &domain.ExternalParty{Type: domain.ExternalPartyCash, Reference: "branch-12"}
+
+	tests := []struct {
+		name     string
+		request  *domain.TransactionRequest
+		expected bool
+	}{
+		{"deposit without source", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice}, true},
+		{"deposit with source", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, ExternalSource: source}, false},
+		{"withdrawal without destination", &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice}, true},
+		{"system deposit", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, System: true}, false},
+		{"transfer", &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if missing := tt.request.MissingExternalParty(); missing != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, missing)
+			}
+		})
+	}
+}
+
+func TestTransactionRequest_PayloadFingerprintCoversExternalParties(t *testing.T) {
+	alice := "alice"
+	request := &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD"}
+	plain := request.PayloadFingerprint()
+
+	request.ExternalSource = &domain.ExternalParty{Type: domain.ExternalPartyCard, Reference: "tok_1"}
+	withCard := request.PayloadFingerprint()
+	request.ExternalSource = &domain.ExternalParty{Type: domain.ExternalPartyCard, Reference: "tok_2"}
+
+	if plain == withCard || withCard == request.PayloadFingerprint() {
+		t.Error("Expected the external source to change the payload fingerprint")
+	}
+}
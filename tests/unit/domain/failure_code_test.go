@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+// TestFailureCodes_CoverEveryDomainError parses errors.go and requires every
+// declared Err* sentinel to be classified in the failureCodes table
+func TestFailureCodes_CoverEveryDomainError(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "../../../internal/domain/errors.go", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse errors.go: %v", err)
+	}
+
+	declared := map[string]bool{}
+	classified := map[string]bool{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, name := range value.Names {
+				switch {
+				case strings.HasPrefix(name.Name, "Err"):
+					declared[name.Name] = true
+				case name.Name == "failureCodes":
+					ast.Inspect(value.Values[i], func(n ast.Node) bool {
+						if ident, ok := n.(*ast.Ident); ok && strings.HasPrefix(ident.Name, "Err") {
+							classified[ident.Name] = true
+						}
+						return true
+					})
+				}
+			}
+		}
+	}
+
+	if len(declared) == 0 {
+		t.Fatal("Expected to find domain errors in errors.go")
+	}
+	for name := range declared {
+		if !classified[name] {
+			t.Errorf("Domain error %s has no failure code mapping", name)
+		}
+	}
+}
+
+func TestFailureCodeFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected domain.FailureCode
+	}{
+		{"insufficient funds", domain.ErrInsufficientFunds, domain.FailureCodeInsufficientFunds},
+		{"wrapped conflict", fmt.Errorf("failed to update account balance: %w", domain.ErrConcurrentUpdate), domain.FailureCodeConcurrentConflict},
+		{"inactive account", domain.ErrAccountInactive, domain.FailureCodeAccountInactive},
+		{"closed account", domain.ErrAccountClosed, domain.FailureCodeAccountClosed},
+		{"currency mismatch", domain.ErrCurrencyMismatch, domain.FailureCodeCurrencyMismatch},
+		{"queue error", domain.ErrQueueError, domain.FailureCodeQueueError},
+		{"expired", domain.ErrTransactionExpired, domain.FailureCodeExpired},
+		{"withdrawal limit", domain.ErrWithdrawalLimitExceeded, domain.FailureCodeLimitExceeded},
+		{"restricted account", domain.ErrAccountRestricted, domain.FailureCodeAccountRestricted},
+		{"unknown error", fmt.Errorf("connection reset"), domain.FailureCodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := domain.FailureCodeFor(tt.err)
+			if code != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, code)
+			}
+			if !code.IsValid() {
+				t.Errorf("Expected %s to be a valid failure code", code)
+			}
+		})
+	}
+}
+
+func TestFailureCodeFromMessage(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected domain.FailureCode
+	}{
+		{"insufficient funds", domain.FailureCodeInsufficientFunds},
+		{"failed to update account balance: concurrent update detected", domain.FailureCodeConcurrentConflict},
+		{"failed to publish message: channel/connection is not open", domain.FailureCodeQueueError},
+		{"something unexpected", domain.FailureCodeInternal},
+	}
+
+	for _, tt := range tests {
+		if code := domain.FailureCodeFromMessage(tt.message); code != tt.expected {
+			t.Errorf("Expected %s for %q, got %s", tt.expected, tt.message, code)
+		}
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"insufficient funds", &domain.InsufficientFundsError{Currency: "USD"}, true},
+		{"account not found", domain.ErrAccountNotFound, true},
+		{"inactive account", domain.ErrAccountInactive, true},
+		{"currency mismatch", domain.ErrCurrencyMismatch, true},
+		{"invalid amount", fmt.Errorf("validation failed: %w", domain.ErrInvalidAmount), true},
+		{"marked permanent", &domain.PermanentError{Err: fmt.Errorf("unexpected end of JSON input")}, true},
+		{"concurrent update", fmt.Errorf("failed to update account balance: %w", domain.ErrConcurrentUpdate), false},
+		{"database error", domain.ErrDatabaseError, false},
+		{"queue error", domain.ErrQueueError, false},
+		{"unknown error", fmt.Errorf("connection reset"), false},
+		{"no error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if permanent := domain.IsPermanent(tt.err); permanent != tt.expected {
+				t.Errorf("Expected IsPermanent to be %v, got %v", tt.expected, permanent)
+			}
+		})
+	}
+}
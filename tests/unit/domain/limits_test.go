@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestTransactionLimits_Check(t *testing.T) {
+	limits := domain.TransactionLimits{MaxAmount: 1_000_000, MaxMetadataKeys: 3, MaxMetadataBytes: 64}
+	accountID := "account1"
+
+	// {"note":"..."} encodes to 11 bytes plus the value
+	note := func(length int) map[string]interface{} {
+		return map[string]interface{}{"note": strings.Repeat("x", length)}
+	}
+	keys := func(count int) map[string]interface{} {
+		metadata := make(map[string]interface{}, count)
+		for i := 0; i < count; i++ {
+			metadata["k"+strconv.Itoa(i)] = i
+		}
+		return metadata
+	}
+
+	tests := []struct {
+		name          string
+		amount        domain.Money
+		currency      string
+		metadata      map[string]interface{}
+		expectedError error
+	}{
+		{"amount at the limit", 100_000_000, "USD", nil, nil},
+		{"amount just over the limit", 100_000_001, "USD", nil, domain.ErrAmountTooLarge},
+		{"zero exponent at the limit", 1_000_000, "JPY", nil, nil},
+		{"zero exponent just over the limit", 1_000_001, "JPY", nil, domain.ErrAmountTooLarge},
+		{"metadata keys at the limit", 100, "USD", keys(3), nil},
+		{"metadata keys over the limit", 100, "USD", keys(4), domain.ErrMetadataTooLarge},
+		{"metadata size at the limit", 100, "USD", note(53), nil},
+		{"metadata size just over the limit", 100, "USD", note(54), domain.ErrMetadataTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &domain.TransactionRequest{
+				Type:        domain.TransactionTypeDeposit,
+				ToAccountID: &accountID,
+				Amount:      tt.amount,
+				Currency:    tt.currency,
+				Metadata:    tt.metadata,
+			}
+
+			if err := limits.Check(request); err != tt.expectedError {
+				t.Errorf("Expected error %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestTransactionLimits_ReversalsIgnoreAmountLimit(t *testing.T) {
+	limits := domain.TransactionLimits{MaxAmount: 100}
+	accountID := "account1"
+
+	request := &domain.TransactionRequest{
+		Type:                  domain.TransactionTypeReversal,
+		FromAccountID:         &accountID,
+		Amount:                50_000,
+		Currency:              "USD",
+		ReversedTransactionID: "tx1",
+	}
+
+	if err := limits.Check(request); err != nil {
+		t.Errorf("Expected reversal to ignore the amount limit, got %v", err)
+	}
+}
+
+func TestTransactionRequest_IsValidEnforcesActiveLimits(t *testing.T) {
+	previous := domain.ActiveTransactionLimits
+	defer func() { domain.ActiveTransactionLimits = previous }()
+	domain.ActiveTransactionLimits = domain.TransactionLimits{MaxAmount: 10}
+
+	accountID := "account1"
+	request := &domain.TransactionRequest{
+		Type:        domain.TransactionTypeDeposit,
+		ToAccountID: &accountID,
+		Amount:      1001,
+		Currency:    "USD",
+	}
+
+	if err := request.IsValid(); err != domain.ErrAmountTooLarge {
+		t.Errorf("Expected ErrAmountTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestGenerateMicroDepositAmounts(t *testing.T) {
+	source := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		amounts, err := domain.GenerateMicroDepositAmounts(source)
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		for _, amount := range amounts {
+			if amount < 1 || amount > 99 {
+				t.Fatalf("Expected amount between 1 and 99 minor units, got %d", amount)
+			}
+		}
+		if amounts[0] == amounts[1] {
+			t.Fatalf("Expected distinct amounts, got %v", amounts)
+		}
+	}
+}
+
+func TestGenerateMicroDepositAmounts_RedrawsDuplicates(t *testing.T) {
+	// Identical bytes draw the same cents twice before a different value
+	source := bytes.NewReader([]byte{7, 7, 9})
+
+	amounts, err := domain.GenerateMicroDepositAmounts(source)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if amounts[0] == amounts[1] {
+		t.Errorf("Expected the duplicate draw to be replaced, got %v", amounts)
+	}
+}
+
+func TestMicroDepositChallenge_Matches(t *testing.T) {
+	challenge := &domain.MicroDepositChallenge{AmountOne: 12, AmountTwo: 30}
+
+	tests := []struct {
+		name     string
+		amounts  []domain.Money
+		expected bool
+	}{
+		{"same order", []domain.Money{12, 30}, true},
+		{"reversed order", []domain.Money{30, 12}, true},
+		{"wrong amount", []domain.Money{12, 31}, false},
+		{"same amount twice", []domain.Money{12, 12}, false},
+		{"single amount", []domain.Money{12}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := challenge.Matches(tt.amounts); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMicroDepositChallenge_IsExpired(t *testing.T) {
+	expiresAt := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	challenge := &domain.MicroDepositChallenge{ExpiresAt: expiresAt}
+
+	if challenge.IsExpired(expiresAt.Add(-time.Second)) {
+		t.Errorf("Expected challenge to be open before expiry")
+	}
+	if !challenge.IsExpired(expiresAt) {
+		t.Errorf("Expected challenge to be expired at expiry")
+	}
+}
@@ -0,0 +1,245 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		currency      string
+		expected      domain.Money
+		expectedError error
+	}{
+		{"two decimals", "10.25", "USD", 1025, nil},
+		{"whole amount", "10", "USD", 1000, nil},
+		{"single decimal", "0.5", "USD", 50, nil},
+		{"trailing zeros", "10.2500", "USD", 1025, nil},
+		{"negative", "-3.10", "EUR", -310, nil},
+		{"zero exponent", "1500", "JPY", 1500, nil},
+		{"three decimals", "1.234", "KWD", 1234, nil},
+		{"smallest unit", "0.01", "USD", 1, nil},
+		{"below smallest unit", "0.001", "USD", 0, domain.ErrInvalidPrecision},
+		{"too precise", "10.255", "USD", 0, domain.ErrInvalidPrecision},
+		{"many decimals", "10.123456789", "USD", 0, domain.ErrInvalidPrecision},
+		{"decimals on zero exponent", "1500.5", "JPY", 0, domain.ErrInvalidPrecision},
+		{"small fraction on zero exponent", "0.01", "JPY", 0, domain.ErrInvalidPrecision},
+		{"zero fraction on zero exponent", "1500.0", "JPY", 1500, nil},
+		{"empty", "", "USD", 0, domain.ErrInvalidAmount},
+		{"letters", "ten", "USD", 0, domain.ErrInvalidAmount},
+		{"dangling point", "10.", "USD", 0, domain.ErrInvalidAmount},
+		{"too large", "100000000000000000", "USD", 0, domain.ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ParseMoney(tt.value, tt.currency)
+			if !errors.Is(err, tt.expectedError) || (err == nil) != (tt.expectedError == nil) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+	tests := []struct {
+		amount   domain.Money
+		currency string
+		expected string
+	}{
+		{1025, "USD", "10.25"},
+		{5, "USD", "0.05"},
+		{-310, "EUR", "-3.10"},
+		{1500, "JPY", "1500"},
+		{1234, "KWD", "1.234"},
+		{0, "USD", "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.amount.Format(tt.currency); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestMoney_AddsWithoutDrift(t *testing.T) {
+	var total domain.Money
+	for i := 0; i < 10; i++ {
+		total += 10 // 0.10 USD
+	}
+
+	if total.Format("USD") != "1.00" {
+		t.Errorf("Expected 1.00, got %s", total.Format("USD"))
+	}
+}
+
+func TestTransactionRequest_JSON(t *testing.T) {
+	request := domain.TransactionRequest{Type: domain.TransactionTypeDeposit, Amount: 1025, Currency: "USD"}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if fields["amount"] != "10.25" {
+		t.Errorf("Expected amount to be emitted as \"10.25\", got %v", fields["amount"])
+	}
+
+	var decoded domain.TransactionRequest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if decoded.Amount != 1025 {
+		t.Errorf("Expected amount 1025, got %d", decoded.Amount)
+	}
+}
+
+func TestAccount_LimitsJSON(t *testing.T) {
+	account := domain.Account{ID: "acc-1", Currency: "USD", AccountLimits: domain.AccountLimits{DailyOutgoing: 50000}}
+
+	data, err := json.Marshal(account)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	var fields struct {
+		Limits map[string]interface{} `json:"limits"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(fields.Limits) != 1 || fields.Limits["daily_outgoing"] != "500.00" {
+		t.Errorf("Expected only the daily limit emitted as \"500.00\", got %v", fields.Limits)
+	}
+
+	var decoded domain.Account
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if decoded.AccountLimits != account.AccountLimits {
+		t.Errorf("Expected limits %+v, got %+v", account.AccountLimits, decoded.AccountLimits)
+	}
+}
+
+func TestTransactionRequest_JSONAcceptsNumbers(t *testing.T) {
+	var request domain.TransactionRequest
+	if err := json.Unmarshal([]byte(`{"type":"deposit","amount":10.25,"currency":"USD"}`), &request); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if request.Amount != 1025 {
+		t.Errorf("Expected amount 1025, got %d", request.Amount)
+	}
+
+	err := json.Unmarshal([]byte(`{"type":"deposit","amount":"10.255","currency":"USD"}`), &request)
+	if !errors.Is(err, domain.ErrInvalidPrecision) {
+		t.Errorf("Expected ErrInvalidPrecision, got %v", err)
+	}
+}
+
+func TestParseMoney_PrecisionError(t *testing.T) {
+	_, err := domain.ParseMoney("1.5", "jpy")
+
+	var precisionErr *domain.PrecisionError
+	if !errors.As(err, &precisionErr) {
+		t.Fatalf("Expected PrecisionError, got %v", err)
+	}
+	if precisionErr.Currency != "JPY" || precisionErr.Exponent != 0 {
+		t.Errorf("Expected JPY with exponent 0, got %s with %d", precisionErr.Currency, precisionErr.Exponent)
+	}
+}
+
+func TestDecimal_Bound(t *testing.T) {
+	tests := []struct {
+		value    domain.Decimal
+		exponent int
+		lower    bool
+		expected domain.Money
+	}{
+		{"10.25", 2, true, 1025},
+		{"10.255", 2, true, 1026},
+		{"10.255", 2, false, 1025},
+		{"0.29", 2, false, 29},
+		{"10.5", 0, true, 11},
+		{"10.5", 0, false, 10},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.value.Bound(tt.exponent, tt.lower)
+		if !ok || got != tt.expected {
+			t.Errorf("Bound(%s, %d, %v): expected %d, got %d (ok %v)", tt.value, tt.exponent, tt.lower, tt.expected, got, ok)
+		}
+	}
+
+	if _, ok := domain.Decimal("abc").Bound(2, true); ok {
+		t.Errorf("Expected a malformed decimal to be rejected")
+	}
+}
+
+func TestDecimal_Compare(t *testing.T) {
+	tests := []struct {
+		value, other domain.Decimal
+		expected     int
+	}{
+		{"10.5", "10.49", 1},
+		{"10.50", "10.5", 0},
+		{"1.9", "2", -1},
+		{"-3", "0", -1},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.value.Compare(tt.other)
+		if !ok || got != tt.expected {
+			t.Errorf("Compare(%s, %s): expected %d, got %d (ok %v)", tt.value, tt.other, tt.expected, got, ok)
+		}
+	}
+
+	if _, ok := domain.Decimal("1").Compare("1e3"); ok {
+		t.Errorf("Expected a malformed decimal to be rejected")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name          string
+		amount        domain.Money
+		from, to      string
+		rate          domain.Decimal
+		expected      domain.Money
+		expectedError error
+	}{
+		{"same precision", 10000, "EUR", "USD", "1.0842", 10842, nil},
+		{"rounds half up", 1, "EUR", "USD", "1.5", 2, nil},
+		{"rounds down below half", 3, "EUR", "USD", "1.1", 3, nil},
+		{"into zero exponent", 1050, "USD", "JPY", "150.123", 1576, nil},
+		{"from zero exponent", 1000, "JPY", "USD", "0.0067", 670, nil},
+		{"into three decimals", 10000, "USD", "KWD", "0.3075", 30750, nil},
+		{"zero rate", 10000, "EUR", "USD", "0", 0, domain.ErrRateUnavailable},
+		{"negative rate", 10000, "EUR", "USD", "-1.1", 0, domain.ErrRateUnavailable},
+		{"malformed rate", 10000, "EUR", "USD", "abc", 0, domain.ErrRateUnavailable},
+		{"exponent notation", 10000, "EUR", "USD", "1e2", 0, domain.ErrRateUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converted, err := domain.Convert(tt.amount, tt.from, tt.to, tt.rate)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if converted != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, converted)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestTransaction_Movements(t *testing.T) {
+	from, to := "a1", "a2"
+
+	tests := []struct {
+		name        string
+		transaction *domain.Transaction
+		expected    map[string]domain.Money
+	}{
+		{
+			name:        "completed transfer",
+			transaction: &domain.Transaction{Type: domain.TransactionTypeTransfer, FromAccountID: &from, ToAccountID: &to, Amount: 500, Status: domain.TransactionStatusCompleted},
+			expected:    map[string]domain.Money{"a1": -500, "a2": 500},
+		},
+		{
+			name:        "completed deposit",
+			transaction: &domain.Transaction{Type: domain.TransactionTypeDeposit, ToAccountID: &to, Amount: 250, Status: domain.TransactionStatusCompleted},
+			expected:    map[string]domain.Money{"a2": 250},
+		},
+		{
+			name: "cross-currency transfer credits the converted amount",
+			transaction: &domain.Transaction{Type: domain.TransactionTypeTransfer, FromAccountID: &from, ToAccountID: &to, Amount: 1000, Status: domain.TransactionStatusCompleted,
+				Exchange: &domain.Exchange{CreditedAmount: 920, CreditedCurrency: "EUR"}},
+			expected: map[string]domain.Money{"a1": -1000, "a2": 920},
+		},
+		{
+			name: "compensated transfer keeps its debit",
+			transaction: &domain.Transaction{Type: domain.TransactionTypeTransfer, FromAccountID: &from, ToAccountID: &to, Amount: 300, Status: domain.TransactionStatusFailed,
+				Compensation: &domain.Compensation{TransactionID: "c1"}},
+			expected: map[string]domain.Money{"a1": -300},
+		},
+		{
+			name:        "failed transfer",
+			transaction: &domain.Transaction{Type: domain.TransactionTypeTransfer, FromAccountID: &from, ToAccountID: &to, Amount: 300, Status: domain.TransactionStatusFailed},
+			expected:    map[string]domain.Money{},
+		},
+		{
+			name:        "processing withdrawal",
+			transaction: &domain.Transaction{Type: domain.TransactionTypeWithdrawal, FromAccountID: &from, Amount: 300, Status: domain.TransactionStatusProcessing},
+			expected:    map[string]domain.Money{},
+		},
+		{
+			name:        "hold",
+			transaction: &domain.Transaction{Type: domain.TransactionTypeHold, FromAccountID: &from, Amount: 300, Status: domain.TransactionStatusCompleted},
+			expected:    map[string]domain.Money{},
+		},
+		{
+			name:        "external transfer",
+			transaction: &domain.Transaction{Type: domain.TransactionTypeExternalTransfer, FromAccountID: &from, Amount: 300, Status: domain.TransactionStatusCompleted},
+			expected:    map[string]domain.Money{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movements := tt.transaction.Movements()
+			if len(movements) != len(tt.expected) {
+				t.Fatalf("Expected movements %v, got %v", tt.expected, movements)
+			}
+			for accountID, amount := range tt.expected {
+				if movements[accountID] != amount {
+					t.Errorf("Expected movements %v, got %v", tt.expected, movements)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscrepancy_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(domain.Discrepancy{AccountID: "a1", Currency: "USD", Expected: 2000, Actual: 2700, Delta: 700})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := `{"account_id":"a1","currency":"USD","expected":"20.00","actual":"27.00","delta":"7.00"}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+func settlementTransfer(id, from, to string, amount domain.Money, currency string, processedAt time.Time) *domain.Transaction {
+	return &domain.Transaction{
+		ID:            id,
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &from,
+		ToAccountID:   &to,
+		Amount:        amount,
+		Currency:      currency,
+		Status:        domain.TransactionStatusCompleted,
+		CreatedAt:     processedAt,
+		ProcessedAt:   &processedAt,
+	}
+}
+
+func TestComputeSettlementNet(t *testing.T) {
+	group := &domain.SettlementGroup{ID: "grp-1", AccountIDs: []string{"a1", "a2"}}
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	midday := day.Add(12 * time.Hour)
+
+	pending := settlementTransfer("tx-pending", "x1", "a1", 40.0, "USD", midday)
+	pending.Status = domain.TransactionStatusPending
+
+	transactions := []*domain.Transaction{
+		settlementTransfer("tx-in", "x1", "a1", 100.0, "USD", midday),
+		settlementTransfer("tx-out", "a2", "x2", 30.0, "USD", midday),
+		settlementTransfer("tx-eur", "a1", "x1", 20.0, "EUR", midday),
+		settlementTransfer("tx-internal", "a1", "a2", 500.0, "USD", midday),
+		settlementTransfer("tx-outside", "x1", "x2", 70.0, "USD", midday),
+		settlementTransfer("tx-yesterday", "x1", "a1", 60.0, "USD", day.Add(-time.Second)),
+		settlementTransfer("tx-tomorrow", "x1", "a1", 80.0, "USD", day.Add(24*time.Hour)),
+		pending,
+	}
+
+	net := domain.ComputeSettlementNet(group, midday, transactions)
+
+	if net.Date != "2024-03-01" {
+		t.Errorf("Expected date 2024-03-01, got %s", net.Date)
+	}
+	if len(net.Positions) != 2 {
+		t.Fatalf("Expected 2 positions, got %d", len(net.Positions))
+	}
+
+	eur, usd := net.Positions[0], net.Positions[1]
+	if eur.Currency != "EUR" || eur.Inflow != 0 || eur.Outflow != 20.0 || eur.Net != -20.0 || eur.TransactionCount != 1 {
+		t.Errorf("Unexpected EUR position %+v", eur)
+	}
+	if usd.Currency != "USD" || usd.Inflow != 100.0 || usd.Outflow != 30.0 || usd.Net != 70.0 || usd.TransactionCount != 2 {
+		t.Errorf("Unexpected USD position %+v", usd)
+	}
+
+	expectedIDs := []string{"tx-eur", "tx-in", "tx-out"}
+	if len(net.TransactionIDs) != len(expectedIDs) {
+		t.Fatalf("Expected transaction IDs %v, got %v", expectedIDs, net.TransactionIDs)
+	}
+	for i, id := range expectedIDs {
+		if net.TransactionIDs[i] != id {
+			t.Errorf("Expected transaction IDs %v, got %v", expectedIDs, net.TransactionIDs)
+			break
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestStandingOrder_RunAt(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		frequency domain.StandingOrderFrequency
+		interval  int
+		run       int
+		expected  time.Time
+	}{
+		{"first run is the start", domain.StandingOrderFrequencyMonthly, 1, 0, start},
+		{"monthly clamps to a leap February", domain.StandingOrderFrequencyMonthly, 1, 1, time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC)},
+		{"monthly returns to the start day", domain.StandingOrderFrequencyMonthly, 1, 2, time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC)},
+		{"monthly clamps to a 30-day month", domain.StandingOrderFrequencyMonthly, 1, 3, time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC)},
+		{"quarterly crosses the year", domain.StandingOrderFrequencyMonthly, 3, 4, time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC)},
+		{"every second week", domain.StandingOrderFrequencyWeekly, 2, 1, time.Date(2024, 2, 14, 9, 0, 0, 0, time.UTC)},
+		{"daily", domain.StandingOrderFrequencyDaily, 1, 1, time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &domain.StandingOrder{Frequency: tt.frequency, Interval: tt.interval, StartAt: start}
+			if got := order.RunAt(tt.run); !got.Equal(tt.expected) {
+				t.Errorf("Expected run %d at %v, got %v", tt.run, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStandingOrder_Advance(t *testing.T) {
+	start := time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC)
+	order := &domain.StandingOrder{
+		Frequency: domain.StandingOrderFrequencyMonthly,
+		Interval:  1,
+		StartAt:   start,
+		NextRunAt: start,
+		Status:    domain.StandingOrderStatusActive,
+	}
+
+	// Runs missed while the processor was down are skipped, not caught up
+	order.Advance(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	if order.RunCount != 3 || !order.NextRunAt.Equal(time.Date(2024, 4, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected run 3 on 25 April next, got run %d at %v", order.RunCount, order.NextRunAt)
+	}
+	if order.Status != domain.StandingOrderStatusActive {
+		t.Errorf("Expected the order to stay active, got %s", order.Status)
+	}
+
+	end := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	order.EndAt = &end
+	order.Advance(time.Date(2024, 4, 25, 0, 0, 0, 0, time.UTC))
+	if order.Status != domain.StandingOrderStatusCompleted {
+		t.Errorf("Expected the order to complete after its last run, got %s", order.Status)
+	}
+}
+
+func TestStandingOrderRequest_IsValid(t *testing.T) {
+	start := time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+
+	valid := func() domain.StandingOrderRequest {
+		return domain.StandingOrderRequest{
+			FromAccountID: "account1",
+			ToAccountID:   "account2",
+			Amount:        50000,
+			Currency:      "usd",
+			Frequency:     domain.StandingOrderFrequencyMonthly,
+			StartAt:       start,
+		}
+	}
+
+	request := valid()
+	if err := request.IsValid(); err != nil {
+		t.Fatalf("Expected valid request, got %v", err)
+	}
+	if request.Currency != "USD" || request.Interval != 1 {
+		t.Errorf("Expected currency USD and interval 1, got %s and %d", request.Currency, request.Interval)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(*domain.StandingOrderRequest)
+		expected error
+	}{
+		{"same account", func(r *domain.StandingOrderRequest) { r.ToAccountID = "account1" }, domain.ErrSameAccount},
+		{"missing account", func(r *domain.StandingOrderRequest) { r.FromAccountID = "" }, domain.ErrMissingAccounts},
+		{"zero amount", func(r *domain.StandingOrderRequest) { r.Amount = 0 }, domain.ErrInvalidAmount},
+		{"unknown frequency", func(r *domain.StandingOrderRequest) { r.Frequency = "yearly" }, domain.ErrInvalidStandingOrder},
+		{"negative interval", func(r *domain.StandingOrderRequest) { r.Interval = -1 }, domain.ErrInvalidStandingOrder},
+		{"ends before it starts", func(r *domain.StandingOrderRequest) { r.EndAt = &before }, domain.ErrInvalidStandingOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := valid()
+			tt.modify(&request)
+			if err := request.IsValid(); err != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
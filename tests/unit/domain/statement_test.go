@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestStatementPeriod(t *testing.T) {
+	now := time.Date(2024, 2, 14, 9, 0, 0, 0, time.UTC)
+	february := [2]time.Time{time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name     string
+		from, to string
+		expected [2]time.Time
+	}{
+		{"both dates", "2024-01-05", "2024-01-20", [2]time.Time{time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)}},
+		{"single day", "2024-01-05", "2024-01-05", [2]time.Time{time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC)}},
+		{"absent", "", "", february},
+		{"missing to", "2024-01-05", "", february},
+		{"invalid", "2024-13-01", "2024-01-20", february},
+		{"inverted", "2024-01-20", "2024-01-05", february},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := domain.StatementPeriod(tt.from, tt.to, now)
+			if !start.Equal(tt.expected[0]) || !end.Equal(tt.expected[1]) {
+				t.Errorf("Expected %v to %v, got %v to %v", tt.expected[0], tt.expected[1], start, end)
+			}
+		})
+	}
+}
+
+func TestAccountStatement_MarshalJSON(t *testing.T) {
+	statement := domain.AccountStatement{
+		AccountID:      "a1",
+		Currency:       "JPY",
+		From:           "2024-01-01",
+		To:             "2024-01-31",
+		OpeningBalance: 1000,
+		ClosingBalance: 400,
+		Lines:          []domain.StatementLine{{TransactionID: "t1", Amount: -600, Balance: 400}},
+	}
+
+	data, err := json.Marshal(statement)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	for _, fragment := range []string{`"opening_balance":"1000"`, `"closing_balance":"400"`, `"amount":"-600"`, `"balance":"400"`} {
+		if !strings.Contains(string(data), fragment) {
+			t.Errorf("Expected %s in %s", fragment, data)
+		}
+	}
+}
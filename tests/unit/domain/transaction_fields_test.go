@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestParseTransactionFields(t *testing.T) {
+	tests := []struct {
+		value   string
+		fields  []string
+		unknown []string
+	}{
+		{"id,amount,status", []string{"id", "amount", "status"}, nil},
+		{" id , ,amount,id", []string{"id", "amount"}, nil},
+		{"id,outbox,colour,colour", []string{"id"}, []string{"outbox", "colour"}},
+		{"", nil, nil},
+	}
+
+	for _, tt := range tests {
+		fields, unknown := domain.ParseTransactionFields(tt.value)
+		if fmt.Sprint(fields) != fmt.Sprint(tt.fields) || fmt.Sprint(unknown) != fmt.Sprint(tt.unknown) {
+			t.Errorf("Expected %v and unknown %v for %q, got %v and %v", tt.fields, tt.unknown, tt.value, fields, unknown)
+		}
+	}
+}
+
+func TestTransactionFieldNames_CoverTransaction(t *testing.T) {
+	names := domain.TransactionFieldNames()
+	transactionType := reflect.TypeOf(domain.Transaction{})
+	for i := 0; i < transactionType.NumField(); i++ {
+		name, _, _ := strings.Cut(transactionType.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected transaction field %s selectable in listings", name)
+		}
+	}
+}
+
+func TestTransactionProjection_DocumentFields(t *testing.T) {
+	included := (&domain.TransactionProjection{Include: []string{"id", "amount"}}).DocumentFields()
+	if fmt.Sprint(included) != "map[_id:1 amount:1 currency:1]" {
+		t.Errorf("Expected the ID and amount read with its currency, got %v", included)
+	}
+
+	excluded := domain.DefaultTransactionProjection().DocumentFields()
+	if fmt.Sprint(excluded) != "map[error_message:0 metadata:0]" {
+		t.Errorf("Expected metadata and error_message left out, got %v", excluded)
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tooMany := make([]string, domain.MaxTransactionTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+		err      error
+	}{
+		{"none", nil, nil, nil},
+		{"trimmed and lowercased", []string{" Groceries ", "eating_out"}, []string{"groceries", "eating_out"}, nil},
+		{"repeats dropped in order", []string{"rent", "Salary", "RENT"}, []string{"rent", "salary"}, nil},
+		{"repeats do not count", append(slices.Clone(tooMany[:domain.MaxTransactionTags]), "TAG-0"), tooMany[:domain.MaxTransactionTags], nil},
+		{"longest tag", []string{strings.Repeat("é", domain.MaxTransactionTagLength)}, []string{strings.Repeat("é", domain.MaxTransactionTagLength)}, nil},
+		{"blank tag", []string{"rent", "  "}, nil, domain.ErrInvalidTags},
+		{"tag too long", []string{strings.Repeat("a", domain.MaxTransactionTagLength+1)}, nil, domain.ErrInvalidTags},
+		{"punctuation", []string{"rent,march"}, nil, domain.ErrInvalidTags},
+		{"too many tags", tooMany, nil, domain.ErrInvalidTags},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := domain.NormalizeTags(tt.tags)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if fmt.Sprint(tags) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, tags)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event_id":"evt-1"}`)
+
+	// HMAC-SHA256 of the body keyed by "secret"
+	signature := domain.SignWebhook("secret", body)
+	if signature != "sha256=c68f5d253162efda5544f08a9dcff552b4a8db6b1c2d4f20b2ca4d97e460db41" {
+		t.Errorf("Expected the hex HMAC of the body, got %q", signature)
+	}
+
+	if !domain.VerifyWebhook("secret", body, signature) {
+		t.Error("Expected the signature to verify")
+	}
+	if domain.VerifyWebhook("other", body, signature) {
+		t.Error("Expected a signature under another secret to be rejected")
+	}
+	if domain.VerifyWebhook("secret", []byte(`{"event_id":"evt-2"}`), signature) {
+		t.Error("Expected a signature of another body to be rejected")
+	}
+}
+
+func TestWebhookSubscription_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []domain.NotificationType
+		valid      bool
+	}{
+		{"https", "https://crm.example.com/hooks", []domain.NotificationType{domain.NotificationAccountCreated}, true},
+		{"http", "http://localhost:9000", []domain.NotificationType{domain.NotificationTransactionFailed}, true},
+		{"no host", "https://", []domain.NotificationType{domain.NotificationAccountCreated}, false},
+		{"no event types", "https://crm.example.com", nil, false},
+		{"unknown event type", "https://crm.example.com", []domain.NotificationType{"transfer.done"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := &domain.WebhookSubscription{URL: tt.url, EventTypes: tt.eventTypes}
+			if err := subscription.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package exchange
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/exchange"
+)
+
+func TestLoadStaticRates(t *testing.T) {
+	quotedAt := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	provider, err := exchange.LoadStaticRates([]string{"eur:usd:1.0842", "USD:JPY:150.1"}, quotedAt)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	rate, at, err := provider.GetRate(context.Background(), "EUR", "USD")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if rate != "1.0842" || !at.Equal(quotedAt) {
+		t.Errorf("Expected 1.0842 quoted at %s, got %s at %s", quotedAt, rate, at)
+	}
+
+	// Rates are not inverted
+	if _, _, err := provider.GetRate(context.Background(), "USD", "EUR"); err == nil {
+		t.Error("Expected no USD to EUR rate")
+	}
+}
+
+func TestLoadStaticRates_Invalid(t *testing.T) {
+	for _, entry := range []string{"EUR:USD", "EUR:XYZ:1.1", "EUR:USD:0", "EUR:USD:abc", "USD:USD:1"} {
+		if _, err := exchange.LoadStaticRates([]string{entry}, time.Now()); err == nil {
+			t.Errorf("Expected %q rejected", entry)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"banking-ledger/api/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name           string
+		configured     string
+		authorization  string
+		expectedStatus int
+	}{
+		{"valid token", "s3cret", "Bearer s3cret", http.StatusOK},
+		{"missing token", "s3cret", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "Bearer guess", http.StatusUnauthorized},
+		{"admin API disabled", "", "Bearer anything", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			admin := e.Group("/api/v1/admin", middleware.AdminAuth(tt.configured))
+			admin.GET("/ping", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/ping", nil)
+			if tt.authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-ledger/api/middleware"
+	"banking-ledger/internal/config"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newTestController() *middleware.DegradationController {
+	return middleware.NewDegradationController(config.DegradationConfig{
+		Enabled:            true,
+		PoolSaturationHigh: 0.9,
+		PoolSaturationLow:  0.6,
+		LatencyHigh:        2 * time.Second,
+		LatencyLow:         500 * time.Millisecond,
+		RetryAfter:         30 * time.Second,
+		ShedRoutes:         []string{"GET /api/v1/accounts/:id/summary"},
+	})
+}
+
+func TestDegradationController_Hysteresis(t *testing.T) {
+	dc := newTestController()
+
+	steps := []struct {
+		name     string
+		signals  middleware.DegradationSignals
+		degraded bool
+	}{
+		{"healthy", middleware.DegradationSignals{PoolSaturation: 0.3, Latency: 100 * time.Millisecond}, false},
+		{"between thresholds stays healthy", middleware.DegradationSignals{PoolSaturation: 0.8, Latency: time.Second}, false},
+		{"pool saturation crosses high", middleware.DegradationSignals{PoolSaturation: 0.95, Latency: 100 * time.Millisecond}, true},
+		{"between thresholds stays degraded", middleware.DegradationSignals{PoolSaturation: 0.7, Latency: 100 * time.Millisecond}, true},
+		{"only pool recovered stays degraded", middleware.DegradationSignals{PoolSaturation: 0.5, Latency: time.Second}, true},
+		{"both below low recovers", middleware.DegradationSignals{PoolSaturation: 0.5, Latency: 400 * time.Millisecond}, false},
+		{"latency crosses high", middleware.DegradationSignals{PoolSaturation: 0.1, Latency: 3 * time.Second}, true},
+	}
+
+	for _, step := range steps {
+		if got := dc.Observe(step.signals); got != step.degraded {
+			t.Errorf("%s: expected degraded=%v, got %v", step.name, step.degraded, got)
+		}
+	}
+
+	if transitions := dc.State().Transitions; transitions != 3 {
+		t.Errorf("Expected 3 transitions, got %d", transitions)
+	}
+}
+
+func TestDegradationMiddleware_ShedsOnlyConfiguredRoutes(t *testing.T) {
+	dc := newTestController()
+
+	e := echo.New()
+	e.Use(middleware.Degradation(dc))
+	ok := func(c echo.Context) error { return c.String(http.StatusOK, "ok") }
+	e.GET("/api/v1/accounts/:id/summary", ok)
+	e.GET("/api/v1/accounts/:id/balance", ok)
+	e.POST("/api/v1/transactions", ok)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/api/v1/accounts/a1/summary"); rec.Code != http.StatusOK {
+		t.Errorf("Expected summary to be served while healthy, got %d", rec.Code)
+	}
+
+	dc.Observe(middleware.DegradationSignals{PoolSaturation: 1.0})
+
+	rec := serve(http.MethodGet, "/api/v1/accounts/a1/summary")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected summary to be shed with %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	if rec := serve(http.MethodGet, "/api/v1/accounts/a1/balance"); rec.Code != http.StatusOK {
+		t.Errorf("Expected balance read to survive degradation, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/api/v1/transactions"); rec.Code != http.StatusOK {
+		t.Errorf("Expected transaction submission to survive degradation, got %d", rec.Code)
+	}
+
+	if shed := dc.State().ShedCount; shed != 1 {
+		t.Errorf("Expected 1 shed request, got %d", shed)
+	}
+}
+
+func TestHealthCheck_ReportsDegradationState(t *testing.T) {
+	dc := newTestController()
+	dc.Observe(middleware.DegradationSignals{Latency: 5 * time.Second})
+
+	e := echo.New()
+	e.Use(middleware.HealthCheck(dc))
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `"status":"degraded"`) || !strings.Contains(body, `"degraded":true`) {
+		t.Errorf("Expected degraded health payload, got %s", body)
+	}
+}
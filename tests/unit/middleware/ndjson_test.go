@@ -0,0 +1,32 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"banking-ledger/api/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAcceptsNDJSON(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected bool
+	}{
+		{"application/x-ndjson", true},
+		{"application/json, Application/X-NDJSON;q=0.9", true},
+		{"application/json", false},
+		{"", false},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/transactions", nil)
+		req.Header.Set(echo.HeaderAccept, tt.accept)
+		if got := middleware.AcceptsNDJSON(e.NewContext(req, httptest.NewRecorder())); got != tt.expected {
+			t.Errorf("Expected %v for Accept %q, got %v", tt.expected, tt.accept, got)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/queue"
+
+	"github.com/streadway/amqp"
+)
+
+func TestConfirmer_Publish(t *testing.T) {
+	confirms := make(chan amqp.Confirmation)
+	defer close(confirms)
+	confirmer := queue.NewConfirmer(confirms, 50*time.Millisecond)
+
+	// publishAndConfirm publishes through the confirmer while the broker
+	// answers with confirm, if any
+	publishAndConfirm := func(confirm *amqp.Confirmation) error {
+		t.Helper()
+		published := make(chan struct{})
+		result := make(chan error, 1)
+		go func() {
+			result <- confirmer.Publish(context.Background(), func() error {
+				close(published)
+				return nil
+			})
+		}()
+		<-published
+		if confirm != nil {
+			confirms <- *confirm
+		}
+		select {
+		case err := <-result:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the publish to return")
+			return nil
+		}
+	}
+
+	if err := publishAndConfirm(&amqp.Confirmation{DeliveryTag: 1, Ack: true}); err != nil {
+		t.Errorf("Expected an acked publish to succeed, got %v", err)
+	}
+	if err := publishAndConfirm(&amqp.Confirmation{DeliveryTag: 2, Ack: false}); !errors.Is(err, queue.ErrPublishNacked) {
+		t.Errorf("Expected %v for a nacked publish, got %v", queue.ErrPublishNacked, err)
+	}
+	if err := publishAndConfirm(nil); !errors.Is(err, queue.ErrPublishUnconfirmed) {
+		t.Errorf("Expected %v for an unconfirmed publish, got %v", queue.ErrPublishUnconfirmed, err)
+	}
+
+	// A late confirm for the publish that timed out is not taken for the next
+	confirms <- amqp.Confirmation{DeliveryTag: 3, Ack: true}
+	if err := publishAndConfirm(&amqp.Confirmation{DeliveryTag: 4, Ack: false}); !errors.Is(err, queue.ErrPublishNacked) {
+		t.Errorf("Expected %v for the publish after a late confirm, got %v", queue.ErrPublishNacked, err)
+	}
+
+	// A publish the channel refuses is never numbered by the broker
+	refused := errors.New("channel closed")
+	if err := confirmer.Publish(context.Background(), func() error { return refused }); err != refused {
+		t.Errorf("Expected the channel's error, got %v", err)
+	}
+	if err := publishAndConfirm(&amqp.Confirmation{DeliveryTag: 5, Ack: true}); err != nil {
+		t.Errorf("Expected the publish after a refused one to succeed, got %v", err)
+	}
+
+	// The publish's context bounds the wait as well
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := confirmer.Publish(ctx, func() error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v for a cancelled publish, got %v", context.Canceled, err)
+	}
+}
+
+func TestConfirmer_ChannelClosed(t *testing.T) {
+	confirms := make(chan amqp.Confirmation)
+	confirmer := queue.NewConfirmer(confirms, 0)
+
+	published := make(chan struct{})
+	result := make(chan error, 1)
+	go func() {
+		result <- confirmer.Publish(context.Background(), func() error {
+			close(published)
+			return nil
+		})
+	}()
+	<-published
+	close(confirms)
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, queue.ErrConfirmsClosed) {
+			t.Errorf("Expected %v for a publish waiting as the channel closed, got %v", queue.ErrConfirmsClosed, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the publish to fail")
+	}
+
+	// Once closed, nothing more is published
+	called := false
+	err := confirmer.Publish(context.Background(), func() error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, queue.ErrConfirmsClosed) || called {
+		t.Errorf("Expected %v without publishing, got %v (published %v)", queue.ErrConfirmsClosed, err, called)
+	}
+}
+
+func TestConfirmer_BlockedWriteDoesNotHoldUpConfirms(t *testing.T) {
+	confirms := make(chan amqp.Confirmation)
+	defer close(confirms)
+	confirmer := queue.NewConfirmer(confirms, 0)
+
+	first := make(chan error, 1)
+	firstPublished := make(chan struct{})
+	go func() {
+		first <- confirmer.Publish(context.Background(), func() error {
+			close(firstPublished)
+			return nil
+		})
+	}()
+	<-firstPublished
+
+	// The broker holds up the second write, as under flow control
+	writing := make(chan struct{})
+	unblock := make(chan struct{})
+	second := make(chan error, 1)
+	go func() {
+		second <- confirmer.Publish(context.Background(), func() error {
+			close(writing)
+			<-unblock
+			return nil
+		})
+	}()
+	<-writing
+
+	// The first publish still hears of its confirm
+	select {
+	case confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out handing over the confirm while a write was blocked")
+	}
+	select {
+	case err := <-first:
+		if err != nil {
+			t.Errorf("Expected the first publish to succeed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the first publish while a write was blocked")
+	}
+
+	close(unblock)
+	confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+	select {
+	case err := <-second:
+		if err != nil {
+			t.Errorf("Expected the second publish to succeed, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the second publish")
+	}
+}
@@ -0,0 +1,315 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/queue"
+)
+
+// immediately lets retry delays pass at once
+func immediately(time.Duration) <-chan time.Time {
+	fired := make(chan time.Time, 1)
+	fired <- time.Time{}
+	return fired
+}
+
+// waitFor polls until done reports true
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !done(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestInMemoryQueue_ConcurrentSubscribers(t *testing.T) {
+	const (
+		publishers = 10
+		perPublish = 100
+	)
+	messageQueue := queue.NewInMemoryQueue(queue.WithConsumers("jobs", 0, 4))
+	defer messageQueue.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	handled := make(map[string]int)
+	handler := func(ctx context.Context, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled[string(data)]++
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := messageQueue.Subscribe(ctx, "jobs", handler); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perPublish; i++ {
+				if err := messageQueue.Publish(ctx, "jobs", []byte(fmt.Sprintf("%d-%d", p, i))); err != nil {
+					t.Errorf("Failed to publish: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	waitFor(t, "every message", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == publishers*perPublish
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	for message, count := range handled {
+		if count != 1 {
+			t.Errorf("Expected %s handled once, got %d", message, count)
+		}
+	}
+}
+
+func TestInMemoryQueue_Retries(t *testing.T) {
+	messageQueue := queue.NewInMemoryQueue(queue.WithRetryPolicy(queue.RetryPolicy{MaxRetries: 3, BaseDelay: time.Second, After: immediately}))
+	defer messageQueue.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	_, err := messageQueue.Subscribe(ctx, "jobs", func(ctx context.Context, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[string(data)]++
+		switch string(data) {
+		case "flaky":
+			if attempts["flaky"] < 3 {
+				return domain.ErrDatabaseError
+			}
+		case "broken":
+			return domain.ErrDatabaseError
+		case "invalid":
+			return domain.ErrInsufficientFunds
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for _, message := range []string{"flaky", "broken", "invalid", "done"} {
+		if err := messageQueue.Publish(ctx, "jobs", []byte(message)); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+	waitFor(t, "the last message", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts["done"] == 1
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := map[string]int{"flaky": 3, "broken": 4, "invalid": 1, "done": 1}
+	for message, count := range expected {
+		if attempts[message] != count {
+			t.Errorf("Expected %s attempted %d times, got %d", message, count, attempts[message])
+		}
+	}
+}
+
+func TestInMemoryQueue_FailureHandler(t *testing.T) {
+	var mu sync.Mutex
+	dropped := make(map[string]error)
+	messageQueue := queue.NewInMemoryQueue(
+		queue.WithRetryPolicy(queue.RetryPolicy{MaxRetries: 2, BaseDelay: time.Second, After: immediately}),
+		queue.WithFailureHandler("jobs", func(ctx context.Context, message []byte, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			dropped[string(message)] = err
+		}),
+	)
+	defer messageQueue.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handled := false
+	_, err := messageQueue.Subscribe(ctx, "jobs", func(ctx context.Context, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		switch string(data) {
+		case "broken":
+			return domain.ErrDatabaseError
+		case "invalid":
+			return domain.ErrInsufficientFunds
+		}
+		handled = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for _, message := range []string{"broken", "invalid", "done"} {
+		if err := messageQueue.Publish(ctx, "jobs", []byte(message)); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+	waitFor(t, "every message", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return handled && len(dropped) == 2
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !errors.Is(dropped["broken"], domain.ErrDatabaseError) {
+		t.Errorf("Expected the message out of retries dropped with its last error, got %v", dropped["broken"])
+	}
+	if !errors.Is(dropped["invalid"], domain.ErrInsufficientFunds) {
+		t.Errorf("Expected the permanently failed message dropped with its error, got %v", dropped["invalid"])
+	}
+	if _, ok := dropped["done"]; ok {
+		t.Error("Expected the handled message not to be dropped")
+	}
+}
+
+func TestInMemoryQueue_Shutdown(t *testing.T) {
+	messageQueue := queue.NewInMemoryQueue()
+
+	// Messages published before anyone subscribes wait for a subscriber
+	for i := 0; i < 3; i++ {
+		if err := messageQueue.Publish(context.Background(), "jobs", []byte(fmt.Sprintf("job-%d", i))); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var first []byte
+	subscription, err := messageQueue.Subscribe(ctx, "jobs", func(ctx context.Context, data []byte) error {
+		first = data
+		close(entered)
+		<-release
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// Shutting down while the first message is in hand lets it finish
+	<-entered
+	cancel()
+	close(release)
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelDrain()
+	if drained, err := subscription.Drain(drainCtx); err != nil || drained != 1 {
+		t.Fatalf("Expected one message drained, got %d %v", drained, err)
+	}
+	if string(first) != "job-0" {
+		t.Errorf("Expected the first message handled first, got %s", first)
+	}
+
+	// The rest wait for the next subscriber
+	var mu sync.Mutex
+	var rest []string
+	next, cancelNext := context.WithCancel(context.Background())
+	defer cancelNext()
+	if _, err := messageQueue.Subscribe(next, "jobs", func(ctx context.Context, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		rest = append(rest, string(data))
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	waitFor(t, "the remaining messages", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(rest) == 2
+	})
+
+	messageQueue.Close()
+	if err := messageQueue.Publish(context.Background(), "jobs", []byte("late")); err == nil {
+		t.Error("Expected publishing to a closed queue to fail")
+	}
+}
+
+func TestInMemoryQueue_Fanout(t *testing.T) {
+	messageQueue := queue.NewInMemoryQueue(queue.WithFanout("cancellations"))
+	defer messageQueue.Close()
+
+	// Nobody is subscribed to hear of it yet
+	if err := messageQueue.Publish(context.Background(), "cancellations", []byte("early")); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	var mu sync.Mutex
+	heard := make(map[int][]string)
+	subscribe := func(ctx context.Context, subscriber int) domain.Subscription {
+		subscription, err := messageQueue.Subscribe(ctx, "cancellations", func(ctx context.Context, data []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			heard[subscriber] = append(heard[subscriber], string(data))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+		return subscription
+	}
+	first, stopFirst := context.WithCancel(context.Background())
+	subscription := subscribe(first, 0)
+	second, stopSecond := context.WithCancel(context.Background())
+	defer stopSecond()
+	subscribe(second, 1)
+
+	// Every subscriber hears of each message
+	if err := messageQueue.Publish(context.Background(), "cancellations", []byte("tx-1")); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	waitFor(t, "both subscribers", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(heard[0]) == 1 && len(heard[1]) == 1
+	})
+
+	// A drained subscriber hears of nothing more
+	stopFirst()
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelDrain()
+	if _, err := subscription.Drain(drainCtx); err != nil {
+		t.Fatalf("Expected the subscription to drain, got %v", err)
+	}
+	if err := messageQueue.Publish(context.Background(), "cancellations", []byte("tx-2")); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	waitFor(t, "the remaining subscriber", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(heard[1]) == 2
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(heard[0]) != "[tx-1]" || fmt.Sprint(heard[1]) != "[tx-1 tx-2]" {
+		t.Errorf("Expected each subscriber to hear of the messages published while subscribed, got %v", heard)
+	}
+}
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/queue"
+)
+
+// fakeClock records the delays waited out and lets them pass at once
+type fakeClock struct {
+	delays []time.Duration
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.delays = append(c.delays, d)
+	fired := make(chan time.Time, 1)
+	fired <- time.Time{}
+	return fired
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestRetryPolicy_Schedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   queue.RetryPolicy
+		expected []time.Duration
+	}{
+		{
+			name:     "exponential",
+			policy:   queue.RetryPolicy{MaxRetries: 4, BaseDelay: time.Second, Backoff: queue.BackoffExponential},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:     "linear",
+			policy:   queue.RetryPolicy{MaxRetries: 4, BaseDelay: time.Second, Backoff: queue.BackoffLinear},
+			expected: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second},
+		},
+		{
+			name:     "exponential with jitter",
+			policy:   queue.RetryPolicy{MaxRetries: 3, BaseDelay: time.Second, Backoff: queue.BackoffExponential, Jitter: true, Random: func() float64 { return 0.5 }},
+			expected: []time.Duration{750 * time.Millisecond, 1500 * time.Millisecond, 3 * time.Second},
+		},
+		{
+			name:     "no retries",
+			policy:   queue.RetryPolicy{MaxRetries: 0, BaseDelay: time.Second},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{}
+			tt.policy.After = clock.After
+			attempts := 0
+			failures, err := tt.policy.Retry(context.Background(), discardLogger, func(ctx context.Context) error {
+				attempts++
+				return domain.ErrDatabaseError
+			})
+
+			if !errors.Is(err, domain.ErrDatabaseError) {
+				t.Errorf("Expected the last error returned, got %v", err)
+			}
+			if attempts != tt.policy.MaxRetries+1 || failures != attempts {
+				t.Errorf("Expected %d failed attempts, got %d attempts and %d failures", tt.policy.MaxRetries+1, attempts, failures)
+			}
+			if !slices.Equal(clock.delays, tt.expected) {
+				t.Errorf("Expected delays %v, got %v", tt.expected, clock.delays)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Retry(t *testing.T) {
+	clock := &fakeClock{}
+	policy := queue.RetryPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, After: clock.After}
+
+	// Succeeding on the third attempt stops retrying
+	attempts := 0
+	failures, err := policy.Retry(context.Background(), discardLogger, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return domain.ErrDatabaseError
+		}
+		return nil
+	})
+	if err != nil || failures != 2 || len(clock.delays) != 2 {
+		t.Errorf("Expected success after 2 failures and 2 waits, got %v after %d failures and %d waits", err, failures, len(clock.delays))
+	}
+
+	// A permanent failure is not retried
+	clock.delays = nil
+	failures, err = policy.Retry(context.Background(), discardLogger, func(ctx context.Context) error {
+		return domain.ErrInsufficientFunds
+	})
+	if !domain.IsPermanent(err) || failures != 1 || len(clock.delays) != 0 {
+		t.Errorf("Expected one permanent failure without waiting, got %v after %d failures and %d waits", err, failures, len(clock.delays))
+	}
+
+	// Retrying stops once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	policy.After = func(time.Duration) <-chan time.Time {
+		cancel()
+		return nil
+	}
+	failures, err = policy.Retry(ctx, discardLogger, func(ctx context.Context) error {
+		return domain.ErrDatabaseError
+	})
+	if !errors.Is(err, domain.ErrDatabaseError) || failures != 1 {
+		t.Errorf("Expected retrying to stop after one failure, got %v after %d failures", err, failures)
+	}
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy, err := queue.NewRetryPolicy(0, time.Second, "linear", true)
+	if err != nil || policy.MaxRetries != 0 || policy.Backoff != queue.BackoffLinear || !policy.Jitter {
+		t.Errorf("Expected a linear policy without retries, got %+v %v", policy, err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		maxRetries int
+		delay      time.Duration
+		backoff    string
+	}{
+		{"negative retries", -1, time.Second, "exponential"},
+		{"negative delay", 3, -time.Second, "exponential"},
+		{"unknown backoff", 3, time.Second, "fibonacci"},
+	} {
+		if _, err := queue.NewRetryPolicy(tt.maxRetries, tt.delay, tt.backoff, false); err == nil {
+			t.Errorf("Expected %s rejected", tt.name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/queue"
+)
+
+func TestServe_DrainTimeout(t *testing.T) {
+	deliveries := make(chan int, 2)
+	deliveries <- 1
+	deliveries <- 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The handler runs until its context is cancelled
+	handling := make(chan struct{})
+	aborted := make(chan error, 1)
+	released := make(chan int, 2)
+	subscription := queue.Serve(ctx, deliveries, 1,
+		func(ctx context.Context, delivery int) {
+			close(handling)
+			<-ctx.Done()
+			aborted <- ctx.Err()
+		},
+		func() { close(deliveries) },
+		func(delivery int) { released <- delivery },
+	)
+
+	<-handling
+	cancel()
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelDrain()
+	drained, err := subscription.Drain(drainCtx)
+	if !errors.Is(err, context.DeadlineExceeded) || drained != 0 {
+		t.Errorf("Expected the drain to time out with nothing drained, got %d %v", drained, err)
+	}
+
+	select {
+	case err := <-aborted:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the handler's context cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the handler to be cancelled")
+	}
+
+	// Once the handler returns, the delivery never taken is released
+	select {
+	case delivery := <-released:
+		if delivery != 2 {
+			t.Errorf("Expected the second delivery released, got %d", delivery)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the second delivery to be released")
+	}
+}
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAccountUseCase_CreateAccounts(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	accountRepo.accounts["existing"] = &domain.Account{ID: "existing", UserID: "carol", Currency: "USD", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive, Version: 1}
+	service := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+
+	requests := []*domain.NewAccount{
+		{UserID: "alice", InitialBalance: "100.00", Currency: "usd"},
+		{UserID: "alice", Currency: "USD", Type: domain.AccountTypeSavings},
+		{UserID: "alice", Currency: "USD", Type: domain.AccountTypeChecking},
+		{UserID: "carol", Currency: "USD"},
+		{UserID: "bob", Currency: "XYZ"},
+		{UserID: "bob", InitialBalance: "1.001", Currency: "USD"},
+		{UserID: "", Currency: "USD"},
+		{UserID: "bob", Currency: "USD", Type: domain.AccountTypeSettlement},
+	}
+	results, err := service.CreateAccounts(context.Background(), requests, false)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := []domain.BulkAccountStatus{
+		domain.BulkAccountCreated,
+		domain.BulkAccountCreated,
+		domain.BulkAccountDuplicate,
+		domain.BulkAccountDuplicate,
+		domain.BulkAccountInvalid,
+		domain.BulkAccountInvalid,
+		domain.BulkAccountInvalid,
+		domain.BulkAccountInvalid,
+	}
+	for i, result := range results {
+		if result.Index != i || result.Status != expected[i] {
+			t.Errorf("Expected item %d %s, got item %d %s (%v)", i, expected[i], result.Index, result.Status, result.Err)
+		}
+		if (result.Account != nil) != (result.Status == domain.BulkAccountCreated) {
+			t.Errorf("Expected an account only for created item %d, got %+v", i, result.Account)
+		}
+	}
+	if results[4].Err != domain.ErrUnsupportedCurrency || results[6].Err != domain.ErrMissingUserID || results[7].Err != domain.ErrInternalAccountType {
+		t.Errorf("Expected each invalid item to carry its error, got %v, %v and %v", results[4].Err, results[6].Err, results[7].Err)
+	}
+	if account := results[0].Account; account.Balance != 10000 || account.Currency != "USD" || account.Type != domain.AccountTypeChecking {
+		t.Errorf("Expected a 100.00 USD checking account, got %d %s %s", account.Balance, account.Currency, account.Type)
+	}
+	if len(accountRepo.accounts) != 3 {
+		t.Errorf("Expected two accounts added to the existing one, got %d accounts", len(accountRepo.accounts))
+	}
+}
+
+func TestAccountUseCase_CreateAccountsDryRun(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	service := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+
+	results, err := service.CreateAccounts(context.Background(), []*domain.NewAccount{
+		{UserID: "alice", Currency: "USD"},
+		{UserID: "alice", Currency: "USD"},
+		{UserID: "bob", InitialBalance: "-5.00", Currency: "USD"},
+	}, true)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	for i, expected := range []domain.BulkAccountStatus{domain.BulkAccountValid, domain.BulkAccountDuplicate, domain.BulkAccountInvalid} {
+		if results[i].Status != expected {
+			t.Errorf("Expected item %d %s, got %s", i, expected, results[i].Status)
+		}
+	}
+	if len(accountRepo.accounts) != 0 {
+		t.Errorf("Expected a dry run to open nothing, got %d accounts", len(accountRepo.accounts))
+	}
+}
+
+func TestAccountUseCase_CreateAccountsLimit(t *testing.T) {
+	service := usecase.NewAccountUseCase(NewMockAccountRepository(), NewMockTransactionRepository(), usecase.WithMaxBulkAccounts(2))
+	requests := []*domain.NewAccount{
+		{UserID: "alice", Currency: "USD"},
+		{UserID: "bob", Currency: "USD"},
+		{UserID: "carol", Currency: "USD"},
+	}
+
+	for _, batch := range [][]*domain.NewAccount{nil, requests} {
+		var bulkErr *domain.BulkAccountsError
+		if _, err := service.CreateAccounts(context.Background(), batch, false); !errors.As(err, &bulkErr) || bulkErr.Max != 2 {
+			t.Errorf("Expected %d accounts refused with the limit, got %v", len(batch), err)
+		}
+	}
+	if _, err := service.CreateAccounts(context.Background(), requests[:2], false); err != nil {
+		t.Errorf("Expected accounts within the limit opened, got %v", err)
+	}
+}
+
+func TestAccountHandler_BulkCreateAccounts(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository()),
+		AdminToken:     ownershipAdminToken,
+	})
+
+	body := `{"accounts":[{"user_id":"alice","currency":"USD","initial_balance":"10.00"},{"user_id":"alice","currency":"USD"},{"user_id":"bob","currency":"JPY","initial_balance":"1.5"}]}`
+	if rec := principalRequest(e, "alice", http.MethodPost, "/api/v1/accounts/bulk", body); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a user refused, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/accounts/bulk", `{"accounts":[]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an empty import rejected, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		path := "/api/v1/accounts/bulk"
+		if dryRun {
+			path += "?dry_run=true"
+		}
+		rec := principalRequest(e, "admin", http.MethodPost, path, body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+
+		var response struct {
+			Results []struct {
+				Index   int                      `json:"index"`
+				Status  domain.BulkAccountStatus `json:"status"`
+				Account *domain.Account          `json:"account"`
+				Error   string                   `json:"error"`
+			} `json:"results"`
+			DryRun bool                             `json:"dry_run"`
+			Counts map[domain.BulkAccountStatus]int `json:"counts"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+
+		first := domain.BulkAccountCreated
+		if dryRun {
+			first = domain.BulkAccountValid
+		}
+		if response.DryRun != dryRun || len(response.Results) != 3 || response.Results[0].Status != first {
+			t.Fatalf("Expected the first of three accounts %s, got %+v", first, response)
+		}
+		if response.Results[1].Status != domain.BulkAccountDuplicate || response.Counts[domain.BulkAccountDuplicate] != 1 {
+			t.Errorf("Expected the second account skipped as a duplicate, got %+v", response)
+		}
+		if invalid := response.Results[2]; invalid.Index != 2 || invalid.Status != domain.BulkAccountInvalid || invalid.Error != "JPY amounts cannot have decimal places" {
+			t.Errorf("Expected the third account invalid with its error, got %+v", invalid)
+		}
+	}
+
+	if len(accountRepo.accounts) != 1 {
+		t.Errorf("Expected one account opened, got %d", len(accountRepo.accounts))
+	}
+}
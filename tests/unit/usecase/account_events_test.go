@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAccountUseCase_RecordsEvents(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	accounts := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+	ctx := domain.ContextWithPrincipal(context.Background(), &domain.Principal{Admin: true})
+
+	account, err := accounts.CreateAccount(ctx, "alice", 0, "USD", domain.AccountTypeChecking, domain.AccountDetails{})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if _, err := accounts.FreezeAccount(ctx, account.ID, "Suspected account takeover"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if _, err := accounts.UnfreezeAccount(ctx, account.ID, "Investigation closed"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if _, err := accounts.UpdateOverdraftLimit(ctx, account.ID, "50.00"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if _, err := accounts.UpdateOverdraftLimit(ctx, account.ID, "50.00"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	daily := domain.Decimal("200.00")
+	if _, err := accounts.UpdateLimits(ctx, account.ID, nil, &daily); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if _, err := accounts.CloseAccount(ctx, account.ID); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	events, err := accounts.ListAccountEvents(ctx, account.ID, 100, 0)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	expected := []struct {
+		eventType          domain.AccountEventType
+		oldValue, newValue string
+		reason             string
+	}{
+		{domain.AccountEventClosed, "active", "closed", ""},
+		{domain.AccountEventDailyOutgoingChanged, "0.00", "200.00", ""},
+		{domain.AccountEventOverdraftLimitChanged, "0.00", "50.00", ""},
+		{domain.AccountEventStatusChanged, "frozen", "active", "Investigation closed"},
+		{domain.AccountEventStatusChanged, "active", "frozen", "Suspected account takeover"},
+		{domain.AccountEventCreated, "", "active", ""},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+	for i, want := range expected {
+		event := events[i]
+		if event.EventType != want.eventType || event.OldValue != want.oldValue || event.NewValue != want.newValue || event.Reason != want.reason {
+			t.Errorf("Expected event %d %s %q -> %q (%q), got %s %q -> %q (%q)", i, want.eventType, want.oldValue, want.newValue, want.reason, event.EventType, event.OldValue, event.NewValue, event.Reason)
+		}
+		if event.Actor != "admin" {
+			t.Errorf("Expected event %d by admin, got %q", i, event.Actor)
+		}
+	}
+
+	page, err := accounts.ListAccountEvents(ctx, account.ID, 2, 4)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(page) != 2 || page[0].ID != events[4].ID || page[1].ID != events[5].ID {
+		t.Errorf("Expected the oldest two events on the last page, got %d", len(page))
+	}
+}
+
+func TestAccountEvents_Endpoint(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["bob"] = &domain.Account{ID: "bob", UserID: "bob", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		AdminToken:         ownershipAdminToken,
+	})
+
+	if rec := principalRequest(e, "admin", http.MethodPatch, "/api/v1/accounts/alice/freeze", `{"reason":"Chargeback investigation"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	for principal, visible := range map[string]bool{"admin": true, "alice": false} {
+		rec := principalRequest(e, principal, http.MethodGet, "/api/v1/accounts/alice/events", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, principal, rec.Code, rec.Body)
+		}
+		var body struct {
+			Events []*domain.AccountEvent `json:"events"`
+			Count  int                    `json:"count"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if body.Count != 1 || body.Events[0].EventType != domain.AccountEventStatusChanged || body.Events[0].NewValue != "frozen" {
+			t.Fatalf("Expected the freeze for %s, got %d events", principal, body.Count)
+		}
+		shown := body.Events[0].Reason == "Chargeback investigation" && body.Events[0].Actor == "admin"
+		if shown != visible {
+			t.Errorf("Expected the freeze reason and actor shown to %s: %v, got %q by %q", principal, visible, body.Events[0].Reason, body.Events[0].Actor)
+		}
+	}
+
+	if rec := principalRequest(e, "bob", http.MethodGet, "/api/v1/accounts/alice/events", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected another user refused, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/accounts/missing/events", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected a missing account not found, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAccountHandler_ListAccountsFilters(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	lastWeek := time.Now().UTC().AddDate(0, 0, -7)
+	for _, account := range []*domain.Account{
+		{ID: "eur-frozen-new", UserID: "corp-1", Currency: "EUR", Status: domain.AccountStatusFrozen, CreatedAt: lastWeek.Add(time.Hour)},
+		{ID: "eur-frozen-newer", UserID: "corp-2", Currency: "EUR", Status: domain.AccountStatusFrozen, CreatedAt: lastWeek.Add(2 * time.Hour)},
+		{ID: "eur-frozen-old", UserID: "corp-3", Currency: "EUR", Status: domain.AccountStatusFrozen, CreatedAt: lastWeek.AddDate(0, -1, 0)},
+		{ID: "usd-frozen", UserID: "corp-4", Currency: "USD", Status: domain.AccountStatusFrozen, CreatedAt: lastWeek.Add(time.Hour)},
+		{ID: "eur-active", UserID: "retail-1", Currency: "EUR", Status: domain.AccountStatusActive, CreatedAt: lastWeek.Add(time.Hour)},
+	} {
+		accountRepo.accounts[account.ID] = account
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository()),
+		AdminToken:     ownershipAdminToken,
+	})
+
+	query := "/api/v1/accounts?status=frozen&currency=eur&user_id_prefix=corp-&created_from=" + lastWeek.Format("2006-01-02")
+	rec := principalRequest(e, "admin", http.MethodGet, query, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var body struct {
+		Accounts []*domain.Account    `json:"accounts"`
+		Total    int64                `json:"total"`
+		HasMore  bool                 `json:"has_more"`
+		Filters  domain.AccountFilter `json:"filters"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Accounts) != 2 || body.Accounts[0].ID != "eur-frozen-newer" || body.Accounts[1].ID != "eur-frozen-new" {
+		t.Errorf("Expected last week's frozen EUR accounts newest first, got %d accounts", len(body.Accounts))
+	}
+	if body.Total != 2 || body.HasMore {
+		t.Errorf("Expected a total of 2 and no more pages, got %d and %v", body.Total, body.HasMore)
+	}
+	if body.Filters.Status != domain.AccountStatusFrozen || body.Filters.Currency != "EUR" || body.Filters.UserIDPrefix != "corp-" || body.Filters.CreatedFrom == nil {
+		t.Errorf("Expected the applied filters echoed, got %+v", body.Filters)
+	}
+
+	for _, query := range []string{
+		"status=dormant",
+		"currency=XYZ",
+		"created_from=last-week",
+		"created_from=2026-03-05&created_to=2026-03-01",
+	} {
+		if rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/accounts?"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s refused, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+}
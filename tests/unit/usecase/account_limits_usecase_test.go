@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// newLimitsFixture builds a batch fixture on a fixed clock with alice's
+// limits set
+func newLimitsFixture(t *testing.T, clock *time.Time, limits domain.AccountLimits, opts ...usecase.TransactionOption) (*ledgerFixture, *MockMessageQueue) {
+	t.Helper()
+	f, queue := newBatchFixture(t, append([]usecase.TransactionOption{
+		usecase.WithClock(func() time.Time { return *clock }),
+	}, opts...)...)
+	f.accountRepo.accounts["alice"].AccountLimits = limits
+	return f, queue
+}
+
+// seedOutgoing stores an earlier withdrawal from alice
+func (f *ledgerFixture) seedOutgoing(id string, amount domain.Money, status domain.TransactionStatus, createdAt time.Time) {
+	alice := "alice"
+	f.transactionRepo.transactions[id] = &domain.Transaction{
+		ID: id, Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: amount, Currency: "USD", Status: status, CreatedAt: createdAt,
+	}
+}
+
+func TestAccountLimits_MaxTransactionAmount(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newLimitsFixture(t, &clock, domain.AccountLimits{MaxTransactionAmount: 3000})
+
+	over := f.transferTo(t, "bob", 3500)
+	within := f.transferTo(t, "bob", 3000)
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfers acknowledged, got %v", errs)
+	}
+
+	if stored := f.transactionRepo.transactions[over.ID]; stored.Status != domain.TransactionStatusFailed || stored.FailureCode != domain.FailureCodeLimitExceeded {
+		t.Errorf("Expected the transfer over the maximum failed as limit_exceeded, got %s %s", stored.Status, stored.FailureCode)
+	}
+	if stored := f.transactionRepo.transactions[within.ID]; stored.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the transfer at the maximum completed, got %s: %s", stored.Status, stored.ErrorMessage)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 7000 {
+		t.Errorf("Expected alice debited once, got balance %d", balance)
+	}
+}
+
+func TestAccountLimits_DailyOutgoing(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newLimitsFixture(t, &clock, domain.AccountLimits{DailyOutgoing: 5000})
+
+	// Processing transactions count towards the day; failed ones and those
+	// from yesterday do not
+	f.seedOutgoing("processing", 3000, domain.TransactionStatusProcessing, clock.Add(-time.Hour))
+	f.seedOutgoing("failed", 4000, domain.TransactionStatusFailed, clock.Add(-time.Hour))
+	f.seedOutgoing("yesterday", 4000, domain.TransactionStatusCompleted, clock.Add(-13*time.Hour))
+
+	over := f.transferTo(t, "bob", 2500)
+	within := f.transferTo(t, "bob", 2000)
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfers acknowledged, got %v", errs)
+	}
+
+	stored := f.transactionRepo.transactions[over.ID]
+	if stored.FailureCode != domain.FailureCodeLimitExceeded {
+		t.Errorf("Expected the transfer over the daily limit failed as limit_exceeded, got %s", stored.FailureCode)
+	}
+	if stored := f.transactionRepo.transactions[within.ID]; stored.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the transfer within the daily limit completed, got %s: %s", stored.Status, stored.ErrorMessage)
+	}
+
+	// The completed transfer has used the rest of the day's limit
+	if _, err := f.service.ProcessTransaction(context.Background(), keyedWithdrawal("", 1)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the withdrawal acknowledged, got %v", errs)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 8000 {
+		t.Errorf("Expected only the transfer within the limit debited, got balance %d", balance)
+	}
+}
+
+func TestAccountLimits_DayStartsInConfiguredTimezone(t *testing.T) {
+	// 06:00 UTC is 01:00 in UTC-5, so a withdrawal at 04:00 UTC falls on
+	// the previous day there
+	clock := time.Date(2026, time.March, 15, 6, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		location *time.Location
+		status   domain.TransactionStatus
+	}{
+		{"utc", time.UTC, domain.TransactionStatusFailed},
+		{"utc-5", time.FixedZone("UTC-5", -5*60*60), domain.TransactionStatusCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, queue := newLimitsFixture(t, &clock, domain.AccountLimits{DailyOutgoing: 5000}, usecase.WithLimitTimezone(tt.location))
+			f.seedOutgoing("earlier", 4000, domain.TransactionStatusCompleted, time.Date(2026, time.March, 15, 4, 0, 0, 0, time.UTC))
+
+			transfer := f.transferTo(t, "bob", 2000)
+			if errs := queue.Deliver("transactions"); len(errs) != 0 {
+				t.Fatalf("Expected the transfer acknowledged, got %v", errs)
+			}
+			if stored := f.transactionRepo.transactions[transfer.ID]; stored.Status != tt.status {
+				t.Errorf("Expected the transfer %s, got %s: %s", tt.status, stored.Status, stored.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestAccountLimits_RejectedAtSubmission(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, _ := newLimitsFixture(t, &clock, domain.AccountLimits{MaxTransactionAmount: 3000, DailyOutgoing: 5000}, usecase.WithSubmissionValidation(true))
+	f.seedOutgoing("earlier", 4000, domain.TransactionStatusCompleted, clock.Add(-time.Hour))
+
+	tests := []struct {
+		name   string
+		amount domain.Money
+		limit  domain.AccountLimit
+	}{
+		{"over maximum", 3500, domain.AccountLimitMaxTransaction},
+		{"over daily", 1500, domain.AccountLimitDailyOutgoing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.service.ProcessTransaction(context.Background(), keyedWithdrawal("", tt.amount))
+			var limitErr *domain.LimitExceededError
+			if !errors.As(err, &limitErr) || !errors.Is(err, domain.ErrLimitExceeded) {
+				t.Fatalf("Expected %v, got %v", domain.ErrLimitExceeded, err)
+			}
+			if limitErr.Limit != tt.limit {
+				t.Errorf("Expected the %s limit named, got %s", tt.limit, limitErr.Limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// seedSummaryTransactions stores a mix of alice's transactions, a day apart
+// from base
+func seedSummaryTransactions(transactionRepo *MockTransactionRepository, base time.Time) {
+	alice, bob := "alice", "bob"
+	for i, transaction := range []*domain.Transaction{
+		{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 5000, Status: domain.TransactionStatusCompleted},
+		{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 700, Status: domain.TransactionStatusCompleted},
+		{Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 1200, Status: domain.TransactionStatusCompleted},
+		{Type: domain.TransactionTypeTransfer, FromAccountID: &bob, ToAccountID: &alice, Amount: 300, Status: domain.TransactionStatusCompleted},
+		{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 100, Status: domain.TransactionStatusPending},
+		{Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 9900, Status: domain.TransactionStatusFailed},
+	} {
+		transaction.ID = string(rune('a' + i))
+		transaction.Currency = "USD"
+		transaction.CreatedAt = base.AddDate(0, 0, i)
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+}
+
+func TestAccountUseCase_GetAccountSummary(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accounts := usecase.NewAccountUseCase(accountRepo, transactionRepo)
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 3400, Currency: "USD", Status: domain.AccountStatusActive}
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	seedSummaryTransactions(transactionRepo, base)
+
+	summary, err := accounts.GetAccountSummary(context.Background(), "alice", nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	expected := domain.AccountTotals{
+		TransactionCount:  6,
+		Deposits:          domain.TransactionTotal{Amount: 5000, Count: 1},
+		Withdrawals:       domain.TransactionTotal{Amount: 700, Count: 1},
+		IncomingTransfers: domain.TransactionTotal{Amount: 300, Count: 1},
+		OutgoingTransfers: domain.TransactionTotal{Amount: 1200, Count: 1},
+		PendingCount:      1,
+		FailedCount:       1,
+	}
+	if summary.LastTransactionAt == nil || !summary.LastTransactionAt.Equal(base.AddDate(0, 0, 5)) {
+		t.Errorf("Expected the failed withdrawal as the last transaction, got %v", summary.LastTransactionAt)
+	}
+	summary.LastTransactionAt = nil
+	if summary.AccountTotals != expected {
+		t.Errorf("Expected totals %+v, got %+v", expected, summary.AccountTotals)
+	}
+
+	from, to := base.AddDate(0, 0, 1), base.AddDate(0, 0, 2)
+	summary, err = accounts.GetAccountSummary(context.Background(), "alice", &from, &to)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if summary.TransactionCount != 2 || summary.Deposits.Count != 0 || summary.Withdrawals.Count != 1 || summary.OutgoingTransfers.Count != 1 {
+		t.Errorf("Expected only the withdrawal and outgoing transfer in range, got %+v", summary.AccountTotals)
+	}
+}
+
+func TestAccountHandler_GetAccountSummary(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 3400, Currency: "USD", Status: domain.AccountStatusActive}
+	seedSummaryTransactions(transactionRepo, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		AdminToken:     ownershipAdminToken,
+	})
+
+	rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice/summary?from_date=2026-03-01T00:00:00Z", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var body struct {
+		TransactionCount int64 `json:"transaction_count"`
+		Deposits         struct {
+			Amount string `json:"amount"`
+			Count  int64  `json:"count"`
+		} `json:"deposits"`
+		PendingCount int64 `json:"pending_count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.TransactionCount != 6 || body.Deposits.Amount != "50.00" || body.Deposits.Count != 1 || body.PendingCount != 1 {
+		t.Errorf("Expected the totals with formatted amounts, got %s", rec.Body)
+	}
+
+	for _, query := range []string{"from_date=yesterday", "from_date=2026-03-05T00:00:00Z&to_date=2026-03-01T00:00:00Z"} {
+		if rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice/summary?"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s refused, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+}
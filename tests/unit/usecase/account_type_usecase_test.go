@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// newAccountTypeFixture builds a ledger fixture whose alice account is a
+// savings account, with a fee collection account and a clock in mid-March
+func newAccountTypeFixture(opts ...usecase.TransactionOption) (*ledgerFixture, time.Time) {
+	f := newLedgerFixture()
+	f.accountRepo.accounts["alice"].Type = domain.AccountTypeSavings
+	f.accountRepo.accounts["bob"].Type = domain.AccountTypeChecking
+	f.accountRepo.accounts["fees"] = &domain.Account{ID: "fees", UserID: "bank", Currency: "USD", Type: domain.AccountTypeFeeCollection, Status: domain.AccountStatusActive, Version: 1}
+
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f.service = usecase.NewTransactionUseCase(
+		f.accountRepo, f.transactionRepo, NewMockMessageQueue(), "transactions",
+		append([]usecase.TransactionOption{
+			usecase.WithLedgerEntries(f.ledgerRepo),
+			usecase.WithClock(func() time.Time { return now }),
+		}, opts...)...,
+	).(*usecase.TransactionUseCase)
+	return f, now
+}
+
+// submit stores a request as a pending transaction created at the given time
+// and processes it
+func (f *ledgerFixture) submit(request *domain.TransactionRequest, createdAt time.Time) error {
+	f.transactionRepo.transactions[request.ID] = &domain.Transaction{
+		ID: request.ID, Type: request.Type, FromAccountID: request.FromAccountID, ToAccountID: request.ToAccountID,
+		Amount: request.Amount, Currency: request.Currency, Status: domain.TransactionStatusPending, CreatedAt: createdAt,
+	}
+	return f.service.ProcessTransactionSync(context.Background(), request)
+}
+
+func TestSavingsWithdrawalLimit(t *testing.T) {
+	f, now := newAccountTypeFixture(usecase.WithSavingsWithdrawalLimit(2))
+	alice, bob := "alice", "bob"
+	withdraw := func(id, from string) *domain.TransactionRequest {
+		return &domain.TransactionRequest{ID: id, Type: domain.TransactionTypeWithdrawal, FromAccountID: &from, Amount: 100, Currency: "USD"}
+	}
+
+	// Last month's withdrawals and deposits into the account do not count
+	if err := f.submit(withdraw("february", alice), now.AddDate(0, -1, 0)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := f.submit(&domain.TransactionRequest{ID: "deposit", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD"}, now); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if err := f.submit(withdraw("first", alice), now); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	transfer := &domain.TransactionRequest{ID: "second", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 100, Currency: "USD"}
+	if err := f.submit(transfer, now); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if err := f.submit(withdraw("third", alice), now); err != domain.ErrWithdrawalLimitExceeded {
+		t.Errorf("Expected %v, got %v", domain.ErrWithdrawalLimitExceeded, err)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 9800 {
+		t.Errorf("Expected two debits applied this month, got balance %d", balance)
+	}
+
+	// Reversals out of the account are not limited
+	reversal := &domain.TransactionRequest{
+		ID: "reverse-deposit", Type: domain.TransactionTypeReversal, FromAccountID: &alice, Amount: 100, Currency: "USD",
+		ReversedTransactionID: "deposit",
+	}
+	if err := f.submit(reversal, now); err != nil {
+		t.Errorf("Expected the reversal to succeed, got %v", err)
+	}
+
+	// Checking accounts have no limit
+	for _, id := range []string{"bob-1", "bob-2", "bob-3"} {
+		if err := f.submit(withdraw(id, bob), now); err != nil {
+			t.Errorf("Expected checking withdrawal %s to succeed, got %v", id, err)
+		}
+	}
+}
+
+func TestSavingsWithdrawalLimit_ZeroDisables(t *testing.T) {
+	f, now := newAccountTypeFixture()
+	alice := "alice"
+
+	for _, id := range []string{"w-1", "w-2", "w-3", "w-4", "w-5", "w-6", "w-7"} {
+		request := &domain.TransactionRequest{ID: id, Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 100, Currency: "USD"}
+		if err := f.submit(request, now); err != nil {
+			t.Fatalf("Expected withdrawal %s to succeed, got %v", id, err)
+		}
+	}
+}
+
+func TestFeeCollectionAccount_OnlyCreditedByFees(t *testing.T) {
+	policy := usecase.NewFeePolicy("fees", usecase.FeeFailureFail)
+	policy.SetRule(domain.TransactionTypeTransfer, "USD", usecase.FeeRule{Flat: 50})
+	f, now := newAccountTypeFixture(usecase.WithFeePolicy(policy))
+	bob, fees, alice := "bob", "fees", "alice"
+
+	transfer := &domain.TransactionRequest{ID: "to-fees", Type: domain.TransactionTypeTransfer, FromAccountID: &bob, ToAccountID: &fees, Amount: 100, Currency: "USD"}
+	err := f.submit(transfer, now)
+	if err != domain.ErrAccountRestricted {
+		t.Fatalf("Expected %v, got %v", domain.ErrAccountRestricted, err)
+	}
+	if code := domain.FailureCodeFor(err); code != domain.FailureCodeAccountRestricted {
+		t.Errorf("Expected failure code %s, got %s", domain.FailureCodeAccountRestricted, code)
+	}
+
+	payment := &domain.TransactionRequest{ID: "payment", Type: domain.TransactionTypeTransfer, FromAccountID: &bob, ToAccountID: &alice, Amount: 100, Currency: "USD"}
+	if err := f.submit(payment, now); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if balance := f.accountRepo.accounts["fees"].Balance; balance != 50 {
+		t.Errorf("Expected the fee credited to the fee collection account, got %d", balance)
+	}
+
+	// Reversing a payment out of the fee collection account returns the money
+	payout := &domain.TransactionRequest{
+		ID: "payout", Type: domain.TransactionTypeTransfer, FromAccountID: &fees, ToAccountID: &bob, Amount: 50, Currency: "USD",
+	}
+	if err := f.submit(payout, now); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := f.submit(&domain.TransactionRequest{
+		ID: "payout-reversal", Type: domain.TransactionTypeReversal, FromAccountID: &bob, ToAccountID: &fees, Amount: 50, Currency: "USD",
+		ReversedTransactionID: "payout",
+	}, now); err != nil {
+		t.Errorf("Expected the reversal into the fee collection account to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAccountUseCase_UpdateAccountAttributes(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	accounts := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: domain.AccountStatusActive, BelowThreshold: true, Version: 1}
+	ctx := domain.ContextWithPrincipal(context.Background(), &domain.Principal{UserID: "user1"})
+
+	nickname := "  Holiday fund "
+	account, err := accounts.UpdateAccount(ctx, "acc-1", &domain.AccountUpdate{
+		Version:         1,
+		Nickname:        &nickname,
+		Metadata:        domain.AccountMetadata{"goal": "japan"},
+		ReplaceMetadata: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if account.Nickname != "Holiday fund" || account.Metadata["goal"] != "japan" || account.Version != 2 {
+		t.Errorf("Expected the nickname and metadata set at version 2, got %q %v at %d", account.Nickname, account.Metadata, account.Version)
+	}
+	if !account.BelowThreshold {
+		t.Error("Expected the below-threshold flag kept when the threshold is untouched")
+	}
+
+	if _, err := accounts.UpdateAccount(ctx, "acc-1", &domain.AccountUpdate{Version: 1, Nickname: &nickname}); err != domain.ErrConcurrentUpdate {
+		t.Errorf("Expected an update from a stale read refused, got %v", err)
+	}
+
+	long := strings.Repeat("x", domain.MaxNicknameLength+1)
+	if _, err := accounts.UpdateAccount(ctx, "acc-1", &domain.AccountUpdate{Version: 2, Nickname: &long}); err != domain.ErrNicknameTooLong {
+		t.Errorf("Expected an overlong nickname refused, got %v", err)
+	}
+
+	other := domain.ContextWithPrincipal(context.Background(), &domain.Principal{UserID: "user2"})
+	if _, err := accounts.UpdateAccount(other, "acc-1", &domain.AccountUpdate{Version: 2, Nickname: &nickname}); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("Expected another user refused, got %v", err)
+	}
+
+	account, err = accounts.UpdateAccount(ctx, "acc-1", &domain.AccountUpdate{Version: 2, ReplaceMetadata: true})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if account.Metadata != nil || account.Nickname != "Holiday fund" {
+		t.Errorf("Expected only the metadata cleared, got %q %v", account.Nickname, account.Metadata)
+	}
+}
+
+func TestAccountHandler_UpdateAccount(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 3}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository()),
+		AdminToken:     ownershipAdminToken,
+	})
+
+	rec := principalRequest(e, "alice", http.MethodPatch, "/api/v1/accounts/alice", `{"version":3,"balance":"1000000.00","user_id":"mallory","nickname":"Rich"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected immutable fields refused, got %d: %s", rec.Code, rec.Body)
+	}
+	var refused struct {
+		Fields map[string]string `json:"fields"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &refused); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(refused.Fields) != 2 || refused.Fields["balance"] == "" || refused.Fields["user_id"] == "" {
+		t.Errorf("Expected balance and user_id named, got %v", refused.Fields)
+	}
+
+	if rec := principalRequest(e, "alice", http.MethodPatch, "/api/v1/accounts/alice", `{"nickname":"Bills"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an update without a version refused, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "alice", http.MethodPatch, "/api/v1/accounts/alice", `{"version":2,"nickname":"Bills"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected a stale version refused, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = principalRequest(e, "alice", http.MethodPatch, "/api/v1/accounts/alice", `{"version":3,"nickname":"Bills","metadata":{"category":"household"},"low_balance_threshold":"50.00"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var account domain.Account
+	if err := json.Unmarshal(rec.Body.Bytes(), &account); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if account.Version != 4 || account.Nickname != "Bills" || account.Metadata["category"] != "household" || account.Balance != 10000 {
+		t.Errorf("Expected the updated account at version 4, got %+v", account)
+	}
+}
+
+func TestAccountHandler_FilterByLabel(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository()),
+		AdminToken:     ownershipAdminToken,
+	})
+
+	for _, body := range []string{
+		`{"user_id":"alice","currency":"USD","nickname":"Rent","labels":["bills"," household "]}`,
+		`{"user_id":"alice","currency":"EUR","nickname":"Savings EUR","labels":["savings"]}`,
+		`{"user_id":"bob","currency":"USD","labels":["bills"]}`,
+	} {
+		if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/accounts", body); rec.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rec.Code, rec.Body)
+		}
+	}
+
+	long := `{"user_id":"alice","currency":"USD","nickname":"` + strings.Repeat("x", domain.MaxNicknameLength+1) + `"}`
+	if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/accounts", long); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an overlong nickname refused, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/accounts", `{"user_id":"alice","currency":"USD","labels":[""]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a blank label refused, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for path, expected := range map[string]int{
+		"/api/v1/accounts?label=bills":                      2,
+		"/api/v1/accounts?label=household":                  1,
+		"/api/v1/accounts/search?user_id=alice&label=bills": 1,
+		"/api/v1/accounts/search?user_id=alice":             2,
+	} {
+		rec := principalRequest(e, "admin", http.MethodGet, path, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, path, rec.Code, rec.Body)
+		}
+		var body struct {
+			Accounts []*domain.Account `json:"accounts"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(body.Accounts) != expected {
+			t.Errorf("Expected %d accounts for %s, got %d", expected, path, len(body.Accounts))
+		}
+	}
+}
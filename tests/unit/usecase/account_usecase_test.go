@@ -1,18 +1,29 @@
 package usecase
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"banking-ledger/internal/domain"
 	"banking-ledger/internal/usecase"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 // MockAccountRepository implements domain.AccountRepository for testing
 type MockAccountRepository struct {
-	accounts map[string]*domain.Account
-	nextID   int
+	accounts     map[string]*domain.Account
+	events       []*domain.AccountEvent
+	nextID       int
+	batchLookups int
 }
 
 func NewMockAccountRepository() *MockAccountRepository {
@@ -22,14 +33,14 @@ func NewMockAccountRepository() *MockAccountRepository {
 	}
 }
 
-func (m *MockAccountRepository) Create(ctx context.Context, account *domain.Account) error {
+func (m *MockAccountRepository) Create(ctx context.Context, account *domain.Account, events ...*domain.AccountEvent) error {
 	if account.ID == "" {
 		account.ID = "test-id"
 	}
 
-	// Check for existing account with same user_id and currency
+	// Check for existing account with same user_id, currency and type
 	for _, existing := range m.accounts {
-		if existing.UserID == account.UserID && existing.Currency == account.Currency {
+		if existing.UserID == account.UserID && existing.Currency == account.Currency && existing.Type == account.Type {
 			return domain.ErrAccountExists
 		}
 	}
@@ -39,9 +50,28 @@ func (m *MockAccountRepository) Create(ctx context.Context, account *domain.Acco
 	account.Version = 1
 
 	m.accounts[account.ID] = account
+	m.recordEvents(events)
 	return nil
 }
 
+func (m *MockAccountRepository) CreateMany(ctx context.Context, accounts []*domain.Account, events ...*domain.AccountEvent) (map[string]bool, error) {
+	created := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		if err := m.Create(ctx, account); err == domain.ErrAccountExists {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		created[account.ID] = true
+	}
+	for _, event := range events {
+		if created[event.AccountID] {
+			m.recordEvents([]*domain.AccountEvent{event})
+		}
+	}
+	return created, nil
+}
+
 func (m *MockAccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
 	account, exists := m.accounts[id]
 	if !exists {
@@ -60,7 +90,7 @@ func (m *MockAccountRepository) GetByUserID(ctx context.Context, userID string)
 	return accounts, nil
 }
 
-func (m *MockAccountRepository) Update(ctx context.Context, account *domain.Account) error {
+func (m *MockAccountRepository) Update(ctx context.Context, account *domain.Account, events ...*domain.AccountEvent) error {
 	existing, exists := m.accounts[account.ID]
 	if !exists {
 		return domain.ErrAccountNotFound
@@ -73,10 +103,37 @@ func (m *MockAccountRepository) Update(ctx context.Context, account *domain.Acco
 	account.UpdatedAt = time.Now()
 	account.Version++
 	m.accounts[account.ID] = account
+	m.recordEvents(events)
 	return nil
 }
 
-func (m *MockAccountRepository) UpdateBalance(ctx context.Context, id string, newBalance float64, version int64) error {
+func (m *MockAccountRepository) recordEvents(events []*domain.AccountEvent) {
+	for _, event := range events {
+		if event.ID == "" {
+			event.ID = fmt.Sprintf("event-%d", len(m.events)+1)
+		}
+		m.events = append(m.events, event)
+	}
+}
+
+func (m *MockAccountRepository) ListEvents(ctx context.Context, accountID string, limit, offset int) ([]*domain.AccountEvent, error) {
+	var events []*domain.AccountEvent
+	for i := len(m.events) - 1; i >= 0; i-- {
+		if m.events[i].AccountID == accountID {
+			events = append(events, m.events[i])
+		}
+	}
+	if offset >= len(events) {
+		return nil, nil
+	}
+	events = events[offset:]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (m *MockAccountRepository) UpdateBalance(ctx context.Context, id string, newBalance domain.Money, version int64) error {
 	account, exists := m.accounts[id]
 	if !exists {
 		return domain.ErrAccountNotFound
@@ -92,102 +149,1131 @@ func (m *MockAccountRepository) UpdateBalance(ctx context.Context, id string, ne
 	return nil
 }
 
+func (m *MockAccountRepository) SetBelowThreshold(ctx context.Context, id string, below bool) (bool, error) {
+	account, exists := m.accounts[id]
+	if !exists {
+		return false, domain.ErrAccountNotFound
+	}
+	if account.BelowThreshold == below {
+		return false, nil
+	}
+	account.BelowThreshold = below
+	return true, nil
+}
+
 func (m *MockAccountRepository) Delete(ctx context.Context, id string) error {
 	_, exists := m.accounts[id]
 	if !exists {
 		return domain.ErrAccountNotFound
 	}
-	delete(m.accounts, id)
+	delete(m.accounts, id)
+	return nil
+}
+
+func (m *MockAccountRepository) List(ctx context.Context, filter *domain.AccountFilter) ([]*domain.Account, error) {
+	var matching []*domain.Account
+	for _, account := range m.accounts {
+		if filter.Matches(account) {
+			matching = append(matching, account)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].CreatedAt.After(matching[j].CreatedAt) })
+	if filter.Offset >= len(matching) {
+		return nil, nil
+	}
+	matching = matching[filter.Offset:]
+	if len(matching) > filter.Limit {
+		matching = matching[:filter.Limit]
+	}
+	return matching, nil
+}
+
+func (m *MockAccountRepository) CountAccounts(ctx context.Context, filter *domain.AccountFilter) (int64, error) {
+	var count int64
+	for _, account := range m.accounts {
+		if filter.Matches(account) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *MockAccountRepository) ListUsers(ctx context.Context, filter *domain.UserFilter) ([]*domain.UserOverview, error) {
+	byUser := make(map[string]*domain.UserOverview)
+	var users []*domain.UserOverview
+	for _, account := range m.accounts {
+		if !strings.HasPrefix(account.UserID, filter.UserIDPrefix) {
+			continue
+		}
+		user, ok := byUser[account.UserID]
+		if !ok {
+			user = &domain.UserOverview{UserID: account.UserID, Statuses: make(map[domain.AccountStatus]int)}
+			byUser[account.UserID] = user
+			users = append(users, user)
+		}
+		user.AccountCount++
+		user.Statuses[account.Status]++
+		added := false
+		for _, balance := range user.Balances {
+			if balance.Currency == account.Currency {
+				balance.Balance += account.Balance
+				added = true
+			}
+		}
+		if !added {
+			user.Balances = append(user.Balances, &domain.CurrencyBalance{Currency: account.Currency, Balance: account.Balance})
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	for _, user := range users {
+		sort.Slice(user.Balances, func(i, j int) bool { return user.Balances[i].Currency < user.Balances[j].Currency })
+	}
+	if filter.Offset >= len(users) {
+		return nil, nil
+	}
+	users = users[filter.Offset:]
+	if len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+	return users, nil
+}
+
+func (m *MockAccountRepository) ListAfter(ctx context.Context, afterID string, limit int) ([]*domain.Account, error) {
+	var accounts []*domain.Account
+	for _, account := range m.accounts {
+		if account.ID > afterID {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+	if len(accounts) > limit {
+		accounts = accounts[:limit]
+	}
+	return accounts, nil
+}
+
+func (m *MockAccountRepository) SumBalances(ctx context.Context) ([]*domain.BalanceTotal, error) {
+	totals := make(map[string]*domain.BalanceTotal)
+	for _, account := range m.accounts {
+		if totals[account.Currency] == nil {
+			totals[account.Currency] = &domain.BalanceTotal{Currency: account.Currency}
+		}
+		totals[account.Currency].Balance += account.Balance
+		totals[account.Currency].InitialBalance += account.InitialBalance
+	}
+	var result []*domain.BalanceTotal
+	for _, total := range totals {
+		result = append(result, total)
+	}
+	return result, nil
+}
+
+func (m *MockAccountRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Account, error) {
+	m.batchLookups++
+	var accounts []*domain.Account
+	for _, id := range ids {
+		if account, exists := m.accounts[id]; exists {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+// MockTransactionRepository implements domain.TransactionRepository for testing
+type MockTransactionRepository struct {
+	mu           sync.Mutex
+	transactions map[string]*domain.Transaction
+}
+
+func NewMockTransactionRepository() *MockTransactionRepository {
+	return &MockTransactionRepository{
+		transactions: make(map[string]*domain.Transaction),
+	}
+}
+
+func (m *MockTransactionRepository) Create(ctx context.Context, transaction *domain.Transaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if transaction.ID == "" {
+		transaction.ID = "test-tx-id"
+	}
+	transaction.CreatedAt = time.Now()
+	transaction.UpdatedAt = time.Now()
+	transaction.StatusHistory = []domain.StatusChange{{Status: transaction.Status, Timestamp: transaction.CreatedAt}}
+	if _, exists := m.transactions[transaction.ID]; exists {
+		return domain.ErrTransactionIDTaken
+	}
+	transaction.ReferenceReserved = transaction.ReservesReference()
+	if transaction.ReferenceReserved {
+		for _, existing := range m.transactions {
+			if existing.ReferenceReserved && existing.Reference == transaction.Reference && sameAccount(existing.FromAccountID, transaction.FromAccountID) {
+				return &domain.DuplicateReferenceError{ExistingTransactionID: existing.ID}
+			}
+		}
+	}
+	m.transactions[transaction.ID] = transaction
+	return nil
+}
+
+func sameAccount(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// involvesAccount reports whether either side of a transaction is the
+// filter's account, or one of its accounts
+func involvesAccount(tx *domain.Transaction, filter *domain.TransactionFilter) bool {
+	if filter.AccountID != nil {
+		return sameAccount(tx.FromAccountID, filter.AccountID) || sameAccount(tx.ToAccountID, filter.AccountID)
+	}
+	if len(filter.AccountIDs) == 0 {
+		return true
+	}
+	return tx.FromAccountID != nil && slices.Contains(filter.AccountIDs, *tx.FromAccountID) ||
+		tx.ToAccountID != nil && slices.Contains(filter.AccountIDs, *tx.ToAccountID)
+}
+
+func (m *MockTransactionRepository) GetByID(ctx context.Context, id string) (*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return nil, domain.ErrTransactionNotFound
+	}
+	return transaction, nil
+}
+
+func (m *MockTransactionRepository) GetByAccountID(ctx context.Context, accountID string, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if filter == nil {
+		filter = &domain.TransactionFilter{}
+	}
+	var transactions []*domain.Transaction
+	for _, tx := range m.transactions {
+		if (filter.FromDate != nil && tx.CreatedAt.Before(*filter.FromDate)) || (filter.ToDate != nil && tx.CreatedAt.After(*filter.ToDate)) {
+			continue
+		}
+		if (filter.Type != nil && tx.Type != *filter.Type) || (filter.Status != nil && tx.Status != *filter.Status) {
+			continue
+		}
+		if (tx.FromAccountID != nil && *tx.FromAccountID == accountID) ||
+			(tx.ToAccountID != nil && *tx.ToAccountID == accountID) {
+			transactions = append(transactions, tx)
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].CreatedAt.After(transactions[j].CreatedAt) })
+	if filter.Offset > 0 {
+		transactions = transactions[min(filter.Offset, len(transactions)):]
+	}
+	if filter.Limit > 0 && len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+	}
+	return transactions, nil
+}
+
+func (m *MockTransactionRepository) EachByAccountID(ctx context.Context, accountID string, filter *domain.TransactionFilter, fn func(*domain.Transaction) error) error {
+	scoped := *filter
+	scoped.Limit, scoped.Offset = 0, 0
+	transactions, err := m.GetByAccountID(ctx, accountID, &scoped)
+	if err != nil {
+		return err
+	}
+	for i := len(transactions) - 1; i >= 0; i-- {
+		if err := fn(transactions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockTransactionRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var transactions []*domain.Transaction
+	for _, id := range ids {
+		if tx, exists := m.transactions[id]; exists {
+			transactions = append(transactions, tx)
+		}
+	}
+	return transactions, nil
+}
+
+func (m *MockTransactionRepository) GetByFilter(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	transactions, err := m.getByFilter(filter)
+	if err != nil || filter.Projection == nil {
+		return transactions, err
+	}
+	projected := make([]*domain.Transaction, len(transactions))
+	for i, transaction := range transactions {
+		if projected[i], err = projectTransaction(transaction, filter.Projection); err != nil {
+			return nil, err
+		}
+	}
+	return projected, nil
+}
+
+func (m *MockTransactionRepository) EachByFilter(ctx context.Context, filter *domain.TransactionFilter, fn func(*domain.Transaction) error) error {
+	transactions, err := m.GetByFilter(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, transaction := range transactions {
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// projectTransaction round-trips a transaction through BSON keeping only the
+// projected document fields, as the database would return it
+func projectTransaction(transaction *domain.Transaction, projection *domain.TransactionProjection) (*domain.Transaction, error) {
+	encoded, err := bson.Marshal(transaction)
+	if err != nil {
+		return nil, err
+	}
+	var document bson.M
+	if err := bson.Unmarshal(encoded, &document); err != nil {
+		return nil, err
+	}
+	fields := projection.DocumentFields()
+	for field := range document {
+		// The ID is returned unless excluded, as by MongoDB
+		if field == "_id" && len(projection.Include) > 0 {
+			continue
+		}
+		keep, listed := fields[field]
+		if len(projection.Include) > 0 && (!listed || keep == 0) || len(projection.Include) == 0 && listed {
+			delete(document, field)
+		}
+	}
+	if encoded, err = bson.Marshal(document); err != nil {
+		return nil, err
+	}
+	var projected domain.Transaction
+	if err := bson.Unmarshal(encoded, &projected); err != nil {
+		return nil, err
+	}
+	return &projected, nil
+}
+
+func (m *MockTransactionRepository) getByFilter(filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var transactions []*domain.Transaction
+	for _, tx := range m.transactions {
+		if !involvesAccount(tx, filter) {
+			continue
+		}
+		if !matchesExternalParty(tx.ExternalSource, filter.ExternalSource) || !matchesExternalParty(tx.ExternalDestination, filter.ExternalDestination) {
+			continue
+		}
+		if filter.SelfTransfer != nil && tx.SelfTransfer != *filter.SelfTransfer {
+			continue
+		}
+		if !matchesDetails(tx, filter) {
+			continue
+		}
+		transactions = append(transactions, tx)
+	}
+	if len(filter.FromAccountIDs) == 0 {
+		if filter.SortBy == "" {
+			return transactions, nil
+		}
+		return pageTransactions(sortTransactions(transactions, filter), filter), nil
+	}
+
+	// Debits from a set of accounts are filtered and paged, newest first
+	var debits []*domain.Transaction
+	for _, tx := range transactions {
+		if debitsFrom(tx, filter) {
+			debits = append(debits, tx)
+		}
+	}
+	sort.Slice(debits, func(i, j int) bool { return debits[i].CreatedAt.After(debits[j].CreatedAt) })
+	if filter.Offset >= len(debits) {
+		return nil, nil
+	}
+	debits = debits[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(debits) {
+		debits = debits[:filter.Limit]
+	}
+	return debits, nil
+}
+
+// Search scores transactions by how many words of the query their
+// description and reference contain, ignoring case, like a text index
+func (m *MockTransactionRepository) Search(ctx context.Context, query string, filter *domain.TransactionFilter) ([]*domain.TransactionMatch, error) {
+	transactions, err := m.GetByFilter(ctx, &domain.TransactionFilter{Currency: filter.Currency, Metadata: filter.Metadata})
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*domain.TransactionMatch
+	for _, tx := range transactions {
+		if filter.AccountID != nil && !sameAccount(tx.FromAccountID, filter.AccountID) && !sameAccount(tx.ToAccountID, filter.AccountID) {
+			continue
+		}
+		text := strings.ToLower(tx.Description + " " + tx.Reference)
+		var score float64
+		for _, word := range strings.Fields(strings.ToLower(query)) {
+			if strings.Contains(text, word) {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, &domain.TransactionMatch{Transaction: tx, Score: score})
+		}
+	}
+	slices.SortFunc(matches, func(a, b *domain.TransactionMatch) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Transaction.ID, b.Transaction.ID)
+	})
+	if filter.Offset >= len(matches) {
+		return nil, nil
+	}
+	matches = matches[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matches) {
+		matches = matches[:filter.Limit]
+	}
+	return matches, nil
+}
+
+// sortTransactions orders transactions by a filter's sort field and order,
+// breaking ties by ID
+func sortTransactions(transactions []*domain.Transaction, filter *domain.TransactionFilter) []*domain.Transaction {
+	compare := func(a, b *domain.Transaction) int {
+		switch filter.SortBy {
+		case domain.TransactionSortAmount:
+			if c := cmp.Compare(a.Amount, b.Amount); c != 0 {
+				return c
+			}
+		case domain.TransactionSortProcessedAt:
+			var aTime, bTime time.Time
+			if a.ProcessedAt != nil {
+				aTime = *a.ProcessedAt
+			}
+			if b.ProcessedAt != nil {
+				bTime = *b.ProcessedAt
+			}
+			if c := aTime.Compare(bTime); c != 0 {
+				return c
+			}
+		default:
+			if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(a.ID, b.ID)
+	}
+	slices.SortFunc(transactions, func(a, b *domain.Transaction) int {
+		if filter.SortOrder == domain.SortAscending {
+			return compare(a, b)
+		}
+		return compare(b, a)
+	})
+	return transactions
+}
+
+// pageTransactions applies a filter's offset and limit
+func pageTransactions(transactions []*domain.Transaction, filter *domain.TransactionFilter) []*domain.Transaction {
+	if filter.Offset >= len(transactions) {
+		return nil
+	}
+	transactions = transactions[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(transactions) {
+		transactions = transactions[:filter.Limit]
+	}
+	return transactions
+}
+
+// matchesDetails reports whether a transaction has the currency, reference
+// and string metadata values a filter asks for, if any
+func matchesDetails(tx *domain.Transaction, filter *domain.TransactionFilter) bool {
+	switch {
+	case filter.Currency != nil && tx.Currency != *filter.Currency,
+		filter.Reference != nil && tx.Reference != *filter.Reference,
+		filter.Reference == nil && filter.ReferencePrefix != nil && !strings.HasPrefix(tx.Reference, *filter.ReferencePrefix):
+		return false
+	}
+	for key, value := range filter.Metadata {
+		if stored, ok := tx.Metadata[key].(string); !ok || stored != value {
+			return false
+		}
+	}
+	for _, tag := range filter.Tags {
+		if !slices.Contains(tx.Tags, tag) {
+			return false
+		}
+	}
+	return len(filter.AnyTags) == 0 || slices.ContainsFunc(filter.AnyTags, func(tag string) bool {
+		return slices.Contains(tx.Tags, tag)
+	})
+}
+
+// matchesExternalParty reports whether an external party has the reference a
+// filter asks for, if any
+func matchesExternalParty(party *domain.ExternalParty, reference *string) bool {
+	return reference == nil || (party != nil && party.Reference == *reference)
+}
+
+// debitsFrom reports whether a transaction debits one of a filter's source
+// accounts and matches its types and start date
+func debitsFrom(tx *domain.Transaction, filter *domain.TransactionFilter) bool {
+	if tx.FromAccountID == nil || !slices.Contains(filter.FromAccountIDs, *tx.FromAccountID) {
+		return false
+	}
+	if len(filter.Types) > 0 && !slices.Contains(filter.Types, tx.Type) {
+		return false
+	}
+	return filter.FromDate == nil || !tx.CreatedAt.Before(*filter.FromDate)
+}
+
+func (m *MockTransactionRepository) Update(ctx context.Context, transaction *domain.Transaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, exists := m.transactions[transaction.ID]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	transaction.UpdatedAt = time.Now()
+	m.transactions[transaction.ID] = transaction
+	return nil
+}
+
+func (m *MockTransactionRepository) UpdateTags(ctx context.Context, id string, tags []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	transaction.Tags = tags
+	transaction.UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MockTransactionRepository) UpdateStatus(ctx context.Context, id string, status domain.TransactionStatus, errorMessage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	m.setStatus(transaction, status, errorMessage)
+	return nil
+}
+
+func (m *MockTransactionRepository) UpdateStatusIf(ctx context.Context, id string, expected, status domain.TransactionStatus, errorMessage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	if transaction.Status != expected {
+		return domain.ErrTransactionAlreadyProcessed
+	}
+	m.setStatus(transaction, status, errorMessage)
+	return nil
+}
+
+func (m *MockTransactionRepository) setStatus(transaction *domain.Transaction, status domain.TransactionStatus, errorMessage string) {
+	transaction.Status = status
+	transaction.ErrorMessage = errorMessage
+	transaction.UpdatedAt = time.Now()
+	transaction.StatusHistory = append(transaction.StatusHistory, domain.StatusChange{Status: status, Timestamp: transaction.UpdatedAt, ErrorMessage: errorMessage})
+	if status == domain.TransactionStatusCompleted {
+		now := time.Now()
+		transaction.ProcessedAt = &now
+	}
+	if status == domain.TransactionStatusCancelled {
+		transaction.ReferenceReserved = false
+	}
+}
+
+func (m *MockTransactionRepository) MarkFailed(ctx context.Context, id string, code domain.FailureCode, errorMessage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	transaction.Status = domain.TransactionStatusFailed
+	transaction.FailureCode = code
+	transaction.ErrorMessage = errorMessage
+	transaction.UpdatedAt = time.Now()
+	transaction.StatusHistory = append(transaction.StatusHistory, domain.StatusChange{Status: transaction.Status, Timestamp: transaction.UpdatedAt, ErrorMessage: errorMessage})
+	return nil
+}
+
+func (m *MockTransactionRepository) MarkCompleted(ctx context.Context, id string, changes []*domain.BalanceChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	m.setStatus(transaction, domain.TransactionStatusCompleted, "")
+	transaction.BalanceChanges = changes
+	return nil
+}
+
+func (m *MockTransactionRepository) Count(ctx context.Context, filter *domain.TransactionFilter) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count int64
+	for _, tx := range m.transactions {
+		if !involvesAccount(tx, filter) {
+			continue
+		}
+		if filter.FromAccountID != nil && !sameAccount(tx.FromAccountID, filter.FromAccountID) {
+			continue
+		}
+		if len(filter.FromAccountIDs) > 0 && !debitsFrom(tx, filter) {
+			continue
+		}
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, tx.Type) {
+			continue
+		}
+		if filter.Status != nil && tx.Status != *filter.Status {
+			continue
+		}
+		if filter.FromDate != nil && tx.CreatedAt.Before(*filter.FromDate) {
+			continue
+		}
+		if filter.ToDate != nil && tx.CreatedAt.After(*filter.ToDate) {
+			continue
+		}
+		if !matchesDetails(tx, filter) {
+			continue
+		}
+		if filter.Type != nil && tx.Type != *filter.Type {
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (m *MockTransactionRepository) SetSettlementBatch(ctx context.Context, ids []string, batchID string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var modified int64
+	for _, id := range ids {
+		transaction, exists := m.transactions[id]
+		if !exists || transaction.SettlementBatchID != "" {
+			continue
+		}
+		transaction.SettlementBatchID = batchID
+		modified++
+	}
+	return modified, nil
+}
+
+func (m *MockTransactionRepository) ClaimReversal(ctx context.Context, id, reversalID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	if transaction.Status != domain.TransactionStatusCompleted || len(transaction.RefundIDs) > 0 {
+		return domain.ErrTransactionNotReversible
+	}
+	if transaction.ReversalID != "" {
+		return domain.ErrTransactionAlreadyReversed
+	}
+	transaction.ReversalID = reversalID
+	return nil
+}
+
+func (m *MockTransactionRepository) RecordRefund(ctx context.Context, id, refundID string, amount domain.Money) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	for _, recorded := range transaction.RefundIDs {
+		if recorded == refundID {
+			return nil
+		}
+	}
+	switch {
+	case transaction.Status != domain.TransactionStatusCompleted:
+		return domain.ErrTransactionNotCompleted
+	case transaction.ReversalID != "":
+		return domain.ErrTransactionAlreadyReversed
+	case transaction.RefundedAmount+amount > transaction.Amount:
+		return domain.ErrRefundExceedsOriginal
+	}
+	transaction.RefundedAmount += amount
+	transaction.RefundIDs = append(transaction.RefundIDs, refundID)
+	return nil
+}
+
+func (m *MockTransactionRepository) ReleaseRefund(ctx context.Context, id, refundID string, amount domain.Money) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return nil
+	}
+	for i, recorded := range transaction.RefundIDs {
+		if recorded == refundID {
+			transaction.RefundedAmount -= amount
+			transaction.RefundIDs = append(transaction.RefundIDs[:i], transaction.RefundIDs[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *MockTransactionRepository) ResolveReversal(ctx context.Context, id, reversalID string, completed bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists || transaction.ReversalID != reversalID {
+		return nil
+	}
+	if completed {
+		transaction.ReversedBy = reversalID
+	} else {
+		transaction.ReversalID = ""
+	}
+	return nil
+}
+
+func (m *MockTransactionRepository) ListDueScheduled(ctx context.Context, now time.Time, limit int) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []*domain.Transaction
+	for _, transaction := range m.transactions {
+		if transaction.Status == domain.TransactionStatusScheduled && !transaction.ScheduledAt.After(now) {
+			due = append(due, transaction)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ScheduledAt.Before(*due[j].ScheduledAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (m *MockTransactionRepository) ClaimProcessing(ctx context.Context, id string) error {
+	return m.UpdateStatusIf(ctx, id, domain.TransactionStatusPending, domain.TransactionStatusProcessing, "")
+}
+
+func (m *MockTransactionRepository) ReleaseScheduled(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	if transaction.Status != domain.TransactionStatusScheduled {
+		return domain.ErrTransactionAlreadyProcessed
+	}
+	transaction.Status = domain.TransactionStatusPending
+	transaction.UpdatedAt = time.Now()
+	transaction.StatusHistory = append(transaction.StatusHistory, domain.StatusChange{Status: domain.TransactionStatusPending, Timestamp: transaction.UpdatedAt})
+	return nil
+}
+
+func (m *MockTransactionRepository) ListStalePending(ctx context.Context, before time.Time, limit int) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var stale []*domain.Transaction
+	for _, transaction := range m.transactions {
+		if transaction.Status == domain.TransactionStatusPending && transaction.UpdatedAt.Before(before) && transaction.Outbox == nil {
+			copied := *transaction
+			stale = append(stale, &copied)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].CreatedAt.Before(stale[j].CreatedAt) })
+	if len(stale) > limit {
+		stale = stale[:limit]
+	}
+	return stale, nil
+}
+
+func (m *MockTransactionRepository) FailPending(ctx context.Context, id string, code domain.FailureCode, errorMessage string) error {
+	return m.FailIf(ctx, id, domain.TransactionStatusPending, code, errorMessage)
+}
+
+func (m *MockTransactionRepository) ListAwaitingApproval(ctx context.Context, before time.Time, limit int) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var awaiting []*domain.Transaction
+	for _, transaction := range m.transactions {
+		if transaction.Status == domain.TransactionStatusPendingApproval && transaction.CreatedAt.Before(before) {
+			copied := *transaction
+			awaiting = append(awaiting, &copied)
+		}
+	}
+	sort.Slice(awaiting, func(i, j int) bool { return awaiting[i].CreatedAt.Before(awaiting[j].CreatedAt) })
+	if len(awaiting) > limit {
+		awaiting = awaiting[:limit]
+	}
+	return awaiting, nil
+}
+
+func (m *MockTransactionRepository) FailIf(ctx context.Context, id string, expected domain.TransactionStatus, code domain.FailureCode, errorMessage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	if transaction.Status != expected {
+		return domain.ErrTransactionAlreadyProcessed
+	}
+	transaction.Status = domain.TransactionStatusFailed
+	transaction.FailureCode = code
+	transaction.ErrorMessage = errorMessage
+	transaction.UpdatedAt = time.Now()
+	transaction.StatusHistory = append(transaction.StatusHistory, domain.StatusChange{Status: transaction.Status, Timestamp: transaction.UpdatedAt, ErrorMessage: errorMessage})
+	return nil
+}
+
+func (m *MockTransactionRepository) RequeuePending(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	if transaction.Status != domain.TransactionStatusPending || transaction.RequeuedAt != nil {
+		return domain.ErrTransactionAlreadyProcessed
+	}
+	now := time.Now()
+	transaction.RequeuedAt = &now
+	transaction.UpdatedAt = now
+	transaction.StatusHistory = append(transaction.StatusHistory, domain.StatusChange{Status: domain.TransactionStatusPending, Timestamp: now, RequeuedBy: "system"})
+	return nil
+}
+
+func (m *MockTransactionRepository) Requeue(ctx context.Context, id string, expected domain.TransactionStatus, code domain.FailureCode, actor string) (*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return nil, domain.ErrTransactionNotFound
+	}
+	if transaction.Status != expected || (expected == domain.TransactionStatusFailed && transaction.FailureCode != code) {
+		return nil, domain.ErrTransactionAlreadyProcessed
+	}
+	now := time.Now()
+	transaction.Status = domain.TransactionStatusPending
+	transaction.FailureCode = ""
+	transaction.ErrorMessage = ""
+	transaction.ProcessedAt = nil
+	transaction.RequeuedAt = &now
+	transaction.UpdatedAt = now
+	transaction.StatusHistory = append(transaction.StatusHistory, domain.StatusChange{Status: domain.TransactionStatusPending, Timestamp: now, RequeuedBy: actor})
+	copied := *transaction
+	return &copied, nil
+}
+
+func (m *MockTransactionRepository) GetByBatchID(ctx context.Context, batchID string) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var legs []*domain.Transaction
+	for _, transaction := range m.transactions {
+		if transaction.BatchID == batchID {
+			copied := *transaction
+			legs = append(legs, &copied)
+		}
+	}
+	sort.Slice(legs, func(i, j int) bool { return legs[i].ID < legs[j].ID })
+	return legs, nil
+}
+
+func (m *MockTransactionRepository) SetOutbox(ctx context.Context, id string, message *domain.OutboxMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	transaction.Outbox = message
+	return nil
+}
+
+func (m *MockTransactionRepository) ListUndispatched(ctx context.Context, before time.Time, limit int) ([]*domain.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var undispatched []*domain.Transaction
+	for _, transaction := range m.transactions {
+		if transaction.Outbox != nil && transaction.Outbox.CreatedAt.Before(before) {
+			copied := *transaction
+			undispatched = append(undispatched, &copied)
+		}
+	}
+	sort.Slice(undispatched, func(i, j int) bool {
+		return undispatched[i].Outbox.CreatedAt.Before(undispatched[j].Outbox.CreatedAt)
+	})
+	if len(undispatched) > limit {
+		undispatched = undispatched[:limit]
+	}
+	return undispatched, nil
+}
+
+func (m *MockTransactionRepository) MarkDispatched(ctx context.Context, id string, sequence int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if transaction, exists := m.transactions[id]; exists {
+		transaction.Outbox = nil
+		if sequence > 0 {
+			transaction.QueueSequence = sequence
+		}
+	}
 	return nil
 }
 
-func (m *MockAccountRepository) List(ctx context.Context, limit, offset int) ([]*domain.Account, error) {
-	var accounts []*domain.Account
-	i := 0
-	for _, account := range m.accounts {
-		if i >= offset && i < offset+limit {
-			accounts = append(accounts, account)
+func (m *MockTransactionRepository) CountUndispatched(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var count int64
+	for _, transaction := range m.transactions {
+		if transaction.Outbox != nil {
+			count++
 		}
-		i++
 	}
-	return accounts, nil
+	return count, nil
 }
 
-// MockTransactionRepository implements domain.TransactionRepository for testing
-type MockTransactionRepository struct {
-	transactions map[string]*domain.Transaction
+func (m *MockTransactionRepository) RecordCompensation(ctx context.Context, id string, compensation *domain.Compensation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
+	}
+	if transaction.Compensation != nil {
+		return nil
+	}
+	copied := *compensation
+	transaction.Compensation = &copied
+	if transaction.Metadata == nil {
+		transaction.Metadata = map[string]interface{}{}
+	}
+	transaction.Metadata["compensated"] = true
+	return nil
 }
 
-func NewMockTransactionRepository() *MockTransactionRepository {
-	return &MockTransactionRepository{
-		transactions: make(map[string]*domain.Transaction),
+func (m *MockTransactionRepository) CompleteCompensation(ctx context.Context, id string, completedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists || transaction.Compensation == nil {
+		return domain.ErrTransactionNotFound
 	}
+	transaction.Compensation.Status = domain.CompensationStatusCompleted
+	transaction.Compensation.CompletedAt = &completedAt
+	return nil
 }
 
-func (m *MockTransactionRepository) Create(ctx context.Context, transaction *domain.Transaction) error {
-	if transaction.ID == "" {
-		transaction.ID = "test-tx-id"
+func (m *MockTransactionRepository) RecordExchange(ctx context.Context, id string, exchange *domain.Exchange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return domain.ErrTransactionNotFound
 	}
-	transaction.CreatedAt = time.Now()
+	transaction.Exchange = exchange
 	transaction.UpdatedAt = time.Now()
-	m.transactions[transaction.ID] = transaction
 	return nil
 }
 
-func (m *MockTransactionRepository) GetByID(ctx context.Context, id string) (*domain.Transaction, error) {
-	transaction, exists := m.transactions[id]
-	if !exists {
-		return nil, domain.ErrTransactionNotFound
+func (m *MockTransactionRepository) SumOutgoing(ctx context.Context, accountID string, since time.Time, excludeID string) (domain.Money, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var total domain.Money
+	for _, transaction := range m.transactions {
+		if transaction.FromAccountID == nil || *transaction.FromAccountID != accountID || transaction.ID == excludeID || transaction.CreatedAt.Before(since) {
+			continue
+		}
+		if transaction.Type != domain.TransactionTypeWithdrawal && transaction.Type != domain.TransactionTypeTransfer {
+			continue
+		}
+		if transaction.Status == domain.TransactionStatusCompleted || transaction.Status == domain.TransactionStatusProcessing {
+			total += transaction.Amount
+		}
 	}
-	return transaction, nil
+	return total, nil
 }
 
-func (m *MockTransactionRepository) GetByAccountID(ctx context.Context, accountID string, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
-	var transactions []*domain.Transaction
-	for _, tx := range m.transactions {
-		if (tx.FromAccountID != nil && *tx.FromAccountID == accountID) ||
-			(tx.ToAccountID != nil && *tx.ToAccountID == accountID) {
-			transactions = append(transactions, tx)
+func (m *MockTransactionRepository) SumDeposits(ctx context.Context, accountIDs []string) (domain.Money, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var total domain.Money
+	for _, transaction := range m.transactions {
+		if transaction.Type != domain.TransactionTypeDeposit || transaction.Status != domain.TransactionStatusCompleted {
+			continue
+		}
+		if transaction.ToAccountID != nil && slices.Contains(accountIDs, *transaction.ToAccountID) {
+			total += transaction.Amount
 		}
 	}
-	return transactions, nil
+	return total, nil
 }
 
-func (m *MockTransactionRepository) GetByFilter(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
-	var transactions []*domain.Transaction
-	for _, tx := range m.transactions {
-		transactions = append(transactions, tx)
+func (m *MockTransactionRepository) SummarizeByAccount(ctx context.Context, accountID string, from, to *time.Time) (*domain.AccountTotals, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	totals := &domain.AccountTotals{}
+	add := func(total *domain.TransactionTotal, amount domain.Money) {
+		total.Amount += amount
+		total.Count++
 	}
-	return transactions, nil
+	for _, transaction := range m.transactions {
+		debited := transaction.FromAccountID != nil && *transaction.FromAccountID == accountID
+		credited := transaction.ToAccountID != nil && *transaction.ToAccountID == accountID
+		if !debited && !credited {
+			continue
+		}
+		if (from != nil && transaction.CreatedAt.Before(*from)) || (to != nil && transaction.CreatedAt.After(*to)) {
+			continue
+		}
+		totals.TransactionCount++
+		if totals.LastTransactionAt == nil || transaction.CreatedAt.After(*totals.LastTransactionAt) {
+			createdAt := transaction.CreatedAt
+			totals.LastTransactionAt = &createdAt
+		}
+		switch transaction.Status {
+		case domain.TransactionStatusPending, domain.TransactionStatusProcessing:
+			totals.PendingCount++
+		case domain.TransactionStatusFailed:
+			totals.FailedCount++
+		case domain.TransactionStatusCompleted:
+			switch {
+			case transaction.Type == domain.TransactionTypeDeposit && credited:
+				add(&totals.Deposits, transaction.Amount)
+			case transaction.Type == domain.TransactionTypeWithdrawal && debited:
+				add(&totals.Withdrawals, transaction.Amount)
+			case transaction.Type == domain.TransactionTypeTransfer && debited:
+				add(&totals.OutgoingTransfers, transaction.Amount)
+			case transaction.Type == domain.TransactionTypeTransfer && credited:
+				amount := transaction.Amount
+				if transaction.Exchange != nil {
+					amount = transaction.Exchange.CreditedAmount
+				}
+				add(&totals.IncomingTransfers, amount)
+			}
+		}
+	}
+	return totals, nil
 }
 
-func (m *MockTransactionRepository) Update(ctx context.Context, transaction *domain.Transaction) error {
-	_, exists := m.transactions[transaction.ID]
-	if !exists {
-		return domain.ErrTransactionNotFound
+func (m *MockTransactionRepository) SummarizeActivity(ctx context.Context, accountIDs []string, since time.Time) (*domain.AccountActivity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	activity := &domain.AccountActivity{}
+	totals := make(map[string]*domain.CurrencyActivity)
+	for _, transaction := range m.transactions {
+		from, to := transaction.FromAccountID, transaction.ToAccountID
+		if !(from != nil && slices.Contains(accountIDs, *from)) && !(to != nil && slices.Contains(accountIDs, *to)) {
+			continue
+		}
+		if activity.LastTransactionAt == nil || transaction.CreatedAt.After(*activity.LastTransactionAt) {
+			createdAt := transaction.CreatedAt
+			activity.LastTransactionAt = &createdAt
+		}
+		if transaction.Status != domain.TransactionStatusCompleted || transaction.CreatedAt.Before(since) {
+			continue
+		}
+		if transaction.Type != domain.TransactionTypeDeposit && transaction.Type != domain.TransactionTypeWithdrawal {
+			continue
+		}
+		if totals[transaction.Currency] == nil {
+			totals[transaction.Currency] = &domain.CurrencyActivity{Currency: transaction.Currency}
+			activity.Currencies = append(activity.Currencies, totals[transaction.Currency])
+		}
+		if transaction.Type == domain.TransactionTypeDeposit {
+			totals[transaction.Currency].Deposited += transaction.Amount
+		} else {
+			totals[transaction.Currency].Withdrawn += transaction.Amount
+		}
 	}
-	transaction.UpdatedAt = time.Now()
-	m.transactions[transaction.ID] = transaction
-	return nil
+	return activity, nil
 }
 
-func (m *MockTransactionRepository) UpdateStatus(ctx context.Context, id string, status domain.TransactionStatus, errorMessage string) error {
-	transaction, exists := m.transactions[id]
-	if !exists {
-		return domain.ErrTransactionNotFound
+func (m *MockTransactionRepository) SumMovements(ctx context.Context, accountIDs []string) (map[string]domain.Money, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	totals := make(map[string]domain.Money)
+	for _, transaction := range m.transactions {
+		for accountID, amount := range transaction.Movements() {
+			if slices.Contains(accountIDs, accountID) {
+				totals[accountID] += amount
+			}
+		}
 	}
-	transaction.Status = status
-	transaction.ErrorMessage = errorMessage
-	transaction.UpdatedAt = time.Now()
-	if status == domain.TransactionStatusCompleted {
-		now := time.Now()
-		transaction.ProcessedAt = &now
+	return totals, nil
+}
+
+func (m *MockTransactionRepository) SumMovementsByType(ctx context.Context) ([]*domain.MovementTotal, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	totals := make(map[[2]string]*domain.MovementTotal)
+	total := func(transactionType domain.TransactionType, currency string) *domain.MovementTotal {
+		key := [2]string{string(transactionType), currency}
+		if totals[key] == nil {
+			totals[key] = &domain.MovementTotal{Type: transactionType, Currency: currency}
+		}
+		return totals[key]
 	}
-	return nil
+	for _, transaction := range m.transactions {
+		for accountID, amount := range transaction.Movements() {
+			if transaction.FromAccountID != nil && *transaction.FromAccountID == accountID {
+				total(transaction.Type, transaction.Currency).Debited -= amount
+				continue
+			}
+			currency := transaction.Currency
+			if transaction.Exchange != nil {
+				currency = transaction.Exchange.CreditedCurrency
+			}
+			total(transaction.Type, currency).Credited += amount
+		}
+	}
+	var result []*domain.MovementTotal
+	for _, movementTotal := range totals {
+		result = append(result, movementTotal)
+	}
+	return result, nil
 }
 
-func (m *MockTransactionRepository) Count(ctx context.Context, filter *domain.TransactionFilter) (int64, error) {
-	return int64(len(m.transactions)), nil
+// ArchiveBefore flags the transactions it archives in place, standing in for
+// both collections
+func (m *MockTransactionRepository) ArchiveBefore(ctx context.Context, before time.Time, limit int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []*domain.Transaction
+	for _, transaction := range m.transactions {
+		if !transaction.Archived && transaction.Archivable() && transaction.CreatedAt.Before(before) {
+			due = append(due, transaction)
+		}
+	}
+	slices.SortFunc(due, func(a, b *domain.Transaction) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	archivedAt := time.Now()
+	for _, transaction := range due {
+		transaction.Archived = true
+		transaction.ArchivedAt = &archivedAt
+		transaction.ReferenceReserved = false
+	}
+	return len(due), nil
 }
 
 func TestAccountUseCase_CreateAccount(t *testing.T) {
@@ -198,22 +1284,23 @@ func TestAccountUseCase_CreateAccount(t *testing.T) {
 	tests := []struct {
 		name           string
 		userID         string
-		initialBalance float64
+		initialBalance domain.Money
 		currency       string
+		accountType    domain.AccountType
 		expectError    bool
 		expectedError  error
 	}{
 		{
 			name:           "valid account creation",
 			userID:         "user1",
-			initialBalance: 1000.0,
+			initialBalance: 100000,
 			currency:       "USD",
 			expectError:    false,
 		},
 		{
 			name:           "negative balance",
 			userID:         "user2",
-			initialBalance: -100.0,
+			initialBalance: -10000,
 			currency:       "USD",
 			expectError:    true,
 			expectedError:  domain.ErrInvalidAmount,
@@ -221,19 +1308,51 @@ func TestAccountUseCase_CreateAccount(t *testing.T) {
 		{
 			name:           "empty currency",
 			userID:         "user3",
-			initialBalance: 500.0,
+			initialBalance: 50000,
 			currency:       "",
 			expectError:    true,
 			expectedError:  domain.ErrMissingCurrency,
 		},
+		{
+			name:           "unsupported currency",
+			userID:         "user4",
+			initialBalance: 50000,
+			currency:       "XXX",
+			expectError:    true,
+			expectedError:  domain.ErrUnsupportedCurrency,
+		},
+		{
+			name:           "lowercase currency",
+			userID:         "user5",
+			initialBalance: 50000,
+			currency:       "eur",
+			expectError:    false,
+		},
 		{
 			name:           "duplicate account",
 			userID:         "user1", // Same user as first test
-			initialBalance: 500.0,
+			initialBalance: 50000,
 			currency:       "USD", // Same currency as first test
 			expectError:    true,
 			expectedError:  domain.ErrAccountExists,
 		},
+		{
+			name:           "savings alongside checking",
+			userID:         "user1",
+			initialBalance: 50000,
+			currency:       "USD",
+			accountType:    domain.AccountTypeSavings,
+			expectError:    false,
+		},
+		{
+			name:           "unknown type",
+			userID:         "user6",
+			initialBalance: 50000,
+			currency:       "USD",
+			accountType:    "brokerage",
+			expectError:    true,
+			expectedError:  domain.ErrInvalidAccountType,
+		},
 	}
 
 	for _, tt := range tests {
@@ -243,6 +1362,8 @@ func TestAccountUseCase_CreateAccount(t *testing.T) {
 				tt.userID,
 				tt.initialBalance,
 				tt.currency,
+				tt.accountType,
+				domain.AccountDetails{},
 			)
 
 			if tt.expectError {
@@ -266,14 +1387,21 @@ func TestAccountUseCase_CreateAccount(t *testing.T) {
 					t.Errorf("Expected userID %s, got %s", tt.userID, account.UserID)
 				}
 				if account.Balance != tt.initialBalance {
-					t.Errorf("Expected balance %f, got %f", tt.initialBalance, account.Balance)
+					t.Errorf("Expected balance %d, got %d", tt.initialBalance, account.Balance)
 				}
-				if account.Currency != tt.currency {
-					t.Errorf("Expected currency %s, got %s", tt.currency, account.Currency)
+				if account.Currency != domain.NormalizeCurrency(tt.currency) {
+					t.Errorf("Expected currency %s, got %s", domain.NormalizeCurrency(tt.currency), account.Currency)
 				}
 				if account.Status != "active" {
 					t.Errorf("Expected status 'active', got %s", account.Status)
 				}
+				expectedType := tt.accountType
+				if expectedType == "" {
+					expectedType = domain.AccountTypeChecking
+				}
+				if account.Type != expectedType {
+					t.Errorf("Expected type %s, got %s", expectedType, account.Type)
+				}
 			}
 		})
 	}
@@ -288,7 +1416,7 @@ func TestAccountUseCase_GetAccount(t *testing.T) {
 	testAccount := &domain.Account{
 		ID:       "test-account-1",
 		UserID:   "user1",
-		Balance:  1000.0,
+		Balance:  100000,
 		Currency: "USD",
 		Status:   "active",
 	}
@@ -341,3 +1469,327 @@ func TestAccountUseCase_GetAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestAccountUseCase_UpdateAccountStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		from          domain.AccountStatus
+		to            domain.AccountStatus
+		expectedError error
+	}{
+		{"freeze active account", domain.AccountStatusActive, domain.AccountStatusFrozen, domain.ErrStatusReasonRequired},
+		{"unfreeze frozen account", domain.AccountStatusFrozen, domain.AccountStatusActive, domain.ErrStatusReasonRequired},
+		{"deactivate frozen account", domain.AccountStatusFrozen, domain.AccountStatusInactive, domain.ErrStatusReasonRequired},
+		{"reactivate inactive account", domain.AccountStatusInactive, domain.AccountStatusActive, nil},
+		{"same status is a no-op", domain.AccountStatusInactive, domain.AccountStatusInactive, nil},
+		{"reactivate closed account", domain.AccountStatusClosed, domain.AccountStatusActive, domain.ErrInvalidStatusTransition},
+		{"deactivate closed account", domain.AccountStatusClosed, domain.AccountStatusInactive, domain.ErrInvalidStatusTransition},
+		{"unknown status", domain.AccountStatusActive, domain.AccountStatus("actve"), domain.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := NewMockAccountRepository()
+			accountUseCase := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+			accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: tt.from, Version: 1}
+
+			account, err := accountUseCase.UpdateAccountStatus(context.Background(), "acc-1", tt.to)
+			if err != tt.expectedError {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+
+			expected := tt.to
+			if tt.expectedError != nil {
+				expected = tt.from
+			} else if account.Status != tt.to {
+				t.Errorf("Expected returned status %s, got %s", tt.to, account.Status)
+			}
+			if status := accountRepo.accounts["acc-1"].Status; status != expected {
+				t.Errorf("Expected stored status %s, got %s", expected, status)
+			}
+		})
+	}
+}
+
+func TestAccountUseCase_ActivateAccount(t *testing.T) {
+	tests := []struct {
+		name          string
+		from          domain.AccountStatus
+		expectedError error
+	}{
+		{"reactivate inactive account", domain.AccountStatusInactive, nil},
+		{"unfreeze frozen account", domain.AccountStatusFrozen, domain.ErrStatusReasonRequired},
+		{"already active", domain.AccountStatusActive, domain.ErrAccountAlreadyActive},
+		{"closed account", domain.AccountStatusClosed, domain.ErrInvalidStatusTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := NewMockAccountRepository()
+			accountUseCase := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+			accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: tt.from, Version: 1}
+
+			ctx := domain.ContextWithPrincipal(context.Background(), &domain.Principal{Admin: true})
+			_, err := accountUseCase.ActivateAccount(ctx, "acc-1")
+			if err != tt.expectedError {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+
+			stored := accountRepo.accounts["acc-1"]
+			if tt.expectedError != nil {
+				if stored.Status != tt.from || stored.StatusChangedAt != nil {
+					t.Errorf("Expected the account untouched, got %s changed at %v", stored.Status, stored.StatusChangedAt)
+				}
+				return
+			}
+			if stored.Status != domain.AccountStatusActive {
+				t.Errorf("Expected the account active, got %s", stored.Status)
+			}
+			if stored.StatusChangedAt == nil || stored.StatusChangedBy != "admin" {
+				t.Errorf("Expected the change audited to admin, got %q at %v", stored.StatusChangedBy, stored.StatusChangedAt)
+			}
+		})
+	}
+}
+
+func TestAccountUseCase_DeactivateAccountWithPendingDebits(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountUseCase := usecase.NewAccountUseCase(accountRepo, transactionRepo)
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	from, to := "acc-1", "acc-2"
+	transactionRepo.transactions["tx-1"] = &domain.Transaction{ID: "tx-1", Type: domain.TransactionTypeTransfer, FromAccountID: &from, ToAccountID: &to, Status: domain.TransactionStatusScheduled}
+	ctx := domain.ContextWithPrincipal(context.Background(), &domain.Principal{UserID: "user1"})
+
+	if err := accountUseCase.DeactivateAccount(ctx, "acc-1", false); err != domain.ErrPendingDebits {
+		t.Fatalf("Expected %v, got %v", domain.ErrPendingDebits, err)
+	}
+	if status := accountRepo.accounts["acc-1"].Status; status != domain.AccountStatusActive {
+		t.Fatalf("Expected the account to stay active, got %s", status)
+	}
+
+	if err := accountUseCase.DeactivateAccount(ctx, "acc-1", true); err != nil {
+		t.Fatalf("Expected a forced deactivation to succeed, got %v", err)
+	}
+	stored := accountRepo.accounts["acc-1"]
+	if stored.Status != domain.AccountStatusInactive || stored.StatusChangedBy != "user1" {
+		t.Errorf("Expected the account inactive and audited to user1, got %s by %q", stored.Status, stored.StatusChangedBy)
+	}
+}
+
+func TestAccountUseCase_CloseAccount(t *testing.T) {
+	from, to := "acc-2", "acc-1"
+	tests := []struct {
+		name          string
+		account       domain.Account
+		pending       *domain.Transaction
+		expectedError error
+	}{
+		{"zero balance", domain.Account{Status: domain.AccountStatusInactive}, nil, nil},
+		{"residual balance", domain.Account{Status: domain.AccountStatusActive, Balance: 1250}, nil, domain.ErrResidualBalance},
+		{"incoming transaction pending", domain.Account{Status: domain.AccountStatusActive},
+			&domain.Transaction{ID: "tx-1", Type: domain.TransactionTypeTransfer, FromAccountID: &from, ToAccountID: &to, Status: domain.TransactionStatusProcessing}, domain.ErrPendingTransactions},
+		{"already closed", domain.Account{Status: domain.AccountStatusClosed}, nil, domain.ErrAccountClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := NewMockAccountRepository()
+			transactionRepo := NewMockTransactionRepository()
+			accountUseCase := usecase.NewAccountUseCase(accountRepo, transactionRepo)
+			account := tt.account
+			account.ID, account.UserID, account.Currency, account.Version = "acc-1", "user1", "USD", 1
+			accountRepo.accounts["acc-1"] = &account
+			if tt.pending != nil {
+				transactionRepo.transactions[tt.pending.ID] = tt.pending
+			}
+
+			_, err := accountUseCase.CloseAccount(context.Background(), "acc-1")
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+
+			var residualErr *domain.ResidualBalanceError
+			if errors.As(err, &residualErr) && residualErr.Balance != 1250 {
+				t.Errorf("Expected 12.50 remaining, got %d", residualErr.Balance)
+			}
+			closed := accountRepo.accounts["acc-1"].Status == domain.AccountStatusClosed
+			if closed != (tt.expectedError == nil || tt.expectedError == domain.ErrAccountClosed) {
+				t.Errorf("Expected the account closed only on success, got %s", accountRepo.accounts["acc-1"].Status)
+			}
+		})
+	}
+}
+
+func TestAccountUseCase_ListAccountsHidesClosed(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	accountUseCase := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+	accountRepo.accounts["open"] = &domain.Account{ID: "open", Status: domain.AccountStatusActive}
+	accountRepo.accounts["closed"] = &domain.Account{ID: "closed", Status: domain.AccountStatusClosed}
+
+	for includeClosed, expected := range map[bool]int{false: 1, true: 2} {
+		page, err := accountUseCase.ListAccounts(context.Background(), &domain.AccountFilter{IncludeClosed: includeClosed}, false)
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if len(page.Accounts) != expected {
+			t.Errorf("Expected %d accounts with includeClosed %v, got %d", expected, includeClosed, len(page.Accounts))
+		}
+	}
+}
+
+func TestAccountUseCase_ListAccountsPagination(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	accountUseCase := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("acc-%d", i)
+		accountRepo.accounts[id] = &domain.Account{ID: id, Currency: "USD", Status: domain.AccountStatusActive, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+	}
+
+	tests := []struct {
+		offset          int
+		includeTotal    bool
+		expectedCount   int
+		expectedHasMore bool
+	}{
+		{0, true, 2, true},
+		{2, true, 2, true},
+		{4, true, 1, false},
+		{3, false, 2, false},
+	}
+
+	for _, tt := range tests {
+		page, err := accountUseCase.ListAccounts(context.Background(), &domain.AccountFilter{Limit: 2, Offset: tt.offset}, tt.includeTotal)
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if len(page.Accounts) != tt.expectedCount || page.HasMore != tt.expectedHasMore {
+			t.Errorf("Expected %d accounts and has_more %v at offset %d, got %d and %v", tt.expectedCount, tt.expectedHasMore, tt.offset, len(page.Accounts), page.HasMore)
+		}
+		if tt.includeTotal != (page.Total != nil) || (page.Total != nil && *page.Total != 5) {
+			t.Errorf("Expected the total of 5 only when asked for at offset %d, got %v", tt.offset, page.Total)
+		}
+	}
+}
+
+func TestAccountUseCase_UpdateOverdraftLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         domain.Decimal
+		expected      domain.Money
+		expectedError error
+	}{
+		{"set limit", "500.00", 50000, nil},
+		{"remove limit", "0", 0, nil},
+		{"negative limit", "-1", 0, domain.ErrInvalidOverdraft},
+		{"too precise", "1.001", 0, domain.ErrInvalidPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := NewMockAccountRepository()
+			accountUseCase := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+			accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: domain.AccountStatusActive, OverdraftLimit: 100, Version: 1}
+
+			account, err := accountUseCase.UpdateOverdraftLimit(context.Background(), "acc-1", tt.limit)
+			if !errors.Is(err, tt.expectedError) || (err == nil) != (tt.expectedError == nil) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				if limit := accountRepo.accounts["acc-1"].OverdraftLimit; limit != 100 {
+					t.Errorf("Expected limit to be unchanged, got %s", limit.Format("USD"))
+				}
+				return
+			}
+			if account.OverdraftLimit != tt.expected {
+				t.Errorf("Expected limit %s, got %s", tt.expected.Format("USD"), account.OverdraftLimit.Format("USD"))
+			}
+		})
+	}
+}
+
+func TestAccountUseCase_UpdateLimits(t *testing.T) {
+	decimal := func(value domain.Decimal) *domain.Decimal { return &value }
+	tests := []struct {
+		name           string
+		maxTransaction *domain.Decimal
+		dailyOutgoing  *domain.Decimal
+		expected       domain.AccountLimits
+		expectedError  error
+	}{
+		{"set both", decimal("250.00"), decimal("1000"), domain.AccountLimits{MaxTransactionAmount: 25000, DailyOutgoing: 100000}, nil},
+		{"keep omitted", nil, decimal("1000"), domain.AccountLimits{MaxTransactionAmount: 100, DailyOutgoing: 100000}, nil},
+		{"remove limit", decimal("0"), nil, domain.AccountLimits{DailyOutgoing: 200}, nil},
+		{"negative limit", decimal("-1"), nil, domain.AccountLimits{}, domain.ErrInvalidLimit},
+		{"too precise", nil, decimal("1.001"), domain.AccountLimits{}, domain.ErrInvalidPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := NewMockAccountRepository()
+			accountUseCase := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+			original := domain.AccountLimits{MaxTransactionAmount: 100, DailyOutgoing: 200}
+			accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: domain.AccountStatusActive, AccountLimits: original, Version: 1}
+
+			account, err := accountUseCase.UpdateLimits(context.Background(), "acc-1", tt.maxTransaction, tt.dailyOutgoing)
+			if !errors.Is(err, tt.expectedError) || (err == nil) != (tt.expectedError == nil) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				if limits := accountRepo.accounts["acc-1"].AccountLimits; limits != original {
+					t.Errorf("Expected limits to be unchanged, got %+v", limits)
+				}
+				return
+			}
+			if account.AccountLimits != tt.expected {
+				t.Errorf("Expected limits %+v, got %+v", tt.expected, account.AccountLimits)
+			}
+		})
+	}
+}
+
+func TestAccountUseCase_UpdateAccount(t *testing.T) {
+	threshold, precise := domain.Decimal("25.00"), domain.Decimal("1.001")
+	set := domain.Money(2500)
+	tests := []struct {
+		name          string
+		update        *domain.AccountUpdate
+		expected      *domain.Money
+		expectedError error
+	}{
+		{"set threshold", &domain.AccountUpdate{Version: 1, LowBalanceThreshold: &threshold}, &set, nil},
+		{"clear threshold", &domain.AccountUpdate{Version: 1, ClearLowBalanceThreshold: true}, nil, nil},
+		{"too precise", &domain.AccountUpdate{Version: 1, LowBalanceThreshold: &precise}, nil, domain.ErrInvalidPrecision},
+		{"stale version", &domain.AccountUpdate{Version: 0, LowBalanceThreshold: &threshold}, nil, domain.ErrConcurrentUpdate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := NewMockAccountRepository()
+			accountUseCase := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+			original := domain.Money(10000)
+			accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: domain.AccountStatusActive, LowBalanceThreshold: &original, BelowThreshold: true, Version: 1}
+
+			account, err := accountUseCase.UpdateAccount(context.Background(), "acc-1", tt.update)
+			if !errors.Is(err, tt.expectedError) || (err == nil) != (tt.expectedError == nil) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				if stored := accountRepo.accounts["acc-1"]; *stored.LowBalanceThreshold != original || !stored.BelowThreshold {
+					t.Errorf("Expected the threshold and flag unchanged, got %v %v", *stored.LowBalanceThreshold, stored.BelowThreshold)
+				}
+				return
+			}
+
+			if (account.LowBalanceThreshold == nil) != (tt.expected == nil) || (tt.expected != nil && *account.LowBalanceThreshold != *tt.expected) {
+				t.Errorf("Expected threshold %v, got %v", tt.expected, account.LowBalanceThreshold)
+			}
+			// The next debit under the new threshold notifies afresh
+			if account.BelowThreshold || accountRepo.accounts["acc-1"].BelowThreshold {
+				t.Error("Expected the below-threshold flag cleared")
+			}
+		})
+	}
+}
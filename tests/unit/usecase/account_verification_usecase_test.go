@@ -0,0 +1,277 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockMicroDepositRepository implements domain.MicroDepositRepository for testing
+type MockMicroDepositRepository struct {
+	challenges map[string]*domain.MicroDepositChallenge
+}
+
+func NewMockMicroDepositRepository() *MockMicroDepositRepository {
+	return &MockMicroDepositRepository{
+		challenges: make(map[string]*domain.MicroDepositChallenge),
+	}
+}
+
+func (m *MockMicroDepositRepository) Create(ctx context.Context, challenge *domain.MicroDepositChallenge) error {
+	for _, existing := range m.challenges {
+		if existing.AccountID == challenge.AccountID && existing.Status == domain.MicroDepositStatusPending {
+			return domain.ErrMicroDepositPending
+		}
+	}
+	m.challenges[challenge.ID] = challenge
+	return nil
+}
+
+func (m *MockMicroDepositRepository) GetPendingByAccountID(ctx context.Context, accountID string) (*domain.MicroDepositChallenge, error) {
+	for _, challenge := range m.challenges {
+		if challenge.AccountID == accountID && challenge.Status == domain.MicroDepositStatusPending {
+			return challenge, nil
+		}
+	}
+	return nil, domain.ErrMicroDepositNotFound
+}
+
+func (m *MockMicroDepositRepository) RecordAttempt(ctx context.Context, id string) (int, error) {
+	challenge, exists := m.challenges[id]
+	if !exists || challenge.Status != domain.MicroDepositStatusPending {
+		return 0, domain.ErrMicroDepositNotFound
+	}
+	challenge.Attempts++
+	return challenge.Attempts, nil
+}
+
+func (m *MockMicroDepositRepository) Resolve(ctx context.Context, id string, status domain.MicroDepositStatus) error {
+	challenge, exists := m.challenges[id]
+	if !exists || challenge.Status != domain.MicroDepositStatusPending {
+		return domain.ErrMicroDepositNotFound
+	}
+	challenge.Status = status
+	return nil
+}
+
+func (m *MockMicroDepositRepository) MarkClawedBack(ctx context.Context, id string, at time.Time) error {
+	if challenge, exists := m.challenges[id]; exists && challenge.ClawedBackAt == nil {
+		challenge.ClawedBackAt = &at
+	}
+	return nil
+}
+
+func (m *MockMicroDepositRepository) ListExpiredPending(ctx context.Context, now time.Time, limit int) ([]*domain.MicroDepositChallenge, error) {
+	var challenges []*domain.MicroDepositChallenge
+	for _, challenge := range m.challenges {
+		if challenge.Status == domain.MicroDepositStatusPending && challenge.IsExpired(now) {
+			challenges = append(challenges, challenge)
+		}
+	}
+	return challenges, nil
+}
+
+type verificationFixture struct {
+	service         domain.AccountVerificationService
+	accountRepo     *MockAccountRepository
+	challengeRepo   *MockMicroDepositRepository
+	transactionRepo *MockTransactionRepository
+	clock           *time.Time
+}
+
+func newVerificationFixture() *verificationFixture {
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	accountRepo := NewMockAccountRepository()
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Balance: 10000, Currency: "USD", Status: "active", Version: 1}
+	challengeRepo := NewMockMicroDepositRepository()
+	transactionRepo := NewMockTransactionRepository()
+	transactionService := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions")
+
+	f := &verificationFixture{
+		accountRepo:     accountRepo,
+		challengeRepo:   challengeRepo,
+		transactionRepo: transactionRepo,
+		clock:           &clock,
+	}
+	f.service = usecase.NewAccountVerificationUseCase(
+		accountRepo,
+		challengeRepo,
+		transactionService,
+		72*time.Hour,
+		3,
+		usecase.WithVerificationClock(func() time.Time { return *f.clock }),
+	)
+	return f
+}
+
+func (f *verificationFixture) start(t *testing.T) *domain.MicroDepositChallenge {
+	t.Helper()
+	challenge, err := f.service.StartMicroDeposits(context.Background(), "acc-1")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return challenge
+}
+
+// assertClawedBack checks that both credits were reversed by system withdrawals
+func (f *verificationFixture) assertClawedBack(t *testing.T, challenge *domain.MicroDepositChallenge) {
+	t.Helper()
+	amounts := challenge.Amounts()
+	for i, id := range challenge.ClawbackIDs() {
+		clawback, exists := f.transactionRepo.transactions[id]
+		if !exists {
+			t.Errorf("Expected clawback %s to be submitted", id)
+			continue
+		}
+		if clawback.Type != domain.TransactionTypeWithdrawal || clawback.Amount != amounts[i] || !clawback.System {
+			t.Errorf("Unexpected clawback %+v", clawback)
+		}
+	}
+	if challenge.ClawedBackAt == nil {
+		t.Errorf("Expected challenge to record the clawback")
+	}
+}
+
+// confirmation returns a challenge's amounts as the owner would enter them
+func confirmation(challenge *domain.MicroDepositChallenge) []domain.Decimal {
+	return []domain.Decimal{
+		domain.Decimal(challenge.AmountOne.Format(challenge.Currency)),
+		domain.Decimal(challenge.AmountTwo.Format(challenge.Currency)),
+	}
+}
+
+func TestAccountVerification_StartSubmitsSystemDeposits(t *testing.T) {
+	f := newVerificationFixture()
+	challenge := f.start(t)
+
+	amounts := challenge.Amounts()
+	for i, id := range challenge.DepositIDs() {
+		deposit, exists := f.transactionRepo.transactions[id]
+		if !exists {
+			t.Fatalf("Expected deposit %s to be submitted", id)
+		}
+		if deposit.Type != domain.TransactionTypeDeposit || deposit.Amount != amounts[i] || !deposit.System {
+			t.Errorf("Unexpected deposit %+v", deposit)
+		}
+	}
+	if !challenge.ExpiresAt.Equal(f.clock.Add(72 * time.Hour)) {
+		t.Errorf("Expected expiry 72h after start, got %v", challenge.ExpiresAt)
+	}
+
+	if _, err := f.service.StartMicroDeposits(context.Background(), "acc-1"); err != domain.ErrMicroDepositPending {
+		t.Errorf("Expected %v for a second start, got %v", domain.ErrMicroDepositPending, err)
+	}
+}
+
+func TestAccountVerification_SuccessMarksAccountVerified(t *testing.T) {
+	f := newVerificationFixture()
+	challenge := f.start(t)
+
+	amounts := challenge.Amounts()
+	account, err := f.service.VerifyMicroDeposits(context.Background(), "acc-1", []domain.Decimal{domain.Decimal(amounts[1].Format("USD")), domain.Decimal(amounts[0].Format("USD"))})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if !account.Verified || !f.accountRepo.accounts["acc-1"].Verified {
+		t.Errorf("Expected account to be marked verified")
+	}
+	if challenge.Status != domain.MicroDepositStatusVerified {
+		t.Errorf("Expected challenge status verified, got %s", challenge.Status)
+	}
+	f.assertClawedBack(t, challenge)
+
+	if _, err := f.service.StartMicroDeposits(context.Background(), "acc-1"); err != domain.ErrAccountAlreadyVerified {
+		t.Errorf("Expected %v after verification, got %v", domain.ErrAccountAlreadyVerified, err)
+	}
+}
+
+func TestAccountVerification_LimitsAttempts(t *testing.T) {
+	f := newVerificationFixture()
+	challenge := f.start(t)
+
+	wrong := []domain.Decimal{"1.50", "2.50"}
+	expected := []error{
+		domain.ErrMicroDepositMismatch,
+		domain.ErrMicroDepositMismatch,
+		domain.ErrMicroDepositAttemptsExceeded,
+		domain.ErrMicroDepositNotFound,
+	}
+	for i, want := range expected {
+		if _, err := f.service.VerifyMicroDeposits(context.Background(), "acc-1", wrong); err != want {
+			t.Errorf("Attempt %d: expected %v, got %v", i+1, want, err)
+		}
+	}
+
+	// The correct amounts no longer help once the challenge has failed
+	if _, err := f.service.VerifyMicroDeposits(context.Background(), "acc-1", confirmation(challenge)); err != domain.ErrMicroDepositNotFound {
+		t.Errorf("Expected %v after failure, got %v", domain.ErrMicroDepositNotFound, err)
+	}
+
+	if challenge.Status != domain.MicroDepositStatusFailed {
+		t.Errorf("Expected challenge status failed, got %s", challenge.Status)
+	}
+	if f.accountRepo.accounts["acc-1"].Verified {
+		t.Errorf("Expected account to remain unverified")
+	}
+	f.assertClawedBack(t, challenge)
+}
+
+func TestAccountVerification_ClawsBackOnExpiry(t *testing.T) {
+	f := newVerificationFixture()
+	challenge := f.start(t)
+
+	*f.clock = f.clock.Add(72*time.Hour - time.Second)
+	if expired, err := f.service.ExpireMicroDeposits(context.Background()); err != nil || expired != 0 {
+		t.Fatalf("Expected nothing to expire yet, got %d, %v", expired, err)
+	}
+
+	*f.clock = f.clock.Add(time.Second)
+	expired, err := f.service.ExpireMicroDeposits(context.Background())
+	if err != nil || expired != 1 {
+		t.Fatalf("Expected one expired challenge, got %d, %v", expired, err)
+	}
+
+	if challenge.Status != domain.MicroDepositStatusExpired {
+		t.Errorf("Expected challenge status expired, got %s", challenge.Status)
+	}
+	f.assertClawedBack(t, challenge)
+
+	// Expired challenges free the account for a new attempt
+	f.start(t)
+}
+
+func TestAccountVerification_VerifyAfterExpiry(t *testing.T) {
+	f := newVerificationFixture()
+	challenge := f.start(t)
+
+	*f.clock = f.clock.Add(73 * time.Hour)
+	if _, err := f.service.VerifyMicroDeposits(context.Background(), "acc-1", confirmation(challenge)); err != domain.ErrMicroDepositExpired {
+		t.Errorf("Expected %v, got %v", domain.ErrMicroDepositExpired, err)
+	}
+	f.assertClawedBack(t, challenge)
+}
+
+func TestAccountVerification_SkipsClawbackOfFailedDeposits(t *testing.T) {
+	f := newVerificationFixture()
+	challenge := f.start(t)
+
+	depositIDs := challenge.DepositIDs()
+	f.transactionRepo.transactions[depositIDs[0]].Status = domain.TransactionStatusFailed
+
+	*f.clock = f.clock.Add(73 * time.Hour)
+	if _, err := f.service.ExpireMicroDeposits(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	clawbackIDs := challenge.ClawbackIDs()
+	if _, exists := f.transactionRepo.transactions[clawbackIDs[0]]; exists {
+		t.Errorf("Expected no clawback for the failed deposit")
+	}
+	if _, exists := f.transactionRepo.transactions[clawbackIDs[1]]; !exists {
+		t.Errorf("Expected clawback for the completed deposit")
+	}
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"banking-ledger/internal/domain"
+)
+
+func adjustment(amount domain.Money) *domain.AdjustmentRequest {
+	return &domain.AdjustmentRequest{
+		AccountID:  "bob",
+		Amount:     amount,
+		Currency:   "usd",
+		Reason:     "Reconciliation 2024-03",
+		OperatorID: "ops-17",
+	}
+}
+
+func TestAdjustBalance_CreditAndDebit(t *testing.T) {
+	f := newLedgerFixture()
+	ctx := context.Background()
+
+	credit, err := f.service.AdjustBalance(ctx, adjustment(1250))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if credit.Type != domain.TransactionTypeAdjustment || credit.Status != domain.TransactionStatusCompleted ||
+		credit.ToAccountID == nil || *credit.ToAccountID != "bob" || credit.Amount != 1250 {
+		t.Errorf("Unexpected credit adjustment %+v", credit)
+	}
+	if credit.Metadata["reason"] != "Reconciliation 2024-03" || credit.Metadata["operator_id"] != "ops-17" {
+		t.Errorf("Expected reason and operator in metadata, got %v", credit.Metadata)
+	}
+
+	debit, err := f.service.AdjustBalance(ctx, adjustment(-250))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if debit.FromAccountID == nil || *debit.FromAccountID != "bob" || debit.Amount != 250 {
+		t.Errorf("Unexpected debit adjustment %+v", debit)
+	}
+
+	if balance := f.accountRepo.accounts["bob"].Balance; balance != 6000 {
+		t.Errorf("Expected balance 60.00, got %d", balance)
+	}
+	entries, _ := f.ledgerRepo.GetByAccountID(ctx, "bob", 10, 0)
+	if len(entries) != 2 || entries[0].ID != debit.ID+"-debit" || entries[1].ID != credit.ID+"-credit" {
+		t.Errorf("Expected a ledger entry per adjustment, got %+v", entries)
+	}
+	if stored := f.transactionRepo.transactions[debit.ID]; stored.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the stored adjustment completed, got %s", stored.Status)
+	}
+}
+
+func TestAdjustBalance_NegativeBalance(t *testing.T) {
+	f := newLedgerFixture()
+	ctx := context.Background()
+
+	_, err := f.service.AdjustBalance(ctx, adjustment(-5001))
+	if err != domain.ErrNegativeBalance {
+		t.Fatalf("Expected %v, got %v", domain.ErrNegativeBalance, err)
+	}
+	if balance := f.accountRepo.accounts["bob"].Balance; balance != 5000 {
+		t.Errorf("Expected the balance untouched, got %d", balance)
+	}
+
+	// The rejected attempt stays on record for the audit trail
+	failed := 0
+	for _, transaction := range f.transactionRepo.transactions {
+		if transaction.Type == domain.TransactionTypeAdjustment && transaction.Status == domain.TransactionStatusFailed &&
+			transaction.FailureCode == domain.FailureCodeInsufficientFunds {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expected one failed adjustment on record, got %d", failed)
+	}
+
+	override := adjustment(-5001)
+	override.AllowNegativeBalance = true
+	if _, err := f.service.AdjustBalance(ctx, override); err != nil {
+		t.Fatalf("Expected the override to apply, got %v", err)
+	}
+	if balance := f.accountRepo.accounts["bob"].Balance; balance != -1 {
+		t.Errorf("Expected balance -0.01, got %d", balance)
+	}
+}
+
+func TestAdjustBalance_Validation(t *testing.T) {
+	f := newLedgerFixture()
+	f.accountRepo.accounts["closed"] = &domain.Account{ID: "closed", Currency: "USD", Status: domain.AccountStatusClosed, Version: 1}
+
+	tests := []struct {
+		name     string
+		modify   func(*domain.AdjustmentRequest)
+		expected error
+	}{
+		{"missing reason", func(r *domain.AdjustmentRequest) { r.Reason = " " }, domain.ErrInvalidAdjustment},
+		{"missing operator", func(r *domain.AdjustmentRequest) { r.OperatorID = "" }, domain.ErrInvalidAdjustment},
+		{"zero amount", func(r *domain.AdjustmentRequest) { r.Amount = 0 }, domain.ErrInvalidAmount},
+		{"unknown account", func(r *domain.AdjustmentRequest) { r.AccountID = "nobody" }, domain.ErrAccountNotFound},
+		{"closed account", func(r *domain.AdjustmentRequest) { r.AccountID = "closed" }, domain.ErrAccountClosed},
+		{"currency mismatch", func(r *domain.AdjustmentRequest) { r.Currency = "EUR" }, domain.ErrCurrencyMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := adjustment(100)
+			tt.modify(request)
+			if _, err := f.service.AdjustBalance(context.Background(), request); err != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// newApprovalFixture builds a batch fixture holding transfers over 50.00 for
+// approval for a day
+func newApprovalFixture(t *testing.T, clock *time.Time) (*ledgerFixture, *MockMessageQueue) {
+	t.Helper()
+	return newBatchFixture(t,
+		usecase.WithApproval("50.00", 24*time.Hour),
+		usecase.WithPendingExpiry(time.Hour, false),
+		usecase.WithClock(func() time.Time { return *clock }),
+	)
+}
+
+func TestApproval_LargeTransferWaitsForApprover(t *testing.T) {
+	clock := time.Now()
+	f, queue := newApprovalFixture(t, &clock)
+
+	large := f.transferTo(t, "bob", 6000)
+	small := f.transferTo(t, "bob", 3000)
+	if large.Status != domain.TransactionStatusPendingApproval || small.Status != domain.TransactionStatusPending {
+		t.Fatalf("Expected only the transfer over the threshold held, got %s and %s", large.Status, small.Status)
+	}
+	if count := queue.PublishedCount("transactions"); count != 1 {
+		t.Errorf("Expected only the small transfer queued, got %d messages", count)
+	}
+
+	// Even a message for it is not processed before approval
+	payload, _ := json.Marshal(&domain.TransactionRequest{ID: large.ID, Type: domain.TransactionTypeTransfer, FromAccountID: large.FromAccountID, ToAccountID: large.ToAccountID, Amount: 6000, Currency: "USD"})
+	queue.Publish(context.Background(), "transactions", payload)
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the messages acknowledged, got %v", errs)
+	}
+	if stored := f.transactionRepo.transactions[large.ID]; stored.Status != domain.TransactionStatusPendingApproval {
+		t.Errorf("Expected the large transfer still awaiting approval, got %s", stored.Status)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 7000 {
+		t.Errorf("Expected only the small transfer applied, got balance %d", balance)
+	}
+
+	approved, err := f.service.ApproveTransaction(context.Background(), large.ID)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if approved.Status != domain.TransactionStatusPending {
+		t.Errorf("Expected the approved transfer pending, got %s", approved.Status)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the approved transfer acknowledged, got %v", errs)
+	}
+	if stored := f.transactionRepo.transactions[large.ID]; stored.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the approved transfer completed, got %s: %s", stored.Status, stored.ErrorMessage)
+	}
+
+	if _, err := f.service.ApproveTransaction(context.Background(), large.ID); !errors.Is(err, domain.ErrNotAwaitingApproval) {
+		t.Errorf("Expected approving twice to fail with %v, got %v", domain.ErrNotAwaitingApproval, err)
+	}
+}
+
+func TestApproval_BatchLegOverThresholdIsRefused(t *testing.T) {
+	clock := time.Now()
+	f, queue := newApprovalFixture(t, &clock)
+
+	_, err := f.service.ProcessBatch(context.Background(), payroll(map[string]domain.Money{"bob": 3000, "carol": 6000}, "bob", "carol"))
+	var legErr *domain.BatchLegError
+	if !errors.As(err, &legErr) || legErr.Index != 1 || !errors.Is(err, domain.ErrApprovalRequired) {
+		t.Fatalf("Expected leg 1 refused for needing approval, got %v", err)
+	}
+	if count := len(f.transactionRepo.transactions); count != 0 {
+		t.Errorf("Expected no legs saved, got %d", count)
+	}
+	if count := queue.PublishedCount("transactions"); count != 0 {
+		t.Errorf("Expected nothing queued, got %d messages", count)
+	}
+
+	// Legs under the threshold go through as before
+	if _, err := f.service.ProcessBatch(context.Background(), payroll(map[string]domain.Money{"bob": 3000, "carol": 2000}, "bob", "carol")); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+}
+
+func TestApproval_ApprovedFutureTransferIsScheduled(t *testing.T) {
+	clock := time.Now()
+	f, queue := newApprovalFixture(t, &clock)
+	alice, bob := "alice", "bob"
+	at := clock.Add(time.Hour)
+
+	transfer, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 6000, Currency: "USD", ScheduledAt: &at,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	approved, err := f.service.ApproveTransaction(context.Background(), transfer.ID)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if approved.Status != domain.TransactionStatusScheduled || queue.PublishedCount("transactions") != 0 {
+		t.Errorf("Expected the approved transfer scheduled and not queued, got %s", approved.Status)
+	}
+}
+
+func TestApproval_Reject(t *testing.T) {
+	clock := time.Now()
+	f, queue := newApprovalFixture(t, &clock)
+	transfer := f.transferTo(t, "bob", 6000)
+
+	if _, err := f.service.RejectTransaction(context.Background(), transfer.ID, " "); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("Expected a rejection without a reason to fail with %v, got %v", domain.ErrInvalidInput, err)
+	}
+
+	rejected, err := f.service.RejectTransaction(context.Background(), transfer.ID, "unknown payee")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if rejected.Status != domain.TransactionStatusCancelled || rejected.ErrorMessage != "Rejected: unknown payee" {
+		t.Errorf("Expected the transfer cancelled with the reason, got %s %q", rejected.Status, rejected.ErrorMessage)
+	}
+	if _, err := f.service.ApproveTransaction(context.Background(), transfer.ID); !errors.Is(err, domain.ErrNotAwaitingApproval) {
+		t.Errorf("Expected approving a rejected transfer to fail with %v, got %v", domain.ErrNotAwaitingApproval, err)
+	}
+	if queue.PublishedCount("transactions") != 0 {
+		t.Error("Expected the rejected transfer never queued")
+	}
+}
+
+// stalePendingFailingRepository cannot list stale pending transactions
+type stalePendingFailingRepository struct {
+	*MockTransactionRepository
+}
+
+func (r *stalePendingFailingRepository) ListStalePending(ctx context.Context, before time.Time, limit int) ([]*domain.Transaction, error) {
+	return nil, errors.New("connection reset by peer")
+}
+
+func TestApproval_SweeperReportsExpiriesBeforeFailing(t *testing.T) {
+	clock := time.Now()
+	f, queue := newApprovalFixture(t, &clock)
+	f.transferTo(t, "bob", 6000)
+	service := usecase.NewTransactionUseCase(f.accountRepo, &stalePendingFailingRepository{f.transactionRepo}, queue, "transactions",
+		usecase.WithApproval("50.00", 24*time.Hour),
+		usecase.WithPendingExpiry(time.Hour, false),
+		usecase.WithClock(func() time.Time { return clock }),
+	).(*usecase.TransactionUseCase)
+
+	clock = clock.Add(25 * time.Hour)
+	expired, _, err := service.ExpireStalePending(context.Background())
+	if err == nil || expired != 1 {
+		t.Errorf("Expected the unapproved transfer counted as expired alongside the error, got %d (%v)", expired, err)
+	}
+}
+
+func TestApproval_SweeperExpiresUnapproved(t *testing.T) {
+	clock := time.Now()
+	f, _ := newApprovalFixture(t, &clock)
+	transfer := f.transferTo(t, "bob", 6000)
+
+	clock = clock.Add(23 * time.Hour)
+	if expired, _, err := f.service.ExpireStalePending(context.Background()); err != nil || expired != 0 {
+		t.Fatalf("Expected nothing expired within the window, got %d (%v)", expired, err)
+	}
+
+	clock = clock.Add(2 * time.Hour)
+	expired, _, err := f.service.ExpireStalePending(context.Background())
+	if err != nil || expired != 1 {
+		t.Fatalf("Expected the unapproved transfer expired, got %d (%v)", expired, err)
+	}
+	stored := f.transactionRepo.transactions[transfer.ID]
+	if stored.Status != domain.TransactionStatusFailed || stored.FailureCode != domain.FailureCodeExpired {
+		t.Errorf("Expected the transfer failed as expired, got %s %s", stored.Status, stored.FailureCode)
+	}
+	if _, err := f.service.ApproveTransaction(context.Background(), transfer.ID); !errors.Is(err, domain.ErrNotAwaitingApproval) {
+		t.Errorf("Expected approving an expired transfer to fail with %v, got %v", domain.ErrNotAwaitingApproval, err)
+	}
+}
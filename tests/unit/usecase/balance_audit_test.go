@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestProcessor_RecordsBalanceChanges(t *testing.T) {
+	f := newDryRunFixture()
+	if _, err := f.service.(*usecase.TransactionUseCase).StartTransactionProcessor(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	transaction, err := f.service.ProcessTransaction(context.Background(), transfer("alice", "bob", 5000))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if errs := f.queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfer to be processed, got %v", errs)
+	}
+
+	// The fee's debit of alice is recorded on the fee transaction, not here
+	stored := f.transactionRepo.transactions[transaction.ID]
+	expected := map[string]domain.BalanceChange{
+		"alice": {AccountID: "alice", BalanceBefore: 10000, BalanceAfter: 5000, Currency: "USD", AccountVersion: 1},
+		"bob":   {AccountID: "bob", BalanceBefore: 2000, BalanceAfter: 7000, Currency: "USD", AccountVersion: 1},
+	}
+	if len(stored.BalanceChanges) != len(expected) {
+		t.Fatalf("Expected %d balance changes, got %d", len(expected), len(stored.BalanceChanges))
+	}
+	for _, change := range stored.BalanceChanges {
+		if *change != expected[change.AccountID] {
+			t.Errorf("Expected %+v, got %+v", expected[change.AccountID], *change)
+		}
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		TransactionService: f.service,
+		AdminToken:         ownershipAdminToken,
+	})
+	for principal, visible := range map[string]bool{"admin": true, "alice": false} {
+		rec := principalRequest(e, principal, http.MethodGet, "/api/v1/transactions/"+transaction.ID, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, principal, rec.Code, rec.Body)
+		}
+
+		var body domain.Transaction
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if visible != (len(body.BalanceChanges) == 2) {
+			t.Errorf("Expected balance changes shown to %s: %v, got %d", principal, visible, len(body.BalanceChanges))
+		}
+		if visible && body.BalanceChanges[0].BalanceBefore != 10000 {
+			t.Errorf("Expected the balances to round trip, got %+v", *body.BalanceChanges[0])
+		}
+	}
+}
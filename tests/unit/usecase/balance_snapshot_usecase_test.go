@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockBalanceSnapshotRepository implements domain.BalanceSnapshotRepository for testing
+type MockBalanceSnapshotRepository struct {
+	snapshots map[string]*domain.BalanceSnapshot
+}
+
+func NewMockBalanceSnapshotRepository() *MockBalanceSnapshotRepository {
+	return &MockBalanceSnapshotRepository{snapshots: make(map[string]*domain.BalanceSnapshot)}
+}
+
+func snapshotKey(accountID string, day time.Time) string {
+	return accountID + "/" + day.Format(domain.StatementDateLayout)
+}
+
+func (m *MockBalanceSnapshotRepository) Save(ctx context.Context, snapshots []*domain.BalanceSnapshot) error {
+	for _, snapshot := range snapshots {
+		m.snapshots[snapshotKey(snapshot.AccountID, snapshot.AsOfDate)] = snapshot
+	}
+	return nil
+}
+
+func (m *MockBalanceSnapshotRepository) Get(ctx context.Context, accountID string, day time.Time) (*domain.BalanceSnapshot, error) {
+	snapshot, exists := m.snapshots[snapshotKey(accountID, day)]
+	if !exists {
+		return nil, domain.ErrBalanceSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+func (m *MockBalanceSnapshotRepository) GetLatestBefore(ctx context.Context, accountID string, day time.Time) (*domain.BalanceSnapshot, error) {
+	var latest *domain.BalanceSnapshot
+	for _, snapshot := range m.snapshots {
+		if snapshot.AccountID == accountID && snapshot.AsOfDate.Before(day) &&
+			(latest == nil || snapshot.AsOfDate.After(latest.AsOfDate)) {
+			latest = snapshot
+		}
+	}
+	if latest == nil {
+		return nil, domain.ErrBalanceSnapshotNotFound
+	}
+	return latest, nil
+}
+
+func (m *MockBalanceSnapshotRepository) LatestDay(ctx context.Context) (*time.Time, error) {
+	var latest *time.Time
+	for _, snapshot := range m.snapshots {
+		if latest == nil || snapshot.AsOfDate.After(*latest) {
+			day := snapshot.AsOfDate
+			latest = &day
+		}
+	}
+	return latest, nil
+}
+
+// days returns the days snapshotted for an account
+func (m *MockBalanceSnapshotRepository) days(accountID string) map[string]domain.Money {
+	days := make(map[string]domain.Money)
+	for _, snapshot := range m.snapshots {
+		if snapshot.AccountID == accountID {
+			days[snapshot.AsOfDate.Format(domain.StatementDateLayout)] = snapshot.Balance
+		}
+	}
+	return days
+}
+
+type snapshotFixture struct {
+	accountRepo  *MockAccountRepository
+	ledgerRepo   *MockLedgerEntryRepository
+	snapshotRepo *MockBalanceSnapshotRepository
+	now          time.Time
+}
+
+func march(day, hour int) time.Time {
+	return time.Date(2024, time.March, day, hour, 0, 0, 0, time.UTC)
+}
+
+// newSnapshotFixture opens alice on March 1st with 100.00 and posts her a
+// credit on the 2nd, a debit on the 3rd and a credit on the 4th. Bob opens
+// late on the 3rd and carol on the 5th, and neither has any entries.
+func newSnapshotFixture() *snapshotFixture {
+	accountRepo := NewMockAccountRepository()
+	ledgerRepo := NewMockLedgerEntryRepository(accountRepo)
+
+	for _, account := range []*domain.Account{
+		{ID: "alice", InitialBalance: 10000, Balance: 11300, CreatedAt: march(1, 9)},
+		{ID: "bob", InitialBalance: 5000, Balance: 5000, CreatedAt: march(3, 20)},
+		{ID: "carol", InitialBalance: 700, Balance: 700, CreatedAt: march(5, 9)},
+	} {
+		account.Currency = "USD"
+		account.Status = domain.AccountStatusActive
+		accountRepo.accounts[account.ID] = account
+	}
+
+	ledgerRepo.entries = []*domain.LedgerEntry{
+		{ID: "e1", AccountID: "alice", Direction: domain.EntryDirectionCredit, Amount: 500, ResultingBalance: 10500, AccountVersion: 2, CreatedAt: march(2, 10)},
+		{ID: "e2", AccountID: "alice", Direction: domain.EntryDirectionDebit, Amount: 200, ResultingBalance: 10300, AccountVersion: 3, CreatedAt: march(3, 23)},
+		{ID: "e3", AccountID: "alice", Direction: domain.EntryDirectionCredit, Amount: 1000, ResultingBalance: 11300, AccountVersion: 4, CreatedAt: march(4, 0)},
+	}
+
+	return &snapshotFixture{
+		accountRepo:  accountRepo,
+		ledgerRepo:   ledgerRepo,
+		snapshotRepo: NewMockBalanceSnapshotRepository(),
+	}
+}
+
+func (f *snapshotFixture) service(opts ...usecase.BalanceSnapshotOption) domain.BalanceSnapshotService {
+	opts = append([]usecase.BalanceSnapshotOption{
+		usecase.WithSnapshotPageSize(2),
+		usecase.WithSnapshotClock(func() time.Time { return f.now }),
+	}, opts...)
+	return usecase.NewBalanceSnapshotUseCase(f.accountRepo, f.ledgerRepo, f.snapshotRepo, opts...)
+}
+
+func TestSnapshotDay_TakesBalancesAtTheCloseOfTheDay(t *testing.T) {
+	f := newSnapshotFixture()
+	f.now = march(6, 12)
+	service := f.service()
+	ctx := context.Background()
+
+	taken, err := service.SnapshotDay(ctx, march(3, 0))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if taken != 2 {
+		t.Errorf("Expected snapshots of the two accounts open by the close, got %d", taken)
+	}
+
+	if balance := f.snapshotRepo.days("alice")["2024-03-03"]; balance != 10300 {
+		t.Errorf("Expected alice at 103.00, got %d", balance)
+	}
+	if balance := f.snapshotRepo.days("bob")["2024-03-03"]; balance != 5000 {
+		t.Errorf("Expected bob at his initial 50.00, got %d", balance)
+	}
+	if len(f.snapshotRepo.days("carol")) != 0 {
+		t.Errorf("Expected no snapshot of carol before she opened")
+	}
+
+	// Snapshotting the day again overwrites it
+	if _, err := service.SnapshotDay(ctx, march(3, 0)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(f.snapshotRepo.snapshots) != 2 {
+		t.Errorf("Expected the snapshots to be replaced, got %d", len(f.snapshotRepo.snapshots))
+	}
+}
+
+func TestSnapshotDay_RejectsOpenDay(t *testing.T) {
+	f := newSnapshotFixture()
+	f.now = march(6, 12)
+
+	if _, err := f.service().SnapshotDay(context.Background(), march(6, 0)); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for a day not yet closed, got %v", err)
+	}
+}
+
+func TestSnapshotDue_CatchesUpOnMissedDays(t *testing.T) {
+	f := newSnapshotFixture()
+	f.now = march(6, 12)
+	service := f.service(usecase.WithSnapshotCatchUpDays(3))
+	ctx := context.Background()
+
+	// The first run takes only yesterday
+	if _, err := service.SnapshotDue(ctx); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if days := f.snapshotRepo.days("alice"); len(days) != 1 || days["2024-03-05"] != 11300 {
+		t.Errorf("Expected only March 5th, got %v", days)
+	}
+
+	// Nothing more is due the same day
+	if taken, err := service.SnapshotDue(ctx); err != nil || taken != 0 {
+		t.Errorf("Expected nothing due, got %d, %v", taken, err)
+	}
+
+	// After five days away, only the last three are caught up
+	f.now = march(11, 1)
+	if _, err := service.SnapshotDue(ctx); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	days := f.snapshotRepo.days("carol")
+	for _, day := range []string{"2024-03-05", "2024-03-08", "2024-03-09", "2024-03-10"} {
+		if _, ok := days[day]; !ok {
+			t.Errorf("Expected a snapshot of carol on %s, got %v", day, days)
+		}
+	}
+	if len(days) != 4 {
+		t.Errorf("Expected four snapshots of carol, got %v", days)
+	}
+}
+
+func TestGetBalanceAsOf(t *testing.T) {
+	f := newSnapshotFixture()
+	f.now = march(5, 12)
+	service := f.service()
+	ctx := context.Background()
+
+	if _, err := service.SnapshotDay(ctx, march(3, 0)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	snapshot, err := service.GetBalanceAsOf(ctx, "alice", "2024-03-03")
+	if err != nil || snapshot.Balance != 10300 {
+		t.Errorf("Expected the snapshot of 103.00, got %+v, %v", snapshot, err)
+	}
+
+	if _, err := service.GetBalanceAsOf(ctx, "alice", "2024-03-04"); !errors.Is(err, domain.ErrBalanceSnapshotNotFound) {
+		t.Errorf("Expected ErrBalanceSnapshotNotFound for a day not snapshotted, got %v", err)
+	}
+
+	// Today is the last snapshot plus the movements since
+	snapshot, err = service.GetBalanceAsOf(ctx, "alice", "2024-03-05")
+	if err != nil || snapshot.Balance != 11300 {
+		t.Errorf("Expected 113.00 so far today, got %+v, %v", snapshot, err)
+	}
+
+	// Without a snapshot, today is the initial balance plus every movement
+	snapshot, err = service.GetBalanceAsOf(ctx, "carol", "2024-03-05")
+	if err != nil || snapshot.Balance != 700 {
+		t.Errorf("Expected 7.00 so far today, got %+v, %v", snapshot, err)
+	}
+
+	for _, day := range []string{"2024-03-06", "03/03/2024"} {
+		if _, err := service.GetBalanceAsOf(ctx, "alice", day); !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("Expected ErrInvalidInput for %q, got %v", day, err)
+		}
+	}
+	if _, err := service.GetBalanceAsOf(ctx, "nobody", "2024-03-03"); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// newBatchFixture builds a ledger fixture with a running processor and two
+// more payees, carol and dave
+func newBatchFixture(t *testing.T, opts ...usecase.TransactionOption) (*ledgerFixture, *MockMessageQueue) {
+	t.Helper()
+	f := newLedgerFixture()
+	f.accountRepo.accounts["carol"] = &domain.Account{ID: "carol", UserID: "carol", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	f.accountRepo.accounts["dave"] = &domain.Account{ID: "dave", UserID: "dave", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	queue := NewMockMessageQueue()
+	f.service = usecase.NewTransactionUseCase(
+		f.accountRepo, f.transactionRepo, queue, "transactions",
+		append([]usecase.TransactionOption{usecase.WithLedgerEntries(f.ledgerRepo)}, opts...)...,
+	).(*usecase.TransactionUseCase)
+	if _, err := f.service.StartTransactionProcessor(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return f, queue
+}
+
+// payroll pays each payee from alice
+func payroll(amounts map[string]domain.Money, payees ...string) *domain.BatchRequest {
+	alice := "alice"
+	batch := &domain.BatchRequest{}
+	for _, payee := range payees {
+		to := payee
+		batch.Legs = append(batch.Legs, &domain.TransactionRequest{
+			Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &to, Amount: amounts[payee], Currency: "USD",
+		})
+	}
+	return batch
+}
+
+func TestProcessBatch_AppliesEveryLeg(t *testing.T) {
+	f, queue := newBatchFixture(t)
+	ctx := context.Background()
+
+	batch, err := f.service.ProcessBatch(ctx, payroll(map[string]domain.Money{"bob": 3000, "carol": 2000, "dave": 1000}, "bob", "carol", "dave"))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if batch.Status != domain.TransactionStatusPending || len(batch.Legs) != 3 {
+		t.Fatalf("Expected three pending legs, got %+v", batch)
+	}
+	for _, leg := range batch.Legs {
+		if leg.BatchID != batch.ID {
+			t.Errorf("Expected leg %s in batch %s, got %q", leg.ID, batch.ID, leg.BatchID)
+		}
+	}
+	if count := queue.PublishedCount("transactions"); count != 1 {
+		t.Fatalf("Expected the batch queued as one message, got %d", count)
+	}
+
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected no handler errors, got %v", errs)
+	}
+
+	for accountID, expected := range map[string]domain.Money{"alice": 4000, "bob": 8000, "carol": 2000, "dave": 1000} {
+		if balance := f.accountRepo.accounts[accountID].Balance; balance != expected {
+			t.Errorf("Expected %s balance %d, got %d", accountID, expected, balance)
+		}
+	}
+
+	processed, err := f.service.GetBatch(ctx, batch.ID)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if processed.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the batch completed, got %s", processed.Status)
+	}
+	if processed.Legs[0].ID != domain.LegID(batch.ID, 0) || *processed.Legs[2].ToAccountID != "dave" {
+		t.Errorf("Expected legs in submission order, got %+v", processed.Legs)
+	}
+}
+
+func TestProcessBatch_ChecksTotalDebit(t *testing.T) {
+	f, queue := newBatchFixture(t)
+
+	// Each leg is covered on its own, but not all three together
+	_, err := f.service.ProcessBatch(context.Background(), payroll(map[string]domain.Money{"bob": 4000, "carol": 4000, "dave": 4000}, "bob", "carol", "dave"))
+	if !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("Expected %v, got %v", domain.ErrInsufficientFunds, err)
+	}
+	if len(f.transactionRepo.transactions) != 0 || queue.PublishedCount("transactions") != 0 {
+		t.Error("Expected nothing saved or queued")
+	}
+}
+
+func TestProcessBatch_RejectsLeg(t *testing.T) {
+	f, _ := newBatchFixture(t)
+	f.accountRepo.accounts["carol"].Status = domain.AccountStatusClosed
+
+	_, err := f.service.ProcessBatch(context.Background(), payroll(map[string]domain.Money{"bob": 100, "carol": 100}, "bob", "carol"))
+
+	var legErr *domain.BatchLegError
+	if !errors.As(err, &legErr) || legErr.Index != 1 || !errors.Is(err, domain.ErrAccountClosed) {
+		t.Fatalf("Expected leg 1 rejected as closed, got %v", err)
+	}
+	if len(f.transactionRepo.transactions) != 0 {
+		t.Error("Expected no legs saved")
+	}
+}
+
+func TestProcessBatch_FailsBeforeApplyingAnyLeg(t *testing.T) {
+	f, queue := newBatchFixture(t)
+	ctx := context.Background()
+
+	batch, err := f.service.ProcessBatch(ctx, payroll(map[string]domain.Money{"bob": 3000, "carol": 2000, "dave": 1000}, "bob", "carol", "dave"))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// The last payee is closed after the batch was accepted
+	f.accountRepo.accounts["dave"].Status = domain.AccountStatusClosed
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the failure handled, got %v", errs)
+	}
+
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 10000 || len(f.ledgerRepo.entries) != 0 {
+		t.Errorf("Expected nothing applied, got alice balance %d and %d entries", balance, len(f.ledgerRepo.entries))
+	}
+
+	failed, err := f.service.GetBatch(ctx, batch.ID)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if failed.Status != domain.TransactionStatusFailed {
+		t.Errorf("Expected the batch failed, got %s", failed.Status)
+	}
+	for _, leg := range failed.Legs {
+		if leg.Status != domain.TransactionStatusFailed || leg.FailureCode != domain.FailureCodeAccountClosed {
+			t.Errorf("Expected leg %s failed as closed, got %s %s", leg.ID, leg.Status, leg.FailureCode)
+		}
+	}
+}
+
+func TestProcessBatch_CompensatesAppliedLegs(t *testing.T) {
+	policy := usecase.NewFeePolicy("fees", usecase.FeeFailureFail)
+	policy.SetRule(domain.TransactionTypeTransfer, "USD", usecase.FeeRule{Flat: 50, BasisPoints: 100})
+	f, queue := newBatchFixture(t, usecase.WithFeePolicy(policy))
+	f.accountRepo.accounts["fees"] = &domain.Account{ID: "fees", UserID: "bank", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	ctx := context.Background()
+
+	// The 99.00 total is covered, but not with 0.83 in fees on each leg
+	batch, err := f.service.ProcessBatch(ctx, payroll(map[string]domain.Money{"bob": 3300, "carol": 3300, "dave": 3300}, "bob", "carol", "dave"))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the failure handled, got %v", errs)
+	}
+
+	for accountID, expected := range map[string]domain.Money{"alice": 10000, "bob": 5000, "carol": 0, "dave": 0, "fees": 0} {
+		if balance := f.accountRepo.accounts[accountID].Balance; balance != expected {
+			t.Errorf("Expected %s balance restored to %d, got %d", accountID, expected, balance)
+		}
+	}
+
+	failed, err := f.service.GetBatch(ctx, batch.ID)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if failed.Status != domain.TransactionStatusFailed {
+		t.Errorf("Expected the batch failed, got %s", failed.Status)
+	}
+	for _, leg := range failed.Legs {
+		if leg.Status != domain.TransactionStatusFailed || leg.FailureCode != domain.FailureCodeInsufficientFunds {
+			t.Errorf("Expected leg %s failed for insufficient funds, got %s %s", leg.ID, leg.Status, leg.FailureCode)
+		}
+	}
+
+	// Applied legs and their fees are undone by completed system reversals
+	for _, index := range []int{0, 1} {
+		legID := domain.LegID(batch.ID, index)
+		for _, originalID := range []string{legID, usecase.FeeTransactionID(legID)} {
+			compensation := f.transactionRepo.transactions[originalID+"-compensation"]
+			if compensation == nil || compensation.Status != domain.TransactionStatusCompleted || !compensation.System ||
+				compensation.ReversedTransactionID != originalID {
+				t.Errorf("Expected %s compensated, got %+v", originalID, compensation)
+			}
+		}
+	}
+	if _, exists := f.transactionRepo.transactions[domain.LegID(batch.ID, 2)+"-compensation"]; exists {
+		t.Error("Expected no compensation for the leg that was never applied")
+	}
+}
+
+func TestProcessBatch_RedeliveryAppliesOnce(t *testing.T) {
+	f, queue := newBatchFixture(t)
+
+	if _, err := f.service.ProcessBatch(context.Background(), payroll(map[string]domain.Money{"bob": 3000, "carol": 2000}, "bob", "carol")); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	message := queue.published["transactions"][0]
+
+	queue.Deliver("transactions")
+	if err := queue.handlers["transactions"](context.Background(), message); err != nil {
+		t.Fatalf("Expected the redelivery to succeed, got %v", err)
+	}
+
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 5000 {
+		t.Errorf("Expected alice debited once, got balance %d", balance)
+	}
+}
+
+func TestGetBatch_NotFound(t *testing.T) {
+	f, _ := newBatchFixture(t)
+
+	if _, err := f.service.GetBatch(context.Background(), "missing"); err != domain.ErrBatchNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrBatchNotFound, err)
+	}
+}
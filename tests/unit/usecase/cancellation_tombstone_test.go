@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/queue"
+	"banking-ledger/internal/usecase"
+)
+
+// readCountingRepository counts the transactions read by ID
+type readCountingRepository struct {
+	*MockTransactionRepository
+	reads atomic.Int64
+}
+
+func (r *readCountingRepository) GetByID(ctx context.Context, id string) (*domain.Transaction, error) {
+	r.reads.Add(1)
+	return r.MockTransactionRepository.GetByID(ctx, id)
+}
+
+type tombstoneFixture struct {
+	accountRepo     *MockAccountRepository
+	transactionRepo *readCountingRepository
+	queue           *MockMessageQueue
+	stats           *MockQueueStatsStore
+	service         *usecase.TransactionUseCase
+}
+
+// newTombstoneFixture gives alice 100.00 USD and starts a processor sharing
+// its queue with the API
+func newTombstoneFixture(t *testing.T) *tombstoneFixture {
+	f := &tombstoneFixture{
+		accountRepo:     NewMockAccountRepository(),
+		transactionRepo: &readCountingRepository{MockTransactionRepository: NewMockTransactionRepository()},
+		queue:           NewMockMessageQueue(),
+		stats:           NewMockQueueStatsStore(),
+	}
+	f.accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	f.service = usecase.NewTransactionUseCase(f.accountRepo, f.transactionRepo, f.queue, "transactions",
+		usecase.WithCancellationTombstones("cancellations", 0),
+		usecase.WithQueueStats(f.stats),
+	).(*usecase.TransactionUseCase)
+	if _, err := f.service.StartTransactionProcessor(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return f
+}
+
+func TestCancellationTombstone_SkipsQueuedMessage(t *testing.T) {
+	f := newTombstoneFixture(t)
+	ctx := context.Background()
+
+	transaction, err := f.service.ProcessTransaction(ctx, withdrawal("alice"))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := f.service.CancelTransaction(ctx, transaction.ID); err != nil {
+		t.Fatalf("Expected the cancellation to succeed, got %v", err)
+	}
+
+	// The tombstone overtakes the queued message
+	if errs := f.queue.Deliver("cancellations"); len(errs) != 0 {
+		t.Fatalf("Expected the tombstone to be taken, got %v", errs)
+	}
+	reads := f.transactionRepo.reads.Load()
+	if errs := f.queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the message to be acknowledged, got %v", errs)
+	}
+
+	if f.transactionRepo.reads.Load() != reads {
+		t.Error("Expected the message skipped without reading the transaction")
+	}
+	if f.service.SkippedCancelled() != 1 {
+		t.Errorf("Expected one skipped message counted, got %d", f.service.SkippedCancelled())
+	}
+	if err := f.service.PublishProcessingRate(ctx); err != nil {
+		t.Fatalf("Expected the processing rate to be published, got %v", err)
+	}
+	rates, _ := f.stats.ProcessingRates(ctx, "transactions", time.Now().Add(-time.Minute))
+	if len(rates) != 1 || rates[0].SkippedCancelled != 1 {
+		t.Errorf("Expected the skip reported with the processing rate, got %+v", rates)
+	}
+	if f.accountRepo.accounts["alice"].Balance != 10000 {
+		t.Errorf("Expected the balance untouched, got %d", f.accountRepo.accounts["alice"].Balance)
+	}
+	if status := f.transactionRepo.transactions[transaction.ID].Status; status != domain.TransactionStatusCancelled {
+		t.Errorf("Expected the transaction to stay cancelled, got %s", status)
+	}
+}
+
+func TestCancellationTombstone_CancelAfterMessageConsumed(t *testing.T) {
+	f := newTombstoneFixture(t)
+	ctx := context.Background()
+
+	transaction, err := f.service.ProcessTransaction(ctx, withdrawal("alice"))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if errs := f.queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the message to be processed, got %v", errs)
+	}
+
+	// Too late: nothing is cancelled and no tombstone is published
+	if err := f.service.CancelTransaction(ctx, transaction.ID); !errors.Is(err, domain.ErrTransactionAlreadyProcessed) {
+		t.Fatalf("Expected ErrTransactionAlreadyProcessed, got %v", err)
+	}
+	if f.queue.PublishedCount("cancellations") != 0 {
+		t.Errorf("Expected no tombstone, got %d", f.queue.PublishedCount("cancellations"))
+	}
+	if status := f.transactionRepo.transactions[transaction.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the transaction completed, got %s", status)
+	}
+	if f.service.SkippedCancelled() != 0 {
+		t.Errorf("Expected nothing skipped, got %d", f.service.SkippedCancelled())
+	}
+}
+
+func TestCancellationTombstone_ArrivesAfterMessage(t *testing.T) {
+	f := newTombstoneFixture(t)
+	ctx := context.Background()
+
+	transaction, err := f.service.ProcessTransaction(ctx, withdrawal("alice"))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := f.service.CancelTransaction(ctx, transaction.ID); err != nil {
+		t.Fatalf("Expected the cancellation to succeed, got %v", err)
+	}
+
+	// The message is skipped on the stored status, then the late tombstone
+	// is harmless
+	if errs := f.queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the message to be acknowledged, got %v", errs)
+	}
+	if errs := f.queue.Deliver("cancellations"); len(errs) != 0 {
+		t.Fatalf("Expected the tombstone to be taken, got %v", errs)
+	}
+
+	if f.service.SkippedCancelled() != 0 {
+		t.Errorf("Expected no tombstone skip, got %d", f.service.SkippedCancelled())
+	}
+	if f.accountRepo.accounts["alice"].Balance != 10000 {
+		t.Errorf("Expected the balance untouched, got %d", f.accountRepo.accounts["alice"].Balance)
+	}
+}
+
+// handledQueue tells of each message its subscribers have handled
+type handledQueue struct {
+	domain.MessageQueue
+	handled chan string
+}
+
+func (q *handledQueue) Subscribe(ctx context.Context, queueName string, handler func(context.Context, []byte) error) (domain.Subscription, error) {
+	return q.MessageQueue.Subscribe(ctx, queueName, func(ctx context.Context, data []byte) error {
+		err := handler(ctx, data)
+		q.handled <- queueName
+		return err
+	})
+}
+
+// waitHandled waits for a message of queueName to be handled
+func (q *handledQueue) waitHandled(t *testing.T, queueName string) {
+	t.Helper()
+	select {
+	case handled := <-q.handled:
+		if handled != queueName {
+			t.Fatalf("Expected a message of %s handled, got one of %s", queueName, handled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for a message of %s", queueName)
+	}
+}
+
+func TestCancellationTombstone_ReachesEveryProcessor(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	messageQueue := &handledQueue{
+		MessageQueue: queue.NewInMemoryQueue(queue.WithFanout("cancellations")),
+		handled:      make(chan string, 10),
+	}
+	defer messageQueue.Close()
+
+	// Two processor replicas, each delivered messages on a queue of its own
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queueNames := []string{"transactions-a", "transactions-b"}
+	var processors []*usecase.TransactionUseCase
+	var subscriptions []domain.Subscription
+	for _, queueName := range queueNames {
+		processor := usecase.NewTransactionUseCase(accountRepo, transactionRepo, messageQueue, queueName,
+			usecase.WithCancellationTombstones("cancellations", 0),
+		).(*usecase.TransactionUseCase)
+		subscription, err := processor.StartTransactionProcessor(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		processors = append(processors, processor)
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	// The API's message waits on a queue no processor reads
+	api := usecase.NewTransactionUseCase(accountRepo, transactionRepo, messageQueue, "submitted",
+		usecase.WithCancellationTombstones("cancellations", 0),
+	)
+	transaction, err := api.ProcessTransaction(ctx, withdrawal("alice"))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if err := api.CancelTransaction(ctx, transaction.ID); err != nil {
+		t.Fatalf("Expected the cancellation to succeed, got %v", err)
+	}
+	messageQueue.waitHandled(t, "cancellations")
+	messageQueue.waitHandled(t, "cancellations")
+
+	// Whichever replica is delivered the message skips it on the tombstone
+	message, _ := json.Marshal(&domain.TransactionRequest{ID: transaction.ID})
+	for _, queueName := range queueNames {
+		if err := messageQueue.Publish(ctx, queueName, message); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+		messageQueue.waitHandled(t, queueName)
+	}
+	for i, processor := range processors {
+		if processor.SkippedCancelled() != 1 {
+			t.Errorf("Expected processor %d to skip the message on its tombstone, got %d skipped", i, processor.SkippedCancelled())
+		}
+	}
+
+	// Shutting down drains the tombstone consumer along with the transactions
+	cancel()
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelDrain()
+	for i, subscription := range subscriptions {
+		if _, err := subscription.Drain(drainCtx); err != nil {
+			t.Errorf("Expected processor %d to drain, got %v", i, err)
+		}
+	}
+}
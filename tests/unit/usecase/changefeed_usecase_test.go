@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockChangeSource implements domain.ChangeSource over in-memory events using
+// the same predicate the store queries use
+type MockChangeSource struct {
+	entity domain.ChangeEntity
+	events []*domain.ChangeEvent
+}
+
+func (m *MockChangeSource) add(id string, operation domain.ChangeOperation, updatedAt time.Time) {
+	m.events = append(m.events, &domain.ChangeEvent{
+		Entity:    m.entity,
+		Operation: operation,
+		ID:        id,
+		UpdatedAt: updatedAt,
+	})
+}
+
+func (m *MockChangeSource) Changes(ctx context.Context, after domain.ChangeCursor, until time.Time, limit int) ([]*domain.ChangeEvent, error) {
+	includeTies, afterID := after.TieBreak(m.entity)
+
+	var events []*domain.ChangeEvent
+	for _, event := range m.events {
+		if !event.UpdatedAt.Before(until) {
+			continue
+		}
+		tied := includeTies && event.UpdatedAt.Equal(after.UpdatedAt) && event.ID > afterID
+		if !event.UpdatedAt.After(after.UpdatedAt) && !tied {
+			continue
+		}
+		copied := *event
+		events = append(events, &copied)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Position().Less(events[j].Position())
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func collectChanges(t *testing.T, service domain.ChangeFeedService, cursor string, limit int) ([]string, string) {
+	t.Helper()
+	var seen []string
+	for {
+		feed, err := service.GetChanges(context.Background(), cursor, limit)
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		for _, event := range feed.Events {
+			seen = append(seen, string(event.Entity)+":"+event.ID+":"+string(event.Operation))
+		}
+		cursor = feed.NextCursor
+		if !feed.HasMore {
+			return seen, cursor
+		}
+	}
+}
+
+func TestChangeFeedUseCase_ResumesAcrossTies(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	accounts := &MockChangeSource{entity: domain.ChangeEntityAccount}
+	transactions := &MockChangeSource{entity: domain.ChangeEntityTransaction}
+
+	// Several documents share each timestamp across both entities
+	accounts.add("a1", domain.ChangeOperationCreate, base)
+	accounts.add("a2", domain.ChangeOperationCreate, base)
+	transactions.add("t1", domain.ChangeOperationCreate, base)
+	transactions.add("t2", domain.ChangeOperationUpdate, base.Add(time.Millisecond))
+	accounts.add("a3", domain.ChangeOperationUpdate, base.Add(time.Millisecond))
+	transactions.add("t3", domain.ChangeOperationCreate, base.Add(2*time.Millisecond))
+
+	service := usecase.NewChangeFeedUseCase([]domain.ChangeSource{accounts, transactions}, 0)
+
+	expected := []string{
+		"account:a1:create", "account:a2:create", "transaction:t1:create",
+		"account:a3:update", "transaction:t2:update",
+		"transaction:t3:create",
+	}
+
+	for _, limit := range []int{1, 2, 4, 100} {
+		seen, _ := collectChanges(t, service, "", limit)
+		if len(seen) != len(expected) {
+			t.Fatalf("limit %d: expected %v, got %v", limit, expected, seen)
+		}
+		for i := range expected {
+			if seen[i] != expected[i] {
+				t.Errorf("limit %d: expected %v, got %v", limit, expected, seen)
+				break
+			}
+		}
+	}
+}
+
+func TestChangeFeedUseCase_PicksUpWritesAtCursorTimestamp(t *testing.T) {
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	accounts := &MockChangeSource{entity: domain.ChangeEntityAccount}
+	transactions := &MockChangeSource{entity: domain.ChangeEntityTransaction}
+	accounts.add("a1", domain.ChangeOperationCreate, base)
+
+	service := usecase.NewChangeFeedUseCase([]domain.ChangeSource{accounts, transactions}, 0)
+	_, cursor := collectChanges(t, service, "", 10)
+
+	// Writes landing in the same instant as the cursor, ordered after it
+	accounts.add("a2", domain.ChangeOperationCreate, base)
+	transactions.add("t1", domain.ChangeOperationCreate, base)
+
+	seen, _ := collectChanges(t, service, cursor, 10)
+	if len(seen) != 2 || seen[0] != "account:a2:create" || seen[1] != "transaction:t1:create" {
+		t.Errorf("Expected tied writes after the cursor, got %v", seen)
+	}
+}
+
+func TestChangeFeedUseCase_EmitsTombstones(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	accounts := &MockChangeSource{entity: domain.ChangeEntityAccount}
+	accounts.add("a1", domain.ChangeOperationCreate, base)
+
+	service := usecase.NewChangeFeedUseCase([]domain.ChangeSource{accounts}, 0)
+	_, cursor := collectChanges(t, service, "", 10)
+
+	accounts.events = nil
+	accounts.add("a1", domain.ChangeOperationDelete, base.Add(time.Minute))
+
+	feed, err := service.GetChanges(context.Background(), cursor, 10)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(feed.Events) != 1 {
+		t.Fatalf("Expected a single tombstone, got %d events", len(feed.Events))
+	}
+	tombstone := feed.Events[0]
+	if tombstone.Operation != domain.ChangeOperationDelete || tombstone.ID != "a1" || tombstone.Document != nil {
+		t.Errorf("Unexpected tombstone %+v", tombstone)
+	}
+}
+
+func TestChangeFeedUseCase_HoldsBackUnsettledWrites(t *testing.T) {
+	accounts := &MockChangeSource{entity: domain.ChangeEntityAccount}
+	accounts.add("settled", domain.ChangeOperationCreate, time.Now().Add(-time.Minute))
+	accounts.add("recent", domain.ChangeOperationCreate, time.Now())
+
+	service := usecase.NewChangeFeedUseCase([]domain.ChangeSource{accounts}, 5*time.Second)
+	feed, err := service.GetChanges(context.Background(), "", 10)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(feed.Events) != 1 || feed.Events[0].ID != "settled" {
+		t.Errorf("Expected only the settled write, got %+v", feed.Events)
+	}
+
+	// An empty page keeps the caller's cursor
+	next, err := service.GetChanges(context.Background(), feed.NextCursor, 10)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(next.Events) != 0 || next.NextCursor != feed.NextCursor || next.HasMore {
+		t.Errorf("Expected an empty page at the same cursor, got %+v", next)
+	}
+}
+
+func TestChangeFeedUseCase_RejectsInvalidCursor(t *testing.T) {
+	service := usecase.NewChangeFeedUseCase(nil, 0)
+	if _, err := service.GetChanges(context.Background(), "zz", 10); err != domain.ErrInvalidCursor {
+		t.Errorf("Expected %v, got %v", domain.ErrInvalidCursor, err)
+	}
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestProcessTransaction_ClientSuppliedID(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions",
+			usecase.WithIdempotency(NewMockIdempotencyStore(), time.Hour)),
+		AdminToken: ownershipAdminToken,
+	})
+
+	submit := func(id, idempotencyKey string) *httptest.ResponseRecorder {
+		t.Helper()
+		body := `{"type":"deposit","to_account_id":"alice","amount":"25.00","currency":"USD"`
+		if id != "" {
+			body += `,"id":"` + id + `"`
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions", strings.NewReader(body+"}"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+ownershipAdminToken)
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) *domain.Transaction {
+		t.Helper()
+		var transaction domain.Transaction
+		if err := json.Unmarshal(rec.Body.Bytes(), &transaction); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return &transaction
+	}
+
+	const id = "3f2c6a1e-8d4b-4c1a-9e2f-5b7d0a9c4e21"
+	rec := submit(strings.ToUpper(id), "")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body)
+	}
+	if transaction := decode(rec); transaction.ID != id || transaction.IDSource != domain.TransactionIDClient {
+		t.Errorf("Expected the client's ID lowercased and marked as theirs, got %s (%s)", transaction.ID, transaction.IDSource)
+	}
+
+	rec = submit(id, "")
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a reused ID, got %d: %s", http.StatusConflict, rec.Code, rec.Body)
+	}
+	if len(transactionRepo.transactions) != 1 {
+		t.Errorf("Expected only the first transaction stored, got %d", len(transactionRepo.transactions))
+	}
+
+	// A retry with its idempotency key replays rather than conflicting
+	const keyedID = "9b1d4c7e-2a3f-4e5d-8c6b-1a2b3c4d5e6f"
+	if rec := submit(keyedID, "retry-1"); rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body)
+	}
+	rec = submit(keyedID, "retry-1")
+	if rec.Code != http.StatusOK || decode(rec).ID != keyedID {
+		t.Errorf("Expected the keyed retry replayed, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = submit("", "")
+	if transaction := decode(rec); rec.Code != http.StatusAccepted || transaction.ID == "" || transaction.IDSource != domain.TransactionIDServer {
+		t.Errorf("Expected a generated ID marked as the server's, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, invalid := range []string{
+		"txn-1",
+		"3f2c6a1e8d4b4c1a9e2f5b7d0a9c4e21",
+		"{3f2c6a1e-8d4b-4c1a-9e2f-5b7d0a9c4e21}",
+		"urn:uuid:3f2c6a1e-8d4b-4c1a-9e2f-5b7d0a9c4e21",
+		"00000000-0000-0000-0000-000000000000",
+	} {
+		if rec := submit(invalid, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for id %q, got %d: %s", http.StatusBadRequest, invalid, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// newSplitTransferFixture builds a batch fixture that posts every transfer's
+// debit and credit separately
+func newSplitTransferFixture(t *testing.T, clock *time.Time) (*ledgerFixture, *MockMessageQueue) {
+	t.Helper()
+	return newBatchFixture(t,
+		usecase.WithSplitTransfers(func(from, to *domain.Account) bool { return true }),
+		usecase.WithClock(func() time.Time { return *clock }),
+	)
+}
+
+// transfer submits a transfer from alice to bob
+func (f *ledgerFixture) transfer(t *testing.T, amount domain.Money) *domain.Transaction {
+	t.Helper()
+	alice, bob := "alice", "bob"
+	transaction, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: amount, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return transaction
+}
+
+func TestSplitTransfer_PostsBothLegs(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newSplitTransferFixture(t, &clock)
+
+	transfer := f.transfer(t, 2500)
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfer processed, got %v", errs)
+	}
+
+	if status := f.transactionRepo.transactions[transfer.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the transfer completed, got %s", status)
+	}
+	if alice, bob := f.accountRepo.accounts["alice"].Balance, f.accountRepo.accounts["bob"].Balance; alice != 7500 || bob != 7500 {
+		t.Errorf("Expected alice 7500 and bob 7500, got %d and %d", alice, bob)
+	}
+}
+
+func TestSplitTransfer_CompensatesFailedCredit(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newSplitTransferFixture(t, &clock)
+	f.accountRepo.accounts["bob"].Status = domain.AccountStatusInactive
+
+	transfer := f.transfer(t, 2500)
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfer acknowledged, got %v", errs)
+	}
+
+	stored := f.transactionRepo.transactions[transfer.ID]
+	if stored.Status != domain.TransactionStatusFailed || stored.FailureCode != domain.FailureCodeAccountInactive {
+		t.Fatalf("Expected the transfer failed as account_inactive, got %s %s", stored.Status, stored.FailureCode)
+	}
+	if stored.Metadata["compensated"] != true {
+		t.Errorf("Expected the transfer flagged compensated, got metadata %v", stored.Metadata)
+	}
+	if stored.Compensation == nil || stored.Compensation.Status != domain.CompensationStatusPending {
+		t.Fatalf("Expected a pending compensation, got %+v", stored.Compensation)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 7500 {
+		t.Errorf("Expected alice debited until compensated, got balance %d", balance)
+	}
+
+	// The compensating credit was queued; delivering it returns the money
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the compensation processed, got %v", errs)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 10000 {
+		t.Errorf("Expected alice's debit returned, got balance %d", balance)
+	}
+	if stored.Compensation.Status != domain.CompensationStatusCompleted || stored.Compensation.CompletedAt == nil {
+		t.Errorf("Expected the compensation completed, got %+v", stored.Compensation)
+	}
+	compensation := f.transactionRepo.transactions[usecase.CompensationTransactionID(transfer.ID)]
+	if compensation.Status != domain.TransactionStatusCompleted || compensation.CompensatedTransactionID != transfer.ID {
+		t.Errorf("Expected a completed compensation for %s, got %+v", transfer.ID, compensation)
+	}
+}
+
+func TestSplitTransfer_ResumesAfterDebit(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newSplitTransferFixture(t, &clock)
+
+	transfer := f.transfer(t, 8000)
+
+	// An earlier delivery posted the debit, then crashed before the credit
+	f.transactionRepo.transactions[transfer.ID].Status = domain.TransactionStatusProcessing
+	debit := domain.LedgerPosting{
+		domain.NewLedgerEntry(usecase.TransferLegID(transfer.ID, domain.EntryDirectionDebit), f.accountRepo.accounts["alice"], domain.EntryDirectionDebit, 8000),
+	}
+	if err := f.ledgerRepo.Post(context.Background(), debit); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// Debiting again would fail for insufficient funds
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the redelivery to succeed, got %v", errs)
+	}
+	if alice, bob := f.accountRepo.accounts["alice"].Balance, f.accountRepo.accounts["bob"].Balance; alice != 2000 || bob != 13000 {
+		t.Errorf("Expected alice debited once and bob credited, got %d and %d", alice, bob)
+	}
+	if status := f.transactionRepo.transactions[transfer.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the transfer completed, got %s", status)
+	}
+}
+
+func TestSplitTransfer_CompensationRetriedUntilItSucceeds(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newSplitTransferFixture(t, &clock)
+	f.accountRepo.accounts["bob"].Status = domain.AccountStatusInactive
+
+	transfer := f.transfer(t, 2500)
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfer acknowledged, got %v", errs)
+	}
+	compensationID := usecase.CompensationTransactionID(transfer.ID)
+
+	// The database is down when the compensation is delivered, twice over
+	f.ledgerRepo.postErr = errors.New("connection reset by peer")
+	for attempt := 1; attempt <= 2; attempt++ {
+		if errs := queue.Deliver("transactions"); len(errs) != 0 {
+			t.Fatalf("Expected the failed compensation acknowledged, got %v", errs)
+		}
+		clock = clock.Add(time.Minute)
+		if dispatched, _, err := f.service.DispatchOutbox(context.Background()); err != nil || dispatched != 1 {
+			t.Fatalf("Expected the compensation queued again, got %d, %v", dispatched, err)
+		}
+	}
+	if status := f.transactionRepo.transactions[compensationID].Status; status != domain.TransactionStatusProcessing {
+		t.Errorf("Expected the compensation still processing, got %s", status)
+	}
+
+	// A redelivered transfer does not compensate twice
+	if err := f.service.ProcessTransactionSync(context.Background(), &domain.TransactionRequest{
+		ID: transfer.ID, Type: domain.TransactionTypeTransfer, FromAccountID: transfer.FromAccountID, ToAccountID: transfer.ToAccountID,
+		Amount: transfer.Amount, Currency: "USD",
+	}); !domain.IsPermanent(err) {
+		t.Errorf("Expected the transfer to fail again, got %v", err)
+	}
+
+	f.ledgerRepo.postErr = nil
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the compensation processed, got %v", errs)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 10000 {
+		t.Errorf("Expected alice's debit returned once, got balance %d", balance)
+	}
+	if status := f.transactionRepo.transactions[compensationID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the compensation completed, got %s", status)
+	}
+}
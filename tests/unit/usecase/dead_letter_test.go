@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MockDeadLetterQueue holds the dead letters of one queue in order
+type MockDeadLetterQueue struct {
+	queueName   string
+	deadLetters []*domain.DeadLetter
+	requeued    []*domain.DeadLetter
+}
+
+func (m *MockDeadLetterQueue) PeekDeadLetters(ctx context.Context, queueName string, limit int) ([]*domain.DeadLetter, error) {
+	if queueName != m.queueName {
+		return nil, domain.ErrDeadLetterQueueNotConfigured
+	}
+	return m.deadLetters[:min(limit, len(m.deadLetters))], nil
+}
+
+func (m *MockDeadLetterQueue) RequeueDeadLetters(ctx context.Context, queueName string, ids []string) (*domain.DeadLetterRequeue, error) {
+	if queueName != m.queueName {
+		return nil, domain.ErrDeadLetterQueueNotConfigured
+	}
+	result := &domain.DeadLetterRequeue{Requeued: []string{}, Missing: []string{}}
+	m.deadLetters = slices.DeleteFunc(m.deadLetters, func(deadLetter *domain.DeadLetter) bool {
+		if !slices.Contains(ids, deadLetter.ID) {
+			return false
+		}
+		m.requeued = append(m.requeued, deadLetter)
+		result.Requeued = append(result.Requeued, deadLetter.ID)
+		return true
+	})
+	for _, id := range ids {
+		if !slices.Contains(result.Requeued, id) {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+	return result, nil
+}
+
+func TestDeadLetterRoutes(t *testing.T) {
+	queue := &MockDeadLetterQueue{queueName: "transactions"}
+	for i := 0; i < domain.MaxDeadLetterPageSize+5; i++ {
+		queue.deadLetters = append(queue.deadLetters, &domain.DeadLetter{
+			ID:             fmt.Sprintf("message-%03d", i),
+			Queue:          "transactions",
+			FailureCount:   3,
+			LastError:      "failed after 3 attempts: database error",
+			DeadLetteredAt: time.Now(),
+			Body:           json.RawMessage(fmt.Sprintf(`{"id":"tx-%03d"}`, i)),
+		})
+	}
+
+	newServer := func(deadLetterQueue domain.DeadLetterQueue) *echo.Echo {
+		e := echo.New()
+		routes.SetupRoutes(e, routes.Dependencies{
+			DeadLetterService: usecase.NewDeadLetterUseCase(deadLetterQueue, "transactions"),
+			AdminToken:        ownershipAdminToken,
+		})
+		return e
+	}
+	e := newServer(queue)
+
+	list := func(query string) []*domain.DeadLetter {
+		t.Helper()
+		rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/admin/dlq"+query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response struct {
+			DeadLetters []*domain.DeadLetter `json:"dead_letters"`
+			Count       int                  `json:"count"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Count != len(response.DeadLetters) {
+			t.Errorf("Expected the count to match, got %d for %d", response.Count, len(response.DeadLetters))
+		}
+		return response.DeadLetters
+	}
+
+	if peeked := list(""); len(peeked) != 10 || peeked[0].ID != "message-000" || peeked[0].FailureCount != 3 || string(peeked[0].Body) != `{"id":"tx-000"}` {
+		t.Errorf("Expected the oldest 10 dead letters by default, got %d starting %+v", len(peeked), peeked[0])
+	}
+	if peeked := list("?limit=1000"); len(peeked) != domain.MaxDeadLetterPageSize {
+		t.Errorf("Expected the limit clamped to %d, got %d", domain.MaxDeadLetterPageSize, len(peeked))
+	}
+
+	rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/admin/dlq/requeue", `{"ids":["message-001","message-003","gone"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var result domain.DeadLetterRequeue
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !slices.Equal(result.Requeued, []string{"message-001", "message-003"}) || !slices.Equal(result.Missing, []string{"gone"}) {
+		t.Errorf("Expected two requeued and one missing, got %+v", result)
+	}
+	if peeked := list("?limit=3"); peeked[1].ID != "message-002" || peeked[2].ID != "message-004" {
+		t.Errorf("Expected the requeued dead letters gone, got %s %s", peeked[1].ID, peeked[2].ID)
+	}
+
+	tooMany := make([]string, domain.MaxDeadLetterRequeueIDs+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("message-%03d", i)
+	}
+	body, _ := json.Marshal(map[string][]string{"ids": tooMany})
+	for _, tt := range []struct {
+		name string
+		body string
+	}{
+		{"no IDs", `{"ids":[]}`},
+		{"blank ID", `{"ids":["message-005"," "]}`},
+		{"too many IDs", string(body)},
+	} {
+		if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/admin/dlq/requeue", tt.body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, tt.name, rec.Code)
+		}
+	}
+	if len(queue.requeued) != 2 {
+		t.Errorf("Expected nothing more requeued, got %d", len(queue.requeued))
+	}
+
+	if rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/admin/dlq", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for a user, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	unconfigured := newServer(nil)
+	if rec := principalRequest(unconfigured, "admin", http.MethodGet, "/api/v1/admin/dlq", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without a dead letter queue, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+}
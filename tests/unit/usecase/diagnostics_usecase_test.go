@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// unavailableAccountRepository simulates an account store that cannot be reached
+type unavailableAccountRepository struct {
+	*MockAccountRepository
+}
+
+func (r *unavailableAccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	return nil, errors.New("connection refused")
+}
+
+func seedTransferTransaction(repo *MockTransactionRepository) {
+	from, to := "acc-from", "acc-to"
+	repo.transactions["tx-1"] = &domain.Transaction{
+		ID:            "tx-1",
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &from,
+		ToAccountID:   &to,
+		Amount:        40.0,
+		Currency:      "USD",
+		Status:        domain.TransactionStatusPending,
+	}
+}
+
+func TestDiagnosticsUseCase_GetTransactionDiagnostics(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	diagnosticsUseCase := usecase.NewDiagnosticsUseCase(accountRepo, transactionRepo)
+
+	accountRepo.accounts["acc-from"] = &domain.Account{ID: "acc-from", Balance: 100.0, Currency: "USD", Status: "active", Version: 4}
+	accountRepo.accounts["acc-to"] = &domain.Account{ID: "acc-to", Balance: 10.0, Currency: "USD", Status: "active", Version: 2}
+	seedTransferTransaction(transactionRepo)
+
+	diagnostics, err := diagnosticsUseCase.GetTransactionDiagnostics(context.Background(), "tx-1")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if diagnostics.Transaction == nil || diagnostics.Transaction.ID != "tx-1" {
+		t.Fatalf("Expected transaction tx-1 in diagnostics")
+	}
+	if len(diagnostics.Accounts) != 2 {
+		t.Fatalf("Expected 2 accounts, got %d", len(diagnostics.Accounts))
+	}
+	if diagnostics.Accounts[0].Version != 4 || diagnostics.Accounts[1].Version != 2 {
+		t.Errorf("Expected current account versions 4 and 2, got %d and %d", diagnostics.Accounts[0].Version, diagnostics.Accounts[1].Version)
+	}
+
+	if _, err := diagnosticsUseCase.GetTransactionDiagnostics(context.Background(), "missing"); err != domain.ErrTransactionNotFound {
+		t.Errorf("Expected %v for unknown transaction, got %v", domain.ErrTransactionNotFound, err)
+	}
+}
+
+func TestDiagnosticsUseCase_AccountStoreUnavailable(t *testing.T) {
+	accountRepo := &unavailableAccountRepository{NewMockAccountRepository()}
+	transactionRepo := NewMockTransactionRepository()
+	diagnosticsUseCase := usecase.NewDiagnosticsUseCase(accountRepo, transactionRepo)
+	seedTransferTransaction(transactionRepo)
+
+	diagnostics, err := diagnosticsUseCase.GetTransactionDiagnostics(context.Background(), "tx-1")
+	if err != nil {
+		t.Fatalf("Expected partial diagnostics but got error %v", err)
+	}
+
+	if diagnostics.Transaction == nil {
+		t.Errorf("Expected transaction section to be present")
+	}
+	if len(diagnostics.Accounts) != 0 {
+		t.Errorf("Expected accounts section to be omitted, got %d accounts", len(diagnostics.Accounts))
+	}
+
+	found := false
+	for _, note := range diagnostics.Notes {
+		if strings.Contains(note, "account store unavailable") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a note about the unavailable account store, got %v", diagnostics.Notes)
+	}
+}
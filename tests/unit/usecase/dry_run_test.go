@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type dryRunFixture struct {
+	accountRepo     *MockAccountRepository
+	transactionRepo *MockTransactionRepository
+	queue           *MockMessageQueue
+	service         domain.TransactionService
+}
+
+// newDryRunFixture gives alice 100.00 and bob 20.00 USD, with transfers
+// charged 0.50 plus 1% into the fee account
+func newDryRunFixture() *dryRunFixture {
+	f := &dryRunFixture{
+		accountRepo:     NewMockAccountRepository(),
+		transactionRepo: NewMockTransactionRepository(),
+		queue:           NewMockMessageQueue(),
+	}
+	for _, account := range []*domain.Account{
+		{ID: "alice", UserID: "alice", Balance: 10000},
+		{ID: "bob", UserID: "bob", Balance: 2000},
+		{ID: "fees", UserID: "bank", Type: domain.AccountTypeFeeCollection},
+	} {
+		account.Currency = "USD"
+		account.Status = domain.AccountStatusActive
+		account.Version = 1
+		f.accountRepo.accounts[account.ID] = account
+	}
+
+	policy := usecase.NewFeePolicy("fees", usecase.FeeFailureFail)
+	policy.SetRule(domain.TransactionTypeTransfer, "USD", usecase.FeeRule{Flat: 50, BasisPoints: 100})
+	f.service = usecase.NewTransactionUseCase(f.accountRepo, f.transactionRepo, f.queue, "transactions",
+		usecase.WithFeePolicy(policy),
+	)
+	return f
+}
+
+func transfer(from, to string, amount domain.Money) *domain.TransactionRequest {
+	return &domain.TransactionRequest{
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &from,
+		ToAccountID:   &to,
+		Amount:        amount,
+		Currency:      "USD",
+	}
+}
+
+func TestValidateTransaction_EstimatesFeeAndBalances(t *testing.T) {
+	f := newDryRunFixture()
+
+	validation, err := f.service.ValidateTransaction(context.Background(), transfer("alice", "bob", 5000))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !validation.WouldSucceed || validation.FailureCode != "" {
+		t.Fatalf("Expected the transfer to succeed, got %+v", validation)
+	}
+	if validation.Fee != 100 {
+		t.Errorf("Expected a fee of 1.00, got %d", validation.Fee)
+	}
+
+	balances := make(map[string]domain.Money)
+	for _, estimate := range validation.ResultingBalances {
+		balances[estimate.AccountID] = estimate.Balance
+	}
+	if balances["alice"] != 4900 || balances["bob"] != 7000 {
+		t.Errorf("Expected alice left with 49.00 and bob with 70.00, got %v", balances)
+	}
+
+	// Nothing is saved, queued or posted
+	if len(f.transactionRepo.transactions) != 0 {
+		t.Errorf("Expected no transactions saved, got %d", len(f.transactionRepo.transactions))
+	}
+	if len(f.queue.published["transactions"]) != 0 {
+		t.Errorf("Expected nothing published, got %d messages", len(f.queue.published["transactions"]))
+	}
+	if f.accountRepo.accounts["alice"].Balance != 10000 {
+		t.Errorf("Expected alice's balance untouched, got %d", f.accountRepo.accounts["alice"].Balance)
+	}
+}
+
+func TestValidateTransaction_ReportsFailures(t *testing.T) {
+	f := newDryRunFixture()
+	f.accountRepo.accounts["bob"].Status = domain.AccountStatusFrozen
+
+	tests := []struct {
+		name    string
+		request *domain.TransactionRequest
+		code    domain.FailureCode
+	}{
+		// The fee takes the debit over alice's balance
+		{"insufficient funds with fee", transfer("alice", "bob", 9950), domain.FailureCodeInsufficientFunds},
+		{"frozen source", transfer("bob", "alice", 100), domain.FailureCodeAccountFrozen},
+		{"missing destination", transfer("alice", "carol", 100), domain.FailureCodeAccountNotFound},
+		{"fee collection destination", transfer("alice", "fees", 100), domain.FailureCodeAccountRestricted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validation, err := f.service.ValidateTransaction(context.Background(), tt.request)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if validation.WouldSucceed {
+				t.Fatal("Expected the transaction not to succeed")
+			}
+			if validation.FailureCode != tt.code || validation.FailureReason == "" {
+				t.Errorf("Expected failure code %s with a reason, got %q: %q", tt.code, validation.FailureCode, validation.FailureReason)
+			}
+		})
+	}
+
+	// A malformed request is still an error
+	if _, err := f.service.ValidateTransaction(context.Background(), transfer("alice", "alice", 100)); err != domain.ErrSameAccount {
+		t.Errorf("Expected ErrSameAccount, got %v", err)
+	}
+}
+
+func TestValidateTransaction_HandlerDryRun(t *testing.T) {
+	f := newDryRunFixture()
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		TransactionService: f.service,
+	})
+
+	for _, path := range []string{"/api/v1/transactions?dry_run=true", "/api/v1/transactions"} {
+		rec := principalRequest(e, "alice", http.MethodPost, path,
+			`{"type":"transfer","from_account_id":"alice","to_account_id":"bob","amount":"50.00","currency":"USD","dry_run":true}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+
+		var body struct {
+			WouldSucceed bool   `json:"would_succeed"`
+			Fees         string `json:"fees_that_would_apply"`
+			Balances     []struct {
+				AccountID string `json:"account_id"`
+				Balance   string `json:"balance"`
+			} `json:"resulting_balance_estimate"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if !body.WouldSucceed || body.Fees != "1.00" || len(body.Balances) != 2 || body.Balances[0].Balance != "49.00" {
+			t.Errorf("Unexpected dry run result: %s", rec.Body)
+		}
+	}
+
+	if len(f.transactionRepo.transactions) != 0 {
+		t.Errorf("Expected no transactions saved, got %d", len(f.transactionRepo.transactions))
+	}
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/exchange"
+	"banking-ledger/internal/usecase"
+)
+
+// newExchangeFixture builds a batch fixture with a EUR account and a USD to
+// EUR rate of 0.92 quoted at the fixture's start
+func newExchangeFixture(t *testing.T, clock *time.Time, opts ...usecase.TransactionOption) (*ledgerFixture, *MockMessageQueue) {
+	t.Helper()
+	rates := exchange.NewStaticRateProvider()
+	rates.SetRate("USD", "EUR", "0.92", *clock)
+
+	f, queue := newBatchFixture(t, append([]usecase.TransactionOption{
+		usecase.WithExchangeRates(rates, time.Hour),
+		usecase.WithSubmissionValidation(true),
+		usecase.WithClock(func() time.Time { return *clock }),
+	}, opts...)...)
+	f.accountRepo.accounts["euro"] = &domain.Account{ID: "euro", UserID: "euro", Balance: 1000, Currency: "EUR", Status: domain.AccountStatusActive, Version: 1}
+	return f, queue
+}
+
+// transferTo submits a USD transfer from alice
+func (f *ledgerFixture) transferTo(t *testing.T, to string, amount domain.Money) *domain.Transaction {
+	t.Helper()
+	alice := "alice"
+	transaction, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &to, Amount: amount, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return transaction
+}
+
+func TestCrossCurrencyTransfer_CreditsConvertedAmount(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newExchangeFixture(t, &clock)
+
+	transfer := f.transferTo(t, "euro", 2500)
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfer processed, got %v", errs)
+	}
+
+	stored := f.transactionRepo.transactions[transfer.ID]
+	if stored.Status != domain.TransactionStatusCompleted {
+		t.Fatalf("Expected the transfer completed, got %s: %s", stored.Status, stored.ErrorMessage)
+	}
+	if alice, euro := f.accountRepo.accounts["alice"].Balance, f.accountRepo.accounts["euro"].Balance; alice != 7500 || euro != 3300 {
+		t.Errorf("Expected alice debited 25.00 USD and euro credited 23.00 EUR, got %d and %d", alice, euro)
+	}
+	if stored.Exchange == nil || stored.Exchange.Rate != "0.92" || stored.Exchange.CreditedAmount != 2300 || stored.Exchange.CreditedCurrency != "EUR" {
+		t.Errorf("Expected the rate and credited amount recorded, got %+v", stored.Exchange)
+	}
+
+	// Each ledger entry is in its own account's currency
+	for _, entry := range f.ledgerRepo.entries {
+		expected := map[string]domain.Money{"alice": 2500, "euro": 2300}[entry.AccountID]
+		if entry.Amount != expected || entry.Currency != f.accountRepo.accounts[entry.AccountID].Currency {
+			t.Errorf("Expected %s's entry for %d in its currency, got %d %s", entry.AccountID, expected, entry.Amount, entry.Currency)
+		}
+	}
+}
+
+func TestCrossCurrencyTransfer_ChecksFundsInSourceCurrency(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newExchangeFixture(t, &clock, usecase.WithSubmissionValidation(false))
+
+	// 105.00 USD is more than alice holds, though its 96.60 EUR is not
+	transfer := f.transferTo(t, "euro", 10500)
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfer acknowledged, got %v", errs)
+	}
+
+	stored := f.transactionRepo.transactions[transfer.ID]
+	if stored.FailureCode != domain.FailureCodeInsufficientFunds {
+		t.Errorf("Expected the transfer failed for insufficient funds, got %s", stored.FailureCode)
+	}
+	if stored.Exchange != nil {
+		t.Errorf("Expected no rate recorded for a transfer that was not posted, got %+v", stored.Exchange)
+	}
+}
+
+func TestCrossCurrencyTransfer_RateUnavailable(t *testing.T) {
+	tests := []struct {
+		name    string
+		to      string
+		advance time.Duration
+	}{
+		{"no rate", "jpy", 0},
+		{"stale rate", "euro", 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+			f, queue := newExchangeFixture(t, &clock)
+			f.accountRepo.accounts["jpy"] = &domain.Account{ID: "jpy", Currency: "JPY", Status: domain.AccountStatusActive, Version: 1}
+
+			transfer := f.transferTo(t, tt.to, 2500)
+			clock = clock.Add(tt.advance)
+			if errs := queue.Deliver("transactions"); len(errs) != 0 {
+				t.Fatalf("Expected the failure acknowledged, got %v", errs)
+			}
+
+			stored := f.transactionRepo.transactions[transfer.ID]
+			if stored.Status != domain.TransactionStatusFailed || stored.FailureCode != domain.FailureCodeRateUnavailable {
+				t.Errorf("Expected the transfer failed as rate_unavailable, got %s %s", stored.Status, stored.FailureCode)
+			}
+			if balance := f.accountRepo.accounts["alice"].Balance; balance != 10000 {
+				t.Errorf("Expected alice not debited, got balance %d", balance)
+			}
+		})
+	}
+}
+
+func TestCrossCurrencyTransfer_RejectedWithoutRates(t *testing.T) {
+	f, _ := newBatchFixture(t, usecase.WithSubmissionValidation(true))
+	f.accountRepo.accounts["euro"] = &domain.Account{ID: "euro", Currency: "EUR", Status: domain.AccountStatusActive, Version: 1}
+	alice, euro := "alice", "euro"
+
+	_, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &euro, Amount: 2500, Currency: "USD",
+	})
+	if !errors.Is(err, domain.ErrCurrencyMismatch) {
+		t.Errorf("Expected %v, got %v", domain.ErrCurrencyMismatch, err)
+	}
+}
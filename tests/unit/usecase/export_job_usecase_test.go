@@ -0,0 +1,313 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/storage"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MockExportJobRepository implements domain.ExportJobRepository for testing
+type MockExportJobRepository struct {
+	mu   sync.Mutex
+	jobs map[string]*domain.ExportJob
+	// progress records the rows written at each progress update
+	progress []int64
+}
+
+func NewMockExportJobRepository() *MockExportJobRepository {
+	return &MockExportJobRepository{jobs: make(map[string]*domain.ExportJob)}
+}
+
+func (m *MockExportJobRepository) Create(ctx context.Context, job *domain.ExportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *MockExportJobRepository) Get(ctx context.Context, id string) (*domain.ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, exists := m.jobs[id]
+	if !exists {
+		return nil, domain.ErrExportJobNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (m *MockExportJobRepository) Claim(ctx context.Context, id string, now, staleBefore time.Time) (*domain.ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, exists := m.jobs[id]
+	if !exists {
+		return nil, domain.ErrExportJobNotFound
+	}
+	if job.Status != domain.ExportJobQueued && (job.Status != domain.ExportJobRunning || !job.UpdatedAt.Before(staleBefore)) {
+		return nil, nil
+	}
+	job.Status = domain.ExportJobRunning
+	job.RowCount = 0
+	job.StartedAt = &now
+	job.UpdatedAt = now
+	copied := *job
+	return &copied, nil
+}
+
+func (m *MockExportJobRepository) UpdateProgress(ctx context.Context, job *domain.ExportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.jobs[job.ID]
+	stored.RowCount = job.RowCount
+	stored.TotalRows = job.TotalRows
+	m.progress = append(m.progress, job.RowCount)
+	return nil
+}
+
+func (m *MockExportJobRepository) Finish(ctx context.Context, job *domain.ExportJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *MockExportJobRepository) Cancel(ctx context.Context, id string) (*domain.ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, exists := m.jobs[id]
+	if !exists {
+		return nil, domain.ErrExportJobNotFound
+	}
+	if job.Status != domain.ExportJobQueued {
+		return nil, domain.ErrExportJobNotCancellable
+	}
+	job.Status = domain.ExportJobCancelled
+	copied := *job
+	return &copied, nil
+}
+
+func (m *MockExportJobRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*domain.ExportJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var jobs []*domain.ExportJob
+	for _, job := range m.jobs {
+		if job.Status == domain.ExportJobCompleted && job.ExpiresAt != nil && !job.ExpiresAt.After(now) && len(jobs) < limit {
+			copied := *job
+			jobs = append(jobs, &copied)
+		}
+	}
+	return jobs, nil
+}
+
+func (m *MockExportJobRepository) Expire(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if job, exists := m.jobs[id]; exists && job.Status == domain.ExportJobCompleted {
+		job.Status = domain.ExportJobExpired
+		job.FileLocation = ""
+	}
+	return nil
+}
+
+type exportJobFixture struct {
+	e       *echo.Echo
+	repo    *MockExportJobRepository
+	queue   *MockMessageQueue
+	service domain.ExportJobService
+	now     time.Time
+}
+
+// newExportJobFixture serves export jobs over alice's account, holding three
+// deposits and a withdrawal, with a bob account beside it
+func newExportJobFixture(t *testing.T) *exportJobFixture {
+	t.Helper()
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice-usd"] = &domain.Account{ID: "alice-usd", UserID: "alice", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["bob-usd"] = &domain.Account{ID: "bob-usd", UserID: "bob", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	alice := "alice-usd"
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, id := range []string{"dep-1", "dep-2", "dep-3", "wd-1"} {
+		transaction := &domain.Transaction{
+			ID: id, Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: domain.Money(1000 * (i + 1)),
+			Currency: "USD", Status: domain.TransactionStatusCompleted, CreatedAt: created.Add(time.Duration(i) * time.Hour),
+		}
+		if id == "wd-1" {
+			transaction.Type, transaction.ToAccountID, transaction.FromAccountID = domain.TransactionTypeWithdrawal, nil, &alice
+		}
+		transactionRepo.transactions[id] = transaction
+	}
+
+	exportStorage, err := storage.NewLocalExportStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create export storage: %v", err)
+	}
+
+	f := &exportJobFixture{
+		repo:  NewMockExportJobRepository(),
+		queue: NewMockMessageQueue(),
+		now:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	f.service = usecase.NewExportJobUseCase(f.repo, accountRepo, transactionRepo, exportStorage, f.queue, "exports",
+		usecase.WithExportRetention(24*time.Hour),
+		usecase.WithExportProgressRows(2),
+		usecase.WithExportJobClock(func() time.Time { return f.now }),
+	)
+	if _, err := f.queue.Subscribe(context.Background(), "exports", func(ctx context.Context, message []byte) error {
+		return f.service.HandleExportJob(ctx, message)
+	}); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	f.e = echo.New()
+	routes.SetupRoutes(f.e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		ExportService:      f.service,
+		AdminToken:         ownershipAdminToken,
+	})
+	return f
+}
+
+// job requests an export job as principal, expecting the status, and decodes
+// the response
+func (f *exportJobFixture) job(t *testing.T, principal, method, path, body string, expected int) map[string]interface{} {
+	t.Helper()
+	rec := principalRequest(f.e, principal, method, path, body)
+	if rec.Code != expected {
+		t.Fatalf("Expected status %d for %s %s, got %d: %s", expected, method, path, rec.Code, rec.Body)
+	}
+	var job map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return job
+}
+
+func TestExportJobs_WriteAndDownload(t *testing.T) {
+	f := newExportJobFixture(t)
+
+	job := f.job(t, "alice", http.MethodPost, "/api/v1/exports",
+		`{"account_id":"alice-usd","format":"csv","filter":{"type":"deposit","limit":1}}`, http.StatusAccepted)
+	id, _ := job["id"].(string)
+	if job["status"] != string(domain.ExportJobQueued) || job["download_url"] != nil || f.queue.PublishedCount("exports") != 1 {
+		t.Fatalf("Expected a queued job without a download link, got %v", job)
+	}
+	f.job(t, "bob", http.MethodGet, "/api/v1/exports/"+id, "", http.StatusForbidden)
+	f.job(t, "alice", http.MethodGet, "/api/v1/exports/"+id+"/download", "", http.StatusConflict)
+
+	if errs := f.queue.Deliver("exports"); len(errs) != 0 {
+		t.Fatalf("Expected the job written, got %v", errs)
+	}
+	job = f.job(t, "alice", http.MethodGet, "/api/v1/exports/"+id, "", http.StatusOK)
+	if job["status"] != string(domain.ExportJobCompleted) || job["row_count"] != float64(3) || job["total_rows"] != float64(3) {
+		t.Errorf("Expected the three deposits written, got %v", job)
+	}
+	if job["download_url"] != "/api/v1/exports/"+id+"/download" || job["expires_at"] != "2024-06-02T12:00:00Z" {
+		t.Errorf("Expected a download link expiring after a day, got %v and %v", job["download_url"], job["expires_at"])
+	}
+	if _, leaked := job["file_location"]; leaked {
+		t.Errorf("Expected the file location kept private, got %v", job)
+	}
+	// Progress is recorded with the total, then every second row
+	if len(f.repo.progress) != 2 || f.repo.progress[0] != 0 || f.repo.progress[1] != 2 {
+		t.Errorf("Expected progress at 0 and 2 rows, got %v", f.repo.progress)
+	}
+
+	rec := principalRequest(f.e, "alice", http.MethodGet, "/api/v1/exports/"+id+"/download", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 4 || lines[0] != strings.Join(domain.ExportColumns, ",") || !strings.HasPrefix(lines[1], "dep-1,deposit,credit,10.00,USD") {
+		t.Errorf("Expected the header and the deposits oldest first, got %q", lines)
+	}
+	if disposition := rec.Header().Get(echo.HeaderContentDisposition); !strings.Contains(disposition, "account-alice-usd-transactions.csv") {
+		t.Errorf("Expected the file named after the account, got %q", disposition)
+	}
+
+	// Redelivery does not write the job again
+	f.queue.Publish(context.Background(), "exports", []byte(`{"job_id":"`+id+`"}`))
+	if errs := f.queue.Deliver("exports"); len(errs) != 0 || len(f.repo.progress) != 2 {
+		t.Errorf("Expected a redelivered job skipped, got %v and %v", errs, f.repo.progress)
+	}
+	f.job(t, "alice", http.MethodPatch, "/api/v1/exports/"+id+"/cancel", "", http.StatusConflict)
+
+	location := f.repo.jobs[id].FileLocation
+	if expired, err := f.service.ExpireExports(context.Background()); err != nil || expired != 0 {
+		t.Errorf("Expected nothing expired within the retention, got %d and %v", expired, err)
+	}
+	f.now = f.now.Add(25 * time.Hour)
+	if expired, err := f.service.ExpireExports(context.Background()); err != nil || expired != 1 {
+		t.Errorf("Expected the file expired, got %d and %v", expired, err)
+	}
+	if _, err := os.Stat(location); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the file removed, got %v", err)
+	}
+	job = f.job(t, "alice", http.MethodGet, "/api/v1/exports/"+id, "", http.StatusOK)
+	if job["status"] != string(domain.ExportJobExpired) || job["download_url"] != nil {
+		t.Errorf("Expected the job expired without a link, got %v", job)
+	}
+	f.job(t, "alice", http.MethodGet, "/api/v1/exports/"+id+"/download", "", http.StatusConflict)
+}
+
+func TestExportJobs_CancelAndReject(t *testing.T) {
+	f := newExportJobFixture(t)
+
+	job := f.job(t, "admin", http.MethodPost, "/api/v1/exports", `{"account_id":"alice-usd","format":"json-lines"}`, http.StatusAccepted)
+	id, _ := job["id"].(string)
+	f.job(t, "bob", http.MethodPatch, "/api/v1/exports/"+id+"/cancel", "", http.StatusForbidden)
+	job = f.job(t, "alice", http.MethodPatch, "/api/v1/exports/"+id+"/cancel", "", http.StatusOK)
+	if job["status"] != string(domain.ExportJobCancelled) {
+		t.Errorf("Expected the job cancelled, got %v", job)
+	}
+	if errs := f.queue.Deliver("exports"); len(errs) != 0 || f.repo.jobs[id].Status != domain.ExportJobCancelled || len(f.repo.progress) != 0 {
+		t.Errorf("Expected the cancelled job left unwritten, got %v, %s and %v", errs, f.repo.jobs[id].Status, f.repo.progress)
+	}
+
+	rejected := []struct {
+		principal, body string
+		expected        int
+	}{
+		{"admin", `{"format":"csv"}`, http.StatusBadRequest},
+		{"admin", `{"account_id":"alice-usd","format":"xml"}`, http.StatusBadRequest},
+		{"admin", `{"account_id":"alice-usd","filter":{"from_date":"2024-03-02T00:00:00Z","to_date":"2024-03-01T00:00:00Z"}}`, http.StatusBadRequest},
+		{"admin", `{"account_id":"alice-usd","filter":{"min_amount":"20","max_amount":"10"}}`, http.StatusBadRequest},
+		{"admin", `{"account_id":"missing"}`, http.StatusNotFound},
+		{"bob", `{"account_id":"alice-usd"}`, http.StatusForbidden},
+	}
+	for _, tt := range rejected {
+		f.job(t, tt.principal, http.MethodPost, "/api/v1/exports", tt.body, tt.expected)
+	}
+	f.job(t, "admin", http.MethodGet, "/api/v1/exports/missing", "", http.StatusNotFound)
+
+	f.queue.publishErr = errors.New("broker down")
+	f.job(t, "alice", http.MethodPost, "/api/v1/exports", `{"account_id":"alice-usd"}`, http.StatusServiceUnavailable)
+	failed := 0
+	for _, job := range f.repo.jobs {
+		if job.Status == domain.ExportJobFailed {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expected the unqueued job failed, got %d failed", failed)
+	}
+}
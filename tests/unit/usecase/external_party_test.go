@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestExternalPartiesRequired(t *testing.T) {
+	f := newDryRunFixture()
+	service := usecase.NewTransactionUseCase(f.accountRepo, f.transactionRepo, f.queue, "transactions",
+		usecase.WithExternalPartiesRequired(),
+	)
+	ctx := context.Background()
+
+	if _, err := service.ProcessTransaction(ctx, deposit("alice", 500)); !errors.Is(err, domain.ErrExternalPartyRequired) {
+		t.Errorf("Expected a deposit without a source rejected, got %v", err)
+	}
+	if _, err := service.ValidateTransaction(ctx, withdrawal("alice")); !errors.Is(err, domain.ErrExternalPartyRequired) {
+		t.Errorf("Expected a withdrawal without a destination rejected, got %v", err)
+	}
+	if _, err := service.ProcessTransaction(ctx, transfer("alice", "bob", 500)); err != nil {
+		t.Errorf("Expected transfers unaffected, got %v", err)
+	}
+
+	request := deposit("alice", 500)
+	request.ExternalSource = &domain.ExternalParty{Type: domain.ExternalPartyCard, Reference: "tok_visa_4242"}
+	transaction, err := service.ProcessTransaction(ctx, request)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	stored := f.transactionRepo.transactions[transaction.ID]
+	if stored.ExternalSource == nil || *stored.ExternalSource != *request.ExternalSource {
+		t.Errorf("Expected the external source stored, got %+v", stored.ExternalSource)
+	}
+}
+
+func TestExternalParties_FilterTransactions(t *testing.T) {
+	f := newDryRunFixture()
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		TransactionService: f.service,
+		AdminToken:         ownershipAdminToken,
+	})
+
+	for _, body := range []string{
+		`{"type":"deposit","to_account_id":"alice","amount":"10.00","currency":"USD","external_source":{"type":"bank_account","reference":"acct-1"}}`,
+		`{"type":"deposit","to_account_id":"bob","amount":"20.00","currency":"USD","external_source":{"type":"bank_account","reference":"acct-2"}}`,
+		`{"type":"withdrawal","from_account_id":"alice","amount":"5.00","currency":"USD","external_destination":{"type":"bank_account","reference":"acct-1"}}`,
+	} {
+		if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/transactions", body); rec.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body)
+		}
+	}
+
+	invalid := `{"type":"transfer","from_account_id":"alice","to_account_id":"bob","amount":"1.00","currency":"USD","external_source":{"type":"bank_account","reference":"acct-1"}}`
+	if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/transactions", invalid); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an external source on a transfer rejected, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for query, expected := range map[string]domain.TransactionType{
+		"external_source=acct-1":      domain.TransactionTypeDeposit,
+		"external_destination=acct-1": domain.TransactionTypeWithdrawal,
+	} {
+		rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?"+query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, query, rec.Code, rec.Body)
+		}
+
+		var body struct {
+			Transactions []*domain.Transaction `json:"transactions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(body.Transactions) != 1 || body.Transactions[0].Type != expected {
+			t.Errorf("Expected one %s for %s, got %d transactions", expected, query, len(body.Transactions))
+		}
+	}
+}
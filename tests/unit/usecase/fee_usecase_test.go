@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// newFeeFixture builds a ledger fixture charging 0.50 plus 1% on USD
+// withdrawals and transfers into a "fees" account
+func newFeeFixture(mode usecase.FeeFailureMode) *ledgerFixture {
+	f := newLedgerFixture()
+	f.accountRepo.accounts["fees"] = &domain.Account{ID: "fees", UserID: "bank", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	policy := usecase.NewFeePolicy("fees", mode)
+	policy.SetRule(domain.TransactionTypeWithdrawal, "USD", usecase.FeeRule{Flat: 50, BasisPoints: 100})
+	policy.SetRule(domain.TransactionTypeTransfer, "USD", usecase.FeeRule{Flat: 50, BasisPoints: 100})
+
+	f.service = usecase.NewTransactionUseCase(
+		f.accountRepo, f.transactionRepo, NewMockMessageQueue(), "transactions",
+		usecase.WithLedgerEntries(f.ledgerRepo),
+		usecase.WithFeePolicy(policy),
+	).(*usecase.TransactionUseCase)
+	return f
+}
+
+func TestFeeRule_FeeFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     usecase.FeeRule
+		amount   domain.Money
+		expected domain.Money
+	}{
+		{"flat only", usecase.FeeRule{Flat: 50}, 10000, 50},
+		{"percentage only", usecase.FeeRule{BasisPoints: 150}, 10000, 150},
+		{"flat and percentage", usecase.FeeRule{Flat: 25, BasisPoints: 100}, 4000, 65},
+		{"rounds half up", usecase.FeeRule{BasisPoints: 50}, 101, 1},
+		{"rounds down below half", usecase.FeeRule{BasisPoints: 40}, 101, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.FeeFor(tt.amount); got != tt.expected {
+				t.Errorf("Expected fee %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoadFeePolicy(t *testing.T) {
+	policy, err := usecase.LoadFeePolicy([]string{"withdrawal:usd:0.50:1.5"}, "fees", "record")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	request := &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, Amount: 10000, Currency: "USD"}
+	if fee := policy.FeeFor(request); fee != 200 {
+		t.Errorf("Expected fee 2.00, got %d", fee)
+	}
+	request.Type = domain.TransactionTypeDeposit
+	if fee := policy.FeeFor(request); fee != 0 {
+		t.Errorf("Expected no fee on deposits, got %d", fee)
+	}
+
+	invalid := []struct {
+		name       string
+		rules      []string
+		collection string
+		mode       string
+	}{
+		{"missing collection account", []string{"withdrawal:USD:0.50:0"}, "", "record"},
+		{"unknown mode", nil, "fees", "ignore"},
+		{"too few parts", []string{"withdrawal:USD:0.50"}, "fees", "record"},
+		{"deposit fee", []string{"deposit:USD:0.50:0"}, "fees", "record"},
+		{"unsupported currency", []string{"withdrawal:XYZ:0.50:0"}, "fees", "record"},
+		{"over-precise flat amount", []string{"withdrawal:USD:0.505:0"}, "fees", "record"},
+		{"bad percentage", []string{"withdrawal:USD:0.50:abc"}, "fees", "record"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := usecase.LoadFeePolicy(tt.rules, tt.collection, tt.mode); err == nil {
+				t.Error("Expected an error but got none")
+			}
+		})
+	}
+}
+
+func TestFees_TransferChargesLinkedFee(t *testing.T) {
+	f := newFeeFixture(usecase.FeeFailureRecord)
+	alice, bob := "alice", "bob"
+
+	f.process(t, &domain.TransactionRequest{ID: "tr", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 4000, Currency: "USD"})
+
+	// 40.00 moved plus a 0.50 + 1% fee of 0.90
+	for accountID, expected := range map[string]domain.Money{"alice": 5910, "bob": 9000, "fees": 90} {
+		if balance := f.accountRepo.accounts[accountID].Balance; balance != expected {
+			t.Errorf("Expected %s balance %d, got %d", accountID, expected, balance)
+		}
+	}
+
+	fee, exists := f.transactionRepo.transactions[usecase.FeeTransactionID("tr")]
+	if !exists {
+		t.Fatal("Expected a fee transaction to be recorded")
+	}
+	if fee.Type != domain.TransactionTypeFee || fee.Status != domain.TransactionStatusCompleted || fee.Amount != 90 ||
+		*fee.FromAccountID != "alice" || *fee.ToAccountID != "fees" || fee.Metadata["parent_transaction_id"] != "tr" {
+		t.Errorf("Unexpected fee transaction %+v", fee)
+	}
+
+	// The fee is in the payer's history
+	history, err := f.service.GetTransactionHistory(context.Background(), "alice", &domain.TransactionFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	found := false
+	for _, transaction := range history {
+		found = found || transaction.ID == fee.ID
+	}
+	if !found {
+		t.Error("Expected the fee in the account history")
+	}
+
+	// Both debits of the payer are posted with consecutive versions
+	entries, _ := f.ledgerRepo.GetByAccountID(context.Background(), "alice", 10, 0)
+	if len(entries) != 2 || entries[0].ID != "tr-fee-debit" || entries[0].ResultingBalance != 5910 {
+		t.Errorf("Expected the fee debit after the transfer debit, got %+v", entries)
+	}
+}
+
+func TestFees_ReplayChargesOnce(t *testing.T) {
+	f := newFeeFixture(usecase.FeeFailureRecord)
+	bob := "bob"
+	request := &domain.TransactionRequest{ID: "wd", Type: domain.TransactionTypeWithdrawal, FromAccountID: &bob, Amount: 1000, Currency: "USD"}
+
+	f.process(t, request)
+	f.process(t, request)
+
+	if balance := f.accountRepo.accounts["bob"].Balance; balance != 3940 {
+		t.Errorf("Expected balance 39.40 after one withdrawal and fee, got %d", balance)
+	}
+	if balance := f.accountRepo.accounts["fees"].Balance; balance != 60 {
+		t.Errorf("Expected 0.60 collected, got %d", balance)
+	}
+}
+
+func TestFees_InsufficientFundsForFee(t *testing.T) {
+	bob := "bob"
+
+	t.Run("record", func(t *testing.T) {
+		f := newFeeFixture(usecase.FeeFailureRecord)
+		f.process(t, &domain.TransactionRequest{ID: "wd", Type: domain.TransactionTypeWithdrawal, FromAccountID: &bob, Amount: 5000, Currency: "USD"})
+
+		if balance := f.accountRepo.accounts["bob"].Balance; balance != 0 {
+			t.Errorf("Expected the withdrawal to complete without the fee, got balance %d", balance)
+		}
+		fee := f.transactionRepo.transactions[usecase.FeeTransactionID("wd")]
+		if fee == nil || fee.Status != domain.TransactionStatusFailed || fee.FailureCode != domain.FailureCodeInsufficientFunds {
+			t.Errorf("Expected a failed fee transaction, got %+v", fee)
+		}
+		if balance := f.accountRepo.accounts["fees"].Balance; balance != 0 {
+			t.Errorf("Expected nothing collected, got %d", balance)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		f := newFeeFixture(usecase.FeeFailureFail)
+		f.transactionRepo.transactions["wd"] = &domain.Transaction{ID: "wd", Status: domain.TransactionStatusPending}
+		err := f.service.ProcessTransactionSync(context.Background(), &domain.TransactionRequest{
+			ID: "wd", Type: domain.TransactionTypeWithdrawal, FromAccountID: &bob, Amount: 5000, Currency: "USD",
+		})
+		if !errors.Is(err, domain.ErrInsufficientFunds) {
+			t.Fatalf("Expected %v, got %v", domain.ErrInsufficientFunds, err)
+		}
+		if balance := f.accountRepo.accounts["bob"].Balance; balance != 5000 {
+			t.Errorf("Expected the balance untouched, got %d", balance)
+		}
+		if _, exists := f.transactionRepo.transactions[usecase.FeeTransactionID("wd")]; exists {
+			t.Error("Expected no fee transaction")
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestFreeze_TransferWithOneSideFrozen(t *testing.T) {
+	service, accountRepo, transactionRepo, queue := newReversalTestUseCase()
+	accounts := usecase.NewAccountUseCase(accountRepo, transactionRepo)
+	ctx := domain.ContextWithPrincipal(context.Background(), &domain.Principal{Admin: true})
+
+	if _, err := accounts.FreezeAccount(ctx, "acc-1", "Suspected account takeover"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	incoming, err := service.ProcessTransaction(context.Background(), transfer("acc-2", "acc-1", 2500))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	outgoing, err := service.ProcessTransaction(context.Background(), transfer("acc-1", "acc-2", 1000))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfers handled, got %v", errs)
+	}
+
+	if status := transactionRepo.transactions[incoming.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the transfer into the frozen account completed, got %s", status)
+	}
+	failed := transactionRepo.transactions[outgoing.ID]
+	if failed.Status != domain.TransactionStatusFailed || failed.FailureCode != domain.FailureCodeAccountFrozen {
+		t.Errorf("Expected the transfer out of the frozen account failed as frozen, got %s %s", failed.Status, failed.FailureCode)
+	}
+	if balance := accountRepo.accounts["acc-1"].Balance; balance != 12500 {
+		t.Errorf("Expected the frozen account credited only, got %d", balance)
+	}
+	if balance := accountRepo.accounts["acc-2"].Balance; balance != 7500 {
+		t.Errorf("Expected the other account debited only, got %d", balance)
+	}
+}
+
+func TestFreeze_Endpoints(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions",
+			usecase.WithSubmissionValidation(true),
+		),
+		AdminToken: ownershipAdminToken,
+	})
+
+	if rec := principalRequest(e, "admin", http.MethodPatch, "/api/v1/accounts/alice/freeze", `{"reason":" "}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a blank reason rejected, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "alice", http.MethodPatch, "/api/v1/accounts/alice/freeze", `{"reason":"mine"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a user refused, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "admin", http.MethodPatch, "/api/v1/accounts/alice/freeze", `{"reason":"Chargeback investigation"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	// The owner cannot lift the freeze, nor anyone change it without a reason
+	for _, path := range []string{"activate", "deactivate?force=true"} {
+		if rec := principalRequest(e, "alice", http.MethodPatch, "/api/v1/accounts/alice/"+path, ""); rec.Code != http.StatusConflict {
+			t.Errorf("Expected %s of the frozen account refused, got %d: %s", path, rec.Code, rec.Body)
+		}
+	}
+	for _, principal := range []string{"alice", "admin"} {
+		if rec := principalRequest(e, principal, http.MethodPatch, "/api/v1/accounts/alice/status", `{"status":"active"}`); rec.Code != http.StatusConflict {
+			t.Errorf("Expected %s setting the frozen account active refused, got %d: %s", principal, rec.Code, rec.Body)
+		}
+	}
+
+	withdrawal := `{"type":"withdrawal","from_account_id":"alice","amount":"1.00","currency":"USD"}`
+	if rec := principalRequest(e, "alice", http.MethodPost, "/api/v1/transactions", withdrawal); rec.Code != http.StatusLocked {
+		t.Errorf("Expected a withdrawal from the frozen account locked, got %d: %s", rec.Code, rec.Body)
+	}
+	deposit := `{"type":"deposit","to_account_id":"alice","amount":"1.00","currency":"USD"}`
+	if rec := principalRequest(e, "alice", http.MethodPost, "/api/v1/transactions", deposit); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected a deposit into the frozen account accepted, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for principal, visible := range map[string]bool{"admin": true, "alice": false} {
+		rec := principalRequest(e, principal, http.MethodGet, "/api/v1/accounts/alice", "")
+		var account domain.Account
+		if err := json.Unmarshal(rec.Body.Bytes(), &account); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if account.Status != domain.AccountStatusFrozen {
+			t.Errorf("Expected the account frozen for %s, got %s", principal, account.Status)
+		}
+		shown := account.StatusReason == "Chargeback investigation" && account.StatusChangedBy == "admin"
+		if shown != visible {
+			t.Errorf("Expected the freeze reason and actor shown to %s: %v, got %q by %q", principal, visible, account.StatusReason, account.StatusChangedBy)
+		}
+	}
+
+	if rec := principalRequest(e, "admin", http.MethodPatch, "/api/v1/accounts/alice/unfreeze", `{"reason":"Investigation closed"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "admin", http.MethodPatch, "/api/v1/accounts/alice/unfreeze", `{"reason":"Again"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected unfreezing an active account refused, got %d: %s", rec.Code, rec.Body)
+	}
+	if status := accountRepo.accounts["alice"].Status; status != domain.AccountStatusActive {
+		t.Errorf("Expected the account active again, got %s", status)
+	}
+	if rec := principalRequest(e, "admin", http.MethodPatch, "/api/v1/accounts/alice/status", `{"status":"frozen"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected freezing without a reason refused, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,289 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockHoldRepository implements domain.HoldRepository for testing, reserving
+// and releasing held amounts on the mock accounts
+type MockHoldRepository struct {
+	accountRepo *MockAccountRepository
+	ledgerRepo  *MockLedgerEntryRepository
+	holds       map[string]*domain.Hold
+}
+
+func NewMockHoldRepository(accountRepo *MockAccountRepository, ledgerRepo *MockLedgerEntryRepository) *MockHoldRepository {
+	return &MockHoldRepository{
+		accountRepo: accountRepo,
+		ledgerRepo:  ledgerRepo,
+		holds:       make(map[string]*domain.Hold),
+	}
+}
+
+func (m *MockHoldRepository) Place(ctx context.Context, hold *domain.Hold, account *domain.Account) error {
+	stored, exists := m.accountRepo.accounts[account.ID]
+	if !exists {
+		return domain.ErrAccountNotFound
+	}
+	if stored.Version != account.Version {
+		return domain.ErrConcurrentUpdate
+	}
+
+	stored.HeldAmount += hold.Amount
+	stored.Version++
+	account.HeldAmount, account.Version = stored.HeldAmount, stored.Version
+
+	hold.CreatedAt = time.Now()
+	hold.UpdatedAt = hold.CreatedAt
+	hold.Version = 1
+	copied := *hold
+	m.holds[hold.ID] = &copied
+	return nil
+}
+
+func (m *MockHoldRepository) GetByID(ctx context.Context, id string) (*domain.Hold, error) {
+	hold, exists := m.holds[id]
+	if !exists {
+		return nil, domain.ErrHoldNotFound
+	}
+	copied := *hold
+	return &copied, nil
+}
+
+func (m *MockHoldRepository) Resolve(ctx context.Context, hold *domain.Hold, account *domain.Account, posting domain.LedgerPosting) error {
+	stored := m.holds[hold.ID]
+	if stored.Version != hold.Version || stored.Status != domain.HoldStatusActive {
+		return domain.ErrConcurrentUpdate
+	}
+
+	storedAccount := m.accountRepo.accounts[account.ID]
+	if len(posting) > 0 {
+		if err := m.ledgerRepo.Post(ctx, posting); err != nil {
+			return err
+		}
+	} else {
+		if storedAccount.Version != account.Version {
+			return domain.ErrConcurrentUpdate
+		}
+		storedAccount.Version++
+	}
+	storedAccount.HeldAmount -= hold.Amount
+
+	hold.Version++
+	copied := *hold
+	m.holds[hold.ID] = &copied
+	return nil
+}
+
+func (m *MockHoldRepository) ListExpiredActive(ctx context.Context, now time.Time, limit int) ([]*domain.Hold, error) {
+	var holds []*domain.Hold
+	for _, hold := range m.holds {
+		if hold.Status == domain.HoldStatusActive && hold.IsExpired(now) && len(holds) < limit {
+			copied := *hold
+			holds = append(holds, &copied)
+		}
+	}
+	return holds, nil
+}
+
+type holdFixture struct {
+	service         domain.HoldService
+	accountRepo     *MockAccountRepository
+	holdRepo        *MockHoldRepository
+	transactionRepo *MockTransactionRepository
+	clock           *time.Time
+}
+
+func newHoldFixture() *holdFixture {
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	accountRepo := NewMockAccountRepository()
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	holdRepo := NewMockHoldRepository(accountRepo, NewMockLedgerEntryRepository(accountRepo))
+	transactionRepo := NewMockTransactionRepository()
+
+	f := &holdFixture{
+		accountRepo:     accountRepo,
+		holdRepo:        holdRepo,
+		transactionRepo: transactionRepo,
+		clock:           &clock,
+	}
+	f.service = usecase.NewHoldUseCase(
+		accountRepo,
+		holdRepo,
+		transactionRepo,
+		24*time.Hour,
+		usecase.WithHoldClock(func() time.Time { return *f.clock }),
+	)
+	return f
+}
+
+func (f *holdFixture) place(t *testing.T, amount domain.Money) *domain.Hold {
+	t.Helper()
+	hold, err := f.service.PlaceHold(context.Background(), &domain.HoldRequest{AccountID: "acc-1", Amount: amount, Currency: "usd"})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return hold
+}
+
+// assertBalances checks the account's balance and held amount
+func (f *holdFixture) assertBalances(t *testing.T, balance, held domain.Money) {
+	t.Helper()
+	account := f.accountRepo.accounts["acc-1"]
+	if account.Balance != balance || account.HeldAmount != held {
+		t.Errorf("Expected balance %s held %s, got balance %s held %s",
+			balance.Format("USD"), held.Format("USD"), account.Balance.Format("USD"), account.HeldAmount.Format("USD"))
+	}
+}
+
+func TestHoldUseCase_PlaceReducesAvailableBalance(t *testing.T) {
+	f := newHoldFixture()
+	hold := f.place(t, 6000)
+
+	if hold.Status != domain.HoldStatusActive || hold.Currency != "USD" {
+		t.Errorf("Unexpected hold %+v", hold)
+	}
+	if !hold.ExpiresAt.Equal(f.clock.Add(24 * time.Hour)) {
+		t.Errorf("Expected expiry 24h after placing, got %v", hold.ExpiresAt)
+	}
+	f.assertBalances(t, 10000, 6000)
+	if available := f.accountRepo.accounts["acc-1"].AvailableBalance(); available != 4000 {
+		t.Errorf("Expected 40.00 available, got %s", available.Format("USD"))
+	}
+
+	recorded, exists := f.transactionRepo.transactions[hold.ID]
+	if !exists || recorded.Type != domain.TransactionTypeHold || recorded.Amount != 6000 {
+		t.Errorf("Expected hold transaction to be recorded, got %+v", recorded)
+	}
+
+	// Held funds cannot be spent or held again
+	_, err := f.service.PlaceHold(context.Background(), &domain.HoldRequest{AccountID: "acc-1", Amount: 5000, Currency: "USD"})
+	if !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Errorf("Expected %v for a second hold, got %v", domain.ErrInsufficientFunds, err)
+	}
+
+	transactionUseCase := usecase.NewTransactionUseCase(f.accountRepo, f.transactionRepo, NewMockMessageQueue(), "transactions").(*usecase.TransactionUseCase)
+	accountID := "acc-1"
+	f.transactionRepo.transactions["tx-1"] = &domain.Transaction{ID: "tx-1", Status: domain.TransactionStatusPending}
+	err = transactionUseCase.ProcessTransactionSync(context.Background(), &domain.TransactionRequest{
+		ID: "tx-1", Type: domain.TransactionTypeWithdrawal, FromAccountID: &accountID, Amount: 5000, Currency: "USD",
+	})
+	if !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Errorf("Expected %v for a withdrawal of held funds, got %v", domain.ErrInsufficientFunds, err)
+	}
+}
+
+func TestHoldUseCase_CaptureDebitsAndReleases(t *testing.T) {
+	f := newHoldFixture()
+	hold := f.place(t, 6000)
+
+	captured, err := f.service.CaptureHold(context.Background(), hold.ID, "")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if captured.Status != domain.HoldStatusCaptured || captured.CapturedAmount != 6000 {
+		t.Errorf("Unexpected captured hold %+v", captured)
+	}
+	f.assertBalances(t, 4000, 0)
+
+	recorded, exists := f.transactionRepo.transactions[hold.CaptureID()]
+	if !exists || recorded.Type != domain.TransactionTypeCapture || recorded.Amount != 6000 || recorded.Metadata["hold_id"] != hold.ID {
+		t.Errorf("Expected capture transaction to be recorded, got %+v", recorded)
+	}
+
+	if _, err := f.service.CaptureHold(context.Background(), hold.ID, ""); err != domain.ErrHoldNotActive {
+		t.Errorf("Expected %v for a second capture, got %v", domain.ErrHoldNotActive, err)
+	}
+	f.assertBalances(t, 4000, 0)
+}
+
+func TestHoldUseCase_PartialCapture(t *testing.T) {
+	f := newHoldFixture()
+	hold := f.place(t, 6000)
+
+	if _, err := f.service.CaptureHold(context.Background(), hold.ID, "60.01"); err != domain.ErrCaptureExceedsHold {
+		t.Errorf("Expected %v, got %v", domain.ErrCaptureExceedsHold, err)
+	}
+	if _, err := f.service.CaptureHold(context.Background(), hold.ID, "0"); err != domain.ErrInvalidAmount {
+		t.Errorf("Expected %v, got %v", domain.ErrInvalidAmount, err)
+	}
+	if _, err := f.service.CaptureHold(context.Background(), hold.ID, "1.005"); !errors.Is(err, domain.ErrInvalidPrecision) {
+		t.Errorf("Expected %v, got %v", domain.ErrInvalidPrecision, err)
+	}
+	f.assertBalances(t, 10000, 6000)
+
+	captured, err := f.service.CaptureHold(context.Background(), hold.ID, "25.00")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if captured.CapturedAmount != 2500 {
+		t.Errorf("Expected 25.00 captured, got %s", captured.CapturedAmount.Format("USD"))
+	}
+	// The uncaptured remainder is released
+	f.assertBalances(t, 7500, 0)
+}
+
+func TestHoldUseCase_Release(t *testing.T) {
+	f := newHoldFixture()
+	hold := f.place(t, 6000)
+
+	released, err := f.service.ReleaseHold(context.Background(), hold.ID)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if released.Status != domain.HoldStatusReleased {
+		t.Errorf("Expected released hold, got %s", released.Status)
+	}
+	f.assertBalances(t, 10000, 0)
+
+	if _, err := f.service.CaptureHold(context.Background(), hold.ID, ""); err != domain.ErrHoldNotActive {
+		t.Errorf("Expected %v for a capture after release, got %v", domain.ErrHoldNotActive, err)
+	}
+	if _, err := f.service.ReleaseHold(context.Background(), "missing"); err != domain.ErrHoldNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrHoldNotFound, err)
+	}
+}
+
+func TestHoldUseCase_ExpireHolds(t *testing.T) {
+	f := newHoldFixture()
+	expiring := f.place(t, 3000)
+	*f.clock = f.clock.Add(time.Hour)
+	current := f.place(t, 2000)
+
+	*f.clock = f.clock.Add(23 * time.Hour)
+	expired, err := f.service.ExpireHolds(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if expired != 1 {
+		t.Errorf("Expected 1 hold expired, got %d", expired)
+	}
+	if f.holdRepo.holds[expiring.ID].Status != domain.HoldStatusExpired {
+		t.Errorf("Expected %s to expire", expiring.ID)
+	}
+	if f.holdRepo.holds[current.ID].Status != domain.HoldStatusActive {
+		t.Errorf("Expected %s to stay active", current.ID)
+	}
+	f.assertBalances(t, 10000, 2000)
+}
+
+func TestHoldUseCase_CaptureAfterExpiry(t *testing.T) {
+	f := newHoldFixture()
+	hold := f.place(t, 6000)
+
+	// The hold has expired even though no sweep has run yet
+	*f.clock = f.clock.Add(24 * time.Hour)
+	if _, err := f.service.CaptureHold(context.Background(), hold.ID, ""); err != domain.ErrHoldNotActive {
+		t.Errorf("Expected %v, got %v", domain.ErrHoldNotActive, err)
+	}
+	if f.holdRepo.holds[hold.ID].Status != domain.HoldStatusExpired {
+		t.Errorf("Expected the hold to be expired, got %s", f.holdRepo.holds[hold.ID].Status)
+	}
+	f.assertBalances(t, 10000, 0)
+}
@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockIdempotencyStore is a mock implementation of IdempotencyStore
+type MockIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+func NewMockIdempotencyStore() *MockIdempotencyStore {
+	return &MockIdempotencyStore{
+		records: make(map[string]domain.IdempotencyRecord),
+	}
+}
+
+func (m *MockIdempotencyStore) Claim(ctx context.Context, record *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, exists := m.records[record.Key]
+	if exists && existing.CreatedAt.After(record.CreatedAt.Add(-ttl)) {
+		return &existing, nil
+	}
+	m.records[record.Key] = *record
+	return nil, nil
+}
+
+func (m *MockIdempotencyStore) Release(ctx context.Context, key, transactionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.records[key].TransactionID == transactionID {
+		delete(m.records, key)
+	}
+	return nil
+}
+
+func newIdempotencyTestUseCase(clock *time.Time) (domain.TransactionService, *MockTransactionRepository, *MockMessageQueue) {
+	accountRepo := NewMockAccountRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	transactionRepo := NewMockTransactionRepository()
+	queue := NewMockMessageQueue()
+	transactionUseCase := usecase.NewTransactionUseCase(
+		accountRepo, transactionRepo, queue, "transactions",
+		usecase.WithIdempotency(NewMockIdempotencyStore(), 24*time.Hour),
+		usecase.WithSubmissionValidation(true),
+		usecase.WithClock(func() time.Time { return *clock }),
+	)
+	return transactionUseCase, transactionRepo, queue
+}
+
+// keyedWithdrawal is a withdrawal from alice submitted with an idempotency key
+func keyedWithdrawal(key string, amount domain.Money) *domain.TransactionRequest {
+	alice := "alice"
+	return &domain.TransactionRequest{
+		Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: amount, Currency: "USD", IdempotencyKey: key,
+	}
+}
+
+func TestProcessTransaction_IdempotencyKeyReturnsOriginal(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	transactionUseCase, transactionRepo, queue := newIdempotencyTestUseCase(&clock)
+	ctx := context.Background()
+
+	first, err := transactionUseCase.ProcessTransaction(ctx, keyedWithdrawal("retry-1", 2500))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if first.Replayed || first.IdempotencyKey != "retry-1" {
+		t.Errorf("Expected a new transaction recording its key, got replayed=%v key=%q", first.Replayed, first.IdempotencyKey)
+	}
+
+	clock = clock.Add(time.Hour)
+	retry, err := transactionUseCase.ProcessTransaction(ctx, keyedWithdrawal("retry-1", 2500))
+	if err != nil {
+		t.Fatalf("Expected the retry answered, got %v", err)
+	}
+	if retry.ID != first.ID || !retry.Replayed {
+		t.Errorf("Expected the retry to replay %s, got %s (replayed=%v)", first.ID, retry.ID, retry.Replayed)
+	}
+	if len(transactionRepo.transactions) != 1 || queue.PublishedCount("transactions") != 1 {
+		t.Errorf("Expected one transaction queued once, got %d stored and %d queued", len(transactionRepo.transactions), queue.PublishedCount("transactions"))
+	}
+
+	// A different key is a different submission
+	if other, err := transactionUseCase.ProcessTransaction(ctx, keyedWithdrawal("retry-2", 2500)); err != nil || other.ID == first.ID {
+		t.Errorf("Expected a new transaction for another key, got %v", err)
+	}
+}
+
+func TestProcessTransaction_IdempotencyKeyWithDifferentPayload(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	transactionUseCase, _, _ := newIdempotencyTestUseCase(&clock)
+	ctx := context.Background()
+
+	if _, err := transactionUseCase.ProcessTransaction(ctx, keyedWithdrawal("retry-1", 2500)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	changed := keyedWithdrawal("retry-1", 2500)
+	changed.Description = "rent"
+	for _, request := range []*domain.TransactionRequest{keyedWithdrawal("retry-1", 3000), changed} {
+		if _, err := transactionUseCase.ProcessTransaction(ctx, request); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+			t.Errorf("Expected %v, got %v", domain.ErrIdempotencyKeyReused, err)
+		}
+	}
+}
+
+func TestProcessTransaction_IdempotencyKeyExpires(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	transactionUseCase, transactionRepo, _ := newIdempotencyTestUseCase(&clock)
+	ctx := context.Background()
+
+	first, err := transactionUseCase.ProcessTransaction(ctx, keyedWithdrawal("retry-1", 2500))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	clock = clock.Add(25 * time.Hour)
+	second, err := transactionUseCase.ProcessTransaction(ctx, keyedWithdrawal("retry-1", 3000))
+	if err != nil {
+		t.Fatalf("Expected an expired key reusable, got %v", err)
+	}
+	if second.ID == first.ID || second.Replayed || len(transactionRepo.transactions) != 2 {
+		t.Errorf("Expected a new transaction once the key expired, got %s", second.ID)
+	}
+}
+
+func TestProcessTransaction_RejectedSubmissionReleasesIdempotencyKey(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	transactionUseCase, _, _ := newIdempotencyTestUseCase(&clock)
+	ctx := context.Background()
+
+	if _, err := transactionUseCase.ProcessTransaction(ctx, keyedWithdrawal("retry-1", 20000)); !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("Expected %v, got %v", domain.ErrInsufficientFunds, err)
+	}
+
+	// Nothing was created, so the corrected request may use the key
+	transaction, err := transactionUseCase.ProcessTransaction(ctx, keyedWithdrawal("retry-1", 2000))
+	if err != nil {
+		t.Fatalf("Expected the corrected request accepted, got %v", err)
+	}
+	if transaction.Replayed {
+		t.Error("Expected a new transaction, got a replay")
+	}
+}
+
+func TestProcessTransaction_ConcurrentIdempotentRequests(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	transactionUseCase, transactionRepo, _ := newIdempotencyTestUseCase(&clock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Losers see either the original or a request still in progress
+			_, err := transactionUseCase.ProcessTransaction(context.Background(), keyedWithdrawal("retry-1", 2500))
+			if err != nil && !errors.Is(err, domain.ErrIdempotencyKeyInUse) {
+				t.Errorf("Expected a replay or %v, got %v", domain.ErrIdempotencyKeyInUse, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(transactionRepo.transactions) != 1 {
+		t.Errorf("Expected one transaction created, got %d", len(transactionRepo.transactions))
+	}
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockLedgerEntryRepository implements domain.LedgerEntryRepository for
+// testing, applying balances to a MockAccountRepository
+type MockLedgerEntryRepository struct {
+	accountRepo *MockAccountRepository
+	entries     []*domain.LedgerEntry
+	// postErr, when set, fails every posting as an unavailable database would
+	postErr error
+}
+
+func NewMockLedgerEntryRepository(accountRepo *MockAccountRepository) *MockLedgerEntryRepository {
+	return &MockLedgerEntryRepository{accountRepo: accountRepo}
+}
+
+func (m *MockLedgerEntryRepository) Post(ctx context.Context, posting domain.LedgerPosting) error {
+	if m.postErr != nil {
+		return m.postErr
+	}
+	// Check everything first so a failed posting changes nothing. Entries on
+	// the same account apply in turn, each bumping its version.
+	versions := make(map[string]int64)
+	for _, entry := range posting {
+		for _, existing := range m.entries {
+			if existing.ID == entry.ID {
+				return domain.ErrTransactionAlreadyProcessed
+			}
+		}
+		account, exists := m.accountRepo.accounts[entry.AccountID]
+		if !exists {
+			return domain.ErrAccountNotFound
+		}
+		version, seen := versions[entry.AccountID]
+		if !seen {
+			version = account.Version
+		}
+		if version != entry.AccountVersion-1 {
+			return domain.ErrConcurrentUpdate
+		}
+		versions[entry.AccountID] = entry.AccountVersion
+	}
+
+	for _, entry := range posting {
+		account := m.accountRepo.accounts[entry.AccountID]
+		account.Balance = entry.ResultingBalance
+		account.Version = entry.AccountVersion
+		m.entries = append(m.entries, entry)
+	}
+	return nil
+}
+
+func (m *MockLedgerEntryRepository) IsApplied(ctx context.Context, transactionID string) (bool, error) {
+	for _, entry := range m.entries {
+		if entry.TransactionID == transactionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockLedgerEntryRepository) GetByAccountID(ctx context.Context, accountID string, limit, offset int) ([]*domain.LedgerEntry, error) {
+	var entries []*domain.LedgerEntry
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if m.entries[i].AccountID == accountID {
+			entries = append(entries, m.entries[i])
+		}
+	}
+	if offset >= len(entries) {
+		return nil, nil
+	}
+	entries = entries[offset:]
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (m *MockLedgerEntryRepository) BalancesAt(ctx context.Context, accountIDs []string, at time.Time) (map[string]domain.Money, error) {
+	balances := make(map[string]domain.Money)
+	versions := make(map[string]int64)
+	for _, entry := range m.entries {
+		if !slices.Contains(accountIDs, entry.AccountID) || !entry.CreatedAt.Before(at) {
+			continue
+		}
+		if version, seen := versions[entry.AccountID]; !seen || entry.AccountVersion > version {
+			versions[entry.AccountID] = entry.AccountVersion
+			balances[entry.AccountID] = entry.ResultingBalance
+		}
+	}
+	return balances, nil
+}
+
+func (m *MockLedgerEntryRepository) SumSince(ctx context.Context, accountID string, since time.Time) (domain.Money, error) {
+	var sum domain.Money
+	for _, entry := range m.entries {
+		if entry.AccountID != accountID || entry.CreatedAt.Before(since) {
+			continue
+		}
+		if entry.Direction == domain.EntryDirectionCredit {
+			sum += entry.Amount
+		} else {
+			sum -= entry.Amount
+		}
+	}
+	return sum, nil
+}
+
+type ledgerFixture struct {
+	accountRepo     *MockAccountRepository
+	transactionRepo *MockTransactionRepository
+	ledgerRepo      *MockLedgerEntryRepository
+	service         *usecase.TransactionUseCase
+}
+
+func newLedgerFixture() *ledgerFixture {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	ledgerRepo := NewMockLedgerEntryRepository(accountRepo)
+
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["bob"] = &domain.Account{ID: "bob", UserID: "bob", Balance: 5000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	return &ledgerFixture{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		ledgerRepo:      ledgerRepo,
+		service: usecase.NewTransactionUseCase(
+			accountRepo, transactionRepo, NewMockMessageQueue(), "transactions",
+			usecase.WithLedgerEntries(ledgerRepo),
+		).(*usecase.TransactionUseCase),
+	}
+}
+
+func (f *ledgerFixture) process(t *testing.T, request *domain.TransactionRequest) {
+	t.Helper()
+	f.transactionRepo.transactions[request.ID] = &domain.Transaction{ID: request.ID, Status: domain.TransactionStatusPending}
+	if err := f.service.ProcessTransactionSync(context.Background(), request); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+}
+
+func TestLedger_EntriesPerTransactionType(t *testing.T) {
+	f := newLedgerFixture()
+	alice, bob := "alice", "bob"
+
+	f.process(t, &domain.TransactionRequest{ID: "dep", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 2500, Currency: "USD"})
+	f.process(t, &domain.TransactionRequest{ID: "wd", Type: domain.TransactionTypeWithdrawal, FromAccountID: &bob, Amount: 1000, Currency: "USD"})
+	f.process(t, &domain.TransactionRequest{ID: "tr", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 4000, Currency: "USD"})
+
+	expected := []struct {
+		id               string
+		accountID        string
+		direction        domain.EntryDirection
+		amount           domain.Money
+		resultingBalance domain.Money
+	}{
+		{"dep-credit", "alice", domain.EntryDirectionCredit, 2500, 12500},
+		{"wd-debit", "bob", domain.EntryDirectionDebit, 1000, 4000},
+		{"tr-debit", "alice", domain.EntryDirectionDebit, 4000, 8500},
+		{"tr-credit", "bob", domain.EntryDirectionCredit, 4000, 8000},
+	}
+
+	if len(f.ledgerRepo.entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(f.ledgerRepo.entries))
+	}
+	for i, want := range expected {
+		got := f.ledgerRepo.entries[i]
+		if got.ID != want.id || got.AccountID != want.accountID || got.Direction != want.direction ||
+			got.Amount != want.amount || got.ResultingBalance != want.resultingBalance {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	// Every entry's resulting balance agrees with the account's balance
+	for _, accountID := range []string{"alice", "bob"} {
+		entries, _ := f.ledgerRepo.GetByAccountID(context.Background(), accountID, 1, 0)
+		if balance := f.accountRepo.accounts[accountID].Balance; entries[0].ResultingBalance != balance {
+			t.Errorf("Expected latest %s entry to match balance %d, got %d", accountID, balance, entries[0].ResultingBalance)
+		}
+	}
+}
+
+func TestLedger_ReplayDoesNotPostTwice(t *testing.T) {
+	f := newLedgerFixture()
+	alice := "alice"
+	request := &domain.TransactionRequest{ID: "dep", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 2500, Currency: "USD"}
+
+	f.process(t, request)
+	f.process(t, request)
+
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 12500 {
+		t.Errorf("Expected balance 125.00 after a replayed deposit, got %s", balance.Format("USD"))
+	}
+	if len(f.ledgerRepo.entries) != 1 {
+		t.Errorf("Expected 1 entry, got %d", len(f.ledgerRepo.entries))
+	}
+	if status := f.transactionRepo.transactions["dep"].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected replayed deposit to be completed, got %s", status)
+	}
+}
+
+func TestAccountLedgerUseCase_GetAccountLedger(t *testing.T) {
+	f := newLedgerFixture()
+	alice := "alice"
+	f.process(t, &domain.TransactionRequest{ID: "dep-1", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD"})
+	f.process(t, &domain.TransactionRequest{ID: "dep-2", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 200, Currency: "USD"})
+
+	ledgerService := usecase.NewAccountLedgerUseCase(f.accountRepo, f.ledgerRepo)
+
+	entries, err := ledgerService.GetAccountLedger(context.Background(), "alice", 0, 0)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(entries) != 2 || entries[0].TransactionID != "dep-2" || entries[0].ResultingBalance != 10300 || entries[1].ResultingBalance != 10100 {
+		t.Errorf("Expected newest-first running balances 103.00 and 101.00, got %+v", entries)
+	}
+
+	if _, err := ledgerService.GetAccountLedger(context.Background(), "missing", 10, 0); err != domain.ErrAccountNotFound {
+		t.Errorf("Expected ErrAccountNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestTransactionUseCase_MinimumBalance(t *testing.T) {
+	tests := []struct {
+		name          string
+		request       func(from, to string) *domain.TransactionRequest
+		amount        domain.Money
+		expectedError error
+		shortfall     domain.Money
+	}{
+		{"withdrawal down to the minimum", withdrawalOf, 7500, nil, 0},
+		{"withdrawal one cent below the minimum", withdrawalOf, 7501, domain.ErrBelowMinimumBalance, 1},
+		{"transfer down to the minimum", transferOf, 7500, nil, 0},
+		{"transfer below the minimum", transferOf, 9000, domain.ErrBelowMinimumBalance, 1500},
+		{"withdrawal beyond the balance", withdrawalOf, 10001, domain.ErrInsufficientFunds, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := NewMockAccountRepository()
+			transactionRepo := NewMockTransactionRepository()
+			transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions").(*usecase.TransactionUseCase)
+
+			accountRepo.accounts["savings"] = &domain.Account{ID: "savings", UserID: "user1", Balance: 10000, MinimumBalance: 2500, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+			accountRepo.accounts["payee"] = &domain.Account{ID: "payee", UserID: "user2", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+			request := tt.request("savings", "payee")
+			request.ID = "tx-1"
+			request.Amount = tt.amount
+			transactionRepo.transactions["tx-1"] = &domain.Transaction{ID: "tx-1", Status: domain.TransactionStatusPending}
+
+			err := transactionUseCase.ProcessTransactionSync(context.Background(), request)
+			if !errors.Is(err, tt.expectedError) || (err == nil) != (tt.expectedError == nil) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+
+			balance := accountRepo.accounts["savings"].Balance
+			if err != nil {
+				if balance != 10000 {
+					t.Errorf("Expected the refused debit to leave the balance, got %s", balance.Format("USD"))
+				}
+				var minimumErr *domain.BelowMinimumBalanceError
+				if errors.As(err, &minimumErr) && (minimumErr.Shortfall != tt.shortfall || minimumErr.Minimum != 2500) {
+					t.Errorf("Expected a shortfall of %s below 25.00, got %s below %s", tt.shortfall.Format("USD"), minimumErr.Shortfall.Format("USD"), minimumErr.Minimum.Format("USD"))
+				}
+				if code := domain.FailureCodeFor(err); code != domain.FailureCodeInsufficientFunds {
+					t.Errorf("Expected failure code %s, got %s", domain.FailureCodeInsufficientFunds, code)
+				}
+				return
+			}
+			if balance != 2500 {
+				t.Errorf("Expected the balance at the minimum, got %s", balance.Format("USD"))
+			}
+		})
+	}
+}
+
+func withdrawalOf(from, _ string) *domain.TransactionRequest {
+	return withdrawal(from)
+}
+
+func transferOf(from, to string) *domain.TransactionRequest {
+	return transfer(from, to, 0)
+}
+
+func TestAccount_AvailableBalanceLeavesOutMinimum(t *testing.T) {
+	account := &domain.Account{Balance: 10000, HeldAmount: 1000, OverdraftLimit: 500, MinimumBalance: 2500, Currency: "USD"}
+	if available := account.AvailableBalance(); available != 7000 {
+		t.Errorf("Expected 70.00 available, got %s", available.Format("USD"))
+	}
+	if err := account.CheckFunds(7000); err != nil {
+		t.Errorf("Expected the available balance debitable, got %v", err)
+	}
+}
+
+func TestAccountUseCase_UpdateMinimumBalance(t *testing.T) {
+	tests := []struct {
+		name          string
+		minimum       domain.Decimal
+		expected      domain.Money
+		expectedError error
+	}{
+		{"set minimum", "250.00", 25000, nil},
+		{"remove minimum", "0", 0, nil},
+		{"negative minimum", "-1", 0, domain.ErrInvalidMinimumBalance},
+		{"too precise", "1.001", 0, domain.ErrInvalidPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := NewMockAccountRepository()
+			accountUseCase := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+			accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: domain.AccountStatusActive, MinimumBalance: 100, Version: 1}
+
+			account, err := accountUseCase.UpdateMinimumBalance(context.Background(), "acc-1", tt.minimum)
+			if !errors.Is(err, tt.expectedError) || (err == nil) != (tt.expectedError == nil) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if err != nil {
+				if minimum := accountRepo.accounts["acc-1"].MinimumBalance; minimum != 100 {
+					t.Errorf("Expected the minimum unchanged, got %s", minimum.Format("USD"))
+				}
+				return
+			}
+			if account.MinimumBalance != tt.expected {
+				t.Errorf("Expected minimum %s, got %s", tt.expected.Format("USD"), account.MinimumBalance.Format("USD"))
+			}
+		})
+	}
+}
+
+func TestMinimumBalance_Endpoints(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions",
+			usecase.WithSubmissionValidation(true),
+		),
+		AdminToken: ownershipAdminToken,
+	})
+
+	if rec := principalRequest(e, "alice", http.MethodPatch, "/api/v1/accounts/alice/minimum-balance", `{"minimum_balance":"0"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a user refused, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "admin", http.MethodPatch, "/api/v1/accounts/alice/minimum-balance", `{"minimum_balance":"-5.00"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a negative minimum rejected, got %d: %s", rec.Code, rec.Body)
+	}
+	rec := principalRequest(e, "admin", http.MethodPatch, "/api/v1/accounts/alice/minimum-balance", `{"minimum_balance":"25.00"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var account map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &account); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if account["minimum_balance"] != "25.00" {
+		t.Errorf("Expected minimum_balance 25.00, got %v", account["minimum_balance"])
+	}
+
+	withdrawal := `{"type":"withdrawal","from_account_id":"alice","amount":"75.01","currency":"USD"}`
+	rec = principalRequest(e, "alice", http.MethodPost, "/api/v1/transactions", withdrawal)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusUnprocessableEntity, rec.Code, rec.Body)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["shortfall"] != "0.01" || body["minimum_balance"] != "25.00" || body["currency"] != "USD" {
+		t.Errorf("Expected a 0.01 USD shortfall below 25.00, got %v", body)
+	}
+
+	withdrawal = `{"type":"withdrawal","from_account_id":"alice","amount":"75.00","currency":"USD"}`
+	if rec := principalRequest(e, "alice", http.MethodPost, "/api/v1/transactions", withdrawal); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected a withdrawal down to the minimum accepted, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,351 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MockMonthlyStatementRepository implements domain.MonthlyStatementRepository for testing
+type MockMonthlyStatementRepository struct {
+	mu         sync.Mutex
+	statements map[string]*domain.MonthlyStatement
+}
+
+func NewMockMonthlyStatementRepository() *MockMonthlyStatementRepository {
+	return &MockMonthlyStatementRepository{statements: make(map[string]*domain.MonthlyStatement)}
+}
+
+func (m *MockMonthlyStatementRepository) Create(ctx context.Context, statement *domain.MonthlyStatement) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.statements {
+		if existing.AccountID == statement.AccountID && existing.Month == statement.Month {
+			return false, nil
+		}
+	}
+	stored := *statement
+	m.statements[statement.ID] = &stored
+	return true, nil
+}
+
+func (m *MockMonthlyStatementRepository) GetByID(ctx context.Context, id string) (*domain.MonthlyStatement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statement, exists := m.statements[id]
+	if !exists {
+		return nil, domain.ErrStatementNotFound
+	}
+	found := *statement
+	return &found, nil
+}
+
+func (m *MockMonthlyStatementRepository) GetForMonth(ctx context.Context, accountID, month string) (*domain.MonthlyStatement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, statement := range m.statements {
+		if statement.AccountID == accountID && statement.Month == month {
+			found := *statement
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrStatementNotFound
+}
+
+func (m *MockMonthlyStatementRepository) ListByAccount(ctx context.Context, accountID string, limit, offset int) ([]*domain.MonthlyStatement, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var statements []*domain.MonthlyStatement
+	for _, statement := range m.statements {
+		if statement.AccountID == accountID {
+			statements = append(statements, statement.WithoutLines())
+		}
+	}
+	sort.Slice(statements, func(i, j int) bool { return statements[i].Month > statements[j].Month })
+	statements = statements[min(offset, len(statements)):]
+	return statements[:min(limit, len(statements))], nil
+}
+
+func (m *MockMonthlyStatementRepository) MarkNotified(ctx context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if statement, exists := m.statements[id]; exists {
+		statement.NotifiedAt = &at
+	}
+	return nil
+}
+
+// forAccount returns the statements generated for an account by month
+func (m *MockMonthlyStatementRepository) forAccount(accountID string) map[string]*domain.MonthlyStatement {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statements := make(map[string]*domain.MonthlyStatement)
+	for _, statement := range m.statements {
+		if statement.AccountID == accountID {
+			statements[statement.Month] = statement
+		}
+	}
+	return statements
+}
+
+type monthlyStatementFixture struct {
+	accountRepo     *MockAccountRepository
+	transactionRepo *MockTransactionRepository
+	statementRepo   *MockMonthlyStatementRepository
+	now             time.Time
+}
+
+// newMonthlyStatementFixture opens alice in January with 100.00. In February
+// she deposits 50.00 and 20.00, withdraws 30.00 and sends bob 10.00, and a
+// failed withdrawal leaves her balance alone; in March she deposits 5.00.
+// Bob opens in February and carol in March.
+func newMonthlyStatementFixture() *monthlyStatementFixture {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+
+	for _, account := range []*domain.Account{
+		{ID: "alice", UserID: "alice", InitialBalance: 10000, Balance: 13500, CreatedAt: time.Date(2024, time.January, 5, 0, 0, 0, 0, time.UTC)},
+		{ID: "bob", UserID: "bob", Balance: 1000, CreatedAt: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "carol", UserID: "carol", CreatedAt: time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)},
+	} {
+		account.Currency = "USD"
+		account.Status = domain.AccountStatusActive
+		accountRepo.accounts[account.ID] = account
+	}
+
+	alice, bob := "alice", "bob"
+	for i, transaction := range []*domain.Transaction{
+		{ID: "deposit-1", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 5000, Status: domain.TransactionStatusCompleted},
+		{ID: "withdrawal-1", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 3000, Status: domain.TransactionStatusCompleted},
+		{ID: "withdrawal-2", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 90000, Status: domain.TransactionStatusFailed},
+		{ID: "transfer-1", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 1000, Status: domain.TransactionStatusCompleted},
+		{ID: "deposit-2", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 2000, Status: domain.TransactionStatusCompleted},
+	} {
+		transaction.Currency = "USD"
+		transaction.CreatedAt = time.Date(2024, time.February, 10+i, 12, 0, 0, 0, time.UTC)
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+	transactionRepo.transactions["deposit-3"] = &domain.Transaction{
+		ID: "deposit-3", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 500, Currency: "USD",
+		Status: domain.TransactionStatusCompleted, CreatedAt: time.Date(2024, time.March, 3, 9, 0, 0, 0, time.UTC),
+	}
+
+	return &monthlyStatementFixture{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		statementRepo:   NewMockMonthlyStatementRepository(),
+	}
+}
+
+func (f *monthlyStatementFixture) service(opts ...usecase.MonthlyStatementOption) domain.MonthlyStatementService {
+	opts = append([]usecase.MonthlyStatementOption{
+		usecase.WithStatementPageSize(2),
+		usecase.WithStatementClock(func() time.Time { return f.now }),
+	}, opts...)
+	return usecase.NewMonthlyStatementUseCase(f.accountRepo, f.transactionRepo, f.statementRepo, opts...)
+}
+
+func TestGenerateMonth_SummarisesEachAccountOnce(t *testing.T) {
+	f := newMonthlyStatementFixture()
+	f.now = time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	queue := NewMockMessageQueue()
+	service := f.service(usecase.WithStatementNotifications(usecase.NewNotificationUseCase(queue, "notifications")))
+	ctx := context.Background()
+
+	generated, err := service.GenerateMonth(ctx, time.Date(2024, time.February, 20, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if generated != 2 {
+		t.Errorf("Expected statements of the two accounts open by the end of February, got %d", generated)
+	}
+	if len(f.statementRepo.forAccount("carol")) != 0 {
+		t.Errorf("Expected no statement of carol before she opened")
+	}
+
+	statement := f.statementRepo.forAccount("alice")["2024-02"]
+	if statement == nil {
+		t.Fatalf("Expected a February statement of alice")
+	}
+	if statement.From != "2024-02-01" || statement.To != "2024-02-29" {
+		t.Errorf("Expected the whole of February, got %s to %s", statement.From, statement.To)
+	}
+	if statement.OpeningBalance != 10000 || statement.ClosingBalance != 13000 {
+		t.Errorf("Expected 100.00 to 130.00, got %d to %d", statement.OpeningBalance, statement.ClosingBalance)
+	}
+	if statement.TransactionCount != 4 || len(statement.Lines) != 4 {
+		t.Errorf("Expected the four transactions that moved the balance, got %d", statement.TransactionCount)
+	}
+	want := []domain.StatementTotal{
+		{Type: domain.TransactionTypeDeposit, Count: 2, Credits: 7000},
+		{Type: domain.TransactionTypeWithdrawal, Count: 1, Debits: 3000},
+		{Type: domain.TransactionTypeTransfer, Count: 1, Debits: 1000},
+	}
+	if len(statement.Totals) != len(want) {
+		t.Fatalf("Expected totals %+v, got %+v", want, statement.Totals)
+	}
+	for i := range want {
+		if statement.Totals[i] != want[i] {
+			t.Errorf("Expected totals %+v, got %+v", want, statement.Totals)
+			break
+		}
+	}
+	if statement.NotifiedAt == nil {
+		t.Errorf("Expected the statement marked notified")
+	}
+
+	events := queue.events(t, "notifications")
+	if len(events) != 2 {
+		t.Fatalf("Expected one event per statement, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Type != domain.NotificationStatementGenerated || event.StatementID == "" || event.Account == nil {
+			t.Errorf("Expected a statement.generated event with the statement and its account, got %+v", event)
+		}
+		if event.Account != nil && event.Account.ID == "alice" && event.StatementID != statement.ID {
+			t.Errorf("Expected alice's event to carry statement %s, got %s", statement.ID, event.StatementID)
+		}
+	}
+
+	// Generating the month again neither duplicates nor notifies again
+	generated, err = service.GenerateMonth(ctx, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil || generated != 0 {
+		t.Errorf("Expected nothing generated again, got %d, %v", generated, err)
+	}
+	if len(f.statementRepo.statements) != 2 || len(queue.events(t, "notifications")) != 2 {
+		t.Errorf("Expected two statements and two events, got %d and %d", len(f.statementRepo.statements), len(queue.events(t, "notifications")))
+	}
+
+	if _, err := service.GenerateMonth(ctx, f.now); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for a month not yet closed, got %v", err)
+	}
+}
+
+func TestGenerateMonth_RetriesUnpublishedEvents(t *testing.T) {
+	f := newMonthlyStatementFixture()
+	f.now = time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	february := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	broken := &MockNotificationService{}
+	if _, err := f.service(usecase.WithStatementNotifications(broken)).GenerateMonth(ctx, february); err == nil {
+		t.Fatalf("Expected the failed events to fail the run")
+	}
+	if broken.calls != 2 || len(f.statementRepo.statements) != 2 {
+		t.Fatalf("Expected both statements stored despite their events failing, got %d calls and %d statements", broken.calls, len(f.statementRepo.statements))
+	}
+
+	queue := NewMockMessageQueue()
+	generated, err := f.service(usecase.WithStatementNotifications(usecase.NewNotificationUseCase(queue, "notifications"))).GenerateMonth(ctx, february)
+	if err != nil || generated != 0 {
+		t.Errorf("Expected nothing generated again, got %d, %v", generated, err)
+	}
+	if events := queue.events(t, "notifications"); len(events) != 2 {
+		t.Errorf("Expected the two missed events published, got %d", len(events))
+	}
+}
+
+func TestGenerateDue_WaitsForTheStatementDay(t *testing.T) {
+	f := newMonthlyStatementFixture()
+	f.now = time.Date(2024, time.March, 2, 12, 0, 0, 0, time.UTC)
+	service := f.service(usecase.WithStatementDay(3))
+	ctx := context.Background()
+
+	// Before the 3rd, January is the last month due
+	if _, err := service.GenerateDue(ctx); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if statements := f.statementRepo.forAccount("alice"); len(statements) != 1 || statements["2024-01"] == nil {
+		t.Errorf("Expected only January, got %v", statements)
+	}
+
+	f.now = time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC)
+	if generated, err := service.GenerateDue(ctx); err != nil || generated != 2 {
+		t.Errorf("Expected February generated for alice and bob, got %d, %v", generated, err)
+	}
+	if generated, err := service.GenerateDue(ctx); err != nil || generated != 0 {
+		t.Errorf("Expected nothing more due, got %d, %v", generated, err)
+	}
+}
+
+func TestMonthlyStatementRoutes(t *testing.T) {
+	f := newMonthlyStatementFixture()
+	f.now = time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	service := f.service()
+	for _, month := range []time.Time{
+		time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if _, err := service.GenerateMonth(context.Background(), month); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:          usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		MonthlyStatementService: service,
+		AdminToken:              ownershipAdminToken,
+	})
+
+	rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice/statements", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var listed struct {
+		Statements []map[string]interface{} `json:"statements"`
+		Count      int                      `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if listed.Count != 2 || listed.Statements[0]["month"] != "2024-02" || listed.Statements[1]["month"] != "2024-01" {
+		t.Fatalf("Expected February then January, got %s", rec.Body)
+	}
+	if _, hasLines := listed.Statements[0]["lines"]; hasLines {
+		t.Errorf("Expected listed statements without their lines, got %s", rec.Body)
+	}
+
+	id := listed.Statements[0]["id"].(string)
+	rec = principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice/statements/"+id, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var full struct {
+		ClosingBalance string                   `json:"closing_balance"`
+		Totals         []map[string]interface{} `json:"totals"`
+		Lines          []map[string]interface{} `json:"lines"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &full); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if full.ClosingBalance != "130.00" || len(full.Lines) != 4 || full.Totals[0]["credits"] != "70.00" {
+		t.Errorf("Expected the full February statement, got %s", rec.Body)
+	}
+
+	for _, tt := range []struct {
+		principal, path string
+		status          int
+	}{
+		{"bob", "/api/v1/accounts/alice/statements", http.StatusForbidden},
+		{"bob", "/api/v1/accounts/alice/statements/" + id, http.StatusForbidden},
+		{"bob", "/api/v1/accounts/bob/statements/" + id, http.StatusNotFound},
+		{"alice", "/api/v1/accounts/alice/statements/missing", http.StatusNotFound},
+		{"admin", "/api/v1/accounts/nobody/statements", http.StatusNotFound},
+	} {
+		if rec := principalRequest(e, tt.principal, http.MethodGet, tt.path, ""); rec.Code != tt.status {
+			t.Errorf("Expected status %d for %s as %s, got %d: %s", tt.status, tt.path, tt.principal, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockNotificationService is a NotificationService whose every notification
+// fails
+type MockNotificationService struct {
+	calls int
+}
+
+func (m *MockNotificationService) NotifyTransactionCompleted(ctx context.Context, transaction *domain.Transaction) error {
+	m.calls++
+	return errors.New("broker unreachable")
+}
+
+func (m *MockNotificationService) NotifyTransactionFailed(ctx context.Context, transaction *domain.Transaction, err error) error {
+	m.calls++
+	return errors.New("broker unreachable")
+}
+
+func (m *MockNotificationService) NotifyLowBalance(ctx context.Context, account *domain.Account) error {
+	m.calls++
+	return errors.New("broker unreachable")
+}
+
+func (m *MockNotificationService) NotifyAccountCreated(ctx context.Context, account *domain.Account) error {
+	m.calls++
+	return errors.New("broker unreachable")
+}
+
+func (m *MockNotificationService) NotifyStatementGenerated(ctx context.Context, account *domain.Account, statementID string) error {
+	m.calls++
+	return errors.New("broker unreachable")
+}
+
+// events decodes the notification events published to a queue
+func (m *MockMessageQueue) events(t *testing.T, queueName string) []domain.NotificationEvent {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []domain.NotificationEvent
+	for _, message := range m.published[queueName] {
+		var event domain.NotificationEvent
+		if err := json.Unmarshal(message, &event); err != nil {
+			t.Fatalf("Expected a notification event, got %s: %v", message, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestNotificationUseCase_EventSchema(t *testing.T) {
+	queue := NewMockMessageQueue()
+	notifier := usecase.NewNotificationUseCase(queue, "notifications")
+
+	transaction := &domain.Transaction{ID: "tx-1", Type: domain.TransactionTypeWithdrawal, Amount: 2500, Currency: "USD", Status: domain.TransactionStatusFailed}
+	if err := notifier.NotifyTransactionFailed(context.Background(), transaction, domain.ErrInsufficientFunds); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(queue.published["notifications"][0], &fields); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	for _, field := range []string{"event_id", "type", "occurred_at", "transaction", "error"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("Expected the event to have %s, got %v", field, fields)
+		}
+	}
+	if fields["type"] != "transaction.failed" {
+		t.Errorf("Expected type transaction.failed, got %v", fields["type"])
+	}
+	if payload, _ := fields["transaction"].(map[string]interface{}); payload["id"] != "tx-1" || payload["amount"] != "25.00" {
+		t.Errorf("Expected the transaction as its API representation, got %v", fields["transaction"])
+	}
+}
+
+func TestTransactionProcessor_NotifiesOutcomes(t *testing.T) {
+	notifications := NewMockMessageQueue()
+	f, queue := newBatchFixture(t, usecase.WithNotifications(usecase.NewNotificationUseCase(notifications, "notifications"), "50.00"))
+	ctx := context.Background()
+
+	// Takes alice from 100.00 to 40.00, below the threshold
+	completed, err := f.service.ProcessTransaction(ctx, keyedWithdrawal("", 6000))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	failed, err := f.service.ProcessTransaction(ctx, keyedWithdrawal("", 20000))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	// Already below the threshold, so it does not notify again
+	if _, err := f.service.ProcessTransaction(ctx, keyedWithdrawal("", 500)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the withdrawals acknowledged, got %v", errs)
+	}
+
+	events := notifications.events(t, "notifications")
+	expected := []domain.NotificationType{
+		domain.NotificationTransactionCompleted,
+		domain.NotificationAccountLowBalance,
+		domain.NotificationTransactionFailed,
+		domain.NotificationTransactionCompleted,
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %+v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.Type != expected[i] || event.EventID == "" || event.OccurredAt.IsZero() {
+			t.Errorf("Expected event %d to be a complete %s event, got %+v", i, expected[i], event)
+		}
+	}
+	if events[0].Transaction.ID != completed.ID || events[0].Transaction.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the completed withdrawal as it was stored, got %+v", events[0].Transaction)
+	}
+	if events[1].Account.ID != "alice" || events[1].Account.Balance != 4000 {
+		t.Errorf("Expected alice's balance after the withdrawal, got %+v", events[1].Account)
+	}
+	if events[2].Transaction.ID != failed.ID || events[2].Transaction.FailureCode != domain.FailureCodeInsufficientFunds || events[2].Error == "" {
+		t.Errorf("Expected the failed withdrawal with its error, got %+v", events[2])
+	}
+}
+
+func TestTransactionProcessor_NotificationFailureDoesNotFailTransaction(t *testing.T) {
+	notifier := &MockNotificationService{}
+	f, queue := newBatchFixture(t, usecase.WithNotifications(notifier, "50.00"))
+
+	transaction, err := f.service.ProcessTransaction(context.Background(), keyedWithdrawal("", 6000))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the withdrawal acknowledged, got %v", errs)
+	}
+
+	if stored := f.transactionRepo.transactions[transaction.ID]; stored.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the withdrawal completed, got %s: %s", stored.Status, stored.ErrorMessage)
+	}
+	if notifier.calls != 2 {
+		t.Errorf("Expected the completion and low balance notifications attempted, got %d", notifier.calls)
+	}
+}
+
+// lowBalanceEvents counts the account.low_balance events published
+func lowBalanceEvents(t *testing.T, queue *MockMessageQueue) int {
+	t.Helper()
+	count := 0
+	for _, event := range queue.events(t, "notifications") {
+		if event.Type == domain.NotificationAccountLowBalance {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTransactionProcessor_LowBalanceNotifiesOncePerCrossing(t *testing.T) {
+	notifications := NewMockMessageQueue()
+	f, queue := newBatchFixture(t, usecase.WithNotifications(usecase.NewNotificationUseCase(notifications, "notifications"), ""))
+	threshold := domain.Money(5000)
+	f.accountRepo.accounts["alice"].LowBalanceThreshold = &threshold
+	alice := "alice"
+
+	steps := []struct {
+		name     string
+		request  *domain.TransactionRequest
+		expected int
+		below    bool
+	}{
+		{"crossing down", keyedWithdrawal("", 6000), 1, true},
+		{"staying below", keyedWithdrawal("", 500), 1, true},
+		{"recovering", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 3000, Currency: "USD"}, 1, false},
+		{"crossing again", keyedWithdrawal("", 2000), 2, true},
+	}
+
+	for _, step := range steps {
+		if _, err := f.service.ProcessTransaction(context.Background(), step.request); err != nil {
+			t.Fatalf("%s: expected no error but got %v", step.name, err)
+		}
+		if errs := queue.Deliver("transactions"); len(errs) != 0 {
+			t.Fatalf("%s: expected the transaction acknowledged, got %v", step.name, errs)
+		}
+
+		if count := lowBalanceEvents(t, notifications); count != step.expected {
+			t.Errorf("%s: expected %d low balance events, got %d", step.name, step.expected, count)
+		}
+		if below := f.accountRepo.accounts["alice"].BelowThreshold; below != step.below {
+			t.Errorf("%s: expected alice's below-threshold flag %v, got %v", step.name, step.below, below)
+		}
+	}
+}
+
+func TestTransactionProcessor_AccountThresholdOverridesDefault(t *testing.T) {
+	notifications := NewMockMessageQueue()
+	f, queue := newBatchFixture(t, usecase.WithNotifications(usecase.NewNotificationUseCase(notifications, "notifications"), "50.00"))
+	threshold := domain.Money(1000)
+	f.accountRepo.accounts["alice"].LowBalanceThreshold = &threshold
+
+	// 40.00 is below the default but not below alice's 10.00
+	if _, err := f.service.ProcessTransaction(context.Background(), keyedWithdrawal("", 6000)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the withdrawal acknowledged, got %v", errs)
+	}
+
+	if count := lowBalanceEvents(t, notifications); count != 0 {
+		t.Errorf("Expected no low balance events, got %d", count)
+	}
+}
+
+func TestAccountUseCase_NotifiesAccountCreated(t *testing.T) {
+	notifications := NewMockMessageQueue()
+	service := usecase.NewAccountUseCase(NewMockAccountRepository(), NewMockTransactionRepository(),
+		usecase.WithAccountNotifications(usecase.NewNotificationUseCase(notifications, "notifications")))
+
+	account, err := service.CreateAccount(context.Background(), "alice", 10000, "USD", "", domain.AccountDetails{})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	events := notifications.events(t, "notifications")
+	if len(events) != 1 || events[0].Type != domain.NotificationAccountCreated || events[0].Account.ID != account.ID {
+		t.Errorf("Expected an account.created event for the account, got %+v", events)
+	}
+
+	// A notification that cannot be sent does not stop the account opening
+	failing := usecase.NewAccountUseCase(NewMockAccountRepository(), NewMockTransactionRepository(),
+		usecase.WithAccountNotifications(&MockNotificationService{}))
+	if _, err := failing.CreateAccount(context.Background(), "bob", 0, "USD", "", domain.AccountDetails{}); err != nil {
+		t.Errorf("Expected the account to open, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// newOutboxFixture builds a batch fixture whose broker is down and whose
+// clock the test controls
+func newOutboxFixture(t *testing.T, clock *time.Time, opts ...usecase.TransactionOption) (*ledgerFixture, *MockMessageQueue) {
+	t.Helper()
+	f, queue := newBatchFixture(t, append([]usecase.TransactionOption{
+		usecase.WithClock(func() time.Time { return *clock }),
+		usecase.WithOutboxGrace(30 * time.Second),
+	}, opts...)...)
+	queue.publishErr = errors.New("connection refused")
+	return f, queue
+}
+
+func TestOutbox_PublishFailureDelaysDispatch(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newOutboxFixture(t, &clock)
+	ctx := context.Background()
+	alice := "alice"
+
+	deposit, err := f.service.ProcessTransaction(ctx, &domain.TransactionRequest{
+		Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 2500, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected the deposit accepted while the broker is down, got %v", err)
+	}
+
+	stored := f.transactionRepo.transactions[deposit.ID]
+	if stored.Status != domain.TransactionStatusPending || stored.Outbox == nil {
+		t.Fatalf("Expected the deposit pending with its message in the outbox, got %s", stored.Status)
+	}
+
+	// Messages within the grace period are left to their submitter
+	queue.publishErr = nil
+	if dispatched, backlog, err := f.service.DispatchOutbox(ctx); err != nil || dispatched != 0 || backlog != 1 {
+		t.Fatalf("Expected nothing relayed within the grace period, got %d relayed, %d waiting, %v", dispatched, backlog, err)
+	}
+
+	clock = clock.Add(time.Minute)
+	dispatched, backlog, err := f.service.DispatchOutbox(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if dispatched != 1 || backlog != 0 {
+		t.Errorf("Expected 1 relayed and none waiting, got %d and %d", dispatched, backlog)
+	}
+	if stored.Outbox != nil {
+		t.Error("Expected the outbox cleared once published")
+	}
+
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the relayed message processed, got %v", errs)
+	}
+	if stored.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the deposit completed, got %s", stored.Status)
+	}
+}
+
+func TestOutbox_RelayStopsWhileBrokerIsDown(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, _ := newOutboxFixture(t, &clock)
+	ctx := context.Background()
+	alice := "alice"
+
+	for _, reference := range []string{"first", "second"} {
+		if _, err := f.service.ProcessTransaction(ctx, &domain.TransactionRequest{
+			Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD", Reference: reference,
+		}); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+	}
+
+	clock = clock.Add(time.Minute)
+	dispatched, backlog, err := f.service.DispatchOutbox(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if dispatched != 0 || backlog != 2 {
+		t.Errorf("Expected nothing relayed and 2 waiting, got %d and %d", dispatched, backlog)
+	}
+}
+
+func TestOutbox_BatchMessageSavedWithLastLeg(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, queue := newOutboxFixture(t, &clock)
+	ctx := context.Background()
+
+	batch, err := f.service.ProcessBatch(ctx, payroll(map[string]domain.Money{"bob": 3000, "carol": 2000}, "bob", "carol"))
+	if err != nil {
+		t.Fatalf("Expected the batch accepted while the broker is down, got %v", err)
+	}
+	for i, leg := range batch.Legs {
+		stored := f.transactionRepo.transactions[leg.ID]
+		if stored.Status != domain.TransactionStatusPending {
+			t.Errorf("Expected leg %d pending, got %s", i, stored.Status)
+		}
+		if hasOutbox := stored.Outbox != nil; hasOutbox != (i == len(batch.Legs)-1) {
+			t.Errorf("Expected only the last leg to hold the batch message, leg %d has it: %v", i, hasOutbox)
+		}
+	}
+
+	queue.publishErr = nil
+	clock = clock.Add(time.Minute)
+	if dispatched, _, err := f.service.DispatchOutbox(ctx); err != nil || dispatched != 1 {
+		t.Fatalf("Expected the batch message relayed, got %d, %v", dispatched, err)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the batch processed, got %v", errs)
+	}
+	if balance := f.accountRepo.accounts["carol"].Balance; balance != 2000 {
+		t.Errorf("Expected carol paid, got balance %d", balance)
+	}
+}
+
+func TestOutbox_UndispatchedTransactionIsNotExpired(t *testing.T) {
+	clock := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	f, _ := newOutboxFixture(t, &clock, usecase.WithPendingExpiry(time.Hour, false))
+	ctx := context.Background()
+	alice := "alice"
+
+	deposit, err := f.service.ProcessTransaction(ctx, &domain.TransactionRequest{
+		Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	stored := f.transactionRepo.transactions[deposit.ID]
+	stored.CreatedAt = clock.Add(-2 * time.Hour)
+	stored.UpdatedAt = stored.CreatedAt
+
+	// Its message was never queued, so it cannot have been lost
+	if expired, _, err := f.service.ExpireStalePending(ctx); err != nil || expired != 0 {
+		t.Fatalf("Expected nothing expired, got %d, %v", expired, err)
+	}
+	if stored.Status != domain.TransactionStatusPending {
+		t.Errorf("Expected the deposit still pending, got %s", stored.Status)
+	}
+}
@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-ledger/api/middleware"
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+const ownershipAdminToken = "s3cret"
+
+// newOwnershipServer serves the account, transaction, hold and standing order
+// routes over alice's and bob's accounts. Alice has a pending withdrawal, a
+// transfer to bob sent in a batch, a hold and a standing order paying bob.
+func newOwnershipServer(authRequired bool) *echo.Echo {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice-checking"] = &domain.Account{ID: "alice-checking", UserID: "alice", Balance: 10000, HeldAmount: 1000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["bob-checking"] = &domain.Account{ID: "bob-checking", UserID: "bob", Balance: 5000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	alice, bob := "alice-checking", "bob-checking"
+	transactionRepo.transactions["alice-withdrawal"] = &domain.Transaction{ID: "alice-withdrawal", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 1000, Currency: "USD", Status: domain.TransactionStatusPending}
+	transactionRepo.transactions["alice-to-bob"] = &domain.Transaction{ID: "alice-to-bob", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 1000, Currency: "USD", Status: domain.TransactionStatusCompleted, BatchID: "alice-batch"}
+
+	holdRepo := NewMockHoldRepository(accountRepo, NewMockLedgerEntryRepository(accountRepo))
+	holdRepo.holds["alice-hold"] = &domain.Hold{ID: "alice-hold", AccountID: alice, Amount: 1000, Currency: "USD", Status: domain.HoldStatusActive, ExpiresAt: time.Now().Add(time.Hour), Version: 1}
+
+	orderRepo := NewMockStandingOrderRepository()
+	orderRepo.orders["alice-rent"] = &domain.StandingOrder{ID: "alice-rent", FromAccountID: alice, ToAccountID: bob, Amount: 1000, Currency: "USD", Frequency: domain.StandingOrderFrequencyMonthly, Interval: 1, NextRunAt: time.Now().Add(24 * time.Hour), Status: domain.StandingOrderStatusActive}
+
+	transactionService := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions")
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:       usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService:   transactionService,
+		HoldService:          usecase.NewHoldUseCase(accountRepo, holdRepo, transactionRepo, time.Hour),
+		StandingOrderService: usecase.NewStandingOrderUseCase(orderRepo, accountRepo, transactionService),
+		AdminToken:           ownershipAdminToken,
+		AuthRequired:         authRequired,
+	})
+	return e
+}
+
+// principalRequest makes a request as a user, as the admin with "admin", or
+// anonymously with ""
+func principalRequest(e *echo.Echo, principal, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	switch principal {
+	case "":
+	case "admin":
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+ownershipAdminToken)
+	default:
+		req.Header.Set(middleware.UserIDHeader, principal)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOwnership_AccountReads(t *testing.T) {
+	e := newOwnershipServer(false)
+
+	tests := []struct {
+		name           string
+		principal      string
+		path           string
+		expectedStatus int
+	}{
+		{"owner reads account", "alice", "/api/v1/accounts/alice-checking", http.StatusOK},
+		{"other user reads account", "bob", "/api/v1/accounts/alice-checking", http.StatusForbidden},
+		{"admin reads account", "admin", "/api/v1/accounts/alice-checking", http.StatusOK},
+		{"anonymous reads account", "", "/api/v1/accounts/alice-checking", http.StatusOK},
+		{"owner searches own accounts", "alice", "/api/v1/accounts/search?user_id=alice", http.StatusOK},
+		{"other user searches accounts", "bob", "/api/v1/accounts/search?user_id=alice", http.StatusForbidden},
+		{"admin searches accounts", "admin", "/api/v1/accounts/search?user_id=alice", http.StatusOK},
+		{"owner reads history", "alice", "/api/v1/accounts/alice-checking/transactions", http.StatusOK},
+		{"other user reads history", "bob", "/api/v1/accounts/alice-checking/transactions", http.StatusForbidden},
+		{"other user reads history by query", "bob", "/api/v1/transactions/history?account_id=alice-checking", http.StatusForbidden},
+		{"admin reads history", "admin", "/api/v1/accounts/alice-checking/transactions", http.StatusOK},
+		{"other user reads balance", "bob", "/api/v1/accounts/alice-checking/balance", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := principalRequest(e, tt.principal, http.MethodGet, tt.path, "")
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body)
+			}
+			if rec.Code == http.StatusForbidden {
+				var body map[string]string
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["code"] != "account_forbidden" {
+					t.Errorf("Expected the account_forbidden code, got %s", rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestOwnership_Transactions(t *testing.T) {
+	e := newOwnershipServer(false)
+
+	tests := []struct {
+		name           string
+		principal      string
+		body           string
+		expectedStatus int
+	}{
+		{"owner withdraws", "alice", `{"type":"withdrawal","from_account_id":"alice-checking","amount":"10.00","currency":"USD"}`, http.StatusAccepted},
+		{"other user withdraws", "bob", `{"type":"withdrawal","from_account_id":"alice-checking","amount":"10.00","currency":"USD"}`, http.StatusForbidden},
+		{"other user transfers out", "bob", `{"type":"transfer","from_account_id":"alice-checking","to_account_id":"bob-checking","amount":"10.00","currency":"USD"}`, http.StatusForbidden},
+		{"owner transfers out", "bob", `{"type":"transfer","from_account_id":"bob-checking","to_account_id":"alice-checking","amount":"10.00","currency":"USD"}`, http.StatusAccepted},
+		{"other user deposits", "bob", `{"type":"deposit","to_account_id":"alice-checking","amount":"10.00","currency":"USD"}`, http.StatusAccepted},
+		{"admin withdraws", "admin", `{"type":"withdrawal","from_account_id":"alice-checking","amount":"10.00","currency":"USD"}`, http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := principalRequest(e, tt.principal, http.MethodPost, "/api/v1/transactions", tt.body)
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestOwnership_ScopedResources(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"owner creates standing order", "alice", http.MethodPost, "/api/v1/standing-orders", `{"from_account_id":"alice-checking","to_account_id":"bob-checking","amount":"10.00","currency":"USD","frequency":"weekly"}`, http.StatusCreated},
+		{"other user creates standing order", "bob", http.MethodPost, "/api/v1/standing-orders", `{"from_account_id":"alice-checking","to_account_id":"bob-checking","amount":"10.00","currency":"USD","frequency":"weekly"}`, http.StatusForbidden},
+		{"owner reads standing order", "alice", http.MethodGet, "/api/v1/standing-orders/alice-rent", "", http.StatusOK},
+		{"other user reads standing order", "bob", http.MethodGet, "/api/v1/standing-orders/alice-rent", "", http.StatusForbidden},
+		{"admin reads standing order", "admin", http.MethodGet, "/api/v1/standing-orders/alice-rent", "", http.StatusOK},
+		{"other user updates standing order", "bob", http.MethodPatch, "/api/v1/standing-orders/alice-rent", `{"amount":"99.00"}`, http.StatusForbidden},
+		{"owner updates standing order", "alice", http.MethodPatch, "/api/v1/standing-orders/alice-rent", `{"amount":"99.00"}`, http.StatusOK},
+		{"other user cancels standing order", "bob", http.MethodDelete, "/api/v1/standing-orders/alice-rent", "", http.StatusForbidden},
+		{"owner cancels standing order", "alice", http.MethodDelete, "/api/v1/standing-orders/alice-rent", "", http.StatusOK},
+		{"owner lists account's standing orders", "alice", http.MethodGet, "/api/v1/standing-orders?account_id=alice-checking", "", http.StatusOK},
+		{"other user lists account's standing orders", "bob", http.MethodGet, "/api/v1/standing-orders?account_id=alice-checking", "", http.StatusForbidden},
+		{"user lists every standing order", "bob", http.MethodGet, "/api/v1/standing-orders", "", http.StatusForbidden},
+		{"admin lists every standing order", "admin", http.MethodGet, "/api/v1/standing-orders", "", http.StatusOK},
+		{"owner places hold", "alice", http.MethodPost, "/api/v1/holds", `{"account_id":"alice-checking","amount":"10.00","currency":"USD"}`, http.StatusCreated},
+		{"other user places hold", "bob", http.MethodPost, "/api/v1/holds", `{"account_id":"alice-checking","amount":"10.00","currency":"USD"}`, http.StatusForbidden},
+		{"owner reads hold", "alice", http.MethodGet, "/api/v1/holds/alice-hold", "", http.StatusOK},
+		{"other user reads hold", "bob", http.MethodGet, "/api/v1/holds/alice-hold", "", http.StatusForbidden},
+		{"other user captures hold", "bob", http.MethodPost, "/api/v1/holds/alice-hold/capture", `{}`, http.StatusForbidden},
+		{"owner captures hold", "alice", http.MethodPost, "/api/v1/holds/alice-hold/capture", `{}`, http.StatusOK},
+		{"other user releases hold", "bob", http.MethodPost, "/api/v1/holds/alice-hold/release", "", http.StatusForbidden},
+		{"admin releases hold", "admin", http.MethodPost, "/api/v1/holds/alice-hold/release", "", http.StatusOK},
+		{"owner reads transaction", "alice", http.MethodGet, "/api/v1/transactions/alice-withdrawal", "", http.StatusOK},
+		{"counterparty reads transaction", "bob", http.MethodGet, "/api/v1/transactions/alice-to-bob", "", http.StatusOK},
+		{"other user reads transaction", "bob", http.MethodGet, "/api/v1/transactions/alice-withdrawal", "", http.StatusForbidden},
+		{"other user reads transaction status", "bob", http.MethodGet, "/api/v1/transactions/alice-withdrawal/status", "", http.StatusForbidden},
+		{"other user lists account's transactions", "bob", http.MethodGet, "/api/v1/transactions?account_id=alice-checking", "", http.StatusForbidden},
+		{"other user cancels transaction", "bob", http.MethodPatch, "/api/v1/transactions/alice-withdrawal/cancel", "", http.StatusForbidden},
+		{"owner cancels transaction", "alice", http.MethodPatch, "/api/v1/transactions/alice-withdrawal/cancel", "", http.StatusOK},
+		{"counterparty tags transfer", "bob", http.MethodPatch, "/api/v1/transactions/alice-to-bob/tags", `{"tags":["rent"]}`, http.StatusForbidden},
+		{"owner tags transfer", "alice", http.MethodPatch, "/api/v1/transactions/alice-to-bob/tags", `{"tags":["rent"]}`, http.StatusOK},
+		{"owner reads batch", "alice", http.MethodGet, "/api/v1/transactions/batches/alice-batch", "", http.StatusOK},
+		{"counterparty reads batch", "bob", http.MethodGet, "/api/v1/transactions/batches/alice-batch", "", http.StatusOK},
+		{"other user reads batch", "carol", http.MethodGet, "/api/v1/transactions/batches/alice-batch", "", http.StatusForbidden},
+		{"other user deactivates account", "carol", http.MethodPatch, "/api/v1/accounts/alice-checking/deactivate?force=true", "", http.StatusForbidden},
+		{"owner deactivates account", "alice", http.MethodPatch, "/api/v1/accounts/alice-checking/deactivate?force=true", "", http.StatusOK},
+		{"other user activates account", "carol", http.MethodPatch, "/api/v1/accounts/alice-checking/activate", "", http.StatusForbidden},
+		{"owner activates active account", "alice", http.MethodPatch, "/api/v1/accounts/alice-checking/activate", "", http.StatusConflict},
+		{"other user closes account", "carol", http.MethodPatch, "/api/v1/accounts/alice-checking/close", "", http.StatusForbidden},
+		{"owner closes funded account", "alice", http.MethodPatch, "/api/v1/accounts/alice-checking/close", "", http.StatusUnprocessableEntity},
+		{"other user sets account status", "carol", http.MethodPatch, "/api/v1/accounts/alice-checking/status", `{"status":"inactive"}`, http.StatusForbidden},
+		{"owner sets account status", "alice", http.MethodPatch, "/api/v1/accounts/alice-checking/status", `{"status":"inactive"}`, http.StatusOK},
+		{"admin sets account status", "admin", http.MethodPatch, "/api/v1/accounts/alice-checking/status", `{"status":"inactive"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newOwnershipServer(false)
+			rec := principalRequest(e, tt.principal, tt.method, tt.path, tt.body)
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestOwnership_AccountListing(t *testing.T) {
+	e := newOwnershipServer(false)
+
+	tests := []struct {
+		principal string
+		expected  []string
+	}{
+		{"alice", []string{"alice-checking"}},
+		{"bob", []string{"bob-checking"}},
+		{"carol", nil},
+		{"admin", []string{"alice-checking", "bob-checking"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.principal, func(t *testing.T) {
+			rec := principalRequest(e, tt.principal, http.MethodGet, "/api/v1/accounts", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+			}
+			var body struct {
+				Accounts []*domain.Account `json:"accounts"`
+				Total    int64             `json:"total"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			var ids []string
+			for _, account := range body.Accounts {
+				ids = append(ids, account.ID)
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.expected) || body.Total != int64(len(tt.expected)) {
+				t.Errorf("Expected %v, got %v of %d", tt.expected, ids, body.Total)
+			}
+		})
+	}
+}
+
+func TestOwnership_TransactionListings(t *testing.T) {
+	e := newOwnershipServer(false)
+
+	tests := []struct {
+		principal string
+		path      string
+		expected  []string
+	}{
+		{"alice", "/api/v1/transactions", []string{"alice-to-bob", "alice-withdrawal"}},
+		{"bob", "/api/v1/transactions", []string{"alice-to-bob"}},
+		{"carol", "/api/v1/transactions", nil},
+		{"admin", "/api/v1/transactions", []string{"alice-to-bob", "alice-withdrawal"}},
+		{"bob", "/api/v1/transactions?account_id=bob-checking", []string{"alice-to-bob"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.principal+" "+tt.path, func(t *testing.T) {
+			rec := principalRequest(e, tt.principal, http.MethodGet, tt.path, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+			}
+			var body struct {
+				Transactions []*domain.Transaction `json:"transactions"`
+				Total        int64                 `json:"total"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			var ids []string
+			for _, transaction := range body.Transactions {
+				ids = append(ids, transaction.ID)
+			}
+			slices.Sort(ids)
+			if !slices.Equal(ids, tt.expected) || body.Total != int64(len(tt.expected)) {
+				t.Errorf("Expected %v, got %v of %d", tt.expected, ids, body.Total)
+			}
+		})
+	}
+
+	// A lookup reports the transactions the caller is not party to missing
+	rec := principalRequest(e, "bob", http.MethodPost, "/api/v1/transactions/lookup", `{"ids":["alice-to-bob","alice-withdrawal"]}`)
+	var lookup struct {
+		Missing []string `json:"missing"`
+		Count   int      `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &lookup); err != nil || lookup.Count != 1 || !slices.Equal(lookup.Missing, []string{"alice-withdrawal"}) {
+		t.Errorf("Expected only the transfer found, got %s", rec.Body)
+	}
+}
+
+func TestOwnership_AuthRequired(t *testing.T) {
+	e := newOwnershipServer(true)
+
+	if rec := principalRequest(e, "", http.MethodGet, "/api/v1/accounts/alice-checking", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an anonymous request to be refused, got %d", rec.Code)
+	}
+	if rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice-checking", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected the owner to be let through, got %d", rec.Code)
+	}
+}
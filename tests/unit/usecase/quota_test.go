@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type quotaFixture struct {
+	accountRepo     *MockAccountRepository
+	transactionRepo *MockTransactionRepository
+	now             time.Time
+}
+
+// newQuotaFixture gives alice a checking and a savings account and bob a
+// checking account. Alice withdrew from checking 50 and 20 minutes ago and
+// transferred from savings 10 minutes ago; a deposit and a withdrawal from two
+// hours ago do not count.
+func newQuotaFixture() *quotaFixture {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	now := time.Now()
+
+	for _, account := range []*domain.Account{
+		{ID: "alice-checking", UserID: "alice"},
+		{ID: "alice-savings", UserID: "alice"},
+		{ID: "bob-checking", UserID: "bob"},
+	} {
+		account.Balance = 100000
+		account.Currency = "USD"
+		account.Status = domain.AccountStatusActive
+		account.Version = 1
+		accountRepo.accounts[account.ID] = account
+	}
+
+	for _, seeded := range []struct {
+		id      string
+		kind    domain.TransactionType
+		from    string
+		to      string
+		minutes int
+	}{
+		{"old", domain.TransactionTypeWithdrawal, "alice-checking", "", 120},
+		{"w1", domain.TransactionTypeWithdrawal, "alice-checking", "", 50},
+		{"d1", domain.TransactionTypeDeposit, "", "alice-checking", 30},
+		{"w2", domain.TransactionTypeWithdrawal, "alice-checking", "", 20},
+		{"t1", domain.TransactionTypeTransfer, "alice-savings", "bob-checking", 10},
+	} {
+		transaction := &domain.Transaction{
+			ID:        seeded.id,
+			Type:      seeded.kind,
+			Amount:    1000,
+			Currency:  "USD",
+			Status:    domain.TransactionStatusCompleted,
+			CreatedAt: now.Add(-time.Duration(seeded.minutes) * time.Minute),
+		}
+		if seeded.from != "" {
+			from := seeded.from
+			transaction.FromAccountID = &from
+		}
+		if seeded.to != "" {
+			to := seeded.to
+			transaction.ToAccountID = &to
+		}
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	return &quotaFixture{accountRepo: accountRepo, transactionRepo: transactionRepo, now: now}
+}
+
+func (f *quotaFixture) service(quota *usecase.TransactionQuota) domain.TransactionService {
+	return usecase.NewTransactionUseCase(f.accountRepo, f.transactionRepo, NewMockMessageQueue(), "transactions",
+		usecase.WithTransactionQuota(quota),
+		usecase.WithClock(func() time.Time { return f.now }),
+	)
+}
+
+func withdrawal(from string) *domain.TransactionRequest {
+	return &domain.TransactionRequest{
+		Type:          domain.TransactionTypeWithdrawal,
+		FromAccountID: &from,
+		Amount:        100,
+		Currency:      "USD",
+	}
+}
+
+func TestTransactionQuota_RejectsOnceReached(t *testing.T) {
+	f := newQuotaFixture()
+	service := f.service(usecase.NewTransactionQuota(4, time.Hour))
+	ctx := context.Background()
+
+	// Three submissions from alice's accounts are in the window
+	if _, err := service.ProcessTransaction(ctx, withdrawal("alice-savings")); err != nil {
+		t.Fatalf("Expected the fourth submission to be accepted, got %v", err)
+	}
+
+	_, err := service.ProcessTransaction(ctx, withdrawal("alice-checking"))
+	var quotaErr *domain.QuotaExceededError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Fatalf("Expected a QuotaExceededError, got %v", err)
+	}
+	if quotaErr.Limit != 4 || quotaErr.Window != time.Hour {
+		t.Errorf("Expected a quota of 4 per hour, got %d per %s", quotaErr.Limit, quotaErr.Window)
+	}
+	// The withdrawal of 50 minutes ago frees a slot in 10 minutes
+	if quotaErr.RetryAfter != 10*time.Minute {
+		t.Errorf("Expected to retry after 10 minutes, got %s", quotaErr.RetryAfter)
+	}
+
+	// Deposits are exempt, and bob has his own quota
+	to := "alice-checking"
+	deposit := &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &to, Amount: 100, Currency: "USD"}
+	if _, err := service.ProcessTransaction(ctx, deposit); err != nil {
+		t.Errorf("Expected a deposit to be exempt, got %v", err)
+	}
+	if _, err := service.ProcessTransaction(ctx, withdrawal("bob-checking")); err != nil {
+		t.Errorf("Expected bob's withdrawal to be accepted, got %v", err)
+	}
+}
+
+func TestTransactionQuota_Overrides(t *testing.T) {
+	f := newQuotaFixture()
+	quota, err := usecase.LoadTransactionQuota(3, time.Hour, []string{"alice:0", "bob:1"})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	service := f.service(quota)
+	ctx := context.Background()
+
+	if _, err := service.ProcessTransaction(ctx, withdrawal("alice-checking")); err != nil {
+		t.Errorf("Expected alice to be unlimited, got %v", err)
+	}
+	if _, err := service.ProcessTransaction(ctx, withdrawal("bob-checking")); err != nil {
+		t.Fatalf("Expected bob's first withdrawal to be accepted, got %v", err)
+	}
+	if _, err := service.ProcessTransaction(ctx, withdrawal("bob-checking")); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Errorf("Expected bob's second withdrawal to exceed his quota, got %v", err)
+	}
+}
+
+func TestTransactionQuota_CountsBatchLegs(t *testing.T) {
+	f := newQuotaFixture()
+	service := f.service(usecase.NewTransactionQuota(4, time.Hour))
+	ctx := context.Background()
+	to := "bob-checking"
+	deposit := &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: &to, Amount: 100, Currency: "USD"}
+	before := len(f.transactionRepo.transactions)
+
+	// Alice has three submissions in the window, so two more legs from her
+	// accounts are too many, even alongside an exempt deposit
+	_, err := service.ProcessBatch(ctx, &domain.BatchRequest{Legs: []*domain.TransactionRequest{
+		withdrawal("alice-checking"), deposit, withdrawal("alice-savings"),
+	}})
+	var quotaErr *domain.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		t.Fatalf("Expected a QuotaExceededError, got %v", err)
+	}
+	// Two slots free up once the withdrawal of 50 minutes ago leaves the window
+	if quotaErr.RetryAfter != 10*time.Minute {
+		t.Errorf("Expected to retry after 10 minutes, got %s", quotaErr.RetryAfter)
+	}
+	if count := len(f.transactionRepo.transactions); count != before {
+		t.Errorf("Expected no legs saved, got %d new transactions", count-before)
+	}
+
+	// One leg fits, as do bob's legs, counted against his own quota
+	if _, err := service.ProcessBatch(ctx, &domain.BatchRequest{Legs: []*domain.TransactionRequest{withdrawal("alice-checking"), deposit}}); err != nil {
+		t.Errorf("Expected one leg from alice to fit, got %v", err)
+	}
+	if _, err := service.ProcessBatch(ctx, &domain.BatchRequest{Legs: []*domain.TransactionRequest{withdrawal("bob-checking"), withdrawal("bob-checking")}}); err != nil {
+		t.Errorf("Expected bob's legs to fit, got %v", err)
+	}
+	if _, err := service.ProcessBatch(ctx, &domain.BatchRequest{Legs: []*domain.TransactionRequest{withdrawal("alice-savings")}}); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Errorf("Expected alice's saved leg to count against her quota, got %v", err)
+	}
+}
+
+func TestLoadTransactionQuota_RejectsInvalidConfiguration(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		window    time.Duration
+		overrides []string
+	}{
+		{"negative limit", -1, time.Hour, nil},
+		{"no window", 10, 0, nil},
+		{"override without limit", 10, time.Hour, []string{"alice"}},
+		{"override without user", 10, time.Hour, []string{":5"}},
+		{"negative override", 10, time.Hour, []string{"alice:-5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := usecase.LoadTransactionQuota(tt.limit, tt.window, tt.overrides); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestTransactionQuota_HandlerReturnsRetryAfter(t *testing.T) {
+	f := newQuotaFixture()
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		TransactionService: f.service(usecase.NewTransactionQuota(3, time.Hour)),
+	})
+
+	rec := principalRequest(e, "alice", http.MethodPost, "/api/v1/transactions",
+		`{"type":"withdrawal","from_account_id":"alice-checking","amount":"1.00","currency":"USD"}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusTooManyRequests, rec.Code, rec.Body)
+	}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "600" {
+		t.Errorf("Expected to retry after 600 seconds, got %q", retryAfter)
+	}
+}
+
+func TestTransactionQuota_BatchHandlerReturnsRetryAfter(t *testing.T) {
+	f := newQuotaFixture()
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		TransactionService: f.service(usecase.NewTransactionQuota(4, time.Hour)),
+	})
+
+	rec := principalRequest(e, "alice", http.MethodPost, "/api/v1/transactions/batch", `{"legs":[
+		{"type":"withdrawal","from_account_id":"alice-checking","amount":"1.00","currency":"USD"},
+		{"type":"transfer","from_account_id":"alice-savings","to_account_id":"bob-checking","amount":"1.00","currency":"USD"}
+	]}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusTooManyRequests, rec.Code, rec.Body)
+	}
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "600" {
+		t.Errorf("Expected to retry after 600 seconds, got %q", retryAfter)
+	}
+}
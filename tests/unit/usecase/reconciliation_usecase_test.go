@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MockReconciliationReportRepository implements domain.ReconciliationReportRepository for testing
+type MockReconciliationReportRepository struct {
+	reports map[string]*domain.ReconciliationReport
+	latest  string
+}
+
+func NewMockReconciliationReportRepository() *MockReconciliationReportRepository {
+	return &MockReconciliationReportRepository{reports: make(map[string]*domain.ReconciliationReport)}
+}
+
+func (m *MockReconciliationReportRepository) Save(ctx context.Context, report *domain.ReconciliationReport) error {
+	if _, exists := m.reports[report.ID]; !exists {
+		m.latest = report.ID
+	}
+	m.reports[report.ID] = report
+	return nil
+}
+
+func (m *MockReconciliationReportRepository) GetLatest(ctx context.Context) (*domain.ReconciliationReport, error) {
+	report, exists := m.reports[m.latest]
+	if !exists {
+		return nil, domain.ErrReconciliationReportNotFound
+	}
+	return report, nil
+}
+
+type reconciliationFixture struct {
+	accountRepo     *MockAccountRepository
+	transactionRepo *MockTransactionRepository
+	reportRepo      *MockReconciliationReportRepository
+	service         domain.ReconciliationService
+}
+
+// newReconciliationFixture opens four accounts whose balances match their
+// transactions, except dave's, which was credited by a deposit left failed
+func newReconciliationFixture() *reconciliationFixture {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	reportRepo := NewMockReconciliationReportRepository()
+
+	for _, account := range []*domain.Account{
+		{ID: "alice", InitialBalance: 10000, Balance: 8500},
+		{ID: "bob", InitialBalance: 5000, Balance: 9000},
+		{ID: "carol", InitialBalance: 3000, Balance: 3000},
+		{ID: "dave", InitialBalance: 2000, Balance: 2700},
+	} {
+		account.Currency = "USD"
+		account.Status = domain.AccountStatusActive
+		accountRepo.accounts[account.ID] = account
+	}
+
+	alice, bob, carol, dave := "alice", "bob", "carol", "dave"
+	for _, transaction := range []*domain.Transaction{
+		{ID: "dep", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 2500, Status: domain.TransactionStatusCompleted},
+		{ID: "tr", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 4000, Status: domain.TransactionStatusCompleted},
+		{ID: "wd", Type: domain.TransactionTypeWithdrawal, FromAccountID: &bob, Amount: 1000, Status: domain.TransactionStatusPending},
+		{ID: "hold", Type: domain.TransactionTypeHold, FromAccountID: &alice, Amount: 500, Status: domain.TransactionStatusCompleted},
+		{ID: "split", Type: domain.TransactionTypeTransfer, FromAccountID: &carol, ToAccountID: &alice, Amount: 1000, Status: domain.TransactionStatusFailed,
+			Compensation: &domain.Compensation{TransactionID: "split-compensating-credit", Status: domain.CompensationStatusCompleted}},
+		{ID: "split-compensating-credit", Type: domain.TransactionTypeCompensation, ToAccountID: &carol, Amount: 1000, Status: domain.TransactionStatusCompleted},
+		{ID: "lost", Type: domain.TransactionTypeDeposit, ToAccountID: &dave, Amount: 700, Status: domain.TransactionStatusFailed},
+	} {
+		transaction.Currency = "USD"
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	return &reconciliationFixture{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		reportRepo:      reportRepo,
+		service: usecase.NewReconciliationUseCase(accountRepo, transactionRepo, reportRepo,
+			usecase.WithReconciliationPageSize(3)),
+	}
+}
+
+func TestReconcile_ReportsDiscrepancies(t *testing.T) {
+	f := newReconciliationFixture()
+	ctx := context.Background()
+
+	report, err := f.service.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if report.AccountsChecked != 4 {
+		t.Errorf("Expected 4 accounts checked across pages, got %d", report.AccountsChecked)
+	}
+	if len(report.Discrepancies) != 1 {
+		t.Fatalf("Expected one discrepancy, got %+v", report.Discrepancies)
+	}
+	discrepancy := report.Discrepancies[0]
+	if discrepancy.AccountID != "dave" || discrepancy.Expected != 2000 || discrepancy.Actual != 2700 || discrepancy.Delta != 700 {
+		t.Errorf("Unexpected discrepancy %+v", discrepancy)
+	}
+
+	latest, err := f.service.GetLatestReport(ctx)
+	if err != nil || latest.ID != report.ID {
+		t.Errorf("Expected the report to be the latest, got %v, %v", latest, err)
+	}
+}
+
+func TestReconcile_NoReportYet(t *testing.T) {
+	f := newReconciliationFixture()
+
+	if _, err := f.service.GetLatestReport(context.Background()); !errors.Is(err, domain.ErrReconciliationReportNotFound) {
+		t.Errorf("Expected ErrReconciliationReportNotFound, got %v", err)
+	}
+}
+
+func TestFixDiscrepancies_RecordsAdjustmentOnce(t *testing.T) {
+	f := newReconciliationFixture()
+	ctx := context.Background()
+
+	if _, err := f.service.Reconcile(ctx); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	report, err := f.service.FixDiscrepancies(ctx, []string{"dave"}, "ops-17")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	adjustmentID := report.Discrepancy("dave").AdjustmentID
+	adjustment, err := f.transactionRepo.GetByID(ctx, adjustmentID)
+	if err != nil {
+		t.Fatalf("Expected the adjustment to be recorded, got %v", err)
+	}
+	if adjustment.Type != domain.TransactionTypeAdjustment || adjustment.Status != domain.TransactionStatusCompleted ||
+		adjustment.ToAccountID == nil || *adjustment.ToAccountID != "dave" || adjustment.Amount != 700 {
+		t.Errorf("Unexpected adjustment %+v", adjustment)
+	}
+	if adjustment.Metadata["operator_id"] != "ops-17" || adjustment.Metadata["reconciliation_report_id"] != report.ID {
+		t.Errorf("Expected operator and report in metadata, got %v", adjustment.Metadata)
+	}
+	if balance := f.accountRepo.accounts["dave"].Balance; balance != 2700 {
+		t.Errorf("Expected the balance to be left at 27.00, got %d", balance)
+	}
+
+	// Fixing again records nothing more
+	count := len(f.transactionRepo.transactions)
+	if _, err := f.service.FixDiscrepancies(ctx, []string{"dave"}, "ops-17"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(f.transactionRepo.transactions) != count {
+		t.Errorf("Expected no second adjustment")
+	}
+
+	rerun, err := f.service.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(rerun.Discrepancies) != 0 {
+		t.Errorf("Expected no discrepancies after the fix, got %+v", rerun.Discrepancies)
+	}
+}
+
+func TestFixDiscrepancies_SkipsChangedAndRejectsUnknown(t *testing.T) {
+	f := newReconciliationFixture()
+	ctx := context.Background()
+
+	if _, err := f.service.Reconcile(ctx); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if _, err := f.service.FixDiscrepancies(ctx, []string{"dave", "alice"}, "ops-17"); !errors.Is(err, domain.ErrDiscrepancyNotFound) {
+		t.Errorf("Expected ErrDiscrepancyNotFound for an account without a discrepancy, got %v", err)
+	}
+	if _, err := f.service.FixDiscrepancies(ctx, []string{"dave"}, ""); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput without an operator, got %v", err)
+	}
+
+	// The delta grows after the report, so the approved amount is stale
+	f.accountRepo.accounts["dave"].Balance = 2900
+	count := len(f.transactionRepo.transactions)
+
+	report, err := f.service.FixDiscrepancies(ctx, []string{"dave"}, "ops-17")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if report.Discrepancy("dave").AdjustmentID != "" || len(f.transactionRepo.transactions) != count {
+		t.Errorf("Expected a changed discrepancy to be skipped")
+	}
+}
+
+func TestCheckInvariants_ComparesTotalsPerCurrency(t *testing.T) {
+	f := newReconciliationFixture()
+
+	// Frank converts 10.00 USD into 9.20 EUR for erin
+	frank, erin := "frank", "erin"
+	f.accountRepo.accounts[frank] = &domain.Account{ID: frank, Currency: "USD", InitialBalance: 1000, Status: domain.AccountStatusActive}
+	f.accountRepo.accounts[erin] = &domain.Account{ID: erin, Currency: "EUR", Balance: 920, Status: domain.AccountStatusActive}
+	f.transactionRepo.transactions["fx"] = &domain.Transaction{
+		ID: "fx", Type: domain.TransactionTypeTransfer, FromAccountID: &frank, ToAccountID: &erin, Amount: 1000, Currency: "USD",
+		Status: domain.TransactionStatusCompleted, Exchange: &domain.Exchange{CreditedAmount: 920, CreditedCurrency: "EUR"},
+	}
+	f.transactionRepo.transactions["wd"].Status = domain.TransactionStatusProcessing
+
+	report, err := f.service.CheckInvariants(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if report.Balanced || report.InFlight != 1 || report.CompletedAt.Before(report.StartedAt) {
+		t.Errorf("Expected an unbalanced report with one transaction in flight, got %+v", report)
+	}
+	if len(report.Currencies) != 2 {
+		t.Fatalf("Expected two currencies, got %d", len(report.Currencies))
+	}
+
+	// Dave's failed deposit was posted, so USD is 7.00 over
+	eur, usd := report.Currencies[0], report.Currencies[1]
+	if usd.Currency != "USD" || usd.Balance != 23200 || usd.Expected != 22500 || usd.Difference != 700 || usd.Balanced {
+		t.Errorf("Expected USD 7.00 over 225.00, got %+v", usd)
+	}
+	if eur.Currency != "EUR" || eur.Expected != 920 || !eur.Balanced {
+		t.Errorf("Expected EUR balanced at 9.20, got %+v", eur)
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:        usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		ReconciliationService: f.service,
+		AdminToken:            ownershipAdminToken,
+	})
+	rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/admin/invariants", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var body struct {
+		Balanced   bool `json:"balanced"`
+		Currencies []struct {
+			Difference string `json:"difference"`
+		} `json:"currencies"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Balanced || len(body.Currencies) != 2 || body.Currencies[1].Difference != "7.00" {
+		t.Errorf("Unexpected invariant report: %s", rec.Body)
+	}
+}
@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+func TestProcessor_RedeliveredMessageAppliesOnce(t *testing.T) {
+	transactionUseCase, accountRepo, transactionRepo, messageQueue := newReversalTestUseCase()
+	toID := "acc-1"
+
+	deposit, err := transactionUseCase.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeDeposit, ToAccountID: &toID, Amount: 2500, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	message := messageQueue.published["transactions"][0]
+
+	// The ack of the first delivery is lost, so the message arrives twice
+	for delivery := 1; delivery <= 2; delivery++ {
+		if err := messageQueue.handlers["transactions"](context.Background(), message); err != nil {
+			t.Fatalf("Expected delivery %d to succeed, got %v", delivery, err)
+		}
+	}
+
+	if balance := accountRepo.accounts["acc-1"].Balance; balance != 12500 {
+		t.Errorf("Expected acc-1 credited once, got balance %d", balance)
+	}
+	if status := transactionRepo.transactions[deposit.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the deposit completed, got %s", status)
+	}
+}
+
+func TestProcessor_ResumesDeliveryInterruptedAfterPosting(t *testing.T) {
+	f, queue := newBatchFixture(t)
+	alice := "alice"
+
+	withdrawal, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 8000, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// An earlier delivery posted the withdrawal, then crashed before
+	// recording its outcome
+	f.transactionRepo.transactions[withdrawal.ID].Status = domain.TransactionStatusProcessing
+	posting := domain.LedgerPosting{
+		domain.NewLedgerEntry(withdrawal.ID, f.accountRepo.accounts["alice"], domain.EntryDirectionDebit, 8000),
+	}
+	if err := f.ledgerRepo.Post(context.Background(), posting); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// Applying it again would fail for insufficient funds
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the redelivery to succeed, got %v", errs)
+	}
+
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 2000 {
+		t.Errorf("Expected alice debited once, got balance %d", balance)
+	}
+	if status := f.transactionRepo.transactions[withdrawal.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the withdrawal completed, got %s", status)
+	}
+}
+
+func TestProcessor_SkipsTransactionWithOutcome(t *testing.T) {
+	f, queue := newBatchFixture(t)
+	alice := "alice"
+
+	for _, status := range []domain.TransactionStatus{domain.TransactionStatusCompleted, domain.TransactionStatusFailed, domain.TransactionStatusCancelled} {
+		t.Run(string(status), func(t *testing.T) {
+			deposit, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+				Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 100, Currency: "USD", Reference: string(status),
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			f.transactionRepo.transactions[deposit.ID].Status = status
+
+			if errs := queue.Deliver("transactions"); len(errs) != 0 {
+				t.Fatalf("Expected the delivery acknowledged, got %v", errs)
+			}
+			if balance := f.accountRepo.accounts["alice"].Balance; balance != 10000 {
+				t.Errorf("Expected alice unchanged, got balance %d", balance)
+			}
+			if current := f.transactionRepo.transactions[deposit.ID].Status; current != status {
+				t.Errorf("Expected status to stay %s, got %s", status, current)
+			}
+		})
+	}
+}
+
+func TestProcessor_PermanentFailureIsNotRetried(t *testing.T) {
+	f, queue := newBatchFixture(t)
+	alice := "alice"
+
+	withdrawal, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 20000, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// The failure is acknowledged so the queue does not retry it
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the delivery acknowledged, got %v", errs)
+	}
+
+	stored := f.transactionRepo.transactions[withdrawal.ID]
+	if stored.Status != domain.TransactionStatusFailed {
+		t.Errorf("Expected the withdrawal failed, got %s", stored.Status)
+	}
+	if stored.FailureCode != domain.FailureCodeInsufficientFunds {
+		t.Errorf("Expected failure code %s, got %s", domain.FailureCodeInsufficientFunds, stored.FailureCode)
+	}
+}
+
+func TestProcessor_TransientFailureIsRetried(t *testing.T) {
+	f, queue := newBatchFixture(t)
+	alice := "alice"
+
+	withdrawal, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 2500, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	message := queue.published["transactions"][0]
+	handler := queue.handlers["transactions"]
+
+	f.ledgerRepo.postErr = errors.New("connection reset by peer")
+	if err := handler(context.Background(), message); err == nil || domain.IsPermanent(err) {
+		t.Fatalf("Expected a transient error returned for retry, got %v", err)
+	}
+
+	stored := f.transactionRepo.transactions[withdrawal.ID]
+	if stored.Status != domain.TransactionStatusProcessing {
+		t.Errorf("Expected the withdrawal still processing, got %s", stored.Status)
+	}
+	if !strings.HasPrefix(stored.ErrorMessage, "retrying after transient error: ") {
+		t.Errorf("Expected the transient error recorded, got %q", stored.ErrorMessage)
+	}
+
+	// The retry succeeds once the database is back
+	f.ledgerRepo.postErr = nil
+	if err := handler(context.Background(), message); err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if stored.Status != domain.TransactionStatusCompleted || stored.ErrorMessage != "" {
+		t.Errorf("Expected the withdrawal completed without an error, got %s %q", stored.Status, stored.ErrorMessage)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 7500 {
+		t.Errorf("Expected alice debited once, got balance %d", balance)
+	}
+}
+
+func TestProcessor_DroppedTransactionFails(t *testing.T) {
+	f, queue := newBatchFixture(t)
+	alice := "alice"
+
+	withdrawal, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 2500, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	message := queue.published["transactions"][0]
+
+	// The queue runs out of retries and drops the message
+	f.ledgerRepo.postErr = errors.New("connection reset by peer")
+	err = queue.handlers["transactions"](context.Background(), message)
+	if err == nil || domain.IsPermanent(err) {
+		t.Fatalf("Expected a transient error returned for retry, got %v", err)
+	}
+	f.service.FailUndelivered(context.Background(), message, err)
+
+	stored := f.transactionRepo.transactions[withdrawal.ID]
+	if stored.Status != domain.TransactionStatusFailed {
+		t.Errorf("Expected the dropped withdrawal failed, got %s", stored.Status)
+	}
+	if stored.FailureCode != domain.FailureCodeQueueError {
+		t.Errorf("Expected failure code %s, so it can be requeued, got %s", domain.FailureCodeQueueError, stored.FailureCode)
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 10000 {
+		t.Errorf("Expected alice not debited, got balance %d", balance)
+	}
+}
+
+func TestProcessor_MalformedMessageIsPermanent(t *testing.T) {
+	_, queue := newBatchFixture(t)
+
+	if err := queue.handlers["transactions"](context.Background(), []byte("{not json")); !domain.IsPermanent(err) {
+		t.Errorf("Expected a permanent error, got %v", err)
+	}
+}
+
+func TestProcessor_RawMessageForUnknownTransaction(t *testing.T) {
+	_, accountRepo, transactionRepo, messageQueue := newReversalTestUseCase()
+	fromID, toID := "acc-1", "acc-2"
+	handler := messageQueue.handlers["transactions"]
+
+	// Published straight to the queue, so no record was saved
+	for _, request := range []*domain.TransactionRequest{
+		{ID: "replayed-transfer", Type: domain.TransactionTypeTransfer, FromAccountID: &fromID, ToAccountID: &toID, Amount: 2500, Currency: "USD"},
+		{ID: "replayed-overdraft", Type: domain.TransactionTypeWithdrawal, FromAccountID: &fromID, Amount: 50000, Currency: "USD"},
+	} {
+		message, _ := json.Marshal(request)
+		if err := handler(context.Background(), message); err != nil {
+			t.Fatalf("Expected %s to be acknowledged, got %v", request.ID, err)
+		}
+	}
+
+	transfer, ok := transactionRepo.transactions["replayed-transfer"]
+	if !ok {
+		t.Fatal("Expected a record created for the replayed transfer")
+	}
+	if transfer.Status != domain.TransactionStatusCompleted || transfer.Amount != 2500 || *transfer.FromAccountID != fromID {
+		t.Errorf("Expected the transfer recorded as completed, got %s for %d", transfer.Status, transfer.Amount)
+	}
+	if overdraft := transactionRepo.transactions["replayed-overdraft"]; overdraft == nil || overdraft.FailureCode != domain.FailureCodeInsufficientFunds {
+		t.Errorf("Expected the overdraft recorded as failed for insufficient funds, got %+v", overdraft)
+	}
+	if accountRepo.accounts["acc-1"].Balance != 7500 || accountRepo.accounts["acc-2"].Balance != 12500 {
+		t.Errorf("Expected only the transfer applied, got balances %d and %d", accountRepo.accounts["acc-1"].Balance, accountRepo.accounts["acc-2"].Balance)
+	}
+}
+
+// completionFailingRepository loses every write that marks a transaction
+// completed
+type completionFailingRepository struct {
+	*MockTransactionRepository
+}
+
+func (r *completionFailingRepository) MarkCompleted(ctx context.Context, id string, changes []*domain.BalanceChange) error {
+	return errors.New("connection reset by peer")
+}
+
+func TestProcessor_UnrecordedStatusIsNotRetried(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	queue := NewMockMessageQueue()
+	service := usecase.NewTransactionUseCase(accountRepo, &completionFailingRepository{transactionRepo}, queue, "transactions").(*usecase.TransactionUseCase)
+	if _, err := service.StartTransactionProcessor(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	fromID := "acc-1"
+	withdrawal, err := service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeWithdrawal, FromAccountID: &fromID, Amount: 2500, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// Without a ledger, a retry would debit the account again
+	message := queue.published["transactions"][0]
+	errs := queue.Deliver("transactions")
+	var unrecorded *domain.UnrecordedStatusError
+	if len(errs) != 1 || !domain.IsPermanent(errs[0]) || !errors.As(errs[0], &unrecorded) {
+		t.Fatalf("Expected a permanent UnrecordedStatusError, got %v", errs)
+	}
+	if unrecorded.TransactionID != withdrawal.ID {
+		t.Errorf("Expected the error to name %s, got %s", withdrawal.ID, unrecorded.TransactionID)
+	}
+
+	// The posted withdrawal is not failed, even once the queue drops it,
+	// which would misreport the balance
+	service.FailUndelivered(context.Background(), message, errs[0])
+	if status := transactionRepo.transactions[withdrawal.ID].Status; status != domain.TransactionStatusProcessing {
+		t.Errorf("Expected the withdrawal left processing for repair, got %s", status)
+	}
+	if balance := accountRepo.accounts["acc-1"].Balance; balance != 7500 {
+		t.Errorf("Expected acc-1 debited once, got balance %d", balance)
+	}
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// completedPayment transfers 25.00 from acc-1 to acc-2 and processes it
+func completedPayment(t *testing.T, transactionUseCase *usecase.TransactionUseCase, messageQueue *MockMessageQueue) *domain.Transaction {
+	t.Helper()
+	fromID, toID := "acc-1", "acc-2"
+	payment, err := transactionUseCase.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &fromID,
+		ToAccountID:   &toID,
+		Amount:        2500,
+		Currency:      "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected payment to be accepted, got %v", err)
+	}
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected payment to complete, got %v", errs)
+	}
+	return payment
+}
+
+func TestRefundTransaction_PartialThenRemainder(t *testing.T) {
+	transactionUseCase, accountRepo, transactionRepo, messageQueue := newReversalTestUseCase()
+	ctx := context.Background()
+	payment := completedPayment(t, transactionUseCase, messageQueue)
+
+	partial, err := transactionUseCase.RefundTransaction(ctx, payment.ID, "10.00")
+	if err != nil {
+		t.Fatalf("Expected refund to be accepted, got %v", err)
+	}
+	if partial.Type != domain.TransactionTypeRefund || partial.RefundedTransactionID != payment.ID || partial.Amount != 1000 {
+		t.Errorf("Expected a 10.00 refund linked to %s, got %+v", payment.ID, partial)
+	}
+	if *partial.FromAccountID != "acc-2" || *partial.ToAccountID != "acc-1" {
+		t.Errorf("Expected refund to swap accounts, got from %s to %s", *partial.FromAccountID, *partial.ToAccountID)
+	}
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected refund to complete, got %v", errs)
+	}
+	if refunded := transactionRepo.transactions[payment.ID].RefundedAmount; refunded != 1000 {
+		t.Errorf("Expected 10.00 refunded, got %s", refunded.Format("USD"))
+	}
+
+	if _, err := transactionUseCase.RefundTransaction(ctx, payment.ID, "15.01"); err != domain.ErrRefundExceedsOriginal {
+		t.Errorf("Expected %v, got %v", domain.ErrRefundExceedsOriginal, err)
+	}
+
+	remainder, err := transactionUseCase.RefundTransaction(ctx, payment.ID, "")
+	if err != nil {
+		t.Fatalf("Expected refund to be accepted, got %v", err)
+	}
+	if remainder.Amount != 1500 {
+		t.Errorf("Expected the remaining 15.00 refunded, got %s", remainder.Amount.Format("USD"))
+	}
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected refund to complete, got %v", errs)
+	}
+
+	for _, id := range []string{"acc-1", "acc-2"} {
+		if balance := accountRepo.accounts[id].Balance; balance != 10000 {
+			t.Errorf("Expected %s balance restored to 100.00, got %s", id, balance.Format("USD"))
+		}
+		history, _ := transactionUseCase.GetTransactionHistory(ctx, id, &domain.TransactionFilter{Limit: 10})
+		refunds := 0
+		for _, transaction := range history {
+			if transaction.Type == domain.TransactionTypeRefund {
+				refunds++
+			}
+		}
+		if refunds != 2 {
+			t.Errorf("Expected both refunds in %s history, got %d", id, refunds)
+		}
+	}
+
+	if _, err := transactionUseCase.RefundTransaction(ctx, payment.ID, ""); err != domain.ErrRefundExceedsOriginal {
+		t.Errorf("Expected a fully refunded payment to be rejected, got %v", err)
+	}
+	if _, err := transactionUseCase.ReverseTransaction(ctx, payment.ID); err != domain.ErrTransactionNotReversible {
+		t.Errorf("Expected a refunded payment to be irreversible, got %v", err)
+	}
+}
+
+func TestRefundTransaction_ConcurrentRefundsStayWithinAmount(t *testing.T) {
+	transactionUseCase, accountRepo, transactionRepo, messageQueue := newReversalTestUseCase()
+	ctx := context.Background()
+	payment := completedPayment(t, transactionUseCase, messageQueue)
+
+	// Both are accepted before either is processed
+	first, err := transactionUseCase.RefundTransaction(ctx, payment.ID, "20.00")
+	if err != nil {
+		t.Fatalf("Expected refund to be accepted, got %v", err)
+	}
+	second, err := transactionUseCase.RefundTransaction(ctx, payment.ID, "20.00")
+	if err != nil {
+		t.Fatalf("Expected refund to be accepted, got %v", err)
+	}
+
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Errorf("Expected the rejected refund not to be redelivered, got %v", errs)
+	}
+
+	if status := transactionRepo.transactions[first.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected first refund to complete, got %s", status)
+	}
+	rejected := transactionRepo.transactions[second.ID]
+	if rejected.Status != domain.TransactionStatusFailed || rejected.FailureCode != domain.FailureCodeLimitExceeded {
+		t.Errorf("Expected second refund to fail, got %s %s", rejected.Status, rejected.FailureCode)
+	}
+	if refunded := transactionRepo.transactions[payment.ID].RefundedAmount; refunded != 2000 {
+		t.Errorf("Expected 20.00 refunded, got %s", refunded.Format("USD"))
+	}
+	if balance := accountRepo.accounts["acc-2"].Balance; balance != 10500 {
+		t.Errorf("Expected one refund taken from acc-2, got %s", balance.Format("USD"))
+	}
+}
+
+func TestRefundTransaction_RequiresCompletedPayment(t *testing.T) {
+	transactionUseCase, _, transactionRepo, _ := newReversalTestUseCase()
+	fromID, toID := "acc-1", "acc-2"
+
+	for _, status := range []domain.TransactionStatus{domain.TransactionStatusPending, domain.TransactionStatusFailed, domain.TransactionStatusCancelled} {
+		t.Run(string(status), func(t *testing.T) {
+			transactionRepo.transactions["payment"] = &domain.Transaction{
+				ID: "payment", Type: domain.TransactionTypeTransfer, FromAccountID: &fromID, ToAccountID: &toID,
+				Amount: 2500, Currency: "USD", Status: status,
+			}
+			if _, err := transactionUseCase.RefundTransaction(context.Background(), "payment", ""); err != domain.ErrTransactionNotCompleted {
+				t.Errorf("Expected %v, got %v", domain.ErrTransactionNotCompleted, err)
+			}
+		})
+	}
+
+	transactionRepo.transactions["fee"] = &domain.Transaction{ID: "fee", Type: domain.TransactionTypeFee, Status: domain.TransactionStatusCompleted, Amount: 50, Currency: "USD"}
+	if _, err := transactionUseCase.RefundTransaction(context.Background(), "fee", ""); err != domain.ErrTransactionNotRefundable {
+		t.Errorf("Expected %v, got %v", domain.ErrTransactionNotRefundable, err)
+	}
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequeueTransaction(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	queue := NewMockMessageQueue()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, queue, "transactions").(*usecase.TransactionUseCase)
+	if _, err := transactionUseCase.StartTransactionProcessor(context.Background()); err != nil {
+		t.Fatalf("Failed to start processor: %v", err)
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: transactionUseCase,
+		AdminToken:         ownershipAdminToken,
+	})
+
+	toID := "alice"
+	created := time.Now().Add(-2 * time.Hour)
+	for _, transaction := range []*domain.Transaction{
+		{ID: "stuck", Status: domain.TransactionStatusPending},
+		{ID: "expired", Status: domain.TransactionStatusFailed, FailureCode: domain.FailureCodeExpired, ErrorMessage: domain.ErrTransactionExpired.Error()},
+		{ID: "declined", Status: domain.TransactionStatusFailed, FailureCode: domain.FailureCodeAccountFrozen},
+		{ID: "unapproved", Status: domain.TransactionStatusFailed, FailureCode: domain.FailureCodeExpired, StatusHistory: []domain.StatusChange{
+			{Status: domain.TransactionStatusPendingApproval, Timestamp: created},
+			{Status: domain.TransactionStatusFailed, Timestamp: created.Add(time.Hour)},
+		}},
+		{ID: "leg", Status: domain.TransactionStatusPending, BatchID: "batch-1"},
+		{ID: "done", Status: domain.TransactionStatusCompleted},
+	} {
+		transaction.Type = domain.TransactionTypeDeposit
+		transaction.ToAccountID = &toID
+		transaction.Amount = 2500
+		transaction.Currency = "USD"
+		transaction.CreatedAt = created
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	requeue := func(id string) (*domain.TransactionRequeue, int) {
+		t.Helper()
+		rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/admin/transactions/"+id+"/requeue", "")
+		if rec.Code != http.StatusOK {
+			return nil, rec.Code
+		}
+		var result domain.TransactionRequeue
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return &result, rec.Code
+	}
+
+	// A stuck transaction requeued twice is published twice but applied once
+	for want := 1; want <= 2; want++ {
+		result, code := requeue("stuck")
+		if code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+		if result.RequeueCount != want || result.Status != domain.TransactionStatusPending || result.RequeuedBy != "admin" {
+			t.Errorf("Expected requeue %d by admin, got %+v", want, result)
+		}
+	}
+	if published := queue.PublishedCount("transactions"); published != 2 {
+		t.Errorf("Expected two messages published, got %d", published)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the deliveries handled, got %v", errs)
+	}
+	if status := transactionRepo.transactions["stuck"].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the requeued transaction completed, got %s", status)
+	}
+	if balance := accountRepo.accounts["alice"].Balance; balance != 12500 {
+		t.Errorf("Expected the deposit applied once, got a balance of %d", balance)
+	}
+
+	// A retryable failure returns to pending without it
+	result, code := requeue("expired")
+	if code != http.StatusOK || result.RequeueCount != 1 {
+		t.Fatalf("Expected the expired transaction requeued, got %d %+v", code, result)
+	}
+	expired := transactionRepo.transactions["expired"]
+	if expired.Status != domain.TransactionStatusPending || expired.FailureCode != "" || expired.ErrorMessage != "" {
+		t.Errorf("Expected the failure cleared, got %s %s %q", expired.Status, expired.FailureCode, expired.ErrorMessage)
+	}
+	if last := expired.StatusHistory[len(expired.StatusHistory)-1]; last.Status != domain.TransactionStatusPending || last.RequeuedBy != "admin" {
+		t.Errorf("Expected the requeue in the status history, got %+v", last)
+	}
+
+	for _, tt := range []struct {
+		id     string
+		status int
+	}{
+		{"declined", http.StatusConflict},
+		{"unapproved", http.StatusConflict},
+		{"leg", http.StatusConflict},
+		{"done", http.StatusConflict},
+		{"missing", http.StatusNotFound},
+	} {
+		if _, code := requeue(tt.id); code != tt.status {
+			t.Errorf("Expected status %d requeueing %s, got %d", tt.status, tt.id, code)
+		}
+	}
+
+	if rec := principalRequest(e, "alice", http.MethodPost, "/api/v1/admin/transactions/stuck/requeue", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for a user, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
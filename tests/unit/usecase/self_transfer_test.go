@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newSelfTransferFixture gives alice a savings account alongside her checking
+// one, holds transfers over 50.00 for approval and charges 1.00 on transfers,
+// exempting self transfers from both
+func newSelfTransferFixture(t *testing.T, opts ...usecase.TransactionOption) (*ledgerFixture, *MockMessageQueue) {
+	t.Helper()
+	policy := usecase.NewFeePolicy("fees", usecase.FeeFailureFail)
+	policy.SetRule(domain.TransactionTypeTransfer, "USD", usecase.FeeRule{Flat: 100})
+	policy.ExemptSelfTransfers(true)
+
+	f, queue := newBatchFixture(t, append([]usecase.TransactionOption{
+		usecase.WithApproval("50.00", 24*time.Hour),
+		usecase.WithSelfTransferApprovalExemption(),
+		usecase.WithFeePolicy(policy),
+	}, opts...)...)
+	f.accountRepo.accounts["alice-savings"] = &domain.Account{ID: "alice-savings", UserID: "alice", Type: domain.AccountTypeSavings, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	f.accountRepo.accounts["fees"] = &domain.Account{ID: "fees", UserID: "bank", Type: domain.AccountTypeFeeCollection, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	return f, queue
+}
+
+func TestSelfTransfer_SkipsApprovalAndFees(t *testing.T) {
+	notifications := NewMockMessageQueue()
+	f, queue := newSelfTransferFixture(t, usecase.WithNotifications(usecase.NewNotificationUseCase(notifications, "notifications"), ""))
+
+	self := f.transferTo(t, "alice-savings", 6000)
+	if !self.SelfTransfer || self.Status != domain.TransactionStatusPending {
+		t.Fatalf("Expected a pending self transfer, got self_transfer=%v %s", self.SelfTransfer, self.Status)
+	}
+	other := f.transferTo(t, "bob", 3000)
+	if other.SelfTransfer {
+		t.Error("Expected a transfer to another user not flagged")
+	}
+	if large := f.transferTo(t, "bob", 6000); large.Status != domain.TransactionStatusPendingApproval {
+		t.Errorf("Expected a large transfer to another user held for approval, got %s", large.Status)
+	}
+	if errs := queue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the transfers acknowledged, got %v", errs)
+	}
+
+	if stored := f.transactionRepo.transactions[self.ID]; stored.Status != domain.TransactionStatusCompleted || !stored.SelfTransfer {
+		t.Fatalf("Expected the self transfer completed and flagged, got %s self_transfer=%v", stored.Status, stored.SelfTransfer)
+	}
+	if _, charged := f.transactionRepo.transactions[usecase.FeeTransactionID(self.ID)]; charged {
+		t.Error("Expected no fee on the self transfer")
+	}
+	if _, charged := f.transactionRepo.transactions[usecase.FeeTransactionID(other.ID)]; !charged {
+		t.Error("Expected a fee on the transfer to another user")
+	}
+	if balance := f.accountRepo.accounts["alice"].Balance; balance != 10000-6000-3000-100 {
+		t.Errorf("Expected alice charged only the other transfer's fee, got %d", balance)
+	}
+
+	events := make(map[string]domain.NotificationType)
+	for _, event := range notifications.events(t, "notifications") {
+		if event.Transaction != nil {
+			events[event.Transaction.ID] = event.Type
+		}
+	}
+	if events[self.ID] != domain.NotificationTransferInternal || events[other.ID] != domain.NotificationTransactionCompleted {
+		t.Errorf("Expected transfer.internal for the self transfer only, got %v", events)
+	}
+}
+
+func TestSelfTransfer_WithoutExemptions(t *testing.T) {
+	f, _ := newBatchFixture(t, usecase.WithApproval("50.00", 24*time.Hour))
+	f.accountRepo.accounts["alice-savings"] = &domain.Account{ID: "alice-savings", UserID: "alice", Type: domain.AccountTypeSavings, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	self := f.transferTo(t, "alice-savings", 6000)
+	if !self.SelfTransfer || self.Status != domain.TransactionStatusPendingApproval {
+		t.Errorf("Expected the self transfer flagged but held for approval, got self_transfer=%v %s", self.SelfTransfer, self.Status)
+	}
+}
+
+func TestSelfTransfer_SameAccountRefused(t *testing.T) {
+	f, _ := newSelfTransferFixture(t)
+	alice := "alice"
+	_, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &alice, Amount: 100, Currency: "USD",
+	})
+	if !errors.Is(err, domain.ErrSameAccount) {
+		t.Errorf("Expected %v, got %v", domain.ErrSameAccount, err)
+	}
+}
+
+func TestSelfTransfer_FilterTransactions(t *testing.T) {
+	f, _ := newSelfTransferFixture(t)
+	self := f.transferTo(t, "alice-savings", 1000)
+	f.transferTo(t, "bob", 1000)
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		TransactionService: f.service,
+		AdminToken:         ownershipAdminToken,
+	})
+
+	for query, expected := range map[string]bool{"self_transfer=true": true, "self_transfer=false": false} {
+		rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?"+query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, query, rec.Code, rec.Body)
+		}
+
+		var body struct {
+			Transactions []*domain.Transaction `json:"transactions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(body.Transactions) != 1 || body.Transactions[0].SelfTransfer != expected {
+			t.Fatalf("Expected one transaction with self_transfer=%v for %s, got %d", expected, query, len(body.Transactions))
+		}
+		if expected && body.Transactions[0].ID != self.ID {
+			t.Errorf("Expected the self transfer, got %s", body.Transactions[0].ID)
+		}
+	}
+}
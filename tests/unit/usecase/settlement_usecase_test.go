@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+	"banking-ledger/pkg/calendar"
+)
+
+// MockSettlementGroupRepository implements domain.SettlementGroupRepository for testing
+type MockSettlementGroupRepository struct {
+	groups map[string]*domain.SettlementGroup
+}
+
+func NewMockSettlementGroupRepository() *MockSettlementGroupRepository {
+	return &MockSettlementGroupRepository{
+		groups: make(map[string]*domain.SettlementGroup),
+	}
+}
+
+func (m *MockSettlementGroupRepository) Create(ctx context.Context, group *domain.SettlementGroup) error {
+	if group.ID == "" {
+		group.ID = "test-group-id"
+	}
+	m.groups[group.ID] = group
+	return nil
+}
+
+func (m *MockSettlementGroupRepository) GetByID(ctx context.Context, id string) (*domain.SettlementGroup, error) {
+	group, exists := m.groups[id]
+	if !exists {
+		return nil, domain.ErrSettlementGroupNotFound
+	}
+	return group, nil
+}
+
+func (m *MockSettlementGroupRepository) List(ctx context.Context) ([]*domain.SettlementGroup, error) {
+	var groups []*domain.SettlementGroup
+	for _, group := range m.groups {
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func (m *MockSettlementGroupRepository) Update(ctx context.Context, group *domain.SettlementGroup) error {
+	if _, exists := m.groups[group.ID]; !exists {
+		return domain.ErrSettlementGroupNotFound
+	}
+	m.groups[group.ID] = group
+	return nil
+}
+
+func TestSettlementUseCase_CreateGroupValidatesAccounts(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	accountRepo.accounts["a1"] = &domain.Account{ID: "a1", Currency: "USD", Status: "active"}
+	settlementUseCase := usecase.NewSettlementUseCase(NewMockSettlementGroupRepository(), accountRepo, NewMockTransactionRepository())
+
+	if _, err := settlementUseCase.CreateGroup(context.Background(), "Partner", []string{"a1", "missing"}); err != domain.ErrInvalidSettlementGroup {
+		t.Errorf("Expected %v for unknown account, got %v", domain.ErrInvalidSettlementGroup, err)
+	}
+
+	group, err := settlementUseCase.CreateGroup(context.Background(), "Partner", []string{"a1", "a1"})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(group.AccountIDs) != 1 {
+		t.Errorf("Expected duplicate accounts to be dropped, got %v", group.AccountIDs)
+	}
+}
+
+func TestSettlementUseCase_SettleIsIdempotent(t *testing.T) {
+	groupRepo := NewMockSettlementGroupRepository()
+	transactionRepo := NewMockTransactionRepository()
+	settlementUseCase := usecase.NewSettlementUseCase(groupRepo, NewMockAccountRepository(), transactionRepo)
+
+	groupRepo.groups["grp-1"] = &domain.SettlementGroup{ID: "grp-1", Name: "Partner", AccountIDs: []string{"a1"}}
+
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	from, to := "x1", "a1"
+	transactionRepo.transactions["tx-in"] = &domain.Transaction{
+		ID:            "tx-in",
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &from,
+		ToAccountID:   &to,
+		Amount:        100.0,
+		Currency:      "USD",
+		Status:        domain.TransactionStatusCompleted,
+		CreatedAt:     day,
+		ProcessedAt:   &day,
+	}
+
+	first, err := settlementUseCase.Settle(context.Background(), "grp-1", day)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if first.AlreadySettled {
+		t.Errorf("Expected first settlement not to be marked as already settled")
+	}
+	if len(first.Transactions) != 1 {
+		t.Fatalf("Expected 1 settlement transaction, got %d", len(first.Transactions))
+	}
+
+	booked := first.Transactions[0]
+	if booked.Type != domain.TransactionTypeExternalTransfer || booked.Amount != 100.0 || booked.Currency != "USD" {
+		t.Errorf("Unexpected settlement transaction %+v", booked)
+	}
+	if batchID := transactionRepo.transactions["tx-in"].SettlementBatchID; batchID != first.BatchID {
+		t.Errorf("Expected constituent to be marked with batch %s, got %q", first.BatchID, batchID)
+	}
+
+	second, err := settlementUseCase.Settle(context.Background(), "grp-1", day)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !second.AlreadySettled || second.BatchID != first.BatchID {
+		t.Errorf("Expected repeat settlement to return batch %s as already settled, got %+v", first.BatchID, second)
+	}
+	if len(transactionRepo.transactions) != 2 {
+		t.Errorf("Expected repeat settlement not to book again, got %d transactions", len(transactionRepo.transactions))
+	}
+}
+
+func TestSettlementUseCase_RollsValueDateToBusinessDay(t *testing.T) {
+	groupRepo := NewMockSettlementGroupRepository()
+	transactionRepo := NewMockTransactionRepository()
+	calendars, err := calendar.Load([]string{"saturday", "sunday"}, []string{"USD:2024-03-04"})
+	if err != nil {
+		t.Fatalf("Failed to load calendar: %v", err)
+	}
+	settlementUseCase := usecase.NewSettlementUseCase(
+		groupRepo,
+		NewMockAccountRepository(),
+		transactionRepo,
+		usecase.WithSettlementCalendar(calendars, calendar.ConventionFollowing),
+	)
+
+	groupRepo.groups["grp-1"] = &domain.SettlementGroup{ID: "grp-1", Name: "Partner", AccountIDs: []string{"a1"}}
+
+	// Saturday, followed by a Monday holiday
+	day := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	from, to := "x1", "a1"
+	transactionRepo.transactions["tx-in"] = &domain.Transaction{
+		ID:            "tx-in",
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &from,
+		ToAccountID:   &to,
+		Amount:        100.0,
+		Currency:      "USD",
+		Status:        domain.TransactionStatusCompleted,
+		CreatedAt:     day,
+		ProcessedAt:   &day,
+	}
+
+	settlement, err := settlementUseCase.Settle(context.Background(), "grp-1", day)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(settlement.Transactions) != 1 {
+		t.Fatalf("Expected 1 settlement transaction, got %d", len(settlement.Transactions))
+	}
+
+	metadata := settlement.Transactions[0].Metadata
+	if metadata["settlement_date"] != "2024-03-02" || metadata["value_date"] != "2024-03-05" {
+		t.Errorf("Expected settlement date 2024-03-02 valued 2024-03-05, got %v and %v", metadata["settlement_date"], metadata["value_date"])
+	}
+}
@@ -0,0 +1,335 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockStandingOrderRepository implements domain.StandingOrderRepository for
+// testing, handing out copies so claims behave like database updates
+type MockStandingOrderRepository struct {
+	mu     sync.Mutex
+	orders map[string]*domain.StandingOrder
+}
+
+func NewMockStandingOrderRepository() *MockStandingOrderRepository {
+	return &MockStandingOrderRepository{orders: make(map[string]*domain.StandingOrder)}
+}
+
+func (m *MockStandingOrderRepository) Create(ctx context.Context, order *domain.StandingOrder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if order.ID == "" {
+		order.ID = "order-" + string(rune('a'+len(m.orders)))
+	}
+	order.CreatedAt = time.Now()
+	order.UpdatedAt = order.CreatedAt
+	copied := *order
+	m.orders[order.ID] = &copied
+	return nil
+}
+
+func (m *MockStandingOrderRepository) GetByID(ctx context.Context, id string) (*domain.StandingOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	order, exists := m.orders[id]
+	if !exists {
+		return nil, domain.ErrStandingOrderNotFound
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (m *MockStandingOrderRepository) List(ctx context.Context, accountID string) ([]*domain.StandingOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var orders []*domain.StandingOrder
+	for _, order := range m.orders {
+		if accountID == "" || order.FromAccountID == accountID || order.ToAccountID == accountID {
+			copied := *order
+			orders = append(orders, &copied)
+		}
+	}
+	return orders, nil
+}
+
+func (m *MockStandingOrderRepository) Update(ctx context.Context, order *domain.StandingOrder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, exists := m.orders[order.ID]
+	if !exists {
+		return domain.ErrStandingOrderNotFound
+	}
+	if stored.Status.IsClosed() {
+		return domain.ErrStandingOrderClosed
+	}
+	stored.Amount = order.Amount
+	stored.Description = order.Description
+	stored.Status = order.Status
+	return nil
+}
+
+func (m *MockStandingOrderRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*domain.StandingOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var orders []*domain.StandingOrder
+	for _, order := range m.orders {
+		if order.Status == domain.StandingOrderStatusActive && !order.NextRunAt.After(now) && len(orders) < limit {
+			copied := *order
+			orders = append(orders, &copied)
+		}
+	}
+	return orders, nil
+}
+
+func (m *MockStandingOrderRepository) Claim(ctx context.Context, order *domain.StandingOrder, previousRunAt, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.orders[order.ID]
+	if stored.Status != domain.StandingOrderStatusActive || !stored.NextRunAt.Equal(previousRunAt) || stored.NextRunAt.After(now) {
+		return domain.ErrConcurrentUpdate
+	}
+	stored.RunCount = order.RunCount
+	stored.NextRunAt = order.NextRunAt
+	stored.Status = order.Status
+	return nil
+}
+
+func (m *MockStandingOrderRepository) AppendTransaction(ctx context.Context, id, transactionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.orders[id]
+	stored.TransactionIDs = append(stored.TransactionIDs, transactionID)
+	return nil
+}
+
+type standingOrderFixture struct {
+	service         domain.StandingOrderService
+	accountRepo     *MockAccountRepository
+	orderRepo       *MockStandingOrderRepository
+	transactionRepo *MockTransactionRepository
+	queue           *MockMessageQueue
+	clock           *time.Time
+}
+
+func newStandingOrderFixture() *standingOrderFixture {
+	clock := time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)
+	accountRepo := NewMockAccountRepository()
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Balance: 100000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["acc-2"] = &domain.Account{ID: "acc-2", UserID: "user2", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["acc-eur"] = &domain.Account{ID: "acc-eur", UserID: "user3", Currency: "EUR", Status: domain.AccountStatusActive, Version: 1}
+	transactionRepo := NewMockTransactionRepository()
+	queue := NewMockMessageQueue()
+
+	f := &standingOrderFixture{
+		accountRepo:     accountRepo,
+		orderRepo:       NewMockStandingOrderRepository(),
+		transactionRepo: transactionRepo,
+		queue:           queue,
+		clock:           &clock,
+	}
+	f.service = f.newService()
+	return f
+}
+
+// newService builds a service sharing the fixture's stores, as a second
+// processor instance would
+func (f *standingOrderFixture) newService() domain.StandingOrderService {
+	transactionService := usecase.NewTransactionUseCase(f.accountRepo, f.transactionRepo, f.queue, "transactions")
+	return usecase.NewStandingOrderUseCase(
+		f.orderRepo,
+		f.accountRepo,
+		transactionService,
+		usecase.WithStandingOrderClock(func() time.Time { return *f.clock }),
+	)
+}
+
+func (f *standingOrderFixture) create(t *testing.T) *domain.StandingOrder {
+	t.Helper()
+	order, err := f.service.CreateStandingOrder(context.Background(), &domain.StandingOrderRequest{
+		FromAccountID: "acc-1",
+		ToAccountID:   "acc-2",
+		Amount:        50000,
+		Currency:      "USD",
+		Description:   "Rent",
+		Frequency:     domain.StandingOrderFrequencyMonthly,
+		StartAt:       time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return order
+}
+
+func TestStandingOrderUseCase_CreateValidation(t *testing.T) {
+	f := newStandingOrderFixture()
+	ctx := context.Background()
+
+	_, err := f.service.CreateStandingOrder(ctx, &domain.StandingOrderRequest{
+		FromAccountID: "acc-1", ToAccountID: "acc-2", Amount: 100, Currency: "USD",
+		Frequency: domain.StandingOrderFrequencyWeekly, StartAt: f.clock.Add(-time.Hour),
+	})
+	if err != domain.ErrInvalidStandingOrder {
+		t.Errorf("Expected %v for a start in the past, got %v", domain.ErrInvalidStandingOrder, err)
+	}
+
+	_, err = f.service.CreateStandingOrder(ctx, &domain.StandingOrderRequest{
+		FromAccountID: "acc-1", ToAccountID: "acc-eur", Amount: 100, Currency: "USD",
+		Frequency: domain.StandingOrderFrequencyWeekly,
+	})
+	if err != domain.ErrCurrencyMismatch {
+		t.Errorf("Expected %v, got %v", domain.ErrCurrencyMismatch, err)
+	}
+
+	order, err := f.service.CreateStandingOrder(ctx, &domain.StandingOrderRequest{
+		FromAccountID: "acc-1", ToAccountID: "acc-2", Amount: 100, Currency: "USD",
+		Frequency: domain.StandingOrderFrequencyWeekly,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !order.NextRunAt.Equal(*f.clock) || order.Status != domain.StandingOrderStatusActive {
+		t.Errorf("Expected an active order running now, got %+v", order)
+	}
+}
+
+func TestStandingOrderUseCase_ExecuteDue(t *testing.T) {
+	f := newStandingOrderFixture()
+	order := f.create(t)
+	ctx := context.Background()
+
+	if executed, err := f.service.ExecuteDue(ctx); err != nil || executed != 0 {
+		t.Fatalf("Expected nothing due before the start, got %d (err %v)", executed, err)
+	}
+
+	*f.clock = time.Date(2024, 1, 25, 0, 0, 30, 0, time.UTC)
+	if executed, err := f.service.ExecuteDue(ctx); err != nil || executed != 1 {
+		t.Fatalf("Expected 1 order executed, got %d (err %v)", executed, err)
+	}
+
+	runID := order.RunIDFor(0)
+	transaction, exists := f.transactionRepo.transactions[runID]
+	if !exists {
+		t.Fatalf("Expected transfer %s to be submitted", runID)
+	}
+	if transaction.Type != domain.TransactionTypeTransfer || transaction.Amount != 50000 || transaction.Metadata["standing_order_id"] != order.ID {
+		t.Errorf("Unexpected transfer %+v", transaction)
+	}
+	if count := f.queue.PublishedCount("transactions"); count != 1 {
+		t.Errorf("Expected the transfer to be queued, got %d messages", count)
+	}
+
+	stored, _ := f.orderRepo.GetByID(ctx, order.ID)
+	if len(stored.TransactionIDs) != 1 || stored.TransactionIDs[0] != runID {
+		t.Errorf("Expected the transfer recorded on the order, got %v", stored.TransactionIDs)
+	}
+	if !stored.NextRunAt.Equal(time.Date(2024, 2, 25, 0, 0, 0, 0, time.UTC)) || stored.RunCount != 1 {
+		t.Errorf("Expected next run on 25 February, got run %d at %v", stored.RunCount, stored.NextRunAt)
+	}
+
+	// The same run does not fire twice
+	if executed, err := f.service.ExecuteDue(ctx); err != nil || executed != 0 {
+		t.Errorf("Expected nothing due again, got %d (err %v)", executed, err)
+	}
+}
+
+func TestStandingOrderUseCase_ConcurrentProcessorsFireOnce(t *testing.T) {
+	f := newStandingOrderFixture()
+	f.create(t)
+	*f.clock = time.Date(2024, 1, 25, 0, 0, 30, 0, time.UTC)
+
+	services := []domain.StandingOrderService{f.service, f.newService(), f.newService()}
+	results := make(chan int, len(services))
+	var wg sync.WaitGroup
+	for _, service := range services {
+		wg.Add(1)
+		go func(service domain.StandingOrderService) {
+			defer wg.Done()
+			executed, err := service.ExecuteDue(context.Background())
+			if err != nil {
+				t.Errorf("Expected no error but got %v", err)
+			}
+			results <- executed
+		}(service)
+	}
+	wg.Wait()
+	close(results)
+
+	total := 0
+	for executed := range results {
+		total += executed
+	}
+	if total != 1 || f.queue.PublishedCount("transactions") != 1 {
+		t.Errorf("Expected exactly one transfer, got %d executions and %d queued", total, f.queue.PublishedCount("transactions"))
+	}
+}
+
+func TestStandingOrderUseCase_SkipsInactiveAccountsAndPausedOrders(t *testing.T) {
+	f := newStandingOrderFixture()
+	order := f.create(t)
+	ctx := context.Background()
+
+	f.accountRepo.accounts["acc-2"].Status = domain.AccountStatusInactive
+	*f.clock = time.Date(2024, 1, 25, 0, 0, 30, 0, time.UTC)
+	if executed, err := f.service.ExecuteDue(ctx); err != nil || executed != 0 {
+		t.Fatalf("Expected the run to be skipped, got %d (err %v)", executed, err)
+	}
+	stored, _ := f.orderRepo.GetByID(ctx, order.ID)
+	if stored.RunCount != 1 || len(stored.TransactionIDs) != 0 {
+		t.Errorf("Expected the skipped run to pass without a transfer, got run %d and %v", stored.RunCount, stored.TransactionIDs)
+	}
+
+	f.accountRepo.accounts["acc-2"].Status = domain.AccountStatusActive
+	paused := domain.StandingOrderStatusPaused
+	if _, err := f.service.UpdateStandingOrder(ctx, order.ID, &domain.StandingOrderUpdate{Status: &paused}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	*f.clock = time.Date(2024, 2, 25, 0, 0, 30, 0, time.UTC)
+	if executed, err := f.service.ExecuteDue(ctx); err != nil || executed != 0 {
+		t.Errorf("Expected a paused order not to run, got %d (err %v)", executed, err)
+	}
+	if count := f.queue.PublishedCount("transactions"); count != 0 {
+		t.Errorf("Expected no transfers, got %d", count)
+	}
+}
+
+func TestStandingOrderUseCase_UpdateAndCancel(t *testing.T) {
+	f := newStandingOrderFixture()
+	order := f.create(t)
+	ctx := context.Background()
+
+	amount := domain.Decimal("550.00")
+	updated, err := f.service.UpdateStandingOrder(ctx, order.ID, &domain.StandingOrderUpdate{Amount: &amount})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if updated.Amount != 55000 {
+		t.Errorf("Expected amount 550.00, got %s", updated.Amount.Format("USD"))
+	}
+
+	closed := domain.StandingOrderStatusCancelled
+	if _, err := f.service.UpdateStandingOrder(ctx, order.ID, &domain.StandingOrderUpdate{Status: &closed}); err != domain.ErrInvalidInput {
+		t.Errorf("Expected %v when cancelling through an update, got %v", domain.ErrInvalidInput, err)
+	}
+
+	cancelled, err := f.service.CancelStandingOrder(ctx, order.ID)
+	if err != nil || cancelled.Status != domain.StandingOrderStatusCancelled {
+		t.Fatalf("Expected the order cancelled, got %v (err %v)", cancelled, err)
+	}
+	if _, err := f.service.CancelStandingOrder(ctx, order.ID); err != domain.ErrStandingOrderClosed {
+		t.Errorf("Expected %v, got %v", domain.ErrStandingOrderClosed, err)
+	}
+	if _, err := f.service.UpdateStandingOrder(ctx, order.ID, &domain.StandingOrderUpdate{Amount: &amount}); err != domain.ErrStandingOrderClosed {
+		t.Errorf("Expected %v, got %v", domain.ErrStandingOrderClosed, err)
+	}
+
+	*f.clock = time.Date(2024, 1, 25, 0, 0, 30, 0, time.UTC)
+	if executed, _ := f.service.ExecuteDue(ctx); executed != 0 {
+		t.Errorf("Expected a cancelled order not to run, got %d", executed)
+	}
+}
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+func statementFixture(t *testing.T) (*MockTransactionRepository, domain.LedgerService) {
+	t.Helper()
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", Balance: 9300, Currency: "USD", Status: domain.AccountStatusActive}
+
+	alice, bob, carol := "alice", "bob", "carol"
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 12, 0, 0, 0, time.UTC) }
+	for _, transaction := range []*domain.Transaction{
+		{ID: "before", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 1000, Status: domain.TransactionStatusCompleted, CreatedAt: day(time.February, 20)},
+		{ID: "dep", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 2500, Status: domain.TransactionStatusCompleted, CreatedAt: day(time.March, 2)},
+		{ID: "tr", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 4000, Status: domain.TransactionStatusCompleted, CreatedAt: day(time.March, 10)},
+		{ID: "failed", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 300, Status: domain.TransactionStatusFailed, CreatedAt: day(time.March, 12)},
+		{ID: "split", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &carol, Amount: 500, Status: domain.TransactionStatusFailed,
+			Compensation: &domain.Compensation{TransactionID: "comp"}, CreatedAt: day(time.March, 15)},
+		{ID: "comp", Type: domain.TransactionTypeCompensation, ToAccountID: &alice, Amount: 500, Status: domain.TransactionStatusCompleted, CreatedAt: day(time.March, 15).Add(time.Minute)},
+		{ID: "after", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 700, Status: domain.TransactionStatusCompleted, CreatedAt: day(time.April, 3)},
+	} {
+		transaction.Currency = "USD"
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	now := func() time.Time { return day(time.April, 10) }
+	return transactionRepo, usecase.NewLedgerUseCase(accountRepo, transactionRepo,
+		usecase.WithStatementMaxDays(92), usecase.WithLedgerClock(now))
+}
+
+func TestGetAccountStatement_RunningBalances(t *testing.T) {
+	_, service := statementFixture(t)
+
+	statement, err := service.GetAccountStatement(context.Background(), "alice", "2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	if statement.From != "2024-03-01" || statement.To != "2024-03-31" {
+		t.Errorf("Expected March, got %s to %s", statement.From, statement.To)
+	}
+	// The current 93.00 less April's withdrawal back out, then March's movements
+	if statement.ClosingBalance != 10000 || statement.OpeningBalance != 11500 {
+		t.Errorf("Expected opening 115.00 and closing 100.00, got %d and %d", statement.OpeningBalance, statement.ClosingBalance)
+	}
+
+	expected := []struct {
+		id      string
+		amount  domain.Money
+		balance domain.Money
+	}{
+		{"dep", 2500, 14000},
+		{"tr", -4000, 10000},
+		{"split", -500, 9500},
+		{"comp", 500, 10000},
+	}
+	if len(statement.Lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %+v", len(expected), statement.Lines)
+	}
+	for i, want := range expected {
+		line := statement.Lines[i]
+		if line.TransactionID != want.id || line.Amount != want.amount || line.Balance != want.balance {
+			t.Errorf("Line %d: expected %s %d -> %d, got %s %d -> %d", i, want.id, want.amount, want.balance, line.TransactionID, line.Amount, line.Balance)
+		}
+	}
+}
+
+func TestGetAccountStatement_DefaultsToCurrentMonth(t *testing.T) {
+	_, service := statementFixture(t)
+
+	for _, period := range [][2]string{{"", ""}, {"2024-03-01", ""}, {"not-a-date", "2024-03-31"}, {"2024-03-31", "2024-03-01"}} {
+		statement, err := service.GetAccountStatement(context.Background(), "alice", period[0], period[1])
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if statement.From != "2024-04-01" || statement.To != "2024-04-30" {
+			t.Errorf("Expected April for %v, got %s to %s", period, statement.From, statement.To)
+		}
+		if len(statement.Lines) != 1 || statement.OpeningBalance != 10000 || statement.ClosingBalance != 9300 {
+			t.Errorf("Unexpected April statement %+v", statement)
+		}
+	}
+}
+
+func TestGetAccountStatement_Rejections(t *testing.T) {
+	_, service := statementFixture(t)
+	ctx := context.Background()
+
+	if _, err := service.GetAccountStatement(ctx, "alice", "2024-01-01", "2024-06-30"); !errors.Is(err, domain.ErrStatementPeriodTooLong) {
+		t.Errorf("Expected ErrStatementPeriodTooLong, got %v", err)
+	}
+	if _, err := service.GetAccountStatement(ctx, "nobody", "", ""); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"banking-ledger/api/middleware"
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// pausingTransactionRepository hands out a page of transactions, then waits
+// to be released before failing as a lost cursor would
+type pausingTransactionRepository struct {
+	*MockTransactionRepository
+	release chan struct{}
+}
+
+func (r *pausingTransactionRepository) EachByFilter(ctx context.Context, filter *domain.TransactionFilter, fn func(*domain.Transaction) error) error {
+	if err := r.MockTransactionRepository.EachByFilter(ctx, filter, fn); err != nil {
+		return err
+	}
+	<-r.release
+	return errors.New("cursor lost")
+}
+
+// streamRequest asks the server for NDJSON as the admin or a user
+func streamRequest(t *testing.T, server *httptest.Server, principal, path string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set(echo.HeaderAccept, middleware.NDJSONContentType)
+	if principal == "admin" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+ownershipAdminToken)
+	} else {
+		req.Header.Set(middleware.UserIDHeader, principal)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	return resp
+}
+
+func TestTransactionStream_NDJSON(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := &pausingTransactionRepository{MockTransactionRepository: NewMockTransactionRepository(), release: make(chan struct{})}
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	toID := "alice"
+	created := time.Now().Add(-time.Hour)
+	for i := 0; i < domain.MaxTransactionPageSize; i++ {
+		id := fmt.Sprintf("tx-%03d", i)
+		transactionRepo.transactions[id] = &domain.Transaction{
+			ID:          id,
+			Type:        domain.TransactionTypeDeposit,
+			ToAccountID: &toID,
+			Amount:      100,
+			Currency:    "USD",
+			Status:      domain.TransactionStatusCompleted,
+			Metadata:    map[string]interface{}{"source": "pipeline"},
+			CreatedAt:   created.Add(time.Duration(i) * time.Second),
+		}
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		AdminToken:         ownershipAdminToken,
+	})
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	resp := streamRequest(t, server, "admin", fmt.Sprintf("/api/v1/transactions?limit=%d&fields=id,amount", domain.MaxTransactionPageSize))
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get(echo.HeaderContentType) != middleware.NDJSONContentType {
+		t.Fatalf("Expected an NDJSON stream, got %d %s", resp.StatusCode, resp.Header.Get(echo.HeaderContentType))
+	}
+
+	lines := make(chan map[string]interface{})
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				line = map[string]interface{}{"undecodable": scanner.Text()}
+			}
+			lines <- line
+		}
+	}()
+	next := func() (map[string]interface{}, bool) {
+		t.Helper()
+		select {
+		case line, ok := <-lines:
+			return line, ok
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for a line")
+			return nil, false
+		}
+	}
+
+	// The page is read while the repository still holds its cursor open
+	for i := 0; i < domain.MaxTransactionPageSize; i++ {
+		line, ok := next()
+		if !ok {
+			t.Fatalf("Expected %d lines, the stream ended after %d", domain.MaxTransactionPageSize, i)
+		}
+		if len(line) != 2 || line["id"] == nil || line["amount"] == nil {
+			t.Fatalf("Expected only the selected fields, got %v", line)
+		}
+	}
+	close(transactionRepo.release)
+
+	trailer, ok := next()
+	if !ok || len(trailer) != 1 || trailer["error"] != "Internal server error" {
+		t.Errorf("Expected an error trailer, got %v", trailer)
+	}
+	if line, ok := next(); ok {
+		t.Errorf("Expected the stream to end after the trailer, got %v", line)
+	}
+
+	// An account's history streams as seen from the account, and errors found
+	// before the first line keep their status
+	resp = streamRequest(t, server, "alice", "/api/v1/accounts/alice/transactions?limit=3&sort_by=created_at")
+	scanner := bufio.NewScanner(resp.Body)
+	var views []map[string]interface{}
+	for scanner.Scan() {
+		var view map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &view); err != nil {
+			t.Fatalf("Failed to decode line %q: %v", scanner.Text(), err)
+		}
+		views = append(views, view)
+	}
+	resp.Body.Close()
+	if len(views) != 4 || views[0]["direction"] == nil || views[3]["error"] == nil {
+		t.Errorf("Expected three account views and a trailer, got %v", views)
+	}
+
+	for _, tt := range []struct {
+		principal string
+		path      string
+		status    int
+	}{
+		{"alice", "/api/v1/accounts/missing/transactions", http.StatusNotFound},
+		{"bob", "/api/v1/accounts/alice/transactions", http.StatusForbidden},
+	} {
+		resp := streamRequest(t, server, tt.principal, tt.path)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || resp.Header.Get(echo.HeaderContentType) != echo.MIMEApplicationJSONCharsetUTF8 {
+			t.Errorf("Expected a JSON %d for %s, got %d %s", tt.status, tt.path, resp.StatusCode, resp.Header.Get(echo.HeaderContentType))
+		}
+	}
+
+	// Without the Accept header the listing is the usual JSON page
+	rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?limit=5&sort_by=created_at", "")
+	var page struct {
+		Transactions []map[string]interface{} `json:"transactions"`
+		Total        int                      `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Failed to decode page: %v", err)
+	}
+	if rec.Code != http.StatusOK || len(page.Transactions) != 5 || page.Total != domain.MaxTransactionPageSize {
+		t.Errorf("Expected a JSON page of 5 of %d, got %d %d of %d", domain.MaxTransactionPageSize, rec.Code, len(page.Transactions), page.Total)
+	}
+}
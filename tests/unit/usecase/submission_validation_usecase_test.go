@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// newSubmissionValidationUseCase builds a use case that validates submissions,
+// with an active, a frozen, an inactive and a EUR account
+func newSubmissionValidationUseCase(validateFunds bool) (*usecase.TransactionUseCase, *MockTransactionRepository, *MockMessageQueue) {
+	accountRepo := NewMockAccountRepository()
+	for _, account := range []*domain.Account{
+		{ID: "active", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive},
+		{ID: "frozen", Balance: 10000, Currency: "USD", Status: domain.AccountStatusFrozen},
+		{ID: "inactive", Balance: 10000, Currency: "USD", Status: domain.AccountStatusInactive},
+		{ID: "euro", Balance: 10000, Currency: "EUR", Status: domain.AccountStatusActive},
+	} {
+		account.Version = 1
+		accountRepo.accounts[account.ID] = account
+	}
+
+	transactionRepo := NewMockTransactionRepository()
+	messageQueue := NewMockMessageQueue()
+	transactionUseCase := usecase.NewTransactionUseCase(
+		accountRepo, transactionRepo, messageQueue, "transactions",
+		usecase.WithSubmissionValidation(validateFunds),
+		usecase.WithDuplicateGuard(NewMockSubmissionGuard(), 10*time.Second),
+	).(*usecase.TransactionUseCase)
+	return transactionUseCase, transactionRepo, messageQueue
+}
+
+func TestProcessTransaction_ValidatesAccountsOnSubmission(t *testing.T) {
+	account := func(id string) *string { return &id }
+	tests := []struct {
+		name    string
+		request *domain.TransactionRequest
+		err     error
+	}{
+		{"missing source", &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: account("missing"), Amount: 100, Currency: "USD"}, domain.ErrAccountNotFound},
+		{"missing destination", &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: account("active"), ToAccountID: account("missing"), Amount: 100, Currency: "USD"}, domain.ErrAccountNotFound},
+		{"frozen source", &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: account("frozen"), Amount: 100, Currency: "USD"}, domain.ErrAccountFrozen},
+		{"inactive destination", &domain.TransactionRequest{Type: domain.TransactionTypeDeposit, ToAccountID: account("inactive"), Amount: 100, Currency: "USD"}, domain.ErrAccountInactive},
+		{"currency mismatch", &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: account("active"), ToAccountID: account("euro"), Amount: 100, Currency: "USD"}, domain.ErrCurrencyMismatch},
+		{"insufficient funds", &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: account("active"), Amount: 20000, Currency: "USD"}, domain.ErrInsufficientFunds},
+		{"frozen destination", &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: account("active"), ToAccountID: account("frozen"), Amount: 100, Currency: "USD"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactionUseCase, transactionRepo, messageQueue := newSubmissionValidationUseCase(true)
+
+			transaction, err := transactionUseCase.ProcessTransaction(context.Background(), tt.request)
+			if tt.err == nil {
+				if err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+				if transaction.Status != domain.TransactionStatusPending {
+					t.Errorf("Expected the transaction pending, got %s", transaction.Status)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected %v, got %v", tt.err, err)
+			}
+			if len(transactionRepo.transactions) != 0 || messageQueue.PublishedCount("transactions") != 0 {
+				t.Error("Expected a rejected submission to be neither stored nor queued")
+			}
+		})
+	}
+}
+
+func TestProcessTransaction_FundsValidationIsOptional(t *testing.T) {
+	from := "active"
+	overdraw := func() *domain.TransactionRequest {
+		return &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: &from, Amount: 20000, Currency: "USD"}
+	}
+
+	transactionUseCase, _, _ := newSubmissionValidationUseCase(false)
+	if _, err := transactionUseCase.ProcessTransaction(context.Background(), overdraw()); err != nil {
+		t.Errorf("Expected the funds check left to processing, got %v", err)
+	}
+
+	// The account may be funded before a scheduled transaction runs
+	transactionUseCase, _, _ = newSubmissionValidationUseCase(true)
+	scheduled := overdraw()
+	at := time.Now().Add(24 * time.Hour)
+	scheduled.ScheduledAt = &at
+	if _, err := transactionUseCase.ProcessTransaction(context.Background(), scheduled); err != nil {
+		t.Errorf("Expected a scheduled transaction not checked for funds, got %v", err)
+	}
+}
+
+func TestProcessTransaction_RejectedSubmissionDoesNotBlockResubmission(t *testing.T) {
+	transactionUseCase, _, _ := newSubmissionValidationUseCase(true)
+	from := "active"
+	request := func(amount domain.Money) *domain.TransactionRequest {
+		return &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: &from, Amount: amount, Currency: "USD"}
+	}
+
+	if _, err := transactionUseCase.ProcessTransaction(context.Background(), request(20000)); !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("Expected %v, got %v", domain.ErrInsufficientFunds, err)
+	}
+	if _, err := transactionUseCase.ProcessTransaction(context.Background(), request(20000)); !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Errorf("Expected the repeat rejected for funds rather than as a duplicate, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestArchiveTransactions_MovesOldFinalTransactions(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-48 * time.Hour)
+	pending := &domain.Compensation{Status: domain.CompensationStatusPending}
+	for id, transaction := range map[string]*domain.Transaction{
+		"completed":          {Status: domain.TransactionStatusCompleted, CreatedAt: old},
+		"failed":             {Status: domain.TransactionStatusFailed, CreatedAt: old.Add(time.Minute)},
+		"reversed":           {Status: domain.TransactionStatusCompleted, CreatedAt: old.Add(2 * time.Minute), ReversalID: "rev", ReversedBy: "rev"},
+		"recent":             {Status: domain.TransactionStatusCompleted, CreatedAt: now.Add(-time.Hour)},
+		"pending":            {Status: domain.TransactionStatusPending, CreatedAt: old},
+		"reversal-in-flight": {Status: domain.TransactionStatusCompleted, CreatedAt: old, ReversalID: "rev-2"},
+		"compensating":       {Status: domain.TransactionStatusFailed, CreatedAt: old, Compensation: pending},
+		"undispatched":       {Status: domain.TransactionStatusCancelled, CreatedAt: old, Outbox: &domain.OutboxMessage{}},
+	} {
+		transaction.ID = id
+		transaction.Type = domain.TransactionTypeDeposit
+		transaction.Amount = 100
+		transaction.Currency = "USD"
+		transactionRepo.transactions[id] = transaction
+	}
+
+	service := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions",
+		usecase.WithClock(func() time.Time { return now }),
+		usecase.WithArchival(24*time.Hour, 2),
+	)
+
+	archived, err := service.(*usecase.TransactionUseCase).ArchiveTransactions(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if archived != 3 {
+		t.Errorf("Expected 3 transactions archived over two batches, got %d", archived)
+	}
+	for id, transaction := range transactionRepo.transactions {
+		expected := id == "completed" || id == "failed" || id == "reversed"
+		if transaction.Archived != expected {
+			t.Errorf("Expected %s archived: %v, got %v", id, expected, transaction.Archived)
+		}
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: service,
+		AdminToken:         ownershipAdminToken,
+	})
+
+	rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions/completed", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["archived"] != true || body["archived_at"] == nil {
+		t.Errorf("Expected the transaction flagged as archived, got %v", body)
+	}
+
+	for _, action := range []string{"reverse", "refund"} {
+		if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/transactions/completed/"+action, ""); rec.Code != http.StatusConflict {
+			t.Errorf("Expected an archived transaction refused a %s, got %d: %s", action, rec.Code, rec.Body)
+		}
+	}
+
+	if archived, err := service.(*usecase.TransactionUseCase).ArchiveTransactions(context.Background()); err != nil || archived != 0 {
+		t.Errorf("Expected nothing left to archive, got %d and %v", archived, err)
+	}
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newExportServer serves alice's summary transactions with the given
+// transaction options
+func newExportServer(opts ...usecase.TransactionOption) *echo.Echo {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 3400, Currency: "USD", Status: domain.AccountStatusActive}
+	seedSummaryTransactions(transactionRepo, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions", opts...),
+		AdminToken:         ownershipAdminToken,
+	})
+	return e
+}
+
+func TestTransactionHandler_ExportCSV(t *testing.T) {
+	e := newExportServer()
+
+	rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice/transactions/export", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	if disposition := rec.Header().Get(echo.HeaderContentDisposition); disposition != `attachment; filename="account-alice-transactions.csv"` {
+		t.Errorf("Expected a CSV attachment, got %q", disposition)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	if len(records) != 7 || strings.Join(records[0], ",") != "id,type,direction,amount,currency,status,counterparty,reference,created_at,processed_at" {
+		t.Fatalf("Expected a header and six rows, got %v", records)
+	}
+	for i, record := range records[1:] {
+		if record[0] != string(rune('a'+i)) {
+			t.Errorf("Expected the rows oldest first, got %s at row %d", record[0], i+1)
+		}
+	}
+	if transfer := records[3]; transfer[2] != "debit" || transfer[3] != "12.00" || transfer[6] != "bob" || transfer[8] != "2026-03-03T12:00:00Z" {
+		t.Errorf("Expected the outgoing transfer to bob, got %v", transfer)
+	}
+}
+
+func TestTransactionHandler_ExportFilters(t *testing.T) {
+	e := newExportServer()
+
+	rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice/transactions/export?format=json-lines&from=2026-03-02&to=2026-03-03&limit=1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	if contentType := rec.Header().Get(echo.HeaderContentType); contentType != "application/x-ndjson" {
+		t.Errorf("Expected JSON lines, got %q", contentType)
+	}
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the two transactions of the period regardless of the limit, got %d lines", len(lines))
+	}
+	var row struct {
+		ID        string                `json:"id"`
+		Direction domain.EntryDirection `json:"direction"`
+		Amount    string                `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		t.Fatalf("Failed to decode line: %v", err)
+	}
+	if row.ID != "b" || row.Direction != domain.EntryDirectionDebit || row.Amount != "7.00" {
+		t.Errorf("Expected the withdrawal first, got %+v", row)
+	}
+
+	rec = principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice/transactions/export?status=failed", "")
+	if records, err := csv.NewReader(rec.Body).ReadAll(); err != nil || len(records) != 2 || records[1][0] != "f" {
+		t.Errorf("Expected only the failed withdrawal, got %v (%v)", records, err)
+	}
+}
+
+func TestTransactionHandler_ExportRefused(t *testing.T) {
+	e := newExportServer(usecase.WithExportMaxRows(3))
+
+	tests := []struct {
+		name      string
+		principal string
+		path      string
+		expected  int
+	}{
+		{"unknown format", "alice", "/api/v1/accounts/alice/transactions/export?format=xml", http.StatusBadRequest},
+		{"invalid from", "alice", "/api/v1/accounts/alice/transactions/export?from=March", http.StatusBadRequest},
+		{"reversed period", "alice", "/api/v1/accounts/alice/transactions/export?from=2026-03-05&to=2026-03-01", http.StatusBadRequest},
+		{"another user", "bob", "/api/v1/accounts/alice/transactions/export", http.StatusForbidden},
+		{"unknown account", "admin", "/api/v1/accounts/nobody/transactions/export", http.StatusNotFound},
+		{"too many rows", "alice", "/api/v1/accounts/alice/transactions/export", http.StatusBadRequest},
+		{"within the cap", "alice", "/api/v1/accounts/alice/transactions/export?from=2026-03-04", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := principalRequest(e, tt.principal, http.MethodGet, tt.path, "")
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/accounts/alice/transactions/export", "")
+	var body struct {
+		Count int64 `json:"count"`
+		Max   int64 `json:"max"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Count != 6 || body.Max != 3 {
+		t.Errorf("Expected the count and cap reported, got %s", rec.Body)
+	}
+}
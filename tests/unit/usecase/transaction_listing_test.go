@@ -0,0 +1,331 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestListTransactions_SortsAndPages(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, amount := range []domain.Money{300, 100, 500, 200, 400} {
+		id := fmt.Sprintf("tx-%d", i+1)
+		transactionRepo.transactions[id] = &domain.Transaction{
+			ID: id, Type: domain.TransactionTypeDeposit, Amount: amount, Currency: "USD",
+			Status: domain.TransactionStatusCompleted, CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		AdminToken:         ownershipAdminToken,
+	})
+
+	tests := []struct {
+		query   string
+		ids     []string
+		offset  int
+		hasMore bool
+	}{
+		{"sort_by=amount&sort_order=asc&limit=2", []string{"tx-2", "tx-4"}, 0, true},
+		{"sort_by=amount&sort_order=asc&limit=2&offset=4", []string{"tx-3"}, 4, false},
+		{"sort_by=amount&limit=2", []string{"tx-3", "tx-5"}, 0, true},
+		{"sort_by=created_at&sort_order=desc&limit=3&offset=2", []string{"tx-3", "tx-2", "tx-1"}, 2, false},
+	}
+
+	for _, tt := range tests {
+		rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?"+tt.query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, tt.query, rec.Code, rec.Body)
+		}
+
+		var body struct {
+			Transactions []*domain.Transaction `json:"transactions"`
+			Total        int64                 `json:"total"`
+			Offset       int                   `json:"offset"`
+			HasMore      bool                  `json:"has_more"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		var ids []string
+		for _, transaction := range body.Transactions {
+			ids = append(ids, transaction.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.ids) {
+			t.Errorf("Expected %v for %s, got %v", tt.ids, tt.query, ids)
+		}
+		if body.Total != 5 || body.Offset != tt.offset || body.HasMore != tt.hasMore {
+			t.Errorf("Expected total 5, offset %d and has_more %v for %s, got %d, %d and %v", tt.offset, tt.hasMore, tt.query, body.Total, body.Offset, body.HasMore)
+		}
+	}
+
+	for _, query := range []string{"sort_by=currency", "sort_order=up"} {
+		if rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s rejected, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+
+	rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?limit=100000", "")
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["limit"] != float64(domain.MaxTransactionPageSize) {
+		t.Errorf("Expected the limit clamped to %d, got %v", domain.MaxTransactionPageSize, body["limit"])
+	}
+}
+
+func TestListTransactions_RejectsMalformedFilters(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		AdminToken:         ownershipAdminToken,
+	})
+
+	tests := []struct {
+		path    string
+		invalid []string
+	}{
+		{"/api/v1/transactions?from_date=2024-13-45", []string{"from_date"}},
+		{"/api/v1/transactions?min_amount=ten&limit=-1&offset=x", []string{"limit", "min_amount", "offset"}},
+		{"/api/v1/transactions?min_amount=20&max_amount=10.50", []string{"max_amount"}},
+		{"/api/v1/transactions?from_date=2024-03-02T00:00:00Z&to_date=2024-03-01T00:00:00Z", []string{"to_date"}},
+		{"/api/v1/transactions?self_transfer=maybe", []string{"self_transfer"}},
+		{"/api/v1/transactions?fields=id,colour", []string{"fields"}},
+		{"/api/v1/transactions?fields=id&exclude=metadata", []string{"exclude"}},
+		{"/api/v1/transactions?exclude=outbox", []string{"exclude"}},
+		{"/api/v1/transactions/history?account_id=alice&to_date=yesterday", []string{"to_date"}},
+		{"/api/v1/accounts/alice/transactions?limit=lots", []string{"limit"}},
+		{"/api/v1/transactions/by-reference/rent?max_amount=1e3", []string{"max_amount"}},
+	}
+
+	for _, tt := range tests {
+		rec := principalRequest(e, "admin", http.MethodGet, tt.path, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusBadRequest, tt.path, rec.Code, rec.Body)
+		}
+
+		var body struct {
+			InvalidParameters map[string]string `json:"invalid_parameters"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		var invalid []string
+		for param, expected := range body.InvalidParameters {
+			if expected == "" {
+				t.Errorf("Expected the format %s takes for %s", param, tt.path)
+			}
+			invalid = append(invalid, param)
+		}
+		sort.Strings(invalid)
+		if fmt.Sprint(invalid) != fmt.Sprint(tt.invalid) {
+			t.Errorf("Expected %v flagged for %s, got %v", tt.invalid, tt.path, invalid)
+		}
+	}
+
+	if rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?colour=blue&min_amount=1&max_amount=1", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected unknown parameters ignored, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestListTransactions_FiltersByCurrencyReferenceAndMetadata(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	for id, transaction := range map[string]*domain.Transaction{
+		"match":          {Currency: "EUR", Reference: "INV-2024-0042", Metadata: map[string]interface{}{"order_id": "123"}},
+		"other-currency": {Currency: "USD", Reference: "INV-2024-0043", Metadata: map[string]interface{}{"order_id": "123"}},
+		"other-year":     {Currency: "EUR", Reference: "INV-2023-0001", Metadata: map[string]interface{}{"order_id": "123"}},
+		"other-order":    {Currency: "EUR", Reference: "INV-2024-0044", Metadata: map[string]interface{}{"order_id": "124"}},
+		"numeric-order":  {Currency: "EUR", Reference: "INV-2024-0045", Metadata: map[string]interface{}{"order_id": 123}},
+	} {
+		transaction.ID = id
+		transaction.Type = domain.TransactionTypeTransfer
+		transaction.Status = domain.TransactionStatusCompleted
+		transactionRepo.transactions[id] = transaction
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		AdminToken:         ownershipAdminToken,
+	})
+
+	tests := []struct {
+		query string
+		ids   []string
+	}{
+		{"currency=eur&reference=INV-2024-*&metadata.order_id=123", []string{"match"}},
+		{"currency=EUR&reference=INV-2024-*", []string{"match", "numeric-order", "other-order"}},
+		{"reference=INV-2024-0043", []string{"other-currency"}},
+		{"metadata.order_id=123&sort_by=created_at", []string{"match", "other-currency", "other-year"}},
+	}
+
+	for _, tt := range tests {
+		rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?"+tt.query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, tt.query, rec.Code, rec.Body)
+		}
+
+		var body struct {
+			Transactions []*domain.Transaction `json:"transactions"`
+			Total        int64                 `json:"total"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		var ids []string
+		for _, transaction := range body.Transactions {
+			ids = append(ids, transaction.ID)
+		}
+		sort.Strings(ids)
+		if fmt.Sprint(ids) != fmt.Sprint(tt.ids) || body.Total != int64(len(tt.ids)) {
+			t.Errorf("Expected %v for %s, got %v of %d", tt.ids, tt.query, ids, body.Total)
+		}
+	}
+
+	for _, query := range []string{"currency=XYZ", "metadata.order.id=1", "metadata.$where=1", "metadata.a=1&metadata.a=2"} {
+		if rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s rejected, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestListTransactions_SelectsFields(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	transactionRepo.transactions["heavy"] = &domain.Transaction{
+		ID: "heavy", Type: domain.TransactionTypeDeposit, Amount: 1250, Currency: "USD", Status: domain.TransactionStatusFailed,
+		Description: "Payroll", ErrorMessage: "account frozen", Metadata: map[string]interface{}{"payload": "large"},
+		CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	transactionService := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions")
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: transactionService,
+		AdminToken:         ownershipAdminToken,
+	})
+
+	// The default view never decodes the heavyweight fields
+	page, err := transactionService.ListTransactions(context.Background(), &domain.TransactionFilter{Projection: domain.DefaultTransactionProjection()})
+	if err != nil || len(page.Transactions) != 1 {
+		t.Fatalf("Expected the transaction listed, got %v", err)
+	}
+	if listed := page.Transactions[0]; listed.Metadata != nil || listed.ErrorMessage != "" || listed.Description != "Payroll" {
+		t.Errorf("Expected the metadata and error message left unread, got %+v", listed)
+	}
+
+	fields := func(query string) map[string]json.RawMessage {
+		t.Helper()
+		rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions"+query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %q, got %d: %s", http.StatusOK, query, rec.Code, rec.Body)
+		}
+		var body struct {
+			Transactions []map[string]json.RawMessage `json:"transactions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(body.Transactions) != 1 {
+			t.Fatalf("Expected one transaction for %q, got %d", query, len(body.Transactions))
+		}
+		return body.Transactions[0]
+	}
+	names := func(fields map[string]json.RawMessage) []string {
+		var names []string
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	listed := fields("")
+	if _, ok := listed["metadata"]; ok || listed["error_message"] != nil || listed["description"] == nil {
+		t.Errorf("Expected the default view without metadata or error_message, got %v", names(listed))
+	}
+
+	listed = fields("?fields=id,amount,status")
+	if fmt.Sprint(names(listed)) != "[amount id status]" || string(listed["amount"]) != `"12.50"` {
+		t.Errorf("Expected only the selected fields with the amount formatted, got %v", listed)
+	}
+
+	if listed = fields("?fields=id,metadata"); fmt.Sprint(names(listed)) != "[id metadata]" {
+		t.Errorf("Expected metadata returned when selected, got %v", names(listed))
+	}
+
+	listed = fields("?exclude=")
+	if listed["metadata"] == nil || listed["error_message"] == nil {
+		t.Errorf("Expected every field with nothing excluded, got %v", names(listed))
+	}
+
+	rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions/heavy", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"metadata"`) {
+		t.Errorf("Expected the single transaction returned whole, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestTransactionHistory_UnknownAccount(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	service := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions")
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: service,
+		AdminToken:         ownershipAdminToken,
+	})
+
+	for _, path := range []string{"/api/v1/accounts/%s/transactions", "/api/v1/transactions/history?account_id=%s"} {
+		rec := principalRequest(e, "admin", http.MethodGet, fmt.Sprintf(path, "nonexistent"), "")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for an unknown account on %s, got %d: %s", http.StatusNotFound, path, rec.Code, rec.Body)
+		}
+
+		rec = principalRequest(e, "admin", http.MethodGet, fmt.Sprintf(path, "alice"), "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for an empty account on %s, got %d: %s", http.StatusOK, path, rec.Code, rec.Body)
+		}
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if string(body["transactions"]) != "[]" {
+			t.Errorf("Expected an empty array on %s, got %s", path, body["transactions"])
+		}
+	}
+
+	if _, err := service.GetTransactionHistory(context.Background(), "nonexistent", nil); err != domain.ErrAccountNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrAccountNotFound, err)
+	}
+	if history, err := service.GetTransactionHistory(context.Background(), "alice", nil); err != nil || history == nil || len(history) != 0 {
+		t.Errorf("Expected an empty history, got %v and %v", history, err)
+	}
+}
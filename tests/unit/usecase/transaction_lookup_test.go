@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestLookupTransactions_Endpoint(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	to := "alice"
+	for _, id := range []string{"tx-1", "tx-2", "tx-3"} {
+		transactionRepo.transactions[id] = &domain.Transaction{
+			ID: id, Type: domain.TransactionTypeDeposit, ToAccountID: &to, Amount: 100, Currency: "USD", Status: domain.TransactionStatusCompleted,
+			BalanceChanges: []*domain.BalanceChange{{AccountID: "alice", BalanceBefore: 0, BalanceAfter: 100, Currency: "USD"}},
+		}
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		AdminToken:         ownershipAdminToken,
+	})
+
+	for principal, visible := range map[string]bool{"admin": true, "alice": false} {
+		rec := principalRequest(e, principal, http.MethodPost, "/api/v1/transactions/lookup", `{"ids":["tx-3","missing","tx-1","tx-3"]}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+
+		var body struct {
+			Transactions map[string]*domain.Transaction `json:"transactions"`
+			Ordered      []*domain.Transaction          `json:"ordered"`
+			Missing      []string                       `json:"missing"`
+			Count        int                            `json:"count"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if body.Count != 2 || len(body.Ordered) != 2 || body.Ordered[0].ID != "tx-3" || body.Ordered[1].ID != "tx-1" {
+			t.Fatalf("Expected tx-3 then tx-1, got %d transactions", len(body.Ordered))
+		}
+		if len(body.Transactions) != 2 || body.Transactions["tx-1"] == nil || body.Transactions["tx-3"] == nil {
+			t.Errorf("Expected both transactions by ID, got %v", body.Transactions)
+		}
+		if fmt.Sprint(body.Missing) != "[missing]" {
+			t.Errorf("Expected the missing ID listed, got %v", body.Missing)
+		}
+		for _, transaction := range append(body.Ordered, body.Transactions["tx-1"]) {
+			if shown := len(transaction.BalanceChanges) > 0; shown != visible {
+				t.Errorf("Expected balance changes shown to %s: %v, got %v", principal, visible, shown)
+			}
+		}
+	}
+
+	ids := make([]string, domain.MaxTransactionLookupIDs+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%q", fmt.Sprintf("tx-%d", i))
+	}
+	tests := []struct {
+		body     string
+		expected int
+	}{
+		{`{"ids":[` + strings.Join(ids, ",") + `]}`, http.StatusRequestEntityTooLarge},
+		{`{"ids":[]}`, http.StatusBadRequest},
+		{`{"ids":["tx-1"," "]}`, http.StatusBadRequest},
+		{`{"ids":"tx-1"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/transactions/lookup", tt.body); rec.Code != tt.expected {
+			t.Errorf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body)
+		}
+	}
+
+	rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/transactions/lookup", `{"ids":[`+strings.Join(ids[:domain.MaxTransactionLookupIDs], ",")+`]}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected %d IDs accepted, got %d: %s", domain.MaxTransactionLookupIDs, rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newReceiptRoutes serves a completed transfer from alice to bob with its
+// fee, and a pending one, signing receipts with secret
+func newReceiptRoutes(t *testing.T, secret string) *echo.Echo {
+	t.Helper()
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice-usd"] = &domain.Account{ID: "alice-usd", UserID: "alice", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["bob-usd"] = &domain.Account{ID: "bob-usd", UserID: "bob", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	alice, bob, fees := "alice-usd", "bob-usd", "fees"
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	processed := created.Add(time.Second)
+	for _, transaction := range []*domain.Transaction{
+		{ID: "rent", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 125050,
+			Status: domain.TransactionStatusCompleted, Description: "Rent March", Reference: "LEASE-12", ProcessedAt: &processed},
+		{ID: usecase.FeeTransactionID("rent"), Type: domain.TransactionTypeFee, FromAccountID: &alice, ToAccountID: &fees, Amount: 50,
+			Status: domain.TransactionStatusCompleted},
+		{ID: "queued", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 100,
+			Status: domain.TransactionStatusPending},
+	} {
+		transaction.Currency = "USD"
+		transaction.CreatedAt = created
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions", usecase.WithReceiptSecret(secret)),
+		AdminToken:         ownershipAdminToken,
+	})
+	return e
+}
+
+func TestTransactionReceipt_Endpoints(t *testing.T) {
+	e := newReceiptRoutes(t, "receipt-secret")
+
+	rec := principalRequest(e, "bob", http.MethodGet, "/api/v1/transactions/rent/receipt", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	issued := rec.Body.String()
+	var receipt domain.Receipt
+	if err := json.Unmarshal(rec.Body.Bytes(), &receipt); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if receipt.Amount != "1250.50" || receipt.Currency != "USD" || receipt.Status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the completed amount formatted in USD, got %s %s (%s)", receipt.Amount, receipt.Currency, receipt.Status)
+	}
+	if receipt.From == nil || receipt.From.UserID != "alice" || receipt.To == nil || receipt.To.AccountID != "bob-usd" || receipt.To.UserID != "bob" {
+		t.Errorf("Expected alice and bob as the parties, got %+v and %+v", receipt.From, receipt.To)
+	}
+	if len(receipt.Fees) != 1 || receipt.Fees[0].Amount != "0.50" || receipt.Fees[0].TransactionID != "rent-fee" {
+		t.Errorf("Expected the 0.50 fee listed, got %+v", receipt.Fees)
+	}
+	if len(receipt.Hash) != 64 || receipt.ProcessedAt == nil {
+		t.Errorf("Expected a verification hash and processing time, got %q and %v", receipt.Hash, receipt.ProcessedAt)
+	}
+
+	verify := func(body string) bool {
+		t.Helper()
+		rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/receipts/verify", body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		var result struct {
+			Valid bool `json:"valid"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return result.Valid
+	}
+	if !verify(issued) {
+		t.Errorf("Expected the receipt as issued to verify")
+	}
+	if !verify(strings.Replace(issued, receipt.IssuedAt.Format(time.RFC3339Nano), "2030-01-01T00:00:00Z", 1)) {
+		t.Errorf("Expected the issue time left out of the hash")
+	}
+	for _, tampered := range []string{
+		strings.Replace(issued, `"1250.50"`, `"12.50"`, 1),
+		strings.Replace(issued, `"user_id":"bob"`, `"user_id":"carol"`, 1),
+		strings.Replace(issued, `"0.50"`, `"0.00"`, 1),
+	} {
+		if verify(tampered) {
+			t.Errorf("Expected a tampered receipt refused: %s", tampered)
+		}
+	}
+	if !strings.Contains(issued, `"2024-03-01T09:30:00+01:00"`) {
+		t.Fatalf("Expected the creation time as stored, got %s", issued)
+	}
+	if !verify(strings.Replace(issued, `"2024-03-01T09:30:00+01:00"`, `"2024-03-01T08:30:00Z"`, 1)) {
+		t.Errorf("Expected the same instant in UTC to verify")
+	}
+
+	rejected := []struct {
+		principal, method, path, body string
+		expected                      int
+	}{
+		{"admin", http.MethodGet, "/api/v1/transactions/queued/receipt", "", http.StatusConflict},
+		{"admin", http.MethodGet, "/api/v1/transactions/missing/receipt", "", http.StatusNotFound},
+		{"carol", http.MethodGet, "/api/v1/transactions/rent/receipt", "", http.StatusForbidden},
+		{"admin", http.MethodPost, "/api/v1/receipts/verify", `{"transaction_id":"rent"}`, http.StatusBadRequest},
+	}
+	for _, tt := range rejected {
+		if rec := principalRequest(e, tt.principal, tt.method, tt.path, tt.body); rec.Code != tt.expected {
+			t.Errorf("Expected %d for %s %s, got %d: %s", tt.expected, tt.method, tt.path, rec.Code, rec.Body)
+		}
+	}
+
+	// A receipt signed under another secret does not verify
+	other := newReceiptRoutes(t, "another-secret")
+	if rec := principalRequest(other, "admin", http.MethodPost, "/api/v1/receipts/verify", issued); !strings.Contains(rec.Body.String(), `"valid":false`) {
+		t.Errorf("Expected the receipt refused under another secret, got %s", rec.Body)
+	}
+
+	unsigned := newReceiptRoutes(t, "")
+	if rec := principalRequest(unsigned, "admin", http.MethodGet, "/api/v1/transactions/rent/receipt", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected receipts unavailable without a secret, got %d: %s", rec.Code, rec.Body)
+	}
+}
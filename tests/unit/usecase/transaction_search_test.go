@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// newSearchRoutes serves transactions described for a search: rent for two
+// months from alice, and one from bob
+func newSearchRoutes(t *testing.T) *echo.Echo {
+	t.Helper()
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	alice, bob := "alice", "bob"
+	for id, transaction := range map[string]*domain.Transaction{
+		"rent-march":    {FromAccountID: &alice, Description: "Rent March", Reference: "LEASE-12"},
+		"rent-april":    {FromAccountID: &alice, Description: "Rent April", Reference: "LEASE-12"},
+		"groceries":     {FromAccountID: &alice, Description: "Groceries in March"},
+		"bob-rent":      {FromAccountID: &bob, Description: "Rent March"},
+		"no-such-words": {FromAccountID: &alice, Description: "Salary"},
+	} {
+		transaction.ID = id
+		transaction.Type = domain.TransactionTypeWithdrawal
+		transaction.Currency = "USD"
+		transaction.Status = domain.TransactionStatusCompleted
+		transactionRepo.transactions[id] = transaction
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		AdminToken:         ownershipAdminToken,
+	})
+	return e
+}
+
+func TestSearchTransactions_Endpoint(t *testing.T) {
+	e := newSearchRoutes(t)
+
+	rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions/search?q=rent+march&account_id=alice", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var body struct {
+		Results []struct {
+			Transaction *domain.Transaction `json:"transaction"`
+			Score       float64             `json:"score"`
+		} `json:"results"`
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	var ids []string
+	for _, result := range body.Results {
+		if result.Score <= 0 {
+			t.Errorf("Expected a relevance score for %s, got %v", result.Transaction.ID, result.Score)
+		}
+		ids = append(ids, result.Transaction.ID)
+	}
+	if body.Count != 3 || len(ids) != 3 || ids[0] != "rent-march" {
+		t.Errorf("Expected alice's three matches with March rent first, got %v", ids)
+	}
+
+	for _, query := range []string{"", "q=", "q=re", "q=++r++"} {
+		if rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions/search?"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected %q rejected, got %d: %s", query, rec.Code, rec.Body)
+		}
+	}
+	if rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions/search?q=rent&limit=-1", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a malformed filter rejected, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/transactions/search?q=rent", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a user refused, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestSearchTransactions_ClampsLimit(t *testing.T) {
+	transactionRepo := NewMockTransactionRepository()
+	for i := 0; i < domain.MaxTransactionPageSize+5; i++ {
+		id := fmt.Sprintf("rent-%d", i)
+		transactionRepo.transactions[id] = &domain.Transaction{ID: id, Description: "Rent"}
+	}
+	service := usecase.NewTransactionUseCase(NewMockAccountRepository(), transactionRepo, NewMockMessageQueue(), "transactions")
+
+	filter := &domain.TransactionFilter{Limit: 100000}
+	matches, err := service.SearchTransactions(context.Background(), " rent ", filter)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(matches) != domain.MaxTransactionPageSize || filter.Limit != domain.MaxTransactionPageSize {
+		t.Errorf("Expected the page clamped to %d, got %d", domain.MaxTransactionPageSize, len(matches))
+	}
+
+	if _, err := service.SearchTransactions(context.Background(), "ab ", &domain.TransactionFilter{}); !errors.Is(err, domain.ErrSearchQueryTooShort) {
+		t.Errorf("Expected %v, got %v", domain.ErrSearchQueryTooShort, err)
+	}
+}
@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MockQueueStatsStore is a mock implementation of QueueStatsStore
+type MockQueueStatsStore struct {
+	mu        sync.Mutex
+	sequences map[string]int64
+	rates     map[string]*domain.ProcessingRate
+}
+
+func NewMockQueueStatsStore() *MockQueueStatsStore {
+	return &MockQueueStatsStore{
+		sequences: make(map[string]int64),
+		rates:     make(map[string]*domain.ProcessingRate),
+	}
+}
+
+func (m *MockQueueStatsStore) NextSequence(ctx context.Context, queue string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sequences[queue]++
+	return m.sequences[queue], nil
+}
+
+func (m *MockQueueStatsStore) SaveProcessingRate(ctx context.Context, rate *domain.ProcessingRate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	saved := *rate
+	m.rates[rate.Queue+":"+rate.Worker] = &saved
+	return nil
+}
+
+func (m *MockQueueStatsStore) ProcessingRates(ctx context.Context, queue string, since time.Time) ([]*domain.ProcessingRate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rates []*domain.ProcessingRate
+	for _, rate := range m.rates {
+		if rate.Queue == queue && !rate.UpdatedAt.Before(since) {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+func TestTransactionStatus_QueueSequenceAndRate(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	messageQueue := NewMockMessageQueue()
+	stats := NewMockQueueStatsStore()
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, messageQueue, "transactions",
+		usecase.WithQueueStats(stats)).(*usecase.TransactionUseCase)
+	if _, err := transactionUseCase.StartTransactionProcessor(context.Background()); err != nil {
+		t.Fatalf("Failed to start processor: %v", err)
+	}
+
+	toID := "acc-1"
+	for i, want := range []int64{1, 2} {
+		deposit, err := transactionUseCase.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+			Type: domain.TransactionTypeDeposit, ToAccountID: &toID, Amount: 1000, Currency: "USD",
+		})
+		if err != nil {
+			t.Fatalf("Expected deposit %d to be accepted, got %v", i, err)
+		}
+		if got := transactionRepo.transactions[deposit.ID].QueueSequence; got != want {
+			t.Errorf("Expected deposit %d numbered %d in the queue, got %d", i, want, got)
+		}
+	}
+
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected deposits to complete, got %v", errs)
+	}
+	if err := transactionUseCase.PublishProcessingRate(context.Background()); err != nil {
+		t.Fatalf("Expected processing rate to be published, got %v", err)
+	}
+	rates, _ := stats.ProcessingRates(context.Background(), "transactions", time.Now().Add(-time.Minute))
+	if len(rates) != 1 || rates[0].Samples != 2 || rates[0].Worker == "" {
+		t.Errorf("Expected one worker's rate counting both deposits, got %+v", rates)
+	}
+}
+
+func TestTransactionStatus_PendingEstimate(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	stats := NewMockQueueStatsStore()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	toID := "acc-1"
+	processed := now.Add(-time.Minute)
+	for i, transaction := range []*domain.Transaction{
+		{ID: "first", Status: domain.TransactionStatusPending},
+		{ID: "second", Status: domain.TransactionStatusPending},
+		{ID: "done", Status: domain.TransactionStatusCompleted, ProcessedAt: &processed},
+		{ID: "third", Status: domain.TransactionStatusPending, QueueSequence: 7},
+		{ID: "later", Status: domain.TransactionStatusPending},
+	} {
+		transaction.Type = domain.TransactionTypeDeposit
+		transaction.ToAccountID = &toID
+		transaction.Amount = 1000
+		transaction.Currency = "USD"
+		transaction.CreatedAt = now.Add(time.Duration(i-10) * time.Second)
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions",
+			usecase.WithQueueStats(stats), usecase.WithClock(func() time.Time { return now })),
+		AdminToken: ownershipAdminToken,
+	})
+
+	status := func(id string) *domain.TransactionStatusReport {
+		t.Helper()
+		rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions/"+id+"/status", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		var report domain.TransactionStatusReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return &report
+	}
+
+	report := status("third")
+	if report.Status != domain.TransactionStatusPending || report.QueueSequence != 7 || report.Queue == nil {
+		t.Fatalf("Expected a queue estimate for the pending transaction, got %+v", report)
+	}
+	if report.Queue.Ahead != 2 || report.Queue.Position != 3 || !report.Queue.Approximate || report.Queue.Note == "" {
+		t.Errorf("Expected two earlier pending transactions ahead, labeled approximate, got %+v", report.Queue)
+	}
+	if report.Queue.EstimatedWaitSeconds != nil || report.Queue.EstimatedCompletionAt != nil {
+		t.Errorf("Expected no wait without a processing rate, got %+v", report.Queue)
+	}
+
+	// A stale rate is ignored; the fresh ones are summed
+	stats.SaveProcessingRate(context.Background(), &domain.ProcessingRate{Queue: "transactions", Worker: "old", PerSecond: 100, UpdatedAt: now.Add(-time.Hour)})
+	stats.SaveProcessingRate(context.Background(), &domain.ProcessingRate{Queue: "transactions", Worker: "a", PerSecond: 0.25, UpdatedAt: now})
+	stats.SaveProcessingRate(context.Background(), &domain.ProcessingRate{Queue: "transactions", Worker: "b", PerSecond: 0.25, UpdatedAt: now})
+
+	report = status("third")
+	if report.Queue.ProcessingRate == nil || *report.Queue.ProcessingRate != 0.5 {
+		t.Fatalf("Expected a combined rate of 0.5 per second, got %+v", report.Queue)
+	}
+	if report.Queue.EstimatedWaitSeconds == nil || *report.Queue.EstimatedWaitSeconds != 6 ||
+		report.Queue.EstimatedCompletionAt == nil || !report.Queue.EstimatedCompletionAt.Equal(now.Add(6*time.Second)) {
+		t.Errorf("Expected a 6 second wait, got %+v", report.Queue)
+	}
+
+	report = status("done")
+	if report.Status != domain.TransactionStatusCompleted || report.ProcessedAt == nil || report.Queue != nil {
+		t.Errorf("Expected only the status and processing time of a completed transaction, got %+v", report)
+	}
+
+	rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions/missing/status", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
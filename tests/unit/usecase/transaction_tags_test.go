@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestTransactionTags_Endpoints(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:     usecase.NewAccountUseCase(accountRepo, transactionRepo),
+		TransactionService: usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions"),
+		AdminToken:         ownershipAdminToken,
+	})
+
+	rec := principalRequest(e, "admin", http.MethodPost, "/api/v1/transactions",
+		`{"type":"deposit","to_account_id":"alice","amount":"2500.00","currency":"USD","tags":["Salary"," salary","Income"]}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body)
+	}
+	var submitted domain.Transaction
+	if err := json.Unmarshal(rec.Body.Bytes(), &submitted); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if fmt.Sprint(submitted.Tags) != "[salary income]" {
+		t.Errorf("Expected the tags normalized, got %v", submitted.Tags)
+	}
+
+	rec = principalRequest(e, "admin", http.MethodPost, "/api/v1/transactions",
+		`{"type":"deposit","to_account_id":"alice","amount":"1.00","currency":"USD","tags":["rent;"]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid tag rejected, got %d: %s", rec.Code, rec.Body)
+	}
+
+	for id, tags := range map[string][]string{
+		"groceries": {"groceries", "food"},
+		"takeaway":  {"food"},
+		"rent":      {"rent"},
+	} {
+		transactionRepo.transactions[id] = &domain.Transaction{
+			ID: id, Type: domain.TransactionTypeWithdrawal, Amount: 100, Currency: "USD", Status: domain.TransactionStatusCompleted, Tags: tags,
+		}
+	}
+
+	rec = principalRequest(e, "admin", http.MethodPatch, "/api/v1/transactions/rent/tags", `{"tags":["Housing","rent"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	rent := transactionRepo.transactions["rent"]
+	if fmt.Sprint(rent.Tags) != "[housing rent]" || rent.Status != domain.TransactionStatusCompleted || rent.Amount != 100 {
+		t.Errorf("Expected only the tags changed, got %v, %s and %d", rent.Tags, rent.Status, rent.Amount)
+	}
+
+	tests := []struct {
+		query string
+		ids   []string
+	}{
+		{"tags=food", []string{"groceries", "takeaway"}},
+		{"tags=food,groceries", []string{"groceries"}},
+		{"tags=Food&tags=groceries", []string{"groceries"}},
+		{"tags_any=groceries,housing", []string{"groceries", "rent"}},
+		{"tags=food&tags_any=rent", nil},
+	}
+	for _, tt := range tests {
+		rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/transactions?type=withdrawal&"+tt.query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, tt.query, rec.Code, rec.Body)
+		}
+		var body struct {
+			Transactions []*domain.Transaction `json:"transactions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		var ids []string
+		for _, transaction := range body.Transactions {
+			ids = append(ids, transaction.ID)
+		}
+		sort.Strings(ids)
+		if fmt.Sprint(ids) != fmt.Sprint(tt.ids) {
+			t.Errorf("Expected %v for %s, got %v", tt.ids, tt.query, ids)
+		}
+	}
+
+	rejected := []struct {
+		method, path, body string
+		expected           int
+	}{
+		{http.MethodPatch, "/api/v1/transactions/rent/tags", `{"tags":["rent"],"amount":"1.00"}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/v1/transactions/rent/tags", `{"status":"failed"}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/v1/transactions/rent/tags", `{"tags":["a","b","c","d","e","f","g","h","i","j","k"]}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/v1/transactions/missing/tags", `{"tags":["rent"]}`, http.StatusNotFound},
+		{http.MethodGet, "/api/v1/transactions?tags=a,,b", "", http.StatusBadRequest},
+	}
+	for _, tt := range rejected {
+		if rec := principalRequest(e, "admin", tt.method, tt.path, tt.body); rec.Code != tt.expected {
+			t.Errorf("Expected %d for %s %s, got %d: %s", tt.expected, tt.path, tt.body, rec.Code, rec.Body)
+		}
+	}
+
+	rec = principalRequest(e, "admin", http.MethodPatch, "/api/v1/transactions/rent/tags", `{"tags":[]}`)
+	if rec.Code != http.StatusOK || transactionRepo.transactions["rent"].Tags != nil {
+		t.Errorf("Expected the tags removed, got %d and %v", rec.Code, transactionRepo.transactions["rent"].Tags)
+	}
+}
@@ -0,0 +1,1070 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockMessageQueue implements domain.MessageQueue for testing
+type MockMessageQueue struct {
+	mu        sync.Mutex
+	published map[string][][]byte
+	handlers  map[string]func(context.Context, []byte) error
+	// publishErr, when set, fails every publish as an unreachable broker would
+	publishErr error
+}
+
+func NewMockMessageQueue() *MockMessageQueue {
+	return &MockMessageQueue{
+		published: make(map[string][][]byte),
+		handlers:  make(map[string]func(context.Context, []byte) error),
+	}
+}
+
+func (m *MockMessageQueue) Publish(ctx context.Context, queueName string, message []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.publishErr != nil {
+		return m.publishErr
+	}
+	m.published[queueName] = append(m.published[queueName], message)
+	return nil
+}
+
+func (m *MockMessageQueue) Subscribe(ctx context.Context, queueName string, handler func(context.Context, []byte) error) (domain.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[queueName] = handler
+	return mockSubscription{}, nil
+}
+
+// mockSubscription has nothing in hand, as messages are only handled by Deliver
+type mockSubscription struct{}
+
+func (mockSubscription) Drain(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *MockMessageQueue) Close() error {
+	return nil
+}
+
+func (m *MockMessageQueue) PublishedCount(queueName string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.published[queueName])
+}
+
+// Deliver hands every message published to the queue to its subscriber and
+// returns the handler errors
+func (m *MockMessageQueue) Deliver(queueName string) []error {
+	m.mu.Lock()
+	messages := m.published[queueName]
+	m.published[queueName] = nil
+	handler := m.handlers[queueName]
+	m.mu.Unlock()
+
+	var errs []error
+	for _, message := range messages {
+		if err := handler(context.Background(), message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func TestTransactionUseCase_ProcessVerification(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	queue := NewMockMessageQueue()
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, queue, "transactions")
+
+	accountRepo.accounts["active-usd"] = &domain.Account{ID: "active-usd", UserID: "user1", Balance: 250.0, Currency: "USD", Status: "active", Version: 1}
+	accountRepo.accounts["inactive-usd"] = &domain.Account{ID: "inactive-usd", UserID: "user2", Balance: 80.0, Currency: "USD", Status: "inactive", Version: 1}
+
+	tests := []struct {
+		name           string
+		accountID      string
+		currency       string
+		expectedStatus domain.TransactionStatus
+		expectedCode   domain.FailureCode
+	}{
+		{
+			name:           "active account passes",
+			accountID:      "active-usd",
+			currency:       "USD",
+			expectedStatus: domain.TransactionStatusCompleted,
+		},
+		{
+			name:           "inactive account fails",
+			accountID:      "inactive-usd",
+			currency:       "USD",
+			expectedStatus: domain.TransactionStatusFailed,
+			expectedCode:   domain.FailureCodeAccountInactive,
+		},
+		{
+			name:           "currency mismatch fails",
+			accountID:      "active-usd",
+			currency:       "EUR",
+			expectedStatus: domain.TransactionStatusFailed,
+			expectedCode:   domain.FailureCodeCurrencyMismatch,
+		},
+		{
+			name:           "unknown account fails",
+			accountID:      "missing",
+			currency:       "USD",
+			expectedStatus: domain.TransactionStatusFailed,
+			expectedCode:   domain.FailureCodeAccountNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountID := tt.accountID
+			transaction, err := transactionUseCase.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+				Type:          domain.TransactionTypeVerification,
+				FromAccountID: &accountID,
+				Currency:      tt.currency,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+
+			if transaction.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, transaction.Status)
+			}
+			if transaction.FailureCode != tt.expectedCode {
+				t.Errorf("Expected failure code %q, got %q", tt.expectedCode, transaction.FailureCode)
+			}
+			if transaction.ProcessedAt == nil {
+				t.Errorf("Expected verification to be processed immediately")
+			}
+		})
+	}
+
+	if balance := accountRepo.accounts["active-usd"].Balance; balance != 250.0 {
+		t.Errorf("Expected verification to leave balance at 250.0, got %d", balance)
+	}
+	if version := accountRepo.accounts["active-usd"].Version; version != 1 {
+		t.Errorf("Expected verification to leave version at 1, got %d", version)
+	}
+	if published := queue.PublishedCount("transactions"); published != 0 {
+		t.Errorf("Expected verifications not to be queued, got %d messages", published)
+	}
+}
+
+// MockSubmissionGuard implements domain.SubmissionGuard for testing
+type MockSubmissionGuard struct {
+	mu     sync.Mutex
+	guards map[string]mockGuard
+}
+
+type mockGuard struct {
+	transactionID string
+	createdAt     time.Time
+}
+
+func NewMockSubmissionGuard() *MockSubmissionGuard {
+	return &MockSubmissionGuard{
+		guards: make(map[string]mockGuard),
+	}
+}
+
+func (m *MockSubmissionGuard) Claim(ctx context.Context, fingerprint, transactionID string, now time.Time, window time.Duration) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, exists := m.guards[fingerprint]
+	if exists && existing.createdAt.After(now.Add(-window)) {
+		return existing.transactionID, nil
+	}
+	m.guards[fingerprint] = mockGuard{transactionID: transactionID, createdAt: now}
+	return "", nil
+}
+
+func (m *MockSubmissionGuard) Takeover(ctx context.Context, fingerprint, previousID, transactionID string, now time.Time) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing := m.guards[fingerprint]
+	if existing.transactionID != previousID {
+		return existing.transactionID, nil
+	}
+	m.guards[fingerprint] = mockGuard{transactionID: transactionID, createdAt: now}
+	return "", nil
+}
+
+func newDuplicateTestUseCase(clock *time.Time) (domain.TransactionService, *MockTransactionRepository) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	transactionUseCase := usecase.NewTransactionUseCase(
+		accountRepo,
+		transactionRepo,
+		NewMockMessageQueue(),
+		"transactions",
+		usecase.WithDuplicateGuard(NewMockSubmissionGuard(), 10*time.Second),
+		usecase.WithClock(func() time.Time { return *clock }),
+	)
+	return transactionUseCase, transactionRepo
+}
+
+func duplicateTestRequest() *domain.TransactionRequest {
+	from, to := "acc-1", "acc-2"
+	return &domain.TransactionRequest{
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &from,
+		ToAccountID:   &to,
+		Amount:        25.0,
+		Currency:      "USD",
+	}
+}
+
+func TestTransactionUseCase_DuplicateSubmissionWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		elapsed     time.Duration
+		expectError bool
+	}{
+		{"immediate resubmission", 0, true},
+		{"just inside the window", 10*time.Second - time.Millisecond, true},
+		{"exactly at the window", 10 * time.Second, false},
+		{"outside the window", time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+			transactionUseCase, _ := newDuplicateTestUseCase(&clock)
+
+			first, err := transactionUseCase.ProcessTransaction(context.Background(), duplicateTestRequest())
+			if err != nil {
+				t.Fatalf("Expected first submission to succeed, got %v", err)
+			}
+
+			clock = clock.Add(tt.elapsed)
+			_, err = transactionUseCase.ProcessTransaction(context.Background(), duplicateTestRequest())
+
+			if !tt.expectError {
+				if err != nil {
+					t.Errorf("Expected no error but got %v", err)
+				}
+				return
+			}
+
+			var duplicateErr *domain.DuplicateTransactionError
+			if !errors.As(err, &duplicateErr) {
+				t.Fatalf("Expected duplicate error, got %v", err)
+			}
+			if duplicateErr.ExistingTransactionID != first.ID {
+				t.Errorf("Expected duplicate to point at %s, got %s", first.ID, duplicateErr.ExistingTransactionID)
+			}
+		})
+	}
+}
+
+func TestTransactionUseCase_DuplicateSubmissionOverrides(t *testing.T) {
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	transactionUseCase, transactionRepo := newDuplicateTestUseCase(&clock)
+
+	first, err := transactionUseCase.ProcessTransaction(context.Background(), duplicateTestRequest())
+	if err != nil {
+		t.Fatalf("Expected first submission to succeed, got %v", err)
+	}
+
+	allowed := duplicateTestRequest()
+	allowed.AllowDuplicate = true
+	if _, err := transactionUseCase.ProcessTransaction(context.Background(), allowed); err != nil {
+		t.Errorf("Expected allow_duplicate to bypass detection, got %v", err)
+	}
+
+	different := duplicateTestRequest()
+	different.Reference = "INV-2"
+	if _, err := transactionUseCase.ProcessTransaction(context.Background(), different); err != nil {
+		t.Errorf("Expected a different reference not to be a duplicate, got %v", err)
+	}
+
+	// Once the earlier transaction has settled it no longer blocks a repeat
+	transactionRepo.UpdateStatus(context.Background(), first.ID, domain.TransactionStatusCompleted, "")
+	if _, err := transactionUseCase.ProcessTransaction(context.Background(), duplicateTestRequest()); err != nil {
+		t.Errorf("Expected repeat after completion to succeed, got %v", err)
+	}
+}
+
+func TestTransactionUseCase_ConcurrentDuplicateSubmissions(t *testing.T) {
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	transactionUseCase, _ := newDuplicateTestUseCase(&clock)
+
+	const submissions = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted, duplicates := 0, 0
+
+	for i := 0; i < submissions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := transactionUseCase.ProcessTransaction(context.Background(), duplicateTestRequest())
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				accepted++
+			} else if errors.Is(err, domain.ErrDuplicateSubmission) {
+				duplicates++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 || duplicates != submissions-1 {
+		t.Errorf("Expected 1 accepted and %d duplicates, got %d and %d", submissions-1, accepted, duplicates)
+	}
+}
+
+func TestTransactionUseCase_FrozenAccountAcceptsOnlyCredits(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions").(*usecase.TransactionUseCase)
+
+	accountRepo.accounts["frozen"] = &domain.Account{ID: "frozen", UserID: "user1", Balance: 10000, Currency: "USD", Status: domain.AccountStatusFrozen, Version: 1}
+	accountRepo.accounts["active"] = &domain.Account{ID: "active", UserID: "user2", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	frozenID, activeID := "frozen", "active"
+	tests := []struct {
+		name          string
+		request       *domain.TransactionRequest
+		expectedError error
+	}{
+		{"deposit into frozen account", &domain.TransactionRequest{ID: "tx-1", Type: domain.TransactionTypeDeposit, ToAccountID: &frozenID, Amount: 500, Currency: "USD"}, nil},
+		{"transfer into frozen account", &domain.TransactionRequest{ID: "tx-2", Type: domain.TransactionTypeTransfer, FromAccountID: &activeID, ToAccountID: &frozenID, Amount: 500, Currency: "USD"}, nil},
+		{"withdrawal from frozen account", &domain.TransactionRequest{ID: "tx-3", Type: domain.TransactionTypeWithdrawal, FromAccountID: &frozenID, Amount: 500, Currency: "USD"}, domain.ErrAccountFrozen},
+		{"transfer out of frozen account", &domain.TransactionRequest{ID: "tx-4", Type: domain.TransactionTypeTransfer, FromAccountID: &frozenID, ToAccountID: &activeID, Amount: 500, Currency: "USD"}, domain.ErrAccountFrozen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactionRepo.transactions[tt.request.ID] = &domain.Transaction{ID: tt.request.ID, Status: domain.TransactionStatusPending}
+
+			if err := transactionUseCase.ProcessTransactionSync(context.Background(), tt.request); err != tt.expectedError {
+				t.Errorf("Expected error %v, got %v", tt.expectedError, err)
+			}
+		})
+	}
+
+	if balance := accountRepo.accounts["frozen"].Balance; balance != 11000 {
+		t.Errorf("Expected frozen account to hold 110.00 after credits only, got %s", balance.Format("USD"))
+	}
+}
+
+func newReversalTestUseCase() (*usecase.TransactionUseCase, *MockAccountRepository, *MockTransactionRepository, *MockMessageQueue) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	messageQueue := NewMockMessageQueue()
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, messageQueue, "transactions").(*usecase.TransactionUseCase)
+	if _, err := transactionUseCase.StartTransactionProcessor(context.Background()); err != nil {
+		panic(err)
+	}
+
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["acc-2"] = &domain.Account{ID: "acc-2", UserID: "user2", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	return transactionUseCase, accountRepo, transactionRepo, messageQueue
+}
+
+func TestTransactionUseCase_ReverseTransfer(t *testing.T) {
+	transactionUseCase, accountRepo, transactionRepo, messageQueue := newReversalTestUseCase()
+	ctx := context.Background()
+
+	fromID, toID := "acc-1", "acc-2"
+	original, err := transactionUseCase.ProcessTransaction(ctx, &domain.TransactionRequest{
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &fromID,
+		ToAccountID:   &toID,
+		Amount:        2500,
+		Currency:      "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected transfer to be accepted, got %v", err)
+	}
+
+	if _, err := transactionUseCase.ReverseTransaction(ctx, original.ID); err != domain.ErrTransactionNotReversible {
+		t.Errorf("Expected pending transaction to be irreversible, got %v", err)
+	}
+
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected transfer to complete, got %v", errs)
+	}
+
+	reversal, err := transactionUseCase.ReverseTransaction(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("Expected reversal to be accepted, got %v", err)
+	}
+	if reversal.Type != domain.TransactionTypeReversal || reversal.ReversedTransactionID != original.ID {
+		t.Errorf("Expected reversal linked to %s, got %+v", original.ID, reversal)
+	}
+	if *reversal.FromAccountID != toID || *reversal.ToAccountID != fromID {
+		t.Errorf("Expected reversal to swap accounts, got from %s to %s", *reversal.FromAccountID, *reversal.ToAccountID)
+	}
+
+	if _, err := transactionUseCase.ReverseTransaction(ctx, original.ID); err != domain.ErrTransactionAlreadyReversed {
+		t.Errorf("Expected second reversal to be rejected, got %v", err)
+	}
+
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected reversal to complete, got %v", errs)
+	}
+
+	if status := transactionRepo.transactions[reversal.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected reversal to complete, got %s", status)
+	}
+	if reversedBy := transactionRepo.transactions[original.ID].ReversedBy; reversedBy != reversal.ID {
+		t.Errorf("Expected original to be reversed by %s, got %q", reversal.ID, reversedBy)
+	}
+	for _, id := range []string{fromID, toID} {
+		if balance := accountRepo.accounts[id].Balance; balance != 10000 {
+			t.Errorf("Expected %s balance restored to 100.00, got %s", id, balance.Format("USD"))
+		}
+	}
+
+	if _, err := transactionUseCase.ReverseTransaction(ctx, reversal.ID); err != domain.ErrTransactionNotReversible {
+		t.Errorf("Expected a reversal to be irreversible, got %v", err)
+	}
+}
+
+func TestTransactionUseCase_FailedReversalReleasesOriginal(t *testing.T) {
+	transactionUseCase, accountRepo, transactionRepo, messageQueue := newReversalTestUseCase()
+	ctx := context.Background()
+
+	toID := "acc-2"
+	original, err := transactionUseCase.ProcessTransaction(ctx, &domain.TransactionRequest{
+		Type:        domain.TransactionTypeDeposit,
+		ToAccountID: &toID,
+		Amount:      5000,
+		Currency:    "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected deposit to be accepted, got %v", err)
+	}
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected deposit to complete, got %v", errs)
+	}
+
+	// The deposit has since been spent, so the reversal cannot take it back
+	accountRepo.accounts[toID].Balance = 1000
+
+	reversal, err := transactionUseCase.ReverseTransaction(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("Expected reversal to be accepted, got %v", err)
+	}
+	if reversal.ToAccountID != nil || *reversal.FromAccountID != toID {
+		t.Errorf("Expected deposit reversal to withdraw from %s", toID)
+	}
+
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Errorf("Expected failed reversal not to be redelivered, got %v", errs)
+	}
+
+	failed := transactionRepo.transactions[reversal.ID]
+	if failed.Status != domain.TransactionStatusFailed || failed.FailureCode != domain.FailureCodeInsufficientFunds {
+		t.Errorf("Expected reversal to fail with insufficient funds, got %s %s", failed.Status, failed.FailureCode)
+	}
+	if balance := accountRepo.accounts[toID].Balance; balance != 1000 {
+		t.Errorf("Expected balance to be untouched, got %s", balance.Format("USD"))
+	}
+
+	if _, err := transactionUseCase.ReverseTransaction(ctx, original.ID); err != nil {
+		t.Errorf("Expected original to be reversible again after a failed reversal, got %v", err)
+	}
+}
+
+func TestTransactionUseCase_DuplicateReference(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions")
+	ctx := context.Background()
+
+	payroll, other := "payroll", "other"
+	payout := func(from *string, reference string) *domain.TransactionRequest {
+		return &domain.TransactionRequest{
+			Type:          domain.TransactionTypeWithdrawal,
+			FromAccountID: from,
+			Amount:        100000,
+			Currency:      "USD",
+			Reference:     reference,
+		}
+	}
+
+	first, err := transactionUseCase.ProcessTransaction(ctx, payout(&payroll, "2024-06-payroll"))
+	if err != nil {
+		t.Fatalf("Expected first payout to be accepted, got %v", err)
+	}
+
+	_, err = transactionUseCase.ProcessTransaction(ctx, payout(&payroll, "2024-06-payroll"))
+	var referenceErr *domain.DuplicateReferenceError
+	if !errors.As(err, &referenceErr) || referenceErr.ExistingTransactionID != first.ID {
+		t.Fatalf("Expected duplicate reference of %s, got %v", first.ID, err)
+	}
+	if !errors.Is(err, domain.ErrDuplicateReference) {
+		t.Errorf("Expected error to match ErrDuplicateReference, got %v", err)
+	}
+
+	if _, err := transactionUseCase.ProcessTransaction(ctx, payout(&other, "2024-06-payroll")); err != nil {
+		t.Errorf("Expected reference to be reusable from another account, got %v", err)
+	}
+	if _, err := transactionUseCase.ProcessTransaction(ctx, payout(&payroll, "")); err != nil {
+		t.Errorf("Expected empty references to be exempt, got %v", err)
+	}
+
+	if err := transactionUseCase.CancelTransaction(ctx, first.ID); err != nil {
+		t.Fatalf("Expected cancellation to succeed, got %v", err)
+	}
+	if _, err := transactionUseCase.ProcessTransaction(ctx, payout(&payroll, "2024-06-payroll")); err != nil {
+		t.Errorf("Expected reference of a cancelled transaction to be reusable, got %v", err)
+	}
+}
+
+func TestTransactionUseCase_StatusHistory(t *testing.T) {
+	transactionUseCase, accountRepo, transactionRepo, messageQueue := newReversalTestUseCase()
+	ctx := context.Background()
+
+	fromID := "acc-1"
+	withdraw := func(amount domain.Money) *domain.Transaction {
+		transaction, err := transactionUseCase.ProcessTransaction(ctx, &domain.TransactionRequest{
+			Type:          domain.TransactionTypeWithdrawal,
+			FromAccountID: &fromID,
+			Amount:        amount,
+			Currency:      "USD",
+		})
+		if err != nil {
+			t.Fatalf("Expected withdrawal to be accepted, got %v", err)
+		}
+		return transaction
+	}
+
+	completed := withdraw(2500)
+	failed := withdraw(50000)
+	cancelled := withdraw(1000)
+	if err := transactionUseCase.CancelTransaction(ctx, cancelled.ID); err != nil {
+		t.Fatalf("Expected pending withdrawal to be cancellable, got %v", err)
+	}
+
+	messageQueue.Deliver("transactions")
+
+	assertHistory := func(id string, expected ...domain.TransactionStatus) {
+		t.Helper()
+		transaction, err := transactionUseCase.GetTransaction(ctx, id)
+		if err != nil {
+			t.Fatalf("Expected transaction %s, got %v", id, err)
+		}
+		var statuses []domain.TransactionStatus
+		for _, change := range transaction.StatusHistory {
+			statuses = append(statuses, change.Status)
+		}
+		if len(statuses) != len(expected) {
+			t.Fatalf("Expected history %v, got %v", expected, statuses)
+		}
+		for i := range expected {
+			if statuses[i] != expected[i] {
+				t.Fatalf("Expected history %v, got %v", expected, statuses)
+			}
+		}
+	}
+
+	assertHistory(completed.ID, domain.TransactionStatusPending, domain.TransactionStatusProcessing, domain.TransactionStatusCompleted)
+	assertHistory(failed.ID, domain.TransactionStatusPending, domain.TransactionStatusProcessing, domain.TransactionStatusFailed)
+	assertHistory(cancelled.ID, domain.TransactionStatusPending, domain.TransactionStatusCancelled)
+
+	history := transactionRepo.transactions[failed.ID].StatusHistory
+	if message := history[len(history)-1].ErrorMessage; !strings.HasPrefix(message, domain.ErrInsufficientFunds.Error()) {
+		t.Errorf("Expected failure to record the error, got %q", message)
+	}
+	if balance := accountRepo.accounts[fromID].Balance; balance != 7500 {
+		t.Errorf("Expected only the completed withdrawal to be applied, got %s", balance.Format("USD"))
+	}
+}
+
+func TestTransactionUseCase_CancelProcessingTransaction(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions")
+
+	transactionRepo.transactions["tx-1"] = &domain.Transaction{ID: "tx-1", Status: domain.TransactionStatusProcessing}
+
+	if err := transactionUseCase.CancelTransaction(context.Background(), "tx-1"); err != domain.ErrTransactionAlreadyProcessed {
+		t.Errorf("Expected processing transaction not to be cancellable, got %v", err)
+	}
+}
+
+func TestTransactionUseCase_CancelRacingProcessor(t *testing.T) {
+	for run := 0; run < 50; run++ {
+		f, queue := newBatchFixture(t)
+		alice := "alice"
+		withdrawal, err := f.service.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+			Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 2500, Currency: "USD",
+		})
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+
+		var cancelErr error
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cancelErr = f.service.CancelTransaction(context.Background(), withdrawal.ID)
+		}()
+		go func() {
+			defer wg.Done()
+			queue.Deliver("transactions")
+		}()
+		wg.Wait()
+
+		// Exactly one of the cancellation and the processor wins
+		status := f.transactionRepo.transactions[withdrawal.ID].Status
+		balance := f.accountRepo.accounts["alice"].Balance
+		switch {
+		case cancelErr == nil:
+			if status != domain.TransactionStatusCancelled || balance != 10000 {
+				t.Fatalf("Expected a cancelled withdrawal to leave the balance, got %s with balance %d", status, balance)
+			}
+		case errors.Is(cancelErr, domain.ErrTransactionAlreadyProcessed):
+			if status != domain.TransactionStatusCompleted || balance != 7500 {
+				t.Fatalf("Expected a refused cancellation to leave the withdrawal applied, got %s with balance %d", status, balance)
+			}
+		default:
+			t.Fatalf("Expected the cancellation to succeed or be refused, got %v", cancelErr)
+		}
+	}
+}
+
+func TestTransactionUseCase_OverdraftLimit(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions").(*usecase.TransactionUseCase)
+
+	accountRepo.accounts["business"] = &domain.Account{ID: "business", UserID: "user1", Balance: 10000, OverdraftLimit: 5000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["payee"] = &domain.Account{ID: "payee", UserID: "user2", Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	businessID, payeeID := "business", "payee"
+	process := func(id string, request *domain.TransactionRequest) error {
+		request.ID = id
+		request.Currency = "USD"
+		transactionRepo.transactions[id] = &domain.Transaction{ID: id, Status: domain.TransactionStatusPending}
+		return transactionUseCase.ProcessTransactionSync(context.Background(), request)
+	}
+
+	if err := process("tx-1", &domain.TransactionRequest{Type: domain.TransactionTypeWithdrawal, FromAccountID: &businessID, Amount: 12000}); err != nil {
+		t.Fatalf("Expected withdrawal into the overdraft to succeed, got %v", err)
+	}
+	if balance := accountRepo.accounts[businessID].Balance; balance != -2000 {
+		t.Errorf("Expected balance -20.00, got %s", balance.Format("USD"))
+	}
+
+	err := process("tx-2", &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: &businessID, ToAccountID: &payeeID, Amount: 3001})
+	var fundsErr *domain.InsufficientFundsError
+	if !errors.As(err, &fundsErr) || !errors.Is(err, domain.ErrInsufficientFunds) {
+		t.Fatalf("Expected insufficient funds, got %v", err)
+	}
+	if fundsErr.Available != 3000 || !strings.Contains(err.Error(), "30.00 USD available including overdraft") {
+		t.Errorf("Expected 30.00 USD available including overdraft, got %q", err.Error())
+	}
+
+	if err := process("tx-3", &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: &businessID, ToAccountID: &payeeID, Amount: 3000}); err != nil {
+		t.Errorf("Expected transfer up to the overdraft limit to succeed, got %v", err)
+	}
+	if balance := accountRepo.accounts[businessID].Balance; balance != -5000 {
+		t.Errorf("Expected balance at the overdraft limit, got %s", balance.Format("USD"))
+	}
+}
+
+func newScheduledTestUseCase(clock *time.Time) (*usecase.TransactionUseCase, *MockAccountRepository, *MockTransactionRepository, *MockMessageQueue) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	messageQueue := NewMockMessageQueue()
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, messageQueue, "transactions",
+		usecase.WithClock(func() time.Time { return *clock }),
+	).(*usecase.TransactionUseCase)
+	if _, err := transactionUseCase.StartTransactionProcessor(context.Background()); err != nil {
+		panic(err)
+	}
+
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["acc-2"] = &domain.Account{ID: "acc-2", UserID: "user2", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	return transactionUseCase, accountRepo, transactionRepo, messageQueue
+}
+
+func TestTransactionUseCase_ScheduledTransfer(t *testing.T) {
+	clock := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	transactionUseCase, accountRepo, transactionRepo, messageQueue := newScheduledTestUseCase(&clock)
+	ctx := context.Background()
+
+	fromID, toID := "acc-1", "acc-2"
+	scheduledAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	transaction, err := transactionUseCase.ProcessTransaction(ctx, &domain.TransactionRequest{
+		Type:          domain.TransactionTypeTransfer,
+		FromAccountID: &fromID,
+		ToAccountID:   &toID,
+		Amount:        2500,
+		Currency:      "USD",
+		Reference:     "rent-april",
+		ScheduledAt:   &scheduledAt,
+	})
+	if err != nil {
+		t.Fatalf("Expected scheduled transfer to be accepted, got %v", err)
+	}
+	if transaction.Status != domain.TransactionStatusScheduled {
+		t.Errorf("Expected status %s, got %s", domain.TransactionStatusScheduled, transaction.Status)
+	}
+	if count := messageQueue.PublishedCount("transactions"); count != 0 {
+		t.Fatalf("Expected nothing queued before the scheduled time, got %d messages", count)
+	}
+
+	status := domain.TransactionStatusScheduled
+	upcoming, err := transactionUseCase.GetTransactionsByFilter(ctx, &domain.TransactionFilter{Status: &status})
+	if err != nil || len(upcoming) != 1 || upcoming[0].ID != transaction.ID {
+		t.Errorf("Expected the transfer among scheduled transactions, got %v (err %v)", upcoming, err)
+	}
+
+	// Not yet due
+	clock = scheduledAt.Add(-time.Minute)
+	if released, err := transactionUseCase.ReleaseDueTransactions(ctx); err != nil || released != 0 {
+		t.Fatalf("Expected nothing released early, got %d (err %v)", released, err)
+	}
+
+	clock = scheduledAt
+	if released, err := transactionUseCase.ReleaseDueTransactions(ctx); err != nil || released != 1 {
+		t.Fatalf("Expected 1 transaction released, got %d (err %v)", released, err)
+	}
+	if status := transactionRepo.transactions[transaction.ID].Status; status != domain.TransactionStatusPending {
+		t.Errorf("Expected released transaction to be pending, got %s", status)
+	}
+
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected scheduled transfer to process, got %v", errs)
+	}
+	if status := transactionRepo.transactions[transaction.ID].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected status %s, got %s", domain.TransactionStatusCompleted, status)
+	}
+	if accountRepo.accounts[fromID].Balance != 7500 || accountRepo.accounts[toID].Balance != 12500 {
+		t.Errorf("Expected 25.00 moved, got balances %s and %s",
+			accountRepo.accounts[fromID].Balance.Format("USD"), accountRepo.accounts[toID].Balance.Format("USD"))
+	}
+
+	// A released transaction is not released twice
+	if released, err := transactionUseCase.ReleaseDueTransactions(ctx); err != nil || released != 0 {
+		t.Errorf("Expected nothing left to release, got %d (err %v)", released, err)
+	}
+}
+
+func TestTransactionUseCase_CancelScheduledTransaction(t *testing.T) {
+	clock := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	transactionUseCase, accountRepo, transactionRepo, messageQueue := newScheduledTestUseCase(&clock)
+	ctx := context.Background()
+
+	fromID := "acc-1"
+	scheduledAt := clock.Add(24 * time.Hour)
+	transaction, err := transactionUseCase.ProcessTransaction(ctx, &domain.TransactionRequest{
+		Type:          domain.TransactionTypeWithdrawal,
+		FromAccountID: &fromID,
+		Amount:        1000,
+		Currency:      "USD",
+		ScheduledAt:   &scheduledAt,
+	})
+	if err != nil {
+		t.Fatalf("Expected scheduled withdrawal to be accepted, got %v", err)
+	}
+
+	if err := transactionUseCase.CancelTransaction(ctx, transaction.ID); err != nil {
+		t.Fatalf("Expected scheduled transaction to be cancellable, got %v", err)
+	}
+
+	clock = scheduledAt
+	if released, err := transactionUseCase.ReleaseDueTransactions(ctx); err != nil || released != 0 {
+		t.Errorf("Expected a cancelled transaction not to be released, got %d (err %v)", released, err)
+	}
+	if count := messageQueue.PublishedCount("transactions"); count != 0 {
+		t.Errorf("Expected nothing queued, got %d messages", count)
+	}
+	if status := transactionRepo.transactions[transaction.ID].Status; status != domain.TransactionStatusCancelled {
+		t.Errorf("Expected status %s, got %s", domain.TransactionStatusCancelled, status)
+	}
+	if balance := accountRepo.accounts[fromID].Balance; balance != 10000 {
+		t.Errorf("Expected balance unchanged, got %s", balance.Format("USD"))
+	}
+}
+
+func TestTransactionUseCase_PastScheduleProcessesImmediately(t *testing.T) {
+	clock := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+	transactionUseCase, _, _, messageQueue := newScheduledTestUseCase(&clock)
+
+	toID := "acc-2"
+	scheduledAt := clock.Add(-time.Hour)
+	transaction, err := transactionUseCase.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		Type:        domain.TransactionTypeDeposit,
+		ToAccountID: &toID,
+		Amount:      1000,
+		Currency:    "USD",
+		ScheduledAt: &scheduledAt,
+	})
+	if err != nil {
+		t.Fatalf("Expected deposit to be accepted, got %v", err)
+	}
+	if transaction.Status != domain.TransactionStatusPending || messageQueue.PublishedCount("transactions") != 1 {
+		t.Errorf("Expected a past schedule to be queued immediately, got status %s", transaction.Status)
+	}
+}
+
+// newStalePendingTestUseCase submits a transfer whose queue message is lost
+// and backdates it past the one-hour pending TTL
+func newStalePendingTestUseCase(t *testing.T, requeue bool) (*usecase.TransactionUseCase, *MockAccountRepository, *MockTransactionRepository, *MockMessageQueue, []byte) {
+	t.Helper()
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	messageQueue := NewMockMessageQueue()
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, messageQueue, "transactions",
+		usecase.WithPendingExpiry(time.Hour, requeue),
+	).(*usecase.TransactionUseCase)
+	if _, err := transactionUseCase.StartTransactionProcessor(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	accountRepo.accounts["acc-1"] = &domain.Account{ID: "acc-1", UserID: "user1", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+	accountRepo.accounts["acc-2"] = &domain.Account{ID: "acc-2", UserID: "user2", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	fromID, toID := "acc-1", "acc-2"
+	transaction, err := transactionUseCase.ProcessTransaction(context.Background(), &domain.TransactionRequest{
+		ID: "stale", Type: domain.TransactionTypeTransfer, FromAccountID: &fromID, ToAccountID: &toID, Amount: 2500, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatalf("Expected transfer to be accepted, got %v", err)
+	}
+
+	lost := messageQueue.published["transactions"][0]
+	messageQueue.published["transactions"] = nil
+	transaction.CreatedAt = transaction.CreatedAt.Add(-2 * time.Hour)
+	transaction.UpdatedAt = transaction.CreatedAt
+
+	return transactionUseCase, accountRepo, transactionRepo, messageQueue, lost
+}
+
+func TestTransactionUseCase_ExpireStalePending(t *testing.T) {
+	transactionUseCase, accountRepo, transactionRepo, messageQueue, lost := newStalePendingTestUseCase(t, false)
+	ctx := context.Background()
+
+	// A transaction the processor is working on is never expired
+	transactionRepo.transactions["busy"] = &domain.Transaction{ID: "busy", Status: domain.TransactionStatusProcessing, CreatedAt: time.Now().Add(-2 * time.Hour)}
+
+	expired, requeued, err := transactionUseCase.ExpireStalePending(ctx)
+	if err != nil || expired != 1 || requeued != 0 {
+		t.Fatalf("Expected 1 expired, got %d expired and %d requeued (err %v)", expired, requeued, err)
+	}
+	stale := transactionRepo.transactions["stale"]
+	if stale.Status != domain.TransactionStatusFailed || stale.FailureCode != domain.FailureCodeExpired || stale.ErrorMessage != domain.ErrTransactionExpired.Error() {
+		t.Errorf("Expected the transaction to fail as expired, got %s (%s: %s)", stale.Status, stale.FailureCode, stale.ErrorMessage)
+	}
+	if status := transactionRepo.transactions["busy"].Status; status != domain.TransactionStatusProcessing {
+		t.Errorf("Expected the processing transaction untouched, got %s", status)
+	}
+
+	// The lost message turning up late must not move money the client was
+	// told had failed
+	messageQueue.Publish(ctx, "transactions", lost)
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the late message to be skipped, got %v", errs)
+	}
+	if balance := accountRepo.accounts["acc-1"].Balance; balance != 10000 {
+		t.Errorf("Expected the balance untouched, got %d", balance)
+	}
+}
+
+func TestTransactionUseCase_RequeueStalePending(t *testing.T) {
+	transactionUseCase, accountRepo, transactionRepo, messageQueue, _ := newStalePendingTestUseCase(t, true)
+	ctx := context.Background()
+
+	expired, requeued, err := transactionUseCase.ExpireStalePending(ctx)
+	if err != nil || expired != 0 || requeued != 1 {
+		t.Fatalf("Expected 1 requeued, got %d expired and %d requeued (err %v)", expired, requeued, err)
+	}
+	stale := transactionRepo.transactions["stale"]
+	if stale.Status != domain.TransactionStatusPending || stale.RequeuedAt == nil {
+		t.Errorf("Expected the transaction pending and marked requeued, got %s", stale.Status)
+	}
+	if count := messageQueue.PublishedCount("transactions"); count != 1 {
+		t.Fatalf("Expected the transaction queued again, got %d messages", count)
+	}
+
+	// A fresh requeue gets another full TTL
+	if expired, requeued, _ := transactionUseCase.ExpireStalePending(ctx); expired != 0 || requeued != 0 {
+		t.Errorf("Expected nothing stale, got %d expired and %d requeued", expired, requeued)
+	}
+
+	// A requeued transaction that is lost again is failed, not requeued forever
+	stale.UpdatedAt = stale.UpdatedAt.Add(-2 * time.Hour)
+	if expired, requeued, _ := transactionUseCase.ExpireStalePending(ctx); expired != 1 || requeued != 0 {
+		t.Errorf("Expected the second loss to expire, got %d expired and %d requeued", expired, requeued)
+	}
+
+	// Otherwise the requeued message is processed normally
+	transactionUseCase, accountRepo, transactionRepo, messageQueue, _ = newStalePendingTestUseCase(t, true)
+	transactionUseCase.ExpireStalePending(ctx)
+	if errs := messageQueue.Deliver("transactions"); len(errs) != 0 {
+		t.Fatalf("Expected the requeued transfer to complete, got %v", errs)
+	}
+	if status := transactionRepo.transactions["stale"].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the requeued transfer completed, got %s", status)
+	}
+	if balance := accountRepo.accounts["acc-2"].Balance; balance != 12500 {
+		t.Errorf("Expected the transfer credited, got %d", balance)
+	}
+}
+
+func TestGetAccountTransactions_DirectionAndCounterparty(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "user-alice", Balance: 7800, Currency: "USD"}
+	accountRepo.accounts["bob"] = &domain.Account{ID: "bob", UserID: "user-bob", Currency: "USD"}
+
+	alice, bob, closed := "alice", "bob", "closed"
+	now := time.Now()
+	for i, transaction := range []*domain.Transaction{
+		{ID: "dep", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 2500},
+		{ID: "wd", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 1000},
+		{ID: "out", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 4000},
+		{ID: "in", Type: domain.TransactionTypeTransfer, FromAccountID: &bob, ToAccountID: &alice, Amount: 300},
+		{ID: "gone", Type: domain.TransactionTypeTransfer, FromAccountID: &closed, ToAccountID: &alice, Amount: 50},
+	} {
+		transaction.Currency = "USD"
+		transaction.Status = domain.TransactionStatusCompleted
+		transaction.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	service := usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions")
+	views, err := service.GetAccountTransactions(context.Background(), "alice", &domain.TransactionFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(views) != 5 {
+		t.Fatalf("Expected 5 transactions, got %d", len(views))
+	}
+
+	expected := map[string]struct {
+		direction      domain.EntryDirection
+		signedAmount   domain.Money
+		counterparty   string
+		counterpartyID string
+	}{
+		"dep":  {domain.EntryDirectionCredit, 2500, "", ""},
+		"wd":   {domain.EntryDirectionDebit, -1000, "", ""},
+		"out":  {domain.EntryDirectionDebit, -4000, "bob", "user-bob"},
+		"in":   {domain.EntryDirectionCredit, 300, "bob", "user-bob"},
+		"gone": {domain.EntryDirectionCredit, 50, "closed", ""},
+	}
+	for _, view := range views {
+		want := expected[view.ID]
+		if view.Direction != want.direction || view.SignedAmount != want.signedAmount {
+			t.Errorf("%s: expected %s %d, got %s %d", view.ID, want.direction, want.signedAmount, view.Direction, view.SignedAmount)
+		}
+		if (view.CounterpartyAccountID == nil) != (want.counterparty == "") ||
+			(view.CounterpartyAccountID != nil && *view.CounterpartyAccountID != want.counterparty) {
+			t.Errorf("%s: expected counterparty %q, got %v", view.ID, want.counterparty, view.CounterpartyAccountID)
+		}
+		if (view.CounterpartyUserID == nil) != (want.counterpartyID == "") ||
+			(view.CounterpartyUserID != nil && *view.CounterpartyUserID != want.counterpartyID) {
+			t.Errorf("%s: expected counterparty user %q, got %v", view.ID, want.counterpartyID, view.CounterpartyUserID)
+		}
+	}
+
+	if accountRepo.batchLookups != 1 {
+		t.Errorf("Expected the counterparties to be looked up in one query, got %d", accountRepo.batchLookups)
+	}
+}
+
+// newHistoryFixture gives alice, now at 100.00, a deposit, a transfer to bob,
+// a pending withdrawal and a failed deposit, one second apart, oldest first
+func newHistoryFixture() (*MockTransactionRepository, domain.TransactionService) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "user-alice", Balance: 10000, Currency: "USD"}
+	accountRepo.accounts["bob"] = &domain.Account{ID: "bob", UserID: "user-bob", Currency: "USD"}
+
+	alice, bob := "alice", "bob"
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, transaction := range []*domain.Transaction{
+		{ID: "dep", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 3000, Status: domain.TransactionStatusCompleted},
+		{ID: "tr", Type: domain.TransactionTypeTransfer, FromAccountID: &alice, ToAccountID: &bob, Amount: 1500, Status: domain.TransactionStatusCompleted},
+		{ID: "wd", Type: domain.TransactionTypeWithdrawal, FromAccountID: &alice, Amount: 700, Status: domain.TransactionStatusPending},
+		{ID: "lost", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 900, Status: domain.TransactionStatusFailed},
+		{ID: "dep2", Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 500, Status: domain.TransactionStatusCompleted},
+	} {
+		transaction.Currency = "USD"
+		transaction.CreatedAt = start.Add(time.Duration(i) * time.Second)
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	return transactionRepo, usecase.NewTransactionUseCase(accountRepo, transactionRepo, NewMockMessageQueue(), "transactions")
+}
+
+func TestGetAccountTransactions_RunningBalance(t *testing.T) {
+	_, service := newHistoryFixture()
+
+	views, err := service.GetAccountTransactions(context.Background(), "alice", &domain.TransactionFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expected := map[string]domain.Money{"dep2": 10000, "tr": 9500, "dep": 11000}
+	for _, view := range views {
+		balance, moved := expected[view.ID]
+		if !moved {
+			if view.RunningBalance != nil {
+				t.Errorf("Expected no running balance on %s %s, got %d", view.Status, view.ID, *view.RunningBalance)
+			}
+			continue
+		}
+		if view.RunningBalance == nil || *view.RunningBalance != balance {
+			t.Errorf("Expected running balance %d after %s, got %v", balance, view.ID, view.RunningBalance)
+		}
+	}
+}
+
+func TestGetAccountTransactions_RunningBalanceOnLaterPages(t *testing.T) {
+	_, service := newHistoryFixture()
+	ctx := context.Background()
+
+	// The first deposit is alone on the third page of two rows
+	views, err := service.GetAccountTransactions(ctx, "alice", &domain.TransactionFilter{Limit: 2, Offset: 4})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(views) != 1 || views[0].ID != "dep" || views[0].RunningBalance == nil || *views[0].RunningBalance != 11000 {
+		t.Fatalf("Expected dep at 110.00 on the last page, got %+v", views)
+	}
+
+	// Filtering out the later transactions does not leave them out of the walk
+	deposits := domain.TransactionTypeDeposit
+	completed := domain.TransactionStatusCompleted
+	views, err = service.GetAccountTransactions(ctx, "alice", &domain.TransactionFilter{Type: &deposits, Status: &completed, Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(views) != 1 || views[0].ID != "dep" || *views[0].RunningBalance != 11000 {
+		t.Fatalf("Expected dep at 110.00 on the filtered page, got %+v", views)
+	}
+}
+
+func TestGetAccountTransactions_AccountNotFound(t *testing.T) {
+	_, service := newHistoryFixture()
+
+	if _, err := service.GetAccountTransactions(context.Background(), "nobody", &domain.TransactionFilter{Limit: 10}); !errors.Is(err, domain.ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// seedUserAccounts stores accounts of three users, two of them with the
+// prefix "cust-"
+func seedUserAccounts(accountRepo *MockAccountRepository) {
+	for _, account := range []*domain.Account{
+		{ID: "a1", UserID: "cust-alice", Balance: 10000, Currency: "USD", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive},
+		{ID: "a2", UserID: "cust-alice", Balance: 2550, Currency: "USD", Type: domain.AccountTypeSavings, Status: domain.AccountStatusFrozen},
+		{ID: "a3", UserID: "cust-alice", Balance: 700, Currency: "EUR", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive},
+		{ID: "b1", UserID: "cust-bob", Balance: 0, Currency: "GBP", Type: domain.AccountTypeChecking, Status: domain.AccountStatusClosed},
+		{ID: "c1", UserID: "staff-carol", Balance: 500, Currency: "USD", Type: domain.AccountTypeChecking, Status: domain.AccountStatusActive},
+	} {
+		accountRepo.accounts[account.ID] = account
+	}
+}
+
+func TestAccountUseCase_ListUsers(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	seedUserAccounts(accountRepo)
+	service := usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository())
+
+	page, err := service.ListUsers(context.Background(), &domain.UserFilter{UserIDPrefix: "cust-", Limit: 1})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(page.Users) != 1 || !page.HasMore {
+		t.Fatalf("Expected one user with more to follow, got %d (has more %v)", len(page.Users), page.HasMore)
+	}
+
+	alice := page.Users[0]
+	if alice.UserID != "cust-alice" || alice.AccountCount != 3 {
+		t.Errorf("Expected cust-alice with 3 accounts, got %s with %d", alice.UserID, alice.AccountCount)
+	}
+	if len(alice.Balances) != 2 || alice.Balances[0].Currency != "EUR" || alice.Balances[1].Balance != 12550 {
+		t.Errorf("Expected 7.00 EUR and 125.50 USD, got %+v %+v", alice.Balances[0], alice.Balances[1])
+	}
+	if alice.Statuses[domain.AccountStatusActive] != 2 || alice.Statuses[domain.AccountStatusFrozen] != 1 {
+		t.Errorf("Expected two active accounts and one frozen, got %v", alice.Statuses)
+	}
+
+	page, err = service.ListUsers(context.Background(), &domain.UserFilter{UserIDPrefix: "cust-", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(page.Users) != 1 || page.Users[0].UserID != "cust-bob" || page.HasMore {
+		t.Errorf("Expected cust-bob last, got %+v (has more %v)", page.Users, page.HasMore)
+	}
+}
+
+func TestAccountHandler_ListUsers(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	seedUserAccounts(accountRepo)
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository()),
+		AdminToken:     ownershipAdminToken,
+	})
+
+	if rec := principalRequest(e, "cust-alice", http.MethodGet, "/api/v1/admin/users", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a user refused, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/admin/users?user_id_prefix=cust-&limit=500", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	var body struct {
+		Users []struct {
+			UserID   string `json:"user_id"`
+			Balances []struct {
+				Currency string `json:"currency"`
+				Balance  string `json:"balance"`
+			} `json:"balances"`
+			Statuses map[string]int `json:"statuses"`
+		} `json:"users"`
+		Limit   int  `json:"limit"`
+		HasMore bool `json:"has_more"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Users) != 2 || body.Limit != 100 || body.HasMore {
+		t.Fatalf("Expected both customers on a page of at most 100, got %s", rec.Body)
+	}
+	if balance := body.Users[0].Balances[1]; balance.Currency != "USD" || balance.Balance != "125.50" {
+		t.Errorf("Expected the USD balance formatted, got %+v", balance)
+	}
+	if body.Users[1].Statuses["closed"] != 1 {
+		t.Errorf("Expected cust-bob's closed account counted, got %v", body.Users[1].Statuses)
+	}
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAccountUseCase_GetUserSummary(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	transactionRepo := NewMockTransactionRepository()
+	accounts := usecase.NewAccountUseCase(accountRepo, transactionRepo)
+
+	for _, account := range []*domain.Account{
+		{ID: "usd-1", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive},
+		{ID: "usd-2", UserID: "alice", Balance: 2500, Currency: "USD", Status: domain.AccountStatusActive},
+		{ID: "eur-1", UserID: "alice", Balance: 700, Currency: "EUR", Status: domain.AccountStatusActive},
+		{ID: "bob", UserID: "bob", Balance: 9900, Currency: "USD", Status: domain.AccountStatusActive},
+	} {
+		accountRepo.accounts[account.ID] = account
+	}
+
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	usd1, usd2, eur1, bob := "usd-1", "usd-2", "eur-1", "bob"
+	for _, transaction := range []*domain.Transaction{
+		{ID: "t1", Type: domain.TransactionTypeDeposit, ToAccountID: &usd1, Amount: 3000, Currency: "USD", Status: domain.TransactionStatusCompleted, CreatedAt: monthStart.Add(2 * time.Hour)},
+		{ID: "t2", Type: domain.TransactionTypeWithdrawal, FromAccountID: &usd2, Amount: 500, Currency: "USD", Status: domain.TransactionStatusCompleted, CreatedAt: monthStart.Add(time.Hour)},
+		{ID: "t3", Type: domain.TransactionTypeDeposit, ToAccountID: &eur1, Amount: 700, Currency: "EUR", Status: domain.TransactionStatusCompleted, CreatedAt: monthStart.Add(-time.Hour)},
+		{ID: "t4", Type: domain.TransactionTypeWithdrawal, FromAccountID: &usd1, Amount: 100, Currency: "USD", Status: domain.TransactionStatusPending, CreatedAt: monthStart.Add(3 * time.Hour)},
+		{ID: "t5", Type: domain.TransactionTypeDeposit, ToAccountID: &bob, Amount: 9900, Currency: "USD", Status: domain.TransactionStatusCompleted, CreatedAt: monthStart.Add(4 * time.Hour)},
+	} {
+		transactionRepo.transactions[transaction.ID] = transaction
+	}
+
+	summary, err := accounts.GetUserSummary(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if summary.AccountCount != 3 || len(summary.Currencies) != 2 {
+		t.Fatalf("Expected 3 accounts in 2 currencies, got %d in %d", summary.AccountCount, len(summary.Currencies))
+	}
+	if summary.LastTransactionAt == nil || !summary.LastTransactionAt.Equal(monthStart.Add(3*time.Hour)) {
+		t.Errorf("Expected the pending withdrawal as the last transaction, got %v", summary.LastTransactionAt)
+	}
+
+	eur, usd := summary.Currencies[0], summary.Currencies[1]
+	if eur.Currency != "EUR" || eur.Balance != 700 || eur.DepositedThisMonth != 0 || len(eur.Accounts) != 1 {
+		t.Errorf("Expected last month's EUR deposit left out, got %+v", eur)
+	}
+	if usd.Currency != "USD" || usd.Balance != 12500 || usd.DepositedThisMonth != 3000 || usd.WithdrawnThisMonth != 500 || len(usd.Accounts) != 2 {
+		t.Errorf("Expected only completed USD activity counted, got %+v", usd)
+	}
+}
+
+func TestAccountHandler_GetUserSummary(t *testing.T) {
+	accountRepo := NewMockAccountRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 12345, Currency: "USD", Status: domain.AccountStatusActive}
+
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService: usecase.NewAccountUseCase(accountRepo, NewMockTransactionRepository()),
+		AdminToken:     ownershipAdminToken,
+	})
+
+	rec := principalRequest(e, "alice", http.MethodGet, "/api/v1/users/alice/summary", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var body struct {
+		AccountCount int `json:"account_count"`
+		Currencies   []struct {
+			Currency string `json:"currency"`
+			Balance  string `json:"balance"`
+		} `json:"currencies"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.AccountCount != 1 || len(body.Currencies) != 1 || body.Currencies[0].Balance != "123.45" {
+		t.Errorf("Expected one USD account totalling 123.45, got %s", rec.Body)
+	}
+
+	if rec := principalRequest(e, "bob", http.MethodGet, "/api/v1/users/alice/summary", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected another user refused, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := principalRequest(e, "admin", http.MethodGet, "/api/v1/users/carol/summary", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected a user without accounts not found, got %d: %s", rec.Code, rec.Body)
+	}
+}
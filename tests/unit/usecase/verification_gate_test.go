@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/api/routes"
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MockUserVerificationRepository is a mock implementation of UserVerificationRepository
+type MockUserVerificationRepository struct {
+	mu     sync.Mutex
+	levels map[string]domain.VerificationLevel
+}
+
+func NewMockUserVerificationRepository() *MockUserVerificationRepository {
+	return &MockUserVerificationRepository{levels: make(map[string]domain.VerificationLevel)}
+}
+
+func (m *MockUserVerificationRepository) Get(ctx context.Context, userID string) (*domain.UserVerification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	level, ok := m.levels[userID]
+	if !ok {
+		level = domain.VerificationLevelUnverified
+	}
+	return &domain.UserVerification{UserID: userID, Level: level}, nil
+}
+
+func (m *MockUserVerificationRepository) Set(ctx context.Context, verification *domain.UserVerification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	verification.UpdatedAt = time.Now()
+	m.levels[verification.UserID] = verification.Level
+	return nil
+}
+
+type verificationGateFixture struct {
+	accountRepo      *MockAccountRepository
+	transactionRepo  *MockTransactionRepository
+	verificationRepo *MockUserVerificationRepository
+}
+
+// newVerificationGateFixture gives unverified alice USD checking and savings
+// accounts and a EUR account, and verified bob a USD account. Alice has
+// completed deposits of 60.00 and 30.00 USD and 500.00 EUR; a pending deposit
+// of 50.00 USD does not count.
+func newVerificationGateFixture() *verificationGateFixture {
+	f := &verificationGateFixture{
+		accountRepo:      NewMockAccountRepository(),
+		transactionRepo:  NewMockTransactionRepository(),
+		verificationRepo: NewMockUserVerificationRepository(),
+	}
+	f.verificationRepo.levels["bob"] = domain.VerificationLevelVerified
+
+	for _, account := range []*domain.Account{
+		{ID: "alice-checking", UserID: "alice", Currency: "USD"},
+		{ID: "alice-savings", UserID: "alice", Currency: "USD"},
+		{ID: "alice-euro", UserID: "alice", Currency: "EUR"},
+		{ID: "bob-checking", UserID: "bob", Currency: "USD"},
+	} {
+		account.Balance = 100000
+		account.Status = domain.AccountStatusActive
+		account.Version = 1
+		f.accountRepo.accounts[account.ID] = account
+	}
+
+	for _, seeded := range []struct {
+		id     string
+		to     string
+		amount domain.Money
+		status domain.TransactionStatus
+	}{
+		{"d1", "alice-checking", 6000, domain.TransactionStatusCompleted},
+		{"d2", "alice-savings", 3000, domain.TransactionStatusCompleted},
+		{"d3", "alice-euro", 50000, domain.TransactionStatusCompleted},
+		{"d4", "alice-checking", 5000, domain.TransactionStatusPending},
+	} {
+		to := seeded.to
+		currency := "USD"
+		if to == "alice-euro" {
+			currency = "EUR"
+		}
+		f.transactionRepo.transactions[seeded.id] = &domain.Transaction{
+			ID:          seeded.id,
+			Type:        domain.TransactionTypeDeposit,
+			ToAccountID: &to,
+			Amount:      seeded.amount,
+			Currency:    currency,
+			Status:      seeded.status,
+			CreatedAt:   time.Now(),
+		}
+	}
+
+	return f
+}
+
+func (f *verificationGateFixture) service() domain.TransactionService {
+	return usecase.NewTransactionUseCase(f.accountRepo, f.transactionRepo, NewMockMessageQueue(), "transactions",
+		usecase.WithVerificationGate(f.verificationRepo, "100.00"),
+	)
+}
+
+func deposit(to string, amount domain.Money) *domain.TransactionRequest {
+	return &domain.TransactionRequest{
+		Type:        domain.TransactionTypeDeposit,
+		ToAccountID: &to,
+		Amount:      amount,
+		Currency:    "USD",
+	}
+}
+
+func TestVerificationGate_RefusesUnverifiedDebits(t *testing.T) {
+	f := newVerificationGateFixture()
+	service := f.service()
+	ctx := context.Background()
+
+	from, to := "alice-checking", "bob-checking"
+	transfer := &domain.TransactionRequest{Type: domain.TransactionTypeTransfer, FromAccountID: &from, ToAccountID: &to, Amount: 100, Currency: "USD"}
+	for _, request := range []*domain.TransactionRequest{withdrawal("alice-checking"), transfer} {
+		_, err := service.ProcessTransaction(ctx, request)
+		var verificationErr *domain.VerificationRequiredError
+		if !errors.As(err, &verificationErr) || !errors.Is(err, domain.ErrVerificationRequired) {
+			t.Fatalf("Expected a VerificationRequiredError for the %s, got %v", request.Type, err)
+		}
+		if verificationErr.TransactionType != request.Type {
+			t.Errorf("Expected the error to name a %s, got %s", request.Type, verificationErr.TransactionType)
+		}
+	}
+
+	if _, err := service.ProcessTransaction(ctx, withdrawal("bob-checking")); err != nil {
+		t.Errorf("Expected bob's withdrawal to be accepted, got %v", err)
+	}
+
+	// System transactions are not gated
+	system := withdrawal("alice-checking")
+	system.System = true
+	if _, err := service.ProcessTransaction(ctx, system); err != nil {
+		t.Errorf("Expected a system withdrawal to be accepted, got %v", err)
+	}
+}
+
+func TestVerificationGate_CapsUnverifiedDeposits(t *testing.T) {
+	f := newVerificationGateFixture()
+	service := f.service()
+	ctx := context.Background()
+
+	// 90.00 USD of completed deposits leaves 10.00 under the limit
+	_, err := service.ProcessTransaction(ctx, deposit("alice-savings", 1001))
+	var verificationErr *domain.VerificationRequiredError
+	if !errors.As(err, &verificationErr) {
+		t.Fatalf("Expected a VerificationRequiredError, got %v", err)
+	}
+	if verificationErr.DepositLimit != 10000 || verificationErr.Deposited != 9000 || verificationErr.Currency != "USD" {
+		t.Errorf("Expected 90.00 of a 100.00 USD limit deposited, got %d of %d %s", verificationErr.Deposited, verificationErr.DepositLimit, verificationErr.Currency)
+	}
+
+	if _, err := service.ProcessTransaction(ctx, deposit("alice-savings", 1000)); err != nil {
+		t.Errorf("Expected a deposit up to the limit to be accepted, got %v", err)
+	}
+	if _, err := service.ProcessTransaction(ctx, deposit("bob-checking", 50000)); err != nil {
+		t.Errorf("Expected a verified user's deposit to be accepted, got %v", err)
+	}
+}
+
+func TestVerificationGate_CheckedWhenProcessed(t *testing.T) {
+	f := newVerificationGateFixture()
+	f.verificationRepo.levels["alice"] = domain.VerificationLevelVerified
+	service := f.service()
+	ctx := context.Background()
+
+	transaction, err := service.ProcessTransaction(ctx, withdrawal("alice-checking"))
+	if err != nil {
+		t.Fatalf("Expected a verified user's withdrawal to be accepted, got %v", err)
+	}
+
+	// Alice is moved back to unverified before the withdrawal is processed
+	f.verificationRepo.levels["alice"] = domain.VerificationLevelUnverified
+	request := withdrawal("alice-checking")
+	request.ID = transaction.ID
+	err = service.(*usecase.TransactionUseCase).ProcessTransactionSync(ctx, request)
+	if !errors.Is(err, domain.ErrVerificationRequired) {
+		t.Fatalf("Expected ErrVerificationRequired, got %v", err)
+	}
+	if code := domain.FailureCodeFor(err); code != domain.FailureCodeVerificationRequired {
+		t.Errorf("Expected failure code %s, got %s", domain.FailureCodeVerificationRequired, code)
+	}
+	if f.accountRepo.accounts["alice-checking"].Balance != 100000 {
+		t.Errorf("Expected the balance to be untouched, got %d", f.accountRepo.accounts["alice-checking"].Balance)
+	}
+}
+
+func TestVerificationGate_AdminPatchesLevel(t *testing.T) {
+	f := newVerificationGateFixture()
+	e := echo.New()
+	routes.SetupRoutes(e, routes.Dependencies{
+		AccountService:          usecase.NewAccountUseCase(f.accountRepo, f.transactionRepo),
+		TransactionService:      f.service(),
+		UserVerificationService: usecase.NewUserVerificationUseCase(f.verificationRepo),
+		AdminToken:              ownershipAdminToken,
+	})
+	body := `{"type":"withdrawal","from_account_id":"alice-checking","amount":"1.00","currency":"USD"}`
+
+	rec := principalRequest(e, "alice", http.MethodPost, "/api/v1/transactions", body)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusForbidden, rec.Code, rec.Body)
+	}
+	var refused map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &refused)
+	if refused["code"] != "verification_required" {
+		t.Errorf("Expected code verification_required, got %q", refused["code"])
+	}
+
+	rec = principalRequest(e, "admin", http.MethodPatch, "/api/v1/admin/users/alice/verification", `{"level":"trusted"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown level to be rejected with %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	rec = principalRequest(e, "alice", http.MethodPatch, "/api/v1/admin/users/alice/verification", `{"level":"verified"}`)
+	if rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a user to be refused the admin endpoint, got %d", rec.Code)
+	}
+
+	rec = principalRequest(e, "admin", http.MethodPatch, "/api/v1/admin/users/alice/verification", `{"level":"verified"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	rec = principalRequest(e, "alice", http.MethodPost, "/api/v1/transactions", body)
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("Expected status %d once verified, got %d: %s", http.StatusAccepted, rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,348 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/usecase"
+)
+
+// MockWebhookRepository implements domain.WebhookRepository for testing
+type MockWebhookRepository struct {
+	subscriptions map[string]*domain.WebhookSubscription
+	deliveries    []*domain.WebhookDelivery
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{subscriptions: make(map[string]*domain.WebhookSubscription)}
+}
+
+func (m *MockWebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	m.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (m *MockWebhookRepository) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	subscription, exists := m.subscriptions[id]
+	if !exists {
+		return nil, domain.ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+func (m *MockWebhookRepository) ListSubscriptionsFor(ctx context.Context, eventType domain.NotificationType) ([]*domain.WebhookSubscription, error) {
+	var subscriptions []*domain.WebhookSubscription
+	for _, subscription := range m.subscriptions {
+		if subscription.Active && subscription.Subscribes(eventType) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (m *MockWebhookRepository) DeactivateSubscription(ctx context.Context, id string) error {
+	subscription, exists := m.subscriptions[id]
+	if !exists {
+		return domain.ErrWebhookNotFound
+	}
+	subscription.Active = false
+	return nil
+}
+
+func (m *MockWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (bool, error) {
+	for _, existing := range m.deliveries {
+		if existing.SubscriptionID == delivery.SubscriptionID && existing.EventID == delivery.EventID {
+			return false, nil
+		}
+	}
+	delivery.CreatedAt = time.Now()
+	copied := *delivery
+	m.deliveries = append(m.deliveries, &copied)
+	return true, nil
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	for i, existing := range m.deliveries {
+		if existing.ID == delivery.ID {
+			copied := *delivery
+			m.deliveries[i] = &copied
+			return nil
+		}
+	}
+	return errors.New("delivery not found")
+}
+
+func (m *MockWebhookRepository) ClaimDueDeliveries(ctx context.Context, now, lease time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	var claimed []*domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if delivery.Status == domain.WebhookDeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			delivery.NextAttemptAt = &lease
+			copied := *delivery
+			claimed = append(claimed, &copied)
+		}
+	}
+	return claimed, nil
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*domain.WebhookDelivery, error) {
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// webhookEndpoint records the requests it receives and answers each with the
+// next of its statuses, repeating the last
+type webhookEndpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func newWebhookEndpoint(t *testing.T, statuses ...int) (*webhookEndpoint, *httptest.Server) {
+	endpoint := &webhookEndpoint{statuses: statuses}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		endpoint.mu.Lock()
+		defer endpoint.mu.Unlock()
+		endpoint.requests = append(endpoint.requests, r)
+		endpoint.bodies = append(endpoint.bodies, string(body))
+		status := endpoint.statuses[0]
+		if len(endpoint.statuses) > 1 {
+			endpoint.statuses = endpoint.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return endpoint, server
+}
+
+func (e *webhookEndpoint) received() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.requests)
+}
+
+const completedEvent = `{"event_id":"evt-1","type":"transaction.completed","occurred_at":"2024-03-01T10:00:00Z","transaction":{"id":"tx-1"}}`
+
+type webhookFixture struct {
+	repo    *MockWebhookRepository
+	now     time.Time
+	service domain.WebhookService
+}
+
+func newWebhookFixture(maxAttempts int) *webhookFixture {
+	f := &webhookFixture{repo: NewMockWebhookRepository(), now: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)}
+	f.service = usecase.NewWebhookUseCase(f.repo,
+		usecase.WithWebhookRetryPolicy(maxAttempts, time.Minute),
+		usecase.WithWebhookClock(func() time.Time { return f.now }),
+	)
+	return f
+}
+
+func TestRegisterWebhook(t *testing.T) {
+	f := newWebhookFixture(3)
+	ctx := context.Background()
+
+	subscription, err := f.service.RegisterWebhook(ctx, "https://crm.example.com/hooks", []domain.NotificationType{domain.NotificationTransactionCompleted})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if !strings.HasPrefix(subscription.Secret, "whsec_") || len(subscription.Secret) != 70 {
+		t.Errorf("Expected a generated secret, got %q", subscription.Secret)
+	}
+	if !subscription.Active {
+		t.Error("Expected the subscription to be active")
+	}
+
+	for _, tt := range []struct {
+		name       string
+		url        string
+		eventTypes []domain.NotificationType
+	}{
+		{"relative URL", "/hooks", []domain.NotificationType{domain.NotificationTransactionCompleted}},
+		{"unsupported scheme", "ftp://crm.example.com", []domain.NotificationType{domain.NotificationTransactionCompleted}},
+		{"no event types", "https://crm.example.com", nil},
+		{"unknown event type", "https://crm.example.com", []domain.NotificationType{"account.deleted"}},
+	} {
+		if _, err := f.service.RegisterWebhook(ctx, tt.url, tt.eventTypes); !errors.Is(err, domain.ErrInvalidWebhook) {
+			t.Errorf("%s: expected ErrInvalidWebhook, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestHandleNotification_DeliversSignedEventToMatchingSubscriptions(t *testing.T) {
+	f := newWebhookFixture(3)
+	ctx := context.Background()
+	endpoint, server := newWebhookEndpoint(t, http.StatusOK)
+
+	subscription, _ := f.service.RegisterWebhook(ctx, server.URL, []domain.NotificationType{domain.NotificationTransactionCompleted})
+	other, _ := f.service.RegisterWebhook(ctx, server.URL, []domain.NotificationType{domain.NotificationAccountCreated})
+
+	if err := f.service.HandleNotification(ctx, []byte(completedEvent)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if endpoint.received() != 1 {
+		t.Fatalf("Expected one delivery, got %d", endpoint.received())
+	}
+
+	request := endpoint.requests[0]
+	if endpoint.bodies[0] != completedEvent {
+		t.Errorf("Expected the event as the body, got %s", endpoint.bodies[0])
+	}
+	if !domain.VerifyWebhook(subscription.Secret, []byte(endpoint.bodies[0]), request.Header.Get(domain.WebhookSignatureHeader)) {
+		t.Errorf("Expected a valid signature, got %q", request.Header.Get(domain.WebhookSignatureHeader))
+	}
+	if request.Header.Get("X-Webhook-Event") != "transaction.completed" {
+		t.Errorf("Expected the event type header, got %q", request.Header.Get("X-Webhook-Event"))
+	}
+
+	deliveries, err := f.service.GetDeliveries(ctx, subscription.ID, 0)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("Expected one delivery, got %v, %v", deliveries, err)
+	}
+	delivery := deliveries[0]
+	if delivery.Status != domain.WebhookDeliveryDelivered || delivery.Attempts != 1 || delivery.LastStatusCode == nil || *delivery.LastStatusCode != 200 {
+		t.Errorf("Expected a delivery delivered on the first attempt, got %+v", delivery)
+	}
+	if deliveries, _ := f.service.GetDeliveries(ctx, other.ID, 0); len(deliveries) != 0 {
+		t.Errorf("Expected no delivery to the other subscription, got %d", len(deliveries))
+	}
+
+	// The queue redelivering the event does not post it again
+	if err := f.service.HandleNotification(ctx, []byte(completedEvent)); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if endpoint.received() != 1 {
+		t.Errorf("Expected the redelivered event to be skipped, got %d requests", endpoint.received())
+	}
+}
+
+func TestHandleNotification_RetriesWithBackoffUntilOutOfAttempts(t *testing.T) {
+	f := newWebhookFixture(3)
+	ctx := context.Background()
+	endpoint, server := newWebhookEndpoint(t, http.StatusInternalServerError)
+
+	subscription, _ := f.service.RegisterWebhook(ctx, server.URL, []domain.NotificationType{domain.NotificationTransactionCompleted})
+	if err := f.service.HandleNotification(ctx, []byte(completedEvent)); err != nil {
+		t.Fatalf("Expected the failure to be recorded rather than returned, got %v", err)
+	}
+
+	delivery := f.repo.deliveries[0]
+	if delivery.Status != domain.WebhookDeliveryPending || delivery.LastError == "" {
+		t.Fatalf("Expected a pending delivery with the error, got %+v", delivery)
+	}
+	if want := f.now.Add(time.Minute); !delivery.NextAttemptAt.Equal(want) {
+		t.Errorf("Expected the first retry at %s, got %s", want, delivery.NextAttemptAt)
+	}
+
+	// Nothing is due before the retry
+	if retried, err := f.service.RetryDue(ctx); err != nil || retried != 0 {
+		t.Errorf("Expected nothing due, got %d, %v", retried, err)
+	}
+
+	f.now = f.now.Add(time.Minute)
+	if retried, err := f.service.RetryDue(ctx); err != nil || retried != 1 {
+		t.Fatalf("Expected one retry, got %d, %v", retried, err)
+	}
+	delivery = f.repo.deliveries[0]
+	if want := f.now.Add(2 * time.Minute); delivery.Attempts != 2 || !delivery.NextAttemptAt.Equal(want) {
+		t.Errorf("Expected the second retry doubled to %s, got %+v", want, delivery)
+	}
+
+	f.now = f.now.Add(2 * time.Minute)
+	if _, err := f.service.RetryDue(ctx); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	delivery = f.repo.deliveries[0]
+	if delivery.Status != domain.WebhookDeliveryFailed || delivery.Attempts != 3 || delivery.NextAttemptAt != nil {
+		t.Errorf("Expected the delivery to fail after three attempts, got %+v", delivery)
+	}
+	if endpoint.received() != 3 {
+		t.Errorf("Expected three requests, got %d", endpoint.received())
+	}
+
+	deliveries, _ := f.service.GetDeliveries(ctx, subscription.ID, 0)
+	if len(deliveries) != 1 || *deliveries[0].LastStatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected the log to show the last status, got %+v", deliveries)
+	}
+}
+
+func TestRetryDue_DeliversOnceEndpointRecovers(t *testing.T) {
+	f := newWebhookFixture(5)
+	ctx := context.Background()
+	_, server := newWebhookEndpoint(t, http.StatusServiceUnavailable, http.StatusNoContent)
+
+	f.service.RegisterWebhook(ctx, server.URL, []domain.NotificationType{domain.NotificationTransactionCompleted})
+	f.service.HandleNotification(ctx, []byte(completedEvent))
+
+	f.now = f.now.Add(time.Minute)
+	if _, err := f.service.RetryDue(ctx); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	delivery := f.repo.deliveries[0]
+	if delivery.Status != domain.WebhookDeliveryDelivered || delivery.DeliveredAt == nil || delivery.LastError != "" {
+		t.Errorf("Expected the retry to deliver, got %+v", delivery)
+	}
+}
+
+func TestRetryDue_FailsDeliveriesOfDeletedSubscriptions(t *testing.T) {
+	f := newWebhookFixture(5)
+	ctx := context.Background()
+	endpoint, server := newWebhookEndpoint(t, http.StatusBadGateway)
+
+	subscription, _ := f.service.RegisterWebhook(ctx, server.URL, []domain.NotificationType{domain.NotificationTransactionCompleted})
+	f.service.HandleNotification(ctx, []byte(completedEvent))
+	if err := f.service.DeleteWebhook(ctx, subscription.ID); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	f.now = f.now.Add(time.Minute)
+	if retried, err := f.service.RetryDue(ctx); err != nil || retried != 0 {
+		t.Errorf("Expected no retries, got %d, %v", retried, err)
+	}
+	if delivery := f.repo.deliveries[0]; delivery.Status != domain.WebhookDeliveryFailed {
+		t.Errorf("Expected the delivery to fail, got %+v", delivery)
+	}
+	if endpoint.received() != 1 {
+		t.Errorf("Expected no further requests, got %d", endpoint.received())
+	}
+}
+
+func TestHandleNotification_RejectsMalformedMessages(t *testing.T) {
+	f := newWebhookFixture(3)
+
+	for _, message := range []string{`not json`, `{"type":"transaction.completed"}`} {
+		if err := f.service.HandleNotification(context.Background(), []byte(message)); !domain.IsPermanent(err) {
+			t.Errorf("Expected a permanent error for %s, got %v", message, err)
+		}
+	}
+}
+
+func TestGetDeliveries_UnknownWebhook(t *testing.T) {
+	f := newWebhookFixture(3)
+
+	if _, err := f.service.GetDeliveries(context.Background(), "missing", 0); !errors.Is(err, domain.ErrWebhookNotFound) {
+		t.Errorf("Expected ErrWebhookNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"banking-ledger/internal/domain"
+	"banking-ledger/internal/queue"
+	"banking-ledger/internal/usecase"
+)
+
+// sharedAccountRepository serializes a MockAccountRepository for concurrent
+// workers and hands out copies of accounts as a database would, so that
+// workers racing on an account conflict on its version
+type sharedAccountRepository struct {
+	*MockAccountRepository
+	mu        sync.Mutex
+	conflicts int
+}
+
+func (r *sharedAccountRepository) GetByID(ctx context.Context, id string) (*domain.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, err := r.MockAccountRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (r *sharedAccountRepository) UpdateBalance(ctx context.Context, id string, newBalance domain.Money, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.MockAccountRepository.UpdateBalance(ctx, id, newBalance, version)
+	if err == domain.ErrConcurrentUpdate {
+		r.conflicts++
+	}
+	return err
+}
+
+func (r *sharedAccountRepository) SetBelowThreshold(ctx context.Context, id string, below bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockAccountRepository.SetBelowThreshold(ctx, id, below)
+}
+
+// poolMessageQueue hands the messages of one queue to workers through
+// queue.Serve, putting a message whose handler fails transiently back on the
+// queue as the broker would redeliver it
+type poolMessageQueue struct {
+	queueName string
+	workers   int
+	messages  chan []byte
+	redeliver atomic.Int32
+	acked     atomic.Int32
+	released  atomic.Int32
+}
+
+func (q *poolMessageQueue) Publish(ctx context.Context, queueName string, message []byte) error {
+	if queueName == q.queueName {
+		q.messages <- message
+	}
+	return nil
+}
+
+func (q *poolMessageQueue) Subscribe(ctx context.Context, queueName string, handler func(context.Context, []byte) error) (domain.Subscription, error) {
+	if queueName != q.queueName {
+		return mockSubscription{}, nil
+	}
+	return queue.Serve(ctx, q.messages, q.workers,
+		func(ctx context.Context, message []byte) {
+			if err := handler(ctx, message); err != nil && !domain.IsPermanent(err) {
+				q.redeliver.Add(1)
+				q.messages <- message
+				return
+			}
+			q.acked.Add(1)
+		},
+		func() { close(q.messages) },
+		func([]byte) { q.released.Add(1) },
+	), nil
+}
+
+func (q *poolMessageQueue) Close() error {
+	return nil
+}
+
+func TestTransactionProcessor_WorkerPool(t *testing.T) {
+	const (
+		messages = 1000
+		workers  = 8
+	)
+
+	accountRepo := &sharedAccountRepository{MockAccountRepository: NewMockAccountRepository()}
+	transactionRepo := NewMockTransactionRepository()
+	accountIDs := []string{"alice", "bob", "carol", "dave"}
+	expected := make(map[string]domain.Money)
+	for _, id := range accountIDs {
+		accountRepo.accounts[id] = &domain.Account{ID: id, UserID: id, Balance: 100000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+		expected[id] = 100000
+	}
+
+	transactionQueue := &poolMessageQueue{queueName: "transactions", workers: workers, messages: make(chan []byte, 2*messages)}
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, transactionQueue, "transactions").(*usecase.TransactionUseCase)
+
+	// Deposits and withdrawals spread over a few accounts, so that workers
+	// keep racing on the same ones
+	for i := 0; i < messages; i++ {
+		accountID := accountIDs[i%len(accountIDs)]
+		request := domain.TransactionRequest{
+			ID:       fmt.Sprintf("tx-%04d", i),
+			Type:     domain.TransactionTypeDeposit,
+			Amount:   100 + domain.Money(i%7),
+			Currency: "USD",
+		}
+		if i%3 == 0 {
+			request.Type = domain.TransactionTypeWithdrawal
+			request.FromAccountID = &accountID
+			expected[accountID] -= request.Amount
+		} else {
+			request.ToAccountID = &accountID
+			expected[accountID] += request.Amount
+		}
+		transactionRepo.transactions[request.ID] = &domain.Transaction{
+			ID:            request.ID,
+			Type:          request.Type,
+			FromAccountID: request.FromAccountID,
+			ToAccountID:   request.ToAccountID,
+			Amount:        request.Amount,
+			Currency:      request.Currency,
+			Status:        domain.TransactionStatusPending,
+			CreatedAt:     time.Now(),
+		}
+		message, err := json.Marshal(request)
+		if err != nil {
+			t.Fatalf("Failed to encode message: %v", err)
+		}
+		if err := transactionQueue.Publish(context.Background(), "transactions", message); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	subscription, err := transactionUseCase.StartTransactionProcessor(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start processor: %v", err)
+	}
+
+	completed := func() int {
+		transactionRepo.mu.Lock()
+		defer transactionRepo.mu.Unlock()
+		count := 0
+		for _, transaction := range transactionRepo.transactions {
+			if transaction.Status == domain.TransactionStatusCompleted {
+				count++
+			}
+		}
+		return count
+	}
+	for deadline := time.Now().Add(30 * time.Second); completed() < messages; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out with %d of %d transactions completed", completed(), messages)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelDrain()
+	if _, err := subscription.Drain(drainCtx); err != nil {
+		t.Fatalf("Failed to drain the workers: %v", err)
+	}
+
+	for _, id := range accountIDs {
+		account, _ := accountRepo.GetByID(context.Background(), id)
+		if account.Balance != expected[id] {
+			t.Errorf("Expected %s's balance %d, got %d", id, expected[id], account.Balance)
+		}
+	}
+	t.Logf("Processed %d messages on %d workers with %d version conflicts and %d redeliveries", messages, workers, accountRepo.conflicts, transactionQueue.redeliver.Load())
+}
+
+// slowAccountRepository holds the first balance update until released, so
+// that the processor can be shut down while the update is in flight
+type slowAccountRepository struct {
+	*MockAccountRepository
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+	ctxErr  error
+}
+
+func (r *slowAccountRepository) UpdateBalance(ctx context.Context, id string, newBalance domain.Money, version int64) error {
+	r.once.Do(func() {
+		close(r.entered)
+		<-r.release
+		r.ctxErr = ctx.Err()
+	})
+	return r.MockAccountRepository.UpdateBalance(ctx, id, newBalance, version)
+}
+
+func TestTransactionProcessor_GracefulShutdown(t *testing.T) {
+	accountRepo := &slowAccountRepository{MockAccountRepository: NewMockAccountRepository(), entered: make(chan struct{}), release: make(chan struct{})}
+	transactionRepo := NewMockTransactionRepository()
+	accountRepo.accounts["alice"] = &domain.Account{ID: "alice", UserID: "alice", Balance: 10000, Currency: "USD", Status: domain.AccountStatusActive, Version: 1}
+
+	transactionQueue := &poolMessageQueue{queueName: "transactions", workers: 1, messages: make(chan []byte, 10)}
+	transactionUseCase := usecase.NewTransactionUseCase(accountRepo, transactionRepo, transactionQueue, "transactions").(*usecase.TransactionUseCase)
+
+	alice := "alice"
+	for i := 0; i < 3; i++ {
+		request := domain.TransactionRequest{ID: fmt.Sprintf("tx-%d", i), Type: domain.TransactionTypeDeposit, ToAccountID: &alice, Amount: 500, Currency: "USD"}
+		transactionRepo.transactions[request.ID] = &domain.Transaction{
+			ID:          request.ID,
+			Type:        request.Type,
+			ToAccountID: request.ToAccountID,
+			Amount:      request.Amount,
+			Currency:    request.Currency,
+			Status:      domain.TransactionStatusPending,
+			CreatedAt:   time.Now(),
+		}
+		message, _ := json.Marshal(request)
+		transactionQueue.messages <- message
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	subscription, err := transactionUseCase.StartTransactionProcessor(ctx)
+	if err != nil {
+		t.Fatalf("Failed to start processor: %v", err)
+	}
+
+	// Shut down while the first deposit is part way through
+	select {
+	case <-accountRepo.entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the first deposit")
+	}
+	cancel()
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelDrain()
+	type drainResult struct {
+		drained int
+		err     error
+	}
+	result := make(chan drainResult, 1)
+	go func() {
+		drained, err := subscription.Drain(drainCtx)
+		result <- drainResult{drained, err}
+	}()
+
+	select {
+	case <-result:
+		t.Fatal("Expected the drain to wait for the deposit in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(accountRepo.release)
+
+	drain := <-result
+	if drain.err != nil || drain.drained != 1 {
+		t.Fatalf("Expected one message drained, got %d %v", drain.drained, drain.err)
+	}
+	if accountRepo.ctxErr != nil {
+		t.Errorf("Expected the deposit to finish with its context intact, got %v", accountRepo.ctxErr)
+	}
+	if acked, redelivered, released := transactionQueue.acked.Load(), transactionQueue.redeliver.Load(), transactionQueue.released.Load(); acked != 1 || redelivered != 0 || released != 2 {
+		t.Errorf("Expected the deposit acked once and the other two returned, got %d acked, %d redelivered, %d returned", acked, redelivered, released)
+	}
+
+	if status := transactionRepo.transactions["tx-0"].Status; status != domain.TransactionStatusCompleted {
+		t.Errorf("Expected the drained deposit completed, got %s", status)
+	}
+	for _, id := range []string{"tx-1", "tx-2"} {
+		if status := transactionRepo.transactions[id].Status; status != domain.TransactionStatusPending {
+			t.Errorf("Expected %s left pending, got %s", id, status)
+		}
+	}
+	if balance := accountRepo.accounts["alice"].Balance; balance != 10500 {
+		t.Errorf("Expected one deposit applied, got a balance of %d", balance)
+	}
+}